@@ -0,0 +1,130 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	cpcbasic "github.com/jtruco/emu8/emulator/machine/cpc/basic"
+	cpcformat "github.com/jtruco/emu8/emulator/machine/cpc/format"
+	"github.com/jtruco/emu8/emulator/machine/spectrum/basic"
+	"github.com/jtruco/emu8/emulator/machine/spectrum/format"
+)
+
+// -----------------------------------------------------------------------------
+// BASIC commands
+// -----------------------------------------------------------------------------
+
+var basicListCommand = &command{
+	name: "basic list",
+	args: "<file.sna|z80|szx>",
+	help: "List the BASIC program of a snapshot",
+	run:  basicList,
+}
+
+var basicEnterCommand = &command{
+	name: "basic enter",
+	args: "<input.sna|z80|szx> <listing.bas> <output.sna|z80|szx>",
+	help: "Enter a BASIC listing into a snapshot",
+	run:  basicEnter,
+}
+
+// basicSnapshot is a snapshot with a BASIC program in memory
+type basicSnapshot struct {
+	list  func() string              // Lists the program
+	enter func(listing string) error // Enters a program listing
+	save  func(ext string) []byte    // Saves the snapshot
+}
+
+// loadBasicSnapshot loads a ZX Spectrum or Amstrad CPC snapshot
+func loadBasicSnapshot(filename string) (*basicSnapshot, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	if fileExt(filename) == cpcformat.SNA && bytes.HasPrefix(data, cpcSnaID) {
+		snap := cpcformat.LoadSNA(data)
+		if snap == nil {
+			return nil, fmt.Errorf("invalid snapshot file: %s", filename)
+		}
+		return &basicSnapshot{
+			list: func() string {
+				return cpcbasic.Detokenize(cpcbasic.ReadProgram(snap), cpcbasic.ProgramStart)
+			},
+			enter: func(listing string) error {
+				program, err := cpcbasic.Tokenize(listing)
+				if err != nil {
+					return err
+				}
+				return cpcbasic.WriteProgram(snap, program)
+			},
+			save: func(ext string) []byte {
+				if ext == cpcformat.SNA {
+					return snap.SaveSNA()
+				}
+				return nil
+			},
+		}, nil
+	}
+	snap, err := loadSnapshot(filename, data)
+	if err != nil {
+		return nil, err
+	}
+	return &basicSnapshot{
+		list: func() string {
+			return basic.Detokenize(basic.ReadProgram(snap))
+		},
+		enter: func(listing string) error {
+			program, err := basic.Tokenize(listing)
+			if err != nil {
+				return err
+			}
+			return basic.WriteProgram(snap, program)
+		},
+		save: func(ext string) []byte {
+			switch ext {
+			case format.SNA:
+				return snap.SaveSNA()
+			case format.Z80:
+				return snap.SaveZ80()
+			case format.SZX:
+				return snap.SaveSZX()
+			}
+			return nil
+		},
+	}, nil
+}
+
+func basicList(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	snap, err := loadBasicSnapshot(args[0])
+	if err != nil {
+		return err
+	}
+	fmt.Print(snap.list())
+	return nil
+}
+
+func basicEnter(args []string) error {
+	if len(args) != 3 {
+		return errUsage
+	}
+	snap, err := loadBasicSnapshot(args[0])
+	if err != nil {
+		return err
+	}
+	listing, err := ioutil.ReadFile(args[1])
+	if err != nil {
+		return err
+	}
+	if err := snap.enter(string(listing)); err != nil {
+		return fmt.Errorf("%s: %s", args[1], err.Error())
+	}
+	data := snap.save(fileExt(args[2]))
+	if data == nil {
+		return fmt.Errorf("unsupported output format: %s", args[2])
+	}
+	return writeFile(args[2], data)
+}
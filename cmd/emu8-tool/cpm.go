@@ -0,0 +1,123 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator"
+	"github.com/jtruco/emu8/emulator/controller/vfs"
+	"github.com/jtruco/emu8/emulator/machine"
+	"github.com/jtruco/emu8/emulator/machine/cpm"
+)
+
+// -----------------------------------------------------------------------------
+// CP/M command
+// -----------------------------------------------------------------------------
+
+var cpmOptions struct {
+	i8080   bool   // Intel 8080 CPU
+	dir     string // Drive A: directory
+	verbose bool   // Show the emulator log
+}
+
+var cpmCommand = &command{
+	name: "cpm",
+	args: "[-8080] [-dir path] [-v] <file.com> [arguments]",
+	help: "Run a CP/M program on the console",
+	flags: func(flags *flag.FlagSet) {
+		flags.BoolVar(&cpmOptions.i8080, "8080", false, "Intel 8080 CPU")
+		flags.StringVar(&cpmOptions.dir, "dir", ".", "Drive A: directory")
+		flags.BoolVar(&cpmOptions.verbose, "v", false, "Show the emulator log")
+	},
+	run: cpmRun,
+}
+
+func cpmRun(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	program, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	if !cpmOptions.verbose {
+		log.SetOutput(ioutil.Discard)
+	}
+
+	// drive A: host files
+	cwd, _ := os.Getwd()
+	fs := vfs.NewDesktopFileSystem(cwd)
+	fs.SetPath(vfs.FormatDrive, cpmOptions.dir)
+	vfs.SetFileSystem(fs)
+
+	model := "CPM"
+	if cpmOptions.i8080 {
+		model = "CPM8080"
+	}
+	emu, err := emulator.FromModel(model)
+	if err != nil {
+		return err
+	}
+	computer := emu.Machine().(*cpm.CPM)
+	output := bufio.NewWriter(os.Stdout)
+	defer output.Flush()
+	console := computer.Console()
+	console.Output = output
+	if info, err := os.Stdin.Stat(); err == nil {
+		console.Echo = info.Mode()&os.ModeCharDevice == 0 // terminal echoes itself
+	}
+
+	emu.Init()
+	computer.SetCommandLine(strings.Join(args[1:], " "))
+	computer.LoadState(machine.State{Format: cpm.COM, Data: program})
+	emu.Start()
+	defer emu.Stop()
+
+	input := cpmInput()
+	for !computer.Exited() {
+		if computer.Waiting() {
+			output.Flush()
+			data, ok := <-input
+			if !ok {
+				break // end of input
+			}
+			console.Input(data)
+		} else {
+			select {
+			case data, ok := <-input:
+				if ok {
+					console.Input(data)
+				}
+			default:
+			}
+		}
+		emu.Emulate()
+		output.Flush()
+	}
+	return nil
+}
+
+// cpmInput reads the standard input in background
+func cpmInput() <-chan []byte {
+	input := make(chan []byte)
+	go func() {
+		defer close(input)
+		buffer := make([]byte, 0x100)
+		for {
+			n, err := os.Stdin.Read(buffer)
+			if n > 0 {
+				data := make([]byte, n)
+				copy(data, buffer)
+				input <- data
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return input
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	cpcformat "github.com/jtruco/emu8/emulator/machine/cpc/format"
+)
+
+// -----------------------------------------------------------------------------
+// Disk commands
+// -----------------------------------------------------------------------------
+
+var diskOptions struct {
+	format string // Disk format
+}
+
+var diskNewCommand = &command{
+	name: "disk new",
+	args: "[-format data|system|ibm] <file.dsk>",
+	help: "Create a blank formatted CPC DSK disk",
+	flags: func(flags *flag.FlagSet) {
+		flags.StringVar(&diskOptions.format, "format", cpcformat.DiskData, "Disk format : data, system or ibm")
+	},
+	run: diskNew,
+}
+
+func diskNew(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	if fileExt(args[0]) != cpcformat.DSK {
+		return fmt.Errorf("unsupported disk format: %s", args[0])
+	}
+	image := cpcformat.NewDSK(diskOptions.format)
+	if image == nil {
+		return fmt.Errorf("unknown disk format: %s", diskOptions.format)
+	}
+	return writeFile(args[0], cpcformat.SaveDSK(image))
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator/device/io/tape"
+	cpcbasic "github.com/jtruco/emu8/emulator/machine/cpc/basic"
+	cpcformat "github.com/jtruco/emu8/emulator/machine/cpc/format"
+	"github.com/jtruco/emu8/emulator/machine/spectrum/basic"
+	"github.com/jtruco/emu8/emulator/machine/spectrum/format"
+)
+
+// -----------------------------------------------------------------------------
+// Extract command
+// -----------------------------------------------------------------------------
+
+var extractOptions struct {
+	dir string // Output directory
+	raw bool   // Extract BASIC programs as binary
+}
+
+var extractCommand = &command{
+	name: "extract",
+	args: "[-dir path] [-raw] <file.tap|tzx|cdt>",
+	help: "Extract CODE and BASIC files from a tape",
+	flags: func(flags *flag.FlagSet) {
+		flags.StringVar(&extractOptions.dir, "dir", ".", "Output directory")
+		flags.BoolVar(&extractOptions.raw, "raw", false, "Extract BASIC programs as binary")
+	},
+	run: extract,
+}
+
+func extract(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	t, err := loadTape(args[0])
+	if err != nil {
+		return err
+	}
+	if fileExt(args[0]) == cpcformat.CDT {
+		return extractCpc(t)
+	}
+	return extractSpectrum(t)
+}
+
+// loadData returns the ROM loader data of tape block
+func loadData(block tape.Block) []byte {
+	if dataBlock, ok := block.(tape.DataBlock); ok {
+		return dataBlock.LoadData()
+	}
+	return nil
+}
+
+// extractFile returns the output file name of an extracted file
+func extractFile(index int, name, ext string) string {
+	clean := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(name))
+	return filepath.Join(extractOptions.dir, fmt.Sprintf("%03d-%s.%s", index, clean, ext))
+}
+
+// extractSpectrum extracts ZX Spectrum files (header and data blocks)
+func extractSpectrum(t tape.Tape) error {
+	var header *tape.Header
+	for _, block := range t.Blocks() {
+		data := loadData(block)
+		if len(data) < 2 {
+			continue
+		}
+		if meta := block.Meta(); meta.Header != nil {
+			header = meta.Header
+			continue
+		}
+		if header == nil {
+			continue // headerless block
+		}
+		index := block.Info().Index
+		data = data[1 : len(data)-1] // remove flag & checksum
+		var err error
+		switch header.Type {
+		case format.TapeFileProgram:
+			if extractOptions.raw {
+				err = writeFile(extractFile(index, header.Name, "bin"), data)
+			} else {
+				listing := basic.Detokenize(data)
+				err = writeFile(extractFile(index, header.Name, "bas"), []byte(listing))
+			}
+		case format.TapeFileCode:
+			err = writeFile(extractFile(index, header.Name, "bin"), data)
+		default:
+			fmt.Printf("Skipped %s: %s\n", header.TypeName, strings.TrimSpace(header.Name))
+		}
+		if err != nil {
+			return err
+		}
+		header = nil
+	}
+	return nil
+}
+
+// extractCpc extracts Amstrad CPC files (header and data records)
+func extractCpc(t tape.Tape) error {
+	var header, first *cpcformat.CdtHeader
+	var file []byte
+	index := 0
+	for _, block := range t.Blocks() {
+		data := loadData(block)
+		if len(data) == 0 {
+			continue
+		}
+		if data[0] == cpcformat.CdtSyncHeader {
+			header = cpcformat.ReadHeader(data)
+			if header != nil && (header.First || first == nil) {
+				first = header
+				file = file[:0]
+				index = block.Info().Index
+			}
+			continue
+		}
+		if header == nil || data[0] != cpcformat.CdtSyncData {
+			continue
+		}
+		file = append(file, cpcformat.ReadRecord(data, header.Length)...)
+		if header.Last {
+			ext := "bin"
+			switch {
+			case first.Type == cpcformat.CdtFileASCII:
+				ext = "txt"
+			case first.Type == cpcformat.CdtFileBasic && !first.Protected && !extractOptions.raw:
+				ext = "bas"
+				file = []byte(cpcbasic.Detokenize(file, cpcbasic.ProgramStart))
+			}
+			if err := writeFile(extractFile(index, first.Name, ext), file); err != nil {
+				return err
+			}
+			first = nil
+		}
+		header = nil
+	}
+	return nil
+}
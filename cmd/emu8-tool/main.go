@@ -0,0 +1,99 @@
+// package main contains the emu8 tape & snapshot tool
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// command is a tool subcommand
+type command struct {
+	name  string                    // Command name
+	args  string                    // Arguments usage
+	help  string                    // Command description
+	flags func(*flag.FlagSet)       // Command flags
+	run   func(args []string) error // Command function
+}
+
+// tool commands
+var commands = []*command{
+	tapeListCommand,
+	tapeConvertCommand,
+	snapInfoCommand,
+	snapConvertCommand,
+	extractCommand,
+	basicListCommand,
+	basicEnterCommand,
+	romListCommand,
+	romCheckCommand,
+	cpmCommand,
+	diskNewCommand,
+}
+
+// errUsage is returned on wrong command arguments
+var errUsage = errors.New("wrong arguments")
+
+// main program
+func main() {
+	log.SetFlags(0)
+	args := os.Args[1:]
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+	for _, cmd := range commands {
+		names := strings.Fields(cmd.name)
+		if len(args) < len(names) || strings.Join(args[:len(names)], " ") != cmd.name {
+			continue
+		}
+		flags := flag.NewFlagSet(cmd.name, flag.ExitOnError)
+		if cmd.flags != nil {
+			cmd.flags(flags)
+		}
+		flags.Usage = func() {
+			fmt.Fprintf(flags.Output(), "Usage: emu8-tool %s %s\n", cmd.name, cmd.args)
+			flags.PrintDefaults()
+		}
+		flags.Parse(args[len(names):])
+		if err := cmd.run(flags.Args()); err != nil {
+			if err == errUsage {
+				flags.Usage()
+				os.Exit(2)
+			}
+			fmt.Fprintln(os.Stderr, "emu8-tool :", err)
+			os.Exit(1)
+		}
+		return
+	}
+	usage()
+	os.Exit(2)
+}
+
+// usage prints the tool usage
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: emu8-tool <command> [flags] [arguments]")
+	fmt.Fprintln(os.Stderr, "Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-14s %s\n", cmd.name, cmd.help)
+	}
+}
+
+// fileExt returns the lowercase file extension without dot
+func fileExt(filename string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))
+}
+
+// writeFile writes data to file
+func writeFile(filename string, data []byte) error {
+	if err := ioutil.WriteFile(filename, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("%s : %d bytes\n", filename, len(data))
+	return nil
+}
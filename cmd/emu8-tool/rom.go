@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"strings"
+
+	_ "github.com/jtruco/emu8/emulator" // machine models
+	"github.com/jtruco/emu8/emulator/machine"
+)
+
+// -----------------------------------------------------------------------------
+// ROM commands
+// -----------------------------------------------------------------------------
+
+var romListCommand = &command{
+	name: "rom list",
+	args: "[model]",
+	help: "List the ROM slots and known ROM images of machine models",
+	run:  romList,
+}
+
+var romCheckCommand = &command{
+	name: "rom check",
+	args: "<file.rom> ...",
+	help: "Identify ROM images by their checksums",
+	run:  romCheck,
+}
+
+func romList(args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+	for _, model := range machine.Models() {
+		if len(args) == 1 && machine.FindModel(args[0]) != model {
+			continue
+		}
+		set := model.Roms
+		if set == nil {
+			continue
+		}
+		fmt.Printf("%s (%s)\n", model.Name, strings.Join(model.Ids, ", "))
+		for _, slot := range set.Slots {
+			image := slot.Default
+			if image == "" {
+				image = "(empty)"
+			}
+			fmt.Printf("  slot %-8s %5d bytes  %s\n", slot.Name, slot.Size, image)
+		}
+		for _, image := range set.Images {
+			sha := ""
+			if image.SHA1 != "" {
+				sha = " sha1:" + image.SHA1
+			}
+			fmt.Printf("  image %-14s %-18s crc32:%08x%s\n", image.ID, image.File, image.CRC32, sha)
+		}
+	}
+	return nil
+}
+
+func romCheck(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	for _, filename := range args {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return err
+		}
+		known := identifyRom(data)
+		if known == "" {
+			known = "unknown image"
+		}
+		fmt.Printf("%s : %d bytes, crc32:%08x, %s\n", filename, len(data), crc32.ChecksumIEEE(data), known)
+	}
+	return nil
+}
+
+// identifyRom identifies ROM data in all the model ROM sets. Images shared
+// by several models list all of them.
+func identifyRom(data []byte) string {
+	ids := make([]string, 0)
+	models := make(map[string][]string)
+	for _, model := range machine.Models() {
+		if model.Roms == nil {
+			continue
+		}
+		if image := model.Roms.Identify(data); image != nil {
+			if _, ok := models[image.ID]; !ok {
+				ids = append(ids, image.ID)
+			}
+			models[image.ID] = append(models[image.ID], model.Name)
+		}
+	}
+	known := make([]string, 0, len(ids))
+	for _, id := range ids {
+		known = append(known, fmt.Sprintf("%s (%s)", id, strings.Join(models[id], ", ")))
+	}
+	return strings.Join(known, ", ")
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+
+	"github.com/jtruco/emu8/emulator/device/cpu/z80"
+	cpcformat "github.com/jtruco/emu8/emulator/machine/cpc/format"
+	"github.com/jtruco/emu8/emulator/machine/spectrum/format"
+)
+
+// -----------------------------------------------------------------------------
+// Snapshot commands
+// -----------------------------------------------------------------------------
+
+var snapInfoCommand = &command{
+	name: "snap info",
+	args: "<file.sna|z80|szx>",
+	help: "Print snapshot registers and hardware state",
+	run:  snapInfo,
+}
+
+var snapConvertCommand = &command{
+	name: "snap convert",
+	args: "<input.sna|z80|szx> <output.sna|z80|szx>",
+	help: "Convert ZX Spectrum snapshots between SNA, Z80 and SZX",
+	run:  snapConvert,
+}
+
+// cpcSnaID CPC SNA file identifier
+var cpcSnaID = []byte("MV - SNA")
+
+// loadSnapshot loads a ZX Spectrum snapshot file
+func loadSnapshot(filename string, data []byte) (*format.Snapshot, error) {
+	var snap *format.Snapshot
+	switch fileExt(filename) {
+	case format.SNA:
+		snap = format.LoadSNA(data)
+	case format.Z80:
+		snap = format.LoadZ80(data)
+	case format.SZX:
+		snap = format.LoadSZX(data)
+	default:
+		return nil, fmt.Errorf("unsupported snapshot format: %s", filename)
+	}
+	if snap == nil {
+		return nil, fmt.Errorf("invalid snapshot file: %s", filename)
+	}
+	return snap, nil
+}
+
+func snapInfo(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	data, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	if fileExt(args[0]) == cpcformat.SNA && bytes.HasPrefix(data, cpcSnaID) {
+		snap := cpcformat.LoadSNA(data)
+		if snap == nil {
+			return fmt.Errorf("invalid snapshot file: %s", args[0])
+		}
+		fmt.Printf("%s : Amstrad CPC snapshot\n", args[0])
+		printRegisters(&snap.State)
+		fmt.Printf("GA    pen %d mode %d ink %v\n", snap.GaSelectedPen, snap.GaMultiConfig&0x03, snap.GaPenColours)
+		fmt.Printf("CRTC  reg %d %v\n", snap.CrtcSelected, snap.CrtcRegisters)
+		fmt.Printf("PPI   A %02x B %02x C %02x control %02x\n", snap.PpiPortA, snap.PpiPortB, snap.PpiPortC, snap.PpiControl)
+		fmt.Printf("ROM   upper %d\n", snap.RomSelect)
+		return nil
+	}
+	snap, err := loadSnapshot(args[0], data)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s : ZX Spectrum 48k snapshot\n", args[0])
+	printRegisters(&snap.State)
+	fmt.Printf("Border %d  Tstates %d\n", snap.Border, snap.Tstates)
+	return nil
+}
+
+// printRegisters prints the Z80 registers
+func printRegisters(state *z80.State) {
+	fmt.Printf("AF %02x%02x  BC %02x%02x  DE %02x%02x  HL %02x%02x\n",
+		state.A, state.F, state.B, state.C, state.D, state.E, state.H, state.L)
+	fmt.Printf("AF'%02x%02x  BC'%02x%02x  DE'%02x%02x  HL'%02x%02x\n",
+		state.Ax, state.Fx, state.Bx, state.Cx, state.Dx, state.Ex, state.Hx, state.Lx)
+	fmt.Printf("IX %02x%02x  IY %02x%02x  SP %04x  PC %04x\n",
+		state.IXh, state.IXl, state.IYh, state.IYl, state.SP, state.PC)
+	fmt.Printf("I  %02x    R  %02x    IM %d     IFF1 %t IFF2 %t\n",
+		state.I, state.R, state.IM, state.IFF1, state.IFF2)
+}
+
+func snapConvert(args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	data, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	snap, err := loadSnapshot(args[0], data)
+	if err != nil {
+		return err
+	}
+	switch fileExt(args[1]) {
+	case format.SNA:
+		data = snap.SaveSNA()
+	case format.Z80:
+		data = snap.SaveZ80()
+	case format.SZX:
+		data = snap.SaveSZX()
+	default:
+		return fmt.Errorf("unsupported output format: %s", args[1])
+	}
+	return writeFile(args[1], data)
+}
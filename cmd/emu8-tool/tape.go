@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator/device/io/tape"
+	cpcformat "github.com/jtruco/emu8/emulator/machine/cpc/format"
+	"github.com/jtruco/emu8/emulator/machine/spectrum/format"
+)
+
+// -----------------------------------------------------------------------------
+// Tape commands
+// -----------------------------------------------------------------------------
+
+var tapeListCommand = &command{
+	name: "tape list",
+	args: "<file.tap|tzx|cdt>",
+	help: "List tape blocks and headers",
+	run:  tapeList,
+}
+
+var wavRate int
+
+var tapeConvertCommand = &command{
+	name: "tape convert",
+	args: "[-rate n] <input.tap|tzx|cdt> <output.tap|tzx|cdt|wav>",
+	help: "Convert tapes between TAP, TZX and WAV",
+	flags: func(flags *flag.FlagSet) {
+		flags.IntVar(&wavRate, "rate", format.WavDefaultRate, "WAV sample rate")
+	},
+	run: tapeConvert,
+}
+
+// tapeBuilders tape formats by extension
+var tapeBuilders = map[string]tape.Builder{
+	format.TAP:    format.NewTap,
+	format.TZX:    format.NewTzx,
+	cpcformat.CDT: cpcformat.NewCdt,
+}
+
+// loadTape loads a tape file
+func loadTape(filename string) (tape.Tape, error) {
+	builder, ok := tapeBuilders[fileExt(filename)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported tape format: %s", filename)
+	}
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	t := builder()
+	if !t.Load(data) {
+		return nil, fmt.Errorf("invalid tape file: %s", filename)
+	}
+	t.Info().Name = filename
+	return t, nil
+}
+
+func tapeList(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	t, err := loadTape(args[0])
+	if err != nil {
+		return err
+	}
+	blocks := t.Blocks()
+	fmt.Printf("%s : %d blocks\n", args[0], len(blocks))
+	for _, block := range blocks {
+		info := block.Info()
+		meta := block.Meta()
+		fmt.Printf("%4d  0x%02x  %6d  %s\n", info.Index, info.Type, info.Length, meta.Description)
+		if header := meta.Header; header != nil {
+			fmt.Printf("%18s%s: \"%s\" length %d (%d, %d)\n", "",
+				header.TypeName, strings.TrimSpace(header.Name), header.Length, header.Param1, header.Param2)
+		}
+		if timings := meta.Timings; timings != nil {
+			fmt.Printf("%18spilot %dx%d sync %d,%d bits %d,%d pause %d ms\n", "",
+				timings.PilotPulses, timings.Pilot, timings.Sync1, timings.Sync2,
+				timings.Zero, timings.One, timings.Pause)
+		}
+		for _, text := range meta.Text {
+			fmt.Printf("%18s%s\n", "", text)
+		}
+	}
+	return nil
+}
+
+func tapeConvert(args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	t, err := loadTape(args[0])
+	if err != nil {
+		return err
+	}
+	var data []byte
+	switch fileExt(args[1]) {
+	case format.TAP:
+		data = format.SaveTAP(t)
+	case format.TZX:
+		data = format.SaveTZX(t)
+	case cpcformat.CDT:
+		data = cpcformat.SaveCDT(t)
+	case format.WAV:
+		data = format.SaveWAV(t, wavRate)
+	default:
+		return fmt.Errorf("unsupported output format: %s", args[1])
+	}
+	return writeFile(args[1], data)
+}
@@ -2,23 +2,55 @@ package main
 
 import (
 	"flag"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/jtruco/emu8/emulator/config"
 	"github.com/jtruco/emu8/emulator/controller/vfs"
+	"github.com/jtruco/emu8/emulator/machine"
 )
 
+// configFileName is the default configuration file name
+const configFileName = "emu8.ini"
+
 func init() {
 	conf := config.Get()
 
 	// parse config parameters
+	configFile := flag.String("config", defaultConfigFile(), "Configuration file")
+	saveConfig := flag.Bool("saveconfig", false, "Save the current settings as the configuration file defaults")
 	flag.StringVar(&conf.App.File, "file", "", "Load file")
+	flag.StringVar(&conf.App.Paste, "paste", config.DefaultAppPaste, "Text file to paste into the keyboard")
 	flag.BoolVar(&conf.Emulator.Async, "async", config.DefaultEmulatorAsync, "Asynchronous emulation")
 	flag.StringVar(&conf.Machine.Model, "model", config.DefaultMachineModel, "Machine model")
-	flag.StringVar(&conf.Machine.Options, "options", "", "Machine options")
+	options := flag.String("options", config.DefaultMachineOptions, "Machine options")
 	flag.IntVar(&conf.Video.Scale, "scale", config.DefaultVideoScale, "Video scale (1..3)")
+	flag.StringVar(&conf.Video.Filter, "filter", config.DefaultVideoFilter, "Video scaling filter (nearest, linear)")
 	flag.BoolVar(&conf.Video.FullScreen, "fullscreen", config.DefaultVideoFullScreen, "Video in full screen mode")
 	flag.BoolVar(&conf.Audio.Mute, "mute", config.DefaultAudioMute, "Audio Mute")
+	flag.BoolVar(&conf.Tape.FastLoad, "fastload", config.DefaultTapeFastLoad, "Tape fast loading")
+	flag.BoolVar(&conf.Tape.AutoStart, "autostart", config.DefaultTapeAutoStart, "Tape automatic start & stop")
+	flag.BoolVar(&conf.Tape.Turbo, "turbo", config.DefaultTapeTurbo, "Turbo emulation while tape is playing")
+	flag.StringVar(&conf.Input.Mapping, "mapping", config.DefaultInputMapping, "Input mapping profiles file")
+	flag.StringVar(&conf.Input.Profile, "profile", config.DefaultInputProfile, "Input mapping profile")
 	flag.Parse()
+	conf.Machine.Options = *options
+
+	// configuration file, command line flags take precedence
+	file := loadConfig(*configFile)
+	if file != nil {
+		applyConfig(file, conf, config.GlobalSection)
+		flag.Parse()
+		conf.Machine.Merge(*options)
+		for _, name := range modelSections(file, conf.Machine.Model) {
+			applyConfig(file, conf, name)
+		}
+		flag.Parse()
+		conf.Machine.Merge(*options)
+	}
 	if len(flag.Args()) > 0 {
 		conf.App.File = flag.Args()[0]
 	}
@@ -27,7 +59,87 @@ func init() {
 	if conf.Video.Scale < 1 || conf.Video.Scale > 3 {
 		conf.Video.Scale = config.DefaultVideoScale
 	}
+	if conf.Video.Filter != "nearest" && conf.Video.Filter != "linear" {
+		conf.Video.Filter = config.DefaultVideoFilter
+	}
+
+	// save configuration
+	if *saveConfig {
+		if file == nil {
+			file = config.NewFile()
+		}
+		file.Store(conf, config.GlobalSection)
+		saveConfigFile(*configFile, file)
+	}
 
 	// init desktop vfs
 	vfs.InitDesktop()
 }
+
+// defaultConfigFile returns the configuration file of the user config dir
+func defaultConfigFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return configFileName
+	}
+	return filepath.Join(dir, "emu8", configFileName)
+}
+
+// loadConfig loads the configuration file, if exists
+func loadConfig(filename string) *config.File {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Println("App : Error reading configuration:", err.Error())
+		}
+		return nil
+	}
+	file, err := config.ParseFile(string(data))
+	if err != nil {
+		log.Println("App : Invalid configuration:", filename, err.Error())
+		return nil
+	}
+	log.Println("App : Configuration loaded:", filename)
+	return file
+}
+
+// applyConfig applies a configuration file section
+func applyConfig(file *config.File, conf *config.Config, name string) {
+	if err := file.Apply(conf, name); err != nil {
+		log.Println("App : Invalid configuration:", err.Error())
+	}
+}
+
+// modelSections returns the file sections of a machine model, matching its
+// name or any of its IDs
+func modelSections(file *config.File, id string) []string {
+	model := machine.FindModel(id)
+	if model == nil {
+		return nil
+	}
+	ids := append([]string{model.Name}, model.Ids...)
+	names := make([]string, 0)
+	for _, name := range file.Sections() {
+		for _, id := range ids {
+			if name != config.GlobalSection && strings.EqualFold(name, id) {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names
+}
+
+// saveConfigFile saves the configuration file
+func saveConfigFile(filename string, file *config.File) {
+	const dirMode, fileMode = 0775, 0664
+	if err := os.MkdirAll(filepath.Dir(filename), dirMode); err != nil {
+		log.Println("App : Error saving configuration:", err.Error())
+		return
+	}
+	if err := ioutil.WriteFile(filename, []byte(file.String()), fileMode); err != nil {
+		log.Println("App : Error saving configuration:", err.Error())
+		return
+	}
+	log.Println("App : Configuration saved:", filename)
+}
@@ -8,12 +8,14 @@ import (
 	"github.com/jtruco/emu8/emulator"
 	"github.com/jtruco/emu8/emulator/config"
 	"github.com/jtruco/emu8/emulator/controller"
+	"github.com/jtruco/emu8/emulator/device/io/mouse"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
 // app constants
 const (
-	loopSleepMillis = 10 // SDL poll interval
+	loopSleepMillis = 10  // SDL poll interval
+	pasteBootFrames = 150 // Frames to wait for the machine boot before pasting
 )
 
 // App is the SDL application
@@ -25,6 +27,10 @@ type App struct {
 	emulator *emulator.Emulator
 	control  *controller.Controller
 	running  bool
+	title    string
+	captured bool // Mouse is captured
+	mouseX   int  // Mouse X motion remainder
+	mouseY   int  // Mouse Y motion remainder
 }
 
 // NewApp creates a new application
@@ -39,6 +45,9 @@ func NewApp() *App {
 
 // Init the SDL App
 func (app *App) Init(emu *emulator.Emulator) error {
+	if emu.Control().Video().Device() == nil {
+		return errors.New("machine has no video output")
+	}
 	// init sdl
 	if err := sdl.Init(sdl.INIT_VIDEO | sdl.INIT_AUDIO | sdl.INIT_JOYSTICK); err != nil {
 		log.Println("SDL : Error initializing SDL:", err.Error())
@@ -79,6 +88,11 @@ func (app *App) Run() {
 	if app.config.App.File != "" {
 		app.emulator.LoadFile(app.config.App.File)
 	}
+	app.control.LoadProfiles(app.config.Input.Mapping, app.config.Input.Profile)
+	if app.config.App.Paste != "" {
+		app.control.Keyboard().WaitTyping(pasteBootFrames)
+		app.control.PasteFile(app.config.App.Paste)
+	}
 	app.emulator.Start()
 
 	// event loop
@@ -86,6 +100,7 @@ func (app *App) Run() {
 	for app.running {
 		// Poll SDL events
 		app.pollEvents()
+		app.updateTitle()
 
 		// Sync emulation
 		if !app.async {
@@ -132,10 +147,26 @@ func (app *App) pollEvents() {
 			app.processJoyAxis(e)
 		case *sdl.JoyButtonEvent:
 			app.processJoyButton(e)
+		case *sdl.MouseMotionEvent:
+			app.processMouseMotion(e)
+		case *sdl.MouseButtonEvent:
+			app.processMouseButton(e)
 		}
 	}
 }
 
+// updateTitle updates the window title with the tape status
+func (app *App) updateTitle() {
+	title := app.config.App.Title
+	if status := app.control.Tape().Status(); status != "" {
+		title += " - " + status
+	}
+	if title != app.title {
+		app.title = title
+		app.video.SetTitle(title)
+	}
+}
+
 func (app *App) processWindowEvent(e *sdl.WindowEvent) {
 	switch e.Event {
 	case sdl.WINDOWEVENT_SHOWN, sdl.WINDOWEVENT_RESIZED:
@@ -152,9 +183,14 @@ func (app *App) processKeyboard(e *sdl.KeyboardEvent) {
 	if e.Type == sdl.KEYDOWN {
 		captured = true
 		switch e.Keysym.Sym {
+		// Input
+		case sdl.K_F1:
+			app.control.NextProfile()
 		// Snaps
 		case sdl.K_F2:
 			app.emulator.TakeSnapshot()
+		case sdl.K_F3:
+			app.emulator.SaveProgram()
 		// Emulator
 		case sdl.K_F5:
 			app.emulator.Reset()
@@ -172,6 +208,17 @@ func (app *App) processKeyboard(e *sdl.KeyboardEvent) {
 			app.control.Tape().TogglePlay()
 		case sdl.K_F8:
 			app.control.Tape().Rewind()
+		case sdl.K_PAGEUP:
+			app.control.Tape().SeekPrevious()
+		case sdl.K_PAGEDOWN:
+			app.control.Tape().SeekNext()
+		case sdl.K_F9:
+			app.emulator.SetTapeTurbo(!app.emulator.IsTapeTurbo())
+			if app.emulator.IsTapeTurbo() {
+				log.Println("App : Tape turbo is enabled")
+			} else {
+				log.Println("App : Tape turbo is disabled")
+			}
 		// UI
 		case sdl.K_F4:
 			app.audio.config.Mute = !app.audio.config.Mute
@@ -182,6 +229,10 @@ func (app *App) processKeyboard(e *sdl.KeyboardEvent) {
 			}
 		case sdl.K_F11:
 			app.video.ToggleFullscreen()
+		case sdl.K_F12:
+			app.pasteClipboard()
+		case sdl.K_SCROLLLOCK:
+			app.toggleMouseCapture()
 		default:
 			captured = false
 		}
@@ -196,6 +247,73 @@ func (app *App) processKeyboard(e *sdl.KeyboardEvent) {
 	}
 }
 
+// pasteClipboard pastes the clipboard text, or cancels the paste in progress
+func (app *App) pasteClipboard() {
+	keyboard := app.control.Keyboard()
+	if keyboard.IsTyping() {
+		keyboard.CancelTyping()
+		log.Println("App : Paste cancelled")
+		return
+	}
+	text, err := sdl.GetClipboardText()
+	if err != nil || text == "" {
+		log.Println("App : Clipboard is empty")
+		return
+	}
+	app.control.PasteText(text)
+	log.Println("App : Pasting clipboard text")
+}
+
+// toggleMouseCapture captures and releases the host mouse
+func (app *App) toggleMouseCapture() {
+	if !app.control.Mouse().HasMouse() {
+		log.Println("App : The machine has no mouse")
+		return
+	}
+	app.captured = !app.captured
+	sdl.SetRelativeMouseMode(app.captured)
+	app.mouseX, app.mouseY = 0, 0
+	if app.captured {
+		log.Println("App : Mouse is captured")
+	} else {
+		log.Println("App : Mouse is released")
+	}
+}
+
+func (app *App) processMouseMotion(e *sdl.MouseMotionEvent) {
+	if !app.captured {
+		return
+	}
+	// host pixels to machine pixels
+	scale := app.config.Video.Scale
+	app.mouseX += int(e.XRel)
+	app.mouseY += int(e.YRel)
+	dx, dy := app.mouseX/scale, app.mouseY/scale
+	app.mouseX -= dx * scale
+	app.mouseY -= dy * scale
+	if dx != 0 || dy != 0 {
+		app.control.Mouse().MoveEvent(dx, dy)
+	}
+}
+
+func (app *App) processMouseButton(e *sdl.MouseButtonEvent) {
+	if !app.captured {
+		return
+	}
+	var button byte
+	switch e.Button {
+	case sdl.BUTTON_LEFT:
+		button = mouse.ButtonLeft
+	case sdl.BUTTON_RIGHT:
+		button = mouse.ButtonRight
+	case sdl.BUTTON_MIDDLE:
+		button = mouse.ButtonMiddle
+	default:
+		return
+	}
+	app.control.Mouse().ButtonEvent(button, e.State == sdl.PRESSED)
+}
+
 func (app *App) processJoyAxis(e *sdl.JoyAxisEvent) {
 	app.control.Joystick().AxisEvent(
 		byte(e.Which), e.Axis, byte(e.Value>>8))
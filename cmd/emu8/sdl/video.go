@@ -73,6 +73,14 @@ func (video *Video) ToggleFullscreen() {
 	video.updateScreen()
 }
 
+// SetTitle sets the window title
+func (video *Video) SetTitle(title string) {
+	video._sync.Lock()
+	defer video._sync.Unlock()
+
+	video.window.SetTitle(title)
+}
+
 // Update display
 func (video *Video) Update(screen *video.Screen) {
 	if video.app.async {
@@ -121,7 +129,8 @@ func (video *Video) sdlCreateWindow() bool {
 	if err != nil {
 		log.Println("SDL : Error creating Window:", err.Error())
 	}
-	// renderer
+	// renderer & scaling filter
+	sdl.SetHint(sdl.HINT_RENDER_SCALE_QUALITY, video.config.Filter)
 	video.renderer, err = sdl.CreateRenderer(video.window, -1, sdl.RENDERER_ACCELERATED)
 	if err != nil {
 		log.Println("SDL : Errror creating Renderer:", err.Error())
@@ -1,17 +1,26 @@
 // Package config contains the emulator configuration
 package config
 
+import "strings"
+
 // Default configuration constants
 const (
 	DefaultAppTitle        = "emu8"
 	DefaultAppFile         = ""
+	DefaultAppPaste        = ""
 	DefaultEmulatorAsync   = false
 	DefaultMachineModel    = "Speccy"
 	DefaultMachineOptions  = ""
 	DefaultVideoScale      = 2
+	DefaultVideoFilter     = "nearest"
 	DefaultVideoFullScreen = false
 	DefaultAudioFrecuency  = 44100 // 48 KHz
 	DefaultAudioMute       = false
+	DefaultTapeFastLoad    = false
+	DefaultTapeTurbo       = false
+	DefaultTapeAutoStart   = false
+	DefaultInputMapping    = ""
+	DefaultInputProfile    = ""
 )
 
 // -----------------------------------------------------------------------------
@@ -25,12 +34,16 @@ type Config struct {
 	Machine  MachineConfig
 	Video    VideoConfig
 	Audio    AudioConfig
+	Tape     TapeConfig
+	Input    InputConfig
+	Paths    PathConfig
 }
 
 // AppConfig is the application configuration
 type AppConfig struct {
 	Title string // Application base title
 	File  string // File to load
+	Paste string // Text file to paste
 }
 
 // EmulatorConfig is the emulation configuration
@@ -44,10 +57,65 @@ type MachineConfig struct {
 	Options string // Machine options
 }
 
+// Option returns the value of a machine option. Options are a comma
+// separated list of name=value items, names are case insensitive.
+func (config *MachineConfig) Option(name string) string {
+	for _, option := range strings.Split(config.Options, ",") {
+		fields := strings.SplitN(option, "=", 2)
+		if len(fields) == 2 && strings.EqualFold(strings.TrimSpace(fields[0]), name) {
+			return strings.TrimSpace(fields[1])
+		}
+	}
+	return ""
+}
+
+// SetOption sets the value of a machine option, replacing the current one
+func (config *MachineConfig) SetOption(name, value string) {
+	options := make([]string, 0)
+	for _, option := range strings.Split(config.Options, ",") {
+		fields := strings.SplitN(option, "=", 2)
+		if strings.TrimSpace(option) == "" || strings.EqualFold(strings.TrimSpace(fields[0]), name) {
+			continue
+		}
+		options = append(options, option)
+	}
+	config.Options = strings.Join(append(options, name+"="+value), ",")
+}
+
+// Flag checks if a machine option without value is set, as the legacy
+// language option (es, fr)
+func (config *MachineConfig) Flag(name string) bool {
+	for _, option := range strings.Split(config.Options, ",") {
+		if strings.EqualFold(strings.TrimSpace(option), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge sets the options over the current ones, option by option. Options
+// without value are added.
+func (config *MachineConfig) Merge(options string) {
+	for _, option := range strings.Split(options, ",") {
+		fields := strings.SplitN(option, "=", 2)
+		option = strings.TrimSpace(option)
+		switch {
+		case len(fields) == 2:
+			config.SetOption(strings.TrimSpace(fields[0]), strings.TrimSpace(fields[1]))
+		case option != "" && !config.Flag(option):
+			if config.Options != "" {
+				option = config.Options + "," + option
+			}
+			config.Options = option
+		}
+	}
+}
+
 // VideoConfig is the video configuration
 type VideoConfig struct {
-	Scale      int  // Video scale
-	FullScreen bool // Fullscreen mode
+	Scale      int    // Video scale
+	Filter     string // Video scaling filter (nearest, linear)
+	FullScreen bool   // Fullscreen mode
 }
 
 // AudioConfig is the audio configuration
@@ -56,6 +124,32 @@ type AudioConfig struct {
 	Mute      bool // Mute autio
 }
 
+// TapeConfig is the tape configuration
+type TapeConfig struct {
+	FastLoad  bool // Fast tape loading (ROM traps)
+	Turbo     bool // Turbo emulation while tape is playing
+	AutoStart bool // Automatic tape start & stop
+}
+
+// InputConfig is the input mapping configuration
+type InputConfig struct {
+	Mapping string // Input mapping profiles file
+	Profile string // Input mapping profile name
+}
+
+// PathConfig is the file paths configuration. Empty paths are the default
+// subdirectories of the working directory.
+type PathConfig struct {
+	Roms       string // ROM files path
+	Snapshots  string // Snapshot files path
+	Tapes      string // Tape files path
+	Programs   string // BASIC program files path
+	Profiles   string // Input mapping profile files path
+	Cartridges string // Cartridge files path
+	Disks      string // Disk image files path
+	Drives     string // Host drive files path
+}
+
 // -----------------------------------------------------------------------------
 // Configuration Singleton
 // -----------------------------------------------------------------------------
@@ -70,11 +164,18 @@ func Get() *Config { return config }
 func init() {
 	config.App.Title = DefaultAppTitle
 	config.App.File = DefaultAppFile
+	config.App.Paste = DefaultAppPaste
 	config.Emulator.Async = DefaultEmulatorAsync
 	config.Machine.Model = DefaultMachineModel
 	config.Machine.Options = DefaultMachineOptions
 	config.Video.Scale = DefaultVideoScale
+	config.Video.Filter = DefaultVideoFilter
 	config.Video.FullScreen = DefaultVideoFullScreen
 	config.Audio.Frequency = DefaultAudioFrecuency
 	config.Audio.Mute = DefaultAudioMute
+	config.Tape.FastLoad = DefaultTapeFastLoad
+	config.Tape.Turbo = DefaultTapeTurbo
+	config.Tape.AutoStart = DefaultTapeAutoStart
+	config.Input.Mapping = DefaultInputMapping
+	config.Input.Profile = DefaultInputProfile
 }
@@ -0,0 +1,29 @@
+package config
+
+import "testing"
+
+// TestMerge merges command line options over the configuration file
+// options. Options without value, as the legacy language option, are kept.
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		file, options, expected string
+	}{
+		{"", "es", "es"},
+		{"joy1=cursor", "es", "joy1=cursor,es"},
+		{"joy1=cursor,es", "es", "joy1=cursor,es"},
+		{"joy1=cursor", "lang=fr", "joy1=cursor,lang=fr"},
+		{"lang=es,mouse=amx", "lang=fr", "mouse=amx,lang=fr"},
+		{"mouse=amx", "", "mouse=amx"},
+	}
+	for _, test := range tests {
+		machine := MachineConfig{Options: test.file}
+		machine.Merge(test.options)
+		if machine.Options != test.expected {
+			t.Errorf("%q over %q : %q, expected %q", test.options, test.file, machine.Options, test.expected)
+		}
+	}
+	machine := MachineConfig{Options: "joy1=cursor, ES"}
+	if !machine.Flag("es") || machine.Flag("fr") || machine.Flag("joy1") {
+		t.Errorf("%q : wrong flags", machine.Options)
+	}
+}
@@ -0,0 +1,250 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Configuration file
+// -----------------------------------------------------------------------------
+
+// GlobalSection is the name of the section without header
+const GlobalSection = ""
+
+// File is an INI like configuration file. Settings before the first section
+// header are global, [name] sections override them for a machine model.
+// Each setting is a "group.key = value" line, lines starting with # or ;
+// are comments.
+type File struct {
+	sections []*fileSection
+}
+
+// fileSection is a configuration file section
+type fileSection struct {
+	name   string            // Section name
+	keys   []string          // Keys in file order
+	values map[string]string // Values by key
+}
+
+// NewFile creates an empty configuration file
+func NewFile() *File {
+	file := new(File)
+	file.section(GlobalSection, true)
+	return file
+}
+
+// ParseFile parses a configuration file
+func ParseFile(text string) (*File, error) {
+	file := NewFile()
+	section := file.sections[0]
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: invalid section name", n+1)
+			}
+			section = file.section(strings.TrimSpace(line[1:len(line)-1]), true)
+			continue
+		}
+		equal := strings.IndexByte(line, '=')
+		if equal < 0 {
+			return nil, fmt.Errorf("line %d: invalid setting", n+1)
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:equal]))
+		if !validKey(key) {
+			return nil, fmt.Errorf("line %d: unknown setting: %s", n+1, key)
+		}
+		section.set(key, unquote(strings.TrimSpace(line[equal+1:])))
+	}
+	return file, nil
+}
+
+// Sections returns the section names, global section first
+func (file *File) Sections() []string {
+	names := make([]string, len(file.sections))
+	for i, section := range file.sections {
+		names[i] = section.name
+	}
+	return names
+}
+
+// Get gets a setting of a section
+func (file *File) Get(name, key string) (string, bool) {
+	section := file.section(name, false)
+	if section == nil {
+		return "", false
+	}
+	value, ok := section.values[strings.ToLower(key)]
+	return value, ok
+}
+
+// Set sets a setting of a section
+func (file *File) Set(name, key, value string) {
+	file.section(name, true).set(strings.ToLower(key), value)
+}
+
+// Apply applies the settings of a section to the configuration. Unknown
+// sections are ignored.
+func (file *File) Apply(config *Config, name string) error {
+	section := file.section(name, false)
+	if section == nil {
+		return nil
+	}
+	for _, key := range section.keys {
+		if err := setValue(config, key, section.values[key]); err != nil {
+			return fmt.Errorf("[%s] %s", section.name, err.Error())
+		}
+	}
+	return nil
+}
+
+// Store stores the configuration settings into a section
+func (file *File) Store(config *Config, name string) {
+	section := file.section(name, true)
+	for _, setting := range settings(config) {
+		switch value := setting.value.(type) {
+		case *string:
+			section.set(setting.key, *value)
+		case *int:
+			section.set(setting.key, strconv.Itoa(*value))
+		case *bool:
+			section.set(setting.key, strconv.FormatBool(*value))
+		}
+	}
+}
+
+// String returns the configuration file text
+func (file *File) String() string {
+	var sb strings.Builder
+	sb.WriteString("# " + DefaultAppTitle + " configuration\n")
+	for _, section := range file.sections {
+		if len(section.keys) == 0 && section.name == GlobalSection {
+			continue
+		}
+		if section.name != GlobalSection {
+			sb.WriteString("\n[" + section.name + "]\n")
+		}
+		for _, key := range section.keys {
+			sb.WriteString(strings.TrimSpace(key+" = "+section.values[key]) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// section returns the section by name, optionally creating it
+func (file *File) section(name string, create bool) *fileSection {
+	for _, section := range file.sections {
+		if strings.EqualFold(section.name, name) {
+			return section
+		}
+	}
+	if !create {
+		return nil
+	}
+	section := &fileSection{name: name, values: make(map[string]string)}
+	file.sections = append(file.sections, section)
+	return section
+}
+
+// set sets a section value
+func (section *fileSection) set(key, value string) {
+	if _, ok := section.values[key]; !ok {
+		section.keys = append(section.keys, key)
+	}
+	section.values[key] = value
+}
+
+// Settings
+
+// setting is a configuration setting
+type setting struct {
+	key   string      // Setting key
+	value interface{} // Pointer to the configuration value
+}
+
+// settings returns the file settings of a configuration
+func settings(config *Config) []setting {
+	return []setting{
+		{"emulator.async", &config.Emulator.Async},
+		{"machine.model", &config.Machine.Model},
+		{"machine.options", &config.Machine.Options},
+		{"video.scale", &config.Video.Scale},
+		{"video.filter", &config.Video.Filter},
+		{"video.fullscreen", &config.Video.FullScreen},
+		{"audio.frequency", &config.Audio.Frequency},
+		{"audio.mute", &config.Audio.Mute},
+		{"tape.fastload", &config.Tape.FastLoad},
+		{"tape.turbo", &config.Tape.Turbo},
+		{"tape.autostart", &config.Tape.AutoStart},
+		{"input.mapping", &config.Input.Mapping},
+		{"input.profile", &config.Input.Profile},
+		{"paths.roms", &config.Paths.Roms},
+		{"paths.snapshots", &config.Paths.Snapshots},
+		{"paths.tapes", &config.Paths.Tapes},
+		{"paths.programs", &config.Paths.Programs},
+		{"paths.profiles", &config.Paths.Profiles},
+		{"paths.cartridges", &config.Paths.Cartridges},
+		{"paths.disks", &config.Paths.Disks},
+		{"paths.drives", &config.Paths.Drives},
+	}
+}
+
+// validKey checks if a key is a known setting. Other machine.name keys are
+// machine options.
+func validKey(key string) bool {
+	if strings.HasPrefix(key, "machine.") && len(key) > len("machine.") {
+		return true
+	}
+	for _, setting := range settings(new(Config)) {
+		if setting.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// setValue sets a configuration value by key
+func setValue(config *Config, key, text string) error {
+	for _, setting := range settings(config) {
+		if setting.key != key {
+			continue
+		}
+		switch value := setting.value.(type) {
+		case *string:
+			*value = text
+		case *int:
+			n, err := strconv.Atoi(text)
+			if err != nil {
+				return fmt.Errorf("invalid number: %s = %s", key, text)
+			}
+			*value = n
+		case *bool:
+			b, err := strconv.ParseBool(text)
+			if err != nil {
+				return fmt.Errorf("invalid boolean: %s = %s", key, text)
+			}
+			*value = b
+		}
+		return nil
+	}
+	if strings.HasPrefix(key, "machine.") {
+		config.Machine.SetOption(key[len("machine."):], text)
+		return nil
+	}
+	return fmt.Errorf("unknown setting: %s", key)
+}
+
+// unquote removes the optional quotes of a value
+func unquote(text string) string {
+	if len(text) > 1 && text[0] == '"' && text[len(text)-1] == '"' {
+		if s, err := strconv.Unquote(text); err == nil {
+			return s
+		}
+	}
+	return text
+}
@@ -3,6 +3,7 @@ package controller
 
 import (
 	"log"
+	"strings"
 
 	"github.com/jtruco/emu8/emulator/controller/io"
 	"github.com/jtruco/emu8/emulator/controller/ui"
@@ -10,6 +11,7 @@ import (
 	"github.com/jtruco/emu8/emulator/device/audio"
 	"github.com/jtruco/emu8/emulator/device/io/joystick"
 	"github.com/jtruco/emu8/emulator/device/io/keyboard"
+	"github.com/jtruco/emu8/emulator/device/io/mouse"
 	"github.com/jtruco/emu8/emulator/device/io/tape"
 	"github.com/jtruco/emu8/emulator/device/video"
 	"github.com/jtruco/emu8/emulator/machine"
@@ -27,7 +29,10 @@ type Controller struct {
 	audio    *ui.AudioController    // The audio controller
 	keyboard *io.KeyboardController // The keyboard controller
 	joystick *io.JoystickController // The joystick controller
+	mouse    *io.MouseController    // The mouse controller
 	tape     *io.TapeController     // The tape controller
+	profiles []*io.Profile          // Input mapping profiles
+	profile  int                    // Current input mapping profile
 }
 
 // New returns a new emulator controller.
@@ -40,7 +45,10 @@ func New(machine machine.Machine) *Controller {
 	controller.audio = ui.NewAudioController()
 	controller.keyboard = io.NewKeyboardController()
 	controller.joystick = io.NewJoystickController()
+	controller.mouse = io.NewMouseController()
 	controller.tape = io.NewTapeController()
+	controller.keyboard.SetJoystick(controller.joystick)
+	controller.joystick.SetKeyboard(controller.keyboard)
 	return controller
 }
 
@@ -66,6 +74,11 @@ func (controller *Controller) BindJoystick(device joystick.Joystick) {
 	controller.joystick.AddReceiver(device)
 }
 
+// BindMouse adds a mouse device
+func (controller *Controller) BindMouse(device mouse.Mouse) {
+	controller.mouse.AddReceiver(device)
+}
+
 // BindTapeDrive sets the tape drive
 func (controller *Controller) BindTapeDrive(drive *tape.Drive) {
 	controller.tape.SetDrive(drive)
@@ -76,6 +89,11 @@ func (controller *Controller) LoadROM(romname string) ([]byte, error) {
 	return controller.file.LoadROM(romname)
 }
 
+// FileSystem gets the virtual file system
+func (controller *Controller) FileSystem() vfs.FileSystem {
+	return controller.file.FileSystem()
+}
+
 // RegisterSnapshot adds a snapshot format
 func (controller *Controller) RegisterSnapshot(format string) {
 	controller.file.RegisterFormat(vfs.FormatSnapshot, format)
@@ -87,6 +105,16 @@ func (controller *Controller) RegisterTape(format string, builder func() tape.Ta
 	controller.tape.RegisterTape(format, builder)
 }
 
+// RegisterCartridge adds a cartridge format
+func (controller *Controller) RegisterCartridge(format string) {
+	controller.file.RegisterFormat(vfs.FormatCartridge, format)
+}
+
+// RegisterDisk adds a disk image format
+func (controller *Controller) RegisterDisk(format string) {
+	controller.file.RegisterFormat(vfs.FormatDisk, format)
+}
+
 // Controllers
 
 // FileManager returns the file manager
@@ -114,6 +142,11 @@ func (controller *Controller) Joystick() *io.JoystickController {
 	return controller.joystick
 }
 
+// Mouse the mouse controller
+func (controller *Controller) Mouse() *io.MouseController {
+	return controller.mouse
+}
+
 // Tape the tape controller
 func (controller *Controller) Tape() *io.TapeController {
 	return controller.tape
@@ -123,9 +156,10 @@ func (controller *Controller) Tape() *io.TapeController {
 
 // Scan flushes input events
 func (controller *Controller) Scan() {
-	// Keyboard & Joystick events
+	// Keyboard, Joystick & Mouse events
 	controller.keyboard.Flush()
 	controller.joystick.Flush()
+	controller.mouse.Flush()
 }
 
 // Refresh refresh UI and output events
@@ -135,6 +169,12 @@ func (controller *Controller) Refresh() {
 	controller.video.Refresh()
 }
 
+// RefreshSilent refresh UI discarding audio output
+func (controller *Controller) RefreshSilent() {
+	controller.audio.Discard()
+	controller.video.Refresh()
+}
+
 // Load / Save control
 
 // LoadFile loads file into machine
@@ -155,14 +195,167 @@ func (controller *Controller) LoadFile(filename string) {
 			machine.State{Format: info.Ext, Data: info.Data})
 	case vfs.FormatTape:
 		controller.tape.Load(info)
+	case vfs.FormatProgram:
+		controller.loadProgram(info)
+	case vfs.FormatCartridge:
+		controller.loadCartridge(info)
+	case vfs.FormatDisk:
+		controller.loadDisk(info)
 	default:
 		log.Println("Emulator : Unknown format:", info.Format)
 	}
 }
 
+// loadCartridge inserts a cartridge into the machine
+func (controller *Controller) loadCartridge(info *vfs.FileInfo) {
+	cartridge, ok := controller.machine.(machine.Cartridge)
+	if !ok {
+		log.Println("Emulator : Cartridges not supported")
+		return
+	}
+	if err := cartridge.InsertCartridge(info.Data); err != nil {
+		log.Println("Emulator : Error loading cartridge:", err.Error())
+	}
+}
+
+// loadDisk inserts a disk image into the machine drive
+func (controller *Controller) loadDisk(info *vfs.FileInfo) {
+	drive, ok := controller.machine.(machine.DiskDrive)
+	if !ok {
+		log.Println("Emulator : Disks not supported")
+		return
+	}
+	if err := drive.InsertDisk(info.Ext, info.Data); err != nil {
+		log.Println("Emulator : Error loading disk:", err.Error())
+	}
+}
+
+// loadProgram loads a BASIC listing into machine memory
+func (controller *Controller) loadProgram(info *vfs.FileInfo) {
+	basic, ok := controller.machine.(machine.Basic)
+	if !ok {
+		log.Println("Emulator : BASIC programs not supported")
+		return
+	}
+	if err := basic.EnterProgram(string(info.Data)); err != nil {
+		log.Println("Emulator : Error loading program:", err.Error())
+		return
+	}
+	log.Println("Emulator : Program loaded:", info.Name)
+}
+
+// SaveProgram saves the BASIC listing of the program in machine memory
+func (controller *Controller) SaveProgram() {
+	basic, ok := controller.machine.(machine.Basic)
+	if !ok {
+		log.Println("Emulator : BASIC programs not supported")
+		return
+	}
+	name := controller.file.NewName("program", vfs.ExtBasic)
+	err := controller.file.SaveFile(name, vfs.FormatProgram, []byte(basic.ListProgram()))
+	if err == nil {
+		log.Println("Emulator : Program saved:", name)
+	} else {
+		log.Println("Emulator : Error saving program:", name)
+	}
+}
+
+// Input mapping profiles
+
+// LoadProfiles loads the input mapping profiles file and selects a profile.
+// Without filename, loads the machine profiles file if it exists.
+func (controller *Controller) LoadProfiles(filename, name string) {
+	optional := filename == ""
+	if optional {
+		model := strings.ToLower(strings.ReplaceAll(controller.machine.Config().Name, " ", ""))
+		filename = model + "." + vfs.ExtMap
+	}
+	info := vfs.NewFileInfo(filename)
+	info.Format = vfs.FormatProfile
+	err := controller.file.LoadFile(info)
+	if err != nil {
+		if !optional {
+			log.Println("Emulator : Error loading file:", info.Name)
+		}
+		return
+	}
+	profiles, err := io.ParseProfiles(string(info.Data))
+	if err != nil {
+		log.Println("Emulator : Invalid mapping profiles:", info.Name, ":", err.Error())
+		return
+	}
+	controller.profiles = profiles
+	controller.profile = len(profiles) // default machine mapping
+	log.Println("Emulator : Mapping profiles loaded:", info.Name)
+	if name != "" {
+		controller.SetProfile(name)
+	} else if len(profiles) > 0 {
+		controller.selectProfile(0)
+	}
+}
+
+// SetProfile selects an input mapping profile by name
+func (controller *Controller) SetProfile(name string) {
+	for i, profile := range controller.profiles {
+		if strings.EqualFold(profile.Name, name) {
+			controller.selectProfile(i)
+			return
+		}
+	}
+	log.Println("Emulator : Mapping profile not found:", name)
+}
+
+// NextProfile selects the next input mapping profile. After the last
+// profile, the default machine mapping is restored.
+func (controller *Controller) NextProfile() {
+	if len(controller.profiles) == 0 {
+		log.Println("Emulator : No mapping profiles loaded")
+		return
+	}
+	controller.selectProfile((controller.profile + 1) % (len(controller.profiles) + 1))
+}
+
+// selectProfile selects the profile by index, out of range restores the
+// default machine mapping
+func (controller *Controller) selectProfile(index int) {
+	controller.profile = index
+	var profile *io.Profile
+	if index < len(controller.profiles) {
+		profile = controller.profiles[index]
+		log.Println("Emulator : Mapping profile:", profile.Name)
+	} else {
+		log.Println("Emulator : Default machine mapping")
+	}
+	controller.keyboard.SetProfile(profile)
+	controller.joystick.SetProfile(profile)
+}
+
+// Text paste
+
+// PasteText types the text into the machine keyboard
+func (controller *Controller) PasteText(text string) {
+	controller.keyboard.TypeText(text)
+}
+
+// PasteFile types the contents of a text file into the machine keyboard
+func (controller *Controller) PasteFile(filename string) {
+	info := vfs.NewFileInfo(filename)
+	info.Format = vfs.FormatProgram
+	err := controller.file.LoadFile(info)
+	if err != nil {
+		log.Println("Emulator : Error loading file:", info.Name)
+		return
+	}
+	controller.PasteText(string(info.Data))
+	log.Println("Emulator : Pasting file:", info.Name)
+}
+
 // TakeSnapshot saves a snapshot file from machine state
 func (controller *Controller) TakeSnapshot() {
 	state := controller.machine.SaveState()
+	if state.Data == nil {
+		return
+	}
 	name := controller.file.NewName("snap", state.Format)
 	err := controller.file.SaveFile(name, vfs.FormatSnapshot, state.Data)
 	if err == nil {
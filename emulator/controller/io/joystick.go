@@ -12,15 +12,21 @@ import (
 
 // JoystickController is the emulator joystick(s) controller
 type JoystickController struct {
-	receivers  map[byte]joystick.Joystick // Joystick devices mapped by ID
-	eventQueue []joystick.JoyEvent        // Events to dispatch
-	mtx        sync.Mutex                 // Sync
+	receivers  map[byte]joystick.Joystick  // Joystick devices mapped by ID
+	profile    *Profile                    // Input mapping profile
+	keyboard   *KeyboardController         // Keyboard controller of mapped inputs
+	axes       map[byte]int                // Mapped axes pressed input codes
+	controls   map[byte]*[JoyControls]bool // Joystick controls pressed by mappings
+	eventQueue []joystick.JoyEvent         // Events to dispatch
+	mtx        sync.Mutex                  // Sync
 }
 
 // NewJoystickController creates a new controller
 func NewJoystickController() *JoystickController {
 	controller := new(JoystickController)
 	controller.receivers = make(map[byte]joystick.Joystick)
+	controller.axes = make(map[byte]int)
+	controller.controls = make(map[byte]*[JoyControls]bool)
 	controller.eventQueue = make([]joystick.JoyEvent, 0, 5)
 	return controller
 }
@@ -41,20 +47,137 @@ func (controller *JoystickController) RemoveReceiver(receiver joystick.Joystick)
 	}
 }
 
+// Mapping profile
+
+// SetKeyboard sets the keyboard controller that receives the mapped inputs
+func (controller *JoystickController) SetKeyboard(keyboard *KeyboardController) {
+	controller.keyboard = keyboard
+}
+
+// SetProfile sets the input mapping profile. Mapped buttons and axes are
+// sent to the keyboard controller as virtual key codes.
+func (controller *JoystickController) SetProfile(profile *Profile) {
+	controller.mtx.Lock()
+	defer controller.mtx.Unlock()
+
+	controller.profile = profile
+	controller.axes = make(map[byte]int)
+}
+
+// isMapped checks if the input code is mapped by the profile
+func (controller *JoystickController) isMapped(input int) bool {
+	if controller.profile == nil || controller.keyboard == nil {
+		return false
+	}
+	_, ok := controller.profile.Mappings[input]
+	return ok
+}
+
+// mapAxis sends a mapped axis to the keyboard controller
+func (controller *JoystickController) mapAxis(axis, value byte) bool {
+	controller.mtx.Lock()
+	minus, plus := InputAxisMinus+int(axis), InputAxisPlus+int(axis)
+	mapped := controller.isMapped(minus) || controller.isMapped(plus)
+	previous := controller.axes[axis]
+	input := 0
+	if value >= 128 {
+		input = minus
+	} else if value > 0 {
+		input = plus
+	}
+	controller.axes[axis] = input
+	controller.mtx.Unlock()
+
+	if !mapped {
+		return false
+	}
+	if input != previous {
+		if previous != 0 {
+			controller.keyboard.KeyUp(previous)
+		}
+		if input != 0 {
+			controller.keyboard.KeyDown(input)
+		}
+	}
+	return true
+}
+
+// mapButton sends a mapped button to the keyboard controller
+func (controller *JoystickController) mapButton(button, state byte) bool {
+	controller.mtx.Lock()
+	mapped := controller.isMapped(InputButton + int(button))
+	controller.mtx.Unlock()
+
+	if !mapped {
+		return false
+	}
+	if state > 0 {
+		controller.keyboard.KeyDown(InputButton + int(button))
+	} else {
+		controller.keyboard.KeyUp(InputButton + int(button))
+	}
+	return true
+}
+
 // Events
 
 // AxisEvent emits a joystick axis event
 func (controller *JoystickController) AxisEvent(id, axis, value byte) {
+	if controller.mapAxis(axis, value) {
+		return
+	}
 	joyevent := joystick.NewJoyAxisEvent(id, axis, value)
 	controller.appendEvent(joyevent)
 }
 
 // ButtonEvent emits a joystick button event
 func (controller *JoystickController) ButtonEvent(id, button, state byte) {
+	if controller.mapButton(button, state) {
+		return
+	}
 	joyevent := joystick.NewJoyButtonEvent(id, button, state)
 	controller.appendEvent(joyevent)
 }
 
+// ControlEvent emits a joystick control event from a mapped input
+func (controller *JoystickController) ControlEvent(id byte, control int, pressed bool) {
+	controller.mtx.Lock()
+	defer controller.mtx.Unlock()
+
+	state, ok := controller.controls[id]
+	if !ok {
+		state = new([JoyControls]bool)
+		controller.controls[id] = state
+	}
+	state[control] = pressed
+	var joyEvent joystick.JoyEvent
+	switch control {
+	case JoyLeft, JoyRight:
+		joyEvent = joystick.NewJoyAxisEvent(id, 0, axisValue(state[JoyLeft], state[JoyRight]))
+	case JoyUp, JoyDown:
+		joyEvent = joystick.NewJoyAxisEvent(id, 1, axisValue(state[JoyUp], state[JoyDown]))
+	default:
+		button := byte(control - JoyFire1)
+		if pressed {
+			joyEvent = joystick.NewJoyButtonEvent(id, button, 1)
+		} else {
+			joyEvent = joystick.NewJoyButtonEvent(id, button, 0)
+		}
+	}
+	controller.eventQueue = append(controller.eventQueue, joyEvent)
+}
+
+// axisValue returns the axis value of the pressed directions
+func axisValue(minus, plus bool) byte {
+	switch {
+	case minus && !plus:
+		return 0xff
+	case plus && !minus:
+		return 0x01
+	}
+	return 0
+}
+
 // appendEvent adds event to queue
 func (controller *JoystickController) appendEvent(joyEvent joystick.JoyEvent) {
 	controller.mtx.Lock()
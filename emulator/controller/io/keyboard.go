@@ -2,6 +2,8 @@
 package io
 
 import (
+	"log"
+	"strings"
 	"sync"
 
 	"github.com/jtruco/emu8/emulator/device/io/keyboard"
@@ -13,9 +15,13 @@ import (
 
 // KeyboardController is the emulator keyboard controller
 type KeyboardController struct {
-	receivers  map[keyboard.Receiver]keyboard.KeyMap // Keyboard receiver devices
-	eventQueue []keyEvent                            // Keyboard event queue
-	mtx        sync.Mutex                            // Sync
+	receivers  map[keyboard.Receiver]keyboard.KeyMap  // Keyboard receiver devices
+	typists    map[keyboard.Receiver]*keyboard.Typist // Text typists
+	profile    *Profile                               // Input mapping profile
+	joyTargets map[int][]Target                       // Joystick targets by input code
+	joystick   *JoystickController                    // Joystick controller of joystick targets
+	eventQueue []keyEvent                             // Keyboard event queue
+	mtx        sync.Mutex                             // Sync
 }
 
 // Keyboard key event
@@ -28,6 +34,8 @@ type keyEvent struct {
 func NewKeyboardController() *KeyboardController {
 	controller := new(KeyboardController)
 	controller.receivers = make(map[keyboard.Receiver]keyboard.KeyMap)
+	controller.typists = make(map[keyboard.Receiver]*keyboard.Typist)
+	controller.joyTargets = make(map[int][]Target)
 	controller.eventQueue = make([]keyEvent, 0, 5)
 	return controller
 }
@@ -36,12 +44,80 @@ func NewKeyboardController() *KeyboardController {
 
 // AddReceiver adds a keyboard events Receiver to the controller and associated keymap
 func (controller *KeyboardController) AddReceiver(receiver keyboard.Receiver) {
-	controller.receivers[receiver] = receiver.KeyMap()
+	controller.receivers[receiver] = controller.keyMap(receiver)
+	if _, ok := receiver.(keyboard.TextReceiver); ok {
+		controller.typists[receiver] = keyboard.NewTypist(receiver)
+	}
 }
 
 // RemoveReceiver removes the Receiver
 func (controller *KeyboardController) RemoveReceiver(receiver keyboard.Receiver) {
 	delete(controller.receivers, receiver)
+	delete(controller.typists, receiver)
+}
+
+// Mapping profile
+
+// SetJoystick sets the joystick controller that receives the joystick targets
+func (controller *KeyboardController) SetJoystick(joystick *JoystickController) {
+	controller.joystick = joystick
+}
+
+// SetProfile sets the input mapping profile. A nil profile restores the
+// receivers default keymaps.
+func (controller *KeyboardController) SetProfile(profile *Profile) {
+	controller.mtx.Lock()
+	defer controller.mtx.Unlock()
+
+	controller.profile = profile
+	controller.joyTargets = make(map[int][]Target)
+	if profile != nil {
+		for input, targets := range profile.Mappings {
+			for _, target := range targets {
+				if target.Key == "" {
+					controller.joyTargets[input] = append(controller.joyTargets[input], target)
+				}
+			}
+		}
+	}
+	for receiver := range controller.receivers {
+		controller.receivers[receiver] = controller.keyMap(receiver)
+	}
+}
+
+// keyMap returns the receiver keymap with the profile mappings. Mapped
+// inputs replace the default mapping.
+func (controller *KeyboardController) keyMap(receiver keyboard.Receiver) keyboard.KeyMap {
+	keymap := receiver.KeyMap()
+	if controller.profile == nil {
+		return keymap
+	}
+	names := make(map[string]keyboard.Key)
+	if named, ok := receiver.(keyboard.NamedReceiver); ok {
+		for name, key := range named.KeyNames() {
+			names[strings.ToLower(name)] = key
+		}
+	}
+	mapped := make(keyboard.KeyMap, len(keymap))
+	for code, keys := range keymap {
+		mapped[code] = keys
+	}
+	for input, targets := range controller.profile.Mappings {
+		keys := make([]keyboard.Key, 0, len(targets))
+		for _, target := range targets {
+			if target.Key == "" {
+				continue
+			}
+			key, ok := names[strings.ToLower(target.Key)]
+			if !ok {
+				log.Println("Keyboard : Unknown machine key:", target.Key)
+				continue
+			}
+			keys = append(keys, key)
+		}
+		mapped[input] = keys
+	}
+	return mapped
 }
 
 // Key events
@@ -74,19 +150,74 @@ func (controller *KeyboardController) Flush() {
 		controller.emitEvent(e)
 	}
 	controller.eventQueue = controller.eventQueue[:0]
+	for _, typist := range controller.typists {
+		typist.Frame()
+	}
+}
+
+// Text typing
+
+// TypeText types the text into the keyboard receivers that support it. Key
+// presses are spread over frames, so the machine keyboard scanner sees them.
+func (controller *KeyboardController) TypeText(text string) {
+	controller.mtx.Lock()
+	defer controller.mtx.Unlock()
+
+	for receiver, typist := range controller.typists {
+		typist.Type(receiver.(keyboard.TextReceiver).TextKeys(text)...)
+	}
+}
+
+// WaitTyping adds a number of frames to wait before typing text
+func (controller *KeyboardController) WaitTyping(frames int) {
+	controller.mtx.Lock()
+	defer controller.mtx.Unlock()
+
+	for _, typist := range controller.typists {
+		typist.Wait(frames)
+	}
+}
+
+// IsTyping returns true while there is text pending to type
+func (controller *KeyboardController) IsTyping() bool {
+	controller.mtx.Lock()
+	defer controller.mtx.Unlock()
+
+	for _, typist := range controller.typists {
+		if typist.IsTyping() {
+			return true
+		}
+	}
+	return false
+}
+
+// CancelTyping cancels the pending text typing
+func (controller *KeyboardController) CancelTyping() {
+	controller.mtx.Lock()
+	defer controller.mtx.Unlock()
+
+	for _, typist := range controller.typists {
+		typist.Cancel()
+	}
 }
 
 // emitEvent emits a keyboard event
 func (controller *KeyboardController) emitEvent(e keyEvent) {
+	pressed := e.EventType == keyboard.KeyDown
 	// For every receiver checks if keycode is mapped
 	for receiver, keymap := range controller.receivers {
 		keys, ok := keymap[e.Keycode]
 		if ok {
-			pressed := e.EventType == keyboard.KeyDown
 			// For each key emit event to receiver
 			for _, key := range keys {
 				receiver.ProcessKey(key, pressed)
 			}
 		}
 	}
+	// Keyboard as joystick
+	if controller.joystick != nil {
+		for _, target := range controller.joyTargets[e.Keycode] {
+			controller.joystick.ControlEvent(target.Joystick, target.Control, pressed)
+		}
+	}
 }
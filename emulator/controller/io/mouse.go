@@ -0,0 +1,96 @@
+package io
+
+import (
+	"sync"
+
+	"github.com/jtruco/emu8/emulator/device/io/mouse"
+)
+
+// -----------------------------------------------------------------------------
+// Mouse Controller
+// -----------------------------------------------------------------------------
+
+// MouseController is the emulator mouse controller
+type MouseController struct {
+	receivers  []mouse.Mouse      // Mouse devices
+	eventQueue []mouse.MouseEvent // Events to dispatch
+	mtx        sync.Mutex         // Sync
+}
+
+// NewMouseController creates a new controller
+func NewMouseController() *MouseController {
+	controller := new(MouseController)
+	controller.receivers = make([]mouse.Mouse, 0, 1)
+	controller.eventQueue = make([]mouse.MouseEvent, 0, 5)
+	return controller
+}
+
+// Receivers
+
+// HasMouse checks if there are mouse receivers
+func (controller *MouseController) HasMouse() bool {
+	return len(controller.receivers) > 0
+}
+
+// AddReceiver adds a mouse receiver to the controller
+func (controller *MouseController) AddReceiver(receiver mouse.Mouse) {
+	controller.receivers = append(controller.receivers, receiver)
+}
+
+// RemoveReceiver removes the mouse receiver
+func (controller *MouseController) RemoveReceiver(receiver mouse.Mouse) {
+	for i, m := range controller.receivers {
+		if m == receiver {
+			controller.receivers = append(controller.receivers[:i], controller.receivers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Events
+
+// MoveEvent emits a mouse relative motion event. Consecutive motions are
+// merged in one event.
+func (controller *MouseController) MoveEvent(dx, dy int) {
+	controller.mtx.Lock()
+	defer controller.mtx.Unlock()
+
+	last := len(controller.eventQueue) - 1
+	if last >= 0 && controller.eventQueue[last].Code() == mouse.EventMouseMove {
+		controller.eventQueue[last].DX += dx
+		controller.eventQueue[last].DY += dy
+		return
+	}
+	controller.eventQueue = append(controller.eventQueue, mouse.NewMouseMoveEvent(dx, dy))
+}
+
+// ButtonEvent emits a mouse button event
+func (controller *MouseController) ButtonEvent(button byte, pressed bool) {
+	controller.mtx.Lock()
+	defer controller.mtx.Unlock()
+
+	controller.eventQueue = append(controller.eventQueue, mouse.NewMouseButtonEvent(button, pressed))
+}
+
+// Flush flushes mouse events
+func (controller *MouseController) Flush() {
+	controller.mtx.Lock()
+	defer controller.mtx.Unlock()
+
+	for _, e := range controller.eventQueue {
+		controller.emitEvent(&e)
+	}
+	controller.eventQueue = controller.eventQueue[:0]
+}
+
+// emitEvent emits a mouse event to receivers
+func (controller *MouseController) emitEvent(mouseEvent *mouse.MouseEvent) {
+	for _, receiver := range controller.receivers {
+		switch mouseEvent.Code() {
+		case mouse.EventMouseMove:
+			receiver.Move(mouseEvent.DX, mouseEvent.DY)
+		case mouse.EventMouseButton:
+			receiver.SetButton(mouseEvent.Button, mouseEvent.Pressed)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package io
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator/device/io/keyboard"
+)
+
+// -----------------------------------------------------------------------------
+// Input mapping profiles
+// -----------------------------------------------------------------------------
+
+// Gamepad input codes. Gamepad buttons and axes are mapped as virtual key
+// codes, beyond the host keyboard key codes.
+const (
+	InputButton    = 0x1000 // Gamepad button : InputButton + button
+	InputAxisMinus = 0x2000 // Gamepad axis negative direction : InputAxisMinus + axis
+	InputAxisPlus  = 0x3000 // Gamepad axis positive direction : InputAxisPlus + axis
+)
+
+// Joystick controls
+const (
+	JoyUp = iota
+	JoyDown
+	JoyLeft
+	JoyRight
+	JoyFire1
+	JoyFire2
+	JoyFire3
+	JoyControls // limit count
+)
+
+// DefaultProfile is the name of the profile without section
+const DefaultProfile = "default"
+
+// joyControlNames joystick controls by name
+var joyControlNames = map[string]int{
+	"up": JoyUp, "down": JoyDown, "left": JoyLeft, "right": JoyRight,
+	"fire": JoyFire1, "fire1": JoyFire1, "fire2": JoyFire2, "fire3": JoyFire3,
+}
+
+// Target is a mapping target : a machine key or a joystick control
+type Target struct {
+	Key      string // Machine key name, empty for joystick controls
+	Joystick byte   // Joystick ID
+	Control  int    // Joystick control
+}
+
+// Profile is an input mapping profile. Maps host keys, gamepad buttons and
+// axes to machine keys and joystick controls.
+type Profile struct {
+	Name     string           // Profile name
+	Mappings map[int][]Target // Targets by input code
+}
+
+// NewProfile creates an empty profile
+func NewProfile(name string) *Profile {
+	profile := new(Profile)
+	profile.Name = name
+	profile.Mappings = make(map[int][]Target)
+	return profile
+}
+
+// ParseProfiles parses input mapping profiles from text. Each profile starts
+// with a [name] line, mappings before the first one belong to the default
+// profile. A mapping line is "input = target ...", where input is key:name,
+// button:n, axis:n- or axis:n+, and each target is a machine key name or a
+// joystick control (joy:up, joy2:fire, ...). Lines starting with # are
+// comments.
+func ParseProfiles(text string) ([]*Profile, error) {
+	profiles := make([]*Profile, 0)
+	var profile *Profile
+	for n, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("line %d: invalid profile name", n+1)
+			}
+			profile = NewProfile(strings.TrimSpace(line[1 : len(line)-1]))
+			profiles = append(profiles, profile)
+			continue
+		}
+		equal := strings.IndexByte(line, '=')
+		if equal < 0 {
+			return nil, fmt.Errorf("line %d: invalid mapping", n+1)
+		}
+		input, err := parseInput(strings.TrimSpace(line[:equal]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", n+1, err.Error())
+		}
+		targets := make([]Target, 0)
+		for _, field := range strings.Fields(line[equal+1:]) {
+			target, err := parseTarget(field)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", n+1, err.Error())
+			}
+			targets = append(targets, target)
+		}
+		if profile == nil {
+			profile = NewProfile(DefaultProfile)
+			profiles = append(profiles, profile)
+		}
+		profile.Mappings[input] = targets
+	}
+	return profiles, nil
+}
+
+// parseInput parses a host input : key:name, button:n, axis:n- or axis:n+
+func parseInput(text string) (int, error) {
+	colon := strings.IndexByte(text, ':')
+	if colon < 0 {
+		return 0, fmt.Errorf("invalid input: %s", text)
+	}
+	kind, name := strings.ToLower(text[:colon]), text[colon+1:]
+	switch kind {
+	case "key":
+		for keyname, code := range keyboard.KeyNames {
+			if strings.EqualFold(keyname, name) {
+				return code, nil
+			}
+		}
+	case "button":
+		if button, err := strconv.Atoi(name); err == nil && button >= 0 && button < 0x100 {
+			return InputButton + button, nil
+		}
+	case "axis":
+		if len(name) > 1 {
+			axis, err := strconv.Atoi(name[:len(name)-1])
+			if err == nil && axis >= 0 && axis < 0x100 {
+				switch name[len(name)-1] {
+				case '-':
+					return InputAxisMinus + axis, nil
+				case '+':
+					return InputAxisPlus + axis, nil
+				}
+			}
+		}
+	}
+	return 0, fmt.Errorf("invalid input: %s", text)
+}
+
+// parseTarget parses a target : a machine key name or joy[n]:control
+func parseTarget(text string) (Target, error) {
+	lower := strings.ToLower(text)
+	if !strings.HasPrefix(lower, "joy") || strings.IndexByte(lower, ':') < 0 {
+		return Target{Key: text}, nil
+	}
+	colon := strings.IndexByte(lower, ':')
+	id := 1
+	if colon > 3 {
+		n, err := strconv.Atoi(lower[3:colon])
+		if err != nil || n < 1 || n > 0x100 {
+			return Target{}, fmt.Errorf("invalid joystick: %s", text)
+		}
+		id = n
+	}
+	control, ok := joyControlNames[lower[colon+1:]]
+	if !ok {
+		return Target{}, fmt.Errorf("invalid joystick control: %s", text)
+	}
+	return Target{Joystick: byte(id - 1), Control: control}, nil
+}
@@ -1,6 +1,7 @@
 package io
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/jtruco/emu8/emulator/controller/vfs"
@@ -87,6 +88,48 @@ func (controller *TapeController) Rewind() {
 	controller.Drive().Rewind()
 }
 
+// Seek positions the tape at the start of a block
+func (controller *TapeController) Seek(index int) {
+	if !controller.controlTape() {
+		return
+	}
+	if !controller.Drive().Seek(index) {
+		log.Println("Emulator : Invalid tape block : ", index)
+	}
+}
+
+// SeekNext positions the tape at the start of the next block
+func (controller *TapeController) SeekNext() {
+	if controller.controlTape() {
+		controller.Seek(controller.Drive().BlockIndex() + 1)
+	}
+}
+
+// SeekPrevious positions the tape at the start of the previous block
+func (controller *TapeController) SeekPrevious() {
+	if controller.controlTape() {
+		controller.Seek(controller.Drive().BlockIndex() - 1)
+	}
+}
+
+// Status returns the tape position and the current block description
+func (controller *TapeController) Status() string {
+	if !controller.HasDrive() || !controller.Drive().HasTape() {
+		return ""
+	}
+	drive := controller.Drive()
+	index, total := drive.Position()
+	state := "Stopped"
+	if drive.IsPlaying() {
+		state = "Playing"
+	}
+	status := fmt.Sprintf("Tape %s %d/%d (%d%%)", state, index+1, total, drive.Percentage())
+	if block := drive.CurrentBlock(); block != nil {
+		status += " " + block.Meta().String()
+	}
+	return status
+}
+
 // controlTape controls tape drive state
 func (controller *TapeController) controlTape() bool {
 	if controller.HasDrive() {
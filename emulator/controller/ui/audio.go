@@ -56,3 +56,12 @@ func (controller *AudioController) Flush() {
 	}
 	buffer.Reset()
 }
+
+// Discard ends the audio frame and discards the buffer
+func (controller *AudioController) Discard() {
+	if controller.device == nil {
+		return
+	}
+	controller.device.EndFrame()
+	controller.device.Buffer().Reset()
+}
@@ -11,13 +11,20 @@ const (
 	FormatRom
 	FormatSnapshot
 	FormatTape
+	FormatProgram
+	FormatProfile
+	FormatCartridge
+	FormatDisk
+	FormatDrive
 	FormatMax // limit count
 )
 
 // Commont extensions
 const (
-	ExtRom = "rom"
-	ExtZip = "zip"
+	ExtRom   = "rom"
+	ExtZip   = "zip"
+	ExtBasic = "bas"
+	ExtMap   = "map"
 )
 
 // -----------------------------------------------------------------------------
@@ -36,9 +43,15 @@ func NewFileManager() *FileManager {
 	manager.vfs = GetFileSystem()
 	manager.formats = make(map[string]int)
 	manager.RegisterFormat(FormatRom, ExtRom)
+	manager.RegisterFormat(FormatProgram, ExtBasic)
 	return manager
 }
 
+// FileSystem gets the virtual file system
+func (manager *FileManager) FileSystem() FileSystem {
+	return manager.vfs
+}
+
 // SetFileSystem set virtual file system
 func (manager *FileManager) SetFileSystem(vfs FileSystem) {
 	manager.vfs = vfs
@@ -14,6 +14,16 @@ type FileSystem interface {
 	SaveFile(info *FileInfo) error
 }
 
+// DirFileSystem is a virtual filesystem with directory operations
+type DirFileSystem interface {
+	// ReadDir lists the files of a format location.
+	ReadDir(format int) ([]*FileInfo, error)
+	// RemoveFile removes the file from its location.
+	RemoveFile(info *FileInfo) error
+	// RenameFile renames the file in its location.
+	RenameFile(info *FileInfo, name string) error
+}
+
 // fileSystem is the current filesystem : default memory
 var fileSystem FileSystem = NewMemFileSystem()
 
@@ -58,3 +68,34 @@ func (mfs *MemFileSystem) SaveFile(info *FileInfo) error {
 	mfs.files[info.Path] = info.Data
 	return nil
 }
+
+// ReadDir lists the files in memory, of any format
+func (mfs *MemFileSystem) ReadDir(format int) ([]*FileInfo, error) {
+	files := make([]*FileInfo, 0, len(mfs.files))
+	for path := range mfs.files {
+		info := NewFileInfo(path)
+		info.Format = format
+		files = append(files, info)
+	}
+	return files, nil
+}
+
+// RemoveFile removes the file from memory
+func (mfs *MemFileSystem) RemoveFile(info *FileInfo) error {
+	if mfs.files[info.Path] == nil {
+		return errors.New("MemoryFileSystem : file not found")
+	}
+	delete(mfs.files, info.Path)
+	return nil
+}
+
+// RenameFile renames the file in memory
+func (mfs *MemFileSystem) RenameFile(info *FileInfo, name string) error {
+	data := mfs.files[info.Path]
+	if data == nil {
+		return errors.New("MemoryFileSystem : file not found")
+	}
+	delete(mfs.files, info.Path)
+	mfs.files[name] = data
+	return nil
+}
@@ -11,13 +11,20 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+
+	"github.com/jtruco/emu8/emulator/config"
 )
 
 // Default subpath constants
 const (
-	PathRom      = "roms"  // ROMs default subpath
-	PathSnapshot = "snaps" // Snapshots default subpath
-	PathTape     = "tapes" // Tapes default subpath
+	PathRom       = "roms"     // ROMs default subpath
+	PathSnapshot  = "snaps"    // Snapshots default subpath
+	PathTape      = "tapes"    // Tapes default subpath
+	PathProgram   = "programs" // Programs default subpath
+	PathProfile   = "profiles" // Input mapping profiles default subpath
+	PathCartridge = "carts"    // Cartridges default subpath
+	PathDisk      = "disks"    // Disk images default subpath
+	PathDrive     = "drive"    // Host drive files default subpath
 )
 
 // -----------------------------------------------------------------------------
@@ -38,13 +45,35 @@ func NewDesktopFileSystem(path string) *DesktopFileSystem {
 	fs.subpaths[FormatRom] = filepath.Join(path, PathRom)
 	fs.subpaths[FormatSnapshot] = filepath.Join(path, PathSnapshot)
 	fs.subpaths[FormatTape] = filepath.Join(path, PathTape)
+	fs.subpaths[FormatProgram] = filepath.Join(path, PathProgram)
+	fs.subpaths[FormatProfile] = filepath.Join(path, PathProfile)
+	fs.subpaths[FormatCartridge] = filepath.Join(path, PathCartridge)
+	fs.subpaths[FormatDisk] = filepath.Join(path, PathDisk)
+	fs.subpaths[FormatDrive] = filepath.Join(path, PathDrive)
 	return fs
 }
 
 // InitDesktop initialices the desktop filesystem
 func InitDesktop() {
 	cwd, _ := os.Getwd()
-	SetFileSystem(NewDesktopFileSystem(cwd))
+	fs := NewDesktopFileSystem(cwd)
+	paths := &config.Get().Paths
+	fs.SetPath(FormatRom, paths.Roms)
+	fs.SetPath(FormatSnapshot, paths.Snapshots)
+	fs.SetPath(FormatTape, paths.Tapes)
+	fs.SetPath(FormatProgram, paths.Programs)
+	fs.SetPath(FormatProfile, paths.Profiles)
+	fs.SetPath(FormatCartridge, paths.Cartridges)
+	fs.SetPath(FormatDisk, paths.Disks)
+	fs.SetPath(FormatDrive, paths.Drives)
+	SetFileSystem(fs)
+}
+
+// SetPath sets the path of a file format. Empty paths are ignored.
+func (dfs *DesktopFileSystem) SetPath(format int, path string) {
+	if path != "" {
+		dfs.subpaths[format] = path
+	}
 }
 
 // LoadFile loads the file data from it's storage location.
@@ -72,6 +101,35 @@ func (dfs *DesktopFileSystem) SaveFile(info *FileInfo) error {
 	return ioutil.WriteFile(info.Path, info.Data, fileMode)
 }
 
+// ReadDir lists the files of a format location
+func (dfs *DesktopFileSystem) ReadDir(format int) ([]*FileInfo, error) {
+	entries, err := ioutil.ReadDir(dfs.subpaths[format])
+	if err != nil {
+		return nil, err
+	}
+	files := make([]*FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Mode().IsRegular() {
+			info := NewFileInfo(filepath.Join(dfs.subpaths[format], entry.Name()))
+			info.Format = format
+			files = append(files, info)
+		}
+	}
+	return files, nil
+}
+
+// RemoveFile removes the file from its format location
+func (dfs *DesktopFileSystem) RemoveFile(info *FileInfo) error {
+	dfs.formatPath(info)
+	return os.Remove(info.Path)
+}
+
+// RenameFile renames the file in its format location
+func (dfs *DesktopFileSystem) RenameFile(info *FileInfo, name string) error {
+	dfs.formatPath(info)
+	return os.Rename(info.Path, filepath.Join(dfs.subpaths[info.Format], filepath.Base(name)))
+}
+
 // stat checks exists file in default folders
 func (dfs *DesktopFileSystem) stat(info *FileInfo) error {
 	// check for file
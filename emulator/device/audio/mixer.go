@@ -0,0 +1,51 @@
+package audio
+
+// -----------------------------------------------------------------------------
+// Mixer
+// -----------------------------------------------------------------------------
+
+// Mixer mixes the audio of several devices. All devices must have the same
+// audio frequency and frames per second.
+type Mixer struct {
+	devices []Audio // Mixed audio devices
+	buffer  *Buffer // Audio buffer
+}
+
+// NewMixer creates a mixer of devices
+func NewMixer(devices ...Audio) *Mixer {
+	mixer := new(Mixer)
+	mixer.devices = devices
+	mixer.buffer = NewBuffer(devices[0].Config().Samples)
+	return mixer
+}
+
+// Config the audio configuration of the first device
+func (mixer *Mixer) Config() *Config { return mixer.devices[0].Config() }
+
+// Device interface
+
+// Init initializes the mixer
+func (mixer *Mixer) Init() { mixer.Reset() }
+
+// Reset resets the mixer
+func (mixer *Mixer) Reset() { mixer.buffer.Reset() }
+
+// Audio interface
+
+// Buffer gets audio buffer
+func (mixer *Mixer) Buffer() *Buffer { return mixer.buffer }
+
+// EndFrame ends the frame of all devices and mixes their samples
+func (mixer *Mixer) EndFrame() {
+	samples := mixer.buffer.Samples()
+	for _, device := range mixer.devices {
+		device.EndFrame()
+		buffer := device.Buffer()
+		for i, sample := range buffer.Samples() {
+			if i < len(samples) {
+				samples[i] += sample
+			}
+		}
+		buffer.Reset()
+	}
+}
@@ -0,0 +1,377 @@
+package audio
+
+// -----------------------------------------------------------------------------
+// Philips SAA1099 - Sound generator
+// -----------------------------------------------------------------------------
+
+// SAA1099 constants
+const (
+	SAA1099Nreg        = 0x20                      // 32 registers
+	SAA1099Nchannels   = 0x06                      // 6 channels
+	SAA1099Nlevels     = 0x10                      // 16 amplitude levels
+	SAA1099VolumeRange = 0x7fff / SAA1099Nchannels // 32767 / N channels
+	SAA1099Clock       = 62500                     // Emulation clock (8 MHz / 128)
+)
+
+// SAA1099 register constants
+const (
+	SAA1099Amplitude0      = 0x00 // Amplitude of channels 0-5 (0x00-0x05)
+	SAA1099Frequency0      = 0x08 // Frequency of channels 0-5 (0x08-0x0d)
+	SAA1099Octave01        = 0x10 // Octave of channels 0-1, 2-3 and 4-5 (0x10-0x12)
+	SAA1099FrequencyEnable = 0x14
+	SAA1099NoiseEnable     = 0x15
+	SAA1099NoiseClock      = 0x16
+	SAA1099Envelope0       = 0x18
+	SAA1099Envelope1       = 0x19
+	SAA1099Control         = 0x1c
+)
+
+// saa1099Envelopes the envelope levels of the 8 envelope modes. The last
+// 32 steps repeat while the envelope is active.
+var saa1099Envelopes [8][64]byte
+
+func init() {
+	for step := 0; step < 64; step++ {
+		up, down := byte(step&0x0f), byte(15-step&0x0f)
+		cycle := step >> 4
+		envelopes := &saa1099Envelopes
+		envelopes[0][step] = 0  // zero amplitude
+		envelopes[1][step] = 15 // maximum amplitude
+		if cycle == 0 {
+			envelopes[2][step] = down // single decay
+			envelopes[6][step] = up   // single attack
+		}
+		envelopes[3][step] = down // repetitive decay
+		envelopes[7][step] = up   // repetitive attack
+		triangle := up
+		if cycle&0x01 != 0 {
+			triangle = down
+		}
+		if cycle < 2 {
+			envelopes[4][step] = triangle // single triangular
+		}
+		envelopes[5][step] = triangle // repetitive triangular
+	}
+}
+
+// -----------------------------------------------------------------------------
+// SAA1099
+// -----------------------------------------------------------------------------
+
+// SAA1099 is the Philips SAA1099 sound generator : 6 tone channels in two
+// groups of 3, each group with a noise generator and an envelope generator
+// on its third channel. The stereo output is mixed to mono. The chip is
+// emulated at 62.5 KHz, the 8 MHz clock divided by 128.
+type SAA1099 struct {
+	config    *Config
+	buffer    *Buffer
+	registers [SAA1099Nreg]byte
+	selected  byte
+	enabled   bool
+	nsample   float32
+	levels    [2*SAA1099Nlevels - 1]uint16
+	channels  [SAA1099Nchannels]SAA1099Channel
+	noises    [2]SAA1099Noise
+	envelopes [2]SAA1099Envelope
+}
+
+// NewSAA1099 creates a new SAA1099
+func NewSAA1099(config *Config) *SAA1099 {
+	saa := new(SAA1099)
+	saa.config = config
+	saa.buffer = NewBuffer(config.Samples)
+	saa.buffer.SetFilter(NewSmaFilter(3)) // window = 8
+	for l := range saa.levels {
+		saa.levels[l] = uint16(float32(l) / float32(len(saa.levels)-1) * SAA1099VolumeRange * config.Rate)
+	}
+	return saa
+}
+
+// Config returns the audio configuration
+func (saa *SAA1099) Config() *Config { return saa.config }
+
+// Device interface
+
+// Init the SAA1099
+func (saa *SAA1099) Init() { saa.Reset() }
+
+// Reset the SAA1099
+func (saa *SAA1099) Reset() {
+	for i := byte(0); i < SAA1099Nreg; i++ {
+		saa.WriteRegister(i, 0)
+	}
+	saa.selected = 0
+	saa.nsample = 0
+	for i := range saa.channels {
+		saa.channels[i] = SAA1099Channel{}
+	}
+	for i := range saa.noises {
+		saa.noises[i].reset()
+	}
+	for i := range saa.envelopes {
+		saa.envelopes[i].set(0)
+	}
+}
+
+// Audio interface
+
+// Buffer gets audio buffer
+func (saa *SAA1099) Buffer() *Buffer { return saa.buffer }
+
+// EndFrame ends audio frame
+func (saa *SAA1099) EndFrame() { saa.nsample = 0 }
+
+// Register operations
+
+// Selected selected register
+func (saa *SAA1099) Selected() byte { return saa.selected }
+
+// Register gets register value at index
+func (saa *SAA1099) Register(index byte) byte { return saa.registers[index&(SAA1099Nreg-1)] }
+
+// SelectRegister selects the current register. The envelope generators
+// with external clock step on each register selection.
+func (saa *SAA1099) SelectRegister(selected byte) {
+	saa.selected = selected & (SAA1099Nreg - 1)
+	for i := range saa.envelopes {
+		if saa.envelopes[i].external {
+			saa.envelopes[i].onClock()
+		}
+	}
+}
+
+// WriteData writes the selected register
+func (saa *SAA1099) WriteData(data byte) { saa.WriteRegister(saa.selected, data) }
+
+// WriteRegister writes value to register
+func (saa *SAA1099) WriteRegister(register, data byte) {
+	register &= SAA1099Nreg - 1
+	saa.registers[register] = data
+	switch {
+	case register < SAA1099Amplitude0+SAA1099Nchannels:
+		channel := &saa.channels[register]
+		channel.left, channel.right = data&0x0f, data>>4
+	case register >= SAA1099Frequency0 && register < SAA1099Frequency0+SAA1099Nchannels:
+		saa.channels[register-SAA1099Frequency0].frequency = data
+	case register >= SAA1099Octave01 && register < SAA1099Octave01+SAA1099Nchannels/2:
+		index := (register - SAA1099Octave01) << 1
+		saa.channels[index].octave = data & 0x07
+		saa.channels[index+1].octave = data >> 4 & 0x07
+	case register == SAA1099FrequencyEnable:
+		for i := range saa.channels {
+			saa.channels[i].toneEnabled = data>>uint(i)&0x01 != 0
+		}
+	case register == SAA1099NoiseEnable:
+		for i := range saa.channels {
+			saa.channels[i].noiseEnabled = data>>uint(i)&0x01 != 0
+		}
+	case register == SAA1099NoiseClock:
+		saa.noises[0].rate = data & 0x03
+		saa.noises[1].rate = data >> 4 & 0x03
+	case register == SAA1099Envelope0, register == SAA1099Envelope1:
+		saa.envelopes[register-SAA1099Envelope0].set(data)
+	case register == SAA1099Control:
+		saa.enabled = data&0x01 != 0
+		if data&0x02 != 0 { // synchronize the generators
+			for i := range saa.channels {
+				saa.channels[i].counter = 0
+				saa.channels[i].output = false
+			}
+		}
+	}
+}
+
+// Emulation
+
+// Emulate emulates clock cycles
+func (saa *SAA1099) Emulate(cycles int) {
+	for i := 0; i < cycles; i++ {
+		saa.OnClock()
+	}
+}
+
+// OnClock emulates one clock cycle (62.5 KHz)
+func (saa *SAA1099) OnClock() {
+	// tone generators, the channels 1 and 4 clock the envelopes and the
+	// channels 0 and 3 can clock the noise generators
+	for i := range saa.channels {
+		toggles := saa.channels[i].onClock()
+		if toggles == 0 {
+			continue
+		}
+		switch i {
+		case 0, 3:
+			if noise := &saa.noises[i/3]; noise.rate == 3 {
+				noise.step(toggles)
+			}
+		case 1, 4:
+			if envelope := &saa.envelopes[i/3]; !envelope.external && saa.channels[i].output {
+				envelope.onClock()
+			}
+		}
+	}
+	for i := range saa.noises {
+		saa.noises[i].onClock()
+	}
+	// create audio sample
+	mix := uint16(0)
+	if saa.enabled {
+		for i := range saa.channels {
+			mix += saa.channelLevel(i)
+		}
+	}
+	saa.buffer.AddSample(int(saa.nsample), mix)
+	saa.nsample += saa.config.Rate
+}
+
+// channelLevel the left and right output level of a channel. Noise is
+// mixed at half amplitude with the tone.
+func (saa *SAA1099) channelLevel(index int) uint16 {
+	channel := &saa.channels[index]
+	noise := saa.noises[index/3].output()
+	left, right := channel.left, channel.right
+	if index == 2 || index == 5 {
+		if envelope := &saa.envelopes[index/3]; envelope.enabled {
+			left, right = envelope.apply(left, right)
+		}
+	}
+	var level byte
+	switch {
+	case channel.toneEnabled && channel.noiseEnabled:
+		if channel.output {
+			level = left + right
+		} else if noise {
+			level = (left + right) >> 1
+		}
+	case channel.toneEnabled:
+		if channel.output {
+			level = left + right
+		}
+	case channel.noiseEnabled:
+		if noise {
+			level = left + right
+		}
+	}
+	return saa.levels[level]
+}
+
+// -----------------------------------------------------------------------------
+// SAA1099 - Channel
+// -----------------------------------------------------------------------------
+
+// SAA1099Channel tone channel : the frequency is 15625 * 2^octave /
+// (511 - frequency) Hz
+type SAA1099Channel struct {
+	left         byte
+	right        byte
+	frequency    byte
+	octave       byte
+	toneEnabled  bool
+	noiseEnabled bool
+	output       bool
+	counter      int
+}
+
+// onClock steps the tone generator, returns the output toggles
+func (c *SAA1099Channel) onClock() int {
+	period := (511 - int(c.frequency)) << 1
+	c.counter += 1 << c.octave
+	toggles := 0
+	for c.counter >= period {
+		c.counter -= period
+		c.output = !c.output
+		toggles++
+	}
+	return toggles
+}
+
+// -----------------------------------------------------------------------------
+// SAA1099 - Noise
+// -----------------------------------------------------------------------------
+
+// SAA1099Noise noise generator : clocked at 31.25, 15.6 or 7.8 KHz, or by
+// the tone of the group first channel
+type SAA1099Noise struct {
+	rate    byte
+	counter byte
+	level   uint32
+}
+
+func (n *SAA1099Noise) reset() {
+	n.rate = 0
+	n.counter = 0
+	n.level = 1
+}
+
+func (n *SAA1099Noise) output() bool { return n.level&0x01 != 0 }
+
+func (n *SAA1099Noise) onClock() {
+	if n.rate == 3 {
+		return
+	}
+	n.counter++
+	if n.counter >= 2<<n.rate {
+		n.counter = 0
+		n.step(1)
+	}
+}
+
+// step shifts the noise generator
+// https://github.com/mamedev/mame/blob/master/src/devices/sound/saa1099.cpp
+func (n *SAA1099Noise) step(steps int) {
+	for i := 0; i < steps; i++ {
+		if (n.level&0x4000 == 0) == (n.level&0x0040 == 0) {
+			n.level = n.level<<1 | 1
+		} else {
+			n.level <<= 1
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// SAA1099 - Envelope
+// -----------------------------------------------------------------------------
+
+// SAA1099Envelope envelope generator of the group third channel
+type SAA1099Envelope struct {
+	enabled  bool
+	external bool
+	mirror   bool
+	coarse   bool
+	mode     byte
+	step     int
+}
+
+// set sets the envelope control register
+func (e *SAA1099Envelope) set(data byte) {
+	e.enabled = data&0x80 != 0
+	e.external = data&0x20 != 0
+	e.coarse = data&0x10 != 0
+	e.mode = data >> 1 & 0x07
+	e.mirror = data&0x01 != 0
+	e.step = 0
+}
+
+func (e *SAA1099Envelope) onClock() {
+	e.step++
+	if e.coarse {
+		e.step++ // 3 bit resolution
+	}
+	if e.step >= 64 {
+		e.step -= 32
+	}
+}
+
+// apply applies the envelope to the channel amplitudes. The right channel
+// is inverted on mirror mode.
+func (e *SAA1099Envelope) apply(left, right byte) (byte, byte) {
+	level := saa1099Envelopes[e.mode][e.step]
+	if e.coarse {
+		level &^= 0x01
+	}
+	rlevel := level
+	if e.mirror {
+		rlevel = 15 - level
+	}
+	return left * level / 15, right * rlevel / 15
+}
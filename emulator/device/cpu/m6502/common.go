@@ -0,0 +1,202 @@
+package m6502
+
+// -----------------------------------------------------------------------------
+// Constants and tables
+// -----------------------------------------------------------------------------
+
+// Constants
+const (
+	// Flags constants
+	FlagC byte = 0x01 // Carry
+	FlagZ byte = 0x02 // Zero
+	FlagI byte = 0x04 // IRQ disable
+	FlagD byte = 0x08 // Decimal mode
+	FlagB byte = 0x10 // Break (only in the pushed status)
+	FlagU byte = 0x20 // Unused (always set)
+	FlagV byte = 0x40 // Overflow
+	FlagN byte = 0x80 // Negative
+
+	// Vectors and stack
+	vectorNMI   uint16 = 0xfffa
+	vectorReset uint16 = 0xfffc
+	vectorIRQ   uint16 = 0xfffe
+	stackBase   uint16 = 0x0100
+
+	// Unstable opcodes magic constant (ANE & LXA)
+	magicConstant byte = 0xee
+)
+
+// -----------------------------------------------------------------------------
+// Common functions
+// -----------------------------------------------------------------------------
+
+// read reads a byte from memory, a bus cycle
+func (m6502 *M6502) read(address uint16) byte {
+	data := m6502.mem.Read(address)
+	m6502.clock.Inc()
+	return data
+}
+
+// write writes a byte to memory, a bus cycle
+func (m6502 *M6502) write(address uint16, value byte) {
+	m6502.mem.Write(address, value)
+	m6502.clock.Inc()
+}
+
+// readPC reads a byte from pc address and increments PC
+func (m6502 *M6502) readPC() byte {
+	data := m6502.read(m6502.PC)
+	m6502.PC++
+	return data
+}
+
+// readWordPC reads a word from pc address and increments PC
+func (m6502 *M6502) readWordPC() uint16 {
+	lo := m6502.readPC()
+	hi := m6502.readPC()
+	return toword(lo, hi)
+}
+
+// push pushes a byte into the stack
+func (m6502 *M6502) push(value byte) {
+	m6502.write(stackBase|uint16(m6502.S), value)
+	m6502.S--
+}
+
+// pull pulls a byte from the stack
+func (m6502 *M6502) pull() byte {
+	m6502.S++
+	return m6502.read(stackBase | uint16(m6502.S))
+}
+
+// peekStack reads the stack top, a dummy read
+func (m6502 *M6502) peekStack() {
+	m6502.read(stackBase | uint16(m6502.S))
+}
+
+// pushStatus pushes the status and jumps to the vector, for BRK, IRQ and NMI.
+// A pending NMI hijacks the vector of BRK and IRQ.
+func (m6502 *M6502) pushStatus(brk bool, vector uint16) {
+	status := m6502.P | FlagU
+	if brk {
+		status |= FlagB
+	}
+	if m6502.NmiPending {
+		vector = vectorNMI
+		m6502.NmiPending = false
+	}
+	m6502.push(status)
+	m6502.P |= FlagI
+	lo := m6502.read(vector)
+	hi := m6502.read(vector + 1)
+	m6502.PC = toword(lo, hi)
+}
+
+// setStatus sets the status register pulled from the stack
+func (m6502 *M6502) setStatus(value byte) {
+	m6502.P = value&^FlagB | FlagU
+}
+
+// setNZ sets the negative and zero flags of a value
+func (m6502 *M6502) setNZ(value byte) {
+	m6502.P &^= FlagN | FlagZ
+	m6502.P |= value & FlagN
+	if value == 0 {
+		m6502.P |= FlagZ
+	}
+}
+
+// setFlag sets or clears a flag
+func (m6502 *M6502) setFlag(flag byte, value bool) {
+	if value {
+		m6502.P |= flag
+	} else {
+		m6502.P &^= flag
+	}
+}
+
+// carry gets the carry flag as a value
+func (m6502 *M6502) carry() byte {
+	return m6502.P & FlagC
+}
+
+// -----------------------------------------------------------------------------
+// Addressing modes : effective address calculation bus cycles
+// -----------------------------------------------------------------------------
+
+// addrZeroPage zero page address
+func (m6502 *M6502) addrZeroPage() uint16 {
+	return uint16(m6502.readPC())
+}
+
+// addrZeroPageIndex zero page indexed address, wraps in the zero page
+func (m6502 *M6502) addrZeroPageIndex(index byte) uint16 {
+	base := m6502.readPC()
+	m6502.read(uint16(base)) // dummy read
+	return uint16(base + index)
+}
+
+// addrAbsolute absolute address
+func (m6502 *M6502) addrAbsolute() uint16 {
+	return m6502.readWordPC()
+}
+
+// addrAbsoluteIndex absolute indexed address. The address is read with the
+// uncorrected high byte when the page is crossed, and always for writes.
+func (m6502 *M6502) addrAbsoluteIndex(index byte, write bool) uint16 {
+	base := m6502.readWordPC()
+	return m6502.indexAddress(base, index, write)
+}
+
+// addrIndirectX indexed indirect address (zp,X)
+func (m6502 *M6502) addrIndirectX() uint16 {
+	ptr := m6502.readPC()
+	m6502.read(uint16(ptr)) // dummy read
+	ptr += m6502.X
+	lo := m6502.read(uint16(ptr))
+	hi := m6502.read(uint16(ptr + 1))
+	return toword(lo, hi)
+}
+
+// addrIndirectY indirect indexed address (zp),Y
+func (m6502 *M6502) addrIndirectY(write bool) uint16 {
+	base := m6502.pointerY()
+	return m6502.indexAddress(base, m6502.Y, write)
+}
+
+// pointerY reads the base address of the (zp),Y mode
+func (m6502 *M6502) pointerY() uint16 {
+	ptr := m6502.readPC()
+	lo := m6502.read(uint16(ptr))
+	hi := m6502.read(uint16(ptr + 1))
+	return toword(lo, hi)
+}
+
+// indexAddress adds the index to the base address, with the dummy read of
+// the uncorrected address
+func (m6502 *M6502) indexAddress(base uint16, index byte, write bool) uint16 {
+	address := base + uint16(index)
+	if write || address&0xff00 != base&0xff00 {
+		m6502.read(base&0xff00 | address&0x00ff) // dummy read
+	}
+	return address
+}
+
+// -----------------------------------------------------------------------------
+// Helper functions
+// -----------------------------------------------------------------------------
+
+// toword builds a word from low and high bytes
+func toword(lo, hi byte) uint16 {
+	return uint16(lo) | uint16(hi)<<8
+}
+
+// lowbyte gets the low byte of a word
+func lowbyte(value uint16) byte {
+	return byte(value)
+}
+
+// highbyte gets the high byte of a word
+func highbyte(value uint16) byte {
+	return byte(value >> 8)
+}
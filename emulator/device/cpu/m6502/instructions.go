@@ -0,0 +1,294 @@
+package m6502
+
+// -----------------------------------------------------------------------------
+// Instructions
+// -----------------------------------------------------------------------------
+
+// idle is the dummy read of the next opcode of one byte instructions
+func (m6502 *M6502) idle() {
+	m6502.read(m6502.PC)
+}
+
+// branch executes a relative branch. A taken branch adds a cycle, and
+// another when the page is crossed.
+func (m6502 *M6502) branch(condition bool) {
+	offset := m6502.readPC()
+	if !condition {
+		return
+	}
+	m6502.read(m6502.PC) // dummy read
+	target := m6502.PC + uint16(int8(offset))
+	if target&0xff00 != m6502.PC&0xff00 {
+		m6502.read(m6502.PC&0xff00 | target&0x00ff) // dummy read
+	}
+	m6502.PC = target
+}
+
+// rmw executes a read-modify-write instruction. The unmodified value is
+// written back before the result.
+func (m6502 *M6502) rmw(address uint16, operation func(byte) byte) {
+	value := m6502.read(address)
+	m6502.write(address, value) // dummy write
+	m6502.write(address, operation(value))
+}
+
+// sh stores the value and the high byte of the base address plus one. The
+// high byte of the address is corrupted when the page is crossed.
+func (m6502 *M6502) sh(address, base uint16, value byte) {
+	value &= highbyte(base) + 1
+	if address&0xff00 != base&0xff00 {
+		address = uint16(value)<<8 | address&0x00ff
+	}
+	m6502.write(address, value)
+}
+
+// Loads & logical
+
+func (m6502 *M6502) lda(value byte) {
+	m6502.A = value
+	m6502.setNZ(value)
+}
+
+func (m6502 *M6502) ldx(value byte) {
+	m6502.X = value
+	m6502.setNZ(value)
+}
+
+func (m6502 *M6502) ldy(value byte) {
+	m6502.Y = value
+	m6502.setNZ(value)
+}
+
+func (m6502 *M6502) ora(value byte) {
+	m6502.A |= value
+	m6502.setNZ(m6502.A)
+}
+
+func (m6502 *M6502) and(value byte) {
+	m6502.A &= value
+	m6502.setNZ(m6502.A)
+}
+
+func (m6502 *M6502) eor(value byte) {
+	m6502.A ^= value
+	m6502.setNZ(m6502.A)
+}
+
+func (m6502 *M6502) bit(value byte) {
+	m6502.P &^= FlagN | FlagV | FlagZ
+	m6502.P |= value & (FlagN | FlagV)
+	if m6502.A&value == 0 {
+		m6502.P |= FlagZ
+	}
+}
+
+// Arithmetic
+
+// adc adds with carry. The NMOS decimal mode sets N and V from the
+// intermediate result and Z from the binary result.
+func (m6502 *M6502) adc(value byte) {
+	carry := m6502.carry()
+	if m6502.P&FlagD == 0 {
+		sum := uint16(m6502.A) + uint16(value) + uint16(carry)
+		result := byte(sum)
+		m6502.setFlag(FlagV, ^(m6502.A^value)&(m6502.A^result)&0x80 != 0)
+		m6502.setFlag(FlagC, sum > 0xff)
+		m6502.A = result
+		m6502.setNZ(result)
+		return
+	}
+	low := int(m6502.A&0x0f) + int(value&0x0f) + int(carry)
+	if low >= 0x0a {
+		low = ((low + 0x06) & 0x0f) + 0x10
+	}
+	signed := int(int8(m6502.A&0xf0)) + int(int8(value&0xf0)) + low
+	sum := int(m6502.A&0xf0) + int(value&0xf0) + low
+	if sum >= 0xa0 {
+		sum += 0x60
+	}
+	m6502.setNZ(m6502.A + value + carry)
+	m6502.setFlag(FlagN, signed&0x80 != 0)
+	m6502.setFlag(FlagV, signed < -128 || signed > 127)
+	m6502.setFlag(FlagC, sum >= 0x100)
+	m6502.A = byte(sum)
+}
+
+// sbc subtracts with borrow. The NMOS decimal mode sets the flags from the
+// binary result.
+func (m6502 *M6502) sbc(value byte) {
+	borrow := 1 - int(m6502.carry())
+	diff := int(m6502.A) - int(value) - borrow
+	result := byte(diff)
+	m6502.setFlag(FlagV, (m6502.A^value)&(m6502.A^result)&0x80 != 0)
+	m6502.setFlag(FlagC, diff >= 0)
+	m6502.setNZ(result)
+	if m6502.P&FlagD != 0 {
+		low := int(m6502.A&0x0f) - int(value&0x0f) - borrow
+		if low < 0 {
+			low = ((low - 0x06) & 0x0f) - 0x10
+		}
+		diff = int(m6502.A&0xf0) - int(value&0xf0) + low
+		if diff < 0 {
+			diff -= 0x60
+		}
+		result = byte(diff)
+	}
+	m6502.A = result
+}
+
+// compare compares a register with a value
+func (m6502 *M6502) compare(register, value byte) {
+	m6502.setFlag(FlagC, register >= value)
+	m6502.setNZ(register - value)
+}
+
+func (m6502 *M6502) cmpA(value byte) { m6502.compare(m6502.A, value) }
+
+func (m6502 *M6502) cmpX(value byte) { m6502.compare(m6502.X, value) }
+
+func (m6502 *M6502) cmpY(value byte) { m6502.compare(m6502.Y, value) }
+
+// Shifts, rotations, increments & decrements
+
+func (m6502 *M6502) asl(value byte) byte {
+	m6502.setFlag(FlagC, value&0x80 != 0)
+	value <<= 1
+	m6502.setNZ(value)
+	return value
+}
+
+func (m6502 *M6502) lsr(value byte) byte {
+	m6502.setFlag(FlagC, value&0x01 != 0)
+	value >>= 1
+	m6502.setNZ(value)
+	return value
+}
+
+func (m6502 *M6502) rol(value byte) byte {
+	carry := m6502.carry()
+	m6502.setFlag(FlagC, value&0x80 != 0)
+	value = value<<1 | carry
+	m6502.setNZ(value)
+	return value
+}
+
+func (m6502 *M6502) ror(value byte) byte {
+	carry := m6502.carry() << 7
+	m6502.setFlag(FlagC, value&0x01 != 0)
+	value = value>>1 | carry
+	m6502.setNZ(value)
+	return value
+}
+
+func (m6502 *M6502) inc(value byte) byte {
+	value++
+	m6502.setNZ(value)
+	return value
+}
+
+func (m6502 *M6502) dec(value byte) byte {
+	value--
+	m6502.setNZ(value)
+	return value
+}
+
+// Undocumented read-modify-write instructions
+
+func (m6502 *M6502) slo(value byte) byte {
+	value = m6502.asl(value)
+	m6502.ora(value)
+	return value
+}
+
+func (m6502 *M6502) rla(value byte) byte {
+	value = m6502.rol(value)
+	m6502.and(value)
+	return value
+}
+
+func (m6502 *M6502) sre(value byte) byte {
+	value = m6502.lsr(value)
+	m6502.eor(value)
+	return value
+}
+
+func (m6502 *M6502) rra(value byte) byte {
+	value = m6502.ror(value)
+	m6502.adc(value)
+	return value
+}
+
+func (m6502 *M6502) dcp(value byte) byte {
+	value--
+	m6502.compare(m6502.A, value)
+	return value
+}
+
+func (m6502 *M6502) isc(value byte) byte {
+	value++
+	m6502.sbc(value)
+	return value
+}
+
+// Undocumented read instructions
+
+func (m6502 *M6502) lax(value byte) {
+	m6502.A = value
+	m6502.X = value
+	m6502.setNZ(value)
+}
+
+func (m6502 *M6502) las(value byte) {
+	value &= m6502.S
+	m6502.S = value
+	m6502.lax(value)
+}
+
+func (m6502 *M6502) anc(value byte) {
+	m6502.and(value)
+	m6502.setFlag(FlagC, m6502.A&0x80 != 0)
+}
+
+func (m6502 *M6502) alr(value byte) {
+	m6502.A = m6502.lsr(m6502.A & value)
+}
+
+// arr ANDs and rotates right, with the NMOS decimal mode fix ups
+func (m6502 *M6502) arr(value byte) {
+	and := m6502.A & value
+	result := and>>1 | m6502.carry()<<7
+	if m6502.P&FlagD == 0 {
+		m6502.A = result
+		m6502.setNZ(result)
+		m6502.setFlag(FlagC, result&0x40 != 0)
+		m6502.setFlag(FlagV, (result>>6^result>>5)&0x01 != 0)
+		return
+	}
+	m6502.setNZ(result)
+	m6502.setFlag(FlagV, (and^result)&0x40 != 0)
+	if and&0x0f+and&0x01 > 0x05 {
+		result = result&0xf0 | (result+0x06)&0x0f
+	}
+	high := and >> 4
+	m6502.setFlag(FlagC, high+high&0x01 > 0x05)
+	if m6502.P&FlagC != 0 {
+		result += 0x60
+	}
+	m6502.A = result
+}
+
+func (m6502 *M6502) sbx(value byte) {
+	and := m6502.A & m6502.X
+	m6502.setFlag(FlagC, and >= value)
+	m6502.X = and - value
+	m6502.setNZ(m6502.X)
+}
+
+func (m6502 *M6502) ane(value byte) {
+	m6502.A = (m6502.A | magicConstant) & m6502.X & value
+	m6502.setNZ(m6502.A)
+}
+
+func (m6502 *M6502) lxa(value byte) {
+	m6502.lax((m6502.A | magicConstant) & value)
+}
@@ -0,0 +1,119 @@
+// Package m6502 a MOS 6502 CPU emulator
+package m6502
+
+import (
+	"github.com/jtruco/emu8/emulator/device"
+	"github.com/jtruco/emu8/emulator/device/bus"
+)
+
+// -----------------------------------------------------------------------------
+// M6502 - MOS Technology 6502 CPU
+// -----------------------------------------------------------------------------
+
+// M6502 the NMOS 6502 CPU, also the core of the 6510 and 8502. Every cycle
+// is a memory bus access, dummy reads and writes included. The 6510 I/O
+// port at 0x0000 and 0x0001 is mapped by the machine memory.
+type M6502 struct {
+	State              // 6502 State
+	clock device.Clock // Clock device
+	mem   bus.Bus      // Memory data bus
+}
+
+// New creates a new 6502
+func New(clock device.Clock, mem bus.Bus) *M6502 {
+	m6502 := new(M6502)
+	m6502.clock = clock
+	m6502.mem = mem
+	m6502.State.HardReset()
+	return m6502
+}
+
+// Clock gets the Cpu Clock
+func (m6502 *M6502) Clock() device.Clock {
+	return m6502.clock
+}
+
+// Memory gets the Cpu Memory bus
+func (m6502 *M6502) Memory() bus.Bus {
+	return m6502.mem
+}
+
+// Init initializes Cpu (power-on)
+func (m6502 *M6502) Init() {
+	m6502.State.HardReset()
+}
+
+// Reset (soft) resets Cpu
+func (m6502 *M6502) Reset() {
+	m6502.State.SoftReset()
+}
+
+// Execute executes one instruction, or the reset and interrupt sequences
+func (m6502 *M6502) Execute() int {
+	tstate := m6502.clock.Tstates()
+	switch {
+	case m6502.ResetRq:
+		m6502.reset()
+	case m6502.Jammed:
+		m6502.read(0xffff) // bus stuck
+	case m6502.NmiPending:
+		m6502.interrupt(vectorNMI)
+	case m6502.IntRq && !m6502.IntMask:
+		m6502.interrupt(vectorIRQ)
+	default:
+		opcode := m6502.readPC()
+		m6502.execute(opcode)
+		if opcode == 0x58 || opcode == 0x78 || opcode == 0x28 {
+			return m6502.clock.Tstates() - tstate // CLI, SEI & PLP : poll delayed
+		}
+	}
+	m6502.IntMask = m6502.P&FlagI != 0
+	return m6502.clock.Tstates() - tstate
+}
+
+// InterruptRequest sets the IRQ line, the interrupt is taken while the line
+// is active and the I flag is clear
+func (m6502 *M6502) InterruptRequest(request bool) {
+	m6502.IntRq = request
+}
+
+// NMInterruptRequest sets the NMI line, the interrupt is taken on its edge
+func (m6502 *M6502) NMInterruptRequest(request bool) {
+	if request && !m6502.NmiRq {
+		m6502.NmiPending = true
+	}
+	m6502.NmiRq = request
+}
+
+// Rts returns from the current subroutine. Used by machine ROM traps.
+func (m6502 *M6502) Rts() {
+	lo := m6502.pull()
+	hi := m6502.pull()
+	m6502.PC = toword(lo, hi) + 1
+}
+
+// reset executes the reset sequence : the stack writes are reads
+func (m6502 *M6502) reset() {
+	m6502.read(m6502.PC)
+	m6502.read(m6502.PC)
+	for i := 0; i < 3; i++ {
+		m6502.read(stackBase | uint16(m6502.S))
+		m6502.S--
+	}
+	m6502.P |= FlagI
+	lo := m6502.read(vectorReset)
+	hi := m6502.read(vectorReset + 1)
+	m6502.PC = toword(lo, hi)
+	m6502.ResetRq = false
+	m6502.Jammed = false
+	m6502.IntMask = true
+}
+
+// interrupt executes the IRQ and NMI sequence
+func (m6502 *M6502) interrupt(vector uint16) {
+	m6502.read(m6502.PC)
+	m6502.read(m6502.PC)
+	m6502.push(highbyte(m6502.PC))
+	m6502.push(lowbyte(m6502.PC))
+	m6502.pushStatus(false, vector)
+}
@@ -0,0 +1,841 @@
+package m6502
+
+// execute decodes and executes 6502 opcodes, documented and undocumented
+func (m6502 *M6502) execute(opcode byte) {
+
+	switch opcode {
+
+	case 0x00: // BRK
+		m6502.readPC() // padding byte
+		m6502.push(highbyte(m6502.PC))
+		m6502.push(lowbyte(m6502.PC))
+		m6502.pushStatus(true, vectorIRQ)
+
+	case 0x01: // ORA (nn,X)
+		m6502.ora(m6502.read(m6502.addrIndirectX()))
+
+	case 0x02: // JAM
+		m6502.Jammed = true
+
+	case 0x03: // SLO (nn,X)
+		m6502.rmw(m6502.addrIndirectX(), m6502.slo)
+
+	case 0x04: // NOP nn
+		m6502.read(m6502.addrZeroPage())
+
+	case 0x05: // ORA nn
+		m6502.ora(m6502.read(m6502.addrZeroPage()))
+
+	case 0x06: // ASL nn
+		m6502.rmw(m6502.addrZeroPage(), m6502.asl)
+
+	case 0x07: // SLO nn
+		m6502.rmw(m6502.addrZeroPage(), m6502.slo)
+
+	case 0x08: // PHP
+		m6502.idle()
+		m6502.push(m6502.P | FlagB | FlagU)
+
+	case 0x09: // ORA #nn
+		m6502.ora(m6502.readPC())
+
+	case 0x0a: // ASL A
+		m6502.idle()
+		m6502.A = m6502.asl(m6502.A)
+
+	case 0x0b: // ANC #nn
+		m6502.anc(m6502.readPC())
+
+	case 0x0c: // NOP nnnn
+		m6502.read(m6502.addrAbsolute())
+
+	case 0x0d: // ORA nnnn
+		m6502.ora(m6502.read(m6502.addrAbsolute()))
+
+	case 0x0e: // ASL nnnn
+		m6502.rmw(m6502.addrAbsolute(), m6502.asl)
+
+	case 0x0f: // SLO nnnn
+		m6502.rmw(m6502.addrAbsolute(), m6502.slo)
+
+	case 0x10: // BPL nn
+		m6502.branch(m6502.P&FlagN == 0)
+
+	case 0x11: // ORA (nn),Y
+		m6502.ora(m6502.read(m6502.addrIndirectY(false)))
+
+	case 0x12: // JAM
+		m6502.Jammed = true
+
+	case 0x13: // SLO (nn),Y
+		m6502.rmw(m6502.addrIndirectY(true), m6502.slo)
+
+	case 0x14: // NOP nn,X
+		m6502.read(m6502.addrZeroPageIndex(m6502.X))
+
+	case 0x15: // ORA nn,X
+		m6502.ora(m6502.read(m6502.addrZeroPageIndex(m6502.X)))
+
+	case 0x16: // ASL nn,X
+		m6502.rmw(m6502.addrZeroPageIndex(m6502.X), m6502.asl)
+
+	case 0x17: // SLO nn,X
+		m6502.rmw(m6502.addrZeroPageIndex(m6502.X), m6502.slo)
+
+	case 0x18: // CLC
+		m6502.idle()
+		m6502.P &^= FlagC
+
+	case 0x19: // ORA nnnn,Y
+		m6502.ora(m6502.read(m6502.addrAbsoluteIndex(m6502.Y, false)))
+
+	case 0x1a: // NOP
+		m6502.idle()
+
+	case 0x1b: // SLO nnnn,Y
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.Y, true), m6502.slo)
+
+	case 0x1c: // NOP nnnn,X
+		m6502.read(m6502.addrAbsoluteIndex(m6502.X, false))
+
+	case 0x1d: // ORA nnnn,X
+		m6502.ora(m6502.read(m6502.addrAbsoluteIndex(m6502.X, false)))
+
+	case 0x1e: // ASL nnnn,X
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.X, true), m6502.asl)
+
+	case 0x1f: // SLO nnnn,X
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.X, true), m6502.slo)
+
+	case 0x20: // JSR nnnn
+		lo := m6502.readPC()
+		m6502.peekStack()
+		m6502.push(highbyte(m6502.PC))
+		m6502.push(lowbyte(m6502.PC))
+		hi := m6502.read(m6502.PC)
+		m6502.PC = toword(lo, hi)
+
+	case 0x21: // AND (nn,X)
+		m6502.and(m6502.read(m6502.addrIndirectX()))
+
+	case 0x22: // JAM
+		m6502.Jammed = true
+
+	case 0x23: // RLA (nn,X)
+		m6502.rmw(m6502.addrIndirectX(), m6502.rla)
+
+	case 0x24: // BIT nn
+		m6502.bit(m6502.read(m6502.addrZeroPage()))
+
+	case 0x25: // AND nn
+		m6502.and(m6502.read(m6502.addrZeroPage()))
+
+	case 0x26: // ROL nn
+		m6502.rmw(m6502.addrZeroPage(), m6502.rol)
+
+	case 0x27: // RLA nn
+		m6502.rmw(m6502.addrZeroPage(), m6502.rla)
+
+	case 0x28: // PLP
+		m6502.idle()
+		m6502.peekStack()
+		m6502.setStatus(m6502.pull())
+
+	case 0x29: // AND #nn
+		m6502.and(m6502.readPC())
+
+	case 0x2a: // ROL A
+		m6502.idle()
+		m6502.A = m6502.rol(m6502.A)
+
+	case 0x2b: // ANC #nn
+		m6502.anc(m6502.readPC())
+
+	case 0x2c: // BIT nnnn
+		m6502.bit(m6502.read(m6502.addrAbsolute()))
+
+	case 0x2d: // AND nnnn
+		m6502.and(m6502.read(m6502.addrAbsolute()))
+
+	case 0x2e: // ROL nnnn
+		m6502.rmw(m6502.addrAbsolute(), m6502.rol)
+
+	case 0x2f: // RLA nnnn
+		m6502.rmw(m6502.addrAbsolute(), m6502.rla)
+
+	case 0x30: // BMI nn
+		m6502.branch(m6502.P&FlagN != 0)
+
+	case 0x31: // AND (nn),Y
+		m6502.and(m6502.read(m6502.addrIndirectY(false)))
+
+	case 0x32: // JAM
+		m6502.Jammed = true
+
+	case 0x33: // RLA (nn),Y
+		m6502.rmw(m6502.addrIndirectY(true), m6502.rla)
+
+	case 0x34: // NOP nn,X
+		m6502.read(m6502.addrZeroPageIndex(m6502.X))
+
+	case 0x35: // AND nn,X
+		m6502.and(m6502.read(m6502.addrZeroPageIndex(m6502.X)))
+
+	case 0x36: // ROL nn,X
+		m6502.rmw(m6502.addrZeroPageIndex(m6502.X), m6502.rol)
+
+	case 0x37: // RLA nn,X
+		m6502.rmw(m6502.addrZeroPageIndex(m6502.X), m6502.rla)
+
+	case 0x38: // SEC
+		m6502.idle()
+		m6502.P |= FlagC
+
+	case 0x39: // AND nnnn,Y
+		m6502.and(m6502.read(m6502.addrAbsoluteIndex(m6502.Y, false)))
+
+	case 0x3a: // NOP
+		m6502.idle()
+
+	case 0x3b: // RLA nnnn,Y
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.Y, true), m6502.rla)
+
+	case 0x3c: // NOP nnnn,X
+		m6502.read(m6502.addrAbsoluteIndex(m6502.X, false))
+
+	case 0x3d: // AND nnnn,X
+		m6502.and(m6502.read(m6502.addrAbsoluteIndex(m6502.X, false)))
+
+	case 0x3e: // ROL nnnn,X
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.X, true), m6502.rol)
+
+	case 0x3f: // RLA nnnn,X
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.X, true), m6502.rla)
+
+	case 0x40: // RTI
+		m6502.idle()
+		m6502.peekStack()
+		m6502.setStatus(m6502.pull())
+		lo := m6502.pull()
+		hi := m6502.pull()
+		m6502.PC = toword(lo, hi)
+
+	case 0x41: // EOR (nn,X)
+		m6502.eor(m6502.read(m6502.addrIndirectX()))
+
+	case 0x42: // JAM
+		m6502.Jammed = true
+
+	case 0x43: // SRE (nn,X)
+		m6502.rmw(m6502.addrIndirectX(), m6502.sre)
+
+	case 0x44: // NOP nn
+		m6502.read(m6502.addrZeroPage())
+
+	case 0x45: // EOR nn
+		m6502.eor(m6502.read(m6502.addrZeroPage()))
+
+	case 0x46: // LSR nn
+		m6502.rmw(m6502.addrZeroPage(), m6502.lsr)
+
+	case 0x47: // SRE nn
+		m6502.rmw(m6502.addrZeroPage(), m6502.sre)
+
+	case 0x48: // PHA
+		m6502.idle()
+		m6502.push(m6502.A)
+
+	case 0x49: // EOR #nn
+		m6502.eor(m6502.readPC())
+
+	case 0x4a: // LSR A
+		m6502.idle()
+		m6502.A = m6502.lsr(m6502.A)
+
+	case 0x4b: // ALR #nn
+		m6502.alr(m6502.readPC())
+
+	case 0x4c: // JMP nnnn
+		m6502.PC = m6502.readWordPC()
+
+	case 0x4d: // EOR nnnn
+		m6502.eor(m6502.read(m6502.addrAbsolute()))
+
+	case 0x4e: // LSR nnnn
+		m6502.rmw(m6502.addrAbsolute(), m6502.lsr)
+
+	case 0x4f: // SRE nnnn
+		m6502.rmw(m6502.addrAbsolute(), m6502.sre)
+
+	case 0x50: // BVC nn
+		m6502.branch(m6502.P&FlagV == 0)
+
+	case 0x51: // EOR (nn),Y
+		m6502.eor(m6502.read(m6502.addrIndirectY(false)))
+
+	case 0x52: // JAM
+		m6502.Jammed = true
+
+	case 0x53: // SRE (nn),Y
+		m6502.rmw(m6502.addrIndirectY(true), m6502.sre)
+
+	case 0x54: // NOP nn,X
+		m6502.read(m6502.addrZeroPageIndex(m6502.X))
+
+	case 0x55: // EOR nn,X
+		m6502.eor(m6502.read(m6502.addrZeroPageIndex(m6502.X)))
+
+	case 0x56: // LSR nn,X
+		m6502.rmw(m6502.addrZeroPageIndex(m6502.X), m6502.lsr)
+
+	case 0x57: // SRE nn,X
+		m6502.rmw(m6502.addrZeroPageIndex(m6502.X), m6502.sre)
+
+	case 0x58: // CLI
+		m6502.idle()
+		m6502.P &^= FlagI
+
+	case 0x59: // EOR nnnn,Y
+		m6502.eor(m6502.read(m6502.addrAbsoluteIndex(m6502.Y, false)))
+
+	case 0x5a: // NOP
+		m6502.idle()
+
+	case 0x5b: // SRE nnnn,Y
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.Y, true), m6502.sre)
+
+	case 0x5c: // NOP nnnn,X
+		m6502.read(m6502.addrAbsoluteIndex(m6502.X, false))
+
+	case 0x5d: // EOR nnnn,X
+		m6502.eor(m6502.read(m6502.addrAbsoluteIndex(m6502.X, false)))
+
+	case 0x5e: // LSR nnnn,X
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.X, true), m6502.lsr)
+
+	case 0x5f: // SRE nnnn,X
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.X, true), m6502.sre)
+
+	case 0x60: // RTS
+		m6502.idle()
+		m6502.peekStack()
+		lo := m6502.pull()
+		hi := m6502.pull()
+		m6502.PC = toword(lo, hi)
+		m6502.readPC()
+
+	case 0x61: // ADC (nn,X)
+		m6502.adc(m6502.read(m6502.addrIndirectX()))
+
+	case 0x62: // JAM
+		m6502.Jammed = true
+
+	case 0x63: // RRA (nn,X)
+		m6502.rmw(m6502.addrIndirectX(), m6502.rra)
+
+	case 0x64: // NOP nn
+		m6502.read(m6502.addrZeroPage())
+
+	case 0x65: // ADC nn
+		m6502.adc(m6502.read(m6502.addrZeroPage()))
+
+	case 0x66: // ROR nn
+		m6502.rmw(m6502.addrZeroPage(), m6502.ror)
+
+	case 0x67: // RRA nn
+		m6502.rmw(m6502.addrZeroPage(), m6502.rra)
+
+	case 0x68: // PLA
+		m6502.idle()
+		m6502.peekStack()
+		m6502.A = m6502.pull()
+		m6502.setNZ(m6502.A)
+
+	case 0x69: // ADC #nn
+		m6502.adc(m6502.readPC())
+
+	case 0x6a: // ROR A
+		m6502.idle()
+		m6502.A = m6502.ror(m6502.A)
+
+	case 0x6b: // ARR #nn
+		m6502.arr(m6502.readPC())
+
+	case 0x6c: // JMP (nnnn)
+		ptr := m6502.readWordPC()
+		lo := m6502.read(ptr)
+		hi := m6502.read(ptr&0xff00 | uint16(lowbyte(ptr)+1)) // page wrap bug
+		m6502.PC = toword(lo, hi)
+
+	case 0x6d: // ADC nnnn
+		m6502.adc(m6502.read(m6502.addrAbsolute()))
+
+	case 0x6e: // ROR nnnn
+		m6502.rmw(m6502.addrAbsolute(), m6502.ror)
+
+	case 0x6f: // RRA nnnn
+		m6502.rmw(m6502.addrAbsolute(), m6502.rra)
+
+	case 0x70: // BVS nn
+		m6502.branch(m6502.P&FlagV != 0)
+
+	case 0x71: // ADC (nn),Y
+		m6502.adc(m6502.read(m6502.addrIndirectY(false)))
+
+	case 0x72: // JAM
+		m6502.Jammed = true
+
+	case 0x73: // RRA (nn),Y
+		m6502.rmw(m6502.addrIndirectY(true), m6502.rra)
+
+	case 0x74: // NOP nn,X
+		m6502.read(m6502.addrZeroPageIndex(m6502.X))
+
+	case 0x75: // ADC nn,X
+		m6502.adc(m6502.read(m6502.addrZeroPageIndex(m6502.X)))
+
+	case 0x76: // ROR nn,X
+		m6502.rmw(m6502.addrZeroPageIndex(m6502.X), m6502.ror)
+
+	case 0x77: // RRA nn,X
+		m6502.rmw(m6502.addrZeroPageIndex(m6502.X), m6502.rra)
+
+	case 0x78: // SEI
+		m6502.idle()
+		m6502.P |= FlagI
+
+	case 0x79: // ADC nnnn,Y
+		m6502.adc(m6502.read(m6502.addrAbsoluteIndex(m6502.Y, false)))
+
+	case 0x7a: // NOP
+		m6502.idle()
+
+	case 0x7b: // RRA nnnn,Y
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.Y, true), m6502.rra)
+
+	case 0x7c: // NOP nnnn,X
+		m6502.read(m6502.addrAbsoluteIndex(m6502.X, false))
+
+	case 0x7d: // ADC nnnn,X
+		m6502.adc(m6502.read(m6502.addrAbsoluteIndex(m6502.X, false)))
+
+	case 0x7e: // ROR nnnn,X
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.X, true), m6502.ror)
+
+	case 0x7f: // RRA nnnn,X
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.X, true), m6502.rra)
+
+	case 0x80: // NOP #nn
+		m6502.readPC()
+
+	case 0x81: // STA (nn,X)
+		m6502.write(m6502.addrIndirectX(), m6502.A)
+
+	case 0x82: // NOP #nn
+		m6502.readPC()
+
+	case 0x83: // SAX (nn,X)
+		m6502.write(m6502.addrIndirectX(), m6502.A&m6502.X)
+
+	case 0x84: // STY nn
+		m6502.write(m6502.addrZeroPage(), m6502.Y)
+
+	case 0x85: // STA nn
+		m6502.write(m6502.addrZeroPage(), m6502.A)
+
+	case 0x86: // STX nn
+		m6502.write(m6502.addrZeroPage(), m6502.X)
+
+	case 0x87: // SAX nn
+		m6502.write(m6502.addrZeroPage(), m6502.A&m6502.X)
+
+	case 0x88: // DEY
+		m6502.idle()
+		m6502.Y--
+		m6502.setNZ(m6502.Y)
+
+	case 0x89: // NOP #nn
+		m6502.readPC()
+
+	case 0x8a: // TXA
+		m6502.idle()
+		m6502.A = m6502.X
+		m6502.setNZ(m6502.A)
+
+	case 0x8b: // ANE #nn
+		m6502.ane(m6502.readPC())
+
+	case 0x8c: // STY nnnn
+		m6502.write(m6502.addrAbsolute(), m6502.Y)
+
+	case 0x8d: // STA nnnn
+		m6502.write(m6502.addrAbsolute(), m6502.A)
+
+	case 0x8e: // STX nnnn
+		m6502.write(m6502.addrAbsolute(), m6502.X)
+
+	case 0x8f: // SAX nnnn
+		m6502.write(m6502.addrAbsolute(), m6502.A&m6502.X)
+
+	case 0x90: // BCC nn
+		m6502.branch(m6502.P&FlagC == 0)
+
+	case 0x91: // STA (nn),Y
+		m6502.write(m6502.addrIndirectY(true), m6502.A)
+
+	case 0x92: // JAM
+		m6502.Jammed = true
+
+	case 0x93: // SHA (nn),Y
+		base := m6502.pointerY()
+		m6502.sh(m6502.indexAddress(base, m6502.Y, true), base, m6502.A&m6502.X)
+
+	case 0x94: // STY nn,X
+		m6502.write(m6502.addrZeroPageIndex(m6502.X), m6502.Y)
+
+	case 0x95: // STA nn,X
+		m6502.write(m6502.addrZeroPageIndex(m6502.X), m6502.A)
+
+	case 0x96: // STX nn,Y
+		m6502.write(m6502.addrZeroPageIndex(m6502.Y), m6502.X)
+
+	case 0x97: // SAX nn,Y
+		m6502.write(m6502.addrZeroPageIndex(m6502.Y), m6502.A&m6502.X)
+
+	case 0x98: // TYA
+		m6502.idle()
+		m6502.A = m6502.Y
+		m6502.setNZ(m6502.A)
+
+	case 0x99: // STA nnnn,Y
+		m6502.write(m6502.addrAbsoluteIndex(m6502.Y, true), m6502.A)
+
+	case 0x9a: // TXS
+		m6502.idle()
+		m6502.S = m6502.X
+
+	case 0x9b: // TAS nnnn,Y
+		base := m6502.readWordPC()
+		m6502.S = m6502.A & m6502.X
+		m6502.sh(m6502.indexAddress(base, m6502.Y, true), base, m6502.S)
+
+	case 0x9c: // SHY nnnn,X
+		base := m6502.readWordPC()
+		m6502.sh(m6502.indexAddress(base, m6502.X, true), base, m6502.Y)
+
+	case 0x9d: // STA nnnn,X
+		m6502.write(m6502.addrAbsoluteIndex(m6502.X, true), m6502.A)
+
+	case 0x9e: // SHX nnnn,Y
+		base := m6502.readWordPC()
+		m6502.sh(m6502.indexAddress(base, m6502.Y, true), base, m6502.X)
+
+	case 0x9f: // SHA nnnn,Y
+		base := m6502.readWordPC()
+		m6502.sh(m6502.indexAddress(base, m6502.Y, true), base, m6502.A&m6502.X)
+
+	case 0xa0: // LDY #nn
+		m6502.ldy(m6502.readPC())
+
+	case 0xa1: // LDA (nn,X)
+		m6502.lda(m6502.read(m6502.addrIndirectX()))
+
+	case 0xa2: // LDX #nn
+		m6502.ldx(m6502.readPC())
+
+	case 0xa3: // LAX (nn,X)
+		m6502.lax(m6502.read(m6502.addrIndirectX()))
+
+	case 0xa4: // LDY nn
+		m6502.ldy(m6502.read(m6502.addrZeroPage()))
+
+	case 0xa5: // LDA nn
+		m6502.lda(m6502.read(m6502.addrZeroPage()))
+
+	case 0xa6: // LDX nn
+		m6502.ldx(m6502.read(m6502.addrZeroPage()))
+
+	case 0xa7: // LAX nn
+		m6502.lax(m6502.read(m6502.addrZeroPage()))
+
+	case 0xa8: // TAY
+		m6502.idle()
+		m6502.Y = m6502.A
+		m6502.setNZ(m6502.Y)
+
+	case 0xa9: // LDA #nn
+		m6502.lda(m6502.readPC())
+
+	case 0xaa: // TAX
+		m6502.idle()
+		m6502.X = m6502.A
+		m6502.setNZ(m6502.X)
+
+	case 0xab: // LXA #nn
+		m6502.lxa(m6502.readPC())
+
+	case 0xac: // LDY nnnn
+		m6502.ldy(m6502.read(m6502.addrAbsolute()))
+
+	case 0xad: // LDA nnnn
+		m6502.lda(m6502.read(m6502.addrAbsolute()))
+
+	case 0xae: // LDX nnnn
+		m6502.ldx(m6502.read(m6502.addrAbsolute()))
+
+	case 0xaf: // LAX nnnn
+		m6502.lax(m6502.read(m6502.addrAbsolute()))
+
+	case 0xb0: // BCS nn
+		m6502.branch(m6502.P&FlagC != 0)
+
+	case 0xb1: // LDA (nn),Y
+		m6502.lda(m6502.read(m6502.addrIndirectY(false)))
+
+	case 0xb2: // JAM
+		m6502.Jammed = true
+
+	case 0xb3: // LAX (nn),Y
+		m6502.lax(m6502.read(m6502.addrIndirectY(false)))
+
+	case 0xb4: // LDY nn,X
+		m6502.ldy(m6502.read(m6502.addrZeroPageIndex(m6502.X)))
+
+	case 0xb5: // LDA nn,X
+		m6502.lda(m6502.read(m6502.addrZeroPageIndex(m6502.X)))
+
+	case 0xb6: // LDX nn,Y
+		m6502.ldx(m6502.read(m6502.addrZeroPageIndex(m6502.Y)))
+
+	case 0xb7: // LAX nn,Y
+		m6502.lax(m6502.read(m6502.addrZeroPageIndex(m6502.Y)))
+
+	case 0xb8: // CLV
+		m6502.idle()
+		m6502.P &^= FlagV
+
+	case 0xb9: // LDA nnnn,Y
+		m6502.lda(m6502.read(m6502.addrAbsoluteIndex(m6502.Y, false)))
+
+	case 0xba: // TSX
+		m6502.idle()
+		m6502.X = m6502.S
+		m6502.setNZ(m6502.X)
+
+	case 0xbb: // LAS nnnn,Y
+		m6502.las(m6502.read(m6502.addrAbsoluteIndex(m6502.Y, false)))
+
+	case 0xbc: // LDY nnnn,X
+		m6502.ldy(m6502.read(m6502.addrAbsoluteIndex(m6502.X, false)))
+
+	case 0xbd: // LDA nnnn,X
+		m6502.lda(m6502.read(m6502.addrAbsoluteIndex(m6502.X, false)))
+
+	case 0xbe: // LDX nnnn,Y
+		m6502.ldx(m6502.read(m6502.addrAbsoluteIndex(m6502.Y, false)))
+
+	case 0xbf: // LAX nnnn,Y
+		m6502.lax(m6502.read(m6502.addrAbsoluteIndex(m6502.Y, false)))
+
+	case 0xc0: // CPY #nn
+		m6502.cmpY(m6502.readPC())
+
+	case 0xc1: // CMP (nn,X)
+		m6502.cmpA(m6502.read(m6502.addrIndirectX()))
+
+	case 0xc2: // NOP #nn
+		m6502.readPC()
+
+	case 0xc3: // DCP (nn,X)
+		m6502.rmw(m6502.addrIndirectX(), m6502.dcp)
+
+	case 0xc4: // CPY nn
+		m6502.cmpY(m6502.read(m6502.addrZeroPage()))
+
+	case 0xc5: // CMP nn
+		m6502.cmpA(m6502.read(m6502.addrZeroPage()))
+
+	case 0xc6: // DEC nn
+		m6502.rmw(m6502.addrZeroPage(), m6502.dec)
+
+	case 0xc7: // DCP nn
+		m6502.rmw(m6502.addrZeroPage(), m6502.dcp)
+
+	case 0xc8: // INY
+		m6502.idle()
+		m6502.Y++
+		m6502.setNZ(m6502.Y)
+
+	case 0xc9: // CMP #nn
+		m6502.cmpA(m6502.readPC())
+
+	case 0xca: // DEX
+		m6502.idle()
+		m6502.X--
+		m6502.setNZ(m6502.X)
+
+	case 0xcb: // SBX #nn
+		m6502.sbx(m6502.readPC())
+
+	case 0xcc: // CPY nnnn
+		m6502.cmpY(m6502.read(m6502.addrAbsolute()))
+
+	case 0xcd: // CMP nnnn
+		m6502.cmpA(m6502.read(m6502.addrAbsolute()))
+
+	case 0xce: // DEC nnnn
+		m6502.rmw(m6502.addrAbsolute(), m6502.dec)
+
+	case 0xcf: // DCP nnnn
+		m6502.rmw(m6502.addrAbsolute(), m6502.dcp)
+
+	case 0xd0: // BNE nn
+		m6502.branch(m6502.P&FlagZ == 0)
+
+	case 0xd1: // CMP (nn),Y
+		m6502.cmpA(m6502.read(m6502.addrIndirectY(false)))
+
+	case 0xd2: // JAM
+		m6502.Jammed = true
+
+	case 0xd3: // DCP (nn),Y
+		m6502.rmw(m6502.addrIndirectY(true), m6502.dcp)
+
+	case 0xd4: // NOP nn,X
+		m6502.read(m6502.addrZeroPageIndex(m6502.X))
+
+	case 0xd5: // CMP nn,X
+		m6502.cmpA(m6502.read(m6502.addrZeroPageIndex(m6502.X)))
+
+	case 0xd6: // DEC nn,X
+		m6502.rmw(m6502.addrZeroPageIndex(m6502.X), m6502.dec)
+
+	case 0xd7: // DCP nn,X
+		m6502.rmw(m6502.addrZeroPageIndex(m6502.X), m6502.dcp)
+
+	case 0xd8: // CLD
+		m6502.idle()
+		m6502.P &^= FlagD
+
+	case 0xd9: // CMP nnnn,Y
+		m6502.cmpA(m6502.read(m6502.addrAbsoluteIndex(m6502.Y, false)))
+
+	case 0xda: // NOP
+		m6502.idle()
+
+	case 0xdb: // DCP nnnn,Y
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.Y, true), m6502.dcp)
+
+	case 0xdc: // NOP nnnn,X
+		m6502.read(m6502.addrAbsoluteIndex(m6502.X, false))
+
+	case 0xdd: // CMP nnnn,X
+		m6502.cmpA(m6502.read(m6502.addrAbsoluteIndex(m6502.X, false)))
+
+	case 0xde: // DEC nnnn,X
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.X, true), m6502.dec)
+
+	case 0xdf: // DCP nnnn,X
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.X, true), m6502.dcp)
+
+	case 0xe0: // CPX #nn
+		m6502.cmpX(m6502.readPC())
+
+	case 0xe1: // SBC (nn,X)
+		m6502.sbc(m6502.read(m6502.addrIndirectX()))
+
+	case 0xe2: // NOP #nn
+		m6502.readPC()
+
+	case 0xe3: // ISC (nn,X)
+		m6502.rmw(m6502.addrIndirectX(), m6502.isc)
+
+	case 0xe4: // CPX nn
+		m6502.cmpX(m6502.read(m6502.addrZeroPage()))
+
+	case 0xe5: // SBC nn
+		m6502.sbc(m6502.read(m6502.addrZeroPage()))
+
+	case 0xe6: // INC nn
+		m6502.rmw(m6502.addrZeroPage(), m6502.inc)
+
+	case 0xe7: // ISC nn
+		m6502.rmw(m6502.addrZeroPage(), m6502.isc)
+
+	case 0xe8: // INX
+		m6502.idle()
+		m6502.X++
+		m6502.setNZ(m6502.X)
+
+	case 0xe9: // SBC #nn
+		m6502.sbc(m6502.readPC())
+
+	case 0xea: // NOP
+		m6502.idle()
+
+	case 0xeb: // USBC #nn
+		m6502.sbc(m6502.readPC())
+
+	case 0xec: // CPX nnnn
+		m6502.cmpX(m6502.read(m6502.addrAbsolute()))
+
+	case 0xed: // SBC nnnn
+		m6502.sbc(m6502.read(m6502.addrAbsolute()))
+
+	case 0xee: // INC nnnn
+		m6502.rmw(m6502.addrAbsolute(), m6502.inc)
+
+	case 0xef: // ISC nnnn
+		m6502.rmw(m6502.addrAbsolute(), m6502.isc)
+
+	case 0xf0: // BEQ nn
+		m6502.branch(m6502.P&FlagZ != 0)
+
+	case 0xf1: // SBC (nn),Y
+		m6502.sbc(m6502.read(m6502.addrIndirectY(false)))
+
+	case 0xf2: // JAM
+		m6502.Jammed = true
+
+	case 0xf3: // ISC (nn),Y
+		m6502.rmw(m6502.addrIndirectY(true), m6502.isc)
+
+	case 0xf4: // NOP nn,X
+		m6502.read(m6502.addrZeroPageIndex(m6502.X))
+
+	case 0xf5: // SBC nn,X
+		m6502.sbc(m6502.read(m6502.addrZeroPageIndex(m6502.X)))
+
+	case 0xf6: // INC nn,X
+		m6502.rmw(m6502.addrZeroPageIndex(m6502.X), m6502.inc)
+
+	case 0xf7: // ISC nn,X
+		m6502.rmw(m6502.addrZeroPageIndex(m6502.X), m6502.isc)
+
+	case 0xf8: // SED
+		m6502.idle()
+		m6502.P |= FlagD
+
+	case 0xf9: // SBC nnnn,Y
+		m6502.sbc(m6502.read(m6502.addrAbsoluteIndex(m6502.Y, false)))
+
+	case 0xfa: // NOP
+		m6502.idle()
+
+	case 0xfb: // ISC nnnn,Y
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.Y, true), m6502.isc)
+
+	case 0xfc: // NOP nnnn,X
+		m6502.read(m6502.addrAbsoluteIndex(m6502.X, false))
+
+	case 0xfd: // SBC nnnn,X
+		m6502.sbc(m6502.read(m6502.addrAbsoluteIndex(m6502.X, false)))
+
+	case 0xfe: // INC nnnn,X
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.X, true), m6502.inc)
+
+	case 0xff: // ISC nnnn,X
+		m6502.rmw(m6502.addrAbsoluteIndex(m6502.X, true), m6502.isc)
+
+	}
+}
@@ -0,0 +1,59 @@
+package m6502
+
+import "github.com/jtruco/emu8/emulator/device/cpu"
+
+// -----------------------------------------------------------------------------
+// State - 6502 CPU State
+// -----------------------------------------------------------------------------
+
+// State is the 6502 cpu state
+type State struct {
+
+	// 8 bit registers : accumulator, indexes, stack pointer and status
+	A, X, Y cpu.Reg8
+	S       cpu.Reg8
+	P       cpu.Reg8
+
+	// Program counter
+	PC cpu.Reg16
+
+	// Control
+	Jammed     bool // CPU halted by a JAM opcode
+	IntMask    bool // I flag sampled by the interrupt poll
+	IntRq      bool // IRQ line (level)
+	NmiRq      bool // NMI line
+	NmiPending bool // NMI edge detected
+	ResetRq    bool // RESET sequence pending
+}
+
+// NewState creates a new 6502 state
+func NewState() *State {
+	state := new(State)
+	state.HardReset()
+	return state
+}
+
+// SoftReset initializes state (soft). The reset sequence runs on the next
+// instruction.
+func (state *State) SoftReset() {
+	state.Jammed = false
+	state.IntRq = false
+	state.NmiRq = false
+	state.NmiPending = false
+	state.ResetRq = true
+}
+
+// HardReset initializes state (power-on)
+func (state *State) HardReset() {
+	state.A, state.X, state.Y = 0, 0, 0
+	state.S = 0
+	state.P = FlagU | FlagI
+	state.PC = 0
+	state.IntMask = true
+	state.SoftReset()
+}
+
+// Copy copies state values
+func (state *State) Copy(value *State) {
+	*state = *value
+}
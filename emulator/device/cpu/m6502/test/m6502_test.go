@@ -0,0 +1,219 @@
+package m6502
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/jtruco/emu8/emulator/device"
+	"github.com/jtruco/emu8/emulator/device/cpu/m6502"
+)
+
+// Memory
+
+type Memory struct {
+	data   [0x10000]byte
+	cycles [][3]interface{}
+	trace  bool
+}
+
+func (m *Memory) Read(address uint16) byte {
+	if m.trace {
+		m.cycles = append(m.cycles, [3]interface{}{address, m.data[address], "read"})
+	}
+	return m.data[address]
+}
+
+func (m *Memory) Write(address uint16, value byte) {
+	if m.trace {
+		m.cycles = append(m.cycles, [3]interface{}{address, value, "write"})
+	}
+	m.data[address] = value
+}
+
+// 6502 Test
+
+// TestProgram test a small program : a loop, decimal mode and the timing
+func TestProgram(t *testing.T) {
+	mem := new(Memory)
+	cpu := m6502.New(device.NewClock(), mem)
+	program := []byte{
+		0xa2, 0x0a, // LDX #$0A
+		0xa9, 0x00, // LDA #$00
+		0x18,       // CLC
+		0x86, 0x10, // STX $10
+		0x65, 0x10, // ADC $10
+		0xca,       // DEX
+		0xd0, 0xf9, // BNE $0205
+		0xf8,       // SED
+		0x18,       // CLC
+		0x69, 0x45, // ADC #$45
+		0xd8,             // CLD
+		0x4c, 0x11, 0x02, // JMP $0211
+	}
+	copy(mem.data[0x0200:], program)
+	mem.data[0xfffc], mem.data[0xfffd] = 0x00, 0x02
+
+	cpu.Execute() // reset sequence
+	if cpu.PC != 0x0200 || cpu.Clock().Tstates() != 7 {
+		t.Fatalf("reset : PC %04x, %d cycles", cpu.PC, cpu.Clock().Tstates())
+	}
+	start := cpu.Clock().Tstates()
+	for cpu.PC != 0x0211 {
+		cpu.Execute()
+	}
+	if cpu.A != 0x82 || cpu.X != 0 {
+		t.Errorf("result : A %02x X %02x", cpu.A, cpu.X)
+	}
+	if cycles := cpu.Clock().Tstates() - start; cycles != 123 {
+		t.Errorf("timing : %d cycles", cycles)
+	}
+}
+
+// TestFunctional test 6502 emulator with Klaus Dormann's functional test.
+// The test binary is assembled for a load address of 0x0000 and a start
+// address of 0x0400, the success trap is at 0x3469. The binary is not in
+// the repository : copy bin_files/6502_functional_test.bin from
+// https://github.com/Klaus2m5/6502_65C02_functional_tests in this directory.
+func TestFunctional(t *testing.T) {
+	testFunctional(t, "6502_functional_test.bin", 0x3469)
+}
+
+func testFunctional(t *testing.T, testfile string, success uint16) {
+
+	// initialize cpu
+	mem := new(Memory)
+	cpu := m6502.New(device.NewClock(), mem)
+
+	// load testfile
+	data, err := ioutil.ReadFile(testfile)
+	if err != nil {
+		t.Skip(testfile, "not found :", err.Error())
+	}
+	copy(mem.data[:], data[:])
+
+	// prepare test
+	cpu.Execute() // reset sequence
+	cpu.PC = 0x0400
+
+	// run test : every error and the success are traps (jump to itself)
+	for {
+		pc := cpu.PC
+		cpu.Execute()
+		if cpu.PC == pc {
+			break
+		}
+	}
+	if cpu.PC != success {
+		t.Fatalf("6502 functional test trapped at %04x", cpu.PC)
+	}
+	fmt.Println("6502 functional test passed after ", cpu.Clock().Tstates(), " cycles")
+}
+
+// TestSingleStep test 6502 emulator with Tom Harte's single step tests. The
+// tests are not in the repository : copy the 6502/v1 directory of
+// https://github.com/SingleStepTests/65x02 (formerly TomHarte/ProcessorTests)
+// in this directory as v1, with the 00.json to ff.json files.
+func TestSingleStep(t *testing.T) {
+	testSingleStep(t, "v1")
+}
+
+// testExcluded are the opcodes not tested : the JAM opcodes halt the cpu,
+// and the unstable opcodes depend on the chip, ANE & LXA on its magic
+// constant and SHA, SHX, SHY & TAS on the page crossing.
+var testExcluded = map[int]string{
+	0x02: "JAM", 0x12: "JAM", 0x22: "JAM", 0x32: "JAM", 0x42: "JAM", 0x52: "JAM",
+	0x62: "JAM", 0x72: "JAM", 0x92: "JAM", 0xb2: "JAM", 0xd2: "JAM", 0xf2: "JAM",
+	0x8b: "ANE #nn", 0xab: "LXA #nn",
+	0x93: "SHA (nn),Y", 0x9f: "SHA nnnn,Y", 0x9e: "SHX nnnn,Y", 0x9c: "SHY nnnn,X",
+	0x9b: "TAS nnnn,Y",
+}
+
+// testCase is a single step test case
+type testCase struct {
+	Name    string
+	Initial testState
+	Final   testState
+	Cycles  [][3]interface{}
+}
+
+// testState is a single step test cpu and ram state
+type testState struct {
+	PC            uint16
+	S, A, X, Y, P byte
+	RAM           [][2]int
+}
+
+func testSingleStep(t *testing.T, testdir string) {
+
+	// initialize cpu
+	mem := new(Memory)
+	cpu := m6502.New(device.NewClock(), mem)
+
+	for opcode := 0; opcode < 0x100; opcode++ {
+		if _, excluded := testExcluded[opcode]; excluded {
+			continue
+		}
+
+		// load testfile
+		data, err := ioutil.ReadFile(filepath.Join(testdir, fmt.Sprintf("%02x.json", opcode)))
+		if err != nil {
+			t.Skip(testdir, "tests not found :", err.Error())
+		}
+		var tests []testCase
+		if err := json.Unmarshal(data, &tests); err != nil {
+			t.Fatal(err)
+		}
+
+		// run tests
+		failed := 0
+		for _, test := range tests {
+			cpu.Init()
+			cpu.ResetRq = false
+			cpu.PC = test.Initial.PC
+			cpu.S, cpu.A, cpu.X, cpu.Y, cpu.P = test.Initial.S, test.Initial.A, test.Initial.X, test.Initial.Y, test.Initial.P
+			cpu.IntMask = cpu.P&m6502.FlagI != 0
+			for _, ram := range test.Initial.RAM {
+				mem.data[ram[0]] = byte(ram[1])
+			}
+			mem.cycles, mem.trace = mem.cycles[:0], true
+			cpu.Execute()
+			mem.trace = false
+			if !testCheck(cpu, mem, &test) {
+				if failed < 5 {
+					t.Errorf("%s : failed", test.Name)
+				}
+				failed++
+			}
+		}
+		if failed > 0 {
+			t.Errorf("opcode %02x : %d tests failed", opcode, failed)
+		}
+	}
+}
+
+func testCheck(cpu *m6502.M6502, mem *Memory, test *testCase) bool {
+	final := &test.Final
+	if cpu.PC != final.PC || cpu.S != final.S || cpu.A != final.A || cpu.X != final.X || cpu.Y != final.Y {
+		return false
+	}
+	if (cpu.P^final.P)&^m6502.FlagB != 0 {
+		return false
+	}
+	for _, ram := range final.RAM {
+		if mem.data[ram[0]] != byte(ram[1]) {
+			return false
+		}
+	}
+	if len(mem.cycles) != len(test.Cycles) {
+		return false
+	}
+	for i, cycle := range test.Cycles {
+		if uint16(cycle[0].(float64)) != mem.cycles[i][0] || cycle[2] != mem.cycles[i][2] {
+			return false
+		}
+	}
+	return true
+}
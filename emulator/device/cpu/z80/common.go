@@ -107,11 +107,19 @@ func (z80 *Z80) setSP(address uint16) {
 
 // readByte reads a byte from memory
 func (z80 *Z80) readByte(address uint16) byte {
+	z80.wait()
 	data := z80.mem.Read(address)
 	z80.clock.Add(3) // +3 tstates in memory access
 	return data
 }
 
+// wait samples the WAIT line before a memory cycle
+func (z80 *Z80) wait() {
+	if z80.OnWait != nil {
+		z80.OnWait()
+	}
+}
+
 // readBytePC reads a byte from pc address and increments PC
 func (z80 *Z80) readBytePC() byte {
 	data := z80.readByte(z80.PC)
@@ -135,6 +143,7 @@ func (z80 *Z80) readPort(port uint16) byte {
 
 // writeByte writes a byte into memory
 func (z80 *Z80) writeByte(address uint16, value byte) {
+	z80.wait()
 	z80.mem.Write(address, value)
 	z80.clock.Add(3) // +3 tstates in memory access
 }
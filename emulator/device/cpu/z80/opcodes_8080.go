@@ -0,0 +1,443 @@
+package z80
+
+import "github.com/jtruco/emu8/emulator/device/cpu"
+
+// -----------------------------------------------------------------------------
+// Intel 8080 mode
+// -----------------------------------------------------------------------------
+
+// Intel 8080 flags : S, Z, AC, P and C. Bit 1 is always set, bits 3 and 5
+// are always reset.
+const (
+	flags8080    = FlagS | FlagZ | FlagH | FlagP | FlagC
+	flagsSet8080 = FlagN
+)
+
+// Intel 8080 opcode states. Conditional calls and returns add 6 states
+// when taken.
+var states8080 = [0x100]byte{
+	4, 10, 7, 5, 5, 5, 7, 4, 4, 10, 7, 5, 5, 5, 7, 4, // 0x00
+	4, 10, 7, 5, 5, 5, 7, 4, 4, 10, 7, 5, 5, 5, 7, 4, // 0x10
+	4, 10, 16, 5, 5, 5, 7, 4, 4, 10, 16, 5, 5, 5, 7, 4, // 0x20
+	4, 10, 13, 5, 10, 10, 10, 4, 4, 10, 13, 5, 5, 5, 7, 4, // 0x30
+	5, 5, 5, 5, 5, 5, 7, 5, 5, 5, 5, 5, 5, 5, 7, 5, // 0x40
+	5, 5, 5, 5, 5, 5, 7, 5, 5, 5, 5, 5, 5, 5, 7, 5, // 0x50
+	5, 5, 5, 5, 5, 5, 7, 5, 5, 5, 5, 5, 5, 5, 7, 5, // 0x60
+	7, 7, 7, 7, 7, 7, 7, 7, 5, 5, 5, 5, 5, 5, 7, 5, // 0x70
+	4, 4, 4, 4, 4, 4, 7, 4, 4, 4, 4, 4, 4, 4, 7, 4, // 0x80
+	4, 4, 4, 4, 4, 4, 7, 4, 4, 4, 4, 4, 4, 4, 7, 4, // 0x90
+	4, 4, 4, 4, 4, 4, 7, 4, 4, 4, 4, 4, 4, 4, 7, 4, // 0xa0
+	4, 4, 4, 4, 4, 4, 7, 4, 4, 4, 4, 4, 4, 4, 7, 4, // 0xb0
+	5, 10, 10, 10, 11, 11, 7, 11, 5, 10, 10, 10, 11, 17, 7, 11, // 0xc0
+	5, 10, 10, 10, 11, 11, 7, 11, 5, 10, 10, 10, 11, 17, 7, 11, // 0xd0
+	5, 10, 10, 18, 11, 11, 7, 11, 5, 5, 10, 4, 11, 17, 7, 11, // 0xe0
+	5, 10, 10, 4, 11, 11, 7, 11, 5, 5, 10, 4, 11, 17, 7, 11, // 0xf0
+}
+
+// execute8080 fetchs and executes an Intel 8080 opcode. The Z80 prefixes
+// and relative jumps are the 8080 undocumented aliases of NOP, JMP, CALL
+// and RET. Memory accesses are not timed, the clock adds the opcode states.
+func (z80 *Z80) execute8080() {
+	opcode := z80.read8080()
+	if z80.OnFetch != nil {
+		opcode = z80.OnFetch(z80.PC-1, opcode)
+	}
+	z80.ActiveEI = false
+	states := states8080[opcode]
+
+	switch {
+
+	case opcode == 0x76: // HLT
+		z80.Halted = true
+		z80.decPC()
+
+	case opcode&0xc0 == 0x40: // MOV r,r
+		z80.set8080(opcode>>3, z80.get8080(opcode))
+
+	case opcode&0xc0 == 0x80: // ALU r
+		z80.alu8080(opcode>>3, z80.get8080(opcode))
+
+	case opcode&0xc7 == 0x04: // INR r
+		value := z80.get8080(opcode>>3) + 1
+		z80.set8080(opcode>>3, value)
+		z80.F = (z80.F & FlagC) | z80.szp8080(value) | ifthen(value&0x0f == 0, FlagH, 0)
+
+	case opcode&0xc7 == 0x05: // DCR r
+		value := z80.get8080(opcode>>3) - 1
+		z80.set8080(opcode>>3, value)
+		z80.F = (z80.F & FlagC) | z80.szp8080(value) | ifthen(value&0x0f != 0x0f, FlagH, 0)
+
+	case opcode&0xc7 == 0x06: // MVI r,nn
+		z80.set8080(opcode>>3, z80.read8080())
+
+	case opcode&0xc7 == 0xc6: // ALU nn
+		z80.alu8080(opcode>>3, z80.read8080())
+
+	case opcode&0xc7 == 0xc0: // Rcc
+		if z80.cond8080(opcode >> 3) {
+			z80.PC = z80.pop8080()
+			states += 6
+		}
+
+	case opcode&0xc7 == 0xc2: // Jcc nnnn
+		address := z80.read16PC8080()
+		if z80.cond8080(opcode >> 3) {
+			z80.PC = address
+		}
+
+	case opcode&0xc7 == 0xc4: // Ccc nnnn
+		address := z80.read16PC8080()
+		if z80.cond8080(opcode >> 3) {
+			z80.push8080(z80.PC)
+			z80.PC = address
+			states += 6
+		}
+
+	case opcode&0xc7 == 0xc7: // RST n
+		z80.push8080(z80.PC)
+		z80.PC = uint16(opcode & 0x38)
+
+	default:
+		z80.misc8080(opcode)
+	}
+
+	z80.clock.Add(int(states))
+}
+
+// misc8080 executes the 8080 opcodes not decoded by register fields
+func (z80 *Z80) misc8080(opcode byte) {
+
+	switch opcode {
+
+	case 0x00, 0x08, 0x10, 0x18, 0x20, 0x28, 0x30, 0x38: // NOP
+
+	case 0x01: // LXI B,nnnn
+		z80.BC.Set(z80.read16PC8080())
+
+	case 0x11: // LXI D,nnnn
+		z80.DE.Set(z80.read16PC8080())
+
+	case 0x21: // LXI H,nnnn
+		z80.HL.Set(z80.read16PC8080())
+
+	case 0x31: // LXI SP,nnnn
+		z80.SP = z80.read16PC8080()
+
+	case 0x02: // STAX B
+		z80.mem.Write(z80.BC.Get(), z80.A)
+
+	case 0x12: // STAX D
+		z80.mem.Write(z80.DE.Get(), z80.A)
+
+	case 0x0a: // LDAX B
+		z80.A = z80.mem.Read(z80.BC.Get())
+
+	case 0x1a: // LDAX D
+		z80.A = z80.mem.Read(z80.DE.Get())
+
+	case 0x22: // SHLD nnnn
+		address := z80.read16PC8080()
+		z80.mem.Write(address, z80.L)
+		z80.mem.Write(address+1, z80.H)
+
+	case 0x2a: // LHLD nnnn
+		address := z80.read16PC8080()
+		z80.L = z80.mem.Read(address)
+		z80.H = z80.mem.Read(address + 1)
+
+	case 0x32: // STA nnnn
+		z80.mem.Write(z80.read16PC8080(), z80.A)
+
+	case 0x3a: // LDA nnnn
+		z80.A = z80.mem.Read(z80.read16PC8080())
+
+	case 0x03: // INX B
+		z80.BC.Inc()
+
+	case 0x13: // INX D
+		z80.DE.Inc()
+
+	case 0x23: // INX H
+		z80.HL.Inc()
+
+	case 0x33: // INX SP
+		z80.incSP()
+
+	case 0x0b: // DCX B
+		z80.BC.Dec()
+
+	case 0x1b: // DCX D
+		z80.DE.Dec()
+
+	case 0x2b: // DCX H
+		z80.HL.Dec()
+
+	case 0x3b: // DCX SP
+		z80.decSP()
+
+	case 0x09: // DAD B
+		z80.dad8080(z80.BC.Get())
+
+	case 0x19: // DAD D
+		z80.dad8080(z80.DE.Get())
+
+	case 0x29: // DAD H
+		z80.dad8080(z80.HL.Get())
+
+	case 0x39: // DAD SP
+		z80.dad8080(z80.SP)
+
+	case 0x07: // RLC
+		z80.A = z80.A<<1 | z80.A>>7
+		z80.F = (z80.F &^ FlagC) | (z80.A & FlagC)
+
+	case 0x0f: // RRC
+		z80.F = (z80.F &^ FlagC) | (z80.A & FlagC)
+		z80.A = z80.A>>1 | z80.A<<7
+
+	case 0x17: // RAL
+		carry := z80.A >> 7
+		z80.A = z80.A<<1 | (z80.F & FlagC)
+		z80.F = (z80.F &^ FlagC) | carry
+
+	case 0x1f: // RAR
+		carry := z80.A & FlagC
+		z80.A = z80.A>>1 | (z80.F&FlagC)<<7
+		z80.F = (z80.F &^ FlagC) | carry
+
+	case 0x27: // DAA
+		z80.daa8080()
+
+	case 0x2f: // CMA
+		z80.A = ^z80.A
+
+	case 0x37: // STC
+		z80.F |= FlagC
+
+	case 0x3f: // CMC
+		z80.F ^= FlagC
+
+	case 0xc1: // POP B
+		z80.BC.Set(z80.pop8080())
+
+	case 0xd1: // POP D
+		z80.DE.Set(z80.pop8080())
+
+	case 0xe1: // POP H
+		z80.HL.Set(z80.pop8080())
+
+	case 0xf1: // POP PSW
+		z80.AF.Set(z80.pop8080())
+		z80.F = (z80.F & flags8080) | flagsSet8080
+
+	case 0xc5: // PUSH B
+		z80.push8080(z80.BC.Get())
+
+	case 0xd5: // PUSH D
+		z80.push8080(z80.DE.Get())
+
+	case 0xe5: // PUSH H
+		z80.push8080(z80.HL.Get())
+
+	case 0xf5: // PUSH PSW
+		z80.push8080(toword((z80.F&flags8080)|flagsSet8080, z80.A))
+
+	case 0xc3, 0xcb: // JMP nnnn
+		z80.PC = z80.read16PC8080()
+
+	case 0xcd, 0xdd, 0xed, 0xfd: // CALL nnnn
+		address := z80.read16PC8080()
+		z80.push8080(z80.PC)
+		z80.PC = address
+
+	case 0xc9, 0xd9: // RET
+		z80.PC = z80.pop8080()
+
+	case 0xd3: // OUT nn
+		port := uint16(z80.read8080())
+		z80.writePort(port<<8|port, z80.A)
+
+	case 0xdb: // IN nn
+		port := uint16(z80.read8080())
+		z80.A = z80.readPort(port<<8 | port)
+
+	case 0xe3: // XTHL
+		value := z80.HL.Get()
+		z80.L = z80.mem.Read(z80.SP)
+		z80.H = z80.mem.Read(z80.SP + 1)
+		z80.mem.Write(z80.SP, lowbyte(value))
+		z80.mem.Write(z80.SP+1, highbyte(value))
+
+	case 0xe9: // PCHL
+		z80.PC = z80.HL.Get()
+
+	case 0xeb: // XCHG
+		z80.DE.Swap(&z80.HL)
+
+	case 0xf9: // SPHL
+		z80.SP = z80.HL.Get()
+
+	case 0xf3: // DI
+		z80.IFF1, z80.IFF2 = false, false
+
+	case 0xfb: // EI
+		z80.IFF1, z80.IFF2 = true, true
+		z80.ActiveEI = true
+	}
+}
+
+// 8080 memory & registers
+
+// read8080 reads a byte from pc address and increments PC
+func (z80 *Z80) read8080() byte {
+	data := z80.mem.Read(z80.PC)
+	z80.incPC()
+	return data
+}
+
+// read16PC8080 reads a word from pc address and increments PC
+func (z80 *Z80) read16PC8080() uint16 {
+	low := z80.read8080()
+	return toword(low, z80.read8080())
+}
+
+// pop8080 pops a word from the stack
+func (z80 *Z80) pop8080() uint16 {
+	low := z80.mem.Read(z80.SP)
+	high := z80.mem.Read(z80.SP + 1)
+	z80.SP += 2
+	return toword(low, high)
+}
+
+// push8080 pushes a word into the stack
+func (z80 *Z80) push8080(value uint16) {
+	z80.SP -= 2
+	z80.mem.Write(z80.SP, lowbyte(value))
+	z80.mem.Write(z80.SP+1, highbyte(value))
+}
+
+// reg8080 gets the register of an opcode register field, nil for M
+func (z80 *Z80) reg8080(index byte) *cpu.Reg8 {
+	switch index & 0x07 {
+	case 0:
+		return &z80.B
+	case 1:
+		return &z80.C
+	case 2:
+		return &z80.D
+	case 3:
+		return &z80.E
+	case 4:
+		return &z80.H
+	case 5:
+		return &z80.L
+	case 7:
+		return &z80.A
+	}
+	return nil
+}
+
+// get8080 gets the value of an opcode register field, M is (HL)
+func (z80 *Z80) get8080(index byte) byte {
+	if reg := z80.reg8080(index); reg != nil {
+		return *reg
+	}
+	return z80.mem.Read(z80.HL.Get())
+}
+
+// set8080 sets the value of an opcode register field, M is (HL)
+func (z80 *Z80) set8080(index, value byte) {
+	if reg := z80.reg8080(index); reg != nil {
+		*reg = value
+	} else {
+		z80.mem.Write(z80.HL.Get(), value)
+	}
+}
+
+// cond8080 checks an opcode condition field : NZ, Z, NC, C, PO, PE, P, M
+func (z80 *Z80) cond8080(index byte) bool {
+	var flag byte
+	switch index & 0x06 {
+	case 0x00:
+		flag = FlagZ
+	case 0x02:
+		flag = FlagC
+	case 0x04:
+		flag = FlagP
+	case 0x06:
+		flag = FlagS
+	}
+	return (z80.F&flag != 0) == (index&0x01 != 0)
+}
+
+// 8080 instructions
+
+// szp8080 gets the S, Z and P flags of a value
+func (z80 *Z80) szp8080(value byte) byte {
+	return sz53pTable[value]&^(Flag3|Flag5) | flagsSet8080
+}
+
+// alu8080 executes an opcode ALU operation : ADD, ADC, SUB, SBB, ANA, XRA,
+// ORA and CMP
+func (z80 *Z80) alu8080(operation, value byte) {
+	switch operation & 0x07 {
+	case 0: // ADD
+		z80.A = z80.add8080(value, 0)
+	case 1: // ADC
+		z80.A = z80.add8080(value, z80.F&FlagC)
+	case 2: // SUB
+		z80.A = z80.sub8080(value, 0)
+	case 3: // SBB
+		z80.A = z80.sub8080(value, z80.F&FlagC)
+	case 4: // ANA : AC is the OR of the operands bit 3
+		flagH := ifthen((z80.A|value)&0x08 != 0, FlagH, 0)
+		z80.A &= value
+		z80.F = z80.szp8080(z80.A) | flagH
+	case 5: // XRA
+		z80.A ^= value
+		z80.F = z80.szp8080(z80.A)
+	case 6: // ORA
+		z80.A |= value
+		z80.F = z80.szp8080(z80.A)
+	case 7: // CMP
+		z80.sub8080(value, 0)
+	}
+}
+
+// add8080 adds a value and carry to the accumulator, updating flags
+func (z80 *Z80) add8080(value, carry byte) byte {
+	tmp := uint16(z80.A) + uint16(value) + uint16(carry)
+	result := byte(tmp)
+	z80.F = z80.szp8080(result) | ifthen(tmp&0x100 != 0, FlagC, 0) |
+		((z80.A ^ value ^ result) & FlagH)
+	return result
+}
+
+// sub8080 subtracts a value and borrow from the accumulator, updating
+// flags. AC is the carry of the complement addition.
+func (z80 *Z80) sub8080(value, borrow byte) byte {
+	result := z80.add8080(^value, borrow^FlagC)
+	z80.F ^= FlagC
+	return result
+}
+
+// dad8080 adds a value to HL
+func (z80 *Z80) dad8080(value uint16) {
+	tmp := uint32(z80.HL.Get()) + uint32(value)
+	z80.HL.Set(uint16(tmp))
+	z80.F = (z80.F &^ FlagC) | ifthen(tmp&0x10000 != 0, FlagC, 0)
+}
+
+// daa8080 decimal adjust accumulator, after additions only
+func (z80 *Z80) daa8080() {
+	add, carry := byte(0), z80.F&FlagC
+	if z80.F&FlagH != 0 || z80.A&0x0f > 9 {
+		add = 0x06
+	}
+	if carry != 0 || z80.A > 0x99 {
+		add |= 0x60
+		carry = FlagC
+	}
+	z80.A = z80.add8080(add, 0)
+	z80.F |= carry
+}
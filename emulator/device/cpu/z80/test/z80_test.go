@@ -3,7 +3,6 @@ package z80
 import (
 	"fmt"
 	"io/ioutil"
-	"log"
 	"testing"
 
 	"github.com/jtruco/emu8/emulator/device"
@@ -28,20 +27,69 @@ func (m *Memory) Write(address uint16, value byte) {
 
 // TestZexall test Z80 emulator
 func TestZ80(t *testing.T) {
-	testFile("zexall.bin")
+	testFile(t, "zexall.bin", false)
 }
 
-func testFile(testfile string) {
+// Test8080 test Intel 8080 mode with the 8080 exerciser, if present. The
+// exerciser is not in the repository : get 8080EXM.COM, Ian Bartholomew's
+// modified 8080/8085 CPU exerciser (included with the 8080 emulators
+// and the CP/M archives), and copy it in this directory as 8080exm.com.
+func Test8080(t *testing.T) {
+	testFile(t, "8080exm.com", true)
+}
+
+// Test8080Flags test the Intel 8080 mode flags : parity instead of
+// overflow, the auxiliary carry of the additions, subtractions, increments
+// and decrements, DAA and the bit 1 always set.
+func Test8080Flags(t *testing.T) {
+	a := func(cpu *z80.Z80) byte { return cpu.A }
+	b := func(cpu *z80.Z80) byte { return cpu.B }
+	c := func(cpu *z80.Z80) byte { return cpu.C }
+	tests := []struct {
+		name    string
+		program []byte
+		reg     func(*z80.Z80) byte
+		value   byte
+		flags   byte
+	}{
+		{"ADI carry", []byte{0x3e, 0x3a, 0xc6, 0xc6}, a, 0x00, 0x57},       // MVI A,3A ; ADI C6
+		{"ADI parity", []byte{0x3e, 0x7f, 0xc6, 0x01}, a, 0x80, 0x92},      // MVI A,7F ; ADI 01
+		{"SUI borrow", []byte{0x3e, 0x02, 0xd6, 0x03}, a, 0xff, 0x87},      // MVI A,02 ; SUI 03
+		{"SUI", []byte{0x3e, 0x05, 0xd6, 0x03}, a, 0x02, 0x12},             // MVI A,05 ; SUI 03
+		{"DAA", []byte{0x3e, 0x15, 0xc6, 0x27, 0x27}, a, 0x42, 0x16},       // MVI A,15 ; ADI 27 ; DAA
+		{"DAA carry", []byte{0x3e, 0x99, 0xc6, 0x01, 0x27}, a, 0x00, 0x57}, // MVI A,99 ; ADI 01 ; DAA
+		{"INR", []byte{0x37, 0x06, 0x0f, 0x04}, b, 0x10, 0x13},             // STC ; MVI B,0F ; INR B
+		{"INR zero", []byte{0xb7, 0x06, 0xff, 0x04}, b, 0x00, 0x56},        // ORA A ; MVI B,FF ; INR B
+		{"DCR", []byte{0xb7, 0x0e, 0x10, 0x0d}, c, 0x0f, 0x06},             // ORA A ; MVI C,10 ; DCR C
+		{"DCR zero", []byte{0x37, 0x0e, 0x01, 0x0d}, c, 0x00, 0x57},        // STC ; MVI C,01 ; DCR C
+		{"DCR parity", []byte{0xb7, 0x0e, 0x80, 0x0d}, c, 0x7f, 0x02},      // ORA A ; MVI C,80 ; DCR C
+	}
+	for _, test := range tests {
+		mem, io := new(Memory), new(Memory)
+		cpu := z80.New(device.NewClock(), mem, io)
+		cpu.Mode8080 = true
+		copy(mem.data[:], test.program)
+		mem.data[len(test.program)] = 0x76 // HLT
+		for !cpu.Halted {
+			cpu.Execute()
+		}
+		if value := test.reg(cpu); value != test.value || cpu.F != test.flags {
+			t.Errorf("%s : result %02x flags %02x, expected %02x flags %02x", test.name, value, cpu.F, test.value, test.flags)
+		}
+	}
+}
+
+func testFile(t *testing.T, testfile string, mode8080 bool) {
 
 	// initialize cpu
 	mem, io := new(Memory), new(Memory)
 	cpu := z80.New(device.NewClock(), mem, io)
+	cpu.Mode8080 = mode8080
 
 	// load testfile
 	data, err := ioutil.ReadFile(testfile)
 	if err != nil {
-		log.Println(err.Error())
-		return
+		t.Skip(testfile, "not found :", err.Error())
 	}
 	copy(mem.data[0x100:], data[:])
 
@@ -10,13 +10,26 @@ import (
 // Z80 - Zilog Z80 CPU
 // -----------------------------------------------------------------------------
 
+// FetchCallback is the opcode fetch (M1) callback. Returns the opcode
+// read by the CPU, machines may replace it.
+type FetchCallback func(address uint16, opcode byte) byte
+
+// RefreshCallback is the memory refresh callback, with the IR register
+// pair on the address bus
+type RefreshCallback func(address uint16)
+
 // Z80 the Zilog Z80 CPU
 type Z80 struct {
-	State                       // Z80 State
-	clock    device.Clock       // Clock device
-	mem      bus.Bus            // Memory data bus
-	io       bus.Bus            // I/O data bus
-	OnIntAck device.AckCallback // INT / NMI ack callback
+	State                        // Z80 State
+	clock     device.Clock       // Clock device
+	mem       bus.Bus            // Memory data bus
+	io        bus.Bus            // I/O data bus
+	OnIntAck  device.AckCallback // INT / NMI ack callback
+	OnWait    device.Callback    // WAIT line callback, on memory cycles
+	OnFetch   FetchCallback      // Opcode fetch callback (M1 cycle)
+	OnRefresh RefreshCallback    // Memory refresh callback
+	DataBus   byte               // Data bus on interrupt ack (IM 2 vector)
+	Mode8080  bool               // Intel 8080 mode
 }
 
 // New creates a new Z80
@@ -25,6 +38,7 @@ func New(clock device.Clock, mem, io bus.Bus) *Z80 {
 	z80.clock = clock
 	z80.mem = mem
 	z80.io = io
+	z80.DataBus = 0xff
 	z80.State.Init()
 	return z80
 }
@@ -62,7 +76,7 @@ func (z80 *Z80) Execute() int {
 	} else if z80.IntRq && z80.IFF1 {
 		z80.Interrupt()
 	} else {
-		z80.fetchAndExecute(z80.execute)
+		z80.step()
 	}
 	return z80.clock.Tstates() - tstate
 }
@@ -80,7 +94,7 @@ func (z80 *Z80) Interrupt() {
 	}
 	// Check EI activate
 	for z80.ActiveEI {
-		z80.fetchAndExecute(z80.execute)
+		z80.step()
 	}
 	// Check NMOS IFF2 parity bug
 	if z80.ReadIFF2 {
@@ -100,7 +114,7 @@ func (z80 *Z80) Interrupt() {
 		// RST 0x38
 		z80.PC = 0x0038
 	case 2:
-		tmp := (uint16(z80.I) << 8) | 0xff
+		tmp := (uint16(z80.I) << 8) | uint16(z80.DataBus)
 		pcl := z80.readByte(tmp)
 		tmp++
 		pch := z80.readByte(tmp)
@@ -128,12 +142,32 @@ func (z80 *Z80) NMInterrupt() {
 	z80.PC = 0x0066
 }
 
+// Ret returns from the current subroutine. Used by machine ROM traps.
+func (z80 *Z80) Ret() {
+	z80.ret(true)
+}
+
+// step executes the next instruction, of the Z80 or the Intel 8080 mode
+func (z80 *Z80) step() {
+	if z80.Mode8080 {
+		z80.execute8080()
+	} else {
+		z80.fetchAndExecute(z80.execute)
+	}
+}
+
 // fetchAndExecute fetchs and executes an opcode
 func (z80 *Z80) fetchAndExecute(execute func(byte)) {
 	opcode := z80.readByte(z80.PC)
+	if z80.OnFetch != nil {
+		opcode = z80.OnFetch(z80.PC, opcode)
+	}
 	z80.clock.Inc() // +1 tstate opcode execution
 	z80.incPC()
 	z80.incR()
+	if z80.OnRefresh != nil {
+		z80.OnRefresh(uint16(z80.I)<<8 | uint16(z80.R))
+	}
 	z80.ActiveEI = false
 	z80.ReadIFF2 = false
 	execute(opcode)
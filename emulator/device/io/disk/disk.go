@@ -0,0 +1,148 @@
+// Package disk contains the floppy disk images and drives
+package disk
+
+// -----------------------------------------------------------------------------
+// Disk image
+// -----------------------------------------------------------------------------
+
+// Sector is a disk sector : the ID field and the sector data
+type Sector struct {
+	Track   byte   // ID track number (C)
+	Side    byte   // ID side number (H)
+	ID      byte   // ID sector number (R)
+	Size    byte   // ID sector size code (N) : 128 << N bytes
+	Status1 byte   // uPD765 ST1 error flags of the sector (DSK images)
+	Status2 byte   // uPD765 ST2 error flags of the sector (DSK images)
+	Data    []byte // Sector data
+}
+
+// Track is a disk track, the sectors in physical order
+type Track struct {
+	Sectors []*Sector
+}
+
+// Find finds the sector with ID in the track
+func (track *Track) Find(id byte) *Sector {
+	for _, sector := range track.Sectors {
+		if sector.ID == id {
+			return sector
+		}
+	}
+	return nil
+}
+
+// Disk is a floppy disk image
+type Disk struct {
+	Cylinders      int      // Number of cylinders
+	Sides          int      // Number of sides
+	WriteProtected bool     // Disk is write protected
+	Modified       bool     // Disk data has been written
+	tracks         []*Track // Tracks by cylinder and side
+}
+
+// New creates an unformatted disk
+func New(cylinders, sides int) *Disk {
+	disk := new(Disk)
+	disk.Cylinders = cylinders
+	disk.Sides = sides
+	disk.tracks = make([]*Track, cylinders*sides)
+	for i := range disk.tracks {
+		disk.tracks[i] = new(Track)
+	}
+	return disk
+}
+
+// NewRegular creates a disk with the same sectors in all tracks. Data is
+// loaded in cylinder, side and sector order.
+func NewRegular(cylinders, sides, sectors, size int, firstID byte, data []byte) *Disk {
+	disk := New(cylinders, sides)
+	pos := 0
+	for cylinder := 0; cylinder < cylinders; cylinder++ {
+		for side := 0; side < sides; side++ {
+			track := disk.Track(cylinder, side)
+			for i := 0; i < sectors; i++ {
+				sector := &Sector{
+					Track: byte(cylinder),
+					Side:  byte(side),
+					ID:    firstID + byte(i),
+					Size:  SizeCode(size),
+					Data:  make([]byte, size)}
+				if pos < len(data) {
+					copy(sector.Data, data[pos:])
+				}
+				pos += size
+				track.Sectors = append(track.Sectors, sector)
+			}
+		}
+	}
+	return disk
+}
+
+// Track gets the track at cylinder and side, nil if out of the disk
+func (disk *Disk) Track(cylinder, side int) *Track {
+	if cylinder < 0 || cylinder >= disk.Cylinders || side < 0 || side >= disk.Sides {
+		return nil
+	}
+	return disk.tracks[cylinder*disk.Sides+side]
+}
+
+// SetTrack sets the track at cylinder and side
+func (disk *Disk) SetTrack(cylinder, side int, track *Track) {
+	if disk.Track(cylinder, side) != nil {
+		disk.tracks[cylinder*disk.Sides+side] = track
+		disk.Modified = true
+	}
+}
+
+// Data gets the sector data in cylinder, side and sector order
+func (disk *Disk) Data() []byte {
+	data := make([]byte, 0)
+	for _, track := range disk.tracks {
+		for _, sector := range track.Sectors {
+			data = append(data, sector.Data...)
+		}
+	}
+	return data
+}
+
+// SizeCode returns the sector size code (N) of a sector size
+func SizeCode(size int) byte {
+	code := byte(0)
+	for 128<<code < size && code < 7 {
+		code++
+	}
+	return code
+}
+
+// -----------------------------------------------------------------------------
+// Disk drive
+// -----------------------------------------------------------------------------
+
+// Drive is a floppy disk drive
+type Drive struct {
+	disk     *Disk // The inserted disk
+	Cylinder int   // Head cylinder position
+}
+
+// NewDrive creates a disk drive
+func NewDrive() *Drive { return new(Drive) }
+
+// Disk gets the inserted disk
+func (drive *Drive) Disk() *Disk { return drive.disk }
+
+// HasDisk checks if there is a disk inserted
+func (drive *Drive) HasDisk() bool { return drive.disk != nil }
+
+// Insert inserts a disk
+func (drive *Drive) Insert(disk *Disk) { drive.disk = disk }
+
+// Eject ejects the disk
+func (drive *Drive) Eject() { drive.disk = nil }
+
+// Track gets the track under the head at side
+func (drive *Drive) Track(side int) *Track {
+	if drive.disk == nil {
+		return nil
+	}
+	return drive.disk.Track(drive.Cylinder, side)
+}
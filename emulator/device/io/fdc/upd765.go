@@ -0,0 +1,563 @@
+package fdc
+
+import (
+	"github.com/jtruco/emu8/emulator/device/io/disk"
+)
+
+// -----------------------------------------------------------------------------
+// uPD765 - Floppy Disk Controller
+// -----------------------------------------------------------------------------
+
+// uPD765 main status register bits
+const (
+	MsrBusy    = 0x10 // Command in progress (CB)
+	MsrExecute = 0x20 // Execution phase (EXM)
+	MsrDIO     = 0x40 // Data direction : controller to CPU
+	MsrRQM     = 0x80 // Request for master
+)
+
+// uPD765 status register 0 bits
+const (
+	St0Head     = 0x04 // Head address
+	St0NotReady = 0x08 // Not ready
+	St0SeekEnd  = 0x20 // Seek end
+	St0Abnormal = 0x40 // Abnormal termination
+	St0Invalid  = 0x80 // Invalid command
+)
+
+// uPD765 status register 1 bits
+const (
+	St1MissingAM   = 0x01 // Missing address mark
+	St1NotWritable = 0x02 // Write protected
+	St1NoData      = 0x04 // Sector not found
+	St1DataError   = 0x20 // CRC error
+	St1EndCylinder = 0x80 // End of cylinder
+)
+
+// uPD765 status register 2 bits
+const (
+	St2MissingDAM  = 0x01 // Missing data address mark
+	St2DataError   = 0x20 // CRC error in data field
+	St2ControlMark = 0x40 // Deleted data address mark
+)
+
+// uPD765 status register 3 bits
+const (
+	St3Head    = 0x04 // Head address
+	St3TwoSide = 0x08 // Two sided drive
+	St3Track0  = 0x10 // Head at track 0
+	St3Ready   = 0x20 // Drive ready
+	St3WP      = 0x40 // Write protected
+)
+
+// uPD765 commands (low 5 bits)
+const (
+	cmdReadTrack      = 0x02
+	cmdSpecify        = 0x03
+	cmdSenseDrive     = 0x04
+	cmdWriteData      = 0x05
+	cmdReadData       = 0x06
+	cmdRecalibrate    = 0x07
+	cmdSenseInterrupt = 0x08
+	cmdWriteDeleted   = 0x09
+	cmdReadID         = 0x0a
+	cmdReadDeleted    = 0x0c
+	cmdFormatTrack    = 0x0d
+	cmdSeek           = 0x0f
+)
+
+// upd765Commands the command bytes of each command
+var upd765Commands = map[byte]int{
+	cmdReadTrack:      9,
+	cmdSpecify:        3,
+	cmdSenseDrive:     2,
+	cmdWriteData:      9,
+	cmdReadData:       9,
+	cmdRecalibrate:    2,
+	cmdSenseInterrupt: 1,
+	cmdWriteDeleted:   9,
+	cmdReadID:         2,
+	cmdReadDeleted:    9,
+	cmdFormatTrack:    6,
+	cmdSeek:           3,
+}
+
+// uPD765 command phases
+const (
+	phaseCommand = iota
+	phaseExecute
+	phaseResult
+)
+
+// uPD765 constants
+const (
+	upd765MaxCylinder = 83 // Last head cylinder position
+)
+
+// UPD765 is the NEC uPD765 floppy disk controller, in non-DMA mode. Command
+// execution is immediate : the CPU transfers the execution bytes through
+// the data register as soon as it polls the main status register. There is
+// no terminal count line, so multi-sector commands end at the EOT sector
+// with the end of cylinder error, as on the Amstrad CPC.
+type UPD765 struct {
+	drives    []*disk.Drive // The disk drives
+	motor     bool          // Drives motor on
+	phase     int           // Command phase
+	command   []byte        // Command bytes
+	result    []byte        // Result bytes
+	buffer    []byte        // Execution data buffer
+	position  int           // Execution data position
+	sector    *disk.Sector  // Sector being transferred
+	st0       byte          // Status register 0
+	st1       byte          // Status register 1
+	st2       byte          // Status register 2
+	interrupt []byte        // Pending seek interrupts (ST0 per drive)
+	readID    int           // Next read ID sector
+}
+
+// NewUPD765 creates an uPD765 controller with drives
+func NewUPD765(drives int) *UPD765 {
+	fdc := new(UPD765)
+	fdc.drives = make([]*disk.Drive, drives)
+	for i := range fdc.drives {
+		fdc.drives[i] = disk.NewDrive()
+	}
+	return fdc
+}
+
+// Drive gets the disk drive at index
+func (fdc *UPD765) Drive(index int) *disk.Drive { return fdc.drives[index] }
+
+// Motor returns the drives motor state
+func (fdc *UPD765) Motor() bool { return fdc.motor }
+
+// SetMotor switches the drives motor
+func (fdc *UPD765) SetMotor(on bool) { fdc.motor = on }
+
+// Device
+
+// Init initializes the controller
+func (fdc *UPD765) Init() { fdc.Reset() }
+
+// Reset resets the controller
+func (fdc *UPD765) Reset() {
+	fdc.motor = false
+	fdc.interrupt = nil
+	fdc.readID = 0
+	fdc.endCommand()
+	for _, drive := range fdc.drives {
+		drive.Cylinder = 0
+	}
+}
+
+// Registers
+
+// ReadStatus reads the main status register
+func (fdc *UPD765) ReadStatus() byte {
+	switch fdc.phase {
+	case phaseExecute:
+		status := byte(MsrRQM | MsrExecute | MsrBusy)
+		if fdc.isReading() {
+			status |= MsrDIO
+		}
+		return status
+	case phaseResult:
+		return MsrRQM | MsrDIO | MsrBusy
+	}
+	status := byte(MsrRQM)
+	if len(fdc.command) > 0 {
+		status |= MsrBusy
+	}
+	return status
+}
+
+// ReadData reads the data register
+func (fdc *UPD765) ReadData() byte {
+	switch fdc.phase {
+	case phaseExecute:
+		if !fdc.isReading() {
+			return 0xff
+		}
+		data := fdc.buffer[fdc.position]
+		fdc.position++
+		if fdc.position == len(fdc.buffer) {
+			fdc.endTransfer()
+		}
+		return data
+	case phaseResult:
+		data := fdc.result[0]
+		fdc.result = fdc.result[1:]
+		if len(fdc.result) == 0 {
+			fdc.endCommand()
+		}
+		return data
+	}
+	return 0xff
+}
+
+// WriteData writes the data register
+func (fdc *UPD765) WriteData(data byte) {
+	switch fdc.phase {
+	case phaseCommand:
+		fdc.command = append(fdc.command, data)
+		length, ok := upd765Commands[fdc.command[0]&0x1f]
+		if !ok {
+			fdc.setResult(St0Invalid)
+			return
+		}
+		if len(fdc.command) == length {
+			fdc.execute()
+		}
+	case phaseExecute:
+		if fdc.isReading() {
+			return
+		}
+		fdc.buffer[fdc.position] = data
+		fdc.position++
+		if fdc.position == len(fdc.buffer) {
+			fdc.endTransfer()
+		}
+	}
+}
+
+// isReading checks if the execution phase transfers data to the CPU
+func (fdc *UPD765) isReading() bool {
+	switch fdc.command[0] & 0x1f {
+	case cmdReadData, cmdReadDeleted, cmdReadTrack:
+		return true
+	}
+	return false
+}
+
+// Commands
+
+// execute executes the command
+func (fdc *UPD765) execute() {
+	fdc.st0 = 0
+	if len(fdc.command) > 1 {
+		fdc.st0 = fdc.command[1] & (St0Head | 0x03)
+	}
+	fdc.st1, fdc.st2 = 0, 0
+	switch fdc.command[0] & 0x1f {
+	case cmdSpecify:
+		fdc.endCommand() // step rate & head times are not emulated
+	case cmdSenseDrive:
+		fdc.setResult(fdc.senseDrive())
+	case cmdRecalibrate:
+		fdc.seek(0)
+	case cmdSeek:
+		fdc.seek(int(fdc.command[2]))
+	case cmdSenseInterrupt:
+		fdc.senseInterrupt()
+	case cmdReadID:
+		fdc.executeReadID()
+	case cmdReadData, cmdReadDeleted, cmdWriteData, cmdWriteDeleted:
+		fdc.transferSector()
+	case cmdReadTrack:
+		fdc.executeReadTrack()
+	case cmdFormatTrack:
+		fdc.executeFormat()
+	}
+}
+
+// drive gets the drive selected by the command
+func (fdc *UPD765) drive() *disk.Drive {
+	index := int(fdc.command[1] & 0x03)
+	if index >= len(fdc.drives) {
+		return nil
+	}
+	return fdc.drives[index]
+}
+
+// side gets the head selected by the command
+func (fdc *UPD765) side() int { return int(fdc.command[1]&St0Head) >> 2 }
+
+// isReady checks if the selected drive is ready
+func (fdc *UPD765) isReady() bool {
+	drive := fdc.drive()
+	return drive != nil && drive.HasDisk() && fdc.motor
+}
+
+// track gets the track under the head of the selected drive
+func (fdc *UPD765) track() *disk.Track {
+	return fdc.drive().Track(fdc.side())
+}
+
+// senseDrive returns the status register 3 of the drive
+func (fdc *UPD765) senseDrive() byte {
+	status := fdc.command[1] & (St3Head | 0x03)
+	drive := fdc.drive()
+	if drive == nil {
+		return status
+	}
+	if drive.Cylinder == 0 {
+		status |= St3Track0
+	}
+	if fdc.isReady() {
+		status |= St3Ready
+		if drive.Disk().Sides > 1 {
+			status |= St3TwoSide
+		}
+		if drive.Disk().WriteProtected {
+			status |= St3WP
+		}
+	}
+	return status
+}
+
+// seek moves the head of the drive to cylinder, and sets the seek interrupt
+func (fdc *UPD765) seek(cylinder int) {
+	status := fdc.command[1]&0x03 | St0SeekEnd
+	if drive := fdc.drive(); drive != nil {
+		if cylinder > upd765MaxCylinder {
+			cylinder = upd765MaxCylinder
+		}
+		drive.Cylinder = cylinder
+	}
+	if !fdc.isReady() {
+		status |= St0Abnormal | St0NotReady
+	}
+	fdc.interrupt = append(fdc.interrupt, status)
+	fdc.endCommand()
+}
+
+// senseInterrupt returns the pending seek interrupt and the cylinder
+func (fdc *UPD765) senseInterrupt() {
+	if len(fdc.interrupt) == 0 {
+		fdc.setResult(St0Invalid)
+		return
+	}
+	status := fdc.interrupt[0]
+	fdc.interrupt = fdc.interrupt[1:]
+	cylinder := 0
+	if int(status&0x03) < len(fdc.drives) {
+		cylinder = fdc.drives[status&0x03].Cylinder
+	}
+	fdc.setResult(status, byte(cylinder))
+}
+
+// executeReadID returns the ID field of the next sector
+func (fdc *UPD765) executeReadID() {
+	if !fdc.isReady() {
+		fdc.endError(St0NotReady, 0, 0)
+		return
+	}
+	track := fdc.track()
+	if track == nil || len(track.Sectors) == 0 {
+		fdc.endError(0, St1MissingAM|St1NoData, 0)
+		return
+	}
+	sector := track.Sectors[fdc.readID%len(track.Sectors)]
+	fdc.readID++
+	fdc.setResult(fdc.st0, 0, 0, sector.Track, sector.Side, sector.ID, sector.Size)
+}
+
+// transferSector starts the transfer of the command sector (C, H, R, N)
+func (fdc *UPD765) transferSector() {
+	if !fdc.isReady() {
+		fdc.endError(St0NotReady, 0, 0)
+		return
+	}
+	write := fdc.command[0]&0x1f == cmdWriteData || fdc.command[0]&0x1f == cmdWriteDeleted
+	if write && fdc.drive().Disk().WriteProtected {
+		fdc.endError(0, St1NotWritable, 0)
+		return
+	}
+	sector := fdc.findSector()
+	if sector == nil {
+		fdc.endError(0, St1NoData, 0)
+		return
+	}
+	deleted := sector.Status2&St2ControlMark != 0
+	if !write && deleted != (fdc.command[0]&0x1f == cmdReadDeleted) {
+		if fdc.command[0]&0x20 != 0 { // skip
+			fdc.nextSector()
+			return
+		}
+		fdc.st2 |= St2ControlMark // ends after the sector
+	}
+	fdc.sector = sector
+	fdc.buffer = sector.Data
+	if fdc.command[5] == 0 && int(fdc.command[8]) < len(sector.Data) {
+		fdc.buffer = sector.Data[:fdc.command[8]] // DTL
+	}
+	if write {
+		fdc.buffer = make([]byte, len(fdc.buffer))
+	}
+	if len(fdc.buffer) == 0 {
+		fdc.endTransfer()
+		return
+	}
+	fdc.position = 0
+	fdc.phase = phaseExecute
+}
+
+// findSector finds the command sector in the track under the head
+func (fdc *UPD765) findSector() *disk.Sector {
+	track := fdc.track()
+	if track == nil {
+		return nil
+	}
+	for _, sector := range track.Sectors {
+		if sector.Track == fdc.command[2] && sector.Side == fdc.command[3] &&
+			sector.ID == fdc.command[4] && sector.Size == fdc.command[5] {
+			return sector
+		}
+	}
+	return nil
+}
+
+// endTransfer ends the sector transfer and continues with the next sector
+func (fdc *UPD765) endTransfer() {
+	sector := fdc.sector
+	switch fdc.command[0] & 0x1f {
+	case cmdWriteData, cmdWriteDeleted:
+		copy(sector.Data, fdc.buffer)
+		sector.Status2 &^= St2ControlMark
+		if fdc.command[0]&0x1f == cmdWriteDeleted {
+			sector.Status2 |= St2ControlMark
+		}
+		fdc.drive().Disk().Modified = true
+	case cmdReadTrack:
+		fdc.endError(0, St1EndCylinder, 0)
+		return
+	case cmdFormatTrack:
+		fdc.endFormat()
+		return
+	default:
+		if errors := sector.Status1 & St1DataError; errors != 0 {
+			fdc.endError(0, errors, sector.Status2&St2DataError)
+			return
+		}
+	}
+	if fdc.st2&St2ControlMark != 0 {
+		fdc.endError(0, 0, 0)
+		return
+	}
+	fdc.nextSector()
+}
+
+// nextSector continues the command with the next sector, up to EOT
+func (fdc *UPD765) nextSector() {
+	fdc.sector = nil
+	if fdc.command[4] == fdc.command[6] { // EOT
+		if fdc.command[0]&0x80 != 0 && fdc.command[3]&0x01 == 0 { // multi-track
+			fdc.command[1] |= St0Head
+			fdc.command[3] |= 0x01
+			fdc.command[4] = 1
+			fdc.st0 |= St0Head
+			fdc.transferSector()
+			return
+		}
+		// no terminal count : ends with end of cylinder error
+		fdc.command[2]++
+		fdc.command[4] = 1
+		if fdc.command[0]&0x80 != 0 {
+			fdc.command[3] &^= 0x01
+		}
+		fdc.endError(0, St1EndCylinder, 0)
+		return
+	}
+	fdc.command[4]++
+	fdc.transferSector()
+}
+
+// executeReadTrack reads the data of the track sectors, up to EOT sectors
+func (fdc *UPD765) executeReadTrack() {
+	if !fdc.isReady() {
+		fdc.endError(St0NotReady, 0, 0)
+		return
+	}
+	track := fdc.track()
+	if track == nil || len(track.Sectors) == 0 {
+		fdc.endError(0, St1MissingAM|St1NoData, 0)
+		return
+	}
+	var data []byte
+	for i, sector := range track.Sectors {
+		if i == int(fdc.command[6]) {
+			break
+		}
+		if sector.ID != fdc.command[4]+byte(i) {
+			fdc.st1 |= St1NoData
+		}
+		data = append(data, sector.Data...)
+	}
+	fdc.buffer = data
+	fdc.position = 0
+	fdc.phase = phaseExecute
+}
+
+// executeFormat starts the track format : the CPU writes the sector IDs
+func (fdc *UPD765) executeFormat() {
+	if !fdc.isReady() {
+		fdc.endError(St0NotReady, 0, 0)
+		return
+	}
+	if fdc.drive().Disk().WriteProtected {
+		fdc.endError(0, St1NotWritable, 0)
+		return
+	}
+	fdc.buffer = make([]byte, int(fdc.command[3])*4)
+	fdc.position = 0
+	if len(fdc.buffer) == 0 {
+		fdc.endFormat()
+		return
+	}
+	fdc.phase = phaseExecute
+}
+
+// endFormat builds the track sectors from the written IDs
+func (fdc *UPD765) endFormat() {
+	track := new(disk.Track)
+	for i := 0; i < len(fdc.buffer); i += 4 {
+		sector := &disk.Sector{
+			Track: fdc.buffer[i],
+			Side:  fdc.buffer[i+1],
+			ID:    fdc.buffer[i+2],
+			Size:  fdc.buffer[i+3],
+			Data:  make([]byte, 128<<(fdc.command[2]&0x07))}
+		for j := range sector.Data {
+			sector.Data[j] = fdc.command[5]
+		}
+		track.Sectors = append(track.Sectors, sector)
+	}
+	drive := fdc.drive()
+	drive.Disk().SetTrack(drive.Cylinder, fdc.side(), track)
+	drive.Disk().Modified = true
+	fdc.setResult(fdc.st0, 0, 0, 0, 0, 0, fdc.command[2])
+}
+
+// endError ends the read & write commands with the status flags and the
+// current sector ID
+func (fdc *UPD765) endError(st0, st1, st2 byte) {
+	fdc.st0 |= st0
+	if st0|st1|st2|fdc.st2 != 0 {
+		fdc.st0 |= St0Abnormal
+	}
+	fdc.st1 |= st1
+	fdc.st2 |= st2
+	if fdc.command[0]&0x1f == cmdFormatTrack || fdc.command[0]&0x1f == cmdReadID {
+		fdc.setResult(fdc.st0, fdc.st1, fdc.st2, 0, 0, 0, 0)
+		return
+	}
+	fdc.setResult(fdc.st0, fdc.st1, fdc.st2,
+		fdc.command[2], fdc.command[3], fdc.command[4], fdc.command[5])
+}
+
+// setResult starts the result phase
+func (fdc *UPD765) setResult(result ...byte) {
+	fdc.buffer = nil
+	fdc.sector = nil
+	fdc.result = result
+	fdc.phase = phaseResult
+}
+
+// endCommand ends the command, ready for the next one
+func (fdc *UPD765) endCommand() {
+	fdc.buffer = nil
+	fdc.sector = nil
+	fdc.result = nil
+	fdc.command = nil
+	fdc.phase = phaseCommand
+}
@@ -0,0 +1,492 @@
+// Package fdc contains floppy disk controller devices
+package fdc
+
+import (
+	"github.com/jtruco/emu8/emulator/device"
+	"github.com/jtruco/emu8/emulator/device/io/disk"
+)
+
+// -----------------------------------------------------------------------------
+// WD1793 - Floppy Disk Controller
+// -----------------------------------------------------------------------------
+
+// WD1793 registers
+const (
+	RegStatus  = 0 // Status (read) & Command (write) register
+	RegTrack   = 1 // Track register
+	RegSector  = 2 // Sector register
+	RegData    = 3 // Data register
+	RegCommand = RegStatus
+)
+
+// WD1793 status bits
+const (
+	StatusBusy       = 0x01
+	StatusIndex      = 0x02 // Type I commands
+	StatusDRQ        = 0x02 // Type II & III commands
+	StatusTrack0     = 0x04 // Type I commands
+	StatusLostData   = 0x04 // Type II & III commands
+	StatusCRCError   = 0x08
+	StatusSeekError  = 0x10 // Type I commands
+	StatusNotFound   = 0x10 // Type II & III commands
+	StatusHeadLoaded = 0x20 // Type I commands
+	StatusSpinUp     = 0x20 // Type I commands (WD1772)
+	StatusWP         = 0x40
+	StatusNotReady   = 0x80
+	StatusMotorOn    = 0x80 // WD1772
+)
+
+// Controller models
+const (
+	ModelWD1793 = iota // Western Digital WD1793
+	ModelWD1772        // Western Digital WD1772 : motor control, no ready line
+)
+
+// WD1793 constants
+const (
+	wdMaxCylinder    = 83   // Last head cylinder position
+	wdTrackLength    = 6250 // Write track bytes (MFM)
+	wdIndexLength    = 50   // Index pulse length, 1/50 of a revolution
+	wdRevolutionsSec = 5    // Disk revolutions per second (300 rpm)
+	wdMotorRevs      = 10   // Motor on revolutions after a command (WD1772)
+)
+
+// WD1793 is the Western Digital WD1793 floppy disk controller. Commands
+// execute immediately, the data transfers are driven by the CPU through
+// the DRQ and INTRQ lines. The WD1772 model has the same commands, it
+// controls the drive motor and has no side compare.
+type WD1793 struct {
+	model      int           // Controller model
+	clock      device.Clock  // The system clock
+	revolution int64         // TStates per disk revolution
+	drives     []*disk.Drive // The disk drives
+	drive      *disk.Drive   // The selected drive
+	side       int           // The selected side
+	command    byte          // Command register
+	status     byte          // Status register
+	track      byte          // Track register
+	sector     byte          // Sector register
+	data       byte          // Data register
+	typeI      bool          // Last command is a type I command
+	stepIn     bool          // Last step direction
+	drq        bool          // Data request line
+	intrq      bool          // Interrupt request line
+	buffer     []byte        // Data transfer buffer
+	position   int           // Data transfer position
+	current    *disk.Sector  // Sector being written
+	addressID  int           // Next read address sector
+	motorOff   int64         // Motor off clock (WD1772)
+}
+
+// New creates a WD1793 controller with drives, clocked at frequency Hz
+func New(clock device.Clock, frequency int, drives int) *WD1793 {
+	fdc := new(WD1793)
+	fdc.clock = clock
+	fdc.revolution = int64(frequency / wdRevolutionsSec)
+	fdc.drives = make([]*disk.Drive, drives)
+	for i := range fdc.drives {
+		fdc.drives[i] = disk.NewDrive()
+	}
+	fdc.drive = fdc.drives[0]
+	return fdc
+}
+
+// NewWD1772 creates a WD1772 controller with drives, clocked at frequency Hz
+func NewWD1772(clock device.Clock, frequency int, drives int) *WD1793 {
+	fdc := New(clock, frequency, drives)
+	fdc.model = ModelWD1772
+	return fdc
+}
+
+// Drive gets the disk drive at index
+func (fdc *WD1793) Drive(index int) *disk.Drive { return fdc.drives[index] }
+
+// Select selects the disk drive
+func (fdc *WD1793) Select(index int) {
+	if index < len(fdc.drives) {
+		fdc.drive = fdc.drives[index]
+	}
+}
+
+// SetSide selects the disk side
+func (fdc *WD1793) SetSide(side int) { fdc.side = side }
+
+// DRQ returns the data request line
+func (fdc *WD1793) DRQ() bool { return fdc.drq }
+
+// INTRQ returns the interrupt request line
+func (fdc *WD1793) INTRQ() bool { return fdc.intrq }
+
+// Device
+
+// Init initializes the controller
+func (fdc *WD1793) Init() { fdc.Reset() }
+
+// Reset resets the controller
+func (fdc *WD1793) Reset() {
+	fdc.command = 0
+	fdc.status = 0
+	fdc.track = 0
+	fdc.sector = 1
+	fdc.data = 0
+	fdc.typeI = true
+	fdc.drq = false
+	fdc.intrq = false
+	fdc.buffer = nil
+	fdc.current = nil
+	fdc.motorOff = 0
+	for _, drive := range fdc.drives {
+		drive.Cylinder = 0
+	}
+}
+
+// Registers
+
+// Read reads the register
+func (fdc *WD1793) Read(register int) byte {
+	switch register & 0x03 {
+	case RegStatus:
+		fdc.intrq = false
+		return fdc.readStatus()
+	case RegTrack:
+		return fdc.track
+	case RegSector:
+		return fdc.sector
+	default:
+		if fdc.drq && fdc.current == nil {
+			fdc.data = fdc.buffer[fdc.position]
+			fdc.position++
+			if fdc.position == len(fdc.buffer) {
+				fdc.endTransfer()
+			}
+		}
+		return fdc.data
+	}
+}
+
+// Write writes the register
+func (fdc *WD1793) Write(register int, data byte) {
+	switch register & 0x03 {
+	case RegCommand:
+		fdc.execute(data)
+	case RegTrack:
+		fdc.track = data
+	case RegSector:
+		fdc.sector = data
+	default:
+		fdc.data = data
+		if fdc.drq && fdc.current != nil {
+			fdc.buffer[fdc.position] = data
+			fdc.position++
+			if fdc.position == len(fdc.buffer) {
+				fdc.endTransfer()
+			}
+		}
+	}
+}
+
+// readStatus builds the status register
+func (fdc *WD1793) readStatus() byte {
+	status := fdc.status &^ (StatusNotReady | StatusWP | StatusDRQ)
+	if fdc.model == ModelWD1772 {
+		if fdc.status&StatusBusy != 0 || fdc.clock.Total() < fdc.motorOff {
+			status |= StatusMotorOn
+		}
+	} else if !fdc.drive.HasDisk() {
+		status |= StatusNotReady
+	}
+	if fdc.drive.HasDisk() && fdc.drive.Disk().WriteProtected && (fdc.typeI || fdc.command&0x20 != 0) {
+		status |= StatusWP
+	}
+	if fdc.typeI {
+		status &^= StatusTrack0
+		if fdc.drive.Cylinder == 0 {
+			status |= StatusTrack0
+		}
+		if fdc.drive.HasDisk() && fdc.clock.Total()%fdc.revolution < fdc.revolution/wdIndexLength {
+			status |= StatusIndex
+		}
+	} else if fdc.drq {
+		status |= StatusDRQ
+	}
+	return status
+}
+
+// Commands
+
+// execute executes a command
+func (fdc *WD1793) execute(command byte) {
+	if command&0xf0 == 0xd0 { // Force interrupt
+		if fdc.status&StatusBusy != 0 {
+			fdc.status &^= StatusBusy
+		} else {
+			fdc.typeI = true
+			fdc.status = 0
+		}
+		fdc.drq = false
+		fdc.buffer = nil
+		fdc.current = nil
+		fdc.intrq = command&0x08 != 0
+		return
+	}
+	if fdc.status&StatusBusy != 0 {
+		return // ignored while busy
+	}
+	fdc.command = command
+	fdc.intrq = false
+	fdc.drq = false
+	fdc.current = nil
+	fdc.motorOff = fdc.clock.Total() + wdMotorRevs*fdc.revolution
+	if command&0x80 == 0 {
+		fdc.typeI = true
+		fdc.executeTypeI(command)
+		return
+	}
+	fdc.typeI = false
+	fdc.status = 0
+	if !fdc.drive.HasDisk() {
+		if fdc.model == ModelWD1772 {
+			fdc.endCommand(StatusNotFound) // no index pulses
+		} else {
+			fdc.endCommand(StatusNotReady)
+		}
+		return
+	}
+	switch command & 0xf0 {
+	case 0x80, 0x90: // Read sector
+		fdc.readSector()
+	case 0xa0, 0xb0: // Write sector
+		fdc.writeSector()
+	case 0xc0: // Read address
+		fdc.readAddress()
+	case 0xe0: // Read track
+		fdc.readTrack()
+	case 0xf0: // Write track
+		fdc.writeTrack()
+	}
+}
+
+// executeTypeI executes the head positioning commands
+func (fdc *WD1793) executeTypeI(command byte) {
+	fdc.status = 0
+	if command&0x08 != 0 || fdc.model == ModelWD1772 { // h flag or spin-up
+		fdc.status = StatusHeadLoaded
+	}
+	switch command & 0xf0 {
+	case 0x00: // Restore
+		fdc.drive.Cylinder = 0
+		fdc.track = 0
+	case 0x10: // Seek
+		fdc.moveHead(int(fdc.data) - int(fdc.track))
+		fdc.track = fdc.data
+	default: // Step, Step-in & Step-out
+		switch command & 0x60 {
+		case 0x40:
+			fdc.stepIn = true
+		case 0x60:
+			fdc.stepIn = false
+		}
+		step := -1
+		if fdc.stepIn {
+			step = 1
+		}
+		fdc.moveHead(step)
+		if command&0x10 != 0 { // u flag
+			fdc.track += byte(step)
+		}
+	}
+	if command&0x04 != 0 { // verify
+		track := fdc.drive.Track(fdc.side)
+		found := false
+		if track != nil {
+			for _, sector := range track.Sectors {
+				if sector.Track == fdc.track {
+					found = true
+					break
+				}
+			}
+		}
+		if !found {
+			fdc.status |= StatusSeekError
+		}
+	}
+	fdc.intrq = true
+}
+
+// moveHead moves the head of the selected drive
+func (fdc *WD1793) moveHead(steps int) {
+	cylinder := fdc.drive.Cylinder + steps
+	if cylinder < 0 {
+		cylinder = 0
+	} else if cylinder > wdMaxCylinder {
+		cylinder = wdMaxCylinder
+	}
+	fdc.drive.Cylinder = cylinder
+}
+
+// findSector finds the sector in the track under the head
+func (fdc *WD1793) findSector() *disk.Sector {
+	track := fdc.drive.Track(fdc.side)
+	if track == nil {
+		return nil
+	}
+	for _, sector := range track.Sectors {
+		if sector.ID != fdc.sector || sector.Track != fdc.track {
+			continue
+		}
+		if fdc.model == ModelWD1793 && fdc.command&0x02 != 0 && sector.Side != (fdc.command>>3)&0x01 {
+			continue // side compare
+		}
+		return sector
+	}
+	return nil
+}
+
+// readSector starts the sector read
+func (fdc *WD1793) readSector() {
+	sector := fdc.findSector()
+	if sector == nil {
+		fdc.endCommand(StatusNotFound)
+		return
+	}
+	fdc.startTransfer(sector.Data, nil)
+}
+
+// writeSector starts the sector write
+func (fdc *WD1793) writeSector() {
+	if fdc.drive.Disk().WriteProtected {
+		fdc.endCommand(StatusWP)
+		return
+	}
+	sector := fdc.findSector()
+	if sector == nil {
+		fdc.endCommand(StatusNotFound)
+		return
+	}
+	fdc.startTransfer(make([]byte, len(sector.Data)), sector)
+}
+
+// readAddress reads the next sector ID field
+func (fdc *WD1793) readAddress() {
+	track := fdc.drive.Track(fdc.side)
+	if track == nil || len(track.Sectors) == 0 {
+		fdc.endCommand(StatusNotFound)
+		return
+	}
+	sector := track.Sectors[fdc.addressID%len(track.Sectors)]
+	fdc.addressID++
+	fdc.sector = sector.Track
+	fdc.startTransfer([]byte{sector.Track, sector.Side, sector.ID, sector.Size, 0, 0}, nil)
+}
+
+// readTrack reads the track sectors, with their ID and data fields
+func (fdc *WD1793) readTrack() {
+	track := fdc.drive.Track(fdc.side)
+	if track == nil {
+		fdc.endCommand(StatusNotFound)
+		return
+	}
+	data := make([]byte, 0, wdTrackLength)
+	for _, sector := range track.Sectors {
+		data = append(data, 0xa1, 0xa1, 0xa1, 0xfe, sector.Track, sector.Side, sector.ID, sector.Size, 0, 0)
+		data = append(data, 0xa1, 0xa1, 0xa1, 0xfb)
+		data = append(data, sector.Data...)
+		data = append(data, 0, 0)
+	}
+	if len(data) == 0 {
+		data = append(data, 0x4e)
+	}
+	fdc.startTransfer(data, nil)
+}
+
+// writeTrack starts the track format
+func (fdc *WD1793) writeTrack() {
+	if fdc.drive.Disk().WriteProtected {
+		fdc.endCommand(StatusWP)
+		return
+	}
+	fdc.startTransfer(make([]byte, wdTrackLength), &disk.Sector{})
+}
+
+// startTransfer starts the data transfer. Writes are stored into sector.
+func (fdc *WD1793) startTransfer(buffer []byte, sector *disk.Sector) {
+	fdc.buffer = buffer
+	fdc.position = 0
+	fdc.current = sector
+	fdc.status = StatusBusy
+	fdc.drq = true
+}
+
+// endTransfer ends the data transfer
+func (fdc *WD1793) endTransfer() {
+	fdc.drq = false
+	switch fdc.command & 0xf0 {
+	case 0x90: // Read multiple sectors
+		fdc.sector++
+		if sector := fdc.findSector(); sector != nil {
+			fdc.startTransfer(sector.Data, nil)
+			return
+		}
+	case 0xa0, 0xb0: // Write sector
+		copy(fdc.current.Data, fdc.buffer)
+		fdc.drive.Disk().Modified = true
+		if fdc.command&0x10 != 0 {
+			fdc.sector++
+			if sector := fdc.findSector(); sector != nil {
+				fdc.startTransfer(make([]byte, len(sector.Data)), sector)
+				return
+			}
+		}
+	case 0xf0: // Write track
+		fdc.drive.Disk().SetTrack(fdc.drive.Cylinder, fdc.side, formatTrack(fdc.buffer))
+	}
+	fdc.endCommand(0)
+}
+
+// endCommand ends the command with status
+func (fdc *WD1793) endCommand(status byte) {
+	fdc.buffer = nil
+	fdc.current = nil
+	fdc.drq = false
+	fdc.status = status
+	fdc.intrq = true
+}
+
+// formatTrack builds the track sectors from the write track data. Bytes
+// 0xf5 write the A1 sync marks and 0xf7 the CRC.
+func formatTrack(data []byte) *disk.Track {
+	track := new(disk.Track)
+	var sector *disk.Sector
+	for pos := 0; pos < len(data); pos++ {
+		if data[pos] != 0xf5 {
+			continue
+		}
+		for pos < len(data) && data[pos] == 0xf5 {
+			pos++
+		}
+		if pos >= len(data) {
+			break
+		}
+		switch data[pos] {
+		case 0xfe: // ID address mark
+			if pos+4 < len(data) {
+				sector = &disk.Sector{
+					Track: data[pos+1],
+					Side:  data[pos+2],
+					ID:    data[pos+3],
+					Size:  data[pos+4] & 0x03}
+				pos += 4
+			}
+		case 0xfb, 0xf8: // Data address mark
+			if sector != nil {
+				size := 128 << sector.Size
+				sector.Data = make([]byte, size)
+				copy(sector.Data, data[pos+1:])
+				track.Sectors = append(track.Sectors, sector)
+				pos += size
+				sector = nil
+			}
+		}
+	}
+	return track
+}
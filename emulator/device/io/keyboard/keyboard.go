@@ -25,6 +25,19 @@ type Receiver interface {
 	ProcessKey(key Key, pressed bool) // Sets key state
 }
 
+// NamedReceiver is a keyboard receiver with named machine keys
+type NamedReceiver interface {
+	Receiver                  // Is a keyboard event receiver
+	KeyNames() map[string]Key // Machine keys by name
+}
+
+// TextReceiver is a keyboard receiver that can type text following the
+// machine keyboard layout
+type TextReceiver interface {
+	Receiver                      // Is a keyboard event receiver
+	TextKeys(text string) [][]Key // Key combinations that type the text
+}
+
 // KeyEvent is a keyboard event
 type KeyEvent struct {
 	device.Event      // Is a device event
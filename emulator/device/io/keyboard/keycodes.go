@@ -130,3 +130,110 @@ const (
 	KeyRAlt   = 230
 	KeyRGui   = 231
 )
+
+// KeyNames host keyboard key codes by name
+var KeyNames = map[string]KeyCode{
+	"A":            KeyA,
+	"B":            KeyB,
+	"C":            KeyC,
+	"D":            KeyD,
+	"E":            KeyE,
+	"F":            KeyF,
+	"G":            KeyG,
+	"H":            KeyH,
+	"I":            KeyI,
+	"J":            KeyJ,
+	"K":            KeyK,
+	"L":            KeyL,
+	"M":            KeyM,
+	"N":            KeyN,
+	"O":            KeyO,
+	"P":            KeyP,
+	"Q":            KeyQ,
+	"R":            KeyR,
+	"S":            KeyS,
+	"T":            KeyT,
+	"U":            KeyU,
+	"V":            KeyV,
+	"W":            KeyW,
+	"X":            KeyX,
+	"Y":            KeyY,
+	"Z":            KeyZ,
+	"1":            Key1,
+	"2":            Key2,
+	"3":            Key3,
+	"4":            Key4,
+	"5":            Key5,
+	"6":            Key6,
+	"7":            Key7,
+	"8":            Key8,
+	"9":            Key9,
+	"0":            Key0,
+	"Return":       KeyReturn,
+	"Escape":       KeyEscape,
+	"Backspace":    KeyBackspace,
+	"Tab":          KeyTab,
+	"Space":        KeySpace,
+	"Minus":        KeyMinus,
+	"Equals":       KeyEquals,
+	"LeftBracket":  KeyLeftBracket,
+	"RightBracket": KeyRightBracket,
+	"BackSlash":    KeyBackSlash,
+	"NonUsHash":    KeyNonUsHash,
+	"Semicolon":    KeySemicolon,
+	"Apostrophe":   KeyApostrophe,
+	"Grave":        KeyGrave,
+	"Comma":        KeyComma,
+	"Period":       KeyPeriod,
+	"Slash":        KeySlash,
+	"CapsLock":     KeyCapsLock,
+	"F1":           KeyF1,
+	"F2":           KeyF2,
+	"F3":           KeyF3,
+	"F4":           KeyF4,
+	"F5":           KeyF5,
+	"F6":           KeyF6,
+	"F7":           KeyF7,
+	"F8":           KeyF8,
+	"F9":           KeyF9,
+	"F10":          KeyF10,
+	"F11":          KeyF11,
+	"F12":          KeyF12,
+	"PrintScreen":  KeyPrintScreen,
+	"ScrollLock":   KeyScrollLock,
+	"Pause":        KeyPause,
+	"Insert":       KeyInsert,
+	"Home":         KeyHome,
+	"PageUp":       KeyPageUp,
+	"Delete":       KeyDelete,
+	"End":          KeyEnd,
+	"PageDown":     KeyPageDown,
+	"Right":        KeyRight,
+	"Left":         KeyLeft,
+	"Down":         KeyDown,
+	"Up":           KeyUp,
+	"PadDivide":    KeyPadDivide,
+	"PadMultiply":  KeyPadMultiply,
+	"PadMinus":     KeyPadMinus,
+	"PadPlus":      KeyPadPlus,
+	"PadEnter":     KeyPadEnter,
+	"Pad1":         KeyPad1,
+	"Pad2":         KeyPad2,
+	"Pad3":         KeyPad3,
+	"Pad4":         KeyPad4,
+	"Pad5":         KeyPad5,
+	"Pad6":         KeyPad6,
+	"Pad7":         KeyPad7,
+	"Pad8":         KeyPad8,
+	"Pad9":         KeyPad9,
+	"Pad0":         KeyPad0,
+	"PadPeriod":    KeyPadPeriod,
+	"LCtrl":        KeyLCtrl,
+	"LShift":       KeyLShift,
+	"LAlt":         KeyLAlt,
+	"LGui":         KeyLGui,
+	"RCtrl":        KeyRCtrl,
+	"RShift":       KeyRShift,
+	"RAlt":         KeyRAlt,
+	"RGui":         KeyRGui,
+}
@@ -0,0 +1,95 @@
+package keyboard
+
+// -----------------------------------------------------------------------------
+// Typist
+// -----------------------------------------------------------------------------
+
+// Typist timing constants (frames)
+const (
+	TypistPressFrames   = 3 // Frames a key combination is pressed
+	TypistReleaseFrames = 3 // Frames between key combinations
+)
+
+// TypistPause is an empty key combination, a pause between key combinations
+var TypistPause = []Key{}
+
+// typistStep is a key combination or a wait
+type typistStep struct {
+	keys   []Key // Keys pressed at once
+	frames int   // Frames to wait
+}
+
+// Typist types key combinations into a keyboard receiver, spread over frames,
+// so the machine keyboard scanner can see each one of them.
+type Typist struct {
+	receiver Receiver     // The keyboard receiver
+	queue    []typistStep // Pending steps
+	pressed  []Key        // Current pressed keys
+	count    int          // Frames left of current step
+}
+
+// NewTypist creates a new typist for the keyboard receiver
+func NewTypist(receiver Receiver) *Typist {
+	typist := new(Typist)
+	typist.receiver = receiver
+	return typist
+}
+
+// IsTyping returns true if there are pending key combinations
+func (typist *Typist) IsTyping() bool {
+	return len(typist.queue) > 0 || typist.pressed != nil || typist.count > 0
+}
+
+// Type adds key combinations to type. An empty combination is a pause.
+func (typist *Typist) Type(keys ...[]Key) {
+	for _, combination := range keys {
+		typist.queue = append(typist.queue, typistStep{keys: combination})
+	}
+}
+
+// Wait adds a number of frames to wait
+func (typist *Typist) Wait(frames int) {
+	typist.queue = append(typist.queue, typistStep{frames: frames})
+}
+
+// Cancel releases keys and cancels pending key combinations
+func (typist *Typist) Cancel() {
+	typist.release()
+	typist.queue = typist.queue[:0]
+	typist.count = 0
+}
+
+// Frame emulates one frame of typing
+func (typist *Typist) Frame() {
+	if typist.count > 0 {
+		typist.count--
+		return
+	}
+	if typist.pressed != nil {
+		typist.release()
+		typist.count = TypistReleaseFrames
+		return
+	}
+	if len(typist.queue) == 0 {
+		return
+	}
+	step := typist.queue[0]
+	typist.queue = typist.queue[1:]
+	if step.keys == nil {
+		typist.count = step.frames
+		return
+	}
+	for _, key := range step.keys {
+		typist.receiver.ProcessKey(key, true)
+	}
+	typist.pressed = step.keys
+	typist.count = TypistPressFrames
+}
+
+// release releases the pressed keys
+func (typist *Typist) release() {
+	for _, key := range typist.pressed {
+		typist.receiver.ProcessKey(key, false)
+	}
+	typist.pressed = nil
+}
@@ -0,0 +1,53 @@
+// Package mouse contains mouse devices and definitions
+package mouse
+
+import "github.com/jtruco/emu8/emulator/device"
+
+// -----------------------------------------------------------------------------
+// Mouse & Events
+// -----------------------------------------------------------------------------
+
+// Mouse event types
+const (
+	EventMouseMove   = iota // Mouse relative motion event
+	EventMouseButton        // Mouse button event
+)
+
+// Mouse buttons
+const (
+	ButtonLeft = iota
+	ButtonRight
+	ButtonMiddle
+)
+
+// Mouse device
+type Mouse interface {
+	device.Device                        // Is a device
+	Move(dx, dy int)                     // Relative motion, x to the right and y down
+	SetButton(button byte, pressed bool) // Sets button state
+}
+
+// MouseEvent is a mouse event
+type MouseEvent struct {
+	device.Event      // Is a device event
+	DX           int  // Relative X motion
+	DY           int  // Relative Y motion
+	Button       byte // Button number
+	Pressed      bool // Button is pressed
+}
+
+// NewMouseMoveEvent creates a mouse motion event
+func NewMouseMoveEvent(dx, dy int) MouseEvent {
+	return MouseEvent{
+		Event: device.CreateEvent(EventMouseMove),
+		DX:    dx,
+		DY:    dy}
+}
+
+// NewMouseButtonEvent creates a mouse button event
+func NewMouseButtonEvent(button byte, pressed bool) MouseEvent {
+	return MouseEvent{
+		Event:   device.CreateEvent(EventMouseButton),
+		Button:  button,
+		Pressed: pressed}
+}
@@ -0,0 +1,320 @@
+package pio
+
+import "github.com/jtruco/emu8/emulator/device"
+
+// -----------------------------------------------------------------------------
+// MOS 6522 VIA - Versatile Interface Adapter
+// -----------------------------------------------------------------------------
+
+// VIA 6522 registers
+const (
+	VIARegORB   = 0x00 // Output / input register B
+	VIARegORA   = 0x01 // Output / input register A
+	VIARegDDRB  = 0x02 // Data direction register B
+	VIARegDDRA  = 0x03 // Data direction register A
+	VIARegT1CL  = 0x04 // Timer 1 counter low
+	VIARegT1CH  = 0x05 // Timer 1 counter high
+	VIARegT1LL  = 0x06 // Timer 1 latch low
+	VIARegT1LH  = 0x07 // Timer 1 latch high
+	VIARegT2CL  = 0x08 // Timer 2 counter low
+	VIARegT2CH  = 0x09 // Timer 2 counter high
+	VIARegSR    = 0x0a // Shift register
+	VIARegACR   = 0x0b // Auxiliary control register
+	VIARegPCR   = 0x0c // Peripheral control register
+	VIARegIFR   = 0x0d // Interrupt flag register
+	VIARegIER   = 0x0e // Interrupt enable register
+	VIARegORANH = 0x0f // Output / input register A, no handshake
+)
+
+// VIA 6522 interrupt flags
+const (
+	VIAIntCA2 = 0x01
+	VIAIntCA1 = 0x02
+	VIAIntSR  = 0x04
+	VIAIntCB2 = 0x08
+	VIAIntCB1 = 0x10
+	VIAIntT2  = 0x20
+	VIAIntT1  = 0x40
+	VIAIntIRQ = 0x80
+)
+
+// VIA 6522 control bits
+const (
+	viaACRT1FreeRun = 0x40 // Timer 1 continuous interrupts
+	viaACRT1PB7     = 0x80 // Timer 1 output on PB7
+	viaACRT2Count   = 0x20 // Timer 2 counts PB6 pulses
+	viaPCRCA1Edge   = 0x01 // CA1 positive edge
+	viaPCRCB1Edge   = 0x10 // CB1 positive edge
+)
+
+// VIA6522 is the MOS 6522 VIA. Emulates the ports with their data
+// direction, the CA1 and CB1 interrupt inputs, the CA2 and CB2 manual
+// outputs, the timer 1 one-shot and free-run modes, the timer 2 one-shot
+// mode and the interrupt logic. The shift register is not emulated.
+type VIA6522 struct {
+	ora, orb     byte                 // Output registers
+	ddra, ddrb   byte                 // Data direction registers
+	t1counter    uint16               // Timer 1 counter
+	t1latch      uint16               // Timer 1 latch
+	t1armed      bool                 // Timer 1 interrupt armed
+	t1reload     bool                 // Timer 1 reloads on the next cycle
+	pb7          byte                 // Timer 1 PB7 output
+	t2counter    uint16               // Timer 2 counter
+	t2latch      byte                 // Timer 2 latch low
+	t2armed      bool                 // Timer 2 interrupt armed
+	sr           byte                 // Shift register
+	acr          byte                 // Auxiliary control register
+	pcr          byte                 // Peripheral control register
+	ifr          byte                 // Interrupt flag register
+	ier          byte                 // Interrupt enable register
+	ca1, cb1     bool                 // CA1 and CB1 input levels
+	OnReadPortA  device.ReadCallback  // Port A input pins
+	OnReadPortB  device.ReadCallback  // Port B input pins
+	OnWritePortA device.WriteCallback // Port A output pins
+	OnWritePortB device.WriteCallback // Port B output pins
+	OnControl    device.Callback      // CA2 or CB2 output changed
+}
+
+// NewVIA6522 creates a new VIA 6522
+func NewVIA6522() *VIA6522 {
+	return new(VIA6522)
+}
+
+// Device
+
+// Init initializes the VIA
+func (via *VIA6522) Init() { via.Reset() }
+
+// Reset resets the VIA : ports as inputs, timers and interrupts disabled
+func (via *VIA6522) Reset() {
+	via.ora, via.orb, via.ddra, via.ddrb = 0, 0, 0, 0
+	via.t1armed, via.t1reload, via.t2armed = false, false, false
+	via.pb7 = 0x80
+	via.sr, via.acr, via.pcr, via.ifr, via.ier = 0, 0, 0, 0, 0
+	if via.OnWritePortA != nil {
+		via.OnWritePortA(via.OutputA())
+	}
+	if via.OnWritePortB != nil {
+		via.OnWritePortB(via.OutputB())
+	}
+	if via.OnControl != nil {
+		via.OnControl()
+	}
+}
+
+// Ports & control lines
+
+// OutputA gets the port A pins, inputs pulled up
+func (via *VIA6522) OutputA() byte { return via.ora | ^via.ddra }
+
+// OutputB gets the port B pins, inputs pulled up
+func (via *VIA6522) OutputB() byte {
+	data := via.orb | ^via.ddrb
+	if via.acr&viaACRT1PB7 != 0 {
+		data = data&0x7f | via.pb7
+	}
+	return data
+}
+
+// CA2 gets the CA2 output level, high unless in manual low mode
+func (via *VIA6522) CA2() bool { return via.pcr&0x0e != 0x0c }
+
+// CB2 gets the CB2 output level, high unless in manual low mode
+func (via *VIA6522) CB2() bool { return via.pcr&0xe0 != 0xc0 }
+
+// SetCA1 sets the CA1 input level, the active edge sets its interrupt flag
+func (via *VIA6522) SetCA1(level bool) {
+	if level != via.ca1 && level == (via.pcr&viaPCRCA1Edge != 0) {
+		via.ifr |= VIAIntCA1
+	}
+	via.ca1 = level
+}
+
+// SetCB1 sets the CB1 input level, the active edge sets its interrupt flag
+func (via *VIA6522) SetCB1(level bool) {
+	if level != via.cb1 && level == (via.pcr&viaPCRCB1Edge != 0) {
+		via.ifr |= VIAIntCB1
+	}
+	via.cb1 = level
+}
+
+// IntRequest checks if the VIA is requesting an interrupt
+func (via *VIA6522) IntRequest() bool { return via.ifr&via.ier&0x7f != 0 }
+
+// Emulation
+
+// Emulate emulates the timers for a number of cycles
+func (via *VIA6522) Emulate(cycles int) {
+	for ; cycles > 0; cycles-- {
+		// timer 1
+		if via.t1reload {
+			via.t1counter = via.t1latch
+			via.t1reload = false
+		} else {
+			via.t1counter--
+			if via.t1counter == 0xffff {
+				if via.t1armed {
+					via.ifr |= VIAIntT1
+					via.pb7 ^= 0x80
+				}
+				if via.acr&viaACRT1FreeRun != 0 {
+					via.t1reload = true
+				} else {
+					via.t1armed = false
+				}
+			}
+		}
+		// timer 2
+		if via.acr&viaACRT2Count == 0 {
+			via.t2counter--
+			if via.t2counter == 0xffff && via.t2armed {
+				via.ifr |= VIAIntT2
+				via.t2armed = false
+			}
+		}
+	}
+}
+
+// Registers
+
+// Read reads a register
+func (via *VIA6522) Read(address uint16) byte {
+	switch address & 0x0f {
+	case VIARegORB:
+		via.clearControlFlags(VIAIntCB1, VIAIntCB2, via.pcr>>4)
+		data := byte(0xff)
+		if via.OnReadPortB != nil {
+			data = via.OnReadPortB()
+		}
+		return via.OutputB()&via.ddrb | data&^via.ddrb
+	case VIARegORA:
+		via.clearControlFlags(VIAIntCA1, VIAIntCA2, via.pcr)
+		return via.readPortA()
+	case VIARegORANH:
+		return via.readPortA()
+	case VIARegDDRB:
+		return via.ddrb
+	case VIARegDDRA:
+		return via.ddra
+	case VIARegT1CL:
+		via.ifr &^= VIAIntT1
+		return byte(via.t1counter)
+	case VIARegT1CH:
+		return byte(via.t1counter >> 8)
+	case VIARegT1LL:
+		return byte(via.t1latch)
+	case VIARegT1LH:
+		return byte(via.t1latch >> 8)
+	case VIARegT2CL:
+		via.ifr &^= VIAIntT2
+		return byte(via.t2counter)
+	case VIARegT2CH:
+		return byte(via.t2counter >> 8)
+	case VIARegSR:
+		via.ifr &^= VIAIntSR
+		return via.sr
+	case VIARegACR:
+		return via.acr
+	case VIARegPCR:
+		return via.pcr
+	case VIARegIFR:
+		if via.IntRequest() {
+			return via.ifr | VIAIntIRQ
+		}
+		return via.ifr
+	default: // VIARegIER
+		return via.ier | 0x80
+	}
+}
+
+// Write writes a register
+func (via *VIA6522) Write(address uint16, data byte) {
+	switch address & 0x0f {
+	case VIARegORB:
+		via.clearControlFlags(VIAIntCB1, VIAIntCB2, via.pcr>>4)
+		via.orb = data
+		via.writePortB()
+	case VIARegORA:
+		via.clearControlFlags(VIAIntCA1, VIAIntCA2, via.pcr)
+		via.ora = data
+		via.writePortA()
+	case VIARegORANH:
+		via.ora = data
+		via.writePortA()
+	case VIARegDDRB:
+		via.ddrb = data
+		via.writePortB()
+	case VIARegDDRA:
+		via.ddra = data
+		via.writePortA()
+	case VIARegT1CL, VIARegT1LL:
+		via.t1latch = via.t1latch&0xff00 | uint16(data)
+	case VIARegT1CH:
+		via.t1latch = via.t1latch&0x00ff | uint16(data)<<8
+		via.t1counter = via.t1latch
+		via.t1armed, via.t1reload = true, false
+		via.ifr &^= VIAIntT1
+		via.pb7 = 0
+		via.writePortB()
+	case VIARegT1LH:
+		via.t1latch = via.t1latch&0x00ff | uint16(data)<<8
+		via.ifr &^= VIAIntT1
+	case VIARegT2CL:
+		via.t2latch = data
+	case VIARegT2CH:
+		via.t2counter = uint16(data)<<8 | uint16(via.t2latch)
+		via.t2armed = true
+		via.ifr &^= VIAIntT2
+	case VIARegSR:
+		via.sr = data
+		via.ifr &^= VIAIntSR
+	case VIARegACR:
+		via.acr = data
+		via.writePortB()
+	case VIARegPCR:
+		via.pcr = data
+		if via.OnControl != nil {
+			via.OnControl()
+		}
+	case VIARegIFR:
+		via.ifr &^= data & 0x7f
+	default: // VIARegIER
+		if data&0x80 != 0 {
+			via.ier |= data & 0x7f
+		} else {
+			via.ier &^= data & 0x7f
+		}
+	}
+}
+
+// readPortA reads the port A : output pins and input pins
+func (via *VIA6522) readPortA() byte {
+	data := byte(0xff)
+	if via.OnReadPortA != nil {
+		data = via.OnReadPortA()
+	}
+	return via.ora&via.ddra | data&^via.ddra
+}
+
+// writePortA updates the port A output pins
+func (via *VIA6522) writePortA() {
+	if via.OnWritePortA != nil {
+		via.OnWritePortA(via.OutputA())
+	}
+}
+
+// writePortB updates the port B output pins
+func (via *VIA6522) writePortB() {
+	if via.OnWritePortB != nil {
+		via.OnWritePortB(via.OutputB())
+	}
+}
+
+// clearControlFlags clears the control lines interrupt flags on port access.
+// The CA2 / CB2 flag is kept in the independent interrupt input modes.
+func (via *VIA6522) clearControlFlags(flag1, flag2, control byte) {
+	via.ifr &^= flag1
+	if control&0x0a != 0x02 {
+		via.ifr &^= flag2
+	}
+}
@@ -0,0 +1,118 @@
+// Package pio contains parallel input/output devices
+package pio
+
+// -----------------------------------------------------------------------------
+// Z80 PIO - Parallel Input/Output controller
+// -----------------------------------------------------------------------------
+
+// Z80 PIO ports
+const (
+	PortA = 0
+	PortB = 1
+)
+
+// Z80 PIO operating modes
+const (
+	ModeOutput        = 0
+	ModeInput         = 1
+	ModeBidirectional = 2
+	ModeControl       = 3
+)
+
+// pioPort is a Z80 PIO port
+type pioPort struct {
+	data          byte // Data register
+	mode          byte // Operating mode
+	vector        byte // Interrupt vector
+	ioMask        byte // Control mode I/O mask
+	intEnabled    bool // Interrupt enabled
+	intPending    bool // Interrupt pending
+	expectMask    bool // Next control word is the I/O mask
+	expectIntMask bool // Next control word is the interrupt mask
+}
+
+// Z80PIO is the Z80 PIO controller. Emulates the port modes, strobe
+// interrupts and interrupt vectors. Port A has interrupt priority.
+type Z80PIO struct {
+	ports [2]pioPort // Port A and B
+}
+
+// New creates a new Z80 PIO
+func New() *Z80PIO {
+	return new(Z80PIO)
+}
+
+// Init initializes the PIO
+func (pio *Z80PIO) Init() { pio.Reset() }
+
+// Reset resets the PIO
+func (pio *Z80PIO) Reset() {
+	for i := range pio.ports {
+		pio.ports[i] = pioPort{mode: ModeInput}
+	}
+}
+
+// ReadData reads the port data register
+func (pio *Z80PIO) ReadData(port int) byte {
+	return pio.ports[port&1].data
+}
+
+// WriteData writes the port data register
+func (pio *Z80PIO) WriteData(port int, data byte) {
+	pio.ports[port&1].data = data
+}
+
+// WriteControl writes a port control word
+func (pio *Z80PIO) WriteControl(port int, data byte) {
+	p := &pio.ports[port&1]
+	switch {
+	case p.expectMask:
+		p.ioMask = data
+		p.expectMask = false
+	case p.expectIntMask:
+		p.expectIntMask = false // interrupt mask word (not emulated)
+	case data&0x01 == 0: // interrupt vector
+		p.vector = data
+	case data&0x0f == 0x0f: // mode word
+		p.mode = data >> 6
+		p.expectMask = p.mode == ModeControl
+	case data&0x0f == 0x07: // interrupt control word
+		p.intEnabled = data&0x80 != 0
+		p.expectIntMask = data&0x10 != 0
+	case data&0x0f == 0x03: // interrupt enable
+		p.intEnabled = data&0x80 != 0
+	}
+}
+
+// Strobe latches data into an input port, as the peripheral strobe, and
+// requests an interrupt if enabled
+func (pio *Z80PIO) Strobe(port int, data byte) {
+	p := &pio.ports[port&1]
+	p.data = data
+	if p.intEnabled {
+		p.intPending = true
+	}
+}
+
+// IsPending checks if a port has an interrupt pending
+func (pio *Z80PIO) IsPending(port int) bool {
+	return pio.ports[port&1].intPending
+}
+
+// IntRequest checks if the PIO is requesting an interrupt
+func (pio *Z80PIO) IntRequest() bool {
+	return pio.ports[PortA].intPending || pio.ports[PortB].intPending
+}
+
+// IntAcknowledge acknowledges the highest priority interrupt and returns its
+// vector
+func (pio *Z80PIO) IntAcknowledge() byte {
+	for i := range pio.ports {
+		p := &pio.ports[i]
+		if p.intPending {
+			p.intPending = false
+			return p.vector
+		}
+	}
+	return 0xff
+}
@@ -45,4 +45,5 @@ func (control *Control) reset() {
 	control.Timeout = 0
 	control.BlockIndex = 0
 	control.BlockPos = 0
+	control.Block = nil
 }
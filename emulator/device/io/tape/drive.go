@@ -10,11 +10,23 @@ import (
 // Tape Drive
 // -----------------------------------------------------------------------------
 
+// Automatic playback control constants
+const (
+	autoEarReadGap   = 512     // Max tstates between EAR reads of a loader loop
+	autoEarReadCount = 256     // Consecutive EAR reads to detect a loader loop
+	autoStopTimeout  = 7000000 // Tstates without EAR reads to stop playback
+)
+
 // Drive tape device
 type Drive struct {
-	control Control      // Tape control data
-	clock   device.Clock // Clock
-	tape    Tape         // Loaded tape
+	control  Control         // Tape control data
+	clock    device.Clock    // Clock
+	tape     Tape            // Loaded tape
+	auto     bool            // Automatic playback control
+	autoPlay bool            // Playback started by automatic control
+	earReads int             // Consecutive EAR reads
+	earRead  int64           // Clock total tstates at last EAR read
+	OnInsert device.Callback // On tape inserted callback
 }
 
 // New creates a new Tape Drive
@@ -32,6 +44,8 @@ func (drive *Drive) Init() { drive.Reset() }
 func (drive *Drive) Reset() {
 	drive.Stop()
 	drive.control.reset()
+	drive.autoPlay = false
+	drive.earReads = 0
 }
 
 // HasTape if there is a tape
@@ -49,12 +63,18 @@ func (drive *Drive) EarHigh() bool { return (drive.control.Ear & LevelMask) != 0
 // EarLow tape state is high
 func (drive *Drive) EarLow() bool { return (drive.control.Ear & LevelMask) == 0 }
 
+// Tape the loaded tape
+func (drive *Drive) Tape() Tape { return drive.tape }
+
 // Insert loads the tape into the drive
 func (drive *Drive) Insert(tape Tape) {
 	drive.tape = tape
 	drive.control.NumBlocks = len(tape.Blocks())
 	drive.Reset()
 	log.Println("Tape : Tape inserted:", tape.Info().Name)
+	if drive.OnInsert != nil {
+		drive.OnInsert()
+	}
 }
 
 // Eject ejects the tape from drive
@@ -80,6 +100,7 @@ func (drive *Drive) Stop() {
 		return
 	}
 	drive.control.Playing = false
+	drive.autoPlay = false
 	log.Println("Tape : Playback stopped")
 }
 
@@ -89,11 +110,168 @@ func (drive *Drive) Rewind() {
 	log.Println("Tape : Tape rewinded")
 }
 
+// Tape navigation
+
+// Seek positions the tape at the start of a block
+func (drive *Drive) Seek(index int) bool {
+	if !drive.HasTape() || index < 0 || index >= drive.control.NumBlocks {
+		return false
+	}
+	playing := drive.IsPlaying()
+	drive.Reset()
+	drive.control.BlockIndex = index
+	if playing {
+		drive.Play()
+	}
+	log.Println("Tape : Tape positioned at block:", index)
+	return true
+}
+
+// BlockIndex returns the index of the current block
+func (drive *Drive) BlockIndex() int {
+	if drive.control.Block != nil {
+		return drive.control.Block.Info().Index
+	}
+	return drive.control.BlockIndex
+}
+
+// CurrentBlock returns the current block or nil at end of tape
+func (drive *Drive) CurrentBlock() Block {
+	if !drive.HasTape() {
+		return nil
+	}
+	if drive.control.Block != nil {
+		return drive.control.Block
+	}
+	if drive.control.EndOfTape() {
+		return nil
+	}
+	return drive.tape.Blocks()[drive.control.BlockIndex]
+}
+
+// Position returns the current block index and the number of blocks
+func (drive *Drive) Position() (int, int) {
+	return drive.BlockIndex(), drive.control.NumBlocks
+}
+
+// Percentage returns the tape position as a percentage of the tape length
+func (drive *Drive) Percentage() int {
+	block := drive.CurrentBlock()
+	if block == nil {
+		return 0
+	}
+	blocks := drive.tape.Blocks()
+	last := blocks[len(blocks)-1].Info()
+	length := last.Offset + last.Length
+	if length == 0 {
+		return 0
+	}
+	position := block.Info().Offset
+	if block == drive.control.Block {
+		position += drive.control.BlockPos
+	}
+	return position * 100 / length
+}
+
+// Automatic playback control
+
+// AutoPlay returns if automatic playback control is enabled
+func (drive *Drive) AutoPlay() bool { return drive.auto }
+
+// SetAutoPlay enables or disables automatic playback control
+func (drive *Drive) SetAutoPlay(auto bool) { drive.auto = auto }
+
+// LoaderActive notifies the CPU is running a ROM tape loader
+func (drive *Drive) LoaderActive() {
+	if drive.auto {
+		drive.earRead = drive.clock.Total()
+		drive.autoStart()
+	}
+}
+
+// EarRead notifies the CPU has read the EAR level. A tight loop of
+// EAR reads is detected as a tape loader and playback is started.
+func (drive *Drive) EarRead() {
+	if !drive.auto {
+		return
+	}
+	total := drive.clock.Total()
+	if total-drive.earRead < autoEarReadGap {
+		drive.earReads++
+	} else {
+		drive.earReads = 0
+	}
+	drive.earRead = total
+	if drive.earReads >= autoEarReadCount {
+		drive.autoStart()
+	}
+}
+
+// SetMotor sets the tape motor state, controlled by the machine
+func (drive *Drive) SetMotor(on bool) {
+	if !drive.auto {
+		return
+	}
+	if on {
+		drive.Play()
+	} else {
+		drive.Stop()
+	}
+}
+
+// autoStart starts playback from automatic control
+func (drive *Drive) autoStart() {
+	if !drive.IsPlaying() && drive.HasTape() {
+		drive.Play()
+		drive.autoPlay = true
+	}
+}
+
+// autoStop stops auto started playback when loader is not active
+func (drive *Drive) autoStop() {
+	if drive.clock.Total()-drive.earRead > autoStopTimeout {
+		drive.Stop()
+	}
+}
+
+// NextDataBlock returns the loader data of the next data block and
+// advances the tape past it. Returns nil if there are no more data blocks.
+func (drive *Drive) NextDataBlock() []byte {
+	if !drive.HasTape() {
+		return nil
+	}
+	blocks := drive.tape.Blocks()
+	for drive.control.BlockIndex < drive.control.NumBlocks {
+		block := blocks[drive.control.BlockIndex]
+		drive.control.BlockIndex++
+		if dataBlock, ok := block.(DataBlock); ok {
+			data := dataBlock.LoadData()
+			if data != nil {
+				drive.control.State = 0
+				drive.control.Timeout = 0
+				drive.control.BlockPos = 0
+				drive.control.Block = nil
+				if drive.control.EndOfTape() {
+					log.Println("Tape : End of tape")
+					drive.Rewind()
+				}
+				return data
+			}
+		}
+	}
+	log.Println("Tape : End of tape")
+	drive.Rewind()
+	return nil
+}
+
 // Emulate emulates the tape drive
 func (drive *Drive) Emulate(tstates int) {
 	if !drive.IsPlaying() {
 		return
 	}
+	if drive.autoPlay {
+		drive.autoStop()
+	}
 	// control tstates timeout
 	drive.control.Timeout -= tstates
 	if drive.control.Timeout > 0 {
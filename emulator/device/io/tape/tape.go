@@ -1,6 +1,11 @@
 // Package tape contains tape and drive components
 package tape
 
+import (
+	"fmt"
+	"strings"
+)
+
 // -----------------------------------------------------------------------------
 // Tape components
 // -----------------------------------------------------------------------------
@@ -28,9 +33,61 @@ type BlockInfo struct {
 type Block interface {
 	Info() *BlockInfo
 	Data() []byte
+	Meta() *BlockMeta
+}
+
+// DataBlock is a tape block that contains data readable by the ROM loaders
+type DataBlock interface {
+	Block
+	LoadData() []byte // LoadData gets the loader data bytes (flag, data & checksum)
 }
 
 // Info tape information
 type Info struct {
 	Name string // Tape name
 }
+
+// -----------------------------------------------------------------------------
+// Block metadata
+// -----------------------------------------------------------------------------
+
+// BlockMeta contains the decoded block description
+type BlockMeta struct {
+	Description string   // Block description
+	Header      *Header  // Standard header information
+	Timings     *Timings // Data block timings
+	Text        []string // Text contents (descriptions, messages, archive info)
+}
+
+// Header is a standard header block information
+type Header struct {
+	Type     byte   // File type
+	TypeName string // File type name
+	Name     string // File name
+	Length   int    // Data length
+	Param1   int    // First parameter
+	Param2   int    // Second parameter
+}
+
+// Timings data block timings in tstates
+type Timings struct {
+	Pilot       int // Pilot pulse length
+	PilotPulses int // Number of pilot pulses
+	Sync1       int // First sync pulse length
+	Sync2       int // Second sync pulse length
+	Zero        int // Zero bit pulse length
+	One         int // One bit pulse length
+	LastBits    int // Used bits of last byte
+	Pause       int // Pause after block (ms)
+}
+
+// String returns the block description
+func (meta *BlockMeta) String() string {
+	if meta.Header != nil {
+		return fmt.Sprintf("%s: %s", meta.Header.TypeName, strings.TrimSpace(meta.Header.Name))
+	}
+	if meta.Description == "" && len(meta.Text) > 0 {
+		return meta.Text[0]
+	}
+	return meta.Description
+}
@@ -76,3 +76,20 @@ func (memory *Memory) LoadRAM(address uint16, data []byte) {
 		offset = last
 	}
 }
+
+// Peek reads a byte from the bank mapped at address, without bus events
+func (memory *Memory) Peek(address uint16) byte {
+	m, rel := memory.Mapper().Select(address)
+	if m == nil {
+		return 0xff
+	}
+	return m.Device().Read(rel)
+}
+
+// Poke writes a byte into the bank mapped at address, without bus events
+func (memory *Memory) Poke(address uint16, data byte) {
+	m, rel := memory.Mapper().SelectWrite(address)
+	if m != nil {
+		m.Device().Write(rel, data)
+	}
+}
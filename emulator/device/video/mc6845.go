@@ -11,6 +11,17 @@ const (
 	MC6845Nreg = 0x12 // 18 registers
 )
 
+// CRTC types. The CPC was fitted with several 6845 variants, that differ in
+// register read back, sync widths, skew and mid-frame register changes.
+const (
+	CrtcType0 = iota // Hitachi HD6845S / UMC UM6845
+	CrtcType1        // UMC UM6845R
+	CrtcType2        // Motorola MC6845
+	CrtcType3        // Amstrad ASIC (CPC Plus)
+	CrtcType4        // Amstrad Pre-ASIC (CPC cost-down)
+	CrtcTypes        // limit count
+)
+
 // MC6845 register constants
 const (
 	MC6845HorizontalTotal = iota
@@ -39,7 +50,7 @@ var (
 		0x3f, 0x28, 0x2e, 0x8e, 0x26, 0x00, 0x19, 0x1e, 0x00,
 		0x07, 0x00, 0x00, 0x20, 0x00, 0x00, 0x00, 0x00, 0x00}
 	MC6845Masks = [MC6845Nreg]byte{
-		0xff, 0xff, 0xff, 0xff, 0x7f, 0x1f, 0x7f, 0x7f, 0x03,
+		0xff, 0xff, 0xff, 0xff, 0x7f, 0x1f, 0x7f, 0x7f, 0xf3,
 		0x1f, 0x1f, 0x1f, 0x3f, 0xff, 0x3f, 0xff, 0x3f, 0xff}
 )
 
@@ -48,6 +59,7 @@ type MC6845 struct {
 	registers [MC6845Nreg]*byte
 	defaults  [MC6845Nreg]byte
 	selected  byte
+	crtcType  int
 	// registers
 	HorizontalTotal        byte
 	HorizontalDisplayed    byte
@@ -67,16 +79,25 @@ type MC6845 struct {
 	CursorLow              byte
 	LightPenHigh           byte
 	LightPenLow            byte
-	// control
-	currentCol  byte
-	currentRow  byte
-	currentLine byte
-	hSyncWidth  byte
-	vSyncWidth  byte
-	hSyncCount  byte
-	vSyncCount  byte
-	inHSync     bool
-	inVSync     bool
+	// counters
+	currentCol  byte   // Horizontal character counter
+	currentRow  byte   // Vertical character counter (7 bits)
+	currentLine byte   // Raster line counter (5 bits)
+	adjustLine  byte   // Vertical total adjust counter
+	inAdjust    bool   // In vertical total adjust
+	address     uint16 // Memory address counter
+	rowAddress  uint16 // Memory address at the start of the row lines
+	nextAddress uint16 // Memory address of the next row
+	field       byte   // Interlace field (0 even, 1 odd)
+	// display & sync
+	hDisplay   bool
+	vDisplay   bool
+	hSyncWidth byte
+	vSyncWidth byte
+	hSyncCount byte
+	vSyncCount byte
+	inHSync    bool
+	inVSync    bool
 	// callbacks
 	OnHSync device.Callback
 	OnVSync device.Callback
@@ -111,6 +132,17 @@ func NewMC6845() *MC6845 {
 
 // Properties
 
+// Type gets the CRTC type
+func (mc *MC6845) Type() int { return mc.crtcType }
+
+// SetType sets the CRTC type
+func (mc *MC6845) SetType(crtcType int) {
+	if crtcType >= 0 && crtcType < CrtcTypes {
+		mc.crtcType = crtcType
+		mc.updateSyncWidths()
+	}
+}
+
 // CurrentCol current row column
 func (mc *MC6845) CurrentCol() byte { return mc.currentCol }
 
@@ -120,12 +152,55 @@ func (mc *MC6845) CurrentRow() byte { return mc.currentRow }
 // CurrentLine current line in row
 func (mc *MC6845) CurrentLine() byte { return mc.currentLine }
 
+// MemoryAddress current memory address (MA0-MA13)
+func (mc *MC6845) MemoryAddress() uint16 { return mc.address }
+
+// RowAddress memory address of the first character of the current line
+func (mc *MC6845) RowAddress() uint16 { return mc.rowAddress }
+
+// StartAddress the start address register (R12 & R13)
+func (mc *MC6845) StartAddress() uint16 {
+	return uint16(mc.StartAddressHigh)<<8 | uint16(mc.StartAddressLow)
+}
+
+// LoadAddress loads the memory address of the current and next rows
+// (used by the CPC Plus split screen)
+func (mc *MC6845) LoadAddress(address uint16) {
+	mc.address = address
+	mc.rowAddress = address
+	mc.nextAddress = address
+}
+
 // InHSync in HSync
 func (mc *MC6845) InHSync() bool { return mc.inHSync }
 
 // InVSync in VSync
 func (mc *MC6845) InVSync() bool { return mc.inVSync }
 
+// VerticalDisplay checks if the current line is in the vertical display area
+func (mc *MC6845) VerticalDisplay() bool { return mc.vDisplay }
+
+// DisplayEnable checks if the current character is displayed
+func (mc *MC6845) DisplayEnable() bool {
+	return mc.hDisplay && mc.vDisplay && mc.Skew() != 3
+}
+
+// Skew display enable skew in characters (0-2, 3 disables the display).
+// Only types 0, 3 and 4 implement skew.
+func (mc *MC6845) Skew() byte {
+	switch mc.crtcType {
+	case CrtcType0, CrtcType3, CrtcType4:
+		return (mc.InterlaceAndSkew >> 4) & 0x03
+	}
+	return 0
+}
+
+// Interlace checks the interlace sync & video mode
+func (mc *MC6845) Interlace() bool { return mc.InterlaceAndSkew&0x03 == 0x03 }
+
+// Field current interlace field
+func (mc *MC6845) Field() byte { return mc.field }
+
 // SetDefaults sets default register values
 func (mc *MC6845) SetDefaults(defaults [MC6845Nreg]byte) {
 	mc.defaults = defaults
@@ -143,23 +218,32 @@ func (mc *MC6845) Reset() {
 		mc.WriteRegister(i, mc.defaults[i])
 	}
 	mc.currentCol = 0
-	mc.currentLine = 0
-	mc.currentRow = 0
+	mc.field = 0
 	mc.hSyncCount = 0
 	mc.vSyncCount = 0
 	mc.inHSync = false
 	mc.inVSync = false
+	mc.hDisplay = true
+	mc.newFrame()
 }
 
 // IO operations
 
-// Read reads data
+// Read reads data. Port 2 is the status register of type 1, and a mirror
+// of the data register on types 3 and 4.
 func (mc *MC6845) Read(port byte) byte {
-	var data byte = 0xff
-	if port == 0x03 {
-		data &= mc.ReadRegister(mc.selected)
+	switch port {
+	case 0x02:
+		switch mc.crtcType {
+		case CrtcType1:
+			return mc.status()
+		case CrtcType3, CrtcType4:
+			return mc.ReadRegister(mc.selected)
+		}
+	case 0x03:
+		return mc.ReadRegister(mc.selected)
 	}
-	return data
+	return 0xff
 }
 
 // Write writes data
@@ -172,54 +256,95 @@ func (mc *MC6845) Write(port byte, data byte) {
 	}
 }
 
+// status returns the type 1 status register : bit 5 is set in the
+// vertical border, bit 6 on light pen strobe.
+func (mc *MC6845) status() byte {
+	var data byte
+	if !mc.vDisplay {
+		data |= 0x20
+	}
+	return data
+}
+
 // register operations
 
 // Selected selected register
 func (mc *MC6845) Selected() byte { return mc.selected }
 
 // Register gets register value at index
-func (mc *MC6845) Register(index byte) byte { return *mc.registers[index] }
+func (mc *MC6845) Register(index byte) byte {
+	if index < MC6845Nreg {
+		return *mc.registers[index]
+	}
+	return 0
+}
 
 // SelectRegister selects current register
 func (mc *MC6845) SelectRegister(selected byte) {
 	mc.selected = selected
 }
 
-// ReadRegister returns register value
+// ReadRegister returns register value. Readable registers depend on the
+// CRTC type, the rest read as zero.
 func (mc *MC6845) ReadRegister(register byte) byte {
-	if (register > 11) && (register < MC6845Nreg) {
-		return *mc.registers[register]
+	switch mc.crtcType {
+	case CrtcType0:
+		if register >= MC6845StartAddressHigh && register < MC6845Nreg {
+			return *mc.registers[register]
+		}
+	case CrtcType1:
+		if register == 0x1f {
+			return 0xff
+		}
+		fallthrough
+	case CrtcType2:
+		if register >= MC6845CursorHigh && register < MC6845Nreg {
+			return *mc.registers[register]
+		}
+	case CrtcType3, CrtcType4:
+		if register >= MC6845CursorStart && register < MC6845Nreg {
+			return *mc.registers[register]
+		}
 	}
 	return 0 // write only
 }
 
 // WriteRegister writes value to register
 func (mc *MC6845) WriteRegister(register, data byte) {
+	if register >= MC6845Nreg {
+		return
+	}
 	*mc.registers[register] = data & MC6845Masks[register]
 
-	// HSync & VSync widths
-	if register == 0x03 {
-		mc.hSyncWidth = mc.SyncWidths & 0x0f
-		if mc.hSyncWidth == 0 {
-			mc.hSyncWidth = 0x10
-		}
-		mc.vSyncWidth = (mc.SyncWidths >> 4) & 0x0f
-		if mc.vSyncWidth == 0 {
-			mc.vSyncWidth = 0x10
+	switch register {
+	case MC6845SyncWidths:
+		mc.updateSyncWidths()
+	case MC6845StartAddressHigh, MC6845StartAddressLow:
+		// Type 1 reloads the start address on every line of the first row
+		if mc.crtcType == CrtcType1 && mc.currentRow == 0 && !mc.inAdjust {
+			mc.nextAddress = mc.StartAddress()
 		}
 	}
 }
 
-// emulation
-
-// Emulate emulates Tstates
-func (mc *MC6845) Emulate(tstates int) {
-	for i := 0; i < tstates; i++ {
-		mc.OnClock()
+// updateSyncWidths updates HSync & VSync widths. Types 1 and 2 have a fixed
+// VSync of 16 lines, types 0 and 1 have no HSync with width 0.
+func (mc *MC6845) updateSyncWidths() {
+	mc.hSyncWidth = mc.SyncWidths & 0x0f
+	if mc.hSyncWidth == 0 && mc.crtcType != CrtcType0 && mc.crtcType != CrtcType1 {
+		mc.hSyncWidth = 0x10
+	}
+	mc.vSyncWidth = (mc.SyncWidths >> 4) & 0x0f
+	if mc.vSyncWidth == 0 || mc.crtcType == CrtcType1 || mc.crtcType == CrtcType2 {
+		mc.vSyncWidth = 0x10
 	}
 }
 
-// OnClock emulates one clock cycle (1MHz)
+// emulation
+
+// OnClock emulates one clock cycle (1MHz), moving to the next character.
+// Counters are compared for equality, so registers changed below the
+// current counters overflow them, as the real device.
 func (mc *MC6845) OnClock() {
 	// hsync duration control
 	if mc.hSyncCount > 0 {
@@ -229,42 +354,119 @@ func (mc *MC6845) OnClock() {
 		}
 	}
 	// onclock moves one character
-	mc.currentCol++
-	// scanline control
-	if mc.currentCol > mc.HorizontalTotal {
+	mc.address = (mc.address + 1) & 0x3fff
+	if mc.currentCol == mc.HorizontalTotal {
 		mc.currentCol = 0
-		// vsync duration control
-		if mc.vSyncCount > 0 {
-			mc.vSyncCount--
-			if mc.vSyncCount == 0 {
-				mc.inVSync = false
-			}
+		mc.newLine()
+	} else {
+		mc.currentCol++
+	}
+	// horizontal display
+	if mc.currentCol == mc.HorizontalDisplayed {
+		mc.hDisplay = false
+		if mc.isLastLine() {
+			mc.nextAddress = mc.address
 		}
-		// new line
-		mc.currentLine++
-		if mc.currentLine > mc.MaxScanlineAddress {
-			mc.currentLine = 0
-			mc.currentRow++
-			if mc.currentRow > mc.VerticalTotal {
-				mc.currentRow = 0
-			}
+	}
+	// hsync control
+	if !mc.inHSync && mc.currentCol == mc.HorizontalSyncPosition && mc.hSyncWidth > 0 {
+		mc.inHSync = true
+		mc.hSyncCount = mc.hSyncWidth
+		if mc.OnHSync != nil {
+			mc.OnHSync()
 		}
-		// vsync control
-		if !mc.inVSync && mc.currentRow == mc.VerticalSyncPosition {
-			mc.inVSync = true
-			mc.vSyncCount = mc.vSyncWidth
-			if mc.OnVSync != nil {
-				mc.OnVSync()
-			}
+	}
+}
+
+// newLine starts a new raster line
+func (mc *MC6845) newLine() {
+	// vsync duration control
+	if mc.vSyncCount > 0 {
+		mc.vSyncCount--
+		if mc.vSyncCount == 0 {
+			mc.inVSync = false
 		}
-	} else {
-		// hsync control
-		if !mc.inHSync && mc.currentCol == mc.HorizontalSyncPosition {
-			mc.inHSync = true
-			mc.hSyncCount = mc.hSyncWidth
-			if mc.OnHSync != nil {
-				mc.OnHSync()
+	}
+	// vertical counters
+	switch {
+	case mc.inAdjust:
+		mc.currentLine = (mc.currentLine + 1) & 0x1f
+		mc.adjustLine = (mc.adjustLine + 1) & 0x1f
+		if mc.adjustLine == mc.VerticalTotalAdjust {
+			mc.newFrame()
+		}
+	case mc.isLastLine():
+		mc.currentLine = mc.firstLine()
+		mc.rowAddress = mc.nextAddress
+		if mc.currentRow == mc.VerticalTotal {
+			if mc.VerticalTotalAdjust == 0 {
+				mc.newFrame()
+			} else {
+				mc.inAdjust = true
+				mc.adjustLine = 0
+				mc.currentRow = (mc.currentRow + 1) & 0x7f
 			}
+		} else {
+			mc.currentRow = (mc.currentRow + 1) & 0x7f
 		}
+	case mc.Interlace():
+		mc.currentLine = (mc.currentLine + 2) & 0x1f
+	default:
+		mc.currentLine = (mc.currentLine + 1) & 0x1f
+	}
+	// Type 1 reloads the start address on the first row
+	if mc.crtcType == CrtcType1 && mc.currentRow == 0 && !mc.inAdjust {
+		mc.nextAddress = mc.StartAddress()
+		mc.rowAddress = mc.nextAddress
+	}
+	// display
+	mc.address = mc.rowAddress
+	mc.hDisplay = true
+	if mc.currentRow == mc.VerticalDisplayed {
+		mc.vDisplay = false
+	}
+	// vsync control : types 1 & 2 check the vsync position on every line,
+	// the rest on the first line of the row only
+	if !mc.inVSync && mc.currentRow == mc.VerticalSyncPosition &&
+		(mc.currentLine == mc.firstLine() || mc.crtcType == CrtcType1 || mc.crtcType == CrtcType2) {
+		mc.inVSync = true
+		mc.vSyncCount = mc.vSyncWidth
+		if mc.OnVSync != nil {
+			mc.OnVSync()
+		}
+	}
+}
+
+// newFrame starts a new frame, reloading the start address
+func (mc *MC6845) newFrame() {
+	if mc.InterlaceAndSkew&0x01 != 0 {
+		mc.field ^= 1
+	} else {
+		mc.field = 0
+	}
+	mc.currentRow = 0
+	mc.currentLine = mc.firstLine()
+	mc.adjustLine = 0
+	mc.inAdjust = false
+	mc.vDisplay = mc.VerticalDisplayed != 0
+	mc.nextAddress = mc.StartAddress()
+	mc.rowAddress = mc.nextAddress
+	mc.address = mc.rowAddress
+}
+
+// isLastLine checks if the current line is the last line of the row
+func (mc *MC6845) isLastLine() bool {
+	if mc.Interlace() {
+		return mc.currentLine>>1 == mc.MaxScanlineAddress>>1
+	}
+	return mc.currentLine == mc.MaxScanlineAddress
+}
+
+// firstLine returns the first raster line of the rows, the field on
+// interlace sync & video mode
+func (mc *MC6845) firstLine() byte {
+	if mc.Interlace() {
+		return mc.field
 	}
+	return 0
 }
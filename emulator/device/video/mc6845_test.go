@@ -0,0 +1,33 @@
+package video
+
+import "testing"
+
+// TestSkew writes the R8 skew bits and checks the skew and the display
+// enable output of each CRTC type. Types 1 and 2 have no skew.
+func TestSkew(t *testing.T) {
+	tests := []struct {
+		r8      byte
+		skew    [CrtcTypes]byte
+		display [CrtcTypes]bool
+	}{
+		{0x00, [CrtcTypes]byte{0, 0, 0, 0, 0}, [CrtcTypes]bool{true, true, true, true, true}},
+		{0x10, [CrtcTypes]byte{1, 0, 0, 1, 1}, [CrtcTypes]bool{true, true, true, true, true}},
+		{0x20, [CrtcTypes]byte{2, 0, 0, 2, 2}, [CrtcTypes]bool{true, true, true, true, true}},
+		{0x30, [CrtcTypes]byte{3, 0, 0, 3, 3}, [CrtcTypes]bool{false, true, true, false, false}},
+		{0x33, [CrtcTypes]byte{3, 0, 0, 3, 3}, [CrtcTypes]bool{false, true, true, false, false}},
+	}
+	for crtcType := 0; crtcType < CrtcTypes; crtcType++ {
+		mc := NewMC6845()
+		mc.SetType(crtcType)
+		mc.Init()
+		for _, test := range tests {
+			mc.WriteRegister(MC6845InterlaceAndSkew, test.r8)
+			if skew := mc.Skew(); skew != test.skew[crtcType] {
+				t.Errorf("type %d, R8 %02x : skew %d, expected %d", crtcType, test.r8, skew, test.skew[crtcType])
+			}
+			if display := mc.DisplayEnable(); display != test.display[crtcType] {
+				t.Errorf("type %d, R8 %02x : display enable %v, expected %v", crtcType, test.r8, display, test.display[crtcType])
+			}
+		}
+	}
+}
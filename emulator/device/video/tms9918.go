@@ -0,0 +1,416 @@
+package video
+
+// -----------------------------------------------------------------------------
+// Texas Instruments TMS9918A - Video Display Processor
+// -----------------------------------------------------------------------------
+
+// TMS9918 constants
+const (
+	TMS9918Nreg       = 8      // 8 write only registers
+	TMS9918VRAMSize   = 0x4000 // 16K video RAM
+	TMS9918LinesNTSC  = 262    // Lines per frame (60 Hz)
+	TMS9918LinesPAL   = 313    // Lines per frame (50 Hz)
+	tmsScreenWidth    = 256
+	tmsScreenHeight   = 192
+	tmsBorderLeft     = 16
+	tmsBorderTop      = 24
+	tmsTotalWidth     = tmsScreenWidth + 2*tmsBorderLeft
+	tmsTotalHeight    = tmsScreenHeight + 2*tmsBorderTop
+	tmsTextWidth      = 240 // 40 columns of 6 pixels
+	tmsSprites        = 32  // Sprite attribute table entries
+	tmsSpritesPerLine = 4   // Max sprites displayed per line
+	tmsSpriteEnd      = 0xd0
+)
+
+// TMS9918 status flags
+const (
+	TMS9918StatusInt       = 0x80 // Frame (VBLANK) interrupt flag
+	TMS9918StatusFifth     = 0x40 // Fifth sprite flag
+	TMS9918StatusCollision = 0x20 // Sprite collision flag
+	TMS9918StatusSprite    = 0x1f // Fifth sprite number
+)
+
+// TMS9918 register bits
+const (
+	tmsR0Mode3    = 0x02 // Graphics II mode
+	tmsR1Blank    = 0x40 // Display enabled
+	tmsR1IntOn    = 0x20 // Frame interrupt enabled
+	tmsR1Mode1    = 0x10 // Text mode
+	tmsR1Mode2    = 0x08 // Multicolour mode
+	tmsR1Size16   = 0x02 // 16x16 sprites
+	tmsR1Magnify  = 0x01 // Sprites magnified x2
+	tmsEarlyClock = 0x80 // Sprite early clock bit (x - 32)
+)
+
+// TMS9918 screen modes
+const (
+	TMS9918Graphics1   = iota // Screen 1 : 32x24 tiles, colour per 8 patterns
+	TMS9918Graphics2          // Screen 2 : 32x24 tiles, colour per pattern line
+	TMS9918Multicolour        // Screen 3 : 64x48 colour blocks
+	TMS9918Text               // Screen 0 : 40x24 characters
+)
+
+// TMS9918PaletteRGBA is the TMS9918A RGBA colour palette. Colour 0 is
+// transparent and shows the backdrop colour.
+var TMS9918PaletteRGBA = []uint32{
+	0xff000000, 0xff000000, 0xff42c821, 0xff78dc5e, 0xffed5554, 0xfffc767d, 0xff4d52d4, 0xfff5eb42,
+	0xff5455fc, 0xff7879ff, 0xff54c1d4, 0xff80cee6, 0xff3bb021, 0xffba5bc9, 0xffcccccc, 0xffffffff,
+}
+
+// TMS9918 is the TMS9918A VDP. Emulates the four screen modes, the sprites
+// with the fifth sprite and collision flags, and the frame interrupt. The
+// frame is painted line by line, with the border of the backdrop colour.
+type TMS9918 struct {
+	screen    *Screen                // The video screen
+	vram      [TMS9918VRAMSize]byte  // The video RAM
+	registers [TMS9918Nreg]byte      // The control registers
+	status    byte                   // The status register
+	address   uint16                 // The VRAM address counter
+	readAhead byte                   // The read ahead buffer
+	latch     byte                   // The first byte of a control write
+	second    bool                   // Next control write is the second byte
+	lines     int                    // Lines per frame
+	line      int                    // Next line to paint
+	buffer    [tmsScreenWidth]byte   // Line colours buffer
+	sprites   [tmsScreenWidth]bool   // Line sprite pixels (collisions)
+	spriteIdx [tmsSpritesPerLine]int // Sprites displayed in the line
+}
+
+// NewTMS9918 creates the VDP with the lines per frame (NTSC or PAL)
+func NewTMS9918(lines int) *TMS9918 {
+	vdp := new(TMS9918)
+	vdp.lines = lines
+	vdp.screen = NewScreen(tmsTotalWidth, tmsTotalHeight, TMS9918PaletteRGBA)
+	return vdp
+}
+
+// Properties
+
+// VRAM gets the video RAM
+func (vdp *TMS9918) VRAM() []byte { return vdp.vram[:] }
+
+// Register gets the register value at index
+func (vdp *TMS9918) Register(index byte) byte { return vdp.registers[index&0x07] }
+
+// Status gets the status register, without side effects
+func (vdp *TMS9918) Status() byte { return vdp.status }
+
+// Lines gets the lines per frame
+func (vdp *TMS9918) Lines() int { return vdp.lines }
+
+// Mode gets the screen mode
+func (vdp *TMS9918) Mode() int {
+	switch {
+	case vdp.registers[1]&tmsR1Mode1 != 0:
+		return TMS9918Text
+	case vdp.registers[1]&tmsR1Mode2 != 0:
+		return TMS9918Multicolour
+	case vdp.registers[0]&tmsR0Mode3 != 0:
+		return TMS9918Graphics2
+	}
+	return TMS9918Graphics1
+}
+
+// IntRequest checks if the VDP is requesting an interrupt
+func (vdp *TMS9918) IntRequest() bool {
+	return vdp.status&TMS9918StatusInt != 0 && vdp.registers[1]&tmsR1IntOn != 0
+}
+
+// Device
+
+// Init initializes the VDP
+func (vdp *TMS9918) Init() { vdp.Reset() }
+
+// Reset resets the VDP
+func (vdp *TMS9918) Reset() {
+	for i := range vdp.vram {
+		vdp.vram[i] = 0
+	}
+	for i := range vdp.registers {
+		vdp.registers[i] = 0
+	}
+	vdp.status = 0
+	vdp.address = 0
+	vdp.readAhead = 0
+	vdp.latch = 0
+	vdp.second = false
+	vdp.line = 0
+	vdp.screen.Clear(0)
+}
+
+// Video
+
+// Screen the video screen
+func (vdp *TMS9918) Screen() *Screen { return vdp.screen }
+
+// EndFrame paints the remaining lines of the frame
+func (vdp *TMS9918) EndFrame() {
+	vdp.Update(vdp.lines)
+	vdp.line = 0
+}
+
+// Ports
+
+// ReadData reads the VRAM through the read ahead buffer
+func (vdp *TMS9918) ReadData() byte {
+	vdp.second = false
+	data := vdp.readAhead
+	vdp.readAhead = vdp.vram[vdp.address]
+	vdp.address = (vdp.address + 1) & (TMS9918VRAMSize - 1)
+	return data
+}
+
+// WriteData writes the VRAM
+func (vdp *TMS9918) WriteData(data byte) {
+	vdp.second = false
+	vdp.vram[vdp.address] = data
+	vdp.readAhead = data
+	vdp.address = (vdp.address + 1) & (TMS9918VRAMSize - 1)
+}
+
+// ReadStatus reads the status register and clears its flags
+func (vdp *TMS9918) ReadStatus() byte {
+	vdp.second = false
+	data := vdp.status
+	vdp.status &= TMS9918StatusSprite
+	return data
+}
+
+// WriteControl writes the control port : the VRAM address or a register,
+// in two bytes
+func (vdp *TMS9918) WriteControl(data byte) {
+	if !vdp.second {
+		vdp.latch = data
+		vdp.second = true
+		return
+	}
+	vdp.second = false
+	if data&0x80 != 0 {
+		vdp.WriteRegister(data, vdp.latch)
+		return
+	}
+	vdp.address = uint16(data&0x3f)<<8 | uint16(vdp.latch)
+	if data&0x40 == 0 { // read setup
+		vdp.readAhead = vdp.vram[vdp.address]
+		vdp.address = (vdp.address + 1) & (TMS9918VRAMSize - 1)
+	}
+}
+
+// WriteRegister writes a register value
+func (vdp *TMS9918) WriteRegister(index, data byte) {
+	vdp.registers[index&0x07] = data
+}
+
+// Emulation
+
+// Update paints the lines up to the last line. The frame interrupt flag is
+// set at the end of the active display.
+func (vdp *TMS9918) Update(last int) {
+	if last > vdp.lines {
+		last = vdp.lines
+	}
+	for ; vdp.line < last; vdp.line++ {
+		if vdp.line < tmsTotalHeight {
+			vdp.paintLine(vdp.line)
+		}
+		if vdp.line == tmsBorderTop+tmsScreenHeight-1 {
+			vdp.status |= TMS9918StatusInt
+		}
+	}
+}
+
+// paintLine paints a screen line
+func (vdp *TMS9918) paintLine(y int) {
+	backdrop := int(vdp.registers[7] & 0x0f)
+	row := y - tmsBorderTop
+	if row < 0 || row >= tmsScreenHeight {
+		for x := 0; x < tmsTotalWidth; x++ {
+			vdp.screen.SetPixelIndex(x, y, backdrop)
+		}
+		return
+	}
+	for x := range vdp.buffer {
+		vdp.buffer[x] = 0
+	}
+	if vdp.registers[1]&tmsR1Blank != 0 {
+		switch mode := vdp.Mode(); mode {
+		case TMS9918Text:
+			vdp.scanlineText(row)
+		case TMS9918Multicolour:
+			vdp.scanlineMulticolour(row)
+		default:
+			vdp.scanlineGraphics(row, mode == TMS9918Graphics2)
+		}
+		if vdp.Mode() != TMS9918Text {
+			vdp.scanlineSprites(row)
+		}
+	}
+	for x := 0; x < tmsBorderLeft; x++ {
+		vdp.screen.SetPixelIndex(x, y, backdrop)
+		vdp.screen.SetPixelIndex(tmsTotalWidth-1-x, y, backdrop)
+	}
+	for x, colour := range vdp.buffer {
+		if colour == 0 {
+			colour = byte(backdrop)
+		}
+		vdp.screen.SetPixelIndex(tmsBorderLeft+x, y, int(colour))
+	}
+}
+
+// scanlineGraphics paints a line of the Graphics I and II modes
+func (vdp *TMS9918) scanlineGraphics(row int, graphics2 bool) {
+	names := int(vdp.registers[2]&0x0f) << 10
+	patterns := int(vdp.registers[4]&0x07) << 11
+	colours := int(vdp.registers[3]) << 6
+	patternMask, colourMask := 0x3fff, 0x3fff
+	if graphics2 {
+		patterns = int(vdp.registers[4]&0x04) << 11
+		colours = int(vdp.registers[3]&0x80) << 6
+		patternMask = int(vdp.registers[4]&0x03)<<11 | 0x7ff
+		colourMask = int(vdp.registers[3]&0x7f)<<6 | 0x3f
+	}
+	offset := names + (row>>3)<<5
+	for col := 0; col < 32; col++ {
+		name := int(vdp.vram[offset+col])
+		var pattern, colour byte
+		if graphics2 {
+			index := ((row>>6)<<8|name)<<3 | row&0x07
+			pattern = vdp.vram[patterns|index&patternMask]
+			colour = vdp.vram[colours|index&colourMask]
+		} else {
+			pattern = vdp.vram[patterns+name<<3|row&0x07]
+			colour = vdp.vram[colours+name>>3]
+		}
+		vdp.paintPattern(col<<3, pattern, colour>>4, colour&0x0f, 8)
+	}
+}
+
+// scanlineMulticolour paints a line of the Multicolour mode
+func (vdp *TMS9918) scanlineMulticolour(row int) {
+	names := int(vdp.registers[2]&0x0f) << 10
+	patterns := int(vdp.registers[4]&0x07) << 11
+	offset := names + (row>>3)<<5
+	for col := 0; col < 32; col++ {
+		name := int(vdp.vram[offset+col])
+		colour := vdp.vram[patterns+name<<3|(row>>3)&0x03<<1|(row>>2)&0x01]
+		x := col << 3
+		for i := 0; i < 4; i++ {
+			vdp.buffer[x+i] = colour >> 4
+			vdp.buffer[x+4+i] = colour & 0x0f
+		}
+	}
+}
+
+// scanlineText paints a line of the Text mode
+func (vdp *TMS9918) scanlineText(row int) {
+	names := int(vdp.registers[2]&0x0f) << 10
+	patterns := int(vdp.registers[4]&0x07) << 11
+	ink, paper := vdp.registers[7]>>4, vdp.registers[7]&0x0f
+	border := (tmsScreenWidth - tmsTextWidth) / 2
+	for x := 0; x < border; x++ {
+		vdp.buffer[x] = paper
+		vdp.buffer[tmsScreenWidth-1-x] = paper
+	}
+	offset := names + (row>>3)*40
+	for col := 0; col < 40; col++ {
+		name := int(vdp.vram[offset+col])
+		pattern := vdp.vram[patterns+name<<3|row&0x07]
+		vdp.paintPattern(border+col*6, pattern, ink, paper, 6)
+	}
+}
+
+// paintPattern paints the pattern pixels into the line buffer
+func (vdp *TMS9918) paintPattern(x int, pattern, ink, paper byte, width int) {
+	for i := 0; i < width; i++ {
+		if pattern&(0x80>>uint(i)) != 0 {
+			vdp.buffer[x+i] = ink
+		} else {
+			vdp.buffer[x+i] = paper
+		}
+	}
+}
+
+// scanlineSprites paints the sprites of the line. Only four sprites are
+// displayed per line, the fifth sets the fifth sprite flag. Overlapping
+// sprite pixels set the collision flag.
+func (vdp *TMS9918) scanlineSprites(row int) {
+	attributes := int(vdp.registers[5]&0x7f) << 7
+	patterns := int(vdp.registers[6]&0x07) << 11
+	size := 8
+	if vdp.registers[1]&tmsR1Size16 != 0 {
+		size = 16
+	}
+	magnify := 0
+	if vdp.registers[1]&tmsR1Magnify != 0 {
+		magnify = 1
+	}
+	// sprites of the line
+	count, last, fifth := 0, 0, false
+	for i := 0; i < tmsSprites && !fifth; i++ {
+		last = i
+		y := int(vdp.vram[attributes+i<<2])
+		if y == tmsSpriteEnd {
+			break
+		}
+		if y > 0xe0 {
+			y -= 0x100 // partially above the screen
+		}
+		line := row - y - 1
+		if line < 0 || line >= size<<uint(magnify) {
+			continue
+		}
+		if count == tmsSpritesPerLine {
+			fifth = true
+			continue
+		}
+		vdp.spriteIdx[count] = i
+		count++
+	}
+	if vdp.status&TMS9918StatusFifth == 0 {
+		vdp.status = vdp.status&^TMS9918StatusSprite | byte(last)
+		if fifth {
+			vdp.status |= TMS9918StatusFifth
+		}
+	}
+	for x := range vdp.sprites {
+		vdp.sprites[x] = false
+	}
+	// paint in order, the first sprite has priority
+	for i := 0; i < count; i++ {
+		offset := attributes + vdp.spriteIdx[i]<<2
+		y := int(vdp.vram[offset])
+		if y > 0xe0 {
+			y -= 0x100
+		}
+		line := (row - y - 1) >> uint(magnify)
+		x := int(vdp.vram[offset+1])
+		name := int(vdp.vram[offset+2])
+		colour := vdp.vram[offset+3]
+		if colour&tmsEarlyClock != 0 {
+			x -= 32
+		}
+		if size == 16 {
+			name &= 0xfc
+		}
+		base := patterns + name<<3 + line
+		for px := 0; px < size<<uint(magnify); px++ {
+			sx := x + px
+			if sx < 0 || sx >= tmsScreenWidth {
+				continue
+			}
+			bit := px >> uint(magnify)
+			pattern := vdp.vram[base+(bit>>3)<<4]
+			if pattern&(0x80>>uint(bit&0x07)) == 0 {
+				continue
+			}
+			if vdp.sprites[sx] {
+				vdp.status |= TMS9918StatusCollision
+				continue
+			}
+			vdp.sprites[sx] = true
+			if colour&0x0f != 0 {
+				vdp.buffer[sx] = colour & 0x0f
+			}
+		}
+	}
+}
@@ -27,6 +27,7 @@ type Emulator struct {
 	sleep    time.Duration          // Sleep duration
 	current  time.Time              // Current time
 	lost     bool                   // Lost frame
+	turbo    bool                   // Turbo emulation while tape is playing
 }
 
 // New creates a machine emulator
@@ -34,6 +35,7 @@ func New(machine machine.Machine) *Emulator {
 	emulator := new(Emulator)
 	emulator.machine = machine
 	emulator.control = controller.New(machine)
+	emulator.turbo = config.Get().Tape.Turbo
 	return emulator
 }
 
@@ -82,6 +84,14 @@ func (emulator *Emulator) SetAsync(async bool) {
 	emulator.async = async
 }
 
+// IsTapeTurbo turbo emulation while tape is playing is active
+func (emulator *Emulator) IsTapeTurbo() bool { return emulator.turbo }
+
+// SetTapeTurbo sets turbo emulation while tape is playing
+func (emulator *Emulator) SetTapeTurbo(turbo bool) {
+	emulator.turbo = turbo
+}
+
 // Init the emulation
 func (emulator *Emulator) Init() {
 	emulator.machine.Init()
@@ -108,6 +118,11 @@ func (emulator *Emulator) Emulate() {
 
 // Sync synchronizes next frame loop
 func (emulator *Emulator) Sync() {
+	if emulator.inTurbo() {
+		emulator.sleep = 0 // no sleep in turbo mode
+		emulator.current = time.Now()
+		return
+	}
 	emulator.sleep += emulator.duration - time.Since(emulator.current)
 	emulator.current = time.Now()
 	if emulator.sleep > 0 {
@@ -161,6 +176,15 @@ func (emulator *Emulator) TakeSnapshot() {
 	emulator.control.TakeSnapshot()
 }
 
+// SaveProgram saves the BASIC listing of the program in memory
+func (emulator *Emulator) SaveProgram() {
+	if emulator.running {
+		emulator.Stop()
+		defer emulator.Start()
+	}
+	emulator.control.SaveProgram()
+}
+
 // Emulation
 
 // emulationLoop the emulation loop goroutine
@@ -192,5 +216,15 @@ func (emulator *Emulator) emulateFrame() {
 	}
 
 	emulator.machine.EndFrame()
-	emulator.control.Refresh()
+	if emulator.inTurbo() {
+		emulator.control.RefreshSilent()
+	} else {
+		emulator.control.Refresh()
+	}
+}
+
+// inTurbo checks if turbo emulation is active
+func (emulator *Emulator) inTurbo() bool {
+	tape := emulator.control.Tape()
+	return emulator.turbo && tape.HasDrive() && tape.Drive().IsPlaying()
 }
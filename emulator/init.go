@@ -5,7 +5,13 @@ import (
 	_ "github.com/jtruco/emu8/emulator/config"
 	// register machines
 	_ "github.com/jtruco/emu8/emulator/machine/cpc"
+	_ "github.com/jtruco/emu8/emulator/machine/cpm"
+	_ "github.com/jtruco/emu8/emulator/machine/jupiter"
+	_ "github.com/jtruco/emu8/emulator/machine/msx"
+	_ "github.com/jtruco/emu8/emulator/machine/oric"
+	_ "github.com/jtruco/emu8/emulator/machine/sam"
 	_ "github.com/jtruco/emu8/emulator/machine/spectrum"
+	_ "github.com/jtruco/emu8/emulator/machine/zx81"
 )
 
 // emulator package init
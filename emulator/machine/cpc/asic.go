@@ -0,0 +1,383 @@
+package cpc
+
+import (
+	"github.com/jtruco/emu8/emulator/device/video"
+)
+
+// -----------------------------------------------------------------------------
+// Amstrad CPC Plus - ASIC
+// -----------------------------------------------------------------------------
+
+// ASIC registers page offsets (mapped at 0x4000)
+const (
+	asicSpriteData    = 0x0000 // 16 sprites of 16x16 pixels (4 bit)
+	asicSpriteAttrs   = 0x2000 // 16 sprites of 8 bytes : X, Y & magnification
+	asicPalette       = 0x2400 // 32 colours of 12 bit (pens, border & sprites)
+	asicPRI           = 0x2800 // Programmable raster interrupt line
+	asicSPLT          = 0x2801 // Split screen line
+	asicSSA           = 0x2802 // Split screen address (high & low)
+	asicSSCR          = 0x2804 // Soft scroll control
+	asicIVR           = 0x2805 // Interrupt vector
+	asicAnalog        = 0x2808 // Analog inputs (8 ports)
+	asicAnalogEnd     = 0x280f
+	asicDMA           = 0x2c00 // DMA channels : address (2) & prescaler (1)
+	asicDMAEnd        = 0x2c0b
+	asicDCSR          = 0x2c0f // DMA control & status
+	asicSprites       = 16
+	asicSpriteSize    = 16
+	asicSpriteColours = 16 // Sprite palette base
+	asicDMAChannels   = 3
+	asicAnalogCentre  = 0x3f
+)
+
+// ASIC interrupt sources (IVR bits 1-2)
+const (
+	asicIntNone   = -1
+	asicIntDMA2   = 0
+	asicIntDMA1   = 1
+	asicIntDMA0   = 2
+	asicIntRaster = 3
+)
+
+// asicUnlockSequence is the unlock sequence written to the CRTC select
+// port : a non zero byte, zero, and the code. Next byte 0xee unlocks.
+var asicUnlockSequence = []byte{
+	0xff, 0x00, 0xff, 0x77, 0xb3, 0x51, 0xa8, 0xd4,
+	0x62, 0x39, 0x9c, 0x46, 0x2b, 0x15, 0x8a, 0xcd}
+
+// asicInkColours the 12 bit colours (0x0GRB) of the hardware inks
+var asicInkColours [32]uint16
+
+// Asic is the CPC Plus ASIC : hardware sprites, 4096 colour palette, split
+// screen, soft scroll, programmable raster interrupts and DMA sound.
+type Asic struct {
+	cpc       *AmstradCPC
+	page      [0x4000]byte // Registers page
+	colours   [32]uint32   // RGBA palette : pens, border & sprites
+	dma       [asicDMAChannels]asicDMAChannel
+	unlocked  bool // Registers unlocked
+	unlock    int  // Unlock sequence position
+	rmr2      byte // Lower ROM & registers page mapping
+	dcsr      byte // DMA control & status
+	intSource int  // Pending interrupt source
+}
+
+// asicDMAChannel is a DMA sound channel, executing one instruction per line
+type asicDMAChannel struct {
+	address   uint16 // Instruction address
+	prescaler int    // Pause prescaler
+	pause     int    // Pause lines
+	loop      uint16 // Repeat loop address
+	repeat    int    // Repeat count
+}
+
+// NewAsic creates the ASIC
+func NewAsic(cpc *AmstradCPC) *Asic {
+	asic := new(Asic)
+	asic.cpc = cpc
+	return asic
+}
+
+// Init initializes the ASIC
+func (asic *Asic) Init() { asic.Reset() }
+
+// Reset resets the ASIC
+func (asic *Asic) Reset() {
+	for i := range asic.page {
+		asic.page[i] = 0
+	}
+	for i := asicAnalog; i <= asicAnalogEnd; i++ {
+		asic.page[i] = asicAnalogCentre
+	}
+	asic.page[asicIVR] = 0x01
+	for i := range asic.colours {
+		asic.colours[i] = cpcPaletteRGBA[0]
+	}
+	for i := range asic.dma {
+		asic.dma[i] = asicDMAChannel{}
+	}
+	asic.unlocked = false
+	asic.unlock = 0
+	asic.rmr2 = 0
+	asic.dcsr = 0
+	asic.intSource = asicIntNone
+}
+
+// Lock & memory mapping
+
+// IsUnlocked checks if the ASIC registers are unlocked
+func (asic *Asic) IsUnlocked() bool { return asic.unlocked }
+
+// OnCrtcSelect checks the unlock sequence on the CRTC select port
+func (asic *Asic) OnCrtcSelect(data byte) {
+	switch {
+	case asic.unlock == len(asicUnlockSequence):
+		asic.unlocked = data == 0xee
+		asic.unlock = 0
+	case asic.unlock <= 1 && data != 0:
+		asic.unlock = 1
+	case data == asicUnlockSequence[asic.unlock]:
+		asic.unlock++
+	default:
+		asic.unlock = 0
+	}
+}
+
+// WriteRMR2 writes the RMR2 register : lower ROM cartridge page (bits 0-2)
+// and its location (bits 3-4), location 3 maps the registers page.
+func (asic *Asic) WriteRMR2(data byte) {
+	asic.rmr2 = data & 0x1f
+	asic.cpc.selectCartridgeLower(int(asic.rmr2 & 0x07))
+	asic.cpc.updateMemory()
+}
+
+// LowerRomBlock returns the 16K block of the lower ROM
+func (asic *Asic) LowerRomBlock() int {
+	block := int(asic.rmr2>>3) & 0x03
+	if block == 3 {
+		return 0
+	}
+	return block
+}
+
+// IsPageMapped checks if the registers page is mapped at 0x4000
+func (asic *Asic) IsPageMapped() bool { return asic.rmr2&0x18 == 0x18 }
+
+// Registers page bus
+
+// Read reads the registers page
+func (asic *Asic) Read(address uint16) byte {
+	switch {
+	case address < asicSpriteAttrs:
+		return asic.page[address] & 0x0f
+	case address == asicDCSR:
+		return asic.dcsr
+	}
+	return asic.page[address]
+}
+
+// Write writes the registers page
+func (asic *Asic) Write(address uint16, data byte) {
+	switch {
+	case address < asicSpriteAttrs:
+		asic.page[address] = data & 0x0f
+	case address >= asicPalette && address < asicPalette+0x40:
+		asic.page[address] = data
+		asic.updateColour(int(address-asicPalette) >> 1)
+	case address >= asicAnalog && address <= asicAnalogEnd:
+		// read only
+	case address >= asicDMA && address <= asicDMAEnd:
+		asic.page[address] = data
+		asic.updateDMA(int(address-asicDMA) >> 2)
+	case address == asicDCSR:
+		asic.writeDCSR(data)
+	default:
+		asic.page[address] = data
+	}
+}
+
+// Palette
+
+// SetInk sets the 12 bit colour of a Gate Array ink
+func (asic *Asic) SetInk(pen, ink byte) {
+	colour := asicInkColours[ink&0x1f]
+	address := asicPalette + int(pen)<<1
+	asic.page[address] = byte(colour>>4)&0xf0 | byte(colour&0x0f)
+	asic.page[address+1] = byte(colour >> 8)
+	asic.updateColour(int(pen))
+}
+
+// updateColour updates the RGBA colour of a palette entry
+func (asic *Asic) updateColour(index int) {
+	address := asicPalette + index<<1
+	r := uint32(asic.page[address]>>4) * 0x11
+	b := uint32(asic.page[address]&0x0f) * 0x11
+	g := uint32(asic.page[address+1]&0x0f) * 0x11
+	asic.colours[index] = 0xff000000 | b<<16 | g<<8 | r
+	if index < gaTotalPens {
+		asic.cpc.gatearray.colours[index] = asic.colours[index]
+	}
+}
+
+// Soft scroll
+
+// HorizontalScroll returns the horizontal scroll in pixels
+func (asic *Asic) HorizontalScroll() int { return int(asic.page[asicSSCR] & 0x0f) }
+
+// VerticalScroll returns the vertical scroll in raster lines
+func (asic *Asic) VerticalScroll() byte { return (asic.page[asicSSCR] >> 4) & 0x07 }
+
+// IsBorderExtended checks if the border covers the first screen character
+func (asic *Asic) IsBorderExtended() bool { return asic.page[asicSSCR]&0x80 != 0 }
+
+// Sprites
+
+// PaintSprites paints the sprites over a screen character at (x, y). The
+// sprite coordinates are relative to the screen display area, sprite 0
+// has the highest priority.
+func (asic *Asic) PaintSprites(screen *video.Screen, x, y, sx, sy int) {
+	for sprite := asicSprites - 1; sprite >= 0; sprite-- {
+		attrs := asicSpriteAttrs + sprite<<3
+		mag := asic.page[attrs+4]
+		magX, magY := uint((mag>>2)&0x03), uint(mag&0x03)
+		if magX == 0 || magY == 0 {
+			continue
+		}
+		posX := int(int16(uint16(asic.page[attrs+1])<<8 | uint16(asic.page[attrs])))
+		posY := int(int16(uint16(asic.page[attrs+3])<<8 | uint16(asic.page[attrs+2])))
+		py := (sy - posY) >> (magY - 1)
+		if py < 0 || py >= asicSpriteSize {
+			continue
+		}
+		data := asic.page[asicSpriteData+sprite<<8+py<<4:]
+		for i := 0; i < videoCharWidth; i++ {
+			px := (sx + i - posX) >> (magX - 1)
+			if px < 0 || px >= asicSpriteSize || x+i >= videoTotalWidth {
+				continue
+			}
+			if colour := data[px] & 0x0f; colour != 0 {
+				screen.SetPixel(x+i, y, asic.colours[asicSpriteColours+int(colour)])
+			}
+		}
+	}
+}
+
+// Raster interrupts & split screen
+
+// HasRasterInterrupt checks if the raster interrupt is programmed
+func (asic *Asic) HasRasterInterrupt() bool { return asic.page[asicPRI] != 0 }
+
+// OnClock checks the raster interrupt and the split screen lines at the
+// start of each line
+func (asic *Asic) OnClock() {
+	crtc := asic.cpc.crtc
+	if crtc.CurrentCol() != 0 {
+		return
+	}
+	line := byte(int(crtc.CurrentRow())*(int(crtc.MaxScanlineAddress)+1) + int(crtc.CurrentLine()))
+	if pri := asic.page[asicPRI]; pri != 0 && line == pri {
+		asic.interrupt(asicIntRaster)
+	}
+	if splt := asic.page[asicSPLT]; splt != 0 && line == splt {
+		crtc.LoadAddress(uint16(asic.page[asicSSA])<<8 | uint16(asic.page[asicSSA+1]))
+	}
+}
+
+// OnIntAck acknowledges the interrupt, setting its vector on the data bus.
+// Returns if other interrupts are pending.
+func (asic *Asic) OnIntAck() bool {
+	if asic.intSource == asicIntNone {
+		return false
+	}
+	asic.cpc.cpu.DataBus = asic.page[asicIVR]&0xf8 | byte(asic.intSource)<<1
+	if asic.intSource == asicIntRaster {
+		asic.dcsr &^= 0x80
+	}
+	asic.intSource = asicIntNone
+	for channel := 0; channel < asicDMAChannels; channel++ {
+		if asic.dcsr&(0x40>>uint(channel)) != 0 {
+			asic.intSource = asicIntDMA0 - channel
+		}
+	}
+	return asic.intSource != asicIntNone
+}
+
+// interrupt requests an interrupt from source
+func (asic *Asic) interrupt(source int) {
+	if source == asicIntRaster {
+		asic.dcsr |= 0x80
+	}
+	if asic.intSource < source {
+		asic.intSource = source
+	}
+	asic.cpc.cpu.InterruptRequest(true)
+}
+
+// DMA sound
+
+// updateDMA updates the address and prescaler of a DMA channel
+func (asic *Asic) updateDMA(channel int) {
+	if channel >= asicDMAChannels {
+		return
+	}
+	address := asicDMA + channel<<2
+	asic.dma[channel].address = (uint16(asic.page[address+1])<<8 | uint16(asic.page[address])) &^ 0x01
+	asic.dma[channel].prescaler = int(asic.page[address+2])
+}
+
+// writeDCSR enables the DMA channels (bits 0-2) and clears their interrupts
+// (bits 4-6)
+func (asic *Asic) writeDCSR(data byte) {
+	for channel := 0; channel < asicDMAChannels; channel++ {
+		if data&(1<<uint(channel)) != 0 && asic.dcsr&(1<<uint(channel)) == 0 {
+			asic.dma[channel].pause = 0
+			asic.dma[channel].repeat = 0
+		}
+	}
+	asic.dcsr = (asic.dcsr &^ (data & 0x70)) &^ 0x07
+	asic.dcsr |= data & 0x07
+}
+
+// OnHSync executes one instruction of each enabled DMA channel
+func (asic *Asic) OnHSync() {
+	for channel := 0; channel < asicDMAChannels; channel++ {
+		if asic.dcsr&(1<<uint(channel)) != 0 {
+			asic.executeDMA(channel)
+		}
+	}
+}
+
+// executeDMA executes a DMA channel instruction
+func (asic *Asic) executeDMA(channel int) {
+	dma := &asic.dma[channel]
+	if dma.pause > 0 {
+		dma.pause--
+		return
+	}
+	ram := cpcRAM{asic.cpc.memory}
+	instruction := uint16(ram.Peek(dma.address+1))<<8 | uint16(ram.Peek(dma.address))
+	dma.address += 2
+	switch instruction >> 12 {
+	case 0: // LOAD R,D
+		asic.cpc.psg.WriteRegister(byte(instruction>>8)&0x0f, byte(instruction))
+	case 1: // PAUSE n
+		if n := int(instruction & 0x0fff); n > 0 {
+			dma.pause = n*(dma.prescaler+1) - 1
+		}
+	case 2: // REPEAT n
+		dma.repeat = int(instruction & 0x0fff)
+		dma.loop = dma.address
+	case 4: // NOP, LOOP, INT & STOP
+		if instruction&0x0001 != 0 && dma.repeat > 0 {
+			dma.repeat--
+			dma.address = dma.loop
+		}
+		if instruction&0x0010 != 0 {
+			asic.dcsr |= 0x40 >> uint(channel)
+			asic.interrupt(asicIntDMA0 - channel)
+		}
+		if instruction&0x0020 != 0 {
+			asic.dcsr &^= 1 << uint(channel)
+		}
+	}
+	// store the channel address
+	address := asicDMA + channel<<2
+	asic.page[address] = byte(dma.address)
+	asic.page[address+1] = byte(dma.address >> 8)
+}
+
+func init() {
+	// 12 bit colours of the hardware inks : 0%, 50% & 100% levels
+	level := func(c uint32) uint16 {
+		switch c & 0xff {
+		case 0x00:
+			return 0x0
+		case 0x80:
+			return 0x6
+		}
+		return 0xf
+	}
+	for ink, rgba := range cpcPaletteRGBA {
+		asicInkColours[ink] = level(rgba>>8)<<8 | level(rgba)<<4 | level(rgba>>16)
+	}
+}
@@ -0,0 +1,288 @@
+// Package basic implements Locomotive BASIC program conversion
+package basic
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Locomotive BASIC
+// -----------------------------------------------------------------------------
+
+// Locomotive BASIC token codes
+const (
+	CodeEndOfLine   = 0x00 // End of line
+	CodeSeparator   = 0x01 // Statement separator (:)
+	CodeVarInteger  = 0x02 // Integer variable (%)
+	CodeVarString   = 0x03 // String variable ($)
+	CodeVarReal     = 0x04 // Real variable (!)
+	CodeVar         = 0x0d // Variable without type suffix
+	CodeNumber0     = 0x0e // Constants 0 to 10 (0x0e - 0x18)
+	CodeNumber10    = 0x18
+	CodeInteger8    = 0x19 // 8 bit decimal integer
+	CodeInteger16   = 0x1a // 16 bit decimal integer
+	CodeBinary16    = 0x1b // 16 bit binary integer (&X)
+	CodeHex16       = 0x1c // 16 bit hexadecimal integer (&)
+	CodeLinePointer = 0x1d // Line address pointer
+	CodeLineNumber  = 0x1e // Line number
+	CodeReal        = 0x1f // 5 bytes real number
+	CodeRsx         = 0x7c // RSX command (|)
+	CodeFunction    = 0xff // Function token prefix
+	TokenFirst      = 0x80 // First keyword token
+	RealLength      = 5    // Real number length
+	MaxLineNum      = 65535
+)
+
+// Keyword tokens
+const (
+	tokenData    = 0x8c
+	tokenElse    = 0x97
+	tokenRem     = 0xc5
+	tokenComment = 0xc0
+)
+
+// Keywords Locomotive BASIC 1.0 keywords from 0x80
+var Keywords = [...]string{
+	"AFTER", "AUTO", "BORDER", "CALL", "CAT", "CHAIN", "CLEAR", "CLG", // 0x80
+	"CLOSEIN", "CLOSEOUT", "CLS", "CONT", "DATA", "DEF", "DEFINT", "DEFREAL",
+	"DEFSTR", "DEG", "DELETE", "DIM", "DRAW", "DRAWR", "EDIT", "ELSE", // 0x90
+	"END", "ENT", "ENV", "ERASE", "ERROR", "EVERY", "FOR", "GOSUB",
+	"GOTO", "IF", "INK", "INPUT", "KEY", "LET", "LINE", "LIST", // 0xa0
+	"LOAD", "LOCATE", "MEMORY", "MERGE", "MID$", "MODE", "MOVE", "MOVER",
+	"NEXT", "NEW", "ON", "ON BREAK", "ON ERROR GOTO", "ON SQ", "OPENIN", "OPENOUT", // 0xb0
+	"ORIGIN", "OUT", "PAPER", "PEN", "PLOT", "PLOTR", "POKE", "PRINT",
+	"'", "RAD", "RANDOMIZE", "READ", "RELEASE", "REM", "RENUM", "RESTORE", // 0xc0
+	"RESUME", "RETURN", "RUN", "SAVE", "SOUND", "SPEED", "STOP", "SYMBOL",
+	"TAG", "TAGOFF", "TROFF", "TRON", "WAIT", "WEND", "WHILE", "WIDTH", // 0xd0
+	"WINDOW", "WRITE", "ZONE", "DI", "EI", "FILL", "GRAPHICS", "MASK",
+	"FRAME", "CURSOR", "", "ERL", "FN", "SPC", "STEP", "SWAP", // 0xe0
+	"", "", "TAB", "THEN", "TO", "USING", ">", "=",
+	">=", "<", "<>", "<=", "+", "-", "*", "/", // 0xf0
+	"^", "\\", "AND", "MOD", "OR", "XOR", "NOT", "",
+}
+
+// Functions Locomotive BASIC functions (prefixed by 0xff)
+var Functions = map[byte]string{
+	0x00: "ABS", 0x01: "ASC", 0x02: "ATN", 0x03: "CHR$", 0x04: "CINT",
+	0x05: "COS", 0x06: "CREAL", 0x07: "EXP", 0x08: "FIX", 0x09: "FRE",
+	0x0a: "INKEY", 0x0b: "INP", 0x0c: "INT", 0x0d: "JOY", 0x0e: "LEN",
+	0x0f: "LOG", 0x10: "LOG10", 0x11: "LOWER$", 0x12: "PEEK", 0x13: "REMAIN",
+	0x14: "SGN", 0x15: "SIN", 0x16: "SPACE$", 0x17: "SQ", 0x18: "SQR",
+	0x19: "STR$", 0x1a: "TAN", 0x1b: "UNT", 0x1c: "UPPER$", 0x1d: "VAL",
+	0x40: "EOF", 0x41: "ERR", 0x42: "HIMEM", 0x43: "INKEY$", 0x44: "PI",
+	0x45: "RND", 0x46: "TIME", 0x47: "XPOS", 0x48: "YPOS", 0x49: "DERR",
+	0x71: "BIN$", 0x72: "DEC$", 0x73: "HEX$", 0x74: "INSTR", 0x75: "LEFT$",
+	0x76: "MAX", 0x77: "MIN", 0x78: "POS", 0x79: "RIGHT$", 0x7a: "ROUND",
+	0x7b: "STRING$", 0x7c: "TEST", 0x7d: "TESTR", 0x7e: "COPYCHR$", 0x7f: "VPOS",
+}
+
+// Keyword returns the keyword of a token code
+func Keyword(code byte) string {
+	if code < TokenFirst {
+		return ""
+	}
+	return Keywords[code-TokenFirst]
+}
+
+// -----------------------------------------------------------------------------
+// Detokenizer
+// -----------------------------------------------------------------------------
+
+// Line is a BASIC program line
+type Line struct {
+	Address int    // Line offset in program
+	Number  int    // Line number
+	Data    []byte // Tokenized line data (without the end of line)
+}
+
+// Lines splits a tokenized BASIC program in lines, until the end of program
+func Lines(program []byte) []Line {
+	lines := make([]Line, 0)
+	for pos := 0; pos+4 <= len(program); {
+		length := int(program[pos]) | int(program[pos+1])<<8
+		if length < 4 {
+			break // end of program
+		}
+		number := int(program[pos+2]) | int(program[pos+3])<<8
+		end := pos + length
+		if end > len(program) {
+			end = len(program)
+		}
+		data := program[pos+4 : end]
+		if len(data) > 0 && data[len(data)-1] == CodeEndOfLine {
+			data = data[:len(data)-1]
+		}
+		lines = append(lines, Line{pos, number, data})
+		pos = end
+	}
+	return lines
+}
+
+// Detokenize converts a tokenized BASIC program to a text listing. The base
+// is the program address, used to resolve line address pointers.
+func Detokenize(program []byte, base int) string {
+	lines := Lines(program)
+	numbers := make(map[int]int)
+	for _, line := range lines {
+		numbers[base+line.Address-1] = line.Number
+		numbers[base+line.Address] = line.Number
+	}
+	var listing strings.Builder
+	for _, line := range lines {
+		listing.WriteString(strconv.Itoa(line.Number))
+		listing.WriteByte(' ')
+		listing.WriteString(detokenizeLine(line.Data, numbers))
+		listing.WriteByte('\n')
+	}
+	return listing.String()
+}
+
+// DetokenizeLine converts a tokenized BASIC line to text
+func DetokenizeLine(data []byte) string {
+	return detokenizeLine(data, nil)
+}
+
+func detokenizeLine(data []byte, numbers map[int]int) string {
+	var text strings.Builder
+	word := func(pos int) int {
+		if pos+1 < len(data) {
+			return int(data[pos]) | int(data[pos+1])<<8
+		}
+		return 0
+	}
+	quoted, rem, statement := false, false, false
+	for pos := 0; pos < len(data); pos++ {
+		code := data[pos]
+		if rem || quoted && code != '"' || statement && code != '"' && code != CodeSeparator {
+			text.WriteByte(code) // not tokenized text
+			continue
+		}
+		switch {
+		case code == '"':
+			quoted = !quoted
+			text.WriteByte(code)
+		case code == CodeSeparator:
+			statement = false
+			// ELSE and comments are stored after a separator
+			if pos+1 >= len(data) || (data[pos+1] != tokenElse && data[pos+1] != tokenComment) {
+				text.WriteByte(':')
+			}
+		case code >= CodeVarInteger && code <= CodeVar:
+			pos += 3 // variable offset
+			for ; pos < len(data); pos++ {
+				text.WriteByte(data[pos] & 0x7f)
+				if data[pos]&0x80 != 0 {
+					break
+				}
+			}
+			switch code {
+			case CodeVarInteger:
+				text.WriteByte('%')
+			case CodeVarString:
+				text.WriteByte('$')
+			case CodeVarReal:
+				text.WriteByte('!')
+			}
+		case code >= CodeNumber0 && code <= CodeNumber10:
+			text.WriteString(strconv.Itoa(int(code - CodeNumber0)))
+		case code == CodeInteger8:
+			pos++
+			if pos < len(data) {
+				text.WriteString(strconv.Itoa(int(data[pos])))
+			}
+		case code == CodeInteger16, code == CodeLineNumber:
+			text.WriteString(strconv.Itoa(word(pos + 1)))
+			pos += 2
+		case code == CodeBinary16:
+			text.WriteString("&X" + strconv.FormatInt(int64(word(pos+1)), 2))
+			pos += 2
+		case code == CodeHex16:
+			text.WriteString("&" + strings.ToUpper(strconv.FormatInt(int64(word(pos+1)), 16)))
+			pos += 2
+		case code == CodeLinePointer:
+			if number, ok := numbers[word(pos+1)]; ok {
+				text.WriteString(strconv.Itoa(number))
+			} else {
+				text.WriteString(strconv.Itoa(word(pos + 1)))
+			}
+			pos += 2
+		case code == CodeReal:
+			if pos+RealLength < len(data) {
+				text.WriteString(FormatReal(DecodeReal(data[pos+1 : pos+1+RealLength])))
+			}
+			pos += RealLength
+		case code == CodeRsx:
+			text.WriteByte('|')
+			for pos += 2; pos < len(data); pos++ {
+				text.WriteByte(data[pos] & 0x7f)
+				if data[pos]&0x80 != 0 {
+					break
+				}
+			}
+		case code == CodeFunction:
+			pos++
+			if pos < len(data) {
+				text.WriteString(Functions[data[pos]])
+			}
+		case code >= TokenFirst:
+			text.WriteString(Keyword(code))
+			rem = code == tokenRem || code == tokenComment
+			statement = code == tokenData
+		default:
+			text.WriteByte(code)
+		}
+	}
+	return text.String()
+}
+
+// DecodeReal decodes a 5 bytes Locomotive BASIC real number
+func DecodeReal(data []byte) float64 {
+	exponent := int(data[4])
+	if exponent == 0 {
+		return 0
+	}
+	mantissa := uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16 | uint32(data[3])<<24
+	value := math.Ldexp(float64(mantissa|0x80000000), exponent-128-32)
+	if mantissa&0x80000000 != 0 {
+		value = -value
+	}
+	return value
+}
+
+// FormatReal formats a real number as listed by BASIC
+func FormatReal(value float64) string {
+	return strconv.FormatFloat(value, 'G', 9, 64)
+}
+
+// EncodeReal encodes a number in the 5 bytes Locomotive BASIC format
+func EncodeReal(value float64) ([RealLength]byte, error) {
+	var real [RealLength]byte
+	if value == 0 {
+		return real, nil
+	}
+	mantissa, exponent := math.Frexp(math.Abs(value))
+	bits := uint64(math.Round(mantissa * (1 << 32)))
+	if bits == 1<<32 {
+		bits >>= 1
+		exponent++
+	}
+	if exponent+128 < 1 {
+		return real, nil // zero
+	}
+	if exponent+128 > 0xff {
+		return real, fmt.Errorf("number too big: %g", value)
+	}
+	bits &^= 0x80000000
+	if value < 0 {
+		bits |= 0x80000000
+	}
+	real[0] = byte(bits)
+	real[1] = byte(bits >> 8)
+	real[2] = byte(bits >> 16)
+	real[3] = byte(bits >> 24)
+	real[4] = byte(exponent + 128)
+	return real, nil
+}
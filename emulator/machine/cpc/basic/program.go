@@ -0,0 +1,64 @@
+package basic
+
+import "errors"
+
+// -----------------------------------------------------------------------------
+// BASIC program in memory
+// -----------------------------------------------------------------------------
+
+// Memory is the machine memory access
+type Memory interface {
+	Peek(address uint16) byte       // Peek reads a byte
+	Poke(address uint16, data byte) // Poke writes a byte
+}
+
+// Locomotive BASIC 1.0 (CPC 464) memory addresses
+const (
+	ProgramStart  = 0x0170 // Program start address
+	ProgramLimit  = 0xa600 // Program area limit
+	SysProgramEnd = 0xae83 // End of program
+	SysVariables  = 0xae85 // Start of variables
+	SysArrays     = 0xae87 // Start of arrays
+	SysArraysEnd  = 0xae89 // End of arrays
+)
+
+func pokeWord(memory Memory, address uint16, value uint16) {
+	memory.Poke(address, byte(value))
+	memory.Poke(address+1, byte(value>>8))
+}
+
+// ReadProgram reads the tokenized BASIC program from memory, including the
+// end of program mark
+func ReadProgram(memory Memory) []byte {
+	program := make([]byte, 0)
+	address := ProgramStart
+	for address+1 < ProgramLimit {
+		length := int(memory.Peek(uint16(address))) | int(memory.Peek(uint16(address+1)))<<8
+		if length == 0 {
+			break
+		}
+		for i := 0; i < length && address < ProgramLimit; i++ {
+			program = append(program, memory.Peek(uint16(address)))
+			address++
+		}
+	}
+	return append(program, 0, 0)
+}
+
+// WriteProgram writes a tokenized BASIC program into memory. The variables
+// are cleared.
+func WriteProgram(memory Memory, program []byte) error {
+	end := ProgramStart + len(program)
+	if end > ProgramLimit {
+		return errors.New("program too big")
+	}
+	memory.Poke(ProgramStart-1, 0)
+	for i, data := range program {
+		memory.Poke(uint16(ProgramStart+i), data)
+	}
+	pokeWord(memory, SysProgramEnd, uint16(end))
+	pokeWord(memory, SysVariables, uint16(end))
+	pokeWord(memory, SysArrays, uint16(end))
+	pokeWord(memory, SysArraysEnd, uint16(end))
+	return nil
+}
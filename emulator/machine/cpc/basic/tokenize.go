@@ -0,0 +1,330 @@
+package basic
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Tokenizer
+// -----------------------------------------------------------------------------
+
+// Keywords followed by line numbers
+var lineNumberKeywords = map[string]bool{
+	"AUTO": true, "DELETE": true, "EDIT": true, "ELSE": true, "GOSUB": true,
+	"GOTO": true, "LIST": true, "RENUM": true, "RESTORE": true, "RESUME": true,
+	"RUN": true, "THEN": true, "ON ERROR GOTO": true,
+}
+
+// keywordCodes keyword token codes by name
+var keywordCodes = make(map[string]byte)
+
+// functionCodes function codes by name
+var functionCodes = make(map[string]byte)
+
+func init() {
+	for i, keyword := range Keywords {
+		if keyword != "" {
+			keywordCodes[keyword] = TokenFirst + byte(i)
+		}
+	}
+	for code, function := range Functions {
+		functionCodes[function] = code
+	}
+}
+
+// Tokenize converts a text listing to a tokenized BASIC program, including
+// the end of program mark. Lines must start with a line number and are kept
+// in listing order.
+func Tokenize(listing string) ([]byte, error) {
+	program := make([]byte, 0)
+	for n, text := range strings.Split(listing, "\n") {
+		text = strings.TrimSpace(strings.TrimSuffix(text, "\r"))
+		if text == "" {
+			continue
+		}
+		digits := 0
+		for digits < len(text) && isDigit(text[digits]) {
+			digits++
+		}
+		number, err := strconv.Atoi(text[:digits])
+		if err != nil || number < 1 || number > MaxLineNum {
+			return nil, fmt.Errorf("line %d: invalid line number", n+1)
+		}
+		data, err := TokenizeLine(strings.TrimPrefix(text[digits:], " "))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", n+1, err.Error())
+		}
+		length := 4 + len(data) + 1
+		program = append(program, byte(length), byte(length>>8), byte(number), byte(number>>8))
+		program = append(program, data...)
+		program = append(program, CodeEndOfLine)
+	}
+	program = append(program, 0, 0) // end of program
+	return program, nil
+}
+
+// TokenizeLine converts a text line (without line number) to tokenized data
+func TokenizeLine(text string) ([]byte, error) {
+	for _, r := range text {
+		if r >= 0x80 {
+			return nil, fmt.Errorf("invalid character: %c", r)
+		}
+	}
+	data := make([]byte, 0, len(text))
+	lineNumbers := false
+	for pos := 0; pos < len(text); {
+		char := text[pos]
+		switch {
+		case char == '"':
+			end := strings.IndexByte(text[pos+1:], '"')
+			if end < 0 {
+				end = len(text)
+			} else {
+				end += pos + 2
+			}
+			data = append(data, text[pos:end]...)
+			pos = end
+
+		case char == ':':
+			data = append(data, CodeSeparator)
+			lineNumbers = false
+			pos++
+
+		case char == '\'':
+			data = append(data, CodeSeparator, tokenComment)
+			data = append(data, text[pos+1:]...)
+			pos = len(text)
+
+		case char == '|':
+			pos++
+			start := pos
+			for pos < len(text) && isNameChar(text[pos]) {
+				pos++
+			}
+			if pos == start {
+				return nil, errors.New("invalid RSX name")
+			}
+			data = append(data, CodeRsx, 0)
+			data = appendName(data, strings.ToUpper(text[start:pos]))
+
+		case char == '&':
+			length, value, code, err := parseHex(text[pos:])
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, code, byte(value), byte(value>>8))
+			pos += length
+
+		case isDigit(char) || (char == '.' && pos+1 < len(text) && isDigit(text[pos+1])):
+			length := numberLength(text[pos:])
+			number, err := encodeNumber(text[pos:pos+length], lineNumbers)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, number...)
+			pos += length
+
+		case isLetter(char):
+			start := pos
+			for pos < len(text) && isNameChar(text[pos]) {
+				pos++
+			}
+			if pos < len(text) && (text[pos] == '$' || text[pos] == '%' || text[pos] == '!') {
+				pos++
+			}
+			name := text[start:pos]
+			word := strings.ToUpper(name)
+			if word == "ON" {
+				for _, keyword := range []string{"ON ERROR GOTO", "ON BREAK", "ON SQ"} {
+					if length := matchWords(text[start:], keyword); length > 0 {
+						word, pos = keyword, start+length
+						break
+					}
+				}
+			}
+			if code, ok := keywordCodes[word]; ok {
+				if code == tokenElse {
+					data = append(data, CodeSeparator)
+				}
+				data = append(data, code)
+				lineNumbers = lineNumberKeywords[word]
+				if code == tokenRem {
+					data = append(data, text[pos:]...)
+					pos = len(text)
+				} else if code == tokenData {
+					end := dataEnd(text, pos)
+					data = append(data, text[pos:end]...)
+					pos = end
+				}
+			} else if code, ok := functionCodes[word]; ok {
+				data = append(data, CodeFunction, code)
+				lineNumbers = false
+			} else {
+				if strings.HasPrefix(word, "FN") && len(word) > 2 {
+					data = append(data, keywordCodes["FN"]) // FNname
+					name = name[2:]
+				}
+				data = appendVariable(data, name)
+				lineNumbers = false
+			}
+
+		default:
+			if code, length := matchOperator(text[pos:]); length > 0 {
+				data = append(data, code)
+				lineNumbers = lineNumbers && char == '-' // line ranges
+				pos += length
+			} else {
+				data = append(data, char)
+				pos++
+			}
+		}
+	}
+	return data, nil
+}
+
+func isDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
+
+func isLetter(char byte) bool {
+	return (char >= 'A' && char <= 'Z') || (char >= 'a' && char <= 'z')
+}
+
+func isNameChar(char byte) bool {
+	return isLetter(char) || isDigit(char) || char == '.'
+}
+
+// matchWords returns the length of a multiple words keyword at text start
+func matchWords(text, keyword string) int {
+	pos := 0
+	for _, word := range strings.Fields(keyword) {
+		for pos < len(text) && text[pos] == ' ' {
+			pos++
+		}
+		if len(text) < pos+len(word) || strings.ToUpper(text[pos:pos+len(word)]) != word {
+			return 0
+		}
+		pos += len(word)
+		if pos < len(text) && isNameChar(text[pos]) {
+			return 0
+		}
+	}
+	return pos
+}
+
+// matchOperator returns the operator token at text start
+func matchOperator(text string) (byte, int) {
+	for _, operator := range []string{">=", "<>", "<=", ">", "=", "<", "+", "-", "*", "/", "^", "\\"} {
+		if strings.HasPrefix(text, operator) {
+			return keywordCodes[operator], len(operator)
+		}
+	}
+	return 0, 0
+}
+
+// dataEnd returns the end of a DATA statement
+func dataEnd(text string, pos int) int {
+	quoted := false
+	for ; pos < len(text); pos++ {
+		if text[pos] == '"' {
+			quoted = !quoted
+		} else if text[pos] == ':' && !quoted {
+			break
+		}
+	}
+	return pos
+}
+
+// appendName appends a name with the last character marked
+func appendName(data []byte, name string) []byte {
+	data = append(data, name...)
+	data[len(data)-1] |= 0x80
+	return data
+}
+
+// appendVariable appends a variable reference
+func appendVariable(data []byte, name string) []byte {
+	code := byte(CodeVar)
+	switch name[len(name)-1] {
+	case '%':
+		code = CodeVarInteger
+	case '$':
+		code = CodeVarString
+	case '!':
+		code = CodeVarReal
+	}
+	if code != CodeVar {
+		name = name[:len(name)-1]
+	}
+	data = append(data, code, 0, 0) // variable offset
+	return appendName(data, name)
+}
+
+// parseHex parses &hex, &Hhex and &Xbinary numbers
+func parseHex(text string) (int, uint64, byte, error) {
+	pos, base, code := 1, 16, byte(CodeHex16)
+	if len(text) > 1 {
+		switch text[1] {
+		case 'x', 'X':
+			pos, base, code = 2, 2, CodeBinary16
+		case 'h', 'H':
+			pos = 2
+		}
+	}
+	end := pos
+	for end < len(text) && strings.IndexByte("0123456789abcdefABCDEF", text[end]) >= 0 {
+		end++
+	}
+	value, err := strconv.ParseUint(text[pos:end], base, 16)
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("invalid number: %s", text[:end])
+	}
+	return end, value, code, nil
+}
+
+// numberLength returns the length of a number literal
+func numberLength(text string) int {
+	pos := 0
+	for pos < len(text) && (isDigit(text[pos]) || text[pos] == '.') {
+		pos++
+	}
+	if pos < len(text) && (text[pos] == 'E' || text[pos] == 'e') {
+		exp := pos + 1
+		if exp < len(text) && (text[exp] == '+' || text[exp] == '-') {
+			exp++
+		}
+		if exp < len(text) && isDigit(text[exp]) {
+			for pos = exp; pos < len(text) && isDigit(text[pos]); pos++ {
+			}
+		}
+	}
+	return pos
+}
+
+// encodeNumber encodes a number literal
+func encodeNumber(literal string, lineNumber bool) ([]byte, error) {
+	if value, err := strconv.ParseUint(literal, 10, 16); err == nil {
+		switch {
+		case lineNumber:
+			return []byte{CodeLineNumber, byte(value), byte(value >> 8)}, nil
+		case value <= 10:
+			return []byte{CodeNumber0 + byte(value)}, nil
+		case value <= 0xff:
+			return []byte{CodeInteger8, byte(value)}, nil
+		case value <= 0x7fff:
+			return []byte{CodeInteger16, byte(value), byte(value >> 8)}, nil
+		}
+	}
+	value, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid number: %s", literal)
+	}
+	real, err := EncodeReal(value)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{CodeReal}, real[:]...), nil
+}
@@ -2,17 +2,22 @@
 package cpc
 
 import (
+	"errors"
 	"log"
+	"strconv"
+	"strings"
 
 	"github.com/jtruco/emu8/emulator/config"
 	"github.com/jtruco/emu8/emulator/device"
 	"github.com/jtruco/emu8/emulator/device/audio"
 	"github.com/jtruco/emu8/emulator/device/cpu"
 	"github.com/jtruco/emu8/emulator/device/cpu/z80"
+	"github.com/jtruco/emu8/emulator/device/io/keyboard"
 	"github.com/jtruco/emu8/emulator/device/io/tape"
 	"github.com/jtruco/emu8/emulator/device/memory"
 	"github.com/jtruco/emu8/emulator/device/video"
 	"github.com/jtruco/emu8/emulator/machine"
+	"github.com/jtruco/emu8/emulator/machine/cpc/basic"
 	"github.com/jtruco/emu8/emulator/machine/cpc/format"
 )
 
@@ -23,6 +28,10 @@ import (
 // Amstrad CPC models
 const (
 	AmstradCPC464 = iota
+	AmstradCPC6128
+	AmstradCPC464Plus
+	AmstradCPC6128Plus
+	AmstradGX4000
 )
 
 // Default Amstrad CPC
@@ -30,13 +39,9 @@ const (
 	cpcFPS          = 50              // 50 Hz ( 50.08 Hz )
 	cpcTStates      = 79872           // TStates per frame ( 312 sl * 256 Ts ) ~ 4 Mhz
 	cpcAudioTStates = cpcTStates >> 5 // Audio TStates (~ 1MHz / 8)
-	cpcOsRomName    = "cpc464_os.rom"
-	cpcOsRomNameES  = "cpc464_os_es.rom"
-	cpcOsRomNameFR  = "cpc464_os_fr.rom"
-	cpcBasicRomName = "cpc464_basic.rom"
+	cpcIOTStates    = 4               // IO cycle TStates
 	cpcJumpers      = 0x1e
-	cpcLowerROM     = 0
-	cpcUpperROM     = 4
+	cpcUpperROMs    = 16 // Upper ROM slots
 )
 
 // AmstradCPC the Amstrad CPC 464
@@ -55,6 +60,19 @@ type AmstradCPC struct {
 	keyboard   *Keyboard           // The matrix keyboard
 	tape       *tape.Drive         // The tape drive
 	joystick   *Joystick           // The CPC Joystick
+	mouse      *Mouse              // The AMX mouse (optional)
+	disks      *Disks              // The disk drives (6128 models)
+	roms       *machine.RomSet     // The ROM set
+	upperRoms  [cpcUpperROMs]bool  // Upper ROMs loaded (0 is BASIC)
+	romSelect  byte                // Upper ROM select port
+	upperRom   int                 // Upper ROM mapped (-1 none)
+	ramConfig  byte                // RAM configuration (128K models)
+	asic       *Asic               // The CPC Plus ASIC (Plus models)
+	cartridge  [][]byte            // The cartridge pages (Plus models)
+	lowerPage  int                 // Cartridge page of the lower ROM (-1 none)
+	typist     *keyboard.Typist    // The keyboard typist
+	fastload   bool                // Tape fast loading
+	fresh      bool                // Machine is freshly reset
 }
 
 // New returns a new Amstrad CPC
@@ -62,18 +80,35 @@ func New(model int) machine.Machine {
 	cpc := new(AmstradCPC)
 	cpc.config.Model = model
 	cpc.config.SetTimings(cpcTStates, cpcFPS)
+	switch model {
+	case AmstradCPC464:
+		cpc.roms = cpcRomSet
+	case AmstradCPC6128:
+		cpc.roms = cpc6128RomSet
+	case AmstradGX4000:
+		cpc.roms = gx4000RomSet
+	default:
+		cpc.roms = cpcPlusRomSet
+	}
 	// memory map
-	cpc.memory = memory.New(6)
-	cpc.memory.SetMap(0, memory.NewROM(0x0000, memory.Size16K)) // Lower ROM Bios
-	cpc.memory.SetMap(1, memory.NewRAM(0x0000, memory.Size16K))
-	cpc.memory.SetMap(2, memory.NewRAM(0x4000, memory.Size16K))
-	cpc.memory.SetMap(3, memory.NewRAM(0x8000, memory.Size16K))
-	cpc.memory.SetMap(4, memory.NewROM(0xC000, memory.Size16K)) // Upper ROM Basic
-	cpc.memory.SetMap(5, memory.NewRAM(0xC000, memory.Size16K))
+	if cpc.IsPlus() {
+		cpc.asic = NewAsic(cpc)
+	}
+	cpc.memory = newMemory(cpc.asic)
 	// devices
 	cpc.clock = device.NewClock()
 	cpc.cpu = z80.New(cpc.clock, cpc.memory, cpc)
 	cpc.crtc = video.NewMC6845()
+	if cpc.IsPlus() {
+		cpc.crtc.SetType(video.CrtcType3)
+	}
+	if name := config.Get().Machine.Option("crtc"); name != "" {
+		if crtcType, err := strconv.Atoi(name); err == nil && crtcType >= 0 && crtcType < video.CrtcTypes {
+			cpc.crtc.SetType(crtcType)
+		} else {
+			log.Println("CPC : Unknown CRTC type:", name)
+		}
+	}
 	cpc.gatearray = NewGateArray(cpc)
 	cpc.video = NewVduVideo(cpc)
 	cpc.keyboard = NewKeyboard()
@@ -82,20 +117,43 @@ func New(model int) machine.Machine {
 	cpc.psg.OnReadPortA = cpc.onPsgReadPortA
 	cpc.ppi = NewPpi(cpc)
 	cpc.tape = tape.New(cpc.clock)
+	if cpc.hasDisks() {
+		cpc.disks = NewDisks()
+	}
 	cpc.joystick = NewJoystick(cpc.keyboard)
+	switch name := config.Get().Machine.Option("mouse"); name {
+	case "", "none":
+	case "amx":
+		cpc.mouse = NewMouse()
+	default:
+		log.Println("CPC : Unknown mouse:", name)
+	}
+	cpc.tape.SetAutoPlay(config.Get().Tape.AutoStart)
+	cpc.tape.OnInsert = cpc.onTapeInsert
+	cpc.typist = keyboard.NewTypist(cpc.keyboard)
+	cpc.fastload = config.Get().Tape.FastLoad
 	// register all components
 	cpc.components = device.NewComponents()
 	cpc.components.Add(cpc.clock)
 	cpc.components.Add(cpc.cpu)
 	cpc.components.Add(cpc.memory)
+	if cpc.asic != nil {
+		cpc.components.Add(cpc.asic)
+	}
 	cpc.components.Add(cpc.gatearray)
 	cpc.components.Add(cpc.crtc)
 	cpc.components.Add(cpc.video)
 	cpc.components.Add(cpc.keyboard)
 	cpc.components.Add(cpc.psg)
 	cpc.components.Add(cpc.tape)
+	if cpc.disks != nil {
+		cpc.components.Add(cpc.disks)
+	}
 	cpc.components.Add(cpc.ppi)
 	cpc.components.Add(cpc.joystick)
+	if cpc.mouse != nil {
+		cpc.components.Add(cpc.mouse)
+	}
 	return cpc
 }
 
@@ -116,27 +174,69 @@ func (cpc *AmstradCPC) Reset() {
 
 // initAmstrad common init tasks
 func (cpc *AmstradCPC) initAmstrad() {
+	cpc.typist.Cancel()
+	cpc.fresh = true
+	// memory
+	cpc.ramConfig = 0
+	if cpc.IsPlus() {
+		cpc.initCartridge()
+	} else {
+		cpc.initRoms()
+	}
+	cpc.updateMemory()
+	// devices
+	cpc.ppi.jumpers = cpcJumpers
+}
+
+// initRoms loads the OS, BASIC and expansion ROMs
+func (cpc *AmstradCPC) initRoms() {
 	// load lower rom (os)
-	romname := cpcOsRomName
-	switch config.Get().Machine.Options {
-	case "es":
-		romname = cpcOsRomNameES
-	case "fr":
-		romname = cpcOsRomNameFR
-	}
-	data, err := cpc.control.LoadROM(romname)
+	data, err := cpc.loadOsRom()
 	if err != nil {
+		log.Println(err.Error())
 		return
 	}
-	cpc.memory.Bank(cpcLowerROM).Load(0, data[:0x4000]) // lower rom
-	// load upper rom (basic)
-	data, err = cpc.control.LoadROM(cpcBasicRomName)
-	if err != nil {
-		return
+	cpc.memory.Bank(cpcLowerROM).Load(0, data) // lower rom
+	// load upper roms (basic & expansion roms)
+	for i := range cpc.upperRoms {
+		data, err = cpc.roms.Load(cpc.control, cpcUpperSlot(i))
+		if err != nil {
+			log.Println(err.Error())
+		}
+		cpc.upperRoms[i] = data != nil
+		if data != nil {
+			cpc.memory.Bank(upperRomMap(i)).Load(0, data)
+		}
 	}
-	cpc.memory.Bank(cpcUpperROM).Load(0, data[:0x4000]) // upper rom
-	// devices
-	cpc.ppi.jumpers = cpcJumpers
+	cpc.upperRom = -1
+	cpc.selectUpperRom(0) // upper rom
+}
+
+// loadOsRom loads the OS ROM
+func (cpc *AmstradCPC) loadOsRom() ([]byte, error) {
+	return cpc.roms.LoadImage(cpc.control, "os", cpc.osRomSelection())
+}
+
+// osRomSelection returns the OS ROM image. The lang option (es, fr), or the
+// legacy es and fr options, selects the localized OS ROM when the ROM slot
+// is not overridden.
+func (cpc *AmstradCPC) osRomSelection() string {
+	options := &config.Get().Machine
+	lang := strings.ToLower(options.Option("lang"))
+	for _, legacy := range []string{"es", "fr"} {
+		if lang == "" && options.Flag(legacy) {
+			lang = legacy // legacy language option
+		}
+	}
+	selection := cpc.roms.Selection("os")
+	if lang != "" && options.Option("rom.os") == "" {
+		selection = cpc.roms.Slot("os").Default + "_" + lang
+		if cpc.roms.Image(selection) == nil {
+			log.Println("CPC : Unknown language:", lang)
+			selection = cpc.roms.Slot("os").Default
+		}
+	}
+	return selection
 }
 
 // Machine interface
@@ -169,10 +269,23 @@ func (cpc *AmstradCPC) InitControl(control machine.Control) {
 	control.BindAudio(cpc.psg)
 	control.BindKeyboard(cpc.keyboard)
 	control.BindJoystick(cpc.joystick)
-	control.BindTapeDrive(cpc.tape)
+	if cpc.mouse != nil {
+		control.BindMouse(cpc.mouse)
+	}
+	if cpc.config.Model != AmstradGX4000 {
+		control.BindTapeDrive(cpc.tape)
+	}
 	// Register formats
 	control.RegisterSnapshot(format.SNA)
-	control.RegisterTape(format.CDT, format.NewCdt)
+	if cpc.config.Model != AmstradGX4000 {
+		control.RegisterTape(format.CDT, format.NewCdt)
+	}
+	if cpc.IsPlus() {
+		control.RegisterCartridge(format.CPR)
+	}
+	if cpc.disks != nil {
+		control.RegisterDisk(format.DSK)
+	}
 	cpc.control = control
 }
 
@@ -180,22 +293,23 @@ func (cpc *AmstradCPC) InitControl(control machine.Control) {
 // -----------------------------------------------------------------------------
 
 // BeginFrame begin emulation frame tasks
-func (cpc *AmstradCPC) BeginFrame() {} // nothing to do
+func (cpc *AmstradCPC) BeginFrame() {
+	// Keyboard typing
+	cpc.typist.Frame()
+}
 
 // Emulate one machine step
 func (cpc *AmstradCPC) Emulate() {
+	// Tape fast loading
+	if cpc.isLoaderTrap() && cpc.casRead() {
+		return
+	}
+
 	// Executes a CPU instruction
 	tstates := cpc.cpu.Execute()
 
-	// 4-tstate rounding fix
-	fix := (0x04 - tstates) & 0x03
-	if fix != 0 {
-		cpc.clock.Add(fix)
-		tstates += fix
-	}
-
 	// CPC bus emulation
-	cpc.gatearray.Emulate(tstates)
+	cpc.gatearray.Sync()
 
 	// Tape emulation
 	cpc.tape.Emulate(tstates)
@@ -209,6 +323,8 @@ func (cpc *AmstradCPC) EndFrame() {} // nothing to do
 
 // Read bus at address
 func (cpc *AmstradCPC) Read(address uint16) byte {
+	cpc.preIO()
+	defer cpc.postIO()
 	var result byte = 0xff
 	if address&0x4000 == 0 { // CRTC
 		port := byte(address>>8) & 0x03
@@ -218,28 +334,115 @@ func (cpc *AmstradCPC) Read(address uint16) byte {
 		port := byte(address>>8) & 0x3
 		result &= cpc.ppi.Read(port)
 	}
+	if cpc.disks != nil && cpc.disks.IsPort(address) { // FDC
+		result &= cpc.disks.Read(address)
+	}
 	return result
 }
 
 // Write bus at address
 func (cpc *AmstradCPC) Write(address uint16, data byte) {
+	cpc.preIO()
+	defer cpc.postIO()
 	if address&0x4000 == 0 { // CRTC
 		port := byte(address>>8) & 0x3
+		if port == 0 && cpc.asic != nil {
+			cpc.asic.OnCrtcSelect(data)
+		}
 		cpc.crtc.Write(port, data)
 	}
 	if address&0xC000 == 0x4000 { // Gate-Array
 		cpc.gatearray.Write(data)
 	}
+	if address&0x2000 == 0 { // Upper ROM select
+		cpc.selectUpperRom(data)
+	}
 	if address&0x0800 == 0 { // PPI select
 		port := byte(address>>8) & 0x3
 		cpc.ppi.Write(port, data)
 	}
+	if cpc.disks != nil && cpc.disks.IsPort(address) { // FDC & motor
+		cpc.disks.Write(address, data)
+	}
+}
+
+// preIO stretches the IO cycle to the gate array WAIT slot. The Z80 samples
+// the WAIT line one tstate later in IO cycles than in memory cycles, so the
+// cycle starts one tstate before the 1 MHz clock : OUT (n),A takes 3 NOPs
+// and OUT (C),r 4 NOPs. The devices are synchronized to the clock, so the
+// access takes effect at its exact tstate.
+func (cpc *AmstradCPC) preIO() {
+	cpc.clock.Inc()
+	cpc.gatearray.Wait()
+	cpc.gatearray.Sync()
+}
+
+// postIO completes the IO cycle
+func (cpc *AmstradCPC) postIO() {
+	cpc.clock.Add(cpcIOTStates - 1)
+}
+
+// IsPlus checks if the model is a CPC Plus or GX4000
+func (cpc *AmstradCPC) IsPlus() bool {
+	return cpc.config.Model >= AmstradCPC464Plus
+}
+
+// hasRAM128 checks if the model has 128K RAM
+func (cpc *AmstradCPC) hasRAM128() bool {
+	return cpc.config.Model == AmstradCPC6128 || cpc.config.Model == AmstradCPC6128Plus
+}
+
+// hasDisks checks if the model has the built-in disk drive
+func (cpc *AmstradCPC) hasDisks() bool {
+	return cpc.config.Model == AmstradCPC6128 || cpc.config.Model == AmstradCPC6128Plus
+}
+
+// selectRAMConfig selects the RAM configuration of the 128K models
+func (cpc *AmstradCPC) selectRAMConfig(config byte) {
+	if cpc.hasRAM128() {
+		cpc.ramConfig = config & 0x07
+		cpc.updateMemory()
+	}
+}
+
+// updateMemory updates the memory mapping
+func (cpc *AmstradCPC) updateMemory() {
+	lowerBlock, asicPage := 0, false
+	if cpc.asic != nil {
+		lowerBlock, asicPage = cpc.asic.LowerRomBlock(), cpc.asic.IsPageMapped()
+	}
+	cpc.mapper().update(cpcRAMConfigs[cpc.ramConfig], lowerBlock, asicPage)
+}
+
+// mapper gets the memory mapper
+func (cpc *AmstradCPC) mapper() *memoryMapper { return cpc.memory.Mapper().(*memoryMapper) }
+
+// selectUpperRom maps the selected upper ROM. Empty slots select BASIC.
+func (cpc *AmstradCPC) selectUpperRom(rom byte) {
+	cpc.romSelect = rom
+	if cpc.IsPlus() {
+		cpc.selectCartridgeUpper(rom)
+		return
+	}
+	index := 0
+	if int(rom) < cpcUpperROMs && cpc.upperRoms[rom] {
+		index = int(rom)
+	}
+	if index == cpc.upperRom || !cpc.upperRoms[index] {
+		return
+	}
+	cpc.upperRom = index
+	cpc.mapper().SelectUpper(index)
 }
 
 // onPsgReadPortA
 func (cpc *AmstradCPC) onPsgReadPortA() byte {
 	// Keyboard connected to PSG Port A
-	return cpc.keyboard.State()
+	state := cpc.keyboard.State()
+	if cpc.mouse != nil && cpc.keyboard.row == cpcJoystickRow {
+		state &= cpc.mouse.State()
+	}
+	return state
 }
 
 // Files : load & save state / tape
@@ -260,17 +463,22 @@ func (cpc *AmstradCPC) LoadState(state machine.State) {
 }
 
 func (cpc *AmstradCPC) loadSnapshot(snap *format.Snapshot) {
+	cpc.fresh = false
 	// CPU
 	cpc.cpu.State.Copy(&snap.State)
-	// Memory
-	cpc.memory.LoadRAM(0x00, snap.Memory[0:])
+	// Memory (base 64K)
+	for i := 0; i < cpcRAMBlocks; i++ {
+		cpc.memory.Bank(cpcRAMBanks[i]).Load(0, snap.Memory[i<<14:(i+1)<<14])
+	}
 	// GateArray
-	cpc.gatearray.SetPen(snap.GaSelectedPen)
-	palette := cpc.gatearray.Palette()
 	for i := 0; i < gaTotalPens; i++ {
-		palette[i] = int(snap.GaPenColours[i])
+		cpc.gatearray.SetPen(byte(i))
+		cpc.gatearray.SetInk(snap.GaPenColours[i])
 	}
+	cpc.gatearray.SetPen(snap.GaSelectedPen)
 	cpc.gatearray.Write(snap.GaMultiConfig)
+	cpc.selectRAMConfig(snap.GaRAMSelect)
+	cpc.selectUpperRom(snap.RomSelect)
 	// Crtc
 	cpc.crtc.SelectRegister(snap.CrtcSelected)
 	for i := byte(0); i < 18; i++ {
@@ -294,11 +502,10 @@ func (cpc *AmstradCPC) saveSnapshot() *format.Snapshot {
 	var snap = new(format.Snapshot)
 	// CPU
 	snap.State.Copy(&cpc.cpu.State)
-	// Memory banks (64k)
-	cpc.memory.Bank(1).Save(snap.Memory[0x0000:])
-	cpc.memory.Bank(2).Save(snap.Memory[0x4000:])
-	cpc.memory.Bank(3).Save(snap.Memory[0x8000:])
-	cpc.memory.Bank(5).Save(snap.Memory[0xC000:])
+	// Memory banks (base 64k)
+	for i := 0; i < cpcRAMBlocks; i++ {
+		cpc.memory.Bank(cpcRAMBanks[i]).Save(snap.Memory[i<<14:])
+	}
 	// GateArray
 	snap.GaSelectedPen = cpc.gatearray.Pen()
 	palette := cpc.gatearray.Palette()
@@ -306,6 +513,8 @@ func (cpc *AmstradCPC) saveSnapshot() *format.Snapshot {
 		snap.GaPenColours[i] = byte(palette[i])
 	}
 	snap.GaMultiConfig = cpc.gatearray.Config()
+	snap.GaRAMSelect = cpc.ramConfig
+	snap.RomSelect = cpc.romSelect
 	// Crtc
 	snap.CrtcSelected = cpc.crtc.Selected()
 	for i := byte(0); i < 18; i++ {
@@ -318,3 +527,45 @@ func (cpc *AmstradCPC) saveSnapshot() *format.Snapshot {
 	}
 	return snap
 }
+
+// Disks
+
+// InsertDisk inserts a disk image into the first drive
+func (cpc *AmstradCPC) InsertDisk(ext string, data []byte) error {
+	if cpc.disks == nil {
+		return errors.New("No disk drive")
+	}
+	return cpc.disks.InsertDisk(ext, data)
+}
+
+// BASIC : list & enter programs
+
+// cpcRAM is the CPC RAM access, ignoring the ROM mapping
+type cpcRAM struct {
+	memory *memory.Memory
+}
+
+// Peek reads a RAM byte
+func (ram cpcRAM) Peek(address uint16) byte {
+	return ram.memory.Bank(cpcRAMBanks[address>>14]).Read(address & 0x3fff)
+}
+
+// Poke writes a RAM byte
+func (ram cpcRAM) Poke(address uint16, data byte) {
+	ram.memory.Bank(cpcRAMBanks[address>>14]).Write(address&0x3fff, data)
+}
+
+// ListProgram lists the BASIC program in memory
+func (cpc *AmstradCPC) ListProgram() string {
+	program := basic.ReadProgram(cpcRAM{cpc.memory})
+	return basic.Detokenize(program, basic.ProgramStart)
+}
+
+// EnterProgram loads a BASIC listing into memory
+func (cpc *AmstradCPC) EnterProgram(listing string) error {
+	program, err := basic.Tokenize(listing)
+	if err != nil {
+		return err
+	}
+	return basic.WriteProgram(cpcRAM{cpc.memory}, program)
+}
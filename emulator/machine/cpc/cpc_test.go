@@ -0,0 +1,32 @@
+package cpc
+
+import (
+	"testing"
+
+	"github.com/jtruco/emu8/emulator/config"
+)
+
+// TestLanguage selects the localized OS ROM with the lang option and the
+// legacy language option
+func TestLanguage(t *testing.T) {
+	options := &config.Get().Machine
+	defer func(saved string) { options.Options = saved }(options.Options)
+	tests := []struct {
+		options, expected string
+	}{
+		{"", "cpc464_os"},
+		{"lang=es", "cpc464_os_es"},
+		{"es", "cpc464_os_es"},
+		{"joy1=cursor,fr", "cpc464_os_fr"},
+		{"lang=fr,es", "cpc464_os_fr"},
+		{"lang=xx", "cpc464_os"},
+		{"lang=es,rom.os=custom.rom", "custom.rom"},
+	}
+	cpc := newTestCPC(AmstradCPC464)
+	for _, test := range tests {
+		options.Options = test.options
+		if selection := cpc.osRomSelection(); selection != test.expected {
+			t.Errorf("%q : %s, expected %s", test.options, selection, test.expected)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package cpc
+
+import (
+	"errors"
+
+	"github.com/jtruco/emu8/emulator/device/io/disk"
+	"github.com/jtruco/emu8/emulator/device/io/fdc"
+	"github.com/jtruco/emu8/emulator/machine/cpc/format"
+)
+
+// -----------------------------------------------------------------------------
+// Amstrad CPC - Disk drives
+// -----------------------------------------------------------------------------
+
+// Disk drive ports
+const (
+	cpcDrives      = 2      // Number of disk drives (A & B)
+	cpcDiskMask    = 0x0580 // Disk ports address mask (A10, A8 & A7)
+	cpcDiskMotor   = 0x0000 // Motor port : FA7E
+	cpcDiskFdc     = 0x0100 // FDC ports : FB7E status, FB7F data
+	cpcDiskFdcData = 0x0001 // FDC data register (A0)
+)
+
+// Disks are the disk drives of the CPC, connected to the uPD765 controller
+// of the DDI-1 interface (built in the CPC 6128 models)
+type Disks struct {
+	fdc *fdc.UPD765 // The floppy disk controller
+}
+
+// NewDisks creates the disk drives
+func NewDisks() *Disks {
+	disks := new(Disks)
+	disks.fdc = fdc.NewUPD765(cpcDrives)
+	return disks
+}
+
+// Drive gets the disk drive at index
+func (disks *Disks) Drive(index int) *disk.Drive { return disks.fdc.Drive(index) }
+
+// Device
+
+// Init initializes the drives
+func (disks *Disks) Init() { disks.Reset() }
+
+// Reset resets the controller
+func (disks *Disks) Reset() { disks.fdc.Reset() }
+
+// Ports
+
+// IsPort checks if the address is a disk port
+func (disks *Disks) IsPort(address uint16) bool {
+	port := address & cpcDiskMask
+	return port == cpcDiskMotor || port == cpcDiskFdc
+}
+
+// Read reads the controller register of the port
+func (disks *Disks) Read(address uint16) byte {
+	if address&cpcDiskMask != cpcDiskFdc {
+		return 0xff
+	}
+	if address&cpcDiskFdcData != 0 {
+		return disks.fdc.ReadData()
+	}
+	return disks.fdc.ReadStatus()
+}
+
+// Write writes the motor or the controller data port
+func (disks *Disks) Write(address uint16, data byte) {
+	switch address & cpcDiskMask {
+	case cpcDiskMotor:
+		disks.fdc.SetMotor(data&0x01 != 0)
+	case cpcDiskFdc:
+		if address&cpcDiskFdcData != 0 {
+			disks.fdc.WriteData(data)
+		}
+	}
+}
+
+// Disks
+
+// InsertDisk inserts a disk image into the first drive
+func (disks *Disks) InsertDisk(ext string, data []byte) error {
+	if ext != format.DSK {
+		return errors.New("Not supported disk format: " + ext)
+	}
+	image := format.LoadDSK(data)
+	if image == nil {
+		return errors.New("Invalid disk image")
+	}
+	disks.Drive(0).Insert(image)
+	return nil
+}
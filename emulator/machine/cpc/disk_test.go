@@ -0,0 +1,98 @@
+package cpc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jtruco/emu8/emulator/device/io/fdc"
+	"github.com/jtruco/emu8/emulator/machine/cpc/format"
+)
+
+// FDC ports
+const (
+	testPortMotor  = 0xfa7e
+	testPortStatus = 0xfb7e
+	testPortData   = 0xfb7f
+)
+
+// newTestCPC creates an initialized machine, without ROMs
+func newTestCPC(model int) *AmstradCPC {
+	cpc := New(model).(*AmstradCPC)
+	cpc.components.Init()
+	return cpc
+}
+
+// fdcCommand writes the command bytes to the FDC data port
+func fdcCommand(t *testing.T, cpc *AmstradCPC, command ...byte) {
+	for _, data := range command {
+		if cpc.Read(testPortStatus)&(fdc.MsrRQM|fdc.MsrDIO) != fdc.MsrRQM {
+			t.Fatalf("FDC not ready for command %02x", command[0])
+		}
+		cpc.Write(testPortData, data)
+	}
+}
+
+// fdcRead reads the execution or the result phase bytes from the FDC data
+// port
+func fdcRead(cpc *AmstradCPC) []byte {
+	const mask = fdc.MsrRQM | fdc.MsrDIO | fdc.MsrExecute
+	var data []byte
+	phase := cpc.Read(testPortStatus) & mask
+	for phase&fdc.MsrDIO != 0 && cpc.Read(testPortStatus)&mask == phase {
+		data = append(data, cpc.Read(testPortData))
+	}
+	return data
+}
+
+// TestCatalog reads the directory of a DATA disk through the FDC ports and
+// lists the files, as the AMSDOS CAT command
+func TestCatalog(t *testing.T) {
+	image := format.NewDSK(format.DiskData)
+	entry := image.Track(0, 0).Find(0xc1).Data
+	copy(entry, "\x00HELLO   BAS\x00\x00\x00\x08\x02")
+	cpc := newTestCPC(AmstradCPC6128)
+	if err := cpc.InsertDisk(format.DSK, format.SaveDSK(image)); err != nil {
+		t.Fatal(err)
+	}
+	cpc.Write(testPortMotor, 1)
+	fdcCommand(t, cpc, 0x07, 0x00) // recalibrate
+	fdcCommand(t, cpc, 0x08)       // sense interrupt status
+	if result := fdcRead(cpc); len(result) != 2 || result[0] != fdc.St0SeekEnd || result[1] != 0 {
+		t.Fatalf("recalibrate : result % x", result)
+	}
+	fdcCommand(t, cpc, 0x46, 0x00, 0, 0, 0xc1, 2, 0xc4, 0x2a, 0xff) // read data C1-C4
+	directory := fdcRead(cpc)
+	if len(directory) != 4*512 {
+		t.Fatalf("read data : %d bytes, expected %d", len(directory), 4*512)
+	}
+	result := fdcRead(cpc)
+	expected := []byte{fdc.St0Abnormal, fdc.St1EndCylinder, 0, 1, 0, 1, 2}
+	if string(result) != string(expected) {
+		t.Errorf("read data : result % x, expected % x", result, expected)
+	}
+	var files []string
+	for i := 0; i < len(directory); i += 32 {
+		if directory[i] != 0xe5 {
+			name := string(directory[i+1 : i+9])
+			ext := string(directory[i+9 : i+12])
+			files = append(files, strings.TrimSpace(name)+"."+strings.TrimSpace(ext))
+		}
+	}
+	if len(files) != 1 || files[0] != "HELLO.BAS" {
+		t.Errorf("catalog : %v", files)
+	}
+}
+
+// TestNoDisk checks the not ready error without disk
+func TestNoDisk(t *testing.T) {
+	cpc := newTestCPC(AmstradCPC6128)
+	cpc.Write(testPortMotor, 1)
+	fdcCommand(t, cpc, 0x46, 0x00, 0, 0, 0xc1, 2, 0xc1, 0x2a, 0xff)
+	result := fdcRead(cpc)
+	if len(result) != 7 || result[0] != fdc.St0Abnormal|fdc.St0NotReady {
+		t.Errorf("read data : result % x", result)
+	}
+	if err := newTestCPC(AmstradCPC464).InsertDisk(format.DSK, nil); err == nil {
+		t.Error("CPC 464 : disk inserted without disk drive")
+	}
+}
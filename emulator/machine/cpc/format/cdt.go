@@ -1,6 +1,9 @@
 package format
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/jtruco/emu8/emulator/device/io/tape"
 	"github.com/jtruco/emu8/emulator/machine/spectrum/format"
 )
@@ -12,8 +15,146 @@ import (
 // CDT format extension
 const CDT = "cdt"
 
+// CPC tape record sync bytes
+const (
+	CdtSyncHeader = 0x2c
+	CdtSyncData   = 0x16
+)
+
+// CdtTimings are the firmware timings of the tape data blocks at 1000 baud,
+// in TZX tstates (3.5 MHz) : a zero bit is two 333 µs pulses, a one bit two
+// pulses of double length. The pilot is 2048 one bits, the sync a zero bit.
+var CdtTimings = tape.Timings{
+	Pilot:       2333,
+	PilotPulses: 4096,
+	Sync1:       1167,
+	Sync2:       1167,
+	Zero:        1167,
+	One:         2333,
+	LastBits:    8,
+	Pause:       2000}
+
+// CPC tape file types
+const (
+	CdtFileBasic  = 0
+	CdtFileBinary = 1
+	CdtFileScreen = 2
+	CdtFileASCII  = 3
+)
+
+// CPC tape record constants
+const (
+	cdtSegmentSize   = 0x100
+	cdtCrcSize       = 2
+	cdtHeaderSize    = 64
+	cdtFileNameSize  = 16
+	cdtFileProtected = 0x01
+)
+
+// CPC tape file type names
+var cdtFileTypeNames = map[byte]string{
+	CdtFileBasic:  "BASIC",
+	CdtFileBinary: "Binary",
+	CdtFileScreen: "Screen",
+	CdtFileASCII:  "ASCII",
+}
+
+// CdtHeader is a CPC tape file header record
+type CdtHeader struct {
+	Name       string // File name
+	Block      int    // Block number
+	Last       bool   // Last block of file
+	Type       byte   // File type
+	Protected  bool   // Protected file
+	Length     int    // Block data length
+	Address    int    // Block load address
+	First      bool   // First block of file
+	FileLength int    // File logical length
+	Entry      int    // Entry address
+}
+
+// TypeName returns the header file type name
+func (header *CdtHeader) TypeName() string {
+	name, ok := cdtFileTypeNames[header.Type]
+	if !ok {
+		name = "Unknown"
+	}
+	if header.Protected {
+		name = "Protected " + name
+	}
+	return name
+}
+
 // NewCdt creates a new CDT tape
 func NewCdt() tape.Tape {
 	// CDT is the TZX format
-	return format.NewTzx()
+	return format.NewTzxDescriber(describeRecord)
+}
+
+// SaveCDT saves tape t to CDT data format. ROM loader data blocks of non
+// TZX tapes are saved as turbo speed data blocks with the CPC firmware
+// timings.
+func SaveCDT(t tape.Tape) []byte {
+	return format.SaveTZXTimings(t, &CdtTimings)
+}
+
+// ReadHeader decodes a header record (sync, data & CRC). Returns nil if data
+// is not a header record.
+func ReadHeader(data []byte) *CdtHeader {
+	if len(data) < 1+cdtHeaderSize || data[0] != CdtSyncHeader {
+		return nil
+	}
+	data = data[1:]
+	header := new(CdtHeader)
+	header.Name = strings.TrimRight(string(data[0:cdtFileNameSize]), "\x00 ")
+	header.Block = int(data[16])
+	header.Last = data[17] != 0
+	header.Type = (data[18] >> 1) & 0x07
+	header.Protected = (data[18] & cdtFileProtected) != 0
+	header.Length = int(readWord(data, 19))
+	header.Address = int(readWord(data, 21))
+	header.First = data[23] != 0
+	header.FileLength = int(readWord(data, 24))
+	header.Entry = int(readWord(data, 26))
+	return header
+}
+
+// ReadRecord returns the record data bytes, without sync and segment CRCs
+func ReadRecord(data []byte, length int) []byte {
+	record := make([]byte, 0, length)
+	for pos := 1; pos < len(data) && len(record) < length; pos += cdtSegmentSize + cdtCrcSize {
+		end := pos + cdtSegmentSize
+		if end > len(data) {
+			end = len(data)
+		}
+		if remain := length - len(record); end-pos > remain {
+			end = pos + remain
+		}
+		record = append(record, data[pos:end]...)
+	}
+	return record
+}
+
+// describeRecord decodes the CPC tape record
+func describeRecord(data []byte) *tape.BlockMeta {
+	meta := new(tape.BlockMeta)
+	if header := ReadHeader(data); header != nil {
+		meta.Header = &tape.Header{
+			Type:     header.Type,
+			TypeName: header.TypeName(),
+			Name:     header.Name,
+			Length:   header.FileLength,
+			Param1:   header.Address,
+			Param2:   header.Entry}
+		meta.Description = fmt.Sprintf("Header: block %d", header.Block)
+		if header.Last {
+			meta.Description += " (last)"
+		}
+	} else {
+		meta.Description = fmt.Sprintf("Data: %d bytes", len(data))
+		if len(data) > 0 && data[0] != CdtSyncData {
+			meta.Description += fmt.Sprintf(" (sync 0x%02x)", data[0])
+		}
+	}
+	return meta
 }
@@ -0,0 +1,61 @@
+package format
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// -----------------------------------------------------------------------------
+// CPC Plus CPR cartridge format
+// -----------------------------------------------------------------------------
+
+// CPR format extension
+const CPR = "cpr"
+
+// CPR format constants
+const (
+	CprPageSize    = 0x4000 // Cartridge page size (16K)
+	CprMaxPages    = 32     // Cartridge max pages (512K)
+	cprHeaderSize  = 12     // RIFF header size
+	cprChunkHeader = 8      // Chunk header size
+)
+
+// CprMaxSize is the max size of a CPR file
+const CprMaxSize = cprHeaderSize + CprMaxPages*(cprChunkHeader+CprPageSize)
+
+// LoadCPR loads the pages of a CPR cartridge. The file is a RIFF container
+// of type "AMS!" with "cbNN" chunks, one per 16K page. Data after the RIFF
+// container is ignored.
+func LoadCPR(data []byte) ([][]byte, error) {
+	if len(data) < cprHeaderSize || string(data[0:4]) != "RIFF" || string(data[8:12]) != "AMS!" {
+		return nil, errors.New("CPR : Invalid cartridge format")
+	}
+	if end := 8 + (int(data[4]) | int(data[5])<<8 | int(data[6])<<16 | int(data[7])<<24); end > cprHeaderSize && end < len(data) {
+		data = data[:end]
+	}
+	pages := make([][]byte, 0, CprMaxPages)
+	for pos := cprHeaderSize; pos+cprChunkHeader <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(data[pos+4]) | int(data[pos+5])<<8 | int(data[pos+6])<<16 | int(data[pos+7])<<24
+		pos += cprChunkHeader
+		if size < 0 || pos+size > len(data) {
+			return nil, fmt.Errorf("CPR : Invalid chunk size: %s", id)
+		}
+		if id[0:2] == "cb" {
+			index, err := strconv.Atoi(id[2:])
+			if err != nil || index >= CprMaxPages || size > CprPageSize {
+				return nil, fmt.Errorf("CPR : Invalid cartridge page: %s", id)
+			}
+			for len(pages) <= index {
+				pages = append(pages, nil)
+			}
+			pages[index] = data[pos : pos+size]
+		}
+		pos += size + size&1 // chunks are word aligned
+	}
+	if len(pages) == 0 {
+		return nil, errors.New("CPR : Empty cartridge")
+	}
+	return pages, nil
+}
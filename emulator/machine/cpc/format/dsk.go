@@ -0,0 +1,183 @@
+package format
+
+import (
+	"bytes"
+	"encoding/binary"
+	"log"
+
+	"github.com/jtruco/emu8/emulator/device/io/disk"
+)
+
+// -----------------------------------------------------------------------------
+// DSK disk format
+// -----------------------------------------------------------------------------
+
+// DSK format extension
+const DSK = "dsk"
+
+// DSK disk constants
+const (
+	dskSignature      = "MV - CPC"
+	dskExtSignature   = "EXTENDED CPC DSK File\r\nDisk-Info\r\n"
+	dskCreator        = "emu8"
+	dskHeaderSize     = 0x100
+	dskTrackSignature = "Track-Info\r\n"
+	dskTrackInfoSize  = 0x100
+	dskSectorInfoSize = 8
+	dskMaxSectors     = 29 // Sector infos in a track block
+	dskTracks         = 40 // Tracks of the blank disks
+	dskSectorSize     = 512
+	dskHeaderCreator  = 0x22
+	dskHeaderTracks   = 0x30
+	dskHeaderSides    = 0x31
+	dskHeaderSize16   = 0x32 // Track size (standard format)
+	dskHeaderTable    = 0x34 // Track sizes table (extended format)
+	dskTrackNumber    = 0x10
+	dskTrackSide      = 0x11
+	dskTrackSizeCode  = 0x14
+	dskTrackSectors   = 0x15
+	dskTrackGap3      = 0x16
+	dskTrackFiller    = 0x17
+	dskTrackSectorIDs = 0x18
+	dskFiller         = 0xe5 // Formatted sector data
+)
+
+// Disk formats of the AMSDOS FORMAT command
+const (
+	DiskData   = "data"   // DATA : 9 sectors C1-C9, no system tracks
+	DiskSystem = "system" // SYSTEM (vendor) : 9 sectors 41-49, 2 system tracks
+	DiskIBM    = "ibm"    // IBM : 8 sectors 01-08, 1 reserved track
+)
+
+// dskFormats the first sector ID and the sectors per track of the formats
+var dskFormats = map[string][2]int{
+	DiskData:   {0xc1, 9},
+	DiskSystem: {0x41, 9},
+	DiskIBM:    {0x01, 8},
+}
+
+// dskGap3 the format gap of the 512 bytes sectors
+const dskGap3 = 0x52
+
+// NewDSK creates a blank formatted single side disk of 40 tracks. The
+// sectors are filled with 0xe5, the empty CP/M directory. Returns nil on
+// unknown format.
+func NewDSK(format string) *disk.Disk {
+	geometry, ok := dskFormats[format]
+	if !ok {
+		return nil
+	}
+	sectors := geometry[1]
+	data := bytes.Repeat([]byte{dskFiller}, dskTracks*sectors*dskSectorSize)
+	return disk.NewRegular(dskTracks, 1, sectors, dskSectorSize, byte(geometry[0]), data)
+}
+
+// LoadDSK loads a disk from the standard or extended DSK format. The
+// standard format has the same size for all the tracks, the extended
+// format has a table of track sizes and the data length of each sector.
+func LoadDSK(data []byte) *disk.Disk {
+	if len(data) < dskHeaderSize || string(data[:len(dskSignature)]) != dskSignature &&
+		string(data[:len(dskExtSignature)]) != dskExtSignature {
+		log.Println("DSK : Invalid file format")
+		return nil
+	}
+	extended := string(data[:len(dskExtSignature)]) == dskExtSignature
+	tracks, sides := int(data[dskHeaderTracks]), int(data[dskHeaderSides])
+	if tracks == 0 || sides == 0 || sides > 2 || tracks*sides > dskHeaderSize-dskHeaderTable {
+		log.Println("DSK : Invalid file format")
+		return nil
+	}
+	image := disk.New(tracks, sides)
+	pos := dskHeaderSize
+	for i := 0; i < tracks*sides; i++ {
+		size := int(binary.LittleEndian.Uint16(data[dskHeaderSize16:]))
+		if extended {
+			size = int(data[dskHeaderTable+i]) << 8
+		}
+		if size == 0 { // unformatted track
+			continue
+		}
+		if pos+size > len(data) || string(data[pos:pos+len(dskTrackSignature)]) != dskTrackSignature {
+			log.Println("DSK : Invalid track block", i)
+			return nil
+		}
+		block := data[pos : pos+size]
+		track := image.Track(int(block[dskTrackNumber]), int(block[dskTrackSide]))
+		if track == nil {
+			track = image.Track(i/sides, i%sides)
+		}
+		count := int(block[dskTrackSectors])
+		if count > dskMaxSectors {
+			log.Println("DSK : Invalid track block", i)
+			return nil
+		}
+		offset := dskTrackInfoSize
+		for s := 0; s < count; s++ {
+			info := block[dskTrackSectorIDs+s*dskSectorInfoSize:]
+			length := 128 << (info[3] & 0x07)
+			if extended {
+				length = int(binary.LittleEndian.Uint16(info[6:]))
+			} else if info[3] >= 6 {
+				length = 0x1800
+			}
+			sector := &disk.Sector{
+				Track:   info[0],
+				Side:    info[1],
+				ID:      info[2],
+				Size:    info[3],
+				Status1: info[4],
+				Status2: info[5],
+				Data:    make([]byte, length)}
+			if offset < len(block) {
+				copy(sector.Data, block[offset:])
+			}
+			offset += length
+			track.Sectors = append(track.Sectors, sector)
+		}
+		pos += size
+	}
+	return image
+}
+
+// SaveDSK saves a disk in the extended DSK format. Tracks hold up to 29
+// sectors.
+func SaveDSK(image *disk.Disk) []byte {
+	header := make([]byte, dskHeaderSize)
+	copy(header, dskExtSignature)
+	copy(header[dskHeaderCreator:], dskCreator)
+	header[dskHeaderTracks] = byte(image.Cylinders)
+	header[dskHeaderSides] = byte(image.Sides)
+	var buffer bytes.Buffer
+	for cylinder := 0; cylinder < image.Cylinders; cylinder++ {
+		for side := 0; side < image.Sides; side++ {
+			sectors := image.Track(cylinder, side).Sectors
+			if len(sectors) == 0 {
+				continue
+			}
+			if len(sectors) > dskMaxSectors {
+				sectors = sectors[:dskMaxSectors]
+			}
+			block := make([]byte, dskTrackInfoSize)
+			copy(block, dskTrackSignature)
+			block[dskTrackNumber] = byte(cylinder)
+			block[dskTrackSide] = byte(side)
+			block[dskTrackSizeCode] = sectors[0].Size
+			block[dskTrackSectors] = byte(len(sectors))
+			block[dskTrackGap3] = dskGap3
+			block[dskTrackFiller] = dskFiller
+			for s, sector := range sectors {
+				info := block[dskTrackSectorIDs+s*dskSectorInfoSize:]
+				info[0], info[1], info[2], info[3] = sector.Track, sector.Side, sector.ID, sector.Size
+				info[4], info[5] = sector.Status1, sector.Status2
+				binary.LittleEndian.PutUint16(info[6:], uint16(len(sector.Data)))
+				block = append(block, sector.Data...)
+			}
+			if pad := len(block) & 0xff; pad != 0 {
+				block = append(block, make([]byte, 0x100-pad)...)
+			}
+			header[dskHeaderTable+cylinder*image.Sides+side] = byte(len(block) >> 8)
+			buffer.Write(block)
+		}
+	}
+	return append(header, buffer.Bytes()...)
+}
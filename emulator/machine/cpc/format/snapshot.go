@@ -50,6 +50,16 @@ func NewSnapshot() *Snapshot {
 	return snap
 }
 
+// Peek reads a RAM byte from address
+func (snap *Snapshot) Peek(address uint16) byte {
+	return snap.Memory[address]
+}
+
+// Poke writes a RAM byte to address
+func (snap *Snapshot) Poke(address uint16, data byte) {
+	snap.Memory[address] = data
+}
+
 // -----------------------------------------------------------------------------
 // Format common functions
 // -----------------------------------------------------------------------------
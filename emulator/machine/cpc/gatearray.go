@@ -11,16 +11,19 @@ const (
 	gaSlVsyncDelay = 0x02
 	gaSlIntMax     = 0x34 // 52
 	gaSlIntLimit   = 0x20 // 32
+	gaClockTStates = 0x04 // CPU tstates per 1 MHz cycle
 )
 
 // GateArray for the CPC
 type GateArray struct {
 	cpc          *AmstradCPC
 	palette      []int
+	colours      []uint32 // RGBA colours of the pens
 	mode         byte
 	pen          byte
 	countSlInt   int
 	countSlVsync int
+	total        int64 // Emulated CPU tstates
 }
 
 // NewGateArray creates a GA
@@ -28,7 +31,9 @@ func NewGateArray(cpc *AmstradCPC) *GateArray {
 	ga := new(GateArray)
 	ga.cpc = cpc
 	ga.palette = make([]int, gaTotalPens)
+	ga.colours = make([]uint32, gaTotalPens)
 	ga.cpc.cpu.OnIntAck = ga.onInterruptAck
+	ga.cpc.cpu.OnWait = ga.Wait
 	ga.cpc.crtc.OnHSync = ga.onHSync
 	ga.cpc.crtc.OnVSync = ga.onVSync
 	return ga
@@ -45,10 +50,12 @@ func (ga *GateArray) Reset() {
 	ga.pen = 0
 	for i := 0; i < gaTotalPens; i++ {
 		ga.palette[i] = 0
+		ga.colours[i] = cpcPaletteRGBA[0]
 	}
 	// vdu scanline control
 	ga.countSlInt = 0
 	ga.countSlVsync = 0
+	ga.total = 0
 }
 
 // Config gets current config
@@ -59,7 +66,7 @@ func (ga *GateArray) Config() byte {
 	if !ga.cpc.memory.Map(cpcLowerROM).IsActive() {
 		data |= 0x04
 	}
-	if !ga.cpc.memory.Map(cpcUpperROM).IsActive() {
+	if !ga.cpc.mapper().Upper().IsActive() {
 		data |= 0x08
 	}
 	if ga.cpc.cpu.IntRq {
@@ -86,9 +93,19 @@ func (ga *GateArray) Border() int { return ga.palette[gaBorderPen] }
 // Palette returns the active pen colors
 func (ga *GateArray) Palette() []int { return ga.palette }
 
-// SetInk set ink colour & palette
+// Colours returns the RGBA colours of the pens
+func (ga *GateArray) Colours() []uint32 { return ga.colours }
+
+// SetInk set ink colour & palette. The CPC Plus ASIC converts the ink to
+// its 12 bit palette.
 func (ga *GateArray) SetInk(ink byte) {
+	ink &= 0x1f
 	ga.palette[ga.pen] = int(ink)
+	if ga.cpc.asic != nil {
+		ga.cpc.asic.SetInk(ga.pen, ink)
+	} else {
+		ga.colours[ga.pen] = cpcPaletteRGBA[ink]
+	}
 }
 
 // Bus Input / Output
@@ -110,32 +127,55 @@ func (ga *GateArray) Write(data byte) {
 	case 1: // set colur
 		ga.SetInk(data & 0x1f)
 	case 2: // Video Mode & ROM
+		if ga.cpc.asic != nil && ga.cpc.asic.IsUnlocked() && data&0x20 != 0 {
+			ga.cpc.asic.WriteRMR2(data)
+			return
+		}
 		// mode
 		ga.SetMode(data & 0x03)
 		// rom selection
 		ga.cpc.memory.Map(cpcLowerROM).SetActive(data&0x04 == 0)
-		ga.cpc.memory.Map(cpcUpperROM).SetActive(data&0x08 == 0)
+		ga.cpc.mapper().Upper().SetActive(data&0x08 == 0)
 		// interrupts
 		if (data & 0x10) != 0 {
 			// clear pending interrupts
 			ga.cpc.cpu.InterruptRequest(false)
 			ga.countSlInt = 0
 		}
-	case 3:
-		// RAM memory management (not implemented)
+	case 3: // RAM configuration
+		ga.cpc.selectRAMConfig(data)
 	}
 }
 
 // Emulation
 
-// Emulate gate array
-func (ga *GateArray) Emulate(tstates int) {
-	// 4 MHz gatearray emulation
+// Sync emulates the gate array up to the CPU clock. Every 1 MHz cycle the
+// CRTC moves to the next character, that the video renders.
+func (ga *GateArray) Sync() {
+	total := ga.cpc.clock.Total()
+	if ga.total > total { // clock reset
+		ga.total = total &^ (gaClockTStates - 1)
+	}
+	cycles := 0
+	for ; ga.total < total; ga.total += gaClockTStates {
+		ga.cpc.crtc.OnClock()
+		if ga.cpc.asic != nil {
+			ga.cpc.asic.OnClock()
+		}
+		ga.cpc.video.OnClock()
+		cycles++
+	}
+	if cycles > 0 {
+		ga.cpc.psg.Emulate(cycles)
+	}
+}
 
-	// 1 MHz clock emulation
-	gaTstates := tstates >> 2
-	ga.cpc.crtc.Emulate(gaTstates)
-	ga.cpc.psg.Emulate(gaTstates)
+// Wait inserts the WAIT states of a CPU bus access, aligning it to the
+// 1 MHz clock
+func (ga *GateArray) Wait() {
+	if fix := ga.cpc.clock.Tstates() & (gaClockTStates - 1); fix != 0 {
+		ga.cpc.clock.Add(gaClockTStates - fix)
+	}
 }
 
 // onHSync on CRTC hsync callback
@@ -143,19 +183,30 @@ func (ga *GateArray) onHSync() {
 	ga.countSlInt++
 	if ga.countSlVsync == 0 {
 		if ga.countSlInt == gaSlIntMax {
-			ga.cpc.cpu.InterruptRequest(true)
+			ga.interrupt()
 			ga.countSlInt = 0
 		}
 	} else {
 		ga.countSlVsync--
 		if ga.countSlInt >= gaSlIntLimit {
-			ga.cpc.cpu.InterruptRequest(true)
+			ga.interrupt()
 		}
 		ga.countSlInt = 0
 	}
+	if ga.cpc.asic != nil {
+		ga.cpc.asic.OnHSync()
+	}
 	ga.cpc.video.OnHSync()
 }
 
+// interrupt requests the scanline interrupt. The CPC Plus raster interrupt
+// replaces it when programmed.
+func (ga *GateArray) interrupt() {
+	if ga.cpc.asic == nil || !ga.cpc.asic.HasRasterInterrupt() {
+		ga.cpc.cpu.InterruptRequest(true)
+	}
+}
+
 // onVSync on CRTC vsync callback
 func (ga *GateArray) onVSync() {
 	ga.countSlVsync = gaSlVsyncDelay
@@ -165,6 +216,8 @@ func (ga *GateArray) onVSync() {
 // onInterruptAck interrupt ack
 func (ga *GateArray) onInterruptAck() bool {
 	ga.countSlInt &= 0x01F // Unset bit 5
-	ga.cpc.video.OnIntAck()
+	if ga.cpc.asic != nil {
+		return ga.cpc.asic.OnIntAck()
+	}
 	return false
 }
@@ -1,11 +1,86 @@
 package cpc
 
-import "github.com/jtruco/emu8/emulator/machine"
+import (
+	"strconv"
+
+	"github.com/jtruco/emu8/emulator/machine"
+	"github.com/jtruco/emu8/emulator/machine/cpc/format"
+)
 
 // Amstrad CPC models
 var models = []machine.Model{
 	{Name: "Amstrad CPC 464", Ids: []string{"AmstradCPC464", "CPC464"},
-		Build: func() machine.Machine { return New(AmstradCPC464) }},
+		Build: func() machine.Machine { return New(AmstradCPC464) }, Roms: cpcRomSet},
+	{Name: "Amstrad CPC 6128", Ids: []string{"AmstradCPC6128", "CPC6128"},
+		Build: func() machine.Machine { return New(AmstradCPC6128) }, Roms: cpc6128RomSet},
+	{Name: "Amstrad CPC 464 Plus", Ids: []string{"AmstradCPC464Plus", "CPC464Plus"},
+		Build: func() machine.Machine { return New(AmstradCPC464Plus) }, Roms: cpcPlusRomSet},
+	{Name: "Amstrad CPC 6128 Plus", Ids: []string{"AmstradCPC6128Plus", "CPC6128Plus", "CPCPlus"},
+		Build: func() machine.Machine { return New(AmstradCPC6128Plus) }, Roms: cpcPlusRomSet},
+	{Name: "Amstrad GX4000", Ids: []string{"AmstradGX4000", "GX4000"},
+		Build: func() machine.Machine { return New(AmstradGX4000) }, Roms: gx4000RomSet},
+}
+
+// Amstrad CPC ROM set. Upper ROM 0 is BASIC, upper ROMs 1 to 15 are
+// optional expansion ROMs.
+var cpcRomSet = &machine.RomSet{
+	Slots: append([]machine.RomSlot{
+		{Name: "os", Size: 0x4000, Default: "cpc464_os"},
+		{Name: "basic", Size: 0x4000, Default: "cpc464_basic"},
+	}, cpcUpperSlots(nil)...),
+	Images: []machine.RomImage{
+		{ID: "cpc464_os", File: "cpc464_os.rom", CRC32: 0x815752df, SHA1: "475c8080065a7aa9984daca0415a3d70a5305be2"},
+		{ID: "cpc464_os_es", File: "cpc464_os_es.rom", CRC32: 0x09f2ab2b, SHA1: "6a0ca5ba328976d7e855a39ffb2aab293f1101dd"},
+		{ID: "cpc464_os_fr", File: "cpc464_os_fr.rom", CRC32: 0x874fd0c1, SHA1: "39e250779194264fbae0e150343734f28565f5cf"},
+		{ID: "cpc464_basic", File: "cpc464_basic.rom", CRC32: 0x7d9a3bac, SHA1: "0e414c79f7d4458c68ddc0fc2fec96b0045fda88"},
+	},
+}
+
+// Amstrad CPC 6128 ROM set. Upper ROM 7 is AMSDOS, the disk operating
+// system. Images are validated by their CRC32 checksum.
+var cpc6128RomSet = &machine.RomSet{
+	Slots: append([]machine.RomSlot{
+		{Name: "os", Size: 0x4000, Default: "cpc6128_os"},
+		{Name: "basic", Size: 0x4000, Default: "cpc6128_basic"},
+	}, cpcUpperSlots(map[int]string{7: "amsdos"})...),
+	Images: []machine.RomImage{
+		{ID: "cpc6128_os", File: "cpc6128_os.rom", CRC32: 0x0219bb74},
+		{ID: "cpc6128_basic", File: "cpc6128_basic.rom", CRC32: 0xca6af63d},
+		{ID: "amsdos", File: "amsdos.rom", CRC32: 0x1fe22ecd},
+	},
+}
+
+// CPC Plus ROM set. The system cartridge holds the OS, BASIC and AMSDOS,
+// it is loaded from the cpcplus.cpr file.
+var cpcPlusRomSet = &machine.RomSet{
+	Slots: []machine.RomSlot{
+		{Name: "cartridge", Size: format.CprMaxSize, Default: "cpcplus.cpr"},
+	},
+}
+
+// GX4000 ROM set. The console has no default cartridge.
+var gx4000RomSet = &machine.RomSet{
+	Slots: []machine.RomSlot{
+		{Name: "cartridge", Size: format.CprMaxSize},
+	},
+}
+
+// cpcUpperSlots returns the expansion upper ROM slots, with the default
+// images of the built-in ROMs
+func cpcUpperSlots(defaults map[int]string) []machine.RomSlot {
+	slots := make([]machine.RomSlot, 0, cpcUpperROMs-1)
+	for i := 1; i < cpcUpperROMs; i++ {
+		slots = append(slots, machine.RomSlot{Name: cpcUpperSlot(i), Size: 0x4000, Default: defaults[i]})
+	}
+	return slots
+}
+
+// cpcUpperSlot returns the slot name of an upper ROM
+func cpcUpperSlot(rom int) string {
+	if rom == 0 {
+		return "basic"
+	}
+	return "upper" + strconv.Itoa(rom)
 }
 
 func init() {
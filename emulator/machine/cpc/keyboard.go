@@ -46,6 +46,9 @@ func (keyboard *Keyboard) Reset() { keyboard.Init() }
 // KeyMap returns the default keyboard mapping
 func (keyboard *Keyboard) KeyMap() keyboard.KeyMap { return cpcKeyboardMap }
 
+// KeyNames returns the keys by name
+func (keyboard *Keyboard) KeyNames() map[string]keyboard.Key { return cpcKeyNames }
+
 // ProcessKey processes CPC keyboard matrix
 func (keyboard *Keyboard) ProcessKey(key keyboard.Key, pressed bool) {
 	row := key >> 4
@@ -58,6 +61,11 @@ func (keyboard *Keyboard) ProcessKey(key keyboard.Key, pressed bool) {
 	}
 }
 
+// TextKeys returns the key combinations that type the text
+func (keyboard *Keyboard) TextKeys(text string) [][]keyboard.Key {
+	return cpcTextKeys(text)
+}
+
 // -----------------------------------------------------------------------------
 // Amstrad CPC Keys, States & Mapping
 // -----------------------------------------------------------------------------
@@ -86,7 +94,7 @@ const (
 	CpcKeyCloseBracket = 0x23
 	CpcKeyF4           = 0x24
 	CpcKeyShift        = 0x25
-	CpcKeyForwardSlash = 0x26
+	CpcKeyBackslash    = 0x26
 	CpcKeyControl      = 0x27 // line 3, bit 0.. bit 7
 	CpcKeyHat          = 0x30
 	CpcKeyMinus        = 0x31
@@ -94,7 +102,7 @@ const (
 	CpcKeyP            = 0x33
 	CpcKeySemicolon    = 0x34
 	CpcKeyColon        = 0x35
-	CpcKeyBackslash    = 0x36
+	CpcKeyForwardSlash = 0x36
 	CpcKeyDot          = 0x37 // line 4, bit 0..bit 7
 	CpcKey0            = 0x40
 	CpcKey9            = 0x41
@@ -146,6 +154,90 @@ const (
 	CpcKeyDel          = 0x97
 )
 
+// cpcKeyNames Amstrad CPC keys by name
+var cpcKeyNames = map[string]keyboard.Key{
+	"CursorUp":     CpcKeyCursorUp,
+	"CursorRight":  CpcKeyCursorRight,
+	"CursorDown":   CpcKeyCursorDown,
+	"F9":           CpcKeyF9,
+	"F6":           CpcKeyF6,
+	"F3":           CpcKeyF3,
+	"Intro":        CpcKeyIntro,
+	"Fdot":         CpcKeyFdot,
+	"CursorLeft":   CpcKeyCursorLeft,
+	"Copy":         CpcKeyCopy,
+	"F7":           CpcKeyF7,
+	"F8":           CpcKeyF8,
+	"F5":           CpcKeyF5,
+	"F1":           CpcKeyF1,
+	"F2":           CpcKeyF2,
+	"F0":           CpcKeyF0,
+	"Clr":          CpcKeyClr,
+	"OpenBracket":  CpcKeyOpenBracket,
+	"Return":       CpcKeyReturn,
+	"CloseBracket": CpcKeyCloseBracket,
+	"F4":           CpcKeyF4,
+	"Shift":        CpcKeyShift,
+	"Backslash":    CpcKeyBackslash,
+	"Control":      CpcKeyControl,
+	"Hat":          CpcKeyHat,
+	"Minus":        CpcKeyMinus,
+	"At":           CpcKeyAt,
+	"P":            CpcKeyP,
+	"Semicolon":    CpcKeySemicolon,
+	"Colon":        CpcKeyColon,
+	"ForwardSlash": CpcKeyForwardSlash,
+	"Dot":          CpcKeyDot,
+	"0":            CpcKey0,
+	"9":            CpcKey9,
+	"O":            CpcKeyO,
+	"I":            CpcKeyI,
+	"L":            CpcKeyL,
+	"K":            CpcKeyK,
+	"M":            CpcKeyM,
+	"Comma":        CpcKeyComma,
+	"8":            CpcKey8,
+	"7":            CpcKey7,
+	"U":            CpcKeyU,
+	"Y":            CpcKeyY,
+	"H":            CpcKeyH,
+	"J":            CpcKeyJ,
+	"N":            CpcKeyN,
+	"Space":        CpcKeySpace,
+	"6":            CpcKey6,
+	"5":            CpcKey5,
+	"R":            CpcKeyR,
+	"T":            CpcKeyT,
+	"G":            CpcKeyG,
+	"F":            CpcKeyF,
+	"B":            CpcKeyB,
+	"V":            CpcKeyV,
+	"4":            CpcKey4,
+	"3":            CpcKey3,
+	"E":            CpcKeyE,
+	"W":            CpcKeyW,
+	"S":            CpcKeyS,
+	"D":            CpcKeyD,
+	"C":            CpcKeyC,
+	"X":            CpcKeyX,
+	"1":            CpcKey1,
+	"2":            CpcKey2,
+	"Esc":          CpcKeyEsc,
+	"Q":            CpcKeyQ,
+	"Tab":          CpcKeyTab,
+	"A":            CpcKeyA,
+	"CapsLock":     CpcKeyCapsLock,
+	"Z":            CpcKeyZ,
+	"JoyUp":        CpcKeyJoyUp,
+	"JoyDown":      CpcKeyJoyDown,
+	"JoyLeft":      CpcKeyJoyLeft,
+	"JoyRight":     CpcKeyJoyRight,
+	"JoyFire1":     CpcKeyJoyFire1,
+	"JoyFire2":     CpcKeyJoyFire2,
+	"Spare":        CpcKeySpare,
+	"Del":          CpcKeyDel,
+}
+
 // CPC Keyboard map
 var cpcKeyboardMap = map[keyboard.KeyCode][]keyboard.Key{
 	// alphanum
@@ -226,8 +318,68 @@ var cpcKeyboardMap = map[keyboard.KeyCode][]keyboard.Key{
 	// other
 	keyboard.KeyLAlt:       {CpcKeyCopy},
 	keyboard.KeyRAlt:       {CpcKeyCopy},
-	keyboard.KeyGrave:      {CpcKeyForwardSlash},
-	keyboard.KeySlash:      {CpcKeyBackslash},
+	keyboard.KeyGrave:      {CpcKeyBackslash},
+	keyboard.KeySlash:      {CpcKeyForwardSlash},
 	keyboard.KeyApostrophe: {CpcKeySemicolon},
 	keyboard.KeyBackSlash:  {CpcKeyCloseBracket},
 }
+
+// -----------------------------------------------------------------------------
+// Amstrad CPC Text Typing
+// -----------------------------------------------------------------------------
+
+// cpcSymbolKeys CPC keys of symbols, unshifted and shifted
+var cpcSymbolKeys = map[keyboard.Key]string{
+	CpcKey1: "1!", CpcKey2: "2\"", CpcKey3: "3#", CpcKey4: "4$", CpcKey5: "5%",
+	CpcKey6: "6&", CpcKey7: "7'", CpcKey8: "8(", CpcKey9: "9)", CpcKey0: "0_",
+	CpcKeyMinus: "-=", CpcKeyHat: "^£", CpcKeyAt: "@|", CpcKeyOpenBracket: "[{",
+	CpcKeyCloseBracket: "]}", CpcKeySemicolon: ";+", CpcKeyColon: ":*",
+	CpcKeyBackslash: "\\`", CpcKeyComma: ",<", CpcKeyDot: ".>", CpcKeyForwardSlash: "/?",
+}
+
+// cpcLetterKeys CPC keys of letters from A to Z
+var cpcLetterKeys = [...]keyboard.Key{
+	CpcKeyA, CpcKeyB, CpcKeyC, CpcKeyD, CpcKeyE, CpcKeyF, CpcKeyG, CpcKeyH,
+	CpcKeyI, CpcKeyJ, CpcKeyK, CpcKeyL, CpcKeyM, CpcKeyN, CpcKeyO, CpcKeyP,
+	CpcKeyQ, CpcKeyR, CpcKeyS, CpcKeyT, CpcKeyU, CpcKeyV, CpcKeyW, CpcKeyX,
+	CpcKeyY, CpcKeyZ,
+}
+
+// cpcKeyCombinations key combinations by character
+var cpcKeyCombinations = make(map[rune][]keyboard.Key)
+
+func init() {
+	for key, symbols := range cpcSymbolKeys {
+		runes := []rune(symbols)
+		cpcKeyCombinations[runes[0]] = []keyboard.Key{key}
+		cpcKeyCombinations[runes[1]] = []keyboard.Key{CpcKeyShift, key}
+	}
+	for i, key := range cpcLetterKeys {
+		cpcKeyCombinations[rune('a'+i)] = []keyboard.Key{key}
+		cpcKeyCombinations[rune('A'+i)] = []keyboard.Key{CpcKeyShift, key}
+		cpcKeyCombinations[rune(1+i)] = []keyboard.Key{CpcKeyControl, key} // control codes
+	}
+	cpcKeyCombinations[' '] = []keyboard.Key{CpcKeySpace}
+	cpcKeyCombinations['\t'] = []keyboard.Key{CpcKeyTab}
+	cpcKeyCombinations['\n'] = []keyboard.Key{CpcKeyReturn}
+	delete(cpcKeyCombinations, '\r') // CR LF line ends
+}
+
+// cpcTextKeys returns the key combinations that type the text. Characters
+// are typed with Shift and Control as in the CPC keyboard, with Caps Lock
+// off.
+func cpcTextKeys(text string) [][]keyboard.Key {
+	keys := make([][]keyboard.Key, 0, len(text))
+	for _, r := range text {
+		combination, ok := cpcKeyCombinations[r]
+		if !ok {
+			continue
+		}
+		keys = append(keys, combination)
+		if r == '\n' {
+			// give the editor time to process the line
+			keys = append(keys, keyboard.TypistPause, keyboard.TypistPause)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,113 @@
+package cpc
+
+import (
+	"github.com/jtruco/emu8/emulator/device/cpu/z80"
+	"github.com/jtruco/emu8/emulator/device/io/keyboard"
+)
+
+// -----------------------------------------------------------------------------
+// Amstrad CPC - Tape loading
+// -----------------------------------------------------------------------------
+
+// Firmware loader constants
+const (
+	cpcCasRead        = 0xbca1 // CAS READ firmware jumpblock entry
+	cpcJumpOpcode     = 0xcf   // RST 1 : firmware LOW JUMP
+	cpcSegmentSize    = 0x100  // Record segment data size
+	cpcSegmentCRC     = 2      // Record segment CRC size
+	cpcErrorOverrun   = 1      // Read error : data overrun
+	cpcMaxSyncRetries = 0x10   // Max blocks skipped searching the sync byte
+	cpcBootFrames     = 100    // Frames to wait for the firmware boot
+	cpcAutoTypeFrames = 250    // Max frames since reset to auto type RUN"
+	cpcPressPlayWait  = 25     // Frames to wait for the "Press PLAY" message
+)
+
+// cpcRunKeys are the key combinations of RUN" ENTER and any key to start the tape
+var cpcRunKeys = [][]keyboard.Key{
+	{CpcKeyR},
+	{CpcKeyU},
+	{CpcKeyN},
+	{CpcKeyShift, CpcKey2},
+	{CpcKeyReturn},
+}
+
+// onTapeInsert auto types RUN" on a freshly reset machine
+func (cpc *AmstradCPC) onTapeInsert() {
+	if !cpc.tape.AutoPlay() || !cpc.fresh {
+		return
+	}
+	cpc.fresh = false
+	elapsed := int(cpc.clock.Total() / cpcTStates)
+	if elapsed > cpcAutoTypeFrames {
+		return
+	}
+	if elapsed < cpcBootFrames {
+		cpc.typist.Wait(cpcBootFrames - elapsed)
+	}
+	cpc.typist.Type(cpcRunKeys...)
+	cpc.typist.Wait(cpcPressPlayWait)
+	cpc.typist.Type([]keyboard.Key{CpcKeySpace})
+}
+
+// isLoaderTrap checks if the CPU is entering the firmware CAS READ routine
+func (cpc *AmstradCPC) isLoaderTrap() bool {
+	return cpc.fastload &&
+		cpc.cpu.PC == cpcCasRead &&
+		cpc.tape.HasTape() &&
+		cpc.memory.Peek(cpcCasRead) == cpcJumpOpcode
+}
+
+// casRead emulates the CAS READ firmware routine, loading the next tape record
+// directly into memory. Returns false if there is no record to load.
+// Entry : HL address, DE length, A sync byte.
+// Exit : F carry set on success, otherwise carry reset and A the error code.
+func (cpc *AmstradCPC) casRead() bool {
+	cpu := cpc.cpu
+	// search next record with the requested sync byte. On a miss the tape
+	// is restored, so the firmware loader reads the skipped blocks.
+	index := cpc.tape.BlockIndex()
+	var data []byte
+	for i := 0; i < cpcMaxSyncRetries; i++ {
+		data = cpc.tape.NextDataBlock()
+		if data == nil {
+			break
+		}
+		if len(data) > 0 && data[0] == cpu.A {
+			break
+		}
+		data = nil
+	}
+	if data == nil {
+		cpc.tape.Seek(index)
+		return false
+	}
+	// copy record segments, skipping the segment CRC
+	address := cpu.HL.Get()
+	count := int(cpu.DE.Get())
+	pos := 1
+	success := true
+	for count > 0 {
+		size := count
+		if size > cpcSegmentSize {
+			size = cpcSegmentSize
+		}
+		if pos+size > len(data) {
+			success = false
+			break
+		}
+		for i := 0; i < size; i++ {
+			cpc.memory.Poke(address, data[pos+i])
+			address++
+		}
+		pos += cpcSegmentSize + cpcSegmentCRC
+		count -= size
+	}
+	if success {
+		cpu.F |= z80.FlagC
+	} else {
+		cpu.A = cpcErrorOverrun
+		cpu.F &^= z80.FlagC
+	}
+	cpu.Ret()
+	return true
+}
@@ -0,0 +1,94 @@
+package cpc
+
+import (
+	"testing"
+
+	"github.com/jtruco/emu8/emulator/machine/cpc/format"
+	spectrum "github.com/jtruco/emu8/emulator/machine/spectrum/format"
+)
+
+// testCdt builds a CDT tape of standard speed data blocks
+func testCdt(blocks ...[]byte) []byte {
+	data := []byte("ZXTape!\x1a\x01\x14")
+	for _, block := range blocks {
+		data = append(data, 0x10, 0xe8, 0x03, byte(len(block)), byte(len(block)>>8))
+		data = append(data, block...)
+	}
+	return data
+}
+
+// casReadTrap calls the CAS READ trap with the sync byte, loading length
+// bytes at the RAM block 1
+func casReadTrap(cpc *AmstradCPC, sync byte, length uint16) bool {
+	cpc.cpu.SP = 0xbff0
+	cpc.cpu.A = sync
+	cpc.cpu.H, cpc.cpu.L = 0x40, 0x00
+	cpc.cpu.D, cpc.cpu.E = byte(length>>8), byte(length)
+	return cpc.casRead()
+}
+
+// TestCasReadSync checks the CAS READ trap sync byte search. A miss keeps
+// the tape position for the firmware loader.
+func TestCasReadSync(t *testing.T) {
+	cpc := newTestCPC(AmstradCPC464)
+	var blocks [][]byte
+	for i := 0; i <= cpcMaxSyncRetries; i++ {
+		blocks = append(blocks, []byte{format.CdtSyncData, 1, 2, 3, 0, 0})
+	}
+	blocks = append(blocks, []byte{0x99, 4, 5, 6, 0, 0})
+	cdt := format.NewCdt()
+	if !cdt.Load(testCdt(blocks...)) {
+		t.Fatal("CDT not loaded")
+	}
+	cpc.tape.Insert(cdt)
+	if casReadTrap(cpc, 0x55, 3) {
+		t.Error("sync 0x55 : record loaded")
+	}
+	if index := cpc.tape.BlockIndex(); index != 0 {
+		t.Errorf("sync 0x55 : tape at block %d, expected 0", index)
+	}
+	cpc.tape.Seek(cpcMaxSyncRetries)
+	if !casReadTrap(cpc, 0x99, 3) {
+		t.Fatal("sync 0x99 : record not loaded")
+	}
+	ram := cpc.memory.Bank(cpcRAMBanks[1])
+	if data := []byte{ram.Read(0), ram.Read(1), ram.Read(2)}; string(data) != "\x04\x05\x06" {
+		t.Errorf("sync 0x99 : loaded % x, expected 04 05 06", data)
+	}
+}
+
+// TestCdtConvert converts a TAP tape of a CPC record to CDT, with the
+// firmware timings, and loads the record with the CAS READ trap
+func TestCdtConvert(t *testing.T) {
+	record := []byte{format.CdtSyncData}
+	for i := 0; i < 0x100; i++ {
+		record = append(record, byte(i))
+	}
+	record = append(record, 0, 0) // segment CRC
+	tap := spectrum.NewTap()
+	if !tap.Load(append([]byte{byte(len(record)), byte(len(record) >> 8)}, record...)) {
+		t.Fatal("TAP not loaded")
+	}
+	cdt := format.NewCdt()
+	if !cdt.Load(format.SaveCDT(tap)) {
+		t.Fatal("CDT not loaded")
+	}
+	blocks := cdt.Blocks()
+	if len(blocks) != 2 || blocks[1].Info().Type != 0x11 { // glue & turbo speed data
+		t.Fatalf("CDT blocks : %d", len(blocks))
+	}
+	if timings := blocks[1].Meta().Timings; timings == nil || *timings != format.CdtTimings {
+		t.Errorf("CDT block timings : %v, expected %v", timings, format.CdtTimings)
+	}
+	cpc := newTestCPC(AmstradCPC464)
+	cpc.tape.Insert(cdt)
+	if !casReadTrap(cpc, format.CdtSyncData, 0x100) {
+		t.Fatal("record not loaded")
+	}
+	ram := cpc.memory.Bank(cpcRAMBanks[1])
+	for i := 0; i < 0x100; i++ {
+		if data := ram.Read(uint16(i)); data != byte(i) {
+			t.Fatalf("loaded %02x at %04x, expected %02x", data, testCode+i, byte(i))
+		}
+	}
+}
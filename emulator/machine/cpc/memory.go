@@ -0,0 +1,115 @@
+package cpc
+
+import (
+	"github.com/jtruco/emu8/emulator/device/bus"
+	"github.com/jtruco/emu8/emulator/device/memory"
+)
+
+// -----------------------------------------------------------------------------
+// Amstrad CPC - Memory mapping
+// -----------------------------------------------------------------------------
+
+// Memory map indexes
+const (
+	cpcLowerROM     = 0                                  // Lower ROM (OS)
+	cpcUpperROM     = 4                                  // Upper ROM 0 (BASIC), the cartridge page on the Plus
+	cpcExpansionROM = 10                                 // Upper ROMs 1 to 15 (expansion ROMs)
+	cpcAsicPage     = cpcExpansionROM + cpcUpperROMs - 1 // CPC Plus ASIC registers page
+	cpcRAMBlocks    = 4                                  // 16K blocks of the address space
+)
+
+// cpcRAMBanks memory map indexes of the RAM banks. Banks 0 to 3 are the
+// base 64K RAM, banks 4 to 7 the extended RAM of the 128K models.
+var cpcRAMBanks = [8]int{1, 2, 3, 5, 6, 7, 8, 9}
+
+// cpcRAMConfigs RAM banks by 16K block of the Gate Array RAM configurations
+var cpcRAMConfigs = [8][cpcRAMBlocks]int{
+	{0, 1, 2, 3}, {0, 1, 2, 7}, {4, 5, 6, 7}, {0, 3, 2, 7},
+	{0, 4, 2, 3}, {0, 5, 2, 3}, {0, 6, 2, 3}, {0, 7, 2, 3},
+}
+
+// newMemory creates the CPC memory. The ASIC page is only mapped on the
+// CPC Plus models.
+func newMemory(asic *Asic) *memory.Memory {
+	maps := cpcAsicPage
+	if asic != nil {
+		maps++
+	}
+	mem := memory.New(maps)
+	mem.SetMap(cpcLowerROM, memory.NewROM(0x0000, memory.Size16K))
+	for rom := 0; rom < cpcUpperROMs; rom++ {
+		mem.SetMap(upperRomMap(rom), memory.NewROM(0xC000, memory.Size16K))
+	}
+	for i, index := range cpcRAMBanks {
+		mem.SetMap(index, memory.NewRAM(uint16(i&0x03)<<14, memory.Size16K))
+	}
+	if asic != nil {
+		mem.SetMap(cpcAsicPage, bus.NewMap(asic, 0x4000, memory.Size16K, false, false))
+	}
+	mem.SetMapper(new(memoryMapper))
+	return mem
+}
+
+// upperRomMap returns the memory map index of an upper ROM
+func upperRomMap(rom int) int {
+	if rom == 0 {
+		return cpcUpperROM
+	}
+	return cpcExpansionROM + rom - 1
+}
+
+// memoryMapper maps the CPC memory by 16K blocks. Enabled ROMs are read
+// over the RAM, writes always go to the RAM.
+type memoryMapper struct {
+	maps  bus.Maps               // The memory maps
+	rom   [cpcRAMBlocks]*bus.Map // ROM maps by block
+	ram   [cpcRAMBlocks]*bus.Map // RAM maps by block
+	upper int                    // Map index of the selected upper ROM
+}
+
+// Init inits the mapper with the RAM configuration 0 and the upper ROM 0
+func (mapper *memoryMapper) Init(maps bus.Maps) {
+	mapper.maps = maps
+	mapper.upper = cpcUpperROM
+	mapper.update(cpcRAMConfigs[0], 0, false)
+}
+
+// Select selects the map at address for read access
+func (mapper *memoryMapper) Select(address uint16) (*bus.Map, uint16) {
+	block := address >> 14
+	if m := mapper.rom[block]; m != nil && m.IsActive() {
+		return m, address & 0x3fff
+	}
+	return mapper.ram[block], address & 0x3fff
+}
+
+// SelectWrite selects the map at address for write access
+func (mapper *memoryMapper) SelectWrite(address uint16) (*bus.Map, uint16) {
+	return mapper.ram[address>>14], address & 0x3fff
+}
+
+// Upper returns the map of the selected upper ROM
+func (mapper *memoryMapper) Upper() *bus.Map { return mapper.maps[mapper.upper] }
+
+// SelectUpper maps an upper ROM in the block 3. The ROM enable state of the
+// Gate Array is kept.
+func (mapper *memoryMapper) SelectUpper(rom int) {
+	active := mapper.Upper().IsActive()
+	mapper.upper = upperRomMap(rom)
+	mapper.Upper().SetActive(active)
+	mapper.rom[3] = mapper.Upper()
+}
+
+// update updates the mapping : RAM banks by block, lower ROM block and the
+// ASIC registers page at 0x4000.
+func (mapper *memoryMapper) update(config [cpcRAMBlocks]int, lowerBlock int, asicPage bool) {
+	for block := 0; block < cpcRAMBlocks; block++ {
+		mapper.ram[block] = mapper.maps[cpcRAMBanks[config[block]]]
+		mapper.rom[block] = nil
+	}
+	mapper.rom[lowerBlock] = mapper.maps[cpcLowerROM]
+	mapper.rom[3] = mapper.Upper()
+	if asicPage {
+		mapper.ram[1] = mapper.maps[cpcAsicPage]
+	}
+}
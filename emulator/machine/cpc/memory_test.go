@@ -0,0 +1,62 @@
+package cpc
+
+import "testing"
+
+// Gate Array & upper ROM select ports
+const (
+	testPortGateArray = 0x7f00
+	testPortUpperRom  = 0xdf00
+)
+
+// TestRAMConfig checks the 16K banks mapped by the RAM configurations C0-C7
+// of the 128K models. The 64K models ignore the configuration.
+func TestRAMConfig(t *testing.T) {
+	for _, model := range []int{AmstradCPC464, AmstradCPC6128} {
+		cpc := newTestCPC(model)
+		for bank, index := range cpcRAMBanks {
+			cpc.memory.Bank(index).Write(0, byte(bank))
+		}
+		cpc.Write(testPortGateArray, 0x8c) // ROMs disabled
+		for config, banks := range cpcRAMConfigs {
+			cpc.Write(testPortGateArray, 0xc0|byte(config))
+			if !cpc.hasRAM128() {
+				banks = cpcRAMConfigs[0]
+			}
+			for block, bank := range banks {
+				if data := cpc.memory.Peek(uint16(block) << 14); data != byte(bank) {
+					t.Errorf("model %d, C%d : block %d mapped to bank %d, expected %d", model, config, block, data, bank)
+				}
+			}
+		}
+	}
+}
+
+// TestSelectUpper checks the upper ROM selection and the Gate Array ROM
+// enable
+func TestSelectUpper(t *testing.T) {
+	cpc := newTestCPC(AmstradCPC6128)
+	for _, rom := range []int{0, 7} {
+		cpc.memory.Bank(upperRomMap(rom)).Load(0, []byte{0xb0 + byte(rom)})
+		cpc.upperRoms[rom] = true
+	}
+	cpc.memory.Bank(cpcRAMBanks[3]).Write(0, 0xaa)
+	tests := []struct {
+		name     string
+		ga, rom  byte
+		expected byte
+	}{
+		{"ROM 0", 0x84, 0, 0xb0},
+		{"ROM 7", 0x84, 7, 0xb7},
+		{"empty ROM 5", 0x84, 5, 0xb0},
+		{"ROM 7 disabled", 0x8c, 7, 0xaa},
+		{"ROM 0 disabled", 0x8c, 0, 0xaa},
+		{"ROM 0 enabled", 0x84, 0, 0xb0},
+	}
+	for _, test := range tests {
+		cpc.Write(testPortGateArray, test.ga)
+		cpc.Write(testPortUpperRom, test.rom)
+		if data := cpc.memory.Peek(0xc000); data != test.expected {
+			t.Errorf("%s : read %02x, expected %02x", test.name, data, test.expected)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package cpc
+
+import (
+	"github.com/jtruco/emu8/emulator/device/io/mouse"
+)
+
+// -----------------------------------------------------------------------------
+// Amstrad CPC - Mouse emulation
+// -----------------------------------------------------------------------------
+
+// AMX mouse on the joystick port. Motion is read as joystick direction
+// pulses, buttons as the joystick fire buttons.
+
+const cpcJoystickRow = 9 // Keyboard row of joystick 0
+
+// Mouse is the AMX mouse connected to the joystick port
+type Mouse struct {
+	dx, dy  int     // Pending motion
+	pulse   bool    // Direction pulse active
+	buttons [3]bool // Button states
+}
+
+// NewMouse creates a new AMX mouse
+func NewMouse() *Mouse {
+	return new(Mouse)
+}
+
+// Init initializes the device
+func (m *Mouse) Init() { m.Reset() }
+
+// Reset resets the device
+func (m *Mouse) Reset() {
+	m.dx, m.dy = 0, 0
+	m.pulse = false
+	m.buttons = [3]bool{}
+}
+
+// Move moves the mouse
+func (m *Mouse) Move(dx, dy int) {
+	m.dx += dx
+	m.dy += dy
+}
+
+// SetButton sets button state
+func (m *Mouse) SetButton(button byte, pressed bool) {
+	if int(button) < len(m.buttons) {
+		m.buttons[button] = pressed
+	}
+}
+
+// State gets the joystick row state. Every read toggles the direction
+// pulses, consuming one motion unit per axis when active.
+func (m *Mouse) State() byte {
+	result := byte(0xff)
+	if m.buttons[mouse.ButtonLeft] {
+		result &^= 1 << (CpcKeyJoyFire1 & 0x07)
+	}
+	if m.buttons[mouse.ButtonRight] {
+		result &^= 1 << (CpcKeyJoyFire2 & 0x07)
+	}
+	if m.buttons[mouse.ButtonMiddle] {
+		result &^= 1 << (CpcKeySpare & 0x07)
+	}
+	m.pulse = !m.pulse
+	if !m.pulse {
+		return result
+	}
+	switch {
+	case m.dx < 0:
+		result &^= 1 << (CpcKeyJoyLeft & 0x07)
+		m.dx++
+	case m.dx > 0:
+		result &^= 1 << (CpcKeyJoyRight & 0x07)
+		m.dx--
+	}
+	switch {
+	case m.dy < 0:
+		result &^= 1 << (CpcKeyJoyUp & 0x07)
+		m.dy++
+	case m.dy > 0:
+		result &^= 1 << (CpcKeyJoyDown & 0x07)
+		m.dy--
+	}
+	return result
+}
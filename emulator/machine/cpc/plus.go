@@ -0,0 +1,96 @@
+package cpc
+
+import (
+	"log"
+
+	"github.com/jtruco/emu8/emulator/config"
+	"github.com/jtruco/emu8/emulator/machine/cpc/format"
+)
+
+// -----------------------------------------------------------------------------
+// Amstrad CPC Plus - Cartridges
+// -----------------------------------------------------------------------------
+
+// initCartridge loads the cartridge and maps its first pages
+func (cpc *AmstradCPC) initCartridge() {
+	if cpc.cartridge == nil {
+		cpc.loadCartridge()
+	}
+	cpc.lowerPage = -1
+	cpc.upperRom = -1
+	cpc.selectCartridgeLower(0)
+	cpc.selectUpperRom(0)
+}
+
+// loadCartridge loads the cartridge option or the cartridge slot of the
+// ROM set
+func (cpc *AmstradCPC) loadCartridge() {
+	selection := config.Get().Machine.Option("cartridge")
+	if selection == "" {
+		selection = cpc.roms.Selection("cartridge")
+	}
+	data, err := cpc.roms.LoadImage(cpc.control, "cartridge", selection)
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+	if data == nil {
+		log.Println("CPC : No cartridge inserted")
+		return
+	}
+	if cpc.cartridge, err = format.LoadCPR(data); err != nil {
+		log.Println(err.Error())
+	}
+}
+
+// InsertCartridge inserts a CPR cartridge and resets the machine
+func (cpc *AmstradCPC) InsertCartridge(data []byte) error {
+	pages, err := format.LoadCPR(data)
+	if err != nil {
+		return err
+	}
+	cpc.cartridge = pages
+	cpc.Reset()
+	return nil
+}
+
+// selectCartridgeLower maps a cartridge page as lower ROM
+func (cpc *AmstradCPC) selectCartridgeLower(page int) {
+	if page == cpc.lowerPage {
+		return
+	}
+	cpc.lowerPage = page
+	cpc.loadCartridgePage(cpcLowerROM, page)
+}
+
+// selectCartridgeUpper maps a cartridge page as upper ROM. Bit 7 selects
+// any cartridge page, otherwise ROM 7 (AMSDOS) is page 3 and the rest are
+// page 1 (BASIC).
+func (cpc *AmstradCPC) selectCartridgeUpper(rom byte) {
+	page := 1
+	switch {
+	case rom&0x80 != 0:
+		page = int(rom & 0x1f)
+	case rom == 7:
+		page = 3
+	}
+	if page == cpc.upperRom {
+		return
+	}
+	cpc.upperRom = page
+	cpc.loadCartridgePage(cpcUpperROM, page)
+}
+
+// loadCartridgePage loads a cartridge page into a ROM bank. Missing pages
+// read as 0xff.
+func (cpc *AmstradCPC) loadCartridgePage(bank, page int) {
+	data := make([]byte, format.CprPageSize)
+	n := 0
+	if page < len(cpc.cartridge) {
+		n = copy(data, cpc.cartridge[page])
+	}
+	for i := n; i < len(data); i++ {
+		data[i] = 0xff
+	}
+	cpc.memory.Bank(bank).Load(0, data)
+}
@@ -90,7 +90,8 @@ func (ppi *Ppi) Write(port byte, data byte) {
 			ppi.cpc.keyboard.SetRow(data)
 		}
 		if (ppi.control & 0x08) == 0 { // upper nibble
-			// todo : tape
+			// tape motor
+			ppi.cpc.tape.SetMotor(ppi.portC&0x10 != 0)
 			// psg control
 			ppi.cpc.psg.SetControl(data)
 			ppi.cpc.psg.Write(ppi.portA)
@@ -113,7 +114,8 @@ func (ppi *Ppi) Write(port byte, data byte) {
 				ppi.cpc.keyboard.SetRow(ppi.portC)
 			}
 			if (ppi.control & 0x08) == 0 { // upper nibble
-				// TODO : tape control
+				// Tape motor
+				ppi.cpc.tape.SetMotor(ppi.portC&0x10 != 0)
 				// PSG control
 				ppi.cpc.psg.SetControl(ppi.portC)
 				ppi.cpc.psg.Write(ppi.portA)
@@ -0,0 +1,70 @@
+package cpc
+
+import "testing"
+
+// testCode is the address of the test instructions, in the RAM block 1
+const testCode = 0x4000
+
+// TestInstructionTiming runs single instructions and checks their length
+// in NOPs (1 µs), from the CPC instruction timing table
+func TestInstructionTiming(t *testing.T) {
+	tests := []struct {
+		name string
+		code []byte
+		b    byte
+		nops int
+	}{
+		{"NOP", []byte{0x00}, 0xff, 1},
+		{"LD A,(nn)", []byte{0x3a, 0x00, 0x80}, 0xff, 4},
+		{"PUSH BC", []byte{0xc5}, 0xff, 4},
+		{"EX (SP),HL", []byte{0xe3}, 0xff, 6},
+		{"OUT (n),A", []byte{0xd3, 0xff}, 0xff, 3},
+		{"IN A,(n)", []byte{0xdb, 0xff}, 0xff, 3},
+		{"OUT (C),A", []byte{0xed, 0x79}, 0xff, 4},
+		{"OUT (C),0", []byte{0xed, 0x71}, 0xff, 4},
+		{"IN A,(C)", []byte{0xed, 0x78}, 0xff, 4},
+		{"OUTI", []byte{0xed, 0xa3}, 0xff, 5},
+		{"INI", []byte{0xed, 0xa2}, 0xff, 5},
+		{"OTIR (repeat)", []byte{0xed, 0xb3}, 0xfe, 6},
+		{"INIR (repeat)", []byte{0xed, 0xb2}, 0x02, 6},
+		{"INIR (end)", []byte{0xed, 0xb2}, 0x01, 5},
+	}
+	for _, test := range tests {
+		cpc := newTestCPC(AmstradCPC464)
+		for i, data := range test.code {
+			cpc.memory.Bank(cpcRAMBanks[testCode>>14]).Write(uint16(i), data)
+		}
+		cpc.cpu.PC = testCode
+		cpc.cpu.SP = 0xc000
+		cpc.cpu.A, cpc.cpu.B, cpc.cpu.C = 0xff, test.b, 0xff
+		cpc.cpu.H, cpc.cpu.L = 0x80, 0x00
+		start := cpc.clock.Total()
+		cpc.cpu.Execute()
+		tstates := int(cpc.clock.Total() - start)
+		if nops := (tstates + 3) >> 2; nops != test.nops {
+			t.Errorf("%s : %d NOPs (%d tstates), expected %d", test.name, nops, tstates, test.nops)
+		}
+	}
+}
+
+// TestGateArrayWait checks the alignment of the bus accesses to the 1 MHz
+// clock and the CRTC characters emulated by the gate array
+func TestGateArrayWait(t *testing.T) {
+	cpc := newTestCPC(AmstradCPC464)
+	for _, step := range []struct{ tstates, wait int }{{1, 4}, {3, 4}, {4, 4}, {5, 8}} {
+		cpc.clock.SetTstates(step.tstates)
+		cpc.gatearray.Wait()
+		if cpc.clock.Tstates() != step.wait {
+			t.Errorf("Wait at %d : %d tstates, expected %d", step.tstates, cpc.clock.Tstates(), step.wait)
+		}
+	}
+	cpc = newTestCPC(AmstradCPC464)
+	start := cpc.crtc.CurrentCol()
+	for _, step := range []struct{ tstates, cols int }{{1, 1}, {3, 1}, {4, 2}, {8, 4}, {0, 4}} {
+		cpc.clock.Add(step.tstates)
+		cpc.gatearray.Sync()
+		if cols := int(cpc.crtc.CurrentCol() - start); cols != step.cols {
+			t.Errorf("Sync at %d : %d characters, expected %d", cpc.clock.Total(), cols, step.cols)
+		}
+	}
+}
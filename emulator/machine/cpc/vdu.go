@@ -18,6 +18,11 @@ const (
 	videoTotalWidth   = videoScreenWidth + videoHBorder*2
 	videoTotalHeight  = videoScreenHeight + videoVBorder*2
 	videoLineBytes    = 0x800 // 2 KBytes
+	videoFirstLine    = 40    // First visible line after VSync
+	videoMaxLines     = 352   // Lines of the monitor without VSync
+	videoHSyncChars   = 14    // Characters from HSync to the left edge
+	videoLineChars    = 54    // Characters to the monitor line end without HSync
+	videoCharWidth    = 16    // Character width in pixels
 )
 
 // CPC 464 RGBA colour palette (27 colors)
@@ -40,17 +45,14 @@ type VduVideo struct {
 	screen    *video.Screen
 	gatearray *GateArray
 	crtc      *video.MC6845
+	asic      *Asic
 	ram       [][]byte
-	palette   []int
+	colours   []uint32
 	mode      byte
 	paintByte func(int, int, byte) int
-	scanLine  uint16
-	maxSLine  uint16
-	minSLine  uint16
-	lineBytes uint16
-	firstX    int
-	page      byte
-	offset    uint16
+	scanLine  int // Monitor line since VSync
+	hpos      int // Monitor character since the left edge
+	modeDelay int // Mode change delay after HSync
 }
 
 // NewVduVideo creates a new vdu
@@ -60,11 +62,12 @@ func NewVduVideo(cpc *AmstradCPC) *VduVideo {
 	vdu.screen.SetScaleX(videoWidthScale)
 	vdu.gatearray = cpc.gatearray
 	vdu.crtc = cpc.crtc
+	vdu.asic = cpc.asic
+	vdu.colours = cpc.gatearray.Colours()
 	vdu.ram = make([][]byte, 4)
-	vdu.ram[0] = cpc.memory.Bank(1).Data()
-	vdu.ram[1] = cpc.memory.Bank(2).Data()
-	vdu.ram[2] = cpc.memory.Bank(3).Data()
-	vdu.ram[3] = cpc.memory.Bank(5).Data()
+	for i := range vdu.ram {
+		vdu.ram[i] = cpc.memory.Bank(cpcRAMBanks[i]).Data()
+	}
 	return vdu
 }
 
@@ -77,7 +80,10 @@ func (vdu *VduVideo) Init() { vdu.Reset() }
 // Reset resets video device
 func (vdu *VduVideo) Reset() {
 	vdu.screen.Clear(0)
-	vdu.updateCrtc()
+	vdu.scanLine = 0
+	vdu.hpos = 0
+	vdu.modeDelay = 0
+	vdu.updateMode()
 }
 
 // EndFrame updates screen video frame
@@ -100,80 +106,101 @@ func (vdu *VduVideo) updateMode() {
 	case 2: // 1 bpp
 		vdu.paintByte = vdu.paintByte2
 	}
-	// palette
-	vdu.palette = vdu.gatearray.palette
 }
 
-// updateCrtc update crtc screen options
-func (vdu *VduVideo) updateCrtc() {
-	// update gatearray
-	vdu.updateMode()
-	// scanline control
-	firstY := (uint16(vdu.crtc.VerticalTotal+1)*uint16(vdu.crtc.MaxScanlineAddress+1) - videoTotalHeight) / 2
-	firstY += 16 // vsync width
-	vdu.minSLine = firstY
-	vdu.maxSLine = firstY + videoTotalHeight - 1
+// OnVSync starts a new screen
+func (vdu *VduVideo) OnVSync() {
 	vdu.scanLine = 0
-	// screen raster
-	vdu.lineBytes = uint16(vdu.crtc.HorizontalDisplayed) << 1
-	vdu.firstX = (videoTotalWidth - int(vdu.lineBytes)<<3) >> 1
-	// memory offset
-	vdu.page = (vdu.crtc.StartAddressHigh >> 4) & 0x03
-	vdu.offset = (uint16(vdu.crtc.StartAddressHigh&0x03)<<8 | uint16(vdu.crtc.StartAddressLow)) << 1
 }
 
-// OnIntAck on interrupt ack
-func (vdu *VduVideo) OnIntAck() {
-	vdu.updateMode()
+// OnHSync starts a new scanline. Mode changes take effect 1 us after.
+func (vdu *VduVideo) OnHSync() {
+	vdu.newLine()
+	vdu.modeDelay = 1
 }
 
-// OnVSync init a new screen
-func (vdu *VduVideo) OnVSync() {
-	vdu.updateCrtc()
+// OnClock renders the current CRTC character at the beam position, so
+// palette, border and mode changes take effect in the middle of a line
+func (vdu *VduVideo) OnClock() {
+	y := vdu.scanLine - videoFirstLine
+	x := vdu.hpos * videoCharWidth
+	if y >= 0 && y < videoTotalHeight && x >= 0 && x < videoTotalWidth {
+		vdu.paintChar(x, y)
+	}
+	// mode change
+	if vdu.modeDelay > 0 {
+		vdu.modeDelay--
+		if vdu.modeDelay == 0 {
+			vdu.updateMode()
+		}
+	}
+	// monitor free run without syncs
+	vdu.hpos++
+	if vdu.hpos == videoLineChars {
+		vdu.newLine()
+	}
 }
 
-// OnHSync renders a scanline
-func (vdu *VduVideo) OnHSync() {
-
-	// scanline control
-	scanLine := vdu.scanLine
+// newLine moves the beam to the next line
+func (vdu *VduVideo) newLine() {
+	vdu.hpos = -videoHSyncChars
 	vdu.scanLine++
-	if scanLine < vdu.minSLine || scanLine > vdu.maxSLine {
-		return // vertical border paddings
+	if vdu.scanLine == videoMaxLines {
+		vdu.scanLine = 0
 	}
-	y := int(scanLine - vdu.minSLine)
+}
 
-	// vertical border scanlines
-	border := vdu.screen.GetColour(vdu.gatearray.Border())
-	if vdu.crtc.CurrentRow() >= vdu.crtc.VerticalDisplayed {
-		vdu.paintLine(y, 0, videoTotalWidth, border)
+// paintChar paints the current character, border or screen bytes. The
+// display is delayed by the CRTC skew.
+func (vdu *VduVideo) paintChar(x, y int) {
+	crtc := vdu.crtc
+	skew := crtc.Skew()
+	char := int(crtc.CurrentCol()) - int(skew)
+	if !crtc.VerticalDisplay() || skew == 3 || char < 0 || char >= int(crtc.HorizontalDisplayed) {
+		vdu.paintLine(y, x, videoCharWidth, vdu.colours[gaBorderPen])
 		return
 	}
+	if vdu.asic != nil {
+		vdu.paintPlusChar(x, y, char)
+		return
+	}
+	ma := (crtc.RowAddress() + uint16(char)) & 0x3fff
+	ram := vdu.ram[(ma>>12)&0x03]
+	addr := uint16(crtc.CurrentLine()&0x07)<<11 | (ma&0x03ff)<<1
+	x = vdu.paintByte(x, y, ram[addr])
+	vdu.paintByte(x, y, ram[addr+1])
+}
 
-	// render screen
-	defer vdu.updateMode()
-
-	// border left & right
-	vdu.paintLine(y, 0, vdu.firstX, border)
-	vdu.paintLine(y, videoTotalWidth-vdu.firstX, vdu.firstX, border)
-
-	// render screen rasterline
-	rasteraddr := uint16(vdu.crtc.CurrentLine()) << 11
-	offset := vdu.offset + vdu.lineBytes*uint16(vdu.crtc.CurrentRow())
-	x := vdu.firstX
-	for i := uint16(0); i < vdu.lineBytes; i++ {
-		offset &= 0x7ff
-		addr := rasteraddr | offset
-		x = vdu.paintByte(x, y, vdu.ram[vdu.page][addr])
-		offset++
+// paintPlusChar paints the current character with the CPC Plus soft
+// scroll, extended border and hardware sprites
+func (vdu *VduVideo) paintPlusChar(x, y, char int) {
+	crtc := vdu.crtc
+	border := vdu.colours[gaBorderPen]
+	line := int(crtc.CurrentRow())*(int(crtc.MaxScanlineAddress)+1) + int(crtc.CurrentLine())
+	if char == 0 && vdu.asic.IsBorderExtended() {
+		vdu.paintLine(y, x, videoCharWidth, border)
+	} else {
+		scroll := vdu.asic.HorizontalScroll()
+		raster := crtc.CurrentLine() + vdu.asic.VerticalScroll()
+		ma := (crtc.RowAddress() + uint16(char)) & 0x3fff
+		ram := vdu.ram[(ma>>12)&0x03]
+		addr := uint16(raster&0x07)<<11 | (ma&0x03ff)<<1
+		if char == 0 {
+			vdu.paintLine(y, x, scroll, border)
+		}
+		if sx := x + scroll; sx+videoCharWidth <= videoTotalWidth {
+			sx = vdu.paintByte(sx, y, ram[addr])
+			vdu.paintByte(sx, y, ram[addr+1])
+		}
 	}
+	vdu.asic.PaintSprites(vdu.screen, x, y, char*videoCharWidth, line)
 }
 
 // render functions
 
 // paintByte0 paints mode 0 screen byte
 func (vdu *VduVideo) paintByte0(x, y int, data byte) int {
-	colour := vdu.screen.Palette()[vdu.palette[cpcMode0[data][0]]]
+	colour := vdu.colours[cpcMode0[data][0]]
 	vdu.screen.SetPixel(x, y, colour)
 	x++
 	vdu.screen.SetPixel(x, y, colour)
@@ -182,7 +209,7 @@ func (vdu *VduVideo) paintByte0(x, y int, data byte) int {
 	x++
 	vdu.screen.SetPixel(x, y, colour)
 	x++
-	colour = vdu.screen.Palette()[vdu.palette[cpcMode0[data][1]]]
+	colour = vdu.colours[cpcMode0[data][1]]
 	vdu.screen.SetPixel(x, y, colour)
 	x++
 	vdu.screen.SetPixel(x, y, colour)
@@ -196,22 +223,22 @@ func (vdu *VduVideo) paintByte0(x, y int, data byte) int {
 
 // paintByte1 paints mode 1 screen byte
 func (vdu *VduVideo) paintByte1(x, y int, data byte) int {
-	colour := vdu.screen.Palette()[vdu.palette[cpcMode1[data][0]]]
+	colour := vdu.colours[cpcMode1[data][0]]
 	vdu.screen.SetPixel(x, y, colour)
 	x++
 	vdu.screen.SetPixel(x, y, colour)
 	x++
-	colour = vdu.screen.Palette()[vdu.palette[cpcMode1[data][1]]]
+	colour = vdu.colours[cpcMode1[data][1]]
 	vdu.screen.SetPixel(x, y, colour)
 	x++
 	vdu.screen.SetPixel(x, y, colour)
 	x++
-	colour = vdu.screen.Palette()[vdu.palette[cpcMode1[data][2]]]
+	colour = vdu.colours[cpcMode1[data][2]]
 	vdu.screen.SetPixel(x, y, colour)
 	x++
 	vdu.screen.SetPixel(x, y, colour)
 	x++
-	colour = vdu.screen.Palette()[vdu.palette[cpcMode1[data][3]]]
+	colour = vdu.colours[cpcMode1[data][3]]
 	vdu.screen.SetPixel(x, y, colour)
 	x++
 	vdu.screen.SetPixel(x, y, colour)
@@ -221,28 +248,28 @@ func (vdu *VduVideo) paintByte1(x, y int, data byte) int {
 
 // paintByte2 paints mode 2 screen byte
 func (vdu *VduVideo) paintByte2(x, y int, data byte) int {
-	colour := vdu.screen.Palette()[vdu.palette[cpcMode2[data][0]]]
+	colour := vdu.colours[cpcMode2[data][0]]
 	vdu.screen.SetPixel(x, y, colour)
 	x++
-	colour = vdu.screen.Palette()[vdu.palette[cpcMode2[data][1]]]
+	colour = vdu.colours[cpcMode2[data][1]]
 	vdu.screen.SetPixel(x, y, colour)
 	x++
-	colour = vdu.screen.Palette()[vdu.palette[cpcMode2[data][2]]]
+	colour = vdu.colours[cpcMode2[data][2]]
 	vdu.screen.SetPixel(x, y, colour)
 	x++
-	colour = vdu.screen.Palette()[vdu.palette[cpcMode2[data][3]]]
+	colour = vdu.colours[cpcMode2[data][3]]
 	vdu.screen.SetPixel(x, y, colour)
 	x++
-	colour = vdu.screen.Palette()[vdu.palette[cpcMode2[data][4]]]
+	colour = vdu.colours[cpcMode2[data][4]]
 	vdu.screen.SetPixel(x, y, colour)
 	x++
-	colour = vdu.screen.Palette()[vdu.palette[cpcMode2[data][5]]]
+	colour = vdu.colours[cpcMode2[data][5]]
 	vdu.screen.SetPixel(x, y, colour)
 	x++
-	colour = vdu.screen.Palette()[vdu.palette[cpcMode2[data][6]]]
+	colour = vdu.colours[cpcMode2[data][6]]
 	vdu.screen.SetPixel(x, y, colour)
 	x++
-	colour = vdu.screen.Palette()[vdu.palette[cpcMode2[data][7]]]
+	colour = vdu.colours[cpcMode2[data][7]]
 	vdu.screen.SetPixel(x, y, colour)
 	x++
 	return x
@@ -0,0 +1,526 @@
+package cpm
+
+import (
+	"log"
+)
+
+// -----------------------------------------------------------------------------
+// BDOS - Basic Disk Operating System
+// -----------------------------------------------------------------------------
+
+// BDOS functions (CP/M 2.2)
+const (
+	bdosSystemReset     = 0
+	bdosConsoleInput    = 1
+	bdosConsoleOutput   = 2
+	bdosReaderInput     = 3
+	bdosPunchOutput     = 4
+	bdosListOutput      = 5
+	bdosDirectConsole   = 6
+	bdosGetIOByte       = 7
+	bdosSetIOByte       = 8
+	bdosPrintString     = 9
+	bdosReadBuffer      = 10
+	bdosConsoleStatus   = 11
+	bdosVersion         = 12
+	bdosResetDisk       = 13
+	bdosSelectDisk      = 14
+	bdosOpenFile        = 15
+	bdosCloseFile       = 16
+	bdosSearchFirst     = 17
+	bdosSearchNext      = 18
+	bdosDeleteFile      = 19
+	bdosReadSequential  = 20
+	bdosWriteSequential = 21
+	bdosMakeFile        = 22
+	bdosRenameFile      = 23
+	bdosLoginVector     = 24
+	bdosCurrentDisk     = 25
+	bdosSetDMA          = 26
+	bdosAllocVector     = 27
+	bdosWriteProtect    = 28
+	bdosReadOnlyVector  = 29
+	bdosSetAttributes   = 30
+	bdosDiskParameters  = 31
+	bdosUserCode        = 32
+	bdosReadRandom      = 33
+	bdosWriteRandom     = 34
+	bdosFileSize        = 35
+	bdosSetRandom       = 36
+	bdosResetDrive      = 37
+	bdosWriteZeroFill   = 40
+)
+
+// BDOS constants
+const (
+	bdosVersion22   = 0x0022 // CP/M 2.2
+	bdosError       = 0xff   // Error return code
+	bdosEndOfData   = 0x01   // Reading unwritten data
+	bdosSeekError   = 0x06   // Seek past the end of the disk
+	bdosEndOfString = '$'    // Print string terminator
+	bdosDirEntry    = 0x20   // Directory entry size
+	bdosEmptyEntry  = 0xe5   // Empty directory entry
+)
+
+// File Control Block offsets
+const (
+	fcbDrive  = 0  // Drive code
+	fcbName   = 1  // File name and type
+	fcbExtent = 12 // Current extent (EX)
+	fcbS1     = 13 // Reserved
+	fcbS2     = 14 // Extent high bits
+	fcbRecCnt = 15 // Extent record count (RC)
+	fcbAlloc  = 16 // Allocation map
+	fcbRename = 17 // New name of rename
+	fcbRecord = 32 // Current record (CR)
+	fcbRandom = 33 // Random record (R0, R1, R2)
+)
+
+// Extent constants : 128 records of 16K per extent, 2K blocks
+const (
+	extentRecords = 0x80
+	extentMask    = 0x1f
+	extentHigh    = 0x3f
+	blockRecords  = 0x10
+)
+
+// BDOS is the CP/M BDOS emulated on the host. The console functions use
+// the machine console, and the file functions the drive host files.
+type BDOS struct {
+	cpm    *CPM     // The CP/M machine
+	dma    uint16   // DMA buffer address
+	disk   byte     // Current disk
+	user   byte     // Current user code
+	search []string // Search results
+}
+
+// NewBDOS creates the BDOS
+func NewBDOS(cpm *CPM) *BDOS {
+	bdos := new(BDOS)
+	bdos.cpm = cpm
+	return bdos
+}
+
+// Device interface
+
+// Init initializes the BDOS
+func (bdos *BDOS) Init() { bdos.Reset() }
+
+// Reset resets the BDOS
+func (bdos *BDOS) Reset() {
+	bdos.dma = cpmDMA
+	bdos.disk = 0
+	bdos.user = 0
+	bdos.search = nil
+}
+
+// BDOS call
+
+// Call executes the BDOS function in register C, with the parameter in DE.
+// Results are returned in A and HL, as B and HL. Returns false if the
+// function waits for console input.
+func (bdos *BDOS) Call() bool {
+	cpu := bdos.cpm.cpu
+	console := bdos.cpm.console
+	param := cpu.DE.Get()
+	result := uint16(0)
+
+	switch cpu.C {
+
+	case bdosSystemReset:
+		bdos.cpm.exit()
+
+	case bdosConsoleInput:
+		if !console.HasInput() {
+			return false
+		}
+		char := console.Read()
+		console.WriteEcho(char)
+		result = uint16(char)
+
+	case bdosConsoleOutput:
+		console.Write(cpu.E)
+
+	case bdosReaderInput:
+		result = cpmEndOfFile
+
+	case bdosPunchOutput, bdosListOutput:
+
+	case bdosDirectConsole:
+		switch cpu.E {
+		case 0xff: // input
+			if console.HasInput() {
+				result = uint16(console.Read())
+			}
+		case 0xfe: // status
+			if console.HasInput() {
+				result = bdosError
+			}
+		default:
+			console.Write(cpu.E)
+		}
+
+	case bdosGetIOByte:
+		result = uint16(bdos.cpm.memory.Read(cpmIOByte))
+
+	case bdosSetIOByte:
+		bdos.cpm.memory.Write(cpmIOByte, cpu.E)
+
+	case bdosPrintString:
+		for address := param; ; address++ {
+			char := bdos.cpm.memory.Read(address)
+			if char == bdosEndOfString {
+				break
+			}
+			console.Write(char)
+		}
+
+	case bdosReadBuffer:
+		if !bdos.readBuffer(param) {
+			return false
+		}
+
+	case bdosConsoleStatus:
+		if console.HasInput() {
+			result = bdosError
+		}
+
+	case bdosVersion:
+		result = bdosVersion22
+
+	case bdosResetDisk:
+		bdos.cpm.drive.Flush()
+		bdos.dma = cpmDMA
+		bdos.setDisk(0)
+
+	case bdosSelectDisk:
+		if cpu.E != 0 {
+			result = bdosError
+		} else {
+			bdos.setDisk(cpu.E)
+		}
+
+	case bdosOpenFile:
+		result = bdos.openFile(param)
+
+	case bdosCloseFile:
+		result = bdos.closeFile(param)
+
+	case bdosSearchFirst:
+		pattern := cpmAllFiles
+		if bdos.cpm.memory.Read(param+fcbDrive) != cpmWildcard {
+			pattern = bdos.readName(param, fcbName)
+		}
+		bdos.search = bdos.cpm.drive.Search(pattern)
+		result = bdos.searchNext()
+
+	case bdosSearchNext:
+		result = bdos.searchNext()
+
+	case bdosDeleteFile:
+		result = bdosError
+		if bdos.checkDisk(param) && bdos.cpm.drive.Delete(bdos.readName(param, fcbName)) {
+			result = 0
+		}
+
+	case bdosReadSequential:
+		result = bdos.readFile(param, bdos.readRecord(param), true)
+
+	case bdosWriteSequential:
+		result = bdos.writeFile(param, bdos.readRecord(param), true)
+
+	case bdosMakeFile:
+		result = bdos.makeFile(param)
+
+	case bdosRenameFile:
+		result = bdosError
+		name, newname := bdos.readName(param, fcbName), bdos.readName(param, fcbRename)
+		if bdos.checkDisk(param) && bdos.cpm.drive.Rename(name, newname) {
+			result = 0
+		}
+
+	case bdosLoginVector:
+		result = 0x0001 // drive A:
+
+	case bdosCurrentDisk:
+		result = uint16(bdos.disk)
+
+	case bdosSetDMA:
+		bdos.dma = param
+
+	case bdosAllocVector:
+		result = cpmALV
+
+	case bdosWriteProtect, bdosReadOnlyVector, bdosSetAttributes, bdosResetDrive:
+
+	case bdosDiskParameters:
+		result = cpmDPB
+
+	case bdosUserCode:
+		if cpu.E == 0xff {
+			result = uint16(bdos.user)
+		} else {
+			bdos.user = cpu.E & 0x0f
+			bdos.setDisk(bdos.disk)
+		}
+
+	case bdosReadRandom:
+		result = bdos.checkRandom(param)
+		if result == 0 {
+			result = bdos.readFile(param, bdos.readRandom(param), false)
+		}
+
+	case bdosWriteRandom, bdosWriteZeroFill:
+		result = bdos.checkRandom(param)
+		if result == 0 {
+			result = bdos.writeFile(param, bdos.readRandom(param), false)
+		}
+
+	case bdosFileSize:
+		result = bdosError
+		if size := bdos.cpm.drive.Size(bdos.readName(param, fcbName)); size >= 0 {
+			bdos.setRandom(param, size)
+			result = 0
+		}
+
+	case bdosSetRandom:
+		bdos.setRandom(param, bdos.readRecord(param))
+
+	default:
+		log.Printf("CP/M : Not implemented BDOS function: %d", cpu.C)
+		result = bdosError
+	}
+
+	cpu.HL.Set(result)
+	cpu.A, cpu.B = cpu.L, cpu.H
+	return true
+}
+
+// Console functions
+
+// readBuffer reads a console line into the buffer : maximum length, line
+// length and characters. Waits for a complete line.
+func (bdos *BDOS) readBuffer(buffer uint16) bool {
+	console := bdos.cpm.console
+	memory := bdos.cpm.memory
+	max := int(memory.Read(buffer))
+	if !console.HasLine() && console.Pending() < max {
+		return false
+	}
+	n := 0
+	for ; n < max; n++ {
+		char := console.Read()
+		if char == '\r' {
+			break
+		}
+		memory.Write(buffer+2+uint16(n), char)
+		console.WriteEcho(char)
+	}
+	memory.Write(buffer+1, byte(n))
+	console.WriteEcho('\r')
+	return true
+}
+
+// setDisk sets the current disk and user
+func (bdos *BDOS) setDisk(disk byte) {
+	bdos.disk = disk
+	bdos.cpm.memory.Write(cpmDisk, bdos.user<<4|disk)
+}
+
+// File functions
+
+// openFile opens a file, copying the file name and record count to the FCB
+func (bdos *BDOS) openFile(fcb uint16) uint16 {
+	if !bdos.checkDisk(fcb) {
+		return bdosError
+	}
+	file, name := bdos.cpm.drive.Open(bdos.readName(fcb, fcbName))
+	if file == nil {
+		return bdosError
+	}
+	memory := bdos.cpm.memory
+	for i := 0; i < cpmFileSize; i++ {
+		memory.Write(fcb+fcbName+uint16(i), name[i])
+	}
+	memory.Write(fcb+fcbS1, 0)
+	memory.Write(fcb+fcbS2, 0)
+	bdos.setRecordCount(fcb, file)
+	return 0
+}
+
+// closeFile saves and closes a file
+func (bdos *BDOS) closeFile(fcb uint16) uint16 {
+	if bdos.checkDisk(fcb) && bdos.cpm.drive.Close(bdos.readName(fcb, fcbName)) {
+		return 0
+	}
+	return bdosError
+}
+
+// makeFile creates a new empty file
+func (bdos *BDOS) makeFile(fcb uint16) uint16 {
+	if !bdos.checkDisk(fcb) {
+		return bdosError
+	}
+	file := bdos.cpm.drive.Make(bdos.readName(fcb, fcbName))
+	if file == nil {
+		return bdosError
+	}
+	memory := bdos.cpm.memory
+	memory.Write(fcb+fcbS1, 0)
+	memory.Write(fcb+fcbS2, 0)
+	bdos.setRecordCount(fcb, file)
+	return 0
+}
+
+// readFile reads a file record into the DMA buffer. Sequential reads
+// advance to the next record.
+func (bdos *BDOS) readFile(fcb uint16, record int, sequential bool) uint16 {
+	file := bdos.cpm.drive.File(bdos.readName(fcb, fcbName))
+	if file == nil {
+		return bdosError
+	}
+	var buffer [cpmRecord]byte
+	if !file.ReadRecord(record, buffer[:]) {
+		bdos.setRecord(fcb, record, file)
+		return bdosEndOfData
+	}
+	for i, data := range buffer {
+		bdos.cpm.memory.Write(bdos.dma+uint16(i), data)
+	}
+	if sequential {
+		record++
+	}
+	bdos.setRecord(fcb, record, file)
+	return 0
+}
+
+// writeFile writes the DMA buffer into a file record. Sequential writes
+// advance to the next record.
+func (bdos *BDOS) writeFile(fcb uint16, record int, sequential bool) uint16 {
+	file := bdos.cpm.drive.File(bdos.readName(fcb, fcbName))
+	if file == nil {
+		return bdosError
+	}
+	var buffer [cpmRecord]byte
+	for i := range buffer {
+		buffer[i] = bdos.cpm.memory.Read(bdos.dma + uint16(i))
+	}
+	file.WriteRecord(record, buffer[:])
+	if sequential {
+		record++
+	}
+	bdos.setRecord(fcb, record, file)
+	return 0
+}
+
+// searchNext writes the next search result as a directory entry at the
+// DMA buffer. Returns the entry index in the buffer.
+func (bdos *BDOS) searchNext() uint16 {
+	if len(bdos.search) == 0 {
+		return bdosError
+	}
+	name := bdos.search[0]
+	bdos.search = bdos.search[1:]
+	records := bdos.cpm.drive.Size(name)
+	extent, count := 0, 0
+	if records > 0 {
+		extent = (records - 1) / extentRecords
+		count = records - extent*extentRecords
+	}
+	var entry [cpmRecord]byte
+	for i := range entry {
+		entry[i] = bdosEmptyEntry
+	}
+	entry[0] = bdos.user
+	copy(entry[fcbName:], name)
+	entry[fcbExtent] = byte(extent & extentMask)
+	entry[fcbS1] = 0
+	entry[fcbS2] = byte(extent >> 5)
+	entry[fcbRecCnt] = byte(count)
+	for i := 0; i < 8; i++ { // 16 bit block numbers
+		block := 0
+		if i*blockRecords < count {
+			block = extent*8 + i + 1
+		}
+		entry[fcbAlloc+2*i] = byte(block)
+		entry[fcbAlloc+2*i+1] = byte(block >> 8)
+	}
+	for i, data := range entry {
+		bdos.cpm.memory.Write(bdos.dma+uint16(i), data)
+	}
+	return 0
+}
+
+// checkRandom checks the FCB random record
+func (bdos *BDOS) checkRandom(fcb uint16) uint16 {
+	if bdos.cpm.memory.Read(fcb+fcbRandom+2) != 0 {
+		return bdosSeekError
+	}
+	return 0
+}
+
+// FCB fields
+
+// checkDisk checks the FCB drive is the current drive A:
+func (bdos *BDOS) checkDisk(fcb uint16) bool {
+	drive := bdos.cpm.memory.Read(fcb + fcbDrive)
+	return drive == 0 || drive == bdos.disk+1
+}
+
+// readName reads a file name from the FCB, in upper case and without the
+// attribute bits
+func (bdos *BDOS) readName(fcb, offset uint16) string {
+	name := make([]byte, cpmFileSize)
+	for i := range name {
+		char := bdos.cpm.memory.Read(fcb+offset+uint16(i)) & 0x7f
+		if char >= 'a' && char <= 'z' {
+			char -= 'a' - 'A'
+		}
+		name[i] = char
+	}
+	return string(name)
+}
+
+// readRecord gets the current record : S2, EX and CR
+func (bdos *BDOS) readRecord(fcb uint16) int {
+	memory := bdos.cpm.memory
+	extent := int(memory.Read(fcb+fcbS2)&extentHigh)<<5 | int(memory.Read(fcb+fcbExtent)&extentMask)
+	return extent*extentRecords + int(memory.Read(fcb+fcbRecord))
+}
+
+// setRecord sets the current record and the extent record count
+func (bdos *BDOS) setRecord(fcb uint16, record int, file *DriveFile) {
+	memory := bdos.cpm.memory
+	extent := record / extentRecords
+	memory.Write(fcb+fcbRecord, byte(record%extentRecords))
+	memory.Write(fcb+fcbExtent, byte(extent&extentMask))
+	memory.Write(fcb+fcbS2, byte(extent>>5&extentHigh))
+	bdos.setRecordCount(fcb, file)
+}
+
+// setRecordCount sets the record count of the current extent
+func (bdos *BDOS) setRecordCount(fcb uint16, file *DriveFile) {
+	memory := bdos.cpm.memory
+	extent := int(memory.Read(fcb+fcbS2)&extentHigh)<<5 | int(memory.Read(fcb+fcbExtent)&extentMask)
+	count := file.Records() - extent*extentRecords
+	if count < 0 {
+		count = 0
+	} else if count > extentRecords {
+		count = extentRecords
+	}
+	memory.Write(fcb+fcbRecCnt, byte(count))
+}
+
+// readRandom gets the random record : R0, R1
+func (bdos *BDOS) readRandom(fcb uint16) int {
+	memory := bdos.cpm.memory
+	return int(memory.Read(fcb+fcbRandom)) | int(memory.Read(fcb+fcbRandom+1))<<8
+}
+
+// setRandom sets the random record
+func (bdos *BDOS) setRandom(fcb uint16, record int) {
+	memory := bdos.cpm.memory
+	memory.Write(fcb+fcbRandom, byte(record))
+	memory.Write(fcb+fcbRandom+1, byte(record>>8))
+	memory.Write(fcb+fcbRandom+2, byte(record>>16))
+}
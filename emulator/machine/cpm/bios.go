@@ -0,0 +1,68 @@
+package cpm
+
+import (
+	"log"
+)
+
+// -----------------------------------------------------------------------------
+// BIOS - Basic Input Output System
+// -----------------------------------------------------------------------------
+
+// BIOS jump table entries (CP/M 2.2)
+const (
+	biosBoot = iota
+	biosWarmBoot
+	biosConsoleStatus
+	biosConsoleInput
+	biosConsoleOutput
+	biosList
+	biosPunch
+	biosReader
+	biosHome
+	biosSelectDisk
+	biosSetTrack
+	biosSetSector
+	biosSetDMA
+	biosRead
+	biosWrite
+	biosListStatus
+	biosSectorTranslate
+	biosCalls
+)
+
+// biosCall executes a BIOS entry. The disk entries are not supported, as
+// the files are managed by the BDOS. Returns false if the entry waits for
+// console input.
+func (cpm *CPM) biosCall(entry int) bool {
+	cpu := cpm.cpu
+	switch entry {
+	case biosBoot, biosWarmBoot:
+		cpm.exit()
+	case biosConsoleStatus:
+		cpu.A = 0
+		if cpm.console.HasInput() {
+			cpu.A = bdosError
+		}
+	case biosConsoleInput:
+		if !cpm.console.HasInput() {
+			return false
+		}
+		cpu.A = cpm.console.Read()
+	case biosConsoleOutput:
+		cpm.console.Write(cpu.C)
+	case biosList, biosPunch, biosHome, biosSetTrack, biosSetSector, biosSetDMA:
+	case biosReader:
+		cpu.A = cpmEndOfFile
+	case biosSelectDisk:
+		cpu.HL.Set(0) // no disk header
+	case biosRead, biosWrite:
+		cpu.A = 1 // error
+	case biosListStatus:
+		cpu.A = bdosError // ready
+	case biosSectorTranslate:
+		cpu.HL.Set(cpu.BC.Get())
+	default:
+		log.Println("CP/M : Not implemented BIOS entry:", entry)
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package cpm
+
+import (
+	"bytes"
+	"io"
+)
+
+// -----------------------------------------------------------------------------
+// Console - CP/M terminal
+// -----------------------------------------------------------------------------
+
+// Console is the CP/M console device : the terminal input and output. The
+// host line ends are translated to carriage returns.
+type Console struct {
+	Output io.Writer // Terminal output
+	Echo   bool      // Echo the console input to the output
+	input  []byte    // Pending input characters
+}
+
+// NewConsole creates a new console
+func NewConsole() *Console {
+	console := new(Console)
+	console.input = make([]byte, 0, 0x100)
+	return console
+}
+
+// Device interface
+
+// Init initializes the console
+func (console *Console) Init() { console.Reset() }
+
+// Reset resets the console, discarding the pending input
+func (console *Console) Reset() { console.input = console.input[:0] }
+
+// Input
+
+// Input adds the terminal input characters
+func (console *Console) Input(data []byte) {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\r"))
+	for _, char := range data {
+		if char == '\n' {
+			char = '\r'
+		}
+		console.input = append(console.input, char)
+	}
+}
+
+// Pending is the number of pending input characters
+func (console *Console) Pending() int { return len(console.input) }
+
+// HasInput checks if there are pending input characters
+func (console *Console) HasInput() bool { return len(console.input) > 0 }
+
+// HasLine checks if there is a complete line of input
+func (console *Console) HasLine() bool { return bytes.IndexByte(console.input, '\r') >= 0 }
+
+// Read reads the next input character
+func (console *Console) Read() byte {
+	if len(console.input) == 0 {
+		return 0
+	}
+	char := console.input[0]
+	console.input = console.input[1:]
+	return char
+}
+
+// Output
+
+// Write writes a character to the terminal
+func (console *Console) Write(char byte) {
+	if console.Output != nil {
+		console.Output.Write([]byte{char})
+	}
+}
+
+// WriteEcho echoes an input character, if echo is active
+func (console *Console) WriteEcho(char byte) {
+	if console.Echo {
+		console.Write(char)
+	}
+}
@@ -0,0 +1,349 @@
+// Package cpm implements a CP/M machine, with a BDOS emulated on the host
+package cpm
+
+import (
+	"log"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator/device"
+	"github.com/jtruco/emu8/emulator/device/bus"
+	"github.com/jtruco/emu8/emulator/device/cpu"
+	"github.com/jtruco/emu8/emulator/device/cpu/z80"
+	"github.com/jtruco/emu8/emulator/device/memory"
+	"github.com/jtruco/emu8/emulator/machine"
+)
+
+// -----------------------------------------------------------------------------
+// CP/M
+// -----------------------------------------------------------------------------
+
+// CP/M models
+const (
+	CPMZ80 = iota
+	CPM8080
+)
+
+// Program format
+const (
+	COM = "com"
+)
+
+// Default CP/M constants
+const (
+	cpmFPS         = 50      // 50 Hz
+	cpmTStatesZ80  = 80000   // TStates per frame (4 MHz)
+	cpmTStates8080 = 40000   // TStates per frame (2 MHz)
+	cpmIdleTStates = 4       // TStates of an idle step
+	cpmMemoryBanks = 4       // 4 x 16K RAM banks
+	cpmCommandSize = 0x7f    // Command tail size
+	cpmTPATop      = cpmBDOS // TPA end address
+)
+
+// CP/M memory map : the page zero, the Transient Program Area and the
+// BDOS and BIOS entries.
+const (
+	cpmWarmBoot = 0x0000 // JP BIOS warm boot
+	cpmIOByte   = 0x0003 // IOBYTE
+	cpmDisk     = 0x0004 // Current user & disk
+	cpmEntry    = 0x0005 // JP BDOS
+	cpmFCB1     = 0x005c // Default File Control Block
+	cpmFCB2     = 0x006c // Second File Control Block
+	cpmDMA      = 0x0080 // Default DMA buffer & command tail
+	cpmTPA      = 0x0100 // Transient Program Area
+	cpmStack    = 0xfc00 // Program stack
+	cpmBDOS     = 0xfc06 // BDOS entry
+	cpmDPB      = 0xfc10 // Disk Parameter Block
+	cpmALV      = 0xfd00 // Allocation vector
+	cpmBIOS     = 0xfe00 // BIOS jump table
+)
+
+// Disk parameter block : 64 sectors per track, 2K blocks, 16K extents,
+// 4M disk and 512 directory entries
+var cpmDiskParameters = []byte{
+	0x40, 0x00, // SPT
+	0x04,       // BSH
+	0x0f,       // BLM
+	0x00,       // EXM
+	0xff, 0x07, // DSM
+	0xff, 0x01, // DRM
+	0xff, 0x00, // AL0, AL1
+	0x00, 0x00, // CKS
+	0x00, 0x00, // OFF
+}
+
+// CPM is a CP/M 2.2 computer : 64K of RAM, a Z80 or an Intel 8080 CPU and
+// the BDOS and BIOS emulated on the host. Programs are .COM files loaded in
+// the TPA, the drive A: files are the host drive files.
+type CPM struct {
+	config     machine.Config      // Machine information
+	control    machine.Control     // The emulator controller
+	components *device.Components  // Machine device components
+	clock      *device.ClockDevice // The system clock
+	cpu        *z80.Z80            // The Zilog Z80 or Intel 8080 CPU
+	memory     *memory.Memory      // The machine memory
+	console    *Console            // The console terminal
+	drive      *Drive              // The drive A:
+	bdos       *BDOS               // The BDOS
+	program    []byte              // The loaded program
+	command    string              // The program command tail
+	exited     bool                // The program has exited
+	waiting    bool                // Waiting for console input
+}
+
+// New returns a new CP/M machine
+func New(model int) machine.Machine {
+	cpm := new(CPM)
+	cpm.config.Model = model
+	if model == CPM8080 {
+		cpm.config.SetTimings(cpmTStates8080, cpmFPS)
+	} else {
+		cpm.config.SetTimings(cpmTStatesZ80, cpmFPS)
+	}
+	// memory map : 64K RAM
+	cpm.memory = memory.New(cpmMemoryBanks)
+	for i := 0; i < cpmMemoryBanks; i++ {
+		cpm.memory.SetMap(i, memory.NewRAM(uint16(i)<<14, memory.Size16K))
+	}
+	cpm.memory.SetMapper(bus.NewMaskMapper(14))
+	// devices
+	cpm.clock = device.NewClock()
+	cpm.cpu = z80.New(cpm.clock, cpm.memory, new(ioBus))
+	cpm.cpu.Mode8080 = model == CPM8080
+	cpm.console = NewConsole()
+	cpm.drive = NewDrive()
+	cpm.bdos = NewBDOS(cpm)
+	cpm.exited = true
+	// register all components
+	cpm.components = device.NewComponents()
+	cpm.components.Add(cpm.clock)
+	cpm.components.Add(cpm.memory)
+	cpm.components.Add(cpm.cpu)
+	cpm.components.Add(cpm.console)
+	cpm.components.Add(cpm.drive)
+	cpm.components.Add(cpm.bdos)
+	return cpm
+}
+
+// Device interface
+
+// Init initializes the machine
+func (cpm *CPM) Init() {
+	cpm.components.Init()
+	cpm.start()
+}
+
+// Reset resets the machine, restarting the program
+func (cpm *CPM) Reset() {
+	cpm.components.Reset()
+	cpm.start()
+}
+
+// Machine properties
+
+// Clock gets the machine clock
+func (cpm *CPM) Clock() device.Clock { return cpm.clock }
+
+// Config gets the machine info
+func (cpm *CPM) Config() *machine.Config { return &cpm.config }
+
+// CPU gets the machine CPU
+func (cpm *CPM) CPU() cpu.CPU { return cpm.cpu }
+
+// Components gets the machine components
+func (cpm *CPM) Components() *device.Components { return cpm.components }
+
+// Console gets the console terminal
+func (cpm *CPM) Console() *Console { return cpm.console }
+
+// Exited checks if the program has exited
+func (cpm *CPM) Exited() bool { return cpm.exited }
+
+// Waiting checks if the program waits for console input
+func (cpm *CPM) Waiting() bool { return cpm.waiting }
+
+// SetCommandLine sets the program command tail arguments
+func (cpm *CPM) SetCommandLine(command string) {
+	command = strings.ToUpper(strings.TrimSpace(command))
+	if command != "" {
+		command = " " + command
+	}
+	if len(command) > cpmCommandSize {
+		command = command[:cpmCommandSize]
+	}
+	cpm.command = command
+}
+
+// InitControl connect controllers & components
+func (cpm *CPM) InitControl(control machine.Control) {
+	// Host files
+	cpm.drive.SetFileSystem(control.FileSystem())
+	// Register formats
+	control.RegisterSnapshot(COM)
+	cpm.control = control
+}
+
+// Emulation control
+
+// BeginFrame begin emulation frame tasks
+func (cpm *CPM) BeginFrame() {}
+
+// Emulate one machine step
+func (cpm *CPM) Emulate() {
+	cpm.waiting = false
+	if cpm.exited {
+		cpm.clock.Add(cpmIdleTStates)
+		return
+	}
+	// BDOS & BIOS calls
+	pc := cpm.cpu.PC
+	switch {
+	case pc == cpmBDOS:
+		cpm.waiting = !cpm.bdos.Call()
+	case pc >= cpmBIOS && pc < cpmBIOS+3*biosCalls:
+		cpm.waiting = !cpm.biosCall(int(pc-cpmBIOS) / 3)
+	default:
+		cpm.cpu.Execute()
+		return
+	}
+	if cpm.waiting {
+		cpm.clock.Add(cpmIdleTStates)
+	} else if !cpm.exited {
+		cpm.cpu.Ret()
+	}
+}
+
+// EndFrame end emulation frame tasks
+func (cpm *CPM) EndFrame() {}
+
+// Program control
+
+// start starts the loaded program : builds the system area and loads the
+// program into the TPA, with its command tail and file control blocks.
+func (cpm *CPM) start() {
+	cpm.exited = true
+	cpm.waiting = false
+	if cpm.program == nil {
+		return
+	}
+	mem := cpm.memory
+	for address := 0; address < memory.Size64K; address++ {
+		mem.Write(uint16(address), 0)
+	}
+	// page zero, BDOS & BIOS entries
+	cpm.writeJump(cpmWarmBoot, cpmBIOS+3)
+	cpm.writeJump(cpmEntry, cpmBDOS)
+	mem.Write(cpmBDOS, 0xc9) // RET
+	for i := 0; i < biosCalls; i++ {
+		mem.Write(cpmBIOS+uint16(3*i), 0xc9)
+	}
+	for i, data := range cpmDiskParameters {
+		mem.Write(cpmDPB+uint16(i), data)
+	}
+	cpm.bdos.Reset()
+	cpm.bdos.setDisk(0)
+	// command tail & file control blocks
+	mem.Write(cpmDMA, byte(len(cpm.command)))
+	for i := 0; i < len(cpm.command); i++ {
+		mem.Write(cpmDMA+1+uint16(i), cpm.command[i])
+	}
+	args := strings.Fields(cpm.command)
+	for i, fcb := range []uint16{cpmFCB1, cpmFCB2} {
+		arg := ""
+		if i < len(args) {
+			arg = args[i]
+		}
+		cpm.writeFCB(fcb, arg)
+	}
+	// program
+	for i := 0; i < len(cpm.program) && cpmTPA+i < cpmTPATop; i++ {
+		mem.Write(cpmTPA+uint16(i), cpm.program[i])
+	}
+	cpm.cpu.PC = cpmTPA
+	cpm.cpu.SP = cpmStack - 2 // return to warm boot
+	cpm.exited = false
+}
+
+// exit ends the program, saving the drive files
+func (cpm *CPM) exit() {
+	cpm.drive.Flush()
+	cpm.exited = true
+	log.Println("CP/M : Program exit")
+}
+
+// writeJump writes a jump instruction
+func (cpm *CPM) writeJump(address, target uint16) {
+	cpm.memory.Write(address, 0xc3) // JP
+	cpm.memory.Write(address+1, byte(target))
+	cpm.memory.Write(address+2, byte(target>>8))
+}
+
+// writeFCB writes a file name argument into the first 16 bytes of a file
+// control block, as the second block overlaps the first one
+func (cpm *CPM) writeFCB(fcb uint16, arg string) {
+	drive := byte(0)
+	if len(arg) > 1 && arg[1] == ':' {
+		drive = arg[0] - 'A' + 1
+		arg = arg[2:]
+	}
+	name, typ := arg, ""
+	if dot := strings.IndexByte(arg, '.'); dot >= 0 {
+		name, typ = arg[:dot], arg[dot+1:]
+	}
+	cpm.memory.Write(fcb, drive)
+	cpm.writeNamePart(fcb+fcbName, name, cpmNameSize)
+	cpm.writeNamePart(fcb+fcbName+cpmNameSize, typ, cpmTypeSize)
+	for i := uint16(fcbExtent); i < fcbAlloc; i++ {
+		cpm.memory.Write(fcb+i, 0)
+	}
+}
+
+// writeNamePart writes a name part, padded with spaces. The '*' wildcard
+// fills the rest of the part.
+func (cpm *CPM) writeNamePart(address uint16, part string, size int) {
+	fill := byte(' ')
+	for i := 0; i < size; i++ {
+		char := fill
+		if i < len(part) && fill == ' ' {
+			char = part[i]
+			if char == '*' {
+				fill, char = cpmWildcard, cpmWildcard
+			}
+		}
+		cpm.memory.Write(address+uint16(i), char)
+	}
+}
+
+// Snapshots : load & save state
+
+// LoadState loads a .COM program and starts it
+func (cpm *CPM) LoadState(state machine.State) {
+	if state.Format != COM {
+		log.Println("CP/M : Not implemented program format:", state.Format)
+		return
+	}
+	cpm.program = state.Data
+	cpm.components.Reset()
+	cpm.start()
+}
+
+// SaveState saves the TPA as a .COM program
+func (cpm *CPM) SaveState() machine.State {
+	data := make([]byte, cpmTPATop-cpmTPA)
+	for i := range data {
+		data[i] = cpm.memory.Read(cpmTPA + uint16(i))
+	}
+	return machine.State{Format: COM, Data: data}
+}
+
+// -----------------------------------------------------------------------------
+// CP/M - IO bus
+// -----------------------------------------------------------------------------
+
+// ioBus is the empty CP/M IO bus
+type ioBus struct{}
+
+// Read reads an IO port
+func (io *ioBus) Read(port uint16) byte { return 0xff }
+
+// Write writes an IO port
+func (io *ioBus) Write(port uint16, data byte) {}
@@ -0,0 +1,302 @@
+package cpm
+
+import (
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator/controller/vfs"
+)
+
+// -----------------------------------------------------------------------------
+// Drive - CP/M drive on host files
+// -----------------------------------------------------------------------------
+
+// CP/M file names : 8 characters name and 3 characters type, padded with
+// spaces. The '?' character matches any character.
+const (
+	cpmNameSize  = 8
+	cpmTypeSize  = 3
+	cpmFileSize  = cpmNameSize + cpmTypeSize
+	cpmWildcard  = '?'
+	cpmBadChars  = "<>.,;:=?*[]|"
+	cpmAllFiles  = "???????????"
+	cpmRecord    = 0x80 // Record size
+	cpmEndOfFile = 0x1a // Text end of file
+)
+
+// DriveFile is a CP/M file, loaded from the host drive
+type DriveFile struct {
+	info  *vfs.FileInfo // Host file information
+	data  []byte        // File data
+	dirty bool          // File data changed
+}
+
+// Records gets the number of records of the file
+func (file *DriveFile) Records() int {
+	return (len(file.data) + cpmRecord - 1) / cpmRecord
+}
+
+// ReadRecord reads a record into buffer, padding with the end of file
+// character. Returns false at the end of the file.
+func (file *DriveFile) ReadRecord(record int, buffer []byte) bool {
+	offset := record * cpmRecord
+	if offset >= len(file.data) {
+		return false
+	}
+	n := copy(buffer, file.data[offset:])
+	for ; n < len(buffer); n++ {
+		buffer[n] = cpmEndOfFile
+	}
+	return true
+}
+
+// WriteRecord writes a record from buffer, extending the file with zeroes
+func (file *DriveFile) WriteRecord(record int, buffer []byte) {
+	end := (record + 1) * cpmRecord
+	if end > len(file.data) {
+		file.data = append(file.data, make([]byte, end-len(file.data))...)
+	}
+	copy(file.data[record*cpmRecord:end], buffer)
+	file.dirty = true
+}
+
+// Drive is the CP/M drive A:, mapped to the host drive files of the
+// virtual file system. Files are loaded on open and saved on close.
+type Drive struct {
+	fs    vfs.FileSystem        // The virtual file system
+	files map[string]*DriveFile // Open files by CP/M name
+}
+
+// NewDrive creates a new drive
+func NewDrive() *Drive {
+	drive := new(Drive)
+	drive.fs = vfs.GetFileSystem()
+	drive.files = make(map[string]*DriveFile)
+	return drive
+}
+
+// SetFileSystem sets the virtual file system
+func (drive *Drive) SetFileSystem(fs vfs.FileSystem) { drive.fs = fs }
+
+// Device interface
+
+// Init initializes the drive
+func (drive *Drive) Init() { drive.Reset() }
+
+// Reset resets the drive, saving and closing the files
+func (drive *Drive) Reset() {
+	drive.Flush()
+	drive.files = make(map[string]*DriveFile)
+}
+
+// File operations
+
+// Open opens the first file matching the CP/M name. Returns the file
+// and its name.
+func (drive *Drive) Open(pattern string) (*DriveFile, string) {
+	for _, name := range drive.Search(pattern) {
+		if file := drive.files[name]; file != nil {
+			return file, name
+		}
+		info := drive.find(name)
+		if info == nil {
+			continue
+		}
+		info.IsZip = false
+		if err := drive.fs.LoadFile(info); err != nil {
+			log.Println("CP/M : Error loading file:", info.Name)
+			return nil, ""
+		}
+		file := &DriveFile{info: info, data: info.Data}
+		drive.files[name] = file
+		return file, name
+	}
+	return nil, ""
+}
+
+// File gets an open file, or opens it
+func (drive *Drive) File(name string) *DriveFile {
+	if file := drive.files[name]; file != nil {
+		return file
+	}
+	file, _ := drive.Open(name)
+	return file
+}
+
+// Make creates a new empty file
+func (drive *Drive) Make(name string) *DriveFile {
+	info := drive.find(name)
+	if info == nil {
+		info = vfs.NewFileInfo(hostName(name))
+		info.Format = vfs.FormatDrive
+	}
+	file := &DriveFile{info: info, data: make([]byte, 0), dirty: true}
+	drive.files[name] = file
+	if !drive.save(file) {
+		delete(drive.files, name)
+		return nil
+	}
+	return file
+}
+
+// Close saves and closes a file
+func (drive *Drive) Close(name string) bool {
+	file := drive.files[name]
+	if file == nil {
+		return drive.find(name) != nil
+	}
+	delete(drive.files, name)
+	return drive.save(file)
+}
+
+// Flush saves the changed files
+func (drive *Drive) Flush() {
+	for _, file := range drive.files {
+		drive.save(file)
+	}
+}
+
+// Delete deletes the files matching the CP/M name
+func (drive *Drive) Delete(pattern string) bool {
+	dfs, ok := drive.fs.(vfs.DirFileSystem)
+	if !ok {
+		return false
+	}
+	deleted := false
+	for _, name := range drive.Search(pattern) {
+		delete(drive.files, name)
+		if info := drive.find(name); info != nil {
+			deleted = dfs.RemoveFile(info) == nil || deleted
+		}
+	}
+	return deleted
+}
+
+// Rename renames a file
+func (drive *Drive) Rename(name, newname string) bool {
+	dfs, ok := drive.fs.(vfs.DirFileSystem)
+	info := drive.find(name)
+	if !ok || info == nil {
+		return false
+	}
+	if file := drive.files[name]; file != nil {
+		drive.save(file)
+		delete(drive.files, name)
+	}
+	return dfs.RenameFile(info, hostName(newname)) == nil
+}
+
+// Search gets the sorted CP/M names of the files matching a name
+func (drive *Drive) Search(pattern string) []string {
+	names := make([]string, 0)
+	for _, info := range drive.list() {
+		if name, ok := cpmName(info.Name); ok && matchName(pattern, name) {
+			names = append(names, name)
+		}
+	}
+	for name := range drive.files {
+		if matchName(pattern, name) && !containsName(names, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Size gets the records of a file, -1 if not found
+func (drive *Drive) Size(name string) int {
+	if file := drive.File(name); file != nil {
+		return file.Records()
+	}
+	return -1
+}
+
+// list lists the host drive files
+func (drive *Drive) list() []*vfs.FileInfo {
+	if dfs, ok := drive.fs.(vfs.DirFileSystem); ok {
+		files, err := dfs.ReadDir(vfs.FormatDrive)
+		if err == nil {
+			return files
+		}
+	}
+	return nil
+}
+
+// find finds the host file of a CP/M name
+func (drive *Drive) find(name string) *vfs.FileInfo {
+	for _, info := range drive.list() {
+		if host, ok := cpmName(info.Name); ok && host == name {
+			return info
+		}
+	}
+	return nil
+}
+
+// save saves the file data, if changed
+func (drive *Drive) save(file *DriveFile) bool {
+	if !file.dirty {
+		return true
+	}
+	file.info.Data = file.data
+	if err := drive.fs.SaveFile(file.info); err != nil {
+		log.Println("CP/M : Error saving file:", file.info.Name)
+		return false
+	}
+	file.dirty = false
+	return true
+}
+
+// CP/M names
+
+// cpmName gets the CP/M name of a host file name
+func cpmName(filename string) (string, bool) {
+	filename = strings.ToUpper(filename)
+	name, typ := filename, ""
+	if dot := strings.LastIndexByte(filename, '.'); dot >= 0 {
+		name, typ = filename[:dot], filename[dot+1:]
+	}
+	if name == "" || len(name) > cpmNameSize || len(typ) > cpmTypeSize {
+		return "", false
+	}
+	for _, char := range name + typ {
+		if char <= ' ' || char >= 0x7f || strings.ContainsRune(cpmBadChars, char) {
+			return "", false
+		}
+	}
+	return padName(name, cpmNameSize) + padName(typ, cpmTypeSize), true
+}
+
+// hostName gets the host file name of a CP/M name, in lower case
+func hostName(name string) string {
+	host := strings.TrimRight(name[:cpmNameSize], " ")
+	if typ := strings.TrimRight(name[cpmNameSize:], " "); typ != "" {
+		host += "." + typ
+	}
+	return strings.ToLower(host)
+}
+
+// matchName matches a CP/M name with a name pattern
+func matchName(pattern, name string) bool {
+	for i := 0; i < cpmFileSize; i++ {
+		if pattern[i] != cpmWildcard && pattern[i] != name[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// padName pads a name part with spaces
+func padName(name string, size int) string {
+	return name + strings.Repeat(" ", size-len(name))
+}
+
+// containsName checks if a name is in the names list
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,15 @@
+package cpm
+
+import "github.com/jtruco/emu8/emulator/machine"
+
+// CP/M models. The CP/M machine has no ROMs, the BDOS runs on the host.
+var models = []machine.Model{
+	{Name: "CP/M", Ids: []string{"CPM", "CPMZ80"},
+		Build: func() machine.Machine { return New(CPMZ80) }},
+	{Name: "CP/M 8080", Ids: []string{"CPM8080"},
+		Build: func() machine.Machine { return New(CPM8080) }},
+}
+
+func init() {
+	machine.RegisterModels(models)
+}
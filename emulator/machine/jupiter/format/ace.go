@@ -0,0 +1,161 @@
+// Package format implements the Jupiter Ace file formats
+package format
+
+import (
+	"log"
+
+	"github.com/jtruco/emu8/emulator/device/cpu/z80"
+)
+
+// -----------------------------------------------------------------------------
+// ACE snapshot format
+// -----------------------------------------------------------------------------
+
+// ACE format extension
+const ACE = "ace"
+
+// ACE format constants
+const (
+	AceAddress   = 0x2000 // Memory image start address
+	aceImageSize = 0xe000 // Maximum memory image (0x2000 to 0xffff)
+	aceRegisters = 0x0100 // Registers offset (0x2100), in the video RAM mirror
+	aceRamTop    = 0x0081 // RAM top offset (0x2081)
+	aceMarker    = 0xed   // Run length marker
+)
+
+// Snapshot is a Jupiter Ace snapshot : the CPU state and the memory image
+// from 0x2000
+type Snapshot struct {
+	z80.State                    // Z80 state
+	Memory    [aceImageSize]byte // Memory image
+	Size      int                // Memory image size
+}
+
+// NewSnapshot returns a new Jupiter Ace snap
+func NewSnapshot() *Snapshot {
+	snap := new(Snapshot)
+	snap.State.Init()
+	return snap
+}
+
+// LoadACE loads snap from ACE data format. The memory image is run length
+// encoded : ED nn bb repeats nn times byte bb, ED 00 ends the image.
+func LoadACE(data []byte) *Snapshot {
+	snap := NewSnapshot()
+	length := len(data)
+	for pos := 0; pos < length && snap.Size < aceImageSize; {
+		if data[pos] != aceMarker {
+			snap.Memory[snap.Size] = data[pos]
+			snap.Size++
+			pos++
+			continue
+		}
+		if pos+1 >= length {
+			break
+		}
+		count := int(data[pos+1])
+		if count == 0 { // end marker
+			break
+		}
+		if pos+2 >= length {
+			log.Println("ACE : Invalid file format")
+			return nil
+		}
+		for ; count > 0 && snap.Size < aceImageSize; count-- {
+			snap.Memory[snap.Size] = data[pos+2]
+			snap.Size++
+		}
+		pos += 3
+	}
+	if snap.Size <= aceRegisters+0x44 {
+		log.Println("ACE : Invalid file format")
+		return nil
+	}
+	// CPU registers, 32 bit LSB words
+	regs := snap.Memory[aceRegisters:]
+	snap.F, snap.A = regs[0x00], regs[0x01]
+	snap.C, snap.B = regs[0x04], regs[0x05]
+	snap.E, snap.D = regs[0x08], regs[0x09]
+	snap.L, snap.H = regs[0x0c], regs[0x0d]
+	snap.IXl, snap.IXh = regs[0x10], regs[0x11]
+	snap.IYl, snap.IYh = regs[0x14], regs[0x15]
+	snap.SP = readWord(regs, 0x18)
+	snap.PC = readWord(regs, 0x1c)
+	snap.Fx, snap.Ax = regs[0x20], regs[0x21]
+	snap.Cx, snap.Bx = regs[0x24], regs[0x25]
+	snap.Ex, snap.Dx = regs[0x28], regs[0x29]
+	snap.Lx, snap.Hx = regs[0x2c], regs[0x2d]
+	snap.IM = regs[0x30] & 0x03
+	snap.IFF1 = regs[0x34] != 0
+	snap.IFF2 = regs[0x38] != 0
+	snap.I = regs[0x3c]
+	snap.R = regs[0x40]
+	return snap
+}
+
+// SaveACE saves snap to ACE data format
+func (snap *Snapshot) SaveACE() []byte {
+	image := make([]byte, snap.Size)
+	copy(image, snap.Memory[:snap.Size])
+	// RAM top & CPU registers
+	writeWord(image, aceRamTop, uint16(AceAddress+snap.Size))
+	regs := image[aceRegisters:]
+	regs[0x00], regs[0x01] = snap.F, snap.A
+	regs[0x04], regs[0x05] = snap.C, snap.B
+	regs[0x08], regs[0x09] = snap.E, snap.D
+	regs[0x0c], regs[0x0d] = snap.L, snap.H
+	regs[0x10], regs[0x11] = snap.IXl, snap.IXh
+	regs[0x14], regs[0x15] = snap.IYl, snap.IYh
+	writeWord(regs, 0x18, snap.SP)
+	writeWord(regs, 0x1c, snap.PC)
+	regs[0x20], regs[0x21] = snap.Fx, snap.Ax
+	regs[0x24], regs[0x25] = snap.Cx, snap.Bx
+	regs[0x28], regs[0x29] = snap.Ex, snap.Dx
+	regs[0x2c], regs[0x2d] = snap.Lx, snap.Hx
+	regs[0x30] = snap.IM
+	regs[0x34] = boolByte(snap.IFF1)
+	regs[0x38] = boolByte(snap.IFF2)
+	regs[0x3c] = snap.I
+	regs[0x40] = snap.R
+	// run length encoding
+	data := make([]byte, 0, len(image))
+	for pos := 0; pos < len(image); {
+		value := image[pos]
+		count := 1
+		for pos+count < len(image) && image[pos+count] == value && count < 0xff {
+			count++
+		}
+		if count > 4 || value == aceMarker {
+			data = append(data, aceMarker, byte(count), value)
+		} else {
+			for i := 0; i < count; i++ {
+				data = append(data, value)
+			}
+		}
+		pos += count
+	}
+	return append(data, aceMarker, 0x00)
+}
+
+// -----------------------------------------------------------------------------
+// Format common functions
+// -----------------------------------------------------------------------------
+
+// readWord reads a 16 bit LSB unsgined integer
+func readWord(data []byte, pos int) uint16 {
+	return uint16(data[pos]) | (uint16(data[pos+1]) << 8)
+}
+
+// writeWord writes a 16 bit LSB unsgined integer
+func writeWord(data []byte, pos int, value uint16) {
+	data[pos] = byte(value)
+	data[pos+1] = byte(value >> 8)
+}
+
+// boolByte returns 1 if value is true, 0 otherwise
+func boolByte(value bool) byte {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,259 @@
+package format
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator/device/io/tape"
+)
+
+// -----------------------------------------------------------------------------
+// Jupiter Ace TAP tape format
+// -----------------------------------------------------------------------------
+
+// TAP format extension
+const TAP = "tap"
+
+// Jupiter Ace TAP blocks are stored without the flag byte, the header block
+// is 26 bytes long (25 bytes and checksum). The flag byte is played before
+// the block data : 0x00 for headers and 0xff for data blocks.
+const (
+	tapHeaderLength = 26
+	tapFlagHeader   = 0x00
+	tapFlagData     = 0xff
+)
+
+// Tape file types
+const (
+	TapeFileDictionary = 0x00
+	TapeFileBytes      = 0x20
+)
+
+// Tape play states
+const (
+	tapeStateStart = iota
+	tapeStatePilot
+	tapeStateSync
+	tapeStateByte
+	tapeStateBit1
+	tapeStateBit2
+	tapeStatePause
+	tapeStatePauseStop
+	tapeStateStop
+)
+
+// Tape tstate constants (3.25 MHz)
+const (
+	tapeTimingPilot  = 2011
+	tapeTimingSync1  = 601
+	tapeTimingSync2  = 791
+	tapeTimingZero   = 795
+	tapeTimingOne    = 1585
+	tapeHeaderPulses = 8192
+	tapeDataPulses   = 1024
+	tapeTimingEoB    = 3250000 / 1000
+)
+
+// TapBlock is a Jupiter Ace tape block
+type TapBlock struct {
+	tape.BlockInfo
+	data   []byte // Flag, data & checksum
+	header bool   // Header block
+}
+
+// Info gets block information
+func (block *TapBlock) Info() *tape.BlockInfo { return &block.BlockInfo }
+
+// Data gets block data bytes
+func (block *TapBlock) Data() []byte { return block.data }
+
+// Meta gets the decoded block description
+func (block *TapBlock) Meta() *tape.BlockMeta {
+	meta := new(tape.BlockMeta)
+	if block.header {
+		header := new(tape.Header)
+		header.Type = block.data[1]
+		switch header.Type {
+		case TapeFileDictionary:
+			header.TypeName = "Dict"
+		case TapeFileBytes:
+			header.TypeName = "Bytes"
+		default:
+			header.TypeName = "Unknown"
+		}
+		header.Name = readString(block.data, 2, 10)
+		header.Length = int(readWord(block.data, 12))
+		header.Param1 = int(readWord(block.data, 14))
+		meta.Header = header
+		meta.Description = "Header"
+	} else {
+		meta.Description = fmt.Sprintf("Data: %d bytes", len(block.data)-2)
+	}
+	meta.Timings = &tape.Timings{
+		Pilot:       tapeTimingPilot,
+		PilotPulses: tapeDataPulses,
+		Sync1:       tapeTimingSync1,
+		Sync2:       tapeTimingSync2,
+		Zero:        tapeTimingZero,
+		One:         tapeTimingOne,
+		LastBits:    8,
+		Pause:       1}
+	if block.header {
+		meta.Timings.PilotPulses = tapeHeaderPulses
+	}
+	return meta
+}
+
+// Tap implements the Jupiter Ace tape format .TAP
+type Tap struct {
+	info        tape.Info    // Tape information
+	blocks      []tape.Block // Block array
+	pilotPulses int          // Pilot pulses
+	bitMask     byte         // Current bit mask
+	bitTime     int          // Current bit time
+}
+
+// NewTap creates a new tape
+func NewTap() tape.Tape {
+	tap := new(Tap)
+	tap.blocks = make([]tape.Block, 0, 2)
+	return tap
+}
+
+// Info gets tape information
+func (tap *Tap) Info() *tape.Info { return &tap.info }
+
+// Blocks gets the tape blocks
+func (tap *Tap) Blocks() []tape.Block { return tap.blocks }
+
+// Load loads the tape file data
+func (tap *Tap) Load(data []byte) bool {
+	tapeLength := len(data)
+	if tapeLength == 0 {
+		log.Print("Tape (TAP) : Invalid format: 0-length data")
+		return false
+	}
+	index := 0
+	for offset := 0; offset+2 <= tapeLength; {
+		length := int(readWord(data, offset))
+		offset += 2
+		if length == 0 || offset+length > tapeLength {
+			log.Print("Tape (TAP) : Invalid format: block length")
+			return false
+		}
+		block := new(TapBlock)
+		block.header = length == tapHeaderLength
+		flag := byte(tapFlagData)
+		if block.header {
+			flag = tapFlagHeader
+		}
+		block.Type = flag
+		block.Index = index
+		block.Offset = offset
+		block.data = append([]byte{flag}, data[offset:offset+length]...)
+		block.Length = len(block.data)
+		tap.blocks = append(tap.blocks, block)
+		offset += length
+		index++
+	}
+	return len(tap.blocks) > 0
+}
+
+// Play tap
+func (tap *Tap) Play(control *tape.Control) {
+	switch control.State {
+
+	case tapeStateStart:
+		control.Block = tap.blocks[control.BlockIndex]
+		control.BlockPos = 0
+		block := control.Block.(*TapBlock)
+		if block.header {
+			tap.pilotPulses = tapeHeaderPulses
+			log.Println("Tape (TAP) : Header block:", readString(block.data, 2, 10))
+		} else {
+			tap.pilotPulses = tapeDataPulses
+			log.Println("Tape (TAP) : Data block:", block.Length, "bytes")
+		}
+		control.Ear = tape.LevelLow
+		control.Timeout = 0
+		control.State = tapeStatePilot
+
+	case tapeStatePilot:
+		control.Ear ^= tape.LevelMask
+		tap.pilotPulses--
+		if tap.pilotPulses > 0 {
+			control.Timeout = tapeTimingPilot
+		} else {
+			control.Timeout = tapeTimingSync1
+			control.State = tapeStateSync
+		}
+
+	case tapeStateSync:
+		control.Ear ^= tape.LevelMask
+		control.Timeout = tapeTimingSync2
+		control.State = tapeStateByte
+
+	case tapeStateByte:
+		tap.bitMask = 0x80
+		control.State = tapeStateBit1
+
+	case tapeStateBit1:
+		control.Ear ^= tape.LevelMask
+		if (control.DataAtPos() & tap.bitMask) == 0 {
+			tap.bitTime = tapeTimingZero
+		} else {
+			tap.bitTime = tapeTimingOne
+		}
+		control.Timeout = tap.bitTime
+		control.State = tapeStateBit2
+
+	case tapeStateBit2:
+		control.Ear ^= tape.LevelMask
+		control.Timeout = tap.bitTime
+		tap.bitMask >>= 1
+		if tap.bitMask == 0 {
+			control.BlockPos++
+			if !control.EndOfBlock() {
+				control.State = tapeStateByte
+			} else {
+				control.State = tapeStatePause
+			}
+		} else {
+			control.State = tapeStateBit1
+		}
+
+	case tapeStatePause:
+		control.Ear ^= tape.LevelMask
+		control.Timeout = tapeTimingEoB
+		control.State = tapeStatePauseStop
+
+	case tapeStatePauseStop:
+		control.BlockIndex++
+		if control.EndOfTape() {
+			control.State = tapeStateStop
+		} else {
+			control.State = tapeStateStart
+		}
+
+	case tapeStateStop:
+		control.Playing = false // Stop
+
+	default:
+		control.State = tapeStateStop
+	}
+}
+
+// readString reads the printable characters to string
+func readString(data []byte, pos int, len int) string {
+	bytes := make([]byte, len)
+	for i := 0; i < len; i++ {
+		char := data[pos+i]
+		if char > 31 && char < 128 {
+			bytes[i] = char
+		} else {
+			bytes[i] = '?'
+		}
+	}
+	return strings.TrimRight(string(bytes), " ")
+}
@@ -0,0 +1,23 @@
+package jupiter
+
+import "github.com/jtruco/emu8/emulator/machine"
+
+// Jupiter Ace models
+var models = []machine.Model{
+	{Name: "Jupiter Ace", Ids: []string{"JupiterAce", "Ace"},
+		Build: func() machine.Machine { return New(JupiterAce) }, Roms: aceRomSet},
+}
+
+// Jupiter Ace ROM set : the 8K ROM, the two 4K ROM chips in one file
+var aceRomSet = &machine.RomSet{
+	Slots: []machine.RomSlot{
+		{Name: "rom", Size: 0x2000, Default: "jupiterace"},
+	},
+	Images: []machine.RomImage{
+		{ID: "jupiterace", File: "jupiterace.rom", CRC32: 0xe5b1f5f6},
+	},
+}
+
+func init() {
+	machine.RegisterModels(models)
+}
@@ -0,0 +1,268 @@
+// Package jupiter implements the Jupiter Ace machine
+package jupiter
+
+import (
+	"log"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator/config"
+	"github.com/jtruco/emu8/emulator/device"
+	"github.com/jtruco/emu8/emulator/device/audio"
+	"github.com/jtruco/emu8/emulator/device/bus"
+	"github.com/jtruco/emu8/emulator/device/cpu"
+	"github.com/jtruco/emu8/emulator/device/cpu/z80"
+	"github.com/jtruco/emu8/emulator/device/io/tape"
+	"github.com/jtruco/emu8/emulator/device/memory"
+	"github.com/jtruco/emu8/emulator/machine"
+	"github.com/jtruco/emu8/emulator/machine/jupiter/format"
+)
+
+// -----------------------------------------------------------------------------
+// Jupiter Ace
+// -----------------------------------------------------------------------------
+
+// Jupiter Ace models
+const (
+	JupiterAce = iota
+)
+
+// Default Jupiter Ace constants
+const (
+	aceFPS         = 50                  // 50 Hz
+	aceTStates     = 312 * tvLineTstates // TStates per frame (3.25 MHz)
+	aceIntTstates  = 32                  // Interrupt length
+	aceRAMAddress  = 0x4000              // Expansion RAM address
+	aceVideoMirror = 0x0400              // Video RAM mirror size at 0x2000
+	aceMemoryROM   = 0                   // ROM memory map
+	aceMemoryVideo = 1                   // Video RAM map
+	aceMemoryChars = 2                   // Character RAM map
+	aceMemoryRAM   = 3                   // Internal RAM map
+	aceMemoryPack  = 4                   // Expansion RAM pack map
+)
+
+// Ace is the Jupiter Ace
+type Ace struct {
+	config     machine.Config      // Machine information
+	control    machine.Control     // The emulator controller
+	components *device.Components  // Machine device components
+	clock      *device.ClockDevice // The system clock
+	cpu        *z80.Z80            // The Zilog Z80A CPU
+	memory     *memory.Memory      // The machine memory
+	ula        *ULA                // The Ace ULA
+	tv         *TvVideo            // The TV video output
+	beeper     *audio.Beeper       // The speaker
+	keyboard   *Keyboard           // The keyboard
+	tape       *tape.Drive         // The tape drive
+	ramTop     int                 // RAM top address
+	aceInt     bool                // Interrupt request
+}
+
+// New returns a new Jupiter Ace
+func New(model int) machine.Machine {
+	ace := new(Ace)
+	ace.config.Model = model
+	ace.config.SetTimings(aceTStates, aceFPS)
+	// memory map : ROM, video RAM, character RAM, internal RAM & RAM pack
+	packSize := uint16(memory.Size16K)
+	switch ram := strings.ToLower(config.Get().Machine.Option("ram")); ram {
+	case "", "19k":
+	case "3k":
+		packSize = 0
+	case "35k":
+		packSize = memory.Size32K
+	case "51k":
+		packSize = memory.Size32K + memory.Size16K
+	default:
+		log.Println("Jupiter : Unknown RAM size:", ram)
+	}
+	ace.ramTop = aceRAMAddress + int(packSize)
+	banks := aceMemoryPack
+	if packSize > 0 {
+		banks++
+	}
+	ace.memory = memory.New(banks)
+	ace.memory.SetMap(aceMemoryROM, memory.NewROM(0x0000, memory.Size8K))
+	ace.memory.SetMap(aceMemoryVideo, memory.NewRAM(0x2000, memory.Size1K))
+	ace.memory.SetMap(aceMemoryChars, memory.NewRAM(0x2800, memory.Size1K))
+	ace.memory.SetMap(aceMemoryRAM, memory.NewRAM(0x3000, memory.Size1K))
+	if packSize > 0 {
+		ace.memory.SetMap(aceMemoryPack, memory.NewRAM(aceRAMAddress, packSize))
+	}
+	ace.memory.SetMapper(new(memoryMapper))
+	// devices
+	ace.clock = device.NewClock()
+	ace.ula = NewULA(ace)
+	ace.cpu = z80.New(ace.clock, ace.memory, ace.ula)
+	ace.tv = NewTVVideo(ace)
+	ace.beeper = audio.NewBeeper(
+		audio.NewConfig(config.Get().Audio.Frequency, aceFPS, aceTStates))
+	ace.beeper.SetMap(aceBeeperMap)
+	ace.keyboard = NewKeyboard()
+	ace.tape = tape.New(ace.clock)
+	ace.tape.SetAutoPlay(config.Get().Tape.AutoStart)
+	// register all components
+	ace.components = device.NewComponents()
+	ace.components.Add(ace.clock)
+	ace.components.Add(ace.memory)
+	ace.components.Add(ace.ula)
+	ace.components.Add(ace.cpu)
+	ace.components.Add(ace.tv)
+	ace.components.Add(ace.beeper)
+	ace.components.Add(ace.keyboard)
+	ace.components.Add(ace.tape)
+	return ace
+}
+
+// Device interface
+
+// Init initializes the machine
+func (ace *Ace) Init() {
+	ace.components.Init()
+	ace.initAce()
+}
+
+// Reset resets the machine
+func (ace *Ace) Reset() {
+	ace.components.Reset()
+	ace.initAce()
+}
+
+// initAce common init tasks
+func (ace *Ace) initAce() {
+	data, err := aceRomSet.Load(ace.control, "rom")
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+	ace.memory.Bank(aceMemoryROM).Load(0, data)
+}
+
+// Machine properties
+
+// Clock gets the machine clock
+func (ace *Ace) Clock() device.Clock { return ace.clock }
+
+// Config gets the machine info
+func (ace *Ace) Config() *machine.Config { return &ace.config }
+
+// CPU gets the machine CPU
+func (ace *Ace) CPU() cpu.CPU { return ace.cpu }
+
+// Components gets the machine components
+func (ace *Ace) Components() *device.Components { return ace.components }
+
+// InitControl connect controllers & components
+func (ace *Ace) InitControl(control machine.Control) {
+	// Bind devices
+	control.BindVideo(ace.tv)
+	control.BindAudio(ace.beeper)
+	control.BindKeyboard(ace.keyboard)
+	control.BindTapeDrive(ace.tape)
+	// Register formats
+	control.RegisterSnapshot(format.ACE)
+	control.RegisterTape(format.TAP, format.NewTap)
+	ace.control = control
+}
+
+// Emulation control
+
+// BeginFrame begin emulation frame tasks
+func (ace *Ace) BeginFrame() {
+	// Vertical sync interrupt request
+	ace.aceInt = true
+	ace.cpu.InterruptRequest(true)
+}
+
+// Emulate one machine step
+func (ace *Ace) Emulate() {
+	// Executes a CPU instruction
+	tstates := ace.cpu.Execute()
+
+	// Maskable interrupt request length
+	if ace.aceInt && ace.clock.Tstates() >= aceIntTstates {
+		ace.aceInt = false
+		ace.cpu.InterruptRequest(false)
+	}
+
+	// TV beam
+	ace.tv.Update()
+
+	// Tape emulation
+	ace.tape.Emulate(tstates)
+}
+
+// EndFrame end emulation frame tasks
+func (ace *Ace) EndFrame() {} // nothing to do
+
+// Snapshots : load & save state
+
+// LoadState loads a Jupiter Ace snapshot
+func (ace *Ace) LoadState(state machine.State) {
+	if state.Format != format.ACE {
+		log.Println("Jupiter : Not implemented snap format:", state.Format)
+		return
+	}
+	snap := format.LoadACE(state.Data)
+	if snap == nil {
+		return
+	}
+	ace.cpu.State.Copy(&snap.State)
+	ace.clock.SetTstates(0)
+	// the video RAM mirror at 0x2000 holds the registers
+	for i := aceVideoMirror; i < snap.Size && format.AceAddress+i < ace.ramTop; i++ {
+		ace.memory.Poke(format.AceAddress+uint16(i), snap.Memory[i])
+	}
+}
+
+// SaveState saves a Jupiter Ace snapshot
+func (ace *Ace) SaveState() machine.State {
+	snap := format.NewSnapshot()
+	snap.State.Copy(&ace.cpu.State)
+	snap.Size = ace.ramTop - format.AceAddress
+	for i := 0; i < snap.Size; i++ {
+		snap.Memory[i] = ace.memory.Peek(format.AceAddress + uint16(i))
+	}
+	return machine.State{Format: format.ACE, Data: snap.SaveACE()}
+}
+
+// -----------------------------------------------------------------------------
+// Jupiter Ace - Memory mapping
+// -----------------------------------------------------------------------------
+
+// memoryMapper maps the Jupiter Ace memory. The 1K video, character and
+// internal RAMs are mirrored up to 0x27ff, 0x2fff and 0x3fff.
+type memoryMapper struct {
+	maps bus.Maps // Memory maps
+	pack *bus.Map // Expansion RAM pack map
+}
+
+// Init inits the mapper
+func (mapper *memoryMapper) Init(maps bus.Maps) {
+	mapper.maps = maps
+	if len(maps) > aceMemoryPack {
+		mapper.pack = maps[aceMemoryPack]
+	}
+}
+
+// Select selects the map at address for read access
+func (mapper *memoryMapper) Select(address uint16) (*bus.Map, uint16) {
+	switch {
+	case address < 0x2000:
+		return mapper.maps[aceMemoryROM], address
+	case address < 0x2800:
+		return mapper.maps[aceMemoryVideo], address & 0x03ff
+	case address < 0x3000:
+		return mapper.maps[aceMemoryChars], address & 0x03ff
+	case address < aceRAMAddress:
+		return mapper.maps[aceMemoryRAM], address & 0x03ff
+	}
+	if mapper.pack != nil && address-aceRAMAddress < mapper.pack.Size() {
+		return mapper.pack, address - aceRAMAddress
+	}
+	return nil, 0
+}
+
+// SelectWrite selects the map at address for write access
+func (mapper *memoryMapper) SelectWrite(address uint16) (*bus.Map, uint16) {
+	return mapper.Select(address)
+}
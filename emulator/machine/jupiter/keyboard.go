@@ -0,0 +1,284 @@
+package jupiter
+
+import (
+	"github.com/jtruco/emu8/emulator/device/io/keyboard"
+)
+
+// -----------------------------------------------------------------------------
+// Jupiter Ace Keyboard
+// -----------------------------------------------------------------------------
+
+// Keyboard is the Jupiter Ace keyboard : 40 keys in a 8x5 matrix
+type Keyboard struct {
+	rowstates [8]byte // The keyboard row states
+}
+
+// NewKeyboard creates a new keyboard
+func NewKeyboard() *Keyboard {
+	return new(Keyboard)
+}
+
+// Device
+
+// Init initializes the keyboard
+func (keyboard *Keyboard) Init() {
+	for row := 0; row < 8; row++ {
+		keyboard.rowstates[row] = 0xff
+	}
+}
+
+// Reset resets the keyboard
+func (keyboard *Keyboard) Reset() { keyboard.Init() }
+
+// Keyboard
+
+// KeyMap returns the default keyboard mapping
+func (keyboard *Keyboard) KeyMap() keyboard.KeyMap { return aceKeyboardMap }
+
+// KeyNames returns the keys by name
+func (keyboard *Keyboard) KeyNames() map[string]keyboard.Key { return aceKeyNames }
+
+// ProcessKey processes the keyboard events
+func (keyboard *Keyboard) ProcessKey(key keyboard.Key, pressed bool) {
+	state, ok := keyStates[key]
+	if ok {
+		if pressed {
+			keyboard.rowstates[state[0]] &= ^(state[1])
+		} else {
+			keyboard.rowstates[state[0]] |= state[1]
+		}
+	}
+}
+
+// GetState gets keyboard state at scan address
+func (keyboard *Keyboard) GetState(scan byte) byte {
+	var result byte = 0xff
+	mask := byte(1)
+	for row := 0; row < 8; row++ {
+		if (scan & mask) != 0 { // scan rows
+			result &= keyboard.rowstates[row]
+		}
+		mask <<= 1
+	}
+	return result
+}
+
+// -----------------------------------------------------------------------------
+// Jupiter Ace Keys, States & Mapping
+// -----------------------------------------------------------------------------
+
+// Jupiter Ace Keys
+const (
+	AceKey1 = iota
+	AceKey2
+	AceKey3
+	AceKey4
+	AceKey5
+	AceKey6
+	AceKey7
+	AceKey8
+	AceKey9
+	AceKey0
+
+	AceKeyQ
+	AceKeyW
+	AceKeyE
+	AceKeyR
+	AceKeyT
+	AceKeyY
+	AceKeyU
+	AceKeyI
+	AceKeyO
+	AceKeyP
+
+	AceKeyA
+	AceKeyS
+	AceKeyD
+	AceKeyF
+	AceKeyG
+	AceKeyH
+	AceKeyJ
+	AceKeyK
+	AceKeyL
+	AceKeyEnter
+
+	AceKeyShift
+	AceKeySymbolShift
+	AceKeyZ
+	AceKeyX
+	AceKeyC
+	AceKeyV
+	AceKeyB
+	AceKeyN
+	AceKeyM
+	AceKeySpace
+)
+
+// aceKeyNames Jupiter Ace keys by name
+var aceKeyNames = map[string]keyboard.Key{
+	"1":           AceKey1,
+	"2":           AceKey2,
+	"3":           AceKey3,
+	"4":           AceKey4,
+	"5":           AceKey5,
+	"6":           AceKey6,
+	"7":           AceKey7,
+	"8":           AceKey8,
+	"9":           AceKey9,
+	"0":           AceKey0,
+	"Q":           AceKeyQ,
+	"W":           AceKeyW,
+	"E":           AceKeyE,
+	"R":           AceKeyR,
+	"T":           AceKeyT,
+	"Y":           AceKeyY,
+	"U":           AceKeyU,
+	"I":           AceKeyI,
+	"O":           AceKeyO,
+	"P":           AceKeyP,
+	"A":           AceKeyA,
+	"S":           AceKeyS,
+	"D":           AceKeyD,
+	"F":           AceKeyF,
+	"G":           AceKeyG,
+	"H":           AceKeyH,
+	"J":           AceKeyJ,
+	"K":           AceKeyK,
+	"L":           AceKeyL,
+	"Enter":       AceKeyEnter,
+	"Shift":       AceKeyShift,
+	"SymbolShift": AceKeySymbolShift,
+	"Z":           AceKeyZ,
+	"X":           AceKeyX,
+	"C":           AceKeyC,
+	"V":           AceKeyV,
+	"B":           AceKeyB,
+	"N":           AceKeyN,
+	"M":           AceKeyM,
+	"Space":       AceKeySpace,
+}
+
+// keyStates Jupiter Ace Keyboard states
+var keyStates = map[keyboard.Key][2]byte{
+	AceKey1: {3, 0x01},
+	AceKey2: {3, 0x02},
+	AceKey3: {3, 0x04},
+	AceKey4: {3, 0x08},
+	AceKey5: {3, 0x10},
+	AceKey6: {4, 0x10},
+	AceKey7: {4, 0x08},
+	AceKey8: {4, 0x04},
+	AceKey9: {4, 0x02},
+	AceKey0: {4, 0x01},
+
+	AceKeyQ: {2, 0x01},
+	AceKeyW: {2, 0x02},
+	AceKeyE: {2, 0x04},
+	AceKeyR: {2, 0x08},
+	AceKeyT: {2, 0x10},
+	AceKeyY: {5, 0x10},
+	AceKeyU: {5, 0x08},
+	AceKeyI: {5, 0x04},
+	AceKeyO: {5, 0x02},
+	AceKeyP: {5, 0x01},
+
+	AceKeyA:     {1, 0x01},
+	AceKeyS:     {1, 0x02},
+	AceKeyD:     {1, 0x04},
+	AceKeyF:     {1, 0x08},
+	AceKeyG:     {1, 0x10},
+	AceKeyH:     {6, 0x10},
+	AceKeyJ:     {6, 0x08},
+	AceKeyK:     {6, 0x04},
+	AceKeyL:     {6, 0x02},
+	AceKeyEnter: {6, 0x01},
+
+	AceKeyShift:       {0, 0x01},
+	AceKeySymbolShift: {0, 0x02},
+	AceKeyZ:           {0, 0x04},
+	AceKeyX:           {0, 0x08},
+	AceKeyC:           {0, 0x10},
+	AceKeyV:           {7, 0x10},
+	AceKeyB:           {7, 0x08},
+	AceKeyN:           {7, 0x04},
+	AceKeyM:           {7, 0x02},
+	AceKeySpace:       {7, 0x01},
+}
+
+// Jupiter Ace Keyboard map
+var aceKeyboardMap = map[keyboard.KeyCode][]keyboard.Key{
+	// standar mapping
+	keyboard.Key0: {AceKey0},
+	keyboard.Key1: {AceKey1},
+	keyboard.Key2: {AceKey2},
+	keyboard.Key3: {AceKey3},
+	keyboard.Key4: {AceKey4},
+	keyboard.Key5: {AceKey5},
+	keyboard.Key6: {AceKey6},
+	keyboard.Key7: {AceKey7},
+	keyboard.Key8: {AceKey8},
+	keyboard.Key9: {AceKey9},
+
+	keyboard.KeyA: {AceKeyA},
+	keyboard.KeyB: {AceKeyB},
+	keyboard.KeyC: {AceKeyC},
+	keyboard.KeyD: {AceKeyD},
+	keyboard.KeyE: {AceKeyE},
+	keyboard.KeyF: {AceKeyF},
+	keyboard.KeyG: {AceKeyG},
+	keyboard.KeyH: {AceKeyH},
+	keyboard.KeyI: {AceKeyI},
+	keyboard.KeyJ: {AceKeyJ},
+	keyboard.KeyK: {AceKeyK},
+	keyboard.KeyL: {AceKeyL},
+	keyboard.KeyM: {AceKeyM},
+	keyboard.KeyN: {AceKeyN},
+	keyboard.KeyO: {AceKeyO},
+	keyboard.KeyP: {AceKeyP},
+	keyboard.KeyQ: {AceKeyQ},
+	keyboard.KeyR: {AceKeyR},
+	keyboard.KeyS: {AceKeyS},
+	keyboard.KeyT: {AceKeyT},
+	keyboard.KeyU: {AceKeyU},
+	keyboard.KeyV: {AceKeyV},
+	keyboard.KeyW: {AceKeyW},
+	keyboard.KeyX: {AceKeyX},
+	keyboard.KeyY: {AceKeyY},
+	keyboard.KeyZ: {AceKeyZ},
+
+	keyboard.KeyReturn: {AceKeyEnter},
+	keyboard.KeySpace:  {AceKeySpace},
+	keyboard.KeyLShift: {AceKeyShift},
+	keyboard.KeyRShift: {AceKeyShift},
+	keyboard.KeyLCtrl:  {AceKeySymbolShift},
+	keyboard.KeyRCtrl:  {AceKeySymbolShift},
+	keyboard.KeyPeriod: {AceKeySymbolShift, AceKeyM},
+	keyboard.KeyComma:  {AceKeySymbolShift, AceKeyN},
+
+	// cursors
+	keyboard.KeyLeft:  {AceKeyShift, AceKey5},
+	keyboard.KeyDown:  {AceKeyShift, AceKey6},
+	keyboard.KeyUp:    {AceKeyShift, AceKey7},
+	keyboard.KeyRight: {AceKeyShift, AceKey8},
+
+	// keypad
+	keyboard.KeyPad1:        {AceKey1},
+	keyboard.KeyPad2:        {AceKey2},
+	keyboard.KeyPad3:        {AceKey3},
+	keyboard.KeyPad4:        {AceKey4},
+	keyboard.KeyPad5:        {AceKey5},
+	keyboard.KeyPad6:        {AceKey6},
+	keyboard.KeyPad7:        {AceKey7},
+	keyboard.KeyPad8:        {AceKey8},
+	keyboard.KeyPad9:        {AceKey9},
+	keyboard.KeyPad0:        {AceKey0},
+	keyboard.KeyPadMultiply: {AceKeySymbolShift, AceKeyB},
+	keyboard.KeyPadDivide:   {AceKeySymbolShift, AceKeyV},
+	keyboard.KeyPadPlus:     {AceKeySymbolShift, AceKeyK},
+	keyboard.KeyPadMinus:    {AceKeySymbolShift, AceKeyJ},
+	keyboard.KeyPadEnter:    {AceKeyEnter},
+
+	// other keyboard maps
+	keyboard.KeyBackspace: {AceKeyShift, AceKey0},     // DELETE
+	keyboard.KeyEscape:    {AceKeyShift, AceKeySpace}, // BREAK
+}
@@ -0,0 +1,86 @@
+package jupiter
+
+// -----------------------------------------------------------------------------
+// ULA constants & vars
+// -----------------------------------------------------------------------------
+
+// ULA constants
+const (
+	ulaIORead  = 0xff // Default IO read value
+	ulaTapeIn  = 0x20 // Keyboard port bit 5 : tape input
+	ulaKeyMask = 0x1f // Keyboard port bits 0 to 4 : keys
+)
+
+// Audio
+
+// Speaker + Tape
+const (
+	amplRate    = 7 // uint16
+	amplSpeaker = 48 << amplRate
+	amplTape    = 2 << amplRate
+)
+
+var aceBeeperMap = []uint16{0, amplTape, amplSpeaker, (amplSpeaker + amplTape)}
+
+// -----------------------------------------------------------------------------
+// ULA
+// -----------------------------------------------------------------------------
+
+// ULA is the Jupiter Ace video and IO logic. The keyboard port is decoded
+// with A0 reset : reading the port moves the speaker diaphragm in, writing
+// the port moves it out.
+type ULA struct {
+	ace     *Ace // The Jupiter Ace machine
+	speaker int  // Speaker level
+}
+
+// NewULA creates the ULA
+func NewULA(ace *Ace) *ULA {
+	ula := new(ULA)
+	ula.ace = ace
+	return ula
+}
+
+// Device
+
+// Init initializes the ULA
+func (ula *ULA) Init() { ula.Reset() }
+
+// Reset resets the ULA
+func (ula *ULA) Reset() { ula.speaker = 0 }
+
+// DataBus
+
+// Read reads the keyboard port
+func (ula *ULA) Read(address uint16) byte {
+	ula.ace.clock.Add(4)
+	result := byte(ulaIORead)
+	if address&0x0001 == 0 {
+		scan := byte(address>>8) ^ 0xff
+		result = ula.ace.keyboard.GetState(scan)&ulaKeyMask | ^byte(ulaKeyMask)
+		ula.ace.tape.EarRead()
+		if !ula.ace.tape.IsPlaying() || !ula.ace.tape.EarHigh() {
+			result &^= ulaTapeIn
+		}
+		ula.setSpeaker(0)
+	}
+	return result
+}
+
+// Write writes the keyboard port
+func (ula *ULA) Write(address uint16, data byte) {
+	ula.ace.clock.Add(4)
+	if address&0x0001 == 0 {
+		ula.setSpeaker(1)
+	}
+}
+
+// setSpeaker sets the speaker and tape output level
+func (ula *ULA) setSpeaker(level int) {
+	ula.speaker = level
+	beeper := level << 1
+	if ula.ace.tape.IsPlaying() && ula.ace.tape.EarHigh() {
+		beeper |= 0x1
+	}
+	ula.ace.beeper.SetLevel(ula.ace.clock.Tstates(), beeper)
+}
@@ -0,0 +1,129 @@
+package jupiter
+
+import (
+	"github.com/jtruco/emu8/emulator/device/video"
+)
+
+// -----------------------------------------------------------------------------
+// Video constants & vars
+// -----------------------------------------------------------------------------
+
+// Video screen constants
+const (
+	tvScreenWidth  = 256
+	tvScreenHeight = 192
+	tvBorderLeft   = 32
+	tvBorderTop    = 24
+	tvTotalWidth   = tvScreenWidth + 2*tvBorderLeft
+	tvTotalHeight  = tvScreenHeight + 2*tvBorderTop
+	tvLineTstates  = 208                       // TStates per line (64 us)
+	tvFirstLine    = 56                        // First display line after the vertical sync
+	tvTopLine      = tvFirstLine - tvBorderTop // First visible line
+	tvBottomLine   = tvTopLine + tvTotalHeight // Last visible line
+	tvColumns      = tvScreenWidth / 8         // Characters per row
+)
+
+// TV colours : black & white
+const (
+	tvPaper = 0
+	tvInk   = 1
+)
+
+// Jupiter Ace RGBA colour palette
+var acePaletteRGBA = []uint32{0xff000000, 0xffffffff}
+
+// -----------------------------------------------------------------------------
+// Jupiter Ace TV video output
+// -----------------------------------------------------------------------------
+
+// TvVideo is the Jupiter Ace TV video output. The display file is a 32x24
+// characters screen, the character patterns are read from the character
+// RAM. The lines are painted as the TV beam passes them.
+type TvVideo struct {
+	ace    *Ace          // The Jupiter Ace machine
+	screen *video.Screen // The video screen
+	line   int           // Next line to paint
+}
+
+// NewTVVideo creates the video device
+func NewTVVideo(ace *Ace) *TvVideo {
+	tv := new(TvVideo)
+	tv.ace = ace
+	tv.screen = video.NewScreen(tvTotalWidth, tvTotalHeight, acePaletteRGBA)
+	return tv
+}
+
+// Device
+
+// Init initializes video device
+func (tv *TvVideo) Init() { tv.Reset() }
+
+// Reset resets video device
+func (tv *TvVideo) Reset() {
+	tv.screen.Clear(tvPaper)
+	tv.line = 0
+}
+
+// Video
+
+// EndFrame paints the remaining lines of the frame
+func (tv *TvVideo) EndFrame() {
+	tv.paintLines(tvBottomLine)
+	tv.line = 0
+}
+
+// Screen the video screen
+func (tv *TvVideo) Screen() *video.Screen { return tv.screen }
+
+// Painting
+
+// Update paints the lines passed by the TV beam
+func (tv *TvVideo) Update() {
+	tv.paintLines(tv.ace.clock.Tstates() / tvLineTstates)
+}
+
+// paintLines paints the lines up to the last line
+func (tv *TvVideo) paintLines(last int) {
+	if last > tvBottomLine {
+		last = tvBottomLine
+	}
+	for ; tv.line < last; tv.line++ {
+		if tv.line >= tvTopLine {
+			tv.paintLine(tv.line - tvTopLine)
+		}
+	}
+}
+
+// paintLine paints a screen line
+func (tv *TvVideo) paintLine(y int) {
+	row := y - tvBorderTop
+	if row < 0 || row >= tvScreenHeight {
+		for x := 0; x < tvTotalWidth; x++ {
+			tv.screen.SetPixelIndex(x, y, tvPaper)
+		}
+		return
+	}
+	for x := 0; x < tvBorderLeft; x++ {
+		tv.screen.SetPixelIndex(x, y, tvPaper)
+		tv.screen.SetPixelIndex(tvTotalWidth-1-x, y, tvPaper)
+	}
+	videoRAM := tv.ace.memory.Bank(aceMemoryVideo).Data()
+	charRAM := tv.ace.memory.Bank(aceMemoryChars).Data()
+	offset := (row >> 3) * tvColumns
+	x := tvBorderLeft
+	for col := 0; col < tvColumns; col++ {
+		char := videoRAM[offset+col]
+		pattern := charRAM[int(char&0x7f)<<3|row&0x07]
+		if char&0x80 != 0 {
+			pattern ^= 0xff
+		}
+		for mask := byte(0x80); mask != 0; mask >>= 1 {
+			if pattern&mask != 0 {
+				tv.screen.SetPixelIndex(x, y, tvInk)
+			} else {
+				tv.screen.SetPixelIndex(x, y, tvPaper)
+			}
+			x++
+		}
+	}
+}
@@ -4,10 +4,12 @@ package machine
 import (
 	"time"
 
+	"github.com/jtruco/emu8/emulator/controller/vfs"
 	"github.com/jtruco/emu8/emulator/device"
 	"github.com/jtruco/emu8/emulator/device/audio"
 	"github.com/jtruco/emu8/emulator/device/io/joystick"
 	"github.com/jtruco/emu8/emulator/device/io/keyboard"
+	"github.com/jtruco/emu8/emulator/device/io/mouse"
 	"github.com/jtruco/emu8/emulator/device/io/tape"
 	"github.com/jtruco/emu8/emulator/device/video"
 )
@@ -30,6 +32,22 @@ type Machine interface {
 	SaveState() State               // SaveState saves machine state
 }
 
+// Basic is a machine with a BASIC interpreter
+type Basic interface {
+	ListProgram() string               // ListProgram lists the BASIC program in memory
+	EnterProgram(listing string) error // EnterProgram loads a BASIC listing into memory
+}
+
+// Cartridge is a machine with a cartridge slot
+type Cartridge interface {
+	InsertCartridge(data []byte) error // InsertCartridge inserts a cartridge and resets the machine
+}
+
+// DiskDrive is a machine with a floppy disk drive
+type DiskDrive interface {
+	InsertDisk(format string, data []byte) error // InsertDisk inserts a disk image into the first drive
+}
+
 // Control is the machine control interface
 type Control interface {
 	// Device binding
@@ -37,11 +55,15 @@ type Control interface {
 	BindAudio(audio.Audio)          // BindAudio sets the audio device
 	BindKeyboard(keyboard.Keyboard) // BindKeyboard adds a keyboard device
 	BindJoystick(joystick.Joystick) // BindJoystick adds a joystick device
+	BindMouse(mouse.Mouse)          // BindMouse adds a mouse device
 	BindTapeDrive(*tape.Drive)      // BindTapeDrive sets the tape drive
 	// File management
 	LoadROM(string) ([]byte, error)    // Loads a ROM file
+	FileSystem() vfs.FileSystem        // FileSystem gets the virtual file system
 	RegisterSnapshot(string)           // RegisterSnapshot adds a snapshot format
 	RegisterTape(string, tape.Builder) // RegisterTape ads a tape format and its builder
+	RegisterCartridge(string)          // RegisterCartridge adds a cartridge format
+	RegisterDisk(string)               // RegisterDisk adds a disk image format
 }
 
 // Config is the machine configuration
@@ -2,6 +2,7 @@ package machine
 
 import (
 	"errors"
+	"sort"
 	"strings"
 )
 
@@ -16,6 +17,7 @@ type Model struct {
 	Name  string         // Machine model
 	Ids   []string       // Model Ids
 	Build func() Machine // Build builds the machine model
+	Roms  *RomSet        // The model ROM set
 }
 
 // Register register a machine model
@@ -47,6 +49,18 @@ func FindModel(modelId string) *Model {
 	return nil
 }
 
+// Models returns the registered models sorted by name
+func Models() []*Model {
+	list := make([]*Model, 0)
+	for id, model := range models {
+		if id == strings.ToLower(model.Name) {
+			list = append(list, model)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
 // Create returns a machine from a model name
 func Create(modelId string) (Machine, error) {
 	model := FindModel(modelId)
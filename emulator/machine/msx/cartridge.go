@@ -0,0 +1,164 @@
+package msx
+
+import (
+	"github.com/jtruco/emu8/emulator/machine/msx/format"
+)
+
+// -----------------------------------------------------------------------------
+// MSX - ROM cartridge
+// -----------------------------------------------------------------------------
+
+// Cartridge constants
+const (
+	cartWindows    = 8      // 8K windows of the address space
+	cartWindowBits = 13     // 8K window address bits
+	cartPage1      = 0x4000 // Default start address
+	cartPage2      = 0x8000 // Start address of BASIC ROMs
+)
+
+// Cartridge is a ROM cartridge in a slot. The address space is divided in
+// 8K windows mapped to ROM banks, switched by the writes to the mapper
+// registers of the mega ROMs.
+type Cartridge struct {
+	data   []byte           // ROM data (8K banks)
+	mapper int              // ROM mapper
+	banks  [cartWindows]int // ROM offset of each window (-1 unmapped)
+	start  [cartWindows]int // Initial window offsets
+	count  int              // ROM 8K banks
+}
+
+// NewCartridge creates a cartridge of the ROM data. A nil ROM is an empty
+// cartridge slot.
+func NewCartridge(data []byte, mapper int) *Cartridge {
+	cart := new(Cartridge)
+	cart.mapper = mapper
+	if size := len(data); size%format.RomBankSize != 0 {
+		size += format.RomBankSize - size%format.RomBankSize
+		cart.data = make([]byte, size)
+		copy(cart.data, data)
+	} else {
+		cart.data = data
+	}
+	cart.count = len(cart.data) / format.RomBankSize
+	for i := range cart.start {
+		cart.start[i] = -1
+	}
+	if cart.count > 0 {
+		cart.layout()
+	}
+	return cart
+}
+
+// Mapper gets the ROM mapper
+func (cart *Cartridge) Mapper() int { return cart.mapper }
+
+// layout sets the initial windows of the ROM
+func (cart *Cartridge) layout() {
+	switch cart.mapper {
+	case format.MapperPlain:
+		cart.layoutPlain()
+	case format.MapperASCII8, format.MapperASCII16:
+		for i := 2; i < 6; i++ {
+			cart.start[i] = 0
+		}
+	default: // Konami
+		for i := 2; i < 6; i++ {
+			cart.start[i] = cart.bank(i - 2)
+		}
+	}
+}
+
+// layoutPlain maps a plain ROM. ROMs up to 16K are mapped at 0x4000, or
+// 0x8000 for BASIC ROMs, and 8K ROMs are mirrored in the page. ROMs up to
+// 32K are mapped at 0x4000 and bigger ROMs at 0x0000, unless the ROM
+// header is at its start.
+func (cart *Cartridge) layoutPlain() {
+	start := cartPage1
+	size := len(cart.data)
+	switch {
+	case size <= 0x4000:
+		if cart.isBasic() {
+			start = cartPage2
+		}
+		size = 0x4000
+	case size <= 0x8000:
+	default:
+		if !cart.hasHeader() {
+			start = 0
+		}
+	}
+	window := start >> cartWindowBits
+	for i := 0; i < size>>cartWindowBits && window+i < cartWindows; i++ {
+		cart.start[window+i] = (i % cart.count) << cartWindowBits
+	}
+}
+
+// hasHeader checks the "AB" ROM header
+func (cart *Cartridge) hasHeader() bool {
+	return cart.data[0] == 'A' && cart.data[1] == 'B'
+}
+
+// isBasic checks if the ROM header points to code or a BASIC program at
+// page 2
+func (cart *Cartridge) isBasic() bool {
+	if !cart.hasHeader() {
+		return false
+	}
+	init := uint16(cart.data[2]) | uint16(cart.data[3])<<8
+	text := uint16(cart.data[8]) | uint16(cart.data[9])<<8
+	return init&0xc000 == cartPage2 || (init == 0 && text&0xc000 == cartPage2)
+}
+
+// bank gets the ROM offset of an 8K bank
+func (cart *Cartridge) bank(index int) int {
+	return (index % cart.count) << cartWindowBits
+}
+
+// Device
+
+// Init initializes the cartridge
+func (cart *Cartridge) Init() { cart.Reset() }
+
+// Reset resets the mapper banks
+func (cart *Cartridge) Reset() { cart.banks = cart.start }
+
+// Bus
+
+// Read reads the ROM
+func (cart *Cartridge) Read(address uint16) byte {
+	offset := cart.banks[address>>cartWindowBits]
+	if offset < 0 {
+		return 0xff
+	}
+	return cart.data[offset+int(address&(format.RomBankSize-1))]
+}
+
+// Write writes the mapper registers
+func (cart *Cartridge) Write(address uint16, data byte) {
+	if cart.count == 0 {
+		return
+	}
+	bank := int(data)
+	switch cart.mapper {
+	case format.MapperASCII8:
+		if address >= 0x6000 && address < 0x8000 {
+			cart.banks[2+int(address-0x6000)>>11] = cart.bank(bank)
+		}
+	case format.MapperASCII16:
+		switch address & 0xf800 {
+		case 0x6000:
+			cart.banks[2], cart.banks[3] = cart.bank(bank<<1), cart.bank(bank<<1+1)
+		case 0x7000:
+			cart.banks[4], cart.banks[5] = cart.bank(bank<<1), cart.bank(bank<<1+1)
+		}
+	case format.MapperKonami:
+		if address >= 0x6000 && address < 0xc000 {
+			cart.banks[address>>cartWindowBits] = cart.bank(bank)
+		}
+	case format.MapperKonamiSCC:
+		switch address & 0xf800 {
+		case 0x5000, 0x7000, 0x9000, 0xb000:
+			cart.banks[address>>cartWindowBits] = cart.bank(bank)
+		}
+	}
+}
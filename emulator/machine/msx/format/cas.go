@@ -0,0 +1,230 @@
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator/device/io/tape"
+)
+
+// -----------------------------------------------------------------------------
+// MSX CAS tape format
+// -----------------------------------------------------------------------------
+
+// CAS format extension
+const CAS = "cas"
+
+// casHeader is the block header, aligned to 8 bytes in the file
+var casHeader = []byte{0x1f, 0xa6, 0xde, 0xba, 0xcc, 0x13, 0x7d, 0x74}
+
+// CAS file types : the file header block has 10 bytes of the file type and
+// 6 bytes of the file name
+const (
+	CasFileBinary   = 0xd0
+	CasFileBasic    = 0xd3
+	CasFileASCII    = 0xea
+	casFileTypeSize = 10
+	casFileNameSize = 6
+)
+
+// Tape play states
+const (
+	casStateStart = iota
+	casStateHeader
+	casStateByte
+	casStateBit
+	casStateStop
+)
+
+// Tape tstate constants (3.58 MHz, 1200 bauds). A 0 bit is a 1200 Hz cycle,
+// a 1 bit two 2400 Hz cycles. Bytes are sent with a start bit and two stop
+// bits.
+const (
+	casTimingZero   = 1491 // 1200 Hz half cycle
+	casTimingOne    = 746  // 2400 Hz half cycle
+	casTimingSecond = 3579545
+	casLongHeader   = 16000 // 2400 Hz pulses of the file header
+	casShortHeader  = 4000  // 2400 Hz pulses of the data blocks
+	casByteBits     = 11    // Start, 8 data and 2 stop bits
+)
+
+// CasBlock is a CAS tape block
+type CasBlock struct {
+	tape.BlockInfo
+	data []byte // Block data
+}
+
+// Info gets block information
+func (block *CasBlock) Info() *tape.BlockInfo { return &block.BlockInfo }
+
+// Data gets block data bytes
+func (block *CasBlock) Data() []byte { return block.data }
+
+// LoadData gets the loader data bytes
+func (block *CasBlock) LoadData() []byte { return block.data }
+
+// FileType gets the file type of a file header block, 0 for data blocks
+func (block *CasBlock) FileType() byte {
+	if len(block.data) < casFileTypeSize+casFileNameSize {
+		return 0
+	}
+	fileType := block.data[0]
+	switch fileType {
+	case CasFileBinary, CasFileBasic, CasFileASCII:
+	default:
+		return 0
+	}
+	for _, b := range block.data[1:casFileTypeSize] {
+		if b != fileType {
+			return 0
+		}
+	}
+	return fileType
+}
+
+// Meta gets the decoded block description
+func (block *CasBlock) Meta() *tape.BlockMeta {
+	meta := new(tape.BlockMeta)
+	fileType := block.FileType()
+	if fileType == 0 {
+		meta.Description = fmt.Sprintf("Data: %d bytes", len(block.data))
+		return meta
+	}
+	header := new(tape.Header)
+	header.Type = fileType
+	switch fileType {
+	case CasFileBinary:
+		header.TypeName = "Binary"
+	case CasFileBasic:
+		header.TypeName = "Basic"
+	case CasFileASCII:
+		header.TypeName = "ASCII"
+	}
+	header.Name = strings.TrimRight(string(block.data[casFileTypeSize:casFileTypeSize+casFileNameSize]), " ")
+	meta.Header = header
+	meta.Description = "Header"
+	return meta
+}
+
+// Cas implements the MSX tape format .CAS
+type Cas struct {
+	info     tape.Info    // Tape information
+	blocks   []tape.Block // Block array
+	pulses   int          // Pulses left of the header or bit
+	pulseLen int          // Current pulse length
+	frame    uint16       // Current byte frame bits
+	bit      int          // Current byte frame bit
+}
+
+// NewCas creates a new tape
+func NewCas() tape.Tape {
+	cas := new(Cas)
+	cas.blocks = make([]tape.Block, 0, 2)
+	return cas
+}
+
+// Info gets tape information
+func (cas *Cas) Info() *tape.Info { return &cas.info }
+
+// Blocks gets the tape blocks
+func (cas *Cas) Blocks() []tape.Block { return cas.blocks }
+
+// Load loads the tape file data. Blocks start at the 8 byte aligned block
+// headers and end at the next header.
+func (cas *Cas) Load(data []byte) bool {
+	starts := make([]int, 0)
+	for pos := 0; pos+len(casHeader) <= len(data); pos += len(casHeader) {
+		if bytes.Equal(data[pos:pos+len(casHeader)], casHeader) {
+			starts = append(starts, pos)
+		}
+	}
+	if len(starts) == 0 {
+		log.Print("Tape (CAS) : Invalid format: no block headers")
+		return false
+	}
+	for i, start := range starts {
+		end := len(data)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		block := new(CasBlock)
+		block.Index = i
+		block.Offset = start + len(casHeader)
+		block.data = data[block.Offset:end]
+		block.Length = len(block.data)
+		block.Type = block.FileType()
+		cas.blocks = append(cas.blocks, block)
+	}
+	return true
+}
+
+// Play cas
+func (cas *Cas) Play(control *tape.Control) {
+	switch control.State {
+
+	case casStateStart:
+		control.Block = cas.blocks[control.BlockIndex]
+		control.BlockPos = 0
+		block := control.Block.(*CasBlock)
+		control.Ear = tape.LevelLow
+		if block.FileType() != 0 {
+			log.Println("Tape (CAS) : File header:", block.Meta())
+			control.Timeout = casTimingSecond * 2
+			cas.pulses = casLongHeader
+		} else {
+			log.Println("Tape (CAS) : Data block:", block.Length, "bytes")
+			control.Timeout = casTimingSecond
+			cas.pulses = casShortHeader
+		}
+		control.State = casStateHeader
+
+	case casStateHeader:
+		control.Ear ^= tape.LevelMask
+		control.Timeout = casTimingOne
+		cas.pulses--
+		if cas.pulses == 0 {
+			control.State = casStateByte
+		}
+
+	case casStateByte:
+		if control.EndOfBlock() {
+			control.BlockIndex++
+			if control.EndOfTape() {
+				control.State = casStateStop
+			} else {
+				control.State = casStateStart
+			}
+			break
+		}
+		cas.frame = uint16(control.DataAtPos())<<1 | 0x600 // start & stop bits
+		cas.bit = 0
+		cas.pulses = 0
+		control.State = casStateBit
+
+	case casStateBit:
+		if cas.pulses == 0 {
+			if cas.bit == casByteBits {
+				control.BlockPos++
+				control.State = casStateByte
+				break
+			}
+			if cas.frame&(1<<uint(cas.bit)) != 0 {
+				cas.pulses, cas.pulseLen = 4, casTimingOne
+			} else {
+				cas.pulses, cas.pulseLen = 2, casTimingZero
+			}
+			cas.bit++
+		}
+		control.Ear ^= tape.LevelMask
+		control.Timeout = cas.pulseLen
+		cas.pulses--
+
+	case casStateStop:
+		control.Playing = false // Stop
+
+	default:
+		control.State = casStateStop
+	}
+}
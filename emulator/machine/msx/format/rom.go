@@ -0,0 +1,93 @@
+// Package format contains the MSX file formats
+package format
+
+import "strings"
+
+// -----------------------------------------------------------------------------
+// MSX ROM cartridge format
+// -----------------------------------------------------------------------------
+
+// ROM cartridge format extensions
+const (
+	ROM = "rom"
+	MX1 = "mx1"
+)
+
+// ROM cartridge constants
+const (
+	RomBankSize  = 0x2000  // Bank switching unit (8K)
+	RomPlainSize = 0x10000 // Max size of a plain ROM (64K)
+	RomMaxSize   = 0x400000
+)
+
+// ROM cartridge mappers
+const (
+	MapperPlain     = iota // Plain ROM, up to 64K
+	MapperASCII8           // ASCII 8K banks
+	MapperASCII16          // ASCII 16K banks
+	MapperKonami           // Konami 8K banks (without SCC)
+	MapperKonamiSCC        // Konami 8K banks (with SCC)
+	mapperCount
+)
+
+// Mappers the cartridge mappers by option name
+var Mappers = map[string]int{
+	"plain":     MapperPlain,
+	"ascii8":    MapperASCII8,
+	"ascii16":   MapperASCII16,
+	"konami":    MapperKonami,
+	"konamiscc": MapperKonamiSCC,
+}
+
+// MapperName gets the name of a mapper
+func MapperName(mapper int) string {
+	for name, value := range Mappers {
+		if value == mapper {
+			return strings.ToUpper(name)
+		}
+	}
+	return "UNKNOWN"
+}
+
+// DetectMapper guesses the mapper of a ROM cartridge. ROMs up to 64K are
+// plain ROMs, bigger ROMs are scanned for the LD (nnnn),A instructions that
+// write the bank switching addresses of each mapper.
+func DetectMapper(data []byte) int {
+	if len(data) <= RomPlainSize {
+		return MapperPlain
+	}
+	var guess [mapperCount]int
+	for i := 0; i+2 < len(data); i++ {
+		if data[i] != 0x32 { // LD (nnnn),A
+			continue
+		}
+		switch uint16(data[i+1]) | uint16(data[i+2])<<8 {
+		case 0x5000, 0x9000, 0xb000:
+			guess[MapperKonamiSCC]++
+		case 0x4000, 0x8000, 0xa000:
+			guess[MapperKonami]++
+		case 0x6800, 0x7800:
+			guess[MapperASCII8]++
+		case 0x6000:
+			guess[MapperKonami]++
+			guess[MapperASCII8]++
+			guess[MapperASCII16]++
+		case 0x7000:
+			guess[MapperKonamiSCC]++
+			guess[MapperASCII8]++
+			guess[MapperASCII16]++
+		case 0x77ff:
+			guess[MapperASCII16]++
+		}
+	}
+	if guess[MapperASCII8] > 0 {
+		guess[MapperASCII8]-- // 0x6000 and 0x7000 are shared with ASCII16
+	}
+	mapper := MapperKonamiSCC
+	for _, candidate := range []int{MapperKonami, MapperASCII8, MapperASCII16} {
+		if guess[candidate] > guess[mapper] {
+			mapper = candidate
+		}
+	}
+	return mapper
+}
@@ -0,0 +1,20 @@
+package msx
+
+import "github.com/jtruco/emu8/emulator/machine"
+
+// MSX models
+var models = []machine.Model{
+	{Name: "MSX1", Ids: []string{"MSX1", "MSX"},
+		Build: func() machine.Machine { return New(MSX1) }, Roms: msxRomSet},
+}
+
+// MSX ROM set. The BIOS & BASIC ROM image is loaded from file.
+var msxRomSet = &machine.RomSet{
+	Slots: []machine.RomSlot{
+		{Name: "bios", Size: 0x8000, Default: "msx.rom"},
+	},
+}
+
+func init() {
+	machine.RegisterModels(models)
+}
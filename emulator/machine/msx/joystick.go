@@ -0,0 +1,99 @@
+package msx
+
+// -----------------------------------------------------------------------------
+// MSX - Joystick emulation
+// -----------------------------------------------------------------------------
+
+// Joystick port bits (active low), read from the PSG port A
+const (
+	msxJoyUp      = byte(0x01)
+	msxJoyDown    = byte(0x02)
+	msxJoyLeft    = byte(0x04)
+	msxJoyRight   = byte(0x08)
+	msxJoyTrigA   = byte(0x10)
+	msxJoyTrigB   = byte(0x20)
+	msxJoyDefault = byte(0x3f)
+)
+
+// PSG port bits
+const (
+	msxPortAKana = 0x40 // Keyboard layout (JIS = 1)
+	msxPortATape = 0x80 // Tape input
+	msxPortBJoy2 = 0x40 // Joystick port select (port 2 = 1)
+)
+
+// Joystick is a MSX joystick connected to a general purpose port
+type Joystick struct {
+	id    byte // ID
+	state byte // Port state (active low)
+}
+
+// NewJoystick creates a new joystick
+func NewJoystick(id byte) *Joystick {
+	joy := new(Joystick)
+	joy.id = id
+	return joy
+}
+
+// State gets the port state
+func (joy *Joystick) State() byte { return joy.state }
+
+// Init initializes the device
+func (joy *Joystick) Init() { joy.Reset() }
+
+// Reset resets the device
+func (joy *Joystick) Reset() { joy.state = msxJoyDefault }
+
+// ID returns the joystick ID
+func (joy *Joystick) ID() byte { return joy.id }
+
+// SetAxis sets axis value
+func (joy *Joystick) SetAxis(axis byte, value byte) {
+	if axis == 0 { // right / left
+		joy.state |= msxJoyRight | msxJoyLeft
+		if value != 0 && value < 128 {
+			joy.state &^= msxJoyRight
+		} else if value != 0 {
+			joy.state &^= msxJoyLeft
+		}
+	} else if axis == 1 { // down / up
+		joy.state |= msxJoyDown | msxJoyUp
+		if value != 0 && value < 128 {
+			joy.state &^= msxJoyDown
+		} else if value != 0 {
+			joy.state &^= msxJoyUp
+		}
+	}
+}
+
+// SetButton sets button state
+func (joy *Joystick) SetButton(button byte, state byte) {
+	var mask byte
+	switch button {
+	case 0:
+		mask = msxJoyTrigA
+	case 1:
+		mask = msxJoyTrigB
+	default:
+		return
+	}
+	if state > 0 {
+		joy.state &^= mask
+	} else {
+		joy.state |= mask
+	}
+}
+
+// onPsgReadPortA reads the joystick port selected by the PSG port B and
+// the tape input
+func (msx *MSX) onPsgReadPortA() byte {
+	port := 0
+	if msx.psg.Register(15)&msxPortBJoy2 != 0 {
+		port = 1
+	}
+	data := msx.joysticks[port].State() | msxPortAKana
+	if msx.tape.IsPlaying() && msx.tape.EarHigh() {
+		data |= msxPortATape
+	}
+	return data
+}
@@ -0,0 +1,410 @@
+package msx
+
+import (
+	"github.com/jtruco/emu8/emulator/device/io/keyboard"
+)
+
+// -----------------------------------------------------------------------------
+// MSX Keyboard
+// -----------------------------------------------------------------------------
+
+// Keyboard is the MSX keyboard matrix of 11 rows, scanned by the PPI
+type Keyboard struct {
+	rowstates [16]byte
+	row       byte
+}
+
+// NewKeyboard creates a new keyboard
+func NewKeyboard() *Keyboard {
+	return new(Keyboard)
+}
+
+// State gets current row state
+func (keyboard *Keyboard) State() byte {
+	return keyboard.rowstates[keyboard.row]
+}
+
+// SetRow sets current row
+func (keyboard *Keyboard) SetRow(row byte) {
+	keyboard.row = row & 0x0f
+}
+
+// Device
+
+// Init initializes the keyboard
+func (keyboard *Keyboard) Init() {
+	for row := range keyboard.rowstates {
+		keyboard.rowstates[row] = 0xff
+	}
+}
+
+// Reset resets the keyboard
+func (keyboard *Keyboard) Reset() { keyboard.Init() }
+
+// Keyboard
+
+// KeyMap returns the default keyboard mapping
+func (keyboard *Keyboard) KeyMap() keyboard.KeyMap { return msxKeyboardMap }
+
+// KeyNames returns the keys by name
+func (keyboard *Keyboard) KeyNames() map[string]keyboard.Key { return msxKeyNames }
+
+// ProcessKey processes MSX keyboard matrix
+func (keyboard *Keyboard) ProcessKey(key keyboard.Key, pressed bool) {
+	row := key >> 4
+	mask := uint8(1 << uint8(key&0x07))
+	if pressed {
+		keyboard.rowstates[row] &= ^mask
+	} else {
+		keyboard.rowstates[row] |= mask
+	}
+}
+
+// TextKeys returns the key combinations that type the text
+func (keyboard *Keyboard) TextKeys(text string) [][]keyboard.Key {
+	return msxTextKeys(text)
+}
+
+// -----------------------------------------------------------------------------
+// MSX Keys, States & Mapping
+// -----------------------------------------------------------------------------
+
+// MSX Keyboard Keys (international layout)
+const (
+	MsxKey0            = 0x00 // row 0, bit 0..bit 7
+	MsxKey1            = 0x01
+	MsxKey2            = 0x02
+	MsxKey3            = 0x03
+	MsxKey4            = 0x04
+	MsxKey5            = 0x05
+	MsxKey6            = 0x06
+	MsxKey7            = 0x07
+	MsxKey8            = 0x10 // row 1, bit 0..bit 7
+	MsxKey9            = 0x11
+	MsxKeyMinus        = 0x12
+	MsxKeyEquals       = 0x13
+	MsxKeyBackslash    = 0x14
+	MsxKeyOpenBracket  = 0x15
+	MsxKeyCloseBracket = 0x16
+	MsxKeySemicolon    = 0x17
+	MsxKeyQuote        = 0x20 // row 2, bit 0..bit 7
+	MsxKeyGrave        = 0x21
+	MsxKeyComma        = 0x22
+	MsxKeyDot          = 0x23
+	MsxKeySlash        = 0x24
+	MsxKeyDead         = 0x25
+	MsxKeyA            = 0x26
+	MsxKeyB            = 0x27
+	MsxKeyC            = 0x30 // row 3, bit 0..bit 7
+	MsxKeyD            = 0x31
+	MsxKeyE            = 0x32
+	MsxKeyF            = 0x33
+	MsxKeyG            = 0x34
+	MsxKeyH            = 0x35
+	MsxKeyI            = 0x36
+	MsxKeyJ            = 0x37
+	MsxKeyK            = 0x40 // row 4, bit 0..bit 7
+	MsxKeyL            = 0x41
+	MsxKeyM            = 0x42
+	MsxKeyN            = 0x43
+	MsxKeyO            = 0x44
+	MsxKeyP            = 0x45
+	MsxKeyQ            = 0x46
+	MsxKeyR            = 0x47
+	MsxKeyS            = 0x50 // row 5, bit 0..bit 7
+	MsxKeyT            = 0x51
+	MsxKeyU            = 0x52
+	MsxKeyV            = 0x53
+	MsxKeyW            = 0x54
+	MsxKeyX            = 0x55
+	MsxKeyY            = 0x56
+	MsxKeyZ            = 0x57
+	MsxKeyShift        = 0x60 // row 6, bit 0..bit 7
+	MsxKeyCtrl         = 0x61
+	MsxKeyGraph        = 0x62
+	MsxKeyCaps         = 0x63
+	MsxKeyCode         = 0x64
+	MsxKeyF1           = 0x65
+	MsxKeyF2           = 0x66
+	MsxKeyF3           = 0x67
+	MsxKeyF4           = 0x70 // row 7, bit 0..bit 7
+	MsxKeyF5           = 0x71
+	MsxKeyEsc          = 0x72
+	MsxKeyTab          = 0x73
+	MsxKeyStop         = 0x74
+	MsxKeyBackspace    = 0x75
+	MsxKeySelect       = 0x76
+	MsxKeyReturn       = 0x77
+	MsxKeySpace        = 0x80 // row 8, bit 0..bit 7
+	MsxKeyHome         = 0x81
+	MsxKeyInsert       = 0x82
+	MsxKeyDelete       = 0x83
+	MsxKeyLeft         = 0x84
+	MsxKeyUp           = 0x85
+	MsxKeyDown         = 0x86
+	MsxKeyRight        = 0x87
+	MsxKeyPadMultiply  = 0x90 // row 9, bit 0..bit 7
+	MsxKeyPadPlus      = 0x91
+	MsxKeyPadDivide    = 0x92
+	MsxKeyPad0         = 0x93
+	MsxKeyPad1         = 0x94
+	MsxKeyPad2         = 0x95
+	MsxKeyPad3         = 0x96
+	MsxKeyPad4         = 0x97
+	MsxKeyPad5         = 0xa0 // row 10, bit 0..bit 7
+	MsxKeyPad6         = 0xa1
+	MsxKeyPad7         = 0xa2
+	MsxKeyPad8         = 0xa3
+	MsxKeyPad9         = 0xa4
+	MsxKeyPadMinus     = 0xa5
+	MsxKeyPadComma     = 0xa6
+	MsxKeyPadPeriod    = 0xa7
+)
+
+// msxKeyNames MSX keys by name
+var msxKeyNames = map[string]keyboard.Key{
+	"0":            MsxKey0,
+	"1":            MsxKey1,
+	"2":            MsxKey2,
+	"3":            MsxKey3,
+	"4":            MsxKey4,
+	"5":            MsxKey5,
+	"6":            MsxKey6,
+	"7":            MsxKey7,
+	"8":            MsxKey8,
+	"9":            MsxKey9,
+	"Minus":        MsxKeyMinus,
+	"Equals":       MsxKeyEquals,
+	"Backslash":    MsxKeyBackslash,
+	"OpenBracket":  MsxKeyOpenBracket,
+	"CloseBracket": MsxKeyCloseBracket,
+	"Semicolon":    MsxKeySemicolon,
+	"Quote":        MsxKeyQuote,
+	"Grave":        MsxKeyGrave,
+	"Comma":        MsxKeyComma,
+	"Dot":          MsxKeyDot,
+	"Slash":        MsxKeySlash,
+	"Dead":         MsxKeyDead,
+	"A":            MsxKeyA,
+	"B":            MsxKeyB,
+	"C":            MsxKeyC,
+	"D":            MsxKeyD,
+	"E":            MsxKeyE,
+	"F":            MsxKeyF,
+	"G":            MsxKeyG,
+	"H":            MsxKeyH,
+	"I":            MsxKeyI,
+	"J":            MsxKeyJ,
+	"K":            MsxKeyK,
+	"L":            MsxKeyL,
+	"M":            MsxKeyM,
+	"N":            MsxKeyN,
+	"O":            MsxKeyO,
+	"P":            MsxKeyP,
+	"Q":            MsxKeyQ,
+	"R":            MsxKeyR,
+	"S":            MsxKeyS,
+	"T":            MsxKeyT,
+	"U":            MsxKeyU,
+	"V":            MsxKeyV,
+	"W":            MsxKeyW,
+	"X":            MsxKeyX,
+	"Y":            MsxKeyY,
+	"Z":            MsxKeyZ,
+	"Shift":        MsxKeyShift,
+	"Ctrl":         MsxKeyCtrl,
+	"Graph":        MsxKeyGraph,
+	"Caps":         MsxKeyCaps,
+	"Code":         MsxKeyCode,
+	"F1":           MsxKeyF1,
+	"F2":           MsxKeyF2,
+	"F3":           MsxKeyF3,
+	"F4":           MsxKeyF4,
+	"F5":           MsxKeyF5,
+	"Esc":          MsxKeyEsc,
+	"Tab":          MsxKeyTab,
+	"Stop":         MsxKeyStop,
+	"Backspace":    MsxKeyBackspace,
+	"Select":       MsxKeySelect,
+	"Return":       MsxKeyReturn,
+	"Space":        MsxKeySpace,
+	"Home":         MsxKeyHome,
+	"Insert":       MsxKeyInsert,
+	"Delete":       MsxKeyDelete,
+	"Left":         MsxKeyLeft,
+	"Up":           MsxKeyUp,
+	"Down":         MsxKeyDown,
+	"Right":        MsxKeyRight,
+	"PadMultiply":  MsxKeyPadMultiply,
+	"PadPlus":      MsxKeyPadPlus,
+	"PadDivide":    MsxKeyPadDivide,
+	"Pad0":         MsxKeyPad0,
+	"Pad1":         MsxKeyPad1,
+	"Pad2":         MsxKeyPad2,
+	"Pad3":         MsxKeyPad3,
+	"Pad4":         MsxKeyPad4,
+	"Pad5":         MsxKeyPad5,
+	"Pad6":         MsxKeyPad6,
+	"Pad7":         MsxKeyPad7,
+	"Pad8":         MsxKeyPad8,
+	"Pad9":         MsxKeyPad9,
+	"PadMinus":     MsxKeyPadMinus,
+	"PadComma":     MsxKeyPadComma,
+	"PadPeriod":    MsxKeyPadPeriod,
+}
+
+// MSX Keyboard map
+var msxKeyboardMap = map[keyboard.KeyCode][]keyboard.Key{
+	// alphanum
+	keyboard.Key0: {MsxKey0},
+	keyboard.Key1: {MsxKey1},
+	keyboard.Key2: {MsxKey2},
+	keyboard.Key3: {MsxKey3},
+	keyboard.Key4: {MsxKey4},
+	keyboard.Key5: {MsxKey5},
+	keyboard.Key6: {MsxKey6},
+	keyboard.Key7: {MsxKey7},
+	keyboard.Key8: {MsxKey8},
+	keyboard.Key9: {MsxKey9},
+	keyboard.KeyA: {MsxKeyA},
+	keyboard.KeyB: {MsxKeyB},
+	keyboard.KeyC: {MsxKeyC},
+	keyboard.KeyD: {MsxKeyD},
+	keyboard.KeyE: {MsxKeyE},
+	keyboard.KeyF: {MsxKeyF},
+	keyboard.KeyG: {MsxKeyG},
+	keyboard.KeyH: {MsxKeyH},
+	keyboard.KeyI: {MsxKeyI},
+	keyboard.KeyJ: {MsxKeyJ},
+	keyboard.KeyK: {MsxKeyK},
+	keyboard.KeyL: {MsxKeyL},
+	keyboard.KeyM: {MsxKeyM},
+	keyboard.KeyN: {MsxKeyN},
+	keyboard.KeyO: {MsxKeyO},
+	keyboard.KeyP: {MsxKeyP},
+	keyboard.KeyQ: {MsxKeyQ},
+	keyboard.KeyR: {MsxKeyR},
+	keyboard.KeyS: {MsxKeyS},
+	keyboard.KeyT: {MsxKeyT},
+	keyboard.KeyU: {MsxKeyU},
+	keyboard.KeyV: {MsxKeyV},
+	keyboard.KeyW: {MsxKeyW},
+	keyboard.KeyX: {MsxKeyX},
+	keyboard.KeyY: {MsxKeyY},
+	keyboard.KeyZ: {MsxKeyZ},
+	// symbols
+	keyboard.KeySpace:        {MsxKeySpace},
+	keyboard.KeyMinus:        {MsxKeyMinus},
+	keyboard.KeyEquals:       {MsxKeyEquals},
+	keyboard.KeyBackSlash:    {MsxKeyBackslash},
+	keyboard.KeyLeftBracket:  {MsxKeyOpenBracket},
+	keyboard.KeyRightBracket: {MsxKeyCloseBracket},
+	keyboard.KeySemicolon:    {MsxKeySemicolon},
+	keyboard.KeyApostrophe:   {MsxKeyQuote},
+	keyboard.KeyGrave:        {MsxKeyGrave},
+	keyboard.KeyComma:        {MsxKeyComma},
+	keyboard.KeyPeriod:       {MsxKeyDot},
+	keyboard.KeySlash:        {MsxKeySlash},
+	// special
+	keyboard.KeyReturn:    {MsxKeyReturn},
+	keyboard.KeyEscape:    {MsxKeyEsc},
+	keyboard.KeyTab:       {MsxKeyTab},
+	keyboard.KeyBackspace: {MsxKeyBackspace},
+	keyboard.KeyCapsLock:  {MsxKeyCaps},
+	keyboard.KeyPause:     {MsxKeyStop},
+	keyboard.KeyEnd:       {MsxKeySelect},
+	keyboard.KeyHome:      {MsxKeyHome},
+	keyboard.KeyInsert:    {MsxKeyInsert},
+	keyboard.KeyDelete:    {MsxKeyDelete},
+	keyboard.KeyF1:        {MsxKeyF1},
+	keyboard.KeyF2:        {MsxKeyF2},
+	keyboard.KeyF3:        {MsxKeyF3},
+	keyboard.KeyF4:        {MsxKeyF4},
+	keyboard.KeyF5:        {MsxKeyF5},
+	// cursors
+	keyboard.KeyUp:    {MsxKeyUp},
+	keyboard.KeyDown:  {MsxKeyDown},
+	keyboard.KeyLeft:  {MsxKeyLeft},
+	keyboard.KeyRight: {MsxKeyRight},
+	// keypad
+	keyboard.KeyPad0:        {MsxKeyPad0},
+	keyboard.KeyPad1:        {MsxKeyPad1},
+	keyboard.KeyPad2:        {MsxKeyPad2},
+	keyboard.KeyPad3:        {MsxKeyPad3},
+	keyboard.KeyPad4:        {MsxKeyPad4},
+	keyboard.KeyPad5:        {MsxKeyPad5},
+	keyboard.KeyPad6:        {MsxKeyPad6},
+	keyboard.KeyPad7:        {MsxKeyPad7},
+	keyboard.KeyPad8:        {MsxKeyPad8},
+	keyboard.KeyPad9:        {MsxKeyPad9},
+	keyboard.KeyPadMultiply: {MsxKeyPadMultiply},
+	keyboard.KeyPadPlus:     {MsxKeyPadPlus},
+	keyboard.KeyPadDivide:   {MsxKeyPadDivide},
+	keyboard.KeyPadMinus:    {MsxKeyPadMinus},
+	keyboard.KeyPadPeriod:   {MsxKeyPadPeriod},
+	keyboard.KeyPadEnter:    {MsxKeyReturn},
+	// shift, control, graph & code
+	keyboard.KeyLShift: {MsxKeyShift},
+	keyboard.KeyRShift: {MsxKeyShift},
+	keyboard.KeyLCtrl:  {MsxKeyCtrl},
+	keyboard.KeyRCtrl:  {MsxKeyCtrl},
+	keyboard.KeyLAlt:   {MsxKeyGraph},
+	keyboard.KeyRAlt:   {MsxKeyCode},
+}
+
+// -----------------------------------------------------------------------------
+// MSX Text Typing
+// -----------------------------------------------------------------------------
+
+// msxSymbolKeys MSX keys of symbols, unshifted and shifted
+var msxSymbolKeys = map[keyboard.Key]string{
+	MsxKey1: "1!", MsxKey2: "2@", MsxKey3: "3#", MsxKey4: "4$", MsxKey5: "5%",
+	MsxKey6: "6^", MsxKey7: "7&", MsxKey8: "8*", MsxKey9: "9(", MsxKey0: "0)",
+	MsxKeyMinus: "-_", MsxKeyEquals: "=+", MsxKeyBackslash: "\\|",
+	MsxKeyOpenBracket: "[{", MsxKeyCloseBracket: "]}", MsxKeySemicolon: ";:",
+	MsxKeyQuote: "'\"", MsxKeyGrave: "`~", MsxKeyComma: ",<", MsxKeyDot: ".>",
+	MsxKeySlash: "/?",
+}
+
+// msxLetterKeys MSX keys of letters from A to Z
+var msxLetterKeys = [...]keyboard.Key{
+	MsxKeyA, MsxKeyB, MsxKeyC, MsxKeyD, MsxKeyE, MsxKeyF, MsxKeyG, MsxKeyH,
+	MsxKeyI, MsxKeyJ, MsxKeyK, MsxKeyL, MsxKeyM, MsxKeyN, MsxKeyO, MsxKeyP,
+	MsxKeyQ, MsxKeyR, MsxKeyS, MsxKeyT, MsxKeyU, MsxKeyV, MsxKeyW, MsxKeyX,
+	MsxKeyY, MsxKeyZ,
+}
+
+// msxKeyCombinations key combinations by character
+var msxKeyCombinations = make(map[rune][]keyboard.Key)
+
+func init() {
+	for key, symbols := range msxSymbolKeys {
+		runes := []rune(symbols)
+		msxKeyCombinations[runes[0]] = []keyboard.Key{key}
+		msxKeyCombinations[runes[1]] = []keyboard.Key{MsxKeyShift, key}
+	}
+	for i, key := range msxLetterKeys {
+		msxKeyCombinations[rune('a'+i)] = []keyboard.Key{key}
+		msxKeyCombinations[rune('A'+i)] = []keyboard.Key{MsxKeyShift, key}
+	}
+	msxKeyCombinations[' '] = []keyboard.Key{MsxKeySpace}
+	msxKeyCombinations['\t'] = []keyboard.Key{MsxKeyTab}
+	msxKeyCombinations['\n'] = []keyboard.Key{MsxKeyReturn}
+}
+
+// msxTextKeys returns the key combinations that type the text, with Caps
+// off. Lines are followed by a pause for the BASIC editor.
+func msxTextKeys(text string) [][]keyboard.Key {
+	keys := make([][]keyboard.Key, 0, len(text))
+	for _, r := range text {
+		combination, ok := msxKeyCombinations[r]
+		if !ok {
+			continue
+		}
+		keys = append(keys, combination)
+		if r == '\n' {
+			keys = append(keys, keyboard.TypistPause, keyboard.TypistPause)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,51 @@
+package msx
+
+import (
+	"github.com/jtruco/emu8/emulator/device/cpu/z80"
+)
+
+// -----------------------------------------------------------------------------
+// MSX - Tape loading
+// -----------------------------------------------------------------------------
+
+// BIOS tape routines
+const (
+	msxTapion = 0x00e1 // TAPION : reads the tape header
+	msxTapin  = 0x00e4 // TAPIN : reads a byte from tape
+)
+
+// isLoaderTrap checks if the CPU is entering a BIOS tape read routine
+func (msx *MSX) isLoaderTrap() bool {
+	if !msx.fastload || !msx.tape.HasTape() {
+		return false
+	}
+	if pc := msx.cpu.PC; pc != msxTapion && pc != msxTapin {
+		return false
+	}
+	slot, subslot := msx.slots.PageSlot(0)
+	return slot == msxSlotBIOS && subslot == 0
+}
+
+// loaderTrap emulates the BIOS tape routines, reading the tape blocks
+// directly. TAPION starts the next block, TAPIN reads a byte of the block
+// into A. Both set the carry flag on error.
+func (msx *MSX) loaderTrap() {
+	cpu := msx.cpu
+	success := true
+	if cpu.PC == msxTapion {
+		msx.casData = msx.tape.NextDataBlock()
+		msx.casPos = 0
+		success = msx.casData != nil
+	} else if msx.casPos < len(msx.casData) {
+		cpu.A = msx.casData[msx.casPos]
+		msx.casPos++
+	} else {
+		success = false
+	}
+	if success {
+		cpu.F &^= z80.FlagC
+	} else {
+		cpu.F |= z80.FlagC
+	}
+	cpu.Ret()
+}
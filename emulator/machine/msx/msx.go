@@ -0,0 +1,373 @@
+// Package msx implements the MSX machine
+package msx
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator/config"
+	"github.com/jtruco/emu8/emulator/device"
+	"github.com/jtruco/emu8/emulator/device/audio"
+	"github.com/jtruco/emu8/emulator/device/bus"
+	"github.com/jtruco/emu8/emulator/device/cpu"
+	"github.com/jtruco/emu8/emulator/device/cpu/z80"
+	"github.com/jtruco/emu8/emulator/device/io/keyboard"
+	"github.com/jtruco/emu8/emulator/device/io/tape"
+	"github.com/jtruco/emu8/emulator/device/memory"
+	"github.com/jtruco/emu8/emulator/device/video"
+	"github.com/jtruco/emu8/emulator/machine"
+	"github.com/jtruco/emu8/emulator/machine/msx/format"
+)
+
+// -----------------------------------------------------------------------------
+// MSX
+// -----------------------------------------------------------------------------
+
+// MSX models
+const (
+	MSX1 = iota
+)
+
+// Default MSX constants
+const (
+	msxLineTStates   = 228                                     // TStates per line (3.58 MHz)
+	msxTStates60     = msxLineTStates * video.TMS9918LinesNTSC // TStates per frame at 60 Hz
+	msxTStates50     = msxLineTStates * video.TMS9918LinesPAL  // TStates per frame at 50 Hz
+	msxWaitM1        = 1                                       // M1 cycle wait state
+	msxMemoryBIOS    = 0                                       // BIOS ROM memory map
+	msxMemoryRAM     = 1                                       // RAM memory maps (4 pages)
+	msxMemoryCart    = 5                                       // Cartridge memory map
+	msxMemoryBanks   = 6
+	msxSlotBIOS      = 0 // BIOS & BASIC ROM slot
+	msxSlotCartridge = 1 // Cartridge slot
+	msxSlotRAM       = 3 // RAM slot (expanded, subslot 0)
+)
+
+// msxBeeperMap key click levels
+var msxBeeperMap = []uint16{0, 0x0800}
+
+// MSX is a MSX1 computer
+type MSX struct {
+	config     machine.Config      // Machine information
+	control    machine.Control     // The emulator controller
+	components *device.Components  // Machine device components
+	clock      *device.ClockDevice // The system clock
+	cpu        *z80.Z80            // The Zilog Z80A CPU
+	memory     *memory.Memory      // The machine memory
+	slots      *Slots              // The slot system
+	cartridge  *Cartridge          // The ROM cartridge (slot 1)
+	vdp        *video.TMS9918      // The TMS9918A Video Display Processor
+	psg        *audio.AY38910      // The Programmable Sound Generator
+	beeper     *audio.Beeper       // The key click
+	mixer      *audio.Mixer        // The PSG and key click mixer
+	ppi        *Ppi                // The Parallel Peripheral Interface
+	keyboard   *Keyboard           // The matrix keyboard
+	joysticks  [2]*Joystick        // The joysticks
+	tape       *tape.Drive         // The tape drive
+	typist     *keyboard.Typist    // The keyboard typist
+	fastload   bool                // Tape fast loading
+	casData    []byte              // Tape block read by the BIOS
+	casPos     int                 // Tape block position
+	psgCycles  int                 // PSG cycles emulated in the frame
+	frame      int                 // TStates per frame
+}
+
+// New returns a new MSX
+func New(model int) machine.Machine {
+	msx := new(MSX)
+	msx.config.Model = model
+	lines := video.TMS9918LinesNTSC
+	msx.frame, msx.config.Fps = msxTStates60, 60
+	switch hz := config.Get().Machine.Option("hz"); hz {
+	case "", "60":
+	case "50":
+		lines = video.TMS9918LinesPAL
+		msx.frame, msx.config.Fps = msxTStates50, 50
+	default:
+		log.Println("MSX : Unknown display frequency:", hz)
+	}
+	msx.config.SetTimings(msx.frame, msx.config.Fps)
+	// memory map
+	msx.memory = memory.New(msxMemoryBanks)
+	msx.memory.SetMap(msxMemoryBIOS, memory.NewROM(0x0000, memory.Size32K))
+	msx.slots = NewSlots()
+	msx.slots.SetPage(msxSlotBIOS, 0, 0, msxMemoryBIOS)
+	msx.slots.SetPage(msxSlotBIOS, 0, 1, msxMemoryBIOS)
+	msx.slots.Expand(msxSlotRAM)
+	for page := 0; page < msxPages; page++ {
+		msx.memory.SetMap(msxMemoryRAM+page, memory.NewRAM(uint16(page<<14), memory.Size16K))
+		msx.slots.SetPage(msxSlotRAM, 0, page, msxMemoryRAM+page)
+		msx.slots.SetPage(msxSlotCartridge, 0, page, msxMemoryCart)
+	}
+	msx.setCartridge(NewCartridge(nil, format.MapperPlain))
+	msx.memory.SetMapper(msx.slots)
+	// devices
+	msx.clock = device.NewClock()
+	msx.cpu = z80.New(msx.clock, msx.memory, msx)
+	msx.cpu.OnFetch = msx.onFetch
+	msx.vdp = video.NewTMS9918(lines)
+	frequency := config.Get().Audio.Frequency
+	msx.beeper = audio.NewBeeper(audio.NewConfig(frequency, msx.config.Fps, msx.frame))
+	msx.beeper.SetMap(msxBeeperMap)
+	// PSG clocked at half CPU clock, a sample every 8 PSG clocks (rounded up)
+	msx.psg = audio.NewAY38910(audio.NewConfig(frequency, msx.config.Fps, (msx.frame+15)>>4))
+	msx.psg.OnReadPortA = msx.onPsgReadPortA
+	msx.mixer = audio.NewMixer(msx.beeper, msx.psg)
+	msx.keyboard = NewKeyboard()
+	msx.joysticks[0] = NewJoystick(0)
+	msx.joysticks[1] = NewJoystick(1)
+	msx.tape = tape.New(msx.clock)
+	msx.tape.SetAutoPlay(config.Get().Tape.AutoStart)
+	msx.ppi = NewPpi(msx)
+	msx.typist = keyboard.NewTypist(msx.keyboard)
+	msx.fastload = config.Get().Tape.FastLoad
+	// register all components
+	msx.components = device.NewComponents()
+	msx.components.Add(msx.clock)
+	msx.components.Add(msx.cpu)
+	msx.components.Add(msx.memory)
+	msx.components.Add(msx.vdp)
+	msx.components.Add(msx.beeper)
+	msx.components.Add(msx.psg)
+	msx.components.Add(msx.keyboard)
+	msx.components.Add(msx.joysticks[0])
+	msx.components.Add(msx.joysticks[1])
+	msx.components.Add(msx.tape)
+	msx.components.Add(msx.ppi)
+	return msx
+}
+
+// Device interface
+
+// Init initializes the machine
+func (msx *MSX) Init() {
+	msx.components.Init()
+	msx.initMSX()
+}
+
+// Reset resets the machine
+func (msx *MSX) Reset() {
+	msx.components.Reset()
+	msx.initMSX()
+}
+
+// initMSX common init tasks
+func (msx *MSX) initMSX() {
+	msx.typist.Cancel()
+	msx.slots.Reset()
+	msx.casData = nil
+	msx.psgCycles = 0
+	data, err := msxRomSet.Load(msx.control, "bios")
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+	msx.memory.Bank(msxMemoryBIOS).Load(0, data)
+}
+
+// Machine properties
+
+// Clock gets the machine clock
+func (msx *MSX) Clock() device.Clock { return msx.clock }
+
+// Config gets the machine info
+func (msx *MSX) Config() *machine.Config { return &msx.config }
+
+// CPU gets the machine CPU
+func (msx *MSX) CPU() cpu.CPU { return msx.cpu }
+
+// Components gets the machine components
+func (msx *MSX) Components() *device.Components { return msx.components }
+
+// InitControl connect controllers & components
+func (msx *MSX) InitControl(control machine.Control) {
+	// Bind devices
+	control.BindVideo(msx.vdp)
+	control.BindAudio(msx.mixer)
+	control.BindKeyboard(msx.keyboard)
+	control.BindJoystick(msx.joysticks[0])
+	control.BindJoystick(msx.joysticks[1])
+	control.BindTapeDrive(msx.tape)
+	// Register formats
+	control.RegisterTape(format.CAS, format.NewCas)
+	control.RegisterCartridge(format.ROM)
+	control.RegisterCartridge(format.MX1)
+	msx.control = control
+	msx.loadCartridge()
+}
+
+// Emulation control
+
+// BeginFrame begin emulation frame tasks
+func (msx *MSX) BeginFrame() {
+	msx.psgCycles = 0
+	// Keyboard typing
+	msx.typist.Frame()
+}
+
+// Emulate one machine step
+func (msx *MSX) Emulate() {
+	// Tape fast loading
+	if msx.isLoaderTrap() {
+		msx.loaderTrap()
+		return
+	}
+
+	// VDP frame interrupt
+	msx.vdp.Update(msx.clock.Tstates() / msxLineTStates)
+	msx.cpu.InterruptRequest(msx.vdp.IntRequest())
+
+	// Executes a CPU instruction
+	tstates := msx.cpu.Execute()
+
+	// Tape emulation
+	msx.tape.Emulate(tstates)
+}
+
+// EndFrame end emulation frame tasks
+func (msx *MSX) EndFrame() {
+	msx.emulatePSG()
+}
+
+// onFetch adds the M1 cycle wait state of the MSX
+func (msx *MSX) onFetch(address uint16, opcode byte) byte {
+	msx.clock.Add(msxWaitM1)
+	return opcode
+}
+
+// emulatePSG emulates the PSG up to the current tstate
+func (msx *MSX) emulatePSG() {
+	tstates := msx.clock.Tstates()
+	if tstates > msx.frame {
+		tstates = msx.frame
+	}
+	cycles := tstates>>1 - msx.psgCycles
+	if cycles > 0 {
+		msx.psg.Emulate(cycles)
+		msx.psgCycles += cycles
+	}
+}
+
+// setClick sets the key click level
+func (msx *MSX) setClick(on bool) {
+	level := 0
+	if on {
+		level = 1
+	}
+	tstates := msx.clock.Tstates()
+	if tstates > msx.frame {
+		tstates = msx.frame
+	}
+	msx.beeper.SetLevel(tstates, level)
+}
+
+// MSX IO bus
+// -----------------------------------------------------------------------------
+
+// Read bus at address
+func (msx *MSX) Read(address uint16) byte {
+	switch port := byte(address); port {
+	case 0x98: // VDP data
+		msx.syncVDP()
+		return msx.vdp.ReadData()
+	case 0x99: // VDP status
+		msx.syncVDP()
+		return msx.vdp.ReadStatus()
+	case 0xa2: // PSG data
+		return msx.psg.Read()
+	case 0xa8, 0xa9, 0xaa, 0xab: // PPI
+		return msx.ppi.Read(port & 0x03)
+	}
+	return 0xff
+}
+
+// Write bus at address
+func (msx *MSX) Write(address uint16, data byte) {
+	switch port := byte(address); port {
+	case 0x98: // VDP data
+		msx.syncVDP()
+		msx.vdp.WriteData(data)
+	case 0x99: // VDP control
+		msx.syncVDP()
+		msx.vdp.WriteControl(data)
+	case 0xa0: // PSG register select
+		msx.psg.SelectRegister(data & 0x0f)
+	case 0xa1: // PSG data
+		msx.emulatePSG()
+		msx.psg.WriteRegister(msx.psg.Selected(), data)
+	case 0xa8, 0xa9, 0xaa, 0xab: // PPI
+		msx.ppi.Write(port&0x03, data)
+	}
+}
+
+// syncVDP paints the VDP lines up to the current tstate
+func (msx *MSX) syncVDP() {
+	msx.vdp.Update(msx.clock.Tstates() / msxLineTStates)
+}
+
+// Cartridges
+// -----------------------------------------------------------------------------
+
+// setCartridge inserts the cartridge in the cartridge slot
+func (msx *MSX) setCartridge(cart *Cartridge) {
+	msx.cartridge = cart
+	msx.memory.SetMap(msxMemoryCart, bus.NewMap(cart, 0x0000, 0xffff, true, false))
+}
+
+// loadCartridge loads the cartridge option
+func (msx *MSX) loadCartridge() {
+	filename := config.Get().Machine.Option("cartridge")
+	if filename == "" {
+		return
+	}
+	data, err := msx.control.LoadROM(filename)
+	if err != nil {
+		log.Println("MSX : Could not load cartridge", filename, ":", err.Error())
+		return
+	}
+	if err = msx.insertCartridge(data); err != nil {
+		log.Println(err.Error())
+	}
+}
+
+// InsertCartridge inserts a ROM cartridge and resets the machine
+func (msx *MSX) InsertCartridge(data []byte) error {
+	if err := msx.insertCartridge(data); err != nil {
+		return err
+	}
+	msx.Reset()
+	return nil
+}
+
+// insertCartridge inserts a ROM cartridge. The mapper option selects the
+// mapper of mega ROMs, otherwise it is detected.
+func (msx *MSX) insertCartridge(data []byte) error {
+	if len(data) == 0 || len(data) > format.RomMaxSize {
+		return errors.New("Invalid ROM cartridge")
+	}
+	mapper := format.DetectMapper(data)
+	if name := config.Get().Machine.Option("mapper"); name != "" {
+		if value, ok := format.Mappers[strings.ToLower(name)]; ok {
+			mapper = value
+		} else {
+			log.Println("MSX : Unknown cartridge mapper:", name)
+		}
+	}
+	log.Println("MSX : Cartridge mapper:", format.MapperName(mapper))
+	msx.setCartridge(NewCartridge(data, mapper))
+	return nil
+}
+
+// Snapshots : load & save state
+
+// LoadState loads a snapshot. Programs are loaded from tape or cartridge.
+func (msx *MSX) LoadState(state machine.State) {
+	log.Println("MSX : Not implemented snap format:", state.Format)
+}
+
+// SaveState snapshots are not implemented
+func (msx *MSX) SaveState() machine.State {
+	log.Println("MSX : Snapshots not implemented")
+	return machine.State{}
+}
@@ -0,0 +1,94 @@
+package msx
+
+// -----------------------------------------------------------------------------
+// MSX - 8255 Parallel peripheral interface
+// -----------------------------------------------------------------------------
+
+// PPI port C bits
+const (
+	ppiMotorOff = 0x10 // Tape motor off
+	ppiClick    = 0x80 // Key click
+)
+
+// Ppi 8255 parallel peripheral interface. Port A selects the primary slots,
+// port B reads the keyboard row selected by port C.
+type Ppi struct {
+	msx     *MSX
+	portA   byte
+	portB   byte
+	portC   byte
+	control byte
+}
+
+// NewPpi creates new PPI
+func NewPpi(msx *MSX) *Ppi {
+	ppi := new(Ppi)
+	ppi.msx = msx
+	return ppi
+}
+
+// Init the PPI
+func (ppi *Ppi) Init() { ppi.Reset() }
+
+// Reset the PPI
+func (ppi *Ppi) Reset() {
+	ppi.portA = 0x00
+	ppi.portB = 0x00
+	ppi.portC = ppiMotorOff
+	ppi.control = 0x82 // port B input
+	ppi.update()
+}
+
+// Read reads a PPI port
+func (ppi *Ppi) Read(port byte) byte {
+	switch port {
+	case 0: // port A
+		return ppi.portA
+	case 1: // port B
+		if (ppi.control & 0x02) != 0 { // input
+			return ppi.msx.keyboard.State()
+		}
+		return ppi.portB
+	case 2: // port C
+		return ppi.portC
+	}
+	return 0xff
+}
+
+// Write writes a PPI port
+func (ppi *Ppi) Write(port byte, data byte) {
+	switch port {
+	case 0: // port A
+		ppi.portA = data
+		ppi.msx.slots.SetPrimary(data)
+	case 1: // port B
+		ppi.portB = data
+	case 2: // port C
+		ppi.portC = data
+		ppi.update()
+	case 3: // PPI control
+		if (data & 0x80) != 0 {
+			ppi.control = data
+			ppi.portA = 0
+			ppi.portB = 0
+			ppi.portC = 0
+			ppi.msx.slots.SetPrimary(0)
+		} else {
+			mask := byte(1 << ((data >> 1) & 0x7))
+			if (data & 0x01) != 0 {
+				ppi.portC |= mask
+			} else {
+				ppi.portC &= ^mask
+			}
+		}
+		ppi.update()
+	}
+}
+
+// update updates the devices connected to port C : keyboard row, tape
+// motor and key click
+func (ppi *Ppi) update() {
+	ppi.msx.keyboard.SetRow(ppi.portC)
+	ppi.msx.tape.SetMotor(ppi.portC&ppiMotorOff == 0)
+	ppi.msx.setClick(ppi.portC&ppiClick != 0)
+}
@@ -0,0 +1,122 @@
+package msx
+
+import "github.com/jtruco/emu8/emulator/device/bus"
+
+// -----------------------------------------------------------------------------
+// MSX - Slot system
+// -----------------------------------------------------------------------------
+
+// Slot system constants
+const (
+	msxSlots    = 4      // Primary slots
+	msxSubslots = 4      // Secondary slots of an expanded slot
+	msxPages    = 4      // 16K pages of the address space
+	msxSubslotR = 0xffff // Secondary slot register address
+)
+
+// Slots is the MSX slot system mapper. The primary slot register (PPI port
+// A) selects the slot of each 16K page, two bits per page. Expanded slots
+// have four secondary slots, selected by the secondary slot register at
+// 0xffff of the slot. The register reads back inverted.
+type Slots struct {
+	maps      bus.Maps                             // Memory maps
+	pages     [msxSlots][msxSubslots][msxPages]int // Map index of each page (-1 empty)
+	expanded  [msxSlots]bool                       // Slot is expanded
+	primary   byte                                 // Primary slot register
+	secondary [msxSlots]byte                       // Secondary slot registers
+	register  *bus.Map                             // Secondary slot register map
+}
+
+// NewSlots creates the slot system with empty slots
+func NewSlots() *Slots {
+	slots := new(Slots)
+	for slot := range slots.pages {
+		for subslot := range slots.pages[slot] {
+			for page := range slots.pages[slot][subslot] {
+				slots.pages[slot][subslot][page] = -1
+			}
+		}
+	}
+	slots.register = bus.NewMap(&subslotRegister{slots}, msxSubslotR, 1, true, false)
+	return slots
+}
+
+// SetPage maps the memory map at index into a page of a slot
+func (slots *Slots) SetPage(slot, subslot, page, index int) {
+	slots.pages[slot][subslot][page] = index
+}
+
+// Expand expands a primary slot
+func (slots *Slots) Expand(slot int) { slots.expanded[slot] = true }
+
+// Primary gets the primary slot register
+func (slots *Slots) Primary() byte { return slots.primary }
+
+// SetPrimary sets the primary slot register
+func (slots *Slots) SetPrimary(data byte) { slots.primary = data }
+
+// PageSlot gets the primary and secondary slot of a page
+func (slots *Slots) PageSlot(page int) (int, int) {
+	slot := int(slots.primary>>uint(page<<1)) & 0x03
+	subslot := 0
+	if slots.expanded[slot] {
+		subslot = int(slots.secondary[slot]>>uint(page<<1)) & 0x03
+	}
+	return slot, subslot
+}
+
+// Reset resets the slot registers
+func (slots *Slots) Reset() {
+	slots.primary = 0
+	for i := range slots.secondary {
+		slots.secondary[i] = 0
+	}
+}
+
+// Mapper
+
+// Init inits the mapper
+func (slots *Slots) Init(maps bus.Maps) { slots.maps = maps }
+
+// Select selects the map at address for read access
+func (slots *Slots) Select(address uint16) (*bus.Map, uint16) {
+	page := int(address >> 14)
+	slot, subslot := slots.PageSlot(page)
+	if address == msxSubslotR && slots.expanded[slot] {
+		return slots.register, 0
+	}
+	index := slots.pages[slot][subslot][page]
+	if index < 0 {
+		return nil, 0
+	}
+	m := slots.maps[index]
+	return m, address - m.StartAddress()
+}
+
+// SelectWrite selects the map at address for write access
+func (slots *Slots) SelectWrite(address uint16) (*bus.Map, uint16) {
+	return slots.Select(address)
+}
+
+// subslotRegister is the secondary slot register of the slot at page 3
+type subslotRegister struct {
+	slots *Slots
+}
+
+// Init initializes the register
+func (register *subslotRegister) Init() {}
+
+// Reset resets the register
+func (register *subslotRegister) Reset() {}
+
+// Read reads the inverted register
+func (register *subslotRegister) Read(address uint16) byte {
+	slot, _ := register.slots.PageSlot(3)
+	return ^register.slots.secondary[slot]
+}
+
+// Write writes the register
+func (register *subslotRegister) Write(address uint16, data byte) {
+	slot, _ := register.slots.PageSlot(3)
+	register.slots.secondary[slot] = data
+}
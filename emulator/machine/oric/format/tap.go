@@ -0,0 +1,284 @@
+// Package format contains the Oric file formats
+package format
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator/device/io/tape"
+)
+
+// -----------------------------------------------------------------------------
+// Oric TAP tape format
+// -----------------------------------------------------------------------------
+
+// TAP format extension
+const TAP = "tap"
+
+// Oric TAP files are the bytes of the tape files : sync bytes (0x16), the
+// sync marker (0x24), the 9 bytes header, the file name ended by 0x00 and
+// the file data. The header holds the file type, the autorun flag and the
+// end and start addresses (high byte first).
+const (
+	tapSyncByte    = 0x16
+	tapSyncMarker  = 0x24
+	tapHeaderSize  = 9
+	tapNameSize    = 16
+	tapHeaderType  = 2
+	tapHeaderAuto  = 3
+	tapHeaderEnd   = 4
+	tapHeaderStart = 6
+)
+
+// Tape file types
+const (
+	TapeFileBasic = 0x00
+	TapeFileCode  = 0x80
+)
+
+// Tape play states
+const (
+	tapStateStart = iota
+	tapStateByte
+	tapStateBit
+	tapStateStop
+)
+
+// Tape tstate constants (1 MHz, fast format). A bit is a cycle of 208 us
+// for 1 and 416 us for 0. Bytes are sent with a start bit, an odd parity
+// bit and stop bits.
+const (
+	tapTimingOne   = 104    // 1 bit half cycle
+	tapTimingZero  = 208    // 0 bit half cycle
+	tapTimingPause = 500000 // Pause before a file
+	tapPilotBytes  = 256    // Sync bytes played before a file
+	tapGapFrames   = 200    // Stop bit frames played after the file name
+	tapByteBits    = 13     // Start, 8 data, parity and 3 stop bits
+	tapStopBits    = 0x1c00 // Stop bits of a byte frame
+)
+
+// TapBlock is an Oric tape file
+type TapBlock struct {
+	tape.BlockInfo
+	data   []byte // File bytes, from the sync bytes
+	header int    // Header position, after the sync marker
+	body   int    // Data position, after the file name
+}
+
+// Info gets block information
+func (block *TapBlock) Info() *tape.BlockInfo { return &block.BlockInfo }
+
+// Data gets block data bytes
+func (block *TapBlock) Data() []byte { return block.data }
+
+// LoadData gets the loader data bytes : header, name and data
+func (block *TapBlock) LoadData() []byte { return block.data[block.header:] }
+
+// Name gets the file name
+func (block *TapBlock) Name() string {
+	name := block.data[block.header+tapHeaderSize : block.body]
+	if end := bytes.IndexByte(name, 0); end >= 0 {
+		name = name[:end]
+	}
+	return readString(name)
+}
+
+// Start gets the file start address
+func (block *TapBlock) Start() int { return block.address(tapHeaderStart) }
+
+// End gets the file end address
+func (block *TapBlock) End() int { return block.address(tapHeaderEnd) }
+
+// address reads a header address, high byte first
+func (block *TapBlock) address(pos int) int {
+	pos += block.header
+	return int(block.data[pos])<<8 | int(block.data[pos+1])
+}
+
+// Meta gets the decoded block description
+func (block *TapBlock) Meta() *tape.BlockMeta {
+	meta := new(tape.BlockMeta)
+	header := new(tape.Header)
+	header.Type = block.data[block.header+tapHeaderType]
+	if header.Type&TapeFileCode != 0 {
+		header.TypeName = "Code"
+	} else {
+		header.TypeName = "Basic"
+	}
+	header.Name = block.Name()
+	header.Length = block.End() - block.Start() + 1
+	header.Param1 = block.Start()
+	header.Param2 = int(block.data[block.header+tapHeaderAuto])
+	meta.Header = header
+	meta.Description = fmt.Sprintf("File: %d bytes", len(block.data)-block.body)
+	return meta
+}
+
+// Tap implements the Oric tape format .TAP
+type Tap struct {
+	info   tape.Info    // Tape information
+	blocks []tape.Block // Block array
+	pilot  int          // Sync bytes left to play
+	gap    int          // Gap frames left to play
+	frame  uint16       // Current byte frame bits
+	bit    int          // Current byte frame bit
+	pulses int          // Half cycles left of the bit
+	length int          // Current half cycle length
+}
+
+// NewTap creates a new tape
+func NewTap() tape.Tape {
+	tap := new(Tap)
+	tap.blocks = make([]tape.Block, 0, 2)
+	return tap
+}
+
+// Info gets tape information
+func (tap *Tap) Info() *tape.Info { return &tap.info }
+
+// Blocks gets the tape blocks
+func (tap *Tap) Blocks() []tape.Block { return tap.blocks }
+
+// Load loads the tape file data
+func (tap *Tap) Load(data []byte) bool {
+	index := 0
+	for offset := 0; offset < len(data); {
+		// sync bytes & marker
+		pos := offset
+		for pos < len(data) && data[pos] == tapSyncByte {
+			pos++
+		}
+		if pos == offset || pos >= len(data) || data[pos] != tapSyncMarker {
+			break
+		}
+		block := new(TapBlock)
+		block.header = pos + 1 - offset
+		pos += 1 + tapHeaderSize
+		if pos > len(data) {
+			log.Print("Tape (TAP) : Invalid format: file header")
+			return false
+		}
+		// file name & data
+		for name := 0; pos < len(data) && data[pos] != 0 && name < tapNameSize; name++ {
+			pos++
+		}
+		if pos < len(data) && data[pos] == 0 {
+			pos++
+		}
+		block.body = pos - offset
+		block.data = data[offset:]
+		length := block.End() - block.Start() + 1
+		if length < 0 || pos+length > len(data) {
+			log.Print("Tape (TAP) : Invalid format: file length")
+			return false
+		}
+		pos += length
+		block.data = data[offset:pos]
+		block.Type = block.data[block.header+tapHeaderType]
+		block.Index = index
+		block.Offset = offset
+		block.Length = len(block.data)
+		tap.blocks = append(tap.blocks, block)
+		offset = pos
+		index++
+	}
+	if len(tap.blocks) == 0 {
+		log.Print("Tape (TAP) : Invalid format: no tape files")
+		return false
+	}
+	return true
+}
+
+// Play tap
+func (tap *Tap) Play(control *tape.Control) {
+	switch control.State {
+
+	case tapStateStart:
+		control.Block = tap.blocks[control.BlockIndex]
+		control.BlockPos = 0
+		block := control.Block.(*TapBlock)
+		log.Println("Tape (TAP) : File:", block.Meta())
+		tap.pilot = tapPilotBytes
+		tap.gap = 0
+		control.Ear = tape.LevelHigh
+		control.Timeout = tapTimingPause
+		control.State = tapStateByte
+
+	case tapStateByte:
+		block := control.Block.(*TapBlock)
+		switch {
+		case tap.pilot > 0:
+			tap.pilot--
+			tap.frame = tapFrame(tapSyncByte)
+		case tap.gap > 0:
+			tap.gap--
+			tap.frame = 0xffff
+		case control.EndOfBlock():
+			control.BlockIndex++
+			if control.EndOfTape() {
+				control.State = tapStateStop
+			} else {
+				control.State = tapStateStart
+			}
+			return
+		default:
+			tap.frame = tapFrame(control.DataAtPos())
+			control.BlockPos++
+			if control.BlockPos == block.body {
+				tap.gap = tapGapFrames // time to show the file name
+			}
+		}
+		tap.bit = 0
+		tap.pulses = 0
+		control.State = tapStateBit
+
+	case tapStateBit:
+		if tap.pulses == 0 {
+			if tap.bit == tapByteBits {
+				control.State = tapStateByte
+				break
+			}
+			if tap.frame&(1<<uint(tap.bit)) != 0 {
+				tap.length = tapTimingOne
+			} else {
+				tap.length = tapTimingZero
+			}
+			tap.pulses = 2
+			tap.bit++
+		}
+		control.Ear ^= tape.LevelMask
+		control.Timeout = tap.length
+		tap.pulses--
+
+	case tapStateStop:
+		control.Playing = false // Stop
+
+	default:
+		control.State = tapStateStop
+	}
+}
+
+// tapFrame builds the frame bits of a byte : start bit, data bits from the
+// lowest, odd parity and stop bits
+func tapFrame(data byte) uint16 {
+	parity := uint16(1)
+	for bits := data; bits != 0; bits >>= 1 {
+		parity ^= uint16(bits & 1)
+	}
+	return uint16(data)<<1 | parity<<9 | tapStopBits
+}
+
+// readString reads the printable characters to string
+func readString(data []byte) string {
+	chars := make([]byte, len(data))
+	for i, char := range data {
+		if char > 31 && char < 128 {
+			chars[i] = char
+		} else {
+			chars[i] = '?'
+		}
+	}
+	return strings.TrimRight(string(chars), " ")
+}
@@ -0,0 +1,35 @@
+package oric
+
+import "github.com/jtruco/emu8/emulator/machine"
+
+// Oric models
+var models = []machine.Model{
+	{Name: "Oric Atmos", Ids: []string{"Atmos", "OricAtmos"},
+		Build: func() machine.Machine { return New(OricAtmos) }, Roms: oricRomSets[OricAtmos]},
+	{Name: "Oric-1", Ids: []string{"Oric1", "Oric"},
+		Build: func() machine.Machine { return New(Oric1) }, Roms: oricRomSets[Oric1]},
+}
+
+// Oric ROM sets : the BASIC 1.0 (Oric-1) and 1.1b (Atmos) ROMs
+var oricRomSets = []*machine.RomSet{
+	Oric1: {
+		Slots: []machine.RomSlot{
+			{Name: "rom", Size: 0x4000, Default: "basic10"},
+		},
+		Images: []machine.RomImage{
+			{ID: "basic10", File: "basic10.rom", CRC32: 0xf18710b4},
+		},
+	},
+	OricAtmos: {
+		Slots: []machine.RomSlot{
+			{Name: "rom", Size: 0x4000, Default: "basic11b"},
+		},
+		Images: []machine.RomImage{
+			{ID: "basic11b", File: "basic11b.rom", CRC32: 0xc3a92bef},
+		},
+	},
+}
+
+func init() {
+	machine.RegisterModels(models)
+}
@@ -0,0 +1,333 @@
+package oric
+
+import (
+	"github.com/jtruco/emu8/emulator/device/io/keyboard"
+)
+
+// -----------------------------------------------------------------------------
+// Oric Keyboard
+// -----------------------------------------------------------------------------
+
+// Keyboard is the Oric keyboard matrix of 8 rows of 8 keys. The VIA port B
+// selects the row and the PSG port A the columns.
+type Keyboard struct {
+	rowstates [8]byte
+}
+
+// NewKeyboard creates a new keyboard
+func NewKeyboard() *Keyboard {
+	return new(Keyboard)
+}
+
+// Pressed checks if a key of the row is pressed in the selected columns
+// (active low)
+func (keyboard *Keyboard) Pressed(row, columns byte) bool {
+	return ^keyboard.rowstates[row&0x07]&^columns != 0
+}
+
+// Device
+
+// Init initializes the keyboard
+func (keyboard *Keyboard) Init() {
+	for row := range keyboard.rowstates {
+		keyboard.rowstates[row] = 0xff
+	}
+}
+
+// Reset resets the keyboard
+func (keyboard *Keyboard) Reset() { keyboard.Init() }
+
+// Keyboard
+
+// KeyMap returns the default keyboard mapping
+func (keyboard *Keyboard) KeyMap() keyboard.KeyMap { return oricKeyboardMap }
+
+// KeyNames returns the keys by name
+func (keyboard *Keyboard) KeyNames() map[string]keyboard.Key { return oricKeyNames }
+
+// ProcessKey processes Oric keyboard matrix
+func (keyboard *Keyboard) ProcessKey(key keyboard.Key, pressed bool) {
+	row := key >> 4
+	mask := uint8(1 << uint8(key&0x07))
+	if pressed {
+		keyboard.rowstates[row] &= ^mask
+	} else {
+		keyboard.rowstates[row] |= mask
+	}
+}
+
+// TextKeys returns the key combinations that type the text
+func (keyboard *Keyboard) TextKeys(text string) [][]keyboard.Key {
+	return oricTextKeys(text)
+}
+
+// -----------------------------------------------------------------------------
+// Oric Keys, States & Mapping
+// -----------------------------------------------------------------------------
+
+// Oric Keyboard Keys
+const (
+	OricKey7      = 0x00 // row 0, bit 0..bit 7
+	OricKeyN      = 0x01
+	OricKey5      = 0x02
+	OricKeyV      = 0x03
+	OricKey1      = 0x05
+	OricKeyX      = 0x06
+	OricKey3      = 0x07
+	OricKeyJ      = 0x10 // row 1
+	OricKeyT      = 0x11
+	OricKeyR      = 0x12
+	OricKeyF      = 0x13
+	OricKeyEsc    = 0x15
+	OricKeyQ      = 0x16
+	OricKeyD      = 0x17
+	OricKeyM      = 0x20 // row 2
+	OricKey6      = 0x21
+	OricKeyB      = 0x22
+	OricKey4      = 0x23
+	OricKeyCtrl   = 0x24
+	OricKeyZ      = 0x25
+	OricKey2      = 0x26
+	OricKeyC      = 0x27
+	OricKeyK      = 0x30 // row 3
+	OricKey9      = 0x31
+	OricKeySemi   = 0x32
+	OricKeyMinus  = 0x33
+	OricKeyBSlash = 0x36
+	OricKeyQuote  = 0x37
+	OricKeySpace  = 0x40 // row 4
+	OricKeyComma  = 0x41
+	OricKeyDot    = 0x42
+	OricKeyUp     = 0x43
+	OricKeyLShift = 0x44
+	OricKeyLeft   = 0x45
+	OricKeyDown   = 0x46
+	OricKeyRight  = 0x47
+	OricKeyU      = 0x50 // row 5
+	OricKeyI      = 0x51
+	OricKeyO      = 0x52
+	OricKeyP      = 0x53
+	OricKeyFunct  = 0x54
+	OricKeyDel    = 0x55
+	OricKeyRBrack = 0x56
+	OricKeyLBrack = 0x57
+	OricKeyY      = 0x60 // row 6
+	OricKeyH      = 0x61
+	OricKeyG      = 0x62
+	OricKeyE      = 0x63
+	OricKeyA      = 0x65
+	OricKeyS      = 0x66
+	OricKeyW      = 0x67
+	OricKey8      = 0x70 // row 7
+	OricKeyL      = 0x71
+	OricKey0      = 0x72
+	OricKeySlash  = 0x73
+	OricKeyRShift = 0x74
+	OricKeyReturn = 0x75
+	OricKeyEquals = 0x77
+)
+
+// oricKeyNames Oric keys by name
+var oricKeyNames = map[string]keyboard.Key{
+	"0":            OricKey0,
+	"1":            OricKey1,
+	"2":            OricKey2,
+	"3":            OricKey3,
+	"4":            OricKey4,
+	"5":            OricKey5,
+	"6":            OricKey6,
+	"7":            OricKey7,
+	"8":            OricKey8,
+	"9":            OricKey9,
+	"Minus":        OricKeyMinus,
+	"Equals":       OricKeyEquals,
+	"Backslash":    OricKeyBSlash,
+	"OpenBracket":  OricKeyLBrack,
+	"CloseBracket": OricKeyRBrack,
+	"Semicolon":    OricKeySemi,
+	"Quote":        OricKeyQuote,
+	"Comma":        OricKeyComma,
+	"Dot":          OricKeyDot,
+	"Slash":        OricKeySlash,
+	"A":            OricKeyA,
+	"B":            OricKeyB,
+	"C":            OricKeyC,
+	"D":            OricKeyD,
+	"E":            OricKeyE,
+	"F":            OricKeyF,
+	"G":            OricKeyG,
+	"H":            OricKeyH,
+	"I":            OricKeyI,
+	"J":            OricKeyJ,
+	"K":            OricKeyK,
+	"L":            OricKeyL,
+	"M":            OricKeyM,
+	"N":            OricKeyN,
+	"O":            OricKeyO,
+	"P":            OricKeyP,
+	"Q":            OricKeyQ,
+	"R":            OricKeyR,
+	"S":            OricKeyS,
+	"T":            OricKeyT,
+	"U":            OricKeyU,
+	"V":            OricKeyV,
+	"W":            OricKeyW,
+	"X":            OricKeyX,
+	"Y":            OricKeyY,
+	"Z":            OricKeyZ,
+	"LeftShift":    OricKeyLShift,
+	"RightShift":   OricKeyRShift,
+	"Ctrl":         OricKeyCtrl,
+	"Funct":        OricKeyFunct,
+	"Esc":          OricKeyEsc,
+	"Del":          OricKeyDel,
+	"Return":       OricKeyReturn,
+	"Space":        OricKeySpace,
+	"Left":         OricKeyLeft,
+	"Up":           OricKeyUp,
+	"Down":         OricKeyDown,
+	"Right":        OricKeyRight,
+}
+
+// Oric Keyboard map
+var oricKeyboardMap = map[keyboard.KeyCode][]keyboard.Key{
+	// alphanum
+	keyboard.Key0: {OricKey0},
+	keyboard.Key1: {OricKey1},
+	keyboard.Key2: {OricKey2},
+	keyboard.Key3: {OricKey3},
+	keyboard.Key4: {OricKey4},
+	keyboard.Key5: {OricKey5},
+	keyboard.Key6: {OricKey6},
+	keyboard.Key7: {OricKey7},
+	keyboard.Key8: {OricKey8},
+	keyboard.Key9: {OricKey9},
+	keyboard.KeyA: {OricKeyA},
+	keyboard.KeyB: {OricKeyB},
+	keyboard.KeyC: {OricKeyC},
+	keyboard.KeyD: {OricKeyD},
+	keyboard.KeyE: {OricKeyE},
+	keyboard.KeyF: {OricKeyF},
+	keyboard.KeyG: {OricKeyG},
+	keyboard.KeyH: {OricKeyH},
+	keyboard.KeyI: {OricKeyI},
+	keyboard.KeyJ: {OricKeyJ},
+	keyboard.KeyK: {OricKeyK},
+	keyboard.KeyL: {OricKeyL},
+	keyboard.KeyM: {OricKeyM},
+	keyboard.KeyN: {OricKeyN},
+	keyboard.KeyO: {OricKeyO},
+	keyboard.KeyP: {OricKeyP},
+	keyboard.KeyQ: {OricKeyQ},
+	keyboard.KeyR: {OricKeyR},
+	keyboard.KeyS: {OricKeyS},
+	keyboard.KeyT: {OricKeyT},
+	keyboard.KeyU: {OricKeyU},
+	keyboard.KeyV: {OricKeyV},
+	keyboard.KeyW: {OricKeyW},
+	keyboard.KeyX: {OricKeyX},
+	keyboard.KeyY: {OricKeyY},
+	keyboard.KeyZ: {OricKeyZ},
+	// symbols
+	keyboard.KeySpace:        {OricKeySpace},
+	keyboard.KeyMinus:        {OricKeyMinus},
+	keyboard.KeyEquals:       {OricKeyEquals},
+	keyboard.KeyBackSlash:    {OricKeyBSlash},
+	keyboard.KeyLeftBracket:  {OricKeyLBrack},
+	keyboard.KeyRightBracket: {OricKeyRBrack},
+	keyboard.KeySemicolon:    {OricKeySemi},
+	keyboard.KeyApostrophe:   {OricKeyQuote},
+	keyboard.KeyComma:        {OricKeyComma},
+	keyboard.KeyPeriod:       {OricKeyDot},
+	keyboard.KeySlash:        {OricKeySlash},
+	// special
+	keyboard.KeyReturn:    {OricKeyReturn},
+	keyboard.KeyEscape:    {OricKeyEsc},
+	keyboard.KeyBackspace: {OricKeyDel},
+	keyboard.KeyDelete:    {OricKeyDel},
+	// cursors
+	keyboard.KeyUp:    {OricKeyUp},
+	keyboard.KeyDown:  {OricKeyDown},
+	keyboard.KeyLeft:  {OricKeyLeft},
+	keyboard.KeyRight: {OricKeyRight},
+	// keypad
+	keyboard.KeyPad0:        {OricKey0},
+	keyboard.KeyPad1:        {OricKey1},
+	keyboard.KeyPad2:        {OricKey2},
+	keyboard.KeyPad3:        {OricKey3},
+	keyboard.KeyPad4:        {OricKey4},
+	keyboard.KeyPad5:        {OricKey5},
+	keyboard.KeyPad6:        {OricKey6},
+	keyboard.KeyPad7:        {OricKey7},
+	keyboard.KeyPad8:        {OricKey8},
+	keyboard.KeyPad9:        {OricKey9},
+	keyboard.KeyPadMultiply: {OricKeyLShift, OricKey8},
+	keyboard.KeyPadPlus:     {OricKeyLShift, OricKeyEquals},
+	keyboard.KeyPadDivide:   {OricKeySlash},
+	keyboard.KeyPadMinus:    {OricKeyMinus},
+	keyboard.KeyPadPeriod:   {OricKeyDot},
+	keyboard.KeyPadEnter:    {OricKeyReturn},
+	// shift, control & funct
+	keyboard.KeyLShift: {OricKeyLShift},
+	keyboard.KeyRShift: {OricKeyRShift},
+	keyboard.KeyLCtrl:  {OricKeyCtrl},
+	keyboard.KeyRCtrl:  {OricKeyCtrl},
+	keyboard.KeyLAlt:   {OricKeyFunct},
+	keyboard.KeyRAlt:   {OricKeyFunct},
+}
+
+// -----------------------------------------------------------------------------
+// Oric Text Typing
+// -----------------------------------------------------------------------------
+
+// oricSymbolKeys Oric keys of symbols, unshifted and shifted
+var oricSymbolKeys = map[keyboard.Key]string{
+	OricKey1: "1!", OricKey2: "2@", OricKey3: "3#", OricKey4: "4$", OricKey5: "5%",
+	OricKey6: "6^", OricKey7: "7&", OricKey8: "8*", OricKey9: "9(", OricKey0: "0)",
+	OricKeyMinus: "-£", OricKeyEquals: "=+", OricKeyBSlash: "\\|",
+	OricKeyLBrack: "[{", OricKeyRBrack: "]}", OricKeySemi: ";:",
+	OricKeyQuote: "'\"", OricKeyComma: ",<", OricKeyDot: ".>", OricKeySlash: "/?",
+}
+
+// oricLetterKeys Oric keys of letters from A to Z
+var oricLetterKeys = [...]keyboard.Key{
+	OricKeyA, OricKeyB, OricKeyC, OricKeyD, OricKeyE, OricKeyF, OricKeyG,
+	OricKeyH, OricKeyI, OricKeyJ, OricKeyK, OricKeyL, OricKeyM, OricKeyN,
+	OricKeyO, OricKeyP, OricKeyQ, OricKeyR, OricKeyS, OricKeyT, OricKeyU,
+	OricKeyV, OricKeyW, OricKeyX, OricKeyY, OricKeyZ,
+}
+
+// oricKeyCombinations key combinations by character
+var oricKeyCombinations = make(map[rune][]keyboard.Key)
+
+func init() {
+	for key, symbols := range oricSymbolKeys {
+		runes := []rune(symbols)
+		oricKeyCombinations[runes[0]] = []keyboard.Key{key}
+		oricKeyCombinations[runes[1]] = []keyboard.Key{OricKeyLShift, key}
+	}
+	for i, key := range oricLetterKeys {
+		oricKeyCombinations[rune('a'+i)] = []keyboard.Key{key}
+		oricKeyCombinations[rune('A'+i)] = []keyboard.Key{key}
+	}
+	oricKeyCombinations[' '] = []keyboard.Key{OricKeySpace}
+	oricKeyCombinations['\n'] = []keyboard.Key{OricKeyReturn}
+}
+
+// oricTextKeys returns the key combinations that type the text. The BASIC
+// starts with CAPS on, letters are typed without shift. Lines are followed
+// by a pause for the BASIC editor.
+func oricTextKeys(text string) [][]keyboard.Key {
+	keys := make([][]keyboard.Key, 0, len(text))
+	for _, r := range text {
+		combination, ok := oricKeyCombinations[r]
+		if !ok {
+			continue
+		}
+		keys = append(keys, combination)
+		if r == '\n' {
+			keys = append(keys, keyboard.TypistPause, keyboard.TypistPause)
+		}
+	}
+	return keys
+}
@@ -0,0 +1,52 @@
+package oric
+
+import (
+	"github.com/jtruco/emu8/emulator/device/cpu/m6502"
+)
+
+// -----------------------------------------------------------------------------
+// Oric - Tape loading
+// -----------------------------------------------------------------------------
+
+// Oric Atmos ROM V1.1 tape routines
+const (
+	oricSync     = 0xe735 // Synchronizes with the tape file sync bytes
+	oricReadByte = 0xe6c9 // Reads a byte from tape
+	oricTapeByte = 0x002f // Byte read
+	oricParity   = 0x02b1 // Parity error count
+)
+
+// isLoaderTrap checks if the CPU is entering a ROM tape read routine
+func (oric *Oric) isLoaderTrap() bool {
+	if !oric.fastload || !oric.tape.HasTape() || oric.config.Model != OricAtmos {
+		return false
+	}
+	pc := oric.cpu.PC
+	return pc == oricSync || pc == oricReadByte
+}
+
+// loaderTrap emulates the ROM tape routines, reading the tape files
+// directly. The sync routine starts the next file, the byte routine reads
+// a byte of the file header, name or data into A.
+func (oric *Oric) loaderTrap() {
+	cpu := oric.cpu
+	if cpu.PC == oricSync {
+		oric.tapData = oric.tape.NextDataBlock()
+		oric.tapPos = 0
+	} else {
+		data := byte(0)
+		if oric.tapPos < len(oric.tapData) {
+			data = oric.tapData[oric.tapPos]
+			oric.tapPos++
+		}
+		cpu.A = data
+		cpu.P &^= m6502.FlagN | m6502.FlagZ
+		cpu.P |= data & m6502.FlagN
+		if data == 0 {
+			cpu.P |= m6502.FlagZ
+		}
+		oric.memory.Poke(oricTapeByte, data)
+		oric.memory.Poke(oricParity, 0)
+	}
+	cpu.Rts()
+}
@@ -0,0 +1,314 @@
+// Package oric implements the Oric machines
+package oric
+
+import (
+	"log"
+
+	"github.com/jtruco/emu8/emulator/config"
+	"github.com/jtruco/emu8/emulator/device"
+	"github.com/jtruco/emu8/emulator/device/audio"
+	"github.com/jtruco/emu8/emulator/device/bus"
+	"github.com/jtruco/emu8/emulator/device/cpu"
+	"github.com/jtruco/emu8/emulator/device/cpu/m6502"
+	"github.com/jtruco/emu8/emulator/device/io/pio"
+	"github.com/jtruco/emu8/emulator/device/io/tape"
+	"github.com/jtruco/emu8/emulator/device/memory"
+	"github.com/jtruco/emu8/emulator/machine"
+	"github.com/jtruco/emu8/emulator/machine/oric/format"
+)
+
+// -----------------------------------------------------------------------------
+// Oric
+// -----------------------------------------------------------------------------
+
+// Oric models
+const (
+	Oric1 = iota
+	OricAtmos
+)
+
+// Default Oric constants
+const (
+	oricFPS          = 50                        // 50 Hz
+	oricTStates      = ulaLines * ulaLineTstates // TStates per frame (1 MHz)
+	oricAudioTStates = oricTStates >> 3          // Audio TStates (1 MHz / 8)
+	oricIOPage       = 0x03                      // IO page (0x0300-0x03ff)
+	oricROMAddress   = 0xc000                    // ROM address
+	oricMemoryRAM    = 0                         // RAM memory map
+	oricMemoryROM    = 1                         // ROM memory map
+	oricMemoryVIA    = 2                         // VIA memory map
+	oricMemoryBanks  = 3
+)
+
+// VIA port B lines
+const (
+	oricKeyRow   = 0x07 // Keyboard row select
+	oricKeySense = 0x08 // Keyboard sense input
+	oricMotor    = 0x40 // Tape motor relay
+)
+
+// Oric is an Oric-1 or Oric Atmos computer
+type Oric struct {
+	config     machine.Config      // Machine information
+	control    machine.Control     // The emulator controller
+	components *device.Components  // Machine device components
+	clock      *device.ClockDevice // The system clock
+	cpu        *m6502.M6502        // The MOS 6502A CPU
+	memory     *memory.Memory      // The machine memory
+	via        *pio.VIA6522        // The 6522 Versatile Interface Adapter
+	ula        *ULA                // The ULA video
+	psg        *audio.AY38910      // The AY-3-8912 Programmable Sound Generator
+	keyboard   *Keyboard           // The keyboard
+	tape       *tape.Drive         // The tape drive
+	fastload   bool                // Tape fast loading
+	tapData    []byte              // Tape file read by the ROM
+	tapPos     int                 // Tape file position
+	viaTstates int                 // VIA emulated tstate
+	psgCycles  int                 // PSG cycles emulated in the frame
+}
+
+// New returns a new Oric
+func New(model int) machine.Machine {
+	oric := new(Oric)
+	oric.config.Model = model
+	oric.config.SetTimings(oricTStates, oricFPS)
+	// devices
+	oric.clock = device.NewClock()
+	oric.via = pio.NewVIA6522()
+	oric.via.OnReadPortA = oric.onViaReadPortA
+	oric.via.OnReadPortB = oric.onViaReadPortB
+	oric.via.OnWritePortA = oric.onViaWritePortA
+	oric.via.OnWritePortB = oric.onViaWritePortB
+	oric.via.OnControl = oric.updatePSG
+	// memory map : RAM, ROM & VIA
+	oric.memory = memory.New(oricMemoryBanks)
+	oric.memory.SetMap(oricMemoryRAM, memory.NewRAM(0x0000, memory.Size48K))
+	oric.memory.SetMap(oricMemoryROM, memory.NewROM(oricROMAddress, memory.Size16K))
+	viaMap := bus.NewMap(oric.via, uint16(oricIOPage)<<8, memory.Size256B, true, false)
+	viaMap.OnAccess = oric.onViaAccess
+	oric.memory.SetMap(oricMemoryVIA, viaMap)
+	oric.memory.SetMapper(new(memoryMapper))
+	oric.cpu = m6502.New(oric.clock, oric.memory)
+	oric.ula = NewULA(oric)
+	oric.psg = audio.NewAY38910(
+		audio.NewConfig(config.Get().Audio.Frequency, oricFPS, oricAudioTStates))
+	oric.keyboard = NewKeyboard()
+	oric.tape = tape.New(oric.clock)
+	oric.tape.SetAutoPlay(config.Get().Tape.AutoStart)
+	oric.fastload = config.Get().Tape.FastLoad
+	// register all components
+	oric.components = device.NewComponents()
+	oric.components.Add(oric.clock)
+	oric.components.Add(oric.psg)
+	oric.components.Add(oric.memory)
+	oric.components.Add(oric.cpu)
+	oric.components.Add(oric.ula)
+	oric.components.Add(oric.keyboard)
+	oric.components.Add(oric.tape)
+	return oric
+}
+
+// Device interface
+
+// Init initializes the machine
+func (oric *Oric) Init() {
+	oric.components.Init()
+	oric.initOric()
+}
+
+// Reset resets the machine
+func (oric *Oric) Reset() {
+	oric.components.Reset()
+	oric.initOric()
+}
+
+// initOric common init tasks
+func (oric *Oric) initOric() {
+	oric.tapData = nil
+	oric.viaTstates = oric.clock.Tstates()
+	oric.psgCycles = 0
+	roms := oricRomSets[oric.config.Model]
+	data, err := roms.Load(oric.control, "rom")
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+	oric.memory.Bank(oricMemoryROM).Load(0, data)
+}
+
+// Machine properties
+
+// Clock gets the machine clock
+func (oric *Oric) Clock() device.Clock { return oric.clock }
+
+// Config gets the machine info
+func (oric *Oric) Config() *machine.Config { return &oric.config }
+
+// CPU gets the machine CPU
+func (oric *Oric) CPU() cpu.CPU { return oric.cpu }
+
+// Components gets the machine components
+func (oric *Oric) Components() *device.Components { return oric.components }
+
+// InitControl connect controllers & components
+func (oric *Oric) InitControl(control machine.Control) {
+	// Bind devices
+	control.BindVideo(oric.ula)
+	control.BindAudio(oric.psg)
+	control.BindKeyboard(oric.keyboard)
+	control.BindTapeDrive(oric.tape)
+	// Register formats
+	control.RegisterTape(format.TAP, format.NewTap)
+	oric.control = control
+}
+
+// Emulation control
+
+// BeginFrame begin emulation frame tasks
+func (oric *Oric) BeginFrame() {
+	oric.psgCycles = 0
+	oric.viaTstates -= oricTStates // the clock restarts the frame
+}
+
+// Emulate one machine step
+func (oric *Oric) Emulate() {
+	// Tape fast loading
+	if oric.isLoaderTrap() {
+		oric.loaderTrap()
+		return
+	}
+
+	// Executes a CPU instruction
+	tstates := oric.cpu.Execute()
+
+	// VIA timers & interrupt
+	oric.syncVIA()
+	oric.cpu.InterruptRequest(oric.via.IntRequest())
+
+	// TV beam
+	oric.ula.Update()
+
+	// Tape emulation : tape input on CB1
+	oric.tape.Emulate(tstates)
+	oric.via.SetCB1(oric.tape.EarHigh())
+}
+
+// EndFrame end emulation frame tasks
+func (oric *Oric) EndFrame() {
+	oric.syncVIA()
+	oric.emulatePSG()
+}
+
+// syncVIA emulates the VIA up to the current tstate
+func (oric *Oric) syncVIA() {
+	tstates := oric.clock.Tstates()
+	oric.via.Emulate(tstates - oric.viaTstates)
+	oric.viaTstates = tstates
+}
+
+// emulatePSG emulates the PSG up to the current tstate
+func (oric *Oric) emulatePSG() {
+	tstates := oric.clock.Tstates()
+	if tstates > oricTStates {
+		tstates = oricTStates
+	}
+	cycles := tstates - oric.psgCycles
+	if cycles > 0 {
+		oric.psg.Emulate(cycles)
+		oric.psgCycles += cycles
+	}
+}
+
+// VIA connections
+// -----------------------------------------------------------------------------
+
+// onViaAccess syncs the VIA timers before a register access
+func (oric *Oric) onViaAccess(code int, address uint16) { oric.syncVIA() }
+
+// onViaReadPortA reads the PSG data bus
+func (oric *Oric) onViaReadPortA() byte {
+	if oric.psg.Control() == audio.AY38910ReadRegister {
+		return oric.psg.Read()
+	}
+	return 0xff
+}
+
+// onViaWritePortA writes the PSG data bus
+func (oric *Oric) onViaWritePortA(data byte) { oric.updatePSG() }
+
+// onViaReadPortB reads the keyboard sense line of the selected row and the
+// PSG port A columns
+func (oric *Oric) onViaReadPortB() byte {
+	row := oric.via.OutputB() & oricKeyRow
+	columns := oric.psg.Register(audio.AY38910DataPortA)
+	if oric.keyboard.Pressed(row, columns) {
+		return 0xff
+	}
+	return ^byte(oricKeySense)
+}
+
+// onViaWritePortB sets the tape motor
+func (oric *Oric) onViaWritePortB(data byte) {
+	oric.tape.SetMotor(data&oricMotor != 0)
+}
+
+// updatePSG updates the PSG bus control lines : BC1 is CA2 and BDIR is CB2
+func (oric *Oric) updatePSG() {
+	control := byte(audio.AY38910Inactive)
+	if oric.via.CA2() {
+		control |= audio.AY38910ReadRegister
+	}
+	if oric.via.CB2() {
+		control |= audio.AY38910WriteRegister
+	}
+	oric.psg.SetControl(control << 6)
+	if control == audio.AY38910WriteRegister {
+		oric.emulatePSG()
+	}
+	oric.psg.Write(oric.via.OutputA())
+}
+
+// Snapshots : load & save state
+
+// LoadState loads a snapshot. Programs are loaded from tape.
+func (oric *Oric) LoadState(state machine.State) {
+	log.Println("Oric : Not implemented snap format:", state.Format)
+}
+
+// SaveState snapshots are not implemented
+func (oric *Oric) SaveState() machine.State {
+	log.Println("Oric : Snapshots not implemented")
+	return machine.State{}
+}
+
+// -----------------------------------------------------------------------------
+// Oric - Memory mapping
+// -----------------------------------------------------------------------------
+
+// memoryMapper maps the Oric memory : the RAM, the VIA registers mirrored in
+// the IO page and the ROM, that hides the upper RAM.
+type memoryMapper struct {
+	maps bus.Maps // Memory maps
+}
+
+// Init inits the mapper
+func (mapper *memoryMapper) Init(maps bus.Maps) { mapper.maps = maps }
+
+// Select selects the map at address for read access
+func (mapper *memoryMapper) Select(address uint16) (*bus.Map, uint16) {
+	switch {
+	case address>>8 == oricIOPage:
+		return mapper.maps[oricMemoryVIA], address & 0x0f
+	case address < oricROMAddress:
+		return mapper.maps[oricMemoryRAM], address
+	}
+	return mapper.maps[oricMemoryROM], address - oricROMAddress
+}
+
+// SelectWrite selects the map at address for write access
+func (mapper *memoryMapper) SelectWrite(address uint16) (*bus.Map, uint16) {
+	if address >= oricROMAddress {
+		return nil, 0
+	}
+	return mapper.Select(address)
+}
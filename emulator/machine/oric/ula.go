@@ -0,0 +1,201 @@
+package oric
+
+import (
+	"github.com/jtruco/emu8/emulator/device/video"
+)
+
+// -----------------------------------------------------------------------------
+// ULA constants & vars
+// -----------------------------------------------------------------------------
+
+// Video screen constants
+const (
+	ulaScreenWidth  = 240
+	ulaScreenHeight = 224
+	ulaBorderLeft   = 24
+	ulaBorderTop    = 24
+	ulaTotalWidth   = ulaScreenWidth + 2*ulaBorderLeft
+	ulaTotalHeight  = ulaScreenHeight + 2*ulaBorderTop
+	ulaLineTstates  = 64                          // TStates per line (64 us)
+	ulaLines        = 312                         // Lines per frame (50 Hz)
+	ulaFirstLine    = 40                          // First display line
+	ulaTopLine      = ulaFirstLine - ulaBorderTop // First visible line
+	ulaBottomLine   = ulaTopLine + ulaTotalHeight // Last visible line
+	ulaColumns      = 40                          // Cells per line
+	ulaHiresLines   = 200                         // HIRES lines, then 3 text rows
+	ulaBlinkMask    = 0x10                        // Frames of the blink phase
+)
+
+// ULA video memory addresses
+const (
+	ulaTextScreen  = 0xbb80 // TEXT screen
+	ulaTextChars   = 0xb400 // TEXT standard character set
+	ulaHiresScreen = 0xa000 // HIRES screen
+	ulaHiresText   = 0xbf68 // HIRES text rows
+	ulaHiresChars  = 0x9800 // HIRES standard character set
+	ulaAltChars    = 0x0400 // Alternate character set offset
+)
+
+// ULA serial attributes : bits 6-5 are 0, bits 4-3 the attribute type
+const (
+	ulaAttrInk     = 0x00 // Ink colour
+	ulaAttrCharset = 0x08 // Charset : double height, alternate & blink
+	ulaAttrPaper   = 0x10 // Paper colour
+	ulaAttrMode    = 0x18 // Video mode : HIRES
+	ulaDouble      = 0x01
+	ulaAlternate   = 0x02
+	ulaBlink       = 0x04
+	ulaHires       = 0x04
+)
+
+// Oric RGBA colour palette : black, red, green, yellow, blue, magenta, cyan
+// and white
+var oricPaletteRGBA = []uint32{
+	0xff000000, 0xff0000ff, 0xff00ff00, 0xff00ffff,
+	0xffff0000, 0xffff00ff, 0xffffff00, 0xffffffff}
+
+// -----------------------------------------------------------------------------
+// ULA - Oric video
+// -----------------------------------------------------------------------------
+
+// ULA is the Oric video logic. Each line is a row of 40 cells of 6 pixels,
+// the bytes are pixels or characters and serial attributes that change the
+// colours and modes for the rest of the line. The video mode attribute
+// switches between the TEXT and HIRES screens. The lines are painted as the
+// TV beam passes them.
+type ULA struct {
+	oric   *Oric         // The Oric machine
+	screen *video.Screen // The video screen
+	line   int           // Next line to paint
+	hires  bool          // HIRES mode
+	frames int           // Frame counter, for blinking
+}
+
+// NewULA creates the ULA
+func NewULA(oric *Oric) *ULA {
+	ula := new(ULA)
+	ula.oric = oric
+	ula.screen = video.NewScreen(ulaTotalWidth, ulaTotalHeight, oricPaletteRGBA)
+	return ula
+}
+
+// Device
+
+// Init initializes video device
+func (ula *ULA) Init() { ula.Reset() }
+
+// Reset resets video device
+func (ula *ULA) Reset() {
+	ula.screen.Clear(0)
+	ula.line = 0
+	ula.hires = false
+	ula.frames = 0
+}
+
+// Video
+
+// EndFrame paints the remaining lines of the frame
+func (ula *ULA) EndFrame() {
+	ula.paintLines(ulaBottomLine)
+	ula.line = 0
+	ula.frames++
+}
+
+// Screen the video screen
+func (ula *ULA) Screen() *video.Screen { return ula.screen }
+
+// Painting
+
+// Update paints the lines passed by the TV beam
+func (ula *ULA) Update() {
+	ula.paintLines(ula.oric.clock.Tstates() / ulaLineTstates)
+}
+
+// paintLines paints the lines up to the last line
+func (ula *ULA) paintLines(last int) {
+	if last > ulaBottomLine {
+		last = ulaBottomLine
+	}
+	for ; ula.line < last; ula.line++ {
+		if ula.line >= ulaTopLine {
+			ula.paintLine(ula.line - ulaTopLine)
+		}
+	}
+}
+
+// paintLine paints a screen line
+func (ula *ULA) paintLine(y int) {
+	line := y - ulaBorderTop
+	if line < 0 || line >= ulaScreenHeight {
+		for x := 0; x < ulaTotalWidth; x++ {
+			ula.screen.SetPixelIndex(x, y, 0)
+		}
+		return
+	}
+	for x := 0; x < ulaBorderLeft; x++ {
+		ula.screen.SetPixelIndex(x, y, 0)
+		ula.screen.SetPixelIndex(ulaTotalWidth-1-x, y, 0)
+	}
+	// line address and character set
+	hires := ula.hires && line < ulaHiresLines
+	var address, chars uint16
+	switch {
+	case hires:
+		address = ulaHiresScreen + uint16(line*ulaColumns)
+	case ula.hires:
+		address = ulaHiresText + uint16((line-ulaHiresLines)>>3*ulaColumns)
+		chars = ulaHiresChars
+	default:
+		address = ulaTextScreen + uint16(line>>3*ulaColumns)
+		chars = ulaTextChars
+	}
+	// serial attributes
+	ink, paper, charset := 7, 0, byte(0)
+	blinkOff := ula.frames&ulaBlinkMask != 0
+	memory := ula.oric.memory
+	x := ulaBorderLeft
+	for col := 0; col < ulaColumns; col++ {
+		data := memory.Peek(address + uint16(col))
+		pattern := byte(0)
+		if data&0x60 == 0 {
+			value := data & 0x07
+			switch data & 0x18 {
+			case ulaAttrInk:
+				ink = int(value)
+			case ulaAttrCharset:
+				charset = value
+			case ulaAttrPaper:
+				paper = int(value)
+			case ulaAttrMode:
+				ula.hires = value&ulaHires != 0
+			}
+		} else if hires {
+			pattern = data
+		} else {
+			row := line & 0x07
+			if charset&ulaDouble != 0 {
+				row = (line>>3&0x01<<3 | row) >> 1
+			}
+			base := chars
+			if charset&ulaAlternate != 0 {
+				base += ulaAltChars
+			}
+			pattern = memory.Peek(base + uint16(data&0x7f)<<3 + uint16(row))
+		}
+		if charset&ulaBlink != 0 && blinkOff {
+			pattern = 0
+		}
+		fg, bg := ink, paper
+		if data&0x80 != 0 { // inverse video
+			fg, bg = fg^0x07, bg^0x07
+		}
+		for mask := byte(0x20); mask != 0; mask >>= 1 {
+			if pattern&mask != 0 {
+				ula.screen.SetPixelIndex(x, y, fg)
+			} else {
+				ula.screen.SetPixelIndex(x, y, bg)
+			}
+			x++
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package machine
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator/config"
+)
+
+// -----------------------------------------------------------------------------
+// ROM sets
+// -----------------------------------------------------------------------------
+
+// romHeaderSize is the size of the AMSDOS header of some ROM files
+const romHeaderSize = 128
+
+// RomImage is a known ROM image, identified by its checksums
+type RomImage struct {
+	ID    string // Image ID
+	File  string // Default file name
+	CRC32 uint32 // CRC32 checksum
+	SHA1  string // SHA1 checksum (hex), empty if validated by CRC32 only
+}
+
+// matches checks the image checksums
+func (image *RomImage) matches(crc uint32, sha string) bool {
+	return crc == image.CRC32 && (image.SHA1 == "" || sha == image.SHA1)
+}
+
+// RomSlot is a machine ROM slot
+type RomSlot struct {
+	Name    string // Slot name
+	Size    int    // Slot size
+	Default string // Default image ID, empty for optional slots
+}
+
+// RomSet is the ROM set of a machine model : its slots and known images.
+// Any slot can be overridden with the "rom.<slot>" machine option, as an
+// image ID, an image checksum (crc32:hex, sha1:hex) or a file path.
+type RomSet struct {
+	Slots  []RomSlot  // ROM slots
+	Images []RomImage // Known ROM images
+}
+
+// FindRomSet returns the ROM set of a model
+func FindRomSet(modelID string) *RomSet {
+	model := FindModel(modelID)
+	if model == nil {
+		return nil
+	}
+	return model.Roms
+}
+
+// Slot returns a slot by name
+func (set *RomSet) Slot(name string) *RomSlot {
+	for i := range set.Slots {
+		if strings.EqualFold(set.Slots[i].Name, name) {
+			return &set.Slots[i]
+		}
+	}
+	return nil
+}
+
+// Image finds a known image by ID or checksum (crc32:hex, sha1:hex)
+func (set *RomSet) Image(id string) *RomImage {
+	lower := strings.ToLower(id)
+	for i := range set.Images {
+		image := &set.Images[i]
+		switch {
+		case strings.HasPrefix(lower, "crc32:"):
+			crc, err := strconv.ParseUint(lower[6:], 16, 32)
+			if err == nil && uint32(crc) == image.CRC32 {
+				return image
+			}
+		case strings.HasPrefix(lower, "sha1:"):
+			if image.SHA1 != "" && lower[5:] == image.SHA1 {
+				return image
+			}
+		case strings.EqualFold(id, image.ID):
+			return image
+		}
+	}
+	return nil
+}
+
+// Identify finds the known image of ROM data
+func (set *RomSet) Identify(data []byte) *RomImage {
+	crc, sha := crc32.ChecksumIEEE(data), romSHA1(data)
+	for i := range set.Images {
+		if set.Images[i].matches(crc, sha) {
+			return &set.Images[i]
+		}
+	}
+	return nil
+}
+
+// Selection returns the image selected for a slot, the "rom.<slot>" machine
+// option or the slot default
+func (set *RomSet) Selection(slot string) string {
+	if value := config.Get().Machine.Option("rom." + slot); value != "" {
+		return value
+	}
+	if s := set.Slot(slot); s != nil {
+		return s.Default
+	}
+	return ""
+}
+
+// Load loads the selected ROM of a slot. Empty optional slots return no
+// data and no error.
+func (set *RomSet) Load(control Control, slot string) ([]byte, error) {
+	return set.LoadImage(control, slot, set.Selection(slot))
+}
+
+// LoadImage loads a ROM image (ID, checksum or file path) for a slot. Known
+// images are validated by their CRC32 and SHA1 checksums.
+func (set *RomSet) LoadImage(control Control, slot, selection string) ([]byte, error) {
+	s := set.Slot(slot)
+	if s == nil {
+		return nil, fmt.Errorf("ROM : unknown ROM slot: %s", slot)
+	}
+	if selection == "" {
+		return nil, nil
+	}
+	image := set.Image(selection)
+	filename := selection
+	if image != nil {
+		filename = image.File
+	} else if lower := strings.ToLower(selection); strings.HasPrefix(lower, "crc32:") || strings.HasPrefix(lower, "sha1:") {
+		return nil, fmt.Errorf("ROM : unknown ROM image for slot %s: %s", slot, selection)
+	}
+	data, err := control.LoadROM(filename)
+	if err != nil {
+		return nil, fmt.Errorf("ROM : could not load ROM %s for slot %s: %s", filename, slot, err.Error())
+	}
+	if len(data) == s.Size+romHeaderSize {
+		data = data[romHeaderSize:] // skip AMSDOS header
+	}
+	if len(data) == 0 || len(data) > s.Size {
+		return nil, fmt.Errorf("ROM : invalid ROM size of %s for slot %s: %d bytes, expected %d", filename, slot, len(data), s.Size)
+	}
+	crc, sha := crc32.ChecksumIEEE(data), romSHA1(data)
+	if image != nil {
+		if !image.matches(crc, sha) {
+			return nil, fmt.Errorf("ROM : checksum mismatch of %s for slot %s: CRC32 %08x, expected %08x (%s)", filename, slot, crc, image.CRC32, image.ID)
+		}
+	} else if known := set.Identify(data); known != nil {
+		log.Printf("ROM : Slot %s loaded with %s (%s)", slot, filename, known.ID)
+	} else {
+		log.Printf("ROM : Slot %s loaded with custom ROM %s, CRC32 %08x, SHA1 %s", slot, filename, crc, sha)
+	}
+	if len(data) < s.Size { // fill unused space
+		rom := make([]byte, s.Size)
+		for i := copy(rom, data); i < len(rom); i++ {
+			rom[i] = 0xff
+		}
+		data = rom
+	}
+	return data, nil
+}
+
+// romSHA1 returns the hex SHA1 checksum of data
+func romSHA1(data []byte) string {
+	sum := sha1.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
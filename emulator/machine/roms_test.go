@@ -0,0 +1,57 @@
+package machine
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"testing"
+)
+
+// testControl loads the ROM files from memory
+type testControl struct {
+	Control
+	files map[string][]byte
+}
+
+// LoadROM loads a ROM file
+func (control *testControl) LoadROM(filename string) ([]byte, error) {
+	data, ok := control.files[filename]
+	if !ok {
+		return nil, errors.New("file not found")
+	}
+	return data, nil
+}
+
+// TestRomChecksum loads known images, a bad checksum is rejected and custom
+// files are loaded. Short ROMs are checked before filling the slot.
+func TestRomChecksum(t *testing.T) {
+	good := []byte("emu8 test rom")
+	bad := []byte("emu8 test ROM")
+	set := &RomSet{
+		Slots: []RomSlot{{Name: "rom", Size: 0x20, Default: "test"}},
+		Images: []RomImage{
+			{ID: "test", File: "test.rom", CRC32: crc32.ChecksumIEEE(good), SHA1: romSHA1(good)},
+			{ID: "crc", File: "crc.rom", CRC32: crc32.ChecksumIEEE(good)},
+		},
+	}
+	control := &testControl{files: map[string][]byte{"test.rom": good, "crc.rom": good, "custom.rom": bad}}
+	data, err := set.Load(control, "rom")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 0x20 || string(data[:len(good)]) != string(good) || data[len(good)] != 0xff {
+		t.Errorf("test : loaded % x", data)
+	}
+	if _, err = set.LoadImage(control, "rom", "crc"); err != nil {
+		t.Errorf("crc : %s", err.Error())
+	}
+	if _, err = set.LoadImage(control, "rom", "custom.rom"); err != nil {
+		t.Errorf("custom : %s", err.Error())
+	}
+	control.files["test.rom"], control.files["crc.rom"] = bad, bad
+	for _, id := range []string{"test", "crc", fmt.Sprintf("crc32:%08X", set.Images[0].CRC32)} {
+		if _, err = set.LoadImage(control, "rom", id); err == nil {
+			t.Errorf("%s : bad checksum not rejected", id)
+		}
+	}
+}
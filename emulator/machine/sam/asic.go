@@ -0,0 +1,310 @@
+package sam
+
+import (
+	"github.com/jtruco/emu8/emulator/device/video"
+)
+
+// -----------------------------------------------------------------------------
+// ASIC constants & vars
+// -----------------------------------------------------------------------------
+
+// Video screen constants : the screen is painted at 512 pixels per line,
+// the low resolution modes double the pixels.
+const (
+	asicScreenWidth  = 512
+	asicScreenHeight = 192
+	asicBorderLeft   = 64
+	asicBorderTop    = 24
+	asicTotalWidth   = asicScreenWidth + 2*asicBorderLeft
+	asicTotalHeight  = asicScreenHeight + 2*asicBorderTop
+	asicLineTstates  = 384                                                  // TStates per line (64 us at 6 MHz)
+	asicLines        = 312                                                  // Lines per frame (50 Hz)
+	asicFirstLine    = 68                                                   // First screen line
+	asicTopLine      = asicFirstLine - asicBorderTop                        // First visible line
+	asicBottomLine   = asicTopLine + asicTotalHeight                        // Last visible line
+	asicScreenTstate = 256                                                  // TStates of the screen in a line
+	asicFrameInt     = (asicFirstLine + asicScreenHeight) * asicLineTstates // Frame interrupt tstate
+	asicIntLength    = 128                                                  // Interrupt length in tstates
+	asicFlashMask    = 0x10                                                 // Frames of the flash phase
+	asicClutSize     = 16                                                   // Colour look up table entries
+)
+
+// ASIC registers bits
+const (
+	asicStatusLine  = 0x01 // STATUS : line interrupt (active low)
+	asicStatusFrame = 0x08 // STATUS : frame interrupt (active low)
+	asicStatusInts  = 0x1f // STATUS : interrupt bits
+	asicVMPRPage    = 0x1f // VMPR : screen page (bits 0-4)
+	asicVMPRMode    = 0x60 // VMPR : screen mode (bits 5-6)
+	asicBeeper      = 0x10 // BORDER : beeper (bit 4)
+	asicScreenOff   = 0x80 // BORDER : screen off in modes 3 & 4 (bit 7)
+)
+
+// ASIC screen modes
+const (
+	asicMode1 = iota // 256x192, the ZX Spectrum screen
+	asicMode2        // 256x192, linear with 8x1 attributes
+	asicMode3        // 512x192, 4 colours
+	asicMode4        // 256x192, 16 colours
+)
+
+// samPaletteRGBA the 128 colours : bits 0-2 are B0, R0 & G0, bit 3 is the
+// bright and bits 4-6 are B1, R1 & G1. Each component has 8 levels.
+var samPaletteRGBA = make([]uint32, 128)
+
+func init() {
+	for colour := range samPaletteRGBA {
+		level := func(lo, hi uint) uint32 {
+			value := colour>>hi&0x01<<2 | colour>>lo&0x01<<1 | colour>>3&0x01
+			return uint32(value * 255 / 7)
+		}
+		b, r, g := level(0, 4), level(1, 5), level(2, 6)
+		samPaletteRGBA[colour] = 0xff000000 | b<<16 | g<<8 | r
+	}
+}
+
+// -----------------------------------------------------------------------------
+// ASIC - SAM Coupé video & interrupts
+// -----------------------------------------------------------------------------
+
+// Asic is the SAM Coupé ASIC video and interrupt logic. The screen is read
+// from the RAM pages selected by the video memory page register (VMPR) in
+// one of the four modes, the colours from the 16 entries of the colour look
+// up table (CLUT). The line and frame interrupts are active for 128 tstates.
+// The lines are painted as the TV beam passes them.
+type Asic struct {
+	sam    *SAM               // The SAM machine
+	screen *video.Screen      // The video screen
+	clut   [asicClutSize]byte // The colour look up table
+	vmpr   byte               // Video memory page register
+	border byte               // Border register
+	line   byte               // Line interrupt register
+	paint  int                // Next line to paint
+	frames int                // Frame counter, for flashing
+}
+
+// NewAsic creates the ASIC
+func NewAsic(sam *SAM) *Asic {
+	asic := new(Asic)
+	asic.sam = sam
+	asic.screen = video.NewScreen(asicTotalWidth, asicTotalHeight, samPaletteRGBA)
+	asic.screen.SetScaleX(0.5)
+	return asic
+}
+
+// Device
+
+// Init initializes video device
+func (asic *Asic) Init() { asic.Reset() }
+
+// Reset resets video device
+func (asic *Asic) Reset() {
+	asic.screen.Clear(0)
+	for i := range asic.clut {
+		asic.clut[i] = 0
+	}
+	asic.vmpr = 0
+	asic.border = 0
+	asic.line = 0xff
+	asic.paint = 0
+	asic.frames = 0
+}
+
+// Video
+
+// EndFrame paints the remaining lines of the frame
+func (asic *Asic) EndFrame() {
+	asic.paintLines(asicBottomLine)
+	asic.paint = 0
+	asic.frames++
+}
+
+// Screen the video screen
+func (asic *Asic) Screen() *video.Screen { return asic.screen }
+
+// Registers
+
+// VMPR gets the video memory page register
+func (asic *Asic) VMPR() byte { return asic.vmpr }
+
+// SetVMPR sets the video memory page register
+func (asic *Asic) SetVMPR(data byte) {
+	asic.Update()
+	asic.vmpr = data
+}
+
+// Border gets the border register
+func (asic *Asic) Border() byte { return asic.border }
+
+// SetBorder sets the border register
+func (asic *Asic) SetBorder(data byte) {
+	asic.Update()
+	asic.border = data
+}
+
+// SetClut sets the colour of a CLUT entry
+func (asic *Asic) SetClut(index, colour byte) {
+	asic.Update()
+	asic.clut[index&(asicClutSize-1)] = colour & 0x7f
+}
+
+// SetLine sets the line interrupt register, lines out of the screen
+// disable the line interrupt
+func (asic *Asic) SetLine(line byte) { asic.line = line }
+
+// Mode gets the screen mode
+func (asic *Asic) Mode() int { return int(asic.vmpr&asicVMPRMode) >> 5 }
+
+// Status gets the interrupt status bits (active low) at tstate
+func (asic *Asic) Status(tstate int) byte {
+	status := byte(asicStatusInts)
+	if tstate >= asicFrameInt && tstate < asicFrameInt+asicIntLength {
+		status &^= asicStatusFrame
+	}
+	if asic.line < asicScreenHeight {
+		start := (asicFirstLine+int(asic.line)-1)*asicLineTstates + asicScreenTstate
+		if tstate >= start && tstate < start+asicIntLength {
+			status &^= asicStatusLine
+		}
+	}
+	return status
+}
+
+// HPEN gets the screen line of the TV beam at tstate, 192 out of the
+// screen
+func (asic *Asic) HPEN(tstate int) byte {
+	line := tstate/asicLineTstates - asicFirstLine
+	if line < 0 || line >= asicScreenHeight {
+		return asicScreenHeight
+	}
+	return byte(line)
+}
+
+// LPEN gets the horizontal pixel of the TV beam at tstate, 0 out of the
+// screen
+func (asic *Asic) LPEN(tstate int) byte {
+	x := tstate % asicLineTstates
+	if x >= asicScreenTstate {
+		return 0
+	}
+	return byte(x)
+}
+
+// Painting
+
+// Update paints the lines passed by the TV beam
+func (asic *Asic) Update() {
+	asic.paintLines(asic.sam.clock.Tstates() / asicLineTstates)
+}
+
+// paintLines paints the lines up to the last line
+func (asic *Asic) paintLines(last int) {
+	if last > asicBottomLine {
+		last = asicBottomLine
+	}
+	for ; asic.paint < last; asic.paint++ {
+		if asic.paint >= asicTopLine {
+			asic.paintLine(asic.paint - asicTopLine)
+		}
+	}
+}
+
+// paintLine paints a screen line
+func (asic *Asic) paintLine(y int) {
+	border := int(asic.clut[asic.border&0x07|asic.border>>2&0x08]) // bits 0-2 & 5
+	line := y - asicBorderTop
+	if line < 0 || line >= asicScreenHeight {
+		for x := 0; x < asicTotalWidth; x++ {
+			asic.screen.SetPixelIndex(x, y, border)
+		}
+		return
+	}
+	for x := 0; x < asicBorderLeft; x++ {
+		asic.screen.SetPixelIndex(x, y, border)
+		asic.screen.SetPixelIndex(asicTotalWidth-1-x, y, border)
+	}
+	mode := asic.Mode()
+	if mode >= asicMode3 && asic.border&asicScreenOff != 0 {
+		for x := asicBorderLeft; x < asicBorderLeft+asicScreenWidth; x++ {
+			asic.screen.SetPixelIndex(x, y, 0)
+		}
+		return
+	}
+	switch mode {
+	case asicMode1:
+		address := (line&0xc0)<<5 | (line&0x07)<<8 | (line&0x38)<<2
+		asic.paintAttributes(y, address, 0x1800+line>>3<<5)
+	case asicMode2:
+		asic.paintAttributes(y, line<<5, 0x2000+line<<5)
+	case asicMode3:
+		asic.paintMode3(y, line<<7)
+	case asicMode4:
+		asic.paintMode4(y, line<<7)
+	}
+}
+
+// peek reads the screen data at offset. Modes 3 & 4 use two pages from an
+// even page.
+func (asic *Asic) peek(offset int) byte {
+	page := int(asic.vmpr & asicVMPRPage)
+	if asic.Mode() >= asicMode3 {
+		page &^= 0x01
+	}
+	page = asic.sam.mapper.Page(page + offset>>14)
+	return asic.sam.memory.Bank(page).Data()[offset&0x3fff]
+}
+
+// paintAttributes paints a line of the modes 1 and 2 : 32 bytes of pixels,
+// coloured by the attribute bytes. The ink and paper are the CLUT entries
+// 0-15, the flash swaps them.
+func (asic *Asic) paintAttributes(y, address, attributes int) {
+	flash := asic.frames&asicFlashMask != 0
+	x := asicBorderLeft
+	for col := 0; col < 32; col++ {
+		data, attr := asic.peek(address+col), asic.peek(attributes+col)
+		bright := attr >> 3 & 0x08
+		ink := int(asic.clut[attr&0x07|bright])
+		paper := int(asic.clut[attr>>3&0x07|bright])
+		if attr&0x80 != 0 && flash {
+			ink, paper = paper, ink
+		}
+		for mask := byte(0x80); mask != 0; mask >>= 1 {
+			colour := paper
+			if data&mask != 0 {
+				colour = ink
+			}
+			asic.screen.SetPixelIndex(x, y, colour)
+			asic.screen.SetPixelIndex(x+1, y, colour)
+			x += 2
+		}
+	}
+}
+
+// paintMode3 paints a line of the mode 3 : 128 bytes of 4 pixels, the
+// CLUT entry bits 2-3 are the HMPR MD3S bits.
+func (asic *Asic) paintMode3(y, address int) {
+	md3s := asic.sam.mapper.hmpr & samMD3S >> 3
+	x := asicBorderLeft
+	for col := 0; col < 128; col++ {
+		data := asic.peek(address + col)
+		for shift := 6; shift >= 0; shift -= 2 {
+			asic.screen.SetPixelIndex(x, y, int(asic.clut[md3s|data>>uint(shift)&0x03]))
+			x++
+		}
+	}
+}
+
+// paintMode4 paints a line of the mode 4 : 128 bytes of 2 pixels, the
+// high nibble first.
+func (asic *Asic) paintMode4(y, address int) {
+	x := asicBorderLeft
+	for col := 0; col < 128; col++ {
+		data := asic.peek(address + col)
+		for _, pixel := range [2]byte{data >> 4, data & 0x0f} {
+			colour := int(asic.clut[pixel])
+			asic.screen.SetPixelIndex(x, y, colour)
+			asic.screen.SetPixelIndex(x+1, y, colour)
+			x += 2
+		}
+	}
+}
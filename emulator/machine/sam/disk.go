@@ -0,0 +1,96 @@
+package sam
+
+import (
+	"errors"
+
+	"github.com/jtruco/emu8/emulator/device/io/disk"
+	"github.com/jtruco/emu8/emulator/device/io/fdc"
+	"github.com/jtruco/emu8/emulator/machine/sam/format"
+)
+
+// -----------------------------------------------------------------------------
+// SAM Coupé - Disk drives
+// -----------------------------------------------------------------------------
+
+// Disk drive ports
+const (
+	samDrives      = 2    // Number of disk drives
+	samPortDisk    = 0xe0 // Disk ports : 0xe0-0xe7 drive 1, 0xf0-0xf7 drive 2
+	samPortDiskMsk = 0xe8 // Disk ports mask
+	samDiskDrive   = 0x10 // Drive 2 select (bit 4)
+	samDiskSide    = 0x04 // Disk side (bit 2)
+	samDiskReg     = 0x03 // WD1772 register (bits 0-1)
+)
+
+// Disks are the two disk drives of the SAM Coupé, each one with its own
+// WD1772 controller. The port selects the drive, the side and the
+// controller register.
+type Disks struct {
+	fdcs [samDrives]*fdc.WD1793 // The floppy disk controllers
+}
+
+// NewDisks creates the disk drives
+func NewDisks(sam *SAM) *Disks {
+	disks := new(Disks)
+	for i := range disks.fdcs {
+		disks.fdcs[i] = fdc.NewWD1772(sam.clock, samTStates*samFPS, 1)
+	}
+	return disks
+}
+
+// Drive gets the disk drive at index
+func (disks *Disks) Drive(index int) *disk.Drive { return disks.fdcs[index].Drive(0) }
+
+// Device
+
+// Init initializes the drives
+func (disks *Disks) Init() { disks.Reset() }
+
+// Reset resets the controllers
+func (disks *Disks) Reset() {
+	for _, fdc := range disks.fdcs {
+		fdc.Reset()
+	}
+}
+
+// Ports
+
+// IsPort checks if the port is a disk port
+func (disks *Disks) IsPort(port byte) bool { return port&samPortDiskMsk == samPortDisk }
+
+// Read reads the controller register of the port
+func (disks *Disks) Read(port byte) byte {
+	return disks.selectPort(port).Read(int(port & samDiskReg))
+}
+
+// Write writes the controller register of the port
+func (disks *Disks) Write(port, data byte) {
+	disks.selectPort(port).Write(int(port&samDiskReg), data)
+}
+
+// selectPort selects the controller and the side of the port
+func (disks *Disks) selectPort(port byte) *fdc.WD1793 {
+	fdc := disks.fdcs[port&samDiskDrive>>4]
+	fdc.SetSide(int(port&samDiskSide) >> 2)
+	return fdc
+}
+
+// Disks
+
+// InsertDisk inserts a disk image into the first drive
+func (disks *Disks) InsertDisk(ext string, data []byte) error {
+	var image *disk.Disk
+	switch ext {
+	case format.MGT:
+		image = format.LoadMGT(data)
+	case format.SAD:
+		image = format.LoadSAD(data)
+	default:
+		return errors.New("Not supported disk format: " + ext)
+	}
+	if image == nil {
+		return errors.New("Invalid disk image")
+	}
+	disks.Drive(0).Insert(image)
+	return nil
+}
@@ -0,0 +1,83 @@
+// Package format contains the SAM Coupé file formats
+package format
+
+import (
+	"log"
+
+	"github.com/jtruco/emu8/emulator/device/io/disk"
+)
+
+// -----------------------------------------------------------------------------
+// MGT & SAD disk formats
+// -----------------------------------------------------------------------------
+
+// SAM disk format extensions
+const (
+	MGT = "mgt"
+	SAD = "sad"
+)
+
+// SAM disk constants
+const (
+	mgtCylinders  = 80
+	mgtSides      = 2
+	mgtSectorSize = 512
+	mgtSize10     = mgtCylinders * mgtSides * 10 * mgtSectorSize // 800K (SAMDOS)
+	mgtSize9      = mgtCylinders * mgtSides * 9 * mgtSectorSize  // 720K (+D)
+	sadSignature  = "Aley's disk backup"
+	sadHeaderSize = 22
+)
+
+// LoadMGT loads a disk from MGT data format. MGT is the raw sector image of
+// 80 cylinders, 2 sides and 10 or 9 sectors of 512 bytes, logical tracks
+// are stored by cylinder and side.
+func LoadMGT(data []byte) *disk.Disk {
+	sectors := 0
+	switch len(data) {
+	case mgtSize10:
+		sectors = 10
+	case mgtSize9:
+		sectors = 9
+	default:
+		log.Println("MGT : Invalid file format")
+		return nil
+	}
+	return disk.NewRegular(mgtCylinders, mgtSides, sectors, mgtSectorSize, 1, data)
+}
+
+// LoadSAD loads a disk from SAD data format. The SAD header is the
+// signature and the sides, tracks, sectors and sector size / 64 bytes. The
+// sectors are stored by side, track and sector.
+func LoadSAD(data []byte) *disk.Disk {
+	if len(data) < sadHeaderSize || string(data[:len(sadSignature)]) != sadSignature {
+		log.Println("SAD : Invalid file format")
+		return nil
+	}
+	header := data[len(sadSignature):]
+	sides, tracks, sectors := int(header[0]), int(header[1]), int(header[2])
+	size := int(header[3]) * 64
+	if sides == 0 || sides > 2 || tracks == 0 || sectors == 0 || size == 0 ||
+		len(data) < sadHeaderSize+sides*tracks*sectors*size {
+		log.Println("SAD : Invalid file format")
+		return nil
+	}
+	image := disk.New(tracks, sides)
+	pos := sadHeaderSize
+	for side := 0; side < sides; side++ {
+		for cylinder := 0; cylinder < tracks; cylinder++ {
+			track := image.Track(cylinder, side)
+			for i := 0; i < sectors; i++ {
+				sector := &disk.Sector{
+					Track: byte(cylinder),
+					Side:  byte(side),
+					ID:    byte(i + 1),
+					Size:  disk.SizeCode(size),
+					Data:  make([]byte, size)}
+				copy(sector.Data, data[pos:])
+				pos += size
+				track.Sectors = append(track.Sectors, sector)
+			}
+		}
+	}
+	return image
+}
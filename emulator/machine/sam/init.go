@@ -0,0 +1,22 @@
+package sam
+
+import "github.com/jtruco/emu8/emulator/machine"
+
+// SAM Coupé models
+var models = []machine.Model{
+	{Name: "SAM Coupé", Ids: []string{"SAMCoupe", "SAM", "SAM512"},
+		Build: func() machine.Machine { return New(SAM512K) }, Roms: samRomSet},
+	{Name: "SAM Coupé 256K", Ids: []string{"SAM256"},
+		Build: func() machine.Machine { return New(SAM256K) }, Roms: samRomSet},
+}
+
+// samRomSet the 32K ROM, loaded from file
+var samRomSet = &machine.RomSet{
+	Slots: []machine.RomSlot{
+		{Name: "rom", Size: 0x8000, Default: "samcoupe.rom"},
+	},
+}
+
+func init() {
+	machine.RegisterModels(models)
+}
@@ -0,0 +1,381 @@
+package sam
+
+import (
+	"github.com/jtruco/emu8/emulator/device/io/keyboard"
+)
+
+// -----------------------------------------------------------------------------
+// SAM Coupé Keyboard
+// -----------------------------------------------------------------------------
+
+// Keyboard is the SAM Coupé keyboard matrix of 9 rows of 8 keys. The address
+// high byte selects the rows 0 to 7 (active low), the keyboard port reads
+// the bits 0-4 and the status port the bits 5-7. The row 8 is read when no
+// row is selected.
+type Keyboard struct {
+	rowstates [9]byte
+}
+
+// NewKeyboard creates a new keyboard
+func NewKeyboard() *Keyboard {
+	return new(Keyboard)
+}
+
+// Read reads the keys of the selected rows (active low)
+func (keyboard *Keyboard) Read(rows byte) byte {
+	if rows == 0xff {
+		return keyboard.rowstates[8]
+	}
+	result := byte(0xff)
+	for row := uint(0); row < 8; row++ {
+		if rows&(1<<row) == 0 {
+			result &= keyboard.rowstates[row]
+		}
+	}
+	return result
+}
+
+// Device
+
+// Init initializes the keyboard
+func (keyboard *Keyboard) Init() {
+	for row := range keyboard.rowstates {
+		keyboard.rowstates[row] = 0xff
+	}
+}
+
+// Reset resets the keyboard
+func (keyboard *Keyboard) Reset() { keyboard.Init() }
+
+// Keyboard
+
+// KeyMap returns the default keyboard mapping
+func (keyboard *Keyboard) KeyMap() keyboard.KeyMap { return samKeyboardMap }
+
+// KeyNames returns the keys by name
+func (keyboard *Keyboard) KeyNames() map[string]keyboard.Key { return samKeyNames }
+
+// ProcessKey processes SAM keyboard matrix
+func (keyboard *Keyboard) ProcessKey(key keyboard.Key, pressed bool) {
+	row := key >> 4
+	mask := uint8(1 << uint8(key&0x07))
+	if pressed {
+		keyboard.rowstates[row] &= ^mask
+	} else {
+		keyboard.rowstates[row] |= mask
+	}
+}
+
+// TextKeys returns the key combinations that type the text
+func (keyboard *Keyboard) TextKeys(text string) [][]keyboard.Key {
+	return samTextKeys(text)
+}
+
+// -----------------------------------------------------------------------------
+// SAM Keys, States & Mapping
+// -----------------------------------------------------------------------------
+
+// SAM Keyboard Keys
+const (
+	SamKeyShift  = 0x00 // row 0, bit 0..bit 7
+	SamKeyZ      = 0x01
+	SamKeyX      = 0x02
+	SamKeyC      = 0x03
+	SamKeyV      = 0x04
+	SamKeyF1     = 0x05
+	SamKeyF2     = 0x06
+	SamKeyF3     = 0x07
+	SamKeyA      = 0x10 // row 1
+	SamKeyS      = 0x11
+	SamKeyD      = 0x12
+	SamKeyF      = 0x13
+	SamKeyG      = 0x14
+	SamKeyF4     = 0x15
+	SamKeyF5     = 0x16
+	SamKeyF6     = 0x17
+	SamKeyQ      = 0x20 // row 2
+	SamKeyW      = 0x21
+	SamKeyE      = 0x22
+	SamKeyR      = 0x23
+	SamKeyT      = 0x24
+	SamKeyF7     = 0x25
+	SamKeyF8     = 0x26
+	SamKeyF9     = 0x27
+	SamKey1      = 0x30 // row 3
+	SamKey2      = 0x31
+	SamKey3      = 0x32
+	SamKey4      = 0x33
+	SamKey5      = 0x34
+	SamKeyEsc    = 0x35
+	SamKeyTab    = 0x36
+	SamKeyCaps   = 0x37
+	SamKey0      = 0x40 // row 4
+	SamKey9      = 0x41
+	SamKey8      = 0x42
+	SamKey7      = 0x43
+	SamKey6      = 0x44
+	SamKeyMinus  = 0x45
+	SamKeyPlus   = 0x46
+	SamKeyDelete = 0x47
+	SamKeyP      = 0x50 // row 5
+	SamKeyO      = 0x51
+	SamKeyI      = 0x52
+	SamKeyU      = 0x53
+	SamKeyY      = 0x54
+	SamKeyEquals = 0x55
+	SamKeyQuote  = 0x56
+	SamKeyF0     = 0x57
+	SamKeyReturn = 0x60 // row 6
+	SamKeyL      = 0x61
+	SamKeyK      = 0x62
+	SamKeyJ      = 0x63
+	SamKeyH      = 0x64
+	SamKeySemi   = 0x65
+	SamKeyColon  = 0x66
+	SamKeyEdit   = 0x67
+	SamKeySpace  = 0x70 // row 7
+	SamKeySymbol = 0x71
+	SamKeyM      = 0x72
+	SamKeyN      = 0x73
+	SamKeyB      = 0x74
+	SamKeyComma  = 0x75
+	SamKeyDot    = 0x76
+	SamKeyInv    = 0x77
+	SamKeyCntrl  = 0x80 // row 8
+	SamKeyUp     = 0x81
+	SamKeyDown   = 0x82
+	SamKeyLeft   = 0x83
+	SamKeyRight  = 0x84
+)
+
+// samKeyNames SAM keys by name
+var samKeyNames = map[string]keyboard.Key{
+	"0":      SamKey0,
+	"1":      SamKey1,
+	"2":      SamKey2,
+	"3":      SamKey3,
+	"4":      SamKey4,
+	"5":      SamKey5,
+	"6":      SamKey6,
+	"7":      SamKey7,
+	"8":      SamKey8,
+	"9":      SamKey9,
+	"A":      SamKeyA,
+	"B":      SamKeyB,
+	"C":      SamKeyC,
+	"D":      SamKeyD,
+	"E":      SamKeyE,
+	"F":      SamKeyF,
+	"G":      SamKeyG,
+	"H":      SamKeyH,
+	"I":      SamKeyI,
+	"J":      SamKeyJ,
+	"K":      SamKeyK,
+	"L":      SamKeyL,
+	"M":      SamKeyM,
+	"N":      SamKeyN,
+	"O":      SamKeyO,
+	"P":      SamKeyP,
+	"Q":      SamKeyQ,
+	"R":      SamKeyR,
+	"S":      SamKeyS,
+	"T":      SamKeyT,
+	"U":      SamKeyU,
+	"V":      SamKeyV,
+	"W":      SamKeyW,
+	"X":      SamKeyX,
+	"Y":      SamKeyY,
+	"Z":      SamKeyZ,
+	"F0":     SamKeyF0,
+	"F1":     SamKeyF1,
+	"F2":     SamKeyF2,
+	"F3":     SamKeyF3,
+	"F4":     SamKeyF4,
+	"F5":     SamKeyF5,
+	"F6":     SamKeyF6,
+	"F7":     SamKeyF7,
+	"F8":     SamKeyF8,
+	"F9":     SamKeyF9,
+	"Minus":  SamKeyMinus,
+	"Plus":   SamKeyPlus,
+	"Equals": SamKeyEquals,
+	"Quote":  SamKeyQuote,
+	"Semi":   SamKeySemi,
+	"Colon":  SamKeyColon,
+	"Comma":  SamKeyComma,
+	"Dot":    SamKeyDot,
+	"Shift":  SamKeyShift,
+	"Symbol": SamKeySymbol,
+	"Cntrl":  SamKeyCntrl,
+	"Esc":    SamKeyEsc,
+	"Tab":    SamKeyTab,
+	"Caps":   SamKeyCaps,
+	"Delete": SamKeyDelete,
+	"Edit":   SamKeyEdit,
+	"Inv":    SamKeyInv,
+	"Return": SamKeyReturn,
+	"Space":  SamKeySpace,
+	"Left":   SamKeyLeft,
+	"Up":     SamKeyUp,
+	"Down":   SamKeyDown,
+	"Right":  SamKeyRight,
+}
+
+// SAM Keyboard map
+var samKeyboardMap = map[keyboard.KeyCode][]keyboard.Key{
+	// alphanum
+	keyboard.Key0: {SamKey0},
+	keyboard.Key1: {SamKey1},
+	keyboard.Key2: {SamKey2},
+	keyboard.Key3: {SamKey3},
+	keyboard.Key4: {SamKey4},
+	keyboard.Key5: {SamKey5},
+	keyboard.Key6: {SamKey6},
+	keyboard.Key7: {SamKey7},
+	keyboard.Key8: {SamKey8},
+	keyboard.Key9: {SamKey9},
+	keyboard.KeyA: {SamKeyA},
+	keyboard.KeyB: {SamKeyB},
+	keyboard.KeyC: {SamKeyC},
+	keyboard.KeyD: {SamKeyD},
+	keyboard.KeyE: {SamKeyE},
+	keyboard.KeyF: {SamKeyF},
+	keyboard.KeyG: {SamKeyG},
+	keyboard.KeyH: {SamKeyH},
+	keyboard.KeyI: {SamKeyI},
+	keyboard.KeyJ: {SamKeyJ},
+	keyboard.KeyK: {SamKeyK},
+	keyboard.KeyL: {SamKeyL},
+	keyboard.KeyM: {SamKeyM},
+	keyboard.KeyN: {SamKeyN},
+	keyboard.KeyO: {SamKeyO},
+	keyboard.KeyP: {SamKeyP},
+	keyboard.KeyQ: {SamKeyQ},
+	keyboard.KeyR: {SamKeyR},
+	keyboard.KeyS: {SamKeyS},
+	keyboard.KeyT: {SamKeyT},
+	keyboard.KeyU: {SamKeyU},
+	keyboard.KeyV: {SamKeyV},
+	keyboard.KeyW: {SamKeyW},
+	keyboard.KeyX: {SamKeyX},
+	keyboard.KeyY: {SamKeyY},
+	keyboard.KeyZ: {SamKeyZ},
+	// symbols
+	keyboard.KeySpace:      {SamKeySpace},
+	keyboard.KeyMinus:      {SamKeyMinus},
+	keyboard.KeyEquals:     {SamKeyEquals},
+	keyboard.KeySemicolon:  {SamKeySemi},
+	keyboard.KeyApostrophe: {SamKeyQuote},
+	keyboard.KeyComma:      {SamKeyComma},
+	keyboard.KeyPeriod:     {SamKeyDot},
+	keyboard.KeySlash:      {SamKeyShift, SamKeyMinus},
+	// special
+	keyboard.KeyReturn:    {SamKeyReturn},
+	keyboard.KeyEscape:    {SamKeyEsc},
+	keyboard.KeyTab:       {SamKeyTab},
+	keyboard.KeyCapsLock:  {SamKeyCaps},
+	keyboard.KeyBackspace: {SamKeyDelete},
+	keyboard.KeyDelete:    {SamKeyDelete},
+	keyboard.KeyInsert:    {SamKeyInv},
+	keyboard.KeyHome:      {SamKeyEdit},
+	// function keys
+	keyboard.KeyF1:  {SamKeyF1},
+	keyboard.KeyF2:  {SamKeyF2},
+	keyboard.KeyF3:  {SamKeyF3},
+	keyboard.KeyF4:  {SamKeyF4},
+	keyboard.KeyF5:  {SamKeyF5},
+	keyboard.KeyF6:  {SamKeyF6},
+	keyboard.KeyF7:  {SamKeyF7},
+	keyboard.KeyF8:  {SamKeyF8},
+	keyboard.KeyF9:  {SamKeyF9},
+	keyboard.KeyF10: {SamKeyF0},
+	// cursors
+	keyboard.KeyUp:    {SamKeyUp},
+	keyboard.KeyDown:  {SamKeyDown},
+	keyboard.KeyLeft:  {SamKeyLeft},
+	keyboard.KeyRight: {SamKeyRight},
+	// keypad
+	keyboard.KeyPad0:        {SamKeyF0},
+	keyboard.KeyPad1:        {SamKeyF1},
+	keyboard.KeyPad2:        {SamKeyF2},
+	keyboard.KeyPad3:        {SamKeyF3},
+	keyboard.KeyPad4:        {SamKeyF4},
+	keyboard.KeyPad5:        {SamKeyF5},
+	keyboard.KeyPad6:        {SamKeyF6},
+	keyboard.KeyPad7:        {SamKeyF7},
+	keyboard.KeyPad8:        {SamKeyF8},
+	keyboard.KeyPad9:        {SamKeyF9},
+	keyboard.KeyPadMultiply: {SamKeyShift, SamKeyPlus},
+	keyboard.KeyPadPlus:     {SamKeyPlus},
+	keyboard.KeyPadDivide:   {SamKeyShift, SamKeyMinus},
+	keyboard.KeyPadMinus:    {SamKeyMinus},
+	keyboard.KeyPadPeriod:   {SamKeyDot},
+	keyboard.KeyPadEnter:    {SamKeyReturn},
+	// shift, symbol & control
+	keyboard.KeyLShift: {SamKeyShift},
+	keyboard.KeyRShift: {SamKeyShift},
+	keyboard.KeyLCtrl:  {SamKeyCntrl},
+	keyboard.KeyRCtrl:  {SamKeyCntrl},
+	keyboard.KeyLAlt:   {SamKeySymbol},
+	keyboard.KeyRAlt:   {SamKeySymbol},
+}
+
+// -----------------------------------------------------------------------------
+// SAM Text Typing
+// -----------------------------------------------------------------------------
+
+// samSymbolKeys SAM keys of symbols, unshifted and shifted
+var samSymbolKeys = map[keyboard.Key]string{
+	SamKey1: "1!", SamKey2: "2@", SamKey3: "3#", SamKey4: "4$", SamKey5: "5%",
+	SamKey6: "6&", SamKey7: "7'", SamKey8: "8(", SamKey9: "9)", SamKey0: "0~",
+	SamKeyMinus: "-/", SamKeyPlus: "+*", SamKeyComma: ",<", SamKeyDot: ".>",
+}
+
+// samUnshiftedKeys SAM keys of the symbols without shift
+var samUnshiftedKeys = map[rune]keyboard.Key{
+	'=': SamKeyEquals, '"': SamKeyQuote, ';': SamKeySemi, ':': SamKeyColon,
+	' ': SamKeySpace, '\n': SamKeyReturn,
+}
+
+// samLetterKeys SAM keys of letters from A to Z
+var samLetterKeys = [...]keyboard.Key{
+	SamKeyA, SamKeyB, SamKeyC, SamKeyD, SamKeyE, SamKeyF, SamKeyG,
+	SamKeyH, SamKeyI, SamKeyJ, SamKeyK, SamKeyL, SamKeyM, SamKeyN,
+	SamKeyO, SamKeyP, SamKeyQ, SamKeyR, SamKeyS, SamKeyT, SamKeyU,
+	SamKeyV, SamKeyW, SamKeyX, SamKeyY, SamKeyZ,
+}
+
+// samKeyCombinations key combinations by character
+var samKeyCombinations = make(map[rune][]keyboard.Key)
+
+func init() {
+	for key, symbols := range samSymbolKeys {
+		runes := []rune(symbols)
+		samKeyCombinations[runes[0]] = []keyboard.Key{key}
+		samKeyCombinations[runes[1]] = []keyboard.Key{SamKeyShift, key}
+	}
+	for r, key := range samUnshiftedKeys {
+		samKeyCombinations[r] = []keyboard.Key{key}
+	}
+	for i, key := range samLetterKeys {
+		samKeyCombinations[rune('a'+i)] = []keyboard.Key{key}
+		samKeyCombinations[rune('A'+i)] = []keyboard.Key{SamKeyShift, key}
+	}
+}
+
+// samTextKeys returns the key combinations that type the text. Lines are
+// followed by a pause for the BASIC editor.
+func samTextKeys(text string) [][]keyboard.Key {
+	keys := make([][]keyboard.Key, 0, len(text))
+	for _, r := range text {
+		combination, ok := samKeyCombinations[r]
+		if !ok {
+			continue
+		}
+		keys = append(keys, combination)
+		if r == '\n' {
+			keys = append(keys, keyboard.TypistPause, keyboard.TypistPause)
+		}
+	}
+	return keys
+}
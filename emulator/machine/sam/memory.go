@@ -0,0 +1,118 @@
+package sam
+
+import (
+	"github.com/jtruco/emu8/emulator/device/bus"
+	"github.com/jtruco/emu8/emulator/device/memory"
+)
+
+// -----------------------------------------------------------------------------
+// SAM Coupé - Memory paging
+// -----------------------------------------------------------------------------
+
+// Memory map indexes and paging registers
+const (
+	samPages      = 32           // RAM pages of 16K (512K)
+	samMemoryROM0 = samPages     // ROM0, the lower half of the ROM
+	samMemoryROM1 = samPages + 1 // ROM1, the upper half of the ROM
+	samMemoryMaps = samPages + 2
+	samSections   = 4    // 16K sections of the address space : A, B, C & D
+	samPageMask   = 0x1f // LMPR & HMPR page (bits 0-4)
+	samRAM0       = 0x20 // LMPR : RAM in section A, ROM0 disabled
+	samROM1       = 0x40 // LMPR : ROM1 in section D
+	samWriteProt  = 0x80 // LMPR : section A write protected
+	samMD3S       = 0x60 // HMPR : mode 3 CLUT select (bits 5-6)
+)
+
+// newMemory creates the SAM memory : the RAM pages and the ROM halves
+func newMemory() *memory.Memory {
+	mem := memory.New(samMemoryMaps)
+	for page := 0; page < samPages; page++ {
+		mem.SetMap(page, memory.NewRAM(0x0000, memory.Size16K))
+	}
+	mem.SetMap(samMemoryROM0, memory.NewROM(0x0000, memory.Size16K))
+	mem.SetMap(samMemoryROM1, memory.NewROM(0xc000, memory.Size16K))
+	return mem
+}
+
+// memoryMapper maps the SAM memory by 16K sections. The low memory page
+// register (LMPR) selects the pages of sections A and B, the high memory
+// page register (HMPR) the pages of sections C and D. The 256K model has
+// 16 pages, the upper pages mirror the lower.
+type memoryMapper struct {
+	maps     bus.Maps              // The memory maps
+	sections [samSections]*bus.Map // Maps by section
+	writable [samSections]bool     // Writable sections
+	pages    int                   // Number of RAM pages
+	lmpr     byte                  // Low memory page register
+	hmpr     byte                  // High memory page register
+}
+
+// newMemoryMapper creates the mapper of the RAM pages
+func newMemoryMapper(pages int) *memoryMapper {
+	mapper := new(memoryMapper)
+	mapper.pages = pages
+	return mapper
+}
+
+// Init inits the mapper
+func (mapper *memoryMapper) Init(maps bus.Maps) {
+	mapper.maps = maps
+	mapper.Reset()
+}
+
+// Reset resets the paging registers
+func (mapper *memoryMapper) Reset() {
+	mapper.lmpr, mapper.hmpr = 0, 0
+	mapper.update()
+}
+
+// Select selects the map at address for read access
+func (mapper *memoryMapper) Select(address uint16) (*bus.Map, uint16) {
+	return mapper.sections[address>>14], address & 0x3fff
+}
+
+// SelectWrite selects the map at address for write access
+func (mapper *memoryMapper) SelectWrite(address uint16) (*bus.Map, uint16) {
+	section := address >> 14
+	if !mapper.writable[section] {
+		return nil, 0
+	}
+	return mapper.sections[section], address & 0x3fff
+}
+
+// SetLMPR sets the low memory page register
+func (mapper *memoryMapper) SetLMPR(data byte) {
+	mapper.lmpr = data
+	mapper.update()
+}
+
+// SetHMPR sets the high memory page register
+func (mapper *memoryMapper) SetHMPR(data byte) {
+	mapper.hmpr = data
+	mapper.update()
+}
+
+// Page returns the map index of a RAM page
+func (mapper *memoryMapper) Page(page int) int { return page & (mapper.pages - 1) }
+
+// update updates the sections : section B has the page after section A,
+// and section D the page after section C.
+func (mapper *memoryMapper) update() {
+	low, high := int(mapper.lmpr&samPageMask), int(mapper.hmpr&samPageMask)
+	pages := [samSections]int{low, low + 1, high, high + 1}
+	for section, page := range pages {
+		mapper.sections[section] = mapper.maps[mapper.Page(page)]
+		mapper.writable[section] = true
+	}
+	if mapper.lmpr&samRAM0 == 0 {
+		mapper.sections[0] = mapper.maps[samMemoryROM0]
+		mapper.writable[0] = false
+	}
+	if mapper.lmpr&samWriteProt != 0 {
+		mapper.writable[0] = false
+	}
+	if mapper.lmpr&samROM1 != 0 {
+		mapper.sections[3] = mapper.maps[samMemoryROM1]
+		mapper.writable[3] = false
+	}
+}
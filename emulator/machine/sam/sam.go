@@ -0,0 +1,284 @@
+// Package sam implements the SAM Coupé machine
+package sam
+
+import (
+	"log"
+
+	"github.com/jtruco/emu8/emulator/config"
+	"github.com/jtruco/emu8/emulator/device"
+	"github.com/jtruco/emu8/emulator/device/audio"
+	"github.com/jtruco/emu8/emulator/device/cpu"
+	"github.com/jtruco/emu8/emulator/device/cpu/z80"
+	"github.com/jtruco/emu8/emulator/device/memory"
+	"github.com/jtruco/emu8/emulator/machine"
+	"github.com/jtruco/emu8/emulator/machine/sam/format"
+)
+
+// -----------------------------------------------------------------------------
+// SAM Coupé
+// -----------------------------------------------------------------------------
+
+// SAM Coupé models
+const (
+	SAM512K = iota
+	SAM256K
+)
+
+// Default SAM constants
+const (
+	samFPS      = 50                          // 50 Hz
+	samTStates  = asicLines * asicLineTstates // TStates per frame (6 MHz)
+	samSAATicks = audio.SAA1099Clock / samFPS // SAA1099 ticks per frame
+	samPortCLUT = 0xf8                        // CLUT (write), LPEN & HPEN (read)
+	samPortLine = 0xf9                        // Line interrupt (write), status (read)
+	samPortLMPR = 0xfa                        // Low memory page register
+	samPortHMPR = 0xfb                        // High memory page register
+	samPortVMPR = 0xfc                        // Video memory page register
+	samPortULA  = 0xfe                        // Border & beeper (write), keyboard (read)
+	samPortSAA  = 0xff                        // SAA1099 : data, register select on A8
+	samPortA8   = 0x01                        // Port address bit 8 (high byte bit 0)
+	samKeysLow  = 0x1f                        // Keyboard port keys (bits 0-4)
+	samKeysHigh = 0xe0                        // Status port keys (bits 5-7)
+)
+
+// samBeeperMap beeper levels
+var samBeeperMap = []uint16{0, 0x1800}
+
+// SAM is a SAM Coupé computer
+type SAM struct {
+	config     machine.Config      // Machine information
+	control    machine.Control     // The emulator controller
+	components *device.Components  // Machine device components
+	clock      *device.ClockDevice // The system clock
+	cpu        *z80.Z80            // The Zilog Z80B CPU
+	memory     *memory.Memory      // The machine memory
+	mapper     *memoryMapper       // The memory paging
+	asic       *Asic               // The ASIC video & interrupts
+	beeper     *audio.Beeper       // The beeper
+	saa        *audio.SAA1099      // The SAA1099 sound chip
+	mixer      *audio.Mixer        // The beeper and SAA1099 mixer
+	keyboard   *Keyboard           // The keyboard
+	disks      *Disks              // The disk drives
+	saaTicks   int                 // SAA1099 ticks emulated in the frame
+}
+
+// New returns a new SAM Coupé
+func New(model int) machine.Machine {
+	sam := new(SAM)
+	sam.config.Model = model
+	sam.config.SetTimings(samTStates, samFPS)
+	// memory map : RAM pages & ROM
+	pages := samPages
+	if model == SAM256K {
+		pages >>= 1
+	}
+	sam.memory = newMemory()
+	sam.mapper = newMemoryMapper(pages)
+	sam.memory.SetMapper(sam.mapper)
+	// devices
+	sam.clock = device.NewClock()
+	sam.cpu = z80.New(sam.clock, sam.memory, sam)
+	sam.asic = NewAsic(sam)
+	frequency := config.Get().Audio.Frequency
+	sam.beeper = audio.NewBeeper(audio.NewConfig(frequency, samFPS, samTStates))
+	sam.beeper.SetMap(samBeeperMap)
+	sam.saa = audio.NewSAA1099(audio.NewConfig(frequency, samFPS, samSAATicks))
+	sam.mixer = audio.NewMixer(sam.beeper, sam.saa)
+	sam.keyboard = NewKeyboard()
+	sam.disks = NewDisks(sam)
+	// register all components
+	sam.components = device.NewComponents()
+	sam.components.Add(sam.clock)
+	sam.components.Add(sam.cpu)
+	sam.components.Add(sam.memory)
+	sam.components.Add(sam.asic)
+	sam.components.Add(sam.beeper)
+	sam.components.Add(sam.saa)
+	sam.components.Add(sam.keyboard)
+	sam.components.Add(sam.disks)
+	return sam
+}
+
+// Device interface
+
+// Init initializes the machine
+func (sam *SAM) Init() {
+	sam.components.Init()
+	sam.initSAM()
+}
+
+// Reset resets the machine
+func (sam *SAM) Reset() {
+	sam.components.Reset()
+	sam.initSAM()
+}
+
+// initSAM common init tasks
+func (sam *SAM) initSAM() {
+	sam.mapper.Reset()
+	sam.saaTicks = 0
+	data, err := samRomSet.Load(sam.control, "rom")
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+	sam.memory.Bank(samMemoryROM0).Load(0, data[:memory.Size16K])
+	sam.memory.Bank(samMemoryROM1).Load(0, data[memory.Size16K:])
+}
+
+// Machine properties
+
+// Clock gets the machine clock
+func (sam *SAM) Clock() device.Clock { return sam.clock }
+
+// Config gets the machine info
+func (sam *SAM) Config() *machine.Config { return &sam.config }
+
+// CPU gets the machine CPU
+func (sam *SAM) CPU() cpu.CPU { return sam.cpu }
+
+// Components gets the machine components
+func (sam *SAM) Components() *device.Components { return sam.components }
+
+// InitControl connect controllers & components
+func (sam *SAM) InitControl(control machine.Control) {
+	// Bind devices
+	control.BindVideo(sam.asic)
+	control.BindAudio(sam.mixer)
+	control.BindKeyboard(sam.keyboard)
+	// Register formats
+	control.RegisterDisk(format.MGT)
+	control.RegisterDisk(format.SAD)
+	sam.control = control
+}
+
+// Emulation control
+
+// BeginFrame begin emulation frame tasks
+func (sam *SAM) BeginFrame() {
+	sam.saaTicks = 0
+}
+
+// Emulate one machine step
+func (sam *SAM) Emulate() {
+	// ASIC line & frame interrupts
+	status := sam.asic.Status(sam.clock.Tstates())
+	sam.cpu.InterruptRequest(status != asicStatusInts)
+
+	// Executes a CPU instruction
+	sam.cpu.Execute()
+}
+
+// EndFrame end emulation frame tasks
+func (sam *SAM) EndFrame() {
+	sam.emulateSAA()
+}
+
+// frameTstates the current tstate, limited to the frame
+func (sam *SAM) frameTstates() int {
+	tstates := sam.clock.Tstates()
+	if tstates > samTStates {
+		tstates = samTStates
+	}
+	return tstates
+}
+
+// emulateSAA emulates the SAA1099 up to the current tstate
+func (sam *SAM) emulateSAA() {
+	ticks := sam.frameTstates()*samSAATicks/samTStates - sam.saaTicks
+	if ticks > 0 {
+		sam.saa.Emulate(ticks)
+		sam.saaTicks += ticks
+	}
+}
+
+// SAM IO bus
+// -----------------------------------------------------------------------------
+
+// Read bus at address
+func (sam *SAM) Read(address uint16) byte {
+	port, high := byte(address), byte(address>>8)
+	if sam.disks.IsPort(port) {
+		return sam.disks.Read(port)
+	}
+	switch port {
+	case samPortCLUT:
+		if high&samPortA8 != 0 {
+			return sam.asic.HPEN(sam.clock.Tstates())
+		}
+		return sam.asic.LPEN(sam.clock.Tstates())
+	case samPortLine: // status
+		status := sam.asic.Status(sam.clock.Tstates())
+		return status | sam.keyboard.Read(high)&samKeysHigh
+	case samPortLMPR:
+		return sam.mapper.lmpr
+	case samPortHMPR:
+		return sam.mapper.hmpr
+	case samPortVMPR:
+		return sam.asic.VMPR()
+	case samPortULA:
+		return samKeysHigh | sam.keyboard.Read(high)&samKeysLow
+	}
+	return 0xff
+}
+
+// Write bus at address
+func (sam *SAM) Write(address uint16, data byte) {
+	port, high := byte(address), byte(address>>8)
+	if sam.disks.IsPort(port) {
+		sam.disks.Write(port, data)
+		return
+	}
+	switch port {
+	case samPortCLUT:
+		sam.asic.SetClut(high, data)
+	case samPortLine:
+		sam.asic.SetLine(data)
+	case samPortLMPR:
+		sam.mapper.SetLMPR(data)
+	case samPortHMPR:
+		sam.asic.Update() // mode 3 CLUT select
+		sam.mapper.SetHMPR(data)
+	case samPortVMPR:
+		sam.asic.SetVMPR(data)
+	case samPortULA:
+		sam.asic.SetBorder(data)
+		sam.setBeeper(data&asicBeeper != 0)
+	case samPortSAA:
+		sam.emulateSAA()
+		if high&samPortA8 != 0 {
+			sam.saa.SelectRegister(data)
+		} else {
+			sam.saa.WriteData(data)
+		}
+	}
+}
+
+// setBeeper sets the beeper level
+func (sam *SAM) setBeeper(on bool) {
+	level := 0
+	if on {
+		level = 1
+	}
+	sam.beeper.SetLevel(sam.frameTstates(), level)
+}
+
+// Snapshots : load & save state
+
+// LoadState loads a snapshot. Programs are loaded from disk.
+func (sam *SAM) LoadState(state machine.State) {
+	log.Println("SAM : Not implemented snap format:", state.Format)
+}
+
+// SaveState snapshots are not implemented
+func (sam *SAM) SaveState() machine.State {
+	log.Println("SAM : Snapshots not implemented")
+	return machine.State{}
+}
+
+// Disks
+
+// InsertDisk inserts a disk image into the first drive
+func (sam *SAM) InsertDisk(ext string, data []byte) error {
+	return sam.disks.InsertDisk(ext, data)
+}
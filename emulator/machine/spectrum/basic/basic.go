@@ -0,0 +1,172 @@
+// Package basic implements Sinclair BASIC program conversion
+package basic
+
+import (
+	"fmt"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Sinclair BASIC
+// -----------------------------------------------------------------------------
+
+// Sinclair BASIC character codes
+const (
+	CharNumber   = 0x0e // Embedded number marker
+	CharEnter    = 0x0d // End of line
+	CharInk      = 0x10 // INK control
+	CharOver     = 0x15 // OVER control
+	CharAt       = 0x16 // AT control
+	CharTab      = 0x17 // TAB control
+	CharPound    = 0x60 // Pound sign
+	CharCopy     = 0x7f // Copyright sign
+	TokenFirst   = 0xa3 // First token (SPECTRUM)
+	MaxLineNum   = 9999 // Maximum line number
+	NumberLength = 5    // Embedded number length
+)
+
+// Tokens Sinclair BASIC 48k / 128k keywords from 0xA3
+var Tokens = [...]string{
+	"SPECTRUM", "PLAY", "RND", "INKEY$", "PI", "FN", "POINT", "SCREEN$", "ATTR",
+	"AT", "TAB", "VAL$", "CODE", "VAL", "LEN", "SIN", "COS", "TAN", "ASN", "ACS",
+	"ATN", "LN", "EXP", "INT", "SQR", "SGN", "ABS", "PEEK", "IN", "USR", "STR$",
+	"CHR$", "NOT", "BIN", "OR", "AND", "<=", ">=", "<>", "LINE", "THEN", "TO",
+	"STEP", "DEF FN", "CAT", "FORMAT", "MOVE", "ERASE", "OPEN #", "CLOSE #",
+	"MERGE", "VERIFY", "BEEP", "CIRCLE", "INK", "PAPER", "FLASH", "BRIGHT",
+	"INVERSE", "OVER", "OUT", "LPRINT", "LLIST", "STOP", "READ", "DATA",
+	"RESTORE", "NEW", "BORDER", "CONTINUE", "DIM", "REM", "FOR", "GO TO",
+	"GO SUB", "INPUT", "LOAD", "LIST", "LET", "PAUSE", "NEXT", "POKE", "PRINT",
+	"PLOT", "RUN", "SAVE", "RANDOMIZE", "IF", "CLS", "DRAW", "CLEAR", "RETURN",
+	"COPY",
+}
+
+// Token returns the keyword of a token code
+func Token(code byte) string {
+	if code < TokenFirst {
+		return ""
+	}
+	return Tokens[code-TokenFirst]
+}
+
+// tokenLeadingSpace returns if a leading space is listed before a token. As
+// the ROM does, the functions RND to NOT and the operators are listed
+// without it.
+func tokenLeadingSpace(code byte) bool {
+	return code >= tokenBin && isLetter(Token(code)[0])
+}
+
+// tokenTrailingSpace returns if a trailing space is listed after a token
+func tokenTrailingSpace(code byte) bool {
+	switch code {
+	case 0xa5, 0xa6, 0xa7: // RND, INKEY$, PI
+		return false
+	}
+	keyword := Token(code)
+	last := keyword[len(keyword)-1]
+	return isLetter(last) || last == '$' || last == '#'
+}
+
+func isLetter(char byte) bool {
+	return (char >= 'A' && char <= 'Z') || (char >= 'a' && char <= 'z')
+}
+
+// -----------------------------------------------------------------------------
+// Detokenizer
+// -----------------------------------------------------------------------------
+
+// Line is a BASIC program line
+type Line struct {
+	Number int    // Line number
+	Data   []byte // Tokenized line data (without the end of line)
+}
+
+// Lines splits a tokenized BASIC program in lines. The variables area, if
+// present, is ignored.
+func Lines(program []byte) []Line {
+	lines := make([]Line, 0)
+	for pos := 0; pos+4 <= len(program); {
+		number := int(program[pos])<<8 | int(program[pos+1])
+		if number > MaxLineNum {
+			break // variables area
+		}
+		length := int(program[pos+2]) | int(program[pos+3])<<8
+		pos += 4
+		end := pos + length
+		if end > len(program) {
+			end = len(program)
+		}
+		data := program[pos:end]
+		if len(data) > 0 && data[len(data)-1] == CharEnter {
+			data = data[:len(data)-1]
+		}
+		lines = append(lines, Line{number, data})
+		pos = end
+	}
+	return lines
+}
+
+// Detokenize converts a tokenized BASIC program to a text listing
+func Detokenize(program []byte) string {
+	var listing strings.Builder
+	for _, line := range Lines(program) {
+		listing.WriteString(fmt.Sprintf("%d ", line.Number))
+		listing.WriteString(DetokenizeLine(line.Data))
+		listing.WriteByte('\n')
+	}
+	return listing.String()
+}
+
+// DetokenizeLine converts a tokenized BASIC line to text. Non printable
+// characters are escaped as \{code}.
+func DetokenizeLine(data []byte) string {
+	var text strings.Builder
+	last := byte(' ')
+	space := false // pending token trailing space
+	write := func(s string) {
+		if space {
+			text.WriteByte(' ')
+			space = false
+		}
+		text.WriteString(s)
+		last = s[len(s)-1]
+	}
+	for pos := 0; pos < len(data); pos++ {
+		char := data[pos]
+		switch {
+		case char == CharNumber:
+			pos += NumberLength // hidden number value
+		case char >= CharInk && char <= CharTab:
+			params := 1
+			if char >= CharAt {
+				params = 2
+			}
+			write(escape(char))
+			for ; params > 0 && pos+1 < len(data); params-- {
+				pos++
+				write(escape(data[pos]))
+			}
+		case char >= TokenFirst:
+			if tokenLeadingSpace(char) && last != ' ' && !space {
+				write(" ")
+			}
+			write(Token(char))
+			space = tokenTrailingSpace(char)
+		case char == '\\':
+			write("\\\\")
+		case char == CharPound:
+			write("£")
+		case char == CharCopy:
+			write("©")
+		case char >= 0x20 && char < 0x80:
+			write(string(rune(char)))
+		default:
+			write(escape(char))
+		}
+	}
+	return text.String()
+}
+
+// escape escapes a non printable character
+func escape(char byte) string {
+	return fmt.Sprintf("\\{%d}", char)
+}
@@ -0,0 +1,27 @@
+package basic
+
+import "testing"
+
+// TestRoundTrip tokenizes and lists BASIC lines, the listing must match the
+// ROM listing of the line
+func TestRoundTrip(t *testing.T) {
+	lines := []string{
+		"10 PRINT FN f(3); BIN 1010",
+		"20 LET a=RND*10: IF a<=5 THEN GO TO 10",
+		"30 FOR i=1 TO 10 STEP 2: NEXT i",
+		"40 PRINT AT 0,0;\"Hello\"; INK 2; PAPER 6;a$",
+		"50 DEF FN f(x)=x*x",
+		"60 REM a comment; BIN",
+	}
+	for _, line := range lines {
+		program, err := Tokenize(line)
+		if err != nil {
+			t.Errorf("%q : %s", line, err.Error())
+			continue
+		}
+		listing := Detokenize(program)
+		if listing != line+"\n" {
+			t.Errorf("%q : listed as %q", line, listing)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package basic
+
+import "errors"
+
+// -----------------------------------------------------------------------------
+// BASIC program in memory
+// -----------------------------------------------------------------------------
+
+// Memory is the machine memory access
+type Memory interface {
+	Peek(address uint16) byte       // Peek reads a byte
+	Poke(address uint16, data byte) // Poke writes a byte
+}
+
+// Sinclair BASIC system variables
+const (
+	SysVars   = 0x5c4b // VARS : Variables address
+	SysDatadd = 0x5c57 // DATADD : Address of last item of data
+	SysProg   = 0x5c53 // PROG : Program address
+	SysNxtlin = 0x5c55 // NXTLIN : Address of next line
+	SysKCur   = 0x5c5b // K_CUR : Cursor address
+	SysELine  = 0x5c59 // E_LINE : Command line address
+	SysChAdd  = 0x5c5d // CH_ADD : Address of next character to interpret
+	SysXPtr   = 0x5c5f // X_PTR : Syntax error address
+	SysWorksp = 0x5c61 // WORKSP : Workspace address
+	SysStkbot = 0x5c63 // STKBOT : Calculator stack bottom
+	SysStkend = 0x5c65 // STKEND : Calculator stack end
+	SysRamtop = 0x5cb2 // RAMTOP : Last byte of BASIC system area
+)
+
+// Program memory markers
+const (
+	endOfVariables = 0x80
+	freeMargin     = 0x100 // minimum free memory for stacks
+)
+
+func peekWord(memory Memory, address uint16) uint16 {
+	return uint16(memory.Peek(address)) | uint16(memory.Peek(address+1))<<8
+}
+
+func pokeWord(memory Memory, address uint16, value uint16) {
+	memory.Poke(address, byte(value))
+	memory.Poke(address+1, byte(value>>8))
+}
+
+// ReadProgram reads the tokenized BASIC program from memory (PROG to VARS)
+func ReadProgram(memory Memory) []byte {
+	prog := peekWord(memory, SysProg)
+	vars := peekWord(memory, SysVars)
+	if vars < prog {
+		return nil
+	}
+	program := make([]byte, vars-prog)
+	for i := range program {
+		program[i] = memory.Peek(prog + uint16(i))
+	}
+	return program
+}
+
+// WriteProgram writes a tokenized BASIC program into memory at PROG. The
+// variables and the command line are cleared.
+func WriteProgram(memory Memory, program []byte) error {
+	prog := int(peekWord(memory, SysProg))
+	ramtop := int(peekWord(memory, SysRamtop))
+	vars := prog + len(program)
+	eline := vars + 1
+	worksp := eline + 2
+	if worksp+freeMargin > ramtop {
+		return errors.New("program too big")
+	}
+	for i, data := range program {
+		memory.Poke(uint16(prog+i), data)
+	}
+	memory.Poke(uint16(vars), endOfVariables)
+	memory.Poke(uint16(eline), CharEnter)
+	memory.Poke(uint16(eline+1), endOfVariables)
+	pokeWord(memory, SysVars, uint16(vars))
+	pokeWord(memory, SysDatadd, uint16(prog-1))
+	pokeWord(memory, SysNxtlin, uint16(prog))
+	pokeWord(memory, SysELine, uint16(eline))
+	pokeWord(memory, SysKCur, uint16(eline))
+	pokeWord(memory, SysChAdd, uint16(eline))
+	pokeWord(memory, SysXPtr, 0)
+	pokeWord(memory, SysWorksp, uint16(worksp))
+	pokeWord(memory, SysStkbot, uint16(worksp))
+	pokeWord(memory, SysStkend, uint16(worksp))
+	return nil
+}
@@ -0,0 +1,285 @@
+package basic
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Tokenizer
+// -----------------------------------------------------------------------------
+
+// Token codes
+const (
+	tokenRem = 0xea
+	tokenBin = 0xc4
+)
+
+// Tokenize converts a text listing to a tokenized BASIC program. Lines must
+// start with a line number and are kept in listing order.
+func Tokenize(listing string) ([]byte, error) {
+	program := make([]byte, 0)
+	for n, text := range strings.Split(listing, "\n") {
+		text = strings.TrimSpace(strings.TrimSuffix(text, "\r"))
+		if text == "" {
+			continue
+		}
+		digits := 0
+		for digits < len(text) && text[digits] >= '0' && text[digits] <= '9' {
+			digits++
+		}
+		number, err := strconv.Atoi(text[:digits])
+		if err != nil || number > MaxLineNum {
+			return nil, fmt.Errorf("line %d: invalid line number", n+1)
+		}
+		data, err := TokenizeLine(strings.TrimLeft(text[digits:], " "))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %s", n+1, err.Error())
+		}
+		data = append(data, CharEnter)
+		program = append(program, byte(number>>8), byte(number), byte(len(data)), byte(len(data)>>8))
+		program = append(program, data...)
+	}
+	return program, nil
+}
+
+// TokenizeLine converts a text line (without line number) to tokenized data
+func TokenizeLine(text string) ([]byte, error) {
+	chars, err := decodeText(text)
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, 0, len(chars))
+	quoted, rem, bin := false, false, false
+	for pos := 0; pos < len(chars); {
+		char := chars[pos]
+		if rem || quoted && char != '"' {
+			data = append(data, char)
+			pos++
+			continue
+		}
+		if char == '"' {
+			quoted = !quoted
+			data = append(data, char)
+			pos++
+			continue
+		}
+		// keywords
+		if code, length := matchToken(chars, pos); length > 0 {
+			if len(data) > 0 && data[len(data)-1] == ' ' {
+				data = data[:len(data)-1] // listing space
+			}
+			data = append(data, code)
+			pos += length
+			for pos < len(chars) && chars[pos] == ' ' {
+				pos++
+			}
+			rem = code == tokenRem
+			bin = code == tokenBin
+			continue
+		}
+		// numbers
+		if isNumberStart(chars, pos) && !isIdentifier(data) {
+			length := numberLength(chars, pos, bin)
+			literal := string(chars[pos : pos+length])
+			data = append(data, chars[pos:pos+length]...)
+			value, err := parseNumber(literal, bin)
+			if err != nil {
+				return nil, err
+			}
+			number, err := EncodeNumber(value)
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, CharNumber)
+			data = append(data, number[:]...)
+			pos += length
+			bin = false
+			continue
+		}
+		data = append(data, char)
+		pos++
+	}
+	return data, nil
+}
+
+// decodeText converts text to Spectrum characters, decoding escapes
+func decodeText(text string) ([]byte, error) {
+	chars := make([]byte, 0, len(text))
+	runes := []rune(text)
+	for pos := 0; pos < len(runes); pos++ {
+		r := runes[pos]
+		switch {
+		case r == '\\' && pos+1 < len(runes) && runes[pos+1] == '\\':
+			chars = append(chars, '\\')
+			pos++
+		case r == '\\' && pos+1 < len(runes) && runes[pos+1] == '{':
+			end := strings.IndexRune(string(runes[pos:]), '}')
+			if end < 0 {
+				return nil, errors.New("unterminated escape")
+			}
+			escape := string(runes[pos+2 : pos+end])
+			code, err := strconv.Atoi(escape)
+			if err != nil || code < 0 || code > 0xff {
+				return nil, fmt.Errorf("invalid escape: %s", escape)
+			}
+			chars = append(chars, byte(code))
+			pos += end
+		case r == '£':
+			chars = append(chars, CharPound)
+		case r == '©':
+			chars = append(chars, CharCopy)
+		case r < 0x80:
+			chars = append(chars, byte(r))
+		default:
+			return nil, fmt.Errorf("invalid character: %c", r)
+		}
+	}
+	return chars, nil
+}
+
+// matchToken returns the longest keyword at position
+func matchToken(chars []byte, pos int) (byte, int) {
+	if pos > 0 && isLetter(chars[pos-1]) && isLetter(chars[pos]) {
+		return 0, 0 // inside a name
+	}
+	code, length := byte(0), 0
+	for i, keyword := range Tokens {
+		n := matchKeyword(chars, pos, keyword)
+		if n > length {
+			code, length = TokenFirst+byte(i), n
+		}
+	}
+	return code, length
+}
+
+// matchKeyword returns the matched length of keyword at position. Keyword
+// spaces are optional (GOTO, GO TO).
+func matchKeyword(chars []byte, pos int, keyword string) int {
+	start := pos
+	for i := 0; i < len(keyword); i++ {
+		if keyword[i] == ' ' {
+			for pos < len(chars) && chars[pos] == ' ' {
+				pos++
+			}
+			continue
+		}
+		if pos >= len(chars) || upper(chars[pos]) != keyword[i] {
+			return 0
+		}
+		pos++
+	}
+	last := keyword[len(keyword)-1]
+	if isLetter(last) && pos < len(chars) && (isLetter(chars[pos]) || isDigit(chars[pos])) &&
+		!strings.HasSuffix(keyword, "FN") {
+		return 0 // part of a name
+	}
+	return pos - start
+}
+
+func upper(char byte) byte {
+	if char >= 'a' && char <= 'z' {
+		return char - 'a' + 'A'
+	}
+	return char
+}
+
+func isDigit(char byte) bool {
+	return char >= '0' && char <= '9'
+}
+
+// isIdentifier checks if tokenized data ends with a name
+func isIdentifier(data []byte) bool {
+	for i := len(data) - 1; i >= 0; i-- {
+		char := data[i]
+		if isLetter(char) {
+			return true
+		}
+		if !isDigit(char) {
+			return false
+		}
+	}
+	return false
+}
+
+func isNumberStart(chars []byte, pos int) bool {
+	char := chars[pos]
+	return isDigit(char) || (char == '.' && pos+1 < len(chars) && isDigit(chars[pos+1]))
+}
+
+// numberLength returns the length of a number literal
+func numberLength(chars []byte, pos int, bin bool) int {
+	start := pos
+	if bin {
+		for pos < len(chars) && (chars[pos] == '0' || chars[pos] == '1') {
+			pos++
+		}
+		return pos - start
+	}
+	for pos < len(chars) && (isDigit(chars[pos]) || chars[pos] == '.') {
+		pos++
+	}
+	if pos < len(chars) && upper(chars[pos]) == 'E' {
+		exp := pos + 1
+		if exp < len(chars) && (chars[exp] == '+' || chars[exp] == '-') {
+			exp++
+		}
+		if exp < len(chars) && isDigit(chars[exp]) {
+			for pos = exp; pos < len(chars) && isDigit(chars[pos]); pos++ {
+			}
+		}
+	}
+	return pos - start
+}
+
+func parseNumber(literal string, bin bool) (float64, error) {
+	if bin {
+		value, err := strconv.ParseUint(literal, 2, 16)
+		return float64(value), err
+	}
+	value, err := strconv.ParseFloat(literal, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number: %s", literal)
+	}
+	return value, nil
+}
+
+// EncodeNumber encodes a number in the 5 bytes Sinclair BASIC format
+func EncodeNumber(value float64) ([NumberLength]byte, error) {
+	var number [NumberLength]byte
+	if value == math.Trunc(value) && math.Abs(value) <= 0xffff {
+		// small integer
+		integer := int(value)
+		if integer < 0 {
+			number[1] = 0xff
+			integer += 0x10000
+		}
+		number[2] = byte(integer)
+		number[3] = byte(integer >> 8)
+		return number, nil
+	}
+	mantissa, exponent := math.Frexp(math.Abs(value))
+	bits := uint64(math.Round(mantissa * (1 << 32)))
+	if bits == 1<<32 {
+		bits >>= 1
+		exponent++
+	}
+	if exponent+128 < 1 {
+		return number, nil // zero
+	}
+	if exponent+128 > 0xff {
+		return number, errors.New("number too big")
+	}
+	number[0] = byte(exponent + 128)
+	number[1] = byte(bits>>24) & 0x7f
+	if value < 0 {
+		number[1] |= 0x80
+	}
+	number[2] = byte(bits >> 16)
+	number[3] = byte(bits >> 8)
+	number[4] = byte(bits)
+	return number, nil
+}
@@ -0,0 +1,142 @@
+package spectrum
+
+import (
+	"errors"
+
+	"github.com/jtruco/emu8/emulator/device/io/disk"
+	"github.com/jtruco/emu8/emulator/device/io/fdc"
+	"github.com/jtruco/emu8/emulator/machine/spectrum/format"
+)
+
+// -----------------------------------------------------------------------------
+// Beta 128 Disk Interface
+// -----------------------------------------------------------------------------
+
+// Beta disk constants
+const (
+	betaDrives     = 4      // Number of disk drives
+	betaEntry      = 0x3d00 // TR-DOS ROM entry page
+	betaPortMask   = 0x83   // Beta ports address mask
+	betaPortFDC    = 0x03   // WD1793 registers : 0x1f, 0x3f, 0x5f, 0x7f
+	betaPortSystem = 0x83   // System register : 0xff
+)
+
+// Beta is the Beta 128 disk interface : a WD1793 controller with the TR-DOS
+// ROM. The TR-DOS ROM pages in when the CPU fetches an opcode in the 0x3dxx
+// page with the 48K BASIC ROM mapped, and pages out when the CPU fetches an
+// opcode from RAM. The interface ports are only decoded while TR-DOS is
+// paged in.
+type Beta struct {
+	spectrum *Spectrum   // The spectrum machine
+	fdc      *fdc.WD1793 // The floppy disk controller
+	system   byte        // The system register
+}
+
+// NewBeta creates the Beta 128 disk interface
+func NewBeta(spectrum *Spectrum) *Beta {
+	beta := new(Beta)
+	beta.spectrum = spectrum
+	beta.fdc = fdc.New(spectrum.clock, spectrum.timing.frame*zxFPS, betaDrives)
+	return beta
+}
+
+// Active checks if the TR-DOS ROM is paged in
+func (beta *Beta) Active() bool { return beta.spectrum.paging.trdos }
+
+// Drive gets the disk drive at index
+func (beta *Beta) Drive(index int) *disk.Drive { return beta.fdc.Drive(index) }
+
+// Device
+
+// Init initializes the interface
+func (beta *Beta) Init() { beta.Reset() }
+
+// Reset resets the interface
+func (beta *Beta) Reset() {
+	beta.fdc.Reset()
+	beta.writeSystem(0)
+}
+
+// onFetch pages in and out the TR-DOS ROM
+func (beta *Beta) onFetch(address uint16, opcode byte) byte {
+	paging := beta.spectrum.paging
+	if paging.trdos {
+		if address >= 0x4000 {
+			paging.SetTRDOS(false)
+		}
+	} else if address&0xff00 == betaEntry && paging.IsROM48() {
+		paging.SetTRDOS(true)
+		return beta.spectrum.memory.Peek(address)
+	}
+	return opcode
+}
+
+// Ports
+
+// Read reads the interface ports, returns false if not selected
+func (beta *Beta) Read(address uint16) (byte, bool) {
+	if !beta.Active() {
+		return 0xff, false
+	}
+	switch address & betaPortMask {
+	case betaPortFDC:
+		return beta.fdc.Read(int(address>>5) & 0x03), true
+	case betaPortSystem:
+		result := byte(0x3f)
+		if beta.fdc.INTRQ() {
+			result |= 0x80
+		}
+		if beta.fdc.DRQ() {
+			result |= 0x40
+		}
+		return result, true
+	}
+	return 0xff, false
+}
+
+// Write writes the interface ports, returns false if not selected
+func (beta *Beta) Write(address uint16, data byte) bool {
+	if !beta.Active() {
+		return false
+	}
+	switch address & betaPortMask {
+	case betaPortFDC:
+		beta.fdc.Write(int(address>>5)&0x03, data)
+		return true
+	case betaPortSystem:
+		beta.writeSystem(data)
+		return true
+	}
+	return false
+}
+
+// writeSystem writes the system register : drive (bits 0-1), controller
+// reset (bit 2, low) and side (bit 4, low is the upper side)
+func (beta *Beta) writeSystem(data byte) {
+	beta.system = data
+	beta.fdc.Select(int(data & 0x03))
+	beta.fdc.SetSide(int(data>>4)&0x01 ^ 0x01)
+	if data&0x04 == 0 {
+		beta.fdc.Reset()
+	}
+}
+
+// Disks
+
+// InsertDisk inserts a disk image into the first drive
+func (beta *Beta) InsertDisk(ext string, data []byte) error {
+	var image *disk.Disk
+	switch ext {
+	case format.TRD:
+		image = format.LoadTRD(data)
+	case format.SCL:
+		image = format.LoadSCL(data)
+	default:
+		return errors.New("Not supported disk format: " + ext)
+	}
+	if image == nil {
+		return errors.New("Invalid disk image")
+	}
+	beta.fdc.Drive(0).Insert(image)
+	return nil
+}
@@ -0,0 +1,74 @@
+package format
+
+import "log"
+
+// -----------------------------------------------------------------------------
+// DCK cartridge format (Timex TS2068)
+// -----------------------------------------------------------------------------
+
+// DCK format extension
+const DCK = "dck"
+
+// DCK bank IDs
+const (
+	DockBankDock  = 0   // DOCK cartridge bank
+	DockBankExrom = 254 // EXROM bank
+	DockBankHome  = 255 // HOME bank
+)
+
+// DCK constants
+const (
+	dckChunks    = 8
+	dckChunkSize = 0x2000
+	dckRAM       = 0x01 // Chunk is RAM
+	dckData      = 0x02 // Chunk data present
+)
+
+// DockChunk is a cartridge 8K memory chunk
+type DockChunk struct {
+	RAM  bool   // Chunk is RAM, ROM otherwise
+	Data []byte // Chunk data, nil if empty
+}
+
+// DockBank is a cartridge memory bank of 8 chunks, nil chunks are absent
+type DockBank struct {
+	ID     byte                  // Bank ID
+	Chunks [dckChunks]*DockChunk // Bank chunks
+}
+
+// LoadDCK loads the memory banks from DCK data format. Each bank has an ID
+// byte, a chunk type byte for each chunk, and the data of the chunks.
+func LoadDCK(data []byte) []*DockBank {
+	banks := make([]*DockBank, 0)
+	length := len(data)
+	for pos := 0; pos < length; {
+		if pos+1+dckChunks > length {
+			log.Println("DCK : Invalid file format")
+			return nil
+		}
+		bank := &DockBank{ID: data[pos]}
+		types := data[pos+1 : pos+1+dckChunks]
+		pos += 1 + dckChunks
+		for i, chunkType := range types {
+			if chunkType == 0 {
+				continue // absent
+			}
+			chunk := &DockChunk{RAM: chunkType&dckRAM != 0}
+			if chunkType&dckData != 0 {
+				if pos+dckChunkSize > length {
+					log.Println("DCK : Invalid file format")
+					return nil
+				}
+				chunk.Data = data[pos : pos+dckChunkSize]
+				pos += dckChunkSize
+			}
+			bank.Chunks[i] = chunk
+		}
+		banks = append(banks, bank)
+	}
+	if len(banks) == 0 {
+		log.Println("DCK : Invalid file format")
+		return nil
+	}
+	return banks
+}
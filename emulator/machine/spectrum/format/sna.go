@@ -50,9 +50,9 @@ func LoadSNA(data []byte) *Snapshot {
 	snap.A = data[22]
 	snap.SP = readWord(data, 23)
 	snap.IM = data[25] & 0x03
-	snap.PC = 0x72 // RETN at address 0x72
 	snap.Border = data[26] & 0x07
 	copy(snap.Memory[0:0xc000], data[27:])
+	snap.PC = snap.pop()
 	snap.Tstates = 0
 	return snap
 }
@@ -87,9 +87,26 @@ func (snap *Snapshot) SaveSNA() []byte {
 	data[20] = snap.R
 	data[21] = snap.F
 	data[22] = snap.A
-	writeWord(data, 23, snap.SP)
 	data[25] = snap.IM & 0x03
 	data[26] = snap.Border & 0x07
 	copy(data[27:], snap.Memory[0:0xc000])
+	// PC is pushed onto the stack
+	sp := snap.SP - 2
+	snaWriteMemory(data, sp, byte(snap.PC))
+	snaWriteMemory(data, sp+1, byte(snap.PC>>8))
+	writeWord(data, 23, sp)
 	return data
 }
+
+// pop pops a word from the snap stack
+func (snap *Snapshot) pop() uint16 {
+	value := uint16(snap.Peek(snap.SP)) | uint16(snap.Peek(snap.SP+1))<<8
+	snap.SP += 2
+	return value
+}
+
+func snaWriteMemory(data []byte, address uint16, value byte) {
+	if address >= 0x4000 {
+		data[27+int(address-0x4000)] = value
+	}
+}
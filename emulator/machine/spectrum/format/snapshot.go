@@ -21,3 +21,18 @@ func NewSnapshot() *Snapshot {
 	snap.State.Init()
 	return snap
 }
+
+// Peek reads a RAM byte from address (ROM reads as 0xff)
+func (snap *Snapshot) Peek(address uint16) byte {
+	if address < 0x4000 {
+		return 0xff
+	}
+	return snap.Memory[address-0x4000]
+}
+
+// Poke writes a RAM byte to address (ROM writes are ignored)
+func (snap *Snapshot) Poke(address uint16, data byte) {
+	if address >= 0x4000 {
+		snap.Memory[address-0x4000] = data
+	}
+}
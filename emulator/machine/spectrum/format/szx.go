@@ -0,0 +1,187 @@
+package format
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io/ioutil"
+	"log"
+)
+
+// -----------------------------------------------------------------------------
+// SZX snapshot format (zx-state)
+// Version : 1.4. Only 16k and 48k machines supported
+// -----------------------------------------------------------------------------
+
+// SZX format extension
+const SZX = "szx"
+
+const (
+	_SZXSignature     = "ZXST"
+	_SZXHeaderLength  = 8
+	_SZXMajorVersion  = 1
+	_SZXMinorVersion  = 4
+	_SZXMachine16k    = 0
+	_SZXMachine48k    = 1
+	_SZXZ80RLength    = 37
+	_SZXSPCRLength    = 8
+	_SZXPageSize      = 0x4000
+	_SZXPageCompessed = 0x01
+)
+
+// SZX block identifiers
+const (
+	_SZXBlockZ80R = "Z80R"
+	_SZXBlockSPCR = "SPCR"
+	_SZXBlockRAMP = "RAMP"
+)
+
+var szxPageAddresses = map[byte]int{5: 0x0000, 2: 0x4000, 0: 0x8000}
+
+// LoadSZX loads snap from SZX data format
+func LoadSZX(data []byte) *Snapshot {
+	if len(data) < _SZXHeaderLength || string(data[0:4]) != _SZXSignature {
+		log.Println("SZX : Invalid file format")
+		return nil
+	}
+	machine := data[6]
+	if machine != _SZXMachine16k && machine != _SZXMachine48k {
+		log.Println("SZX : Machine not supported :", machine)
+		return nil
+	}
+	snap := NewSnapshot()
+	loaded := false
+	for pos := _SZXHeaderLength; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := readIntN(data, pos+4, 4)
+		pos += 8
+		if size < 0 || pos+size > len(data) {
+			log.Println("SZX : Invalid block size :", id)
+			return nil
+		}
+		block := data[pos : pos+size]
+		pos += size
+		switch id {
+		case _SZXBlockZ80R:
+			if size < _SZXZ80RLength {
+				log.Println("SZX : Invalid Z80R block")
+				return nil
+			}
+			szxLoadRegisters(block, snap)
+			loaded = true
+		case _SZXBlockSPCR:
+			if size >= _SZXSPCRLength {
+				snap.Border = block[0] & 0x07
+			}
+		case _SZXBlockRAMP:
+			if !szxLoadPage(block, snap) {
+				return nil
+			}
+		}
+	}
+	if !loaded {
+		log.Println("SZX : Z80R block not found")
+		return nil
+	}
+	return snap
+}
+
+func szxLoadRegisters(data []byte, snap *Snapshot) {
+	snap.F, snap.A = data[0], data[1]
+	snap.C, snap.B = data[2], data[3]
+	snap.E, snap.D = data[4], data[5]
+	snap.L, snap.H = data[6], data[7]
+	snap.Fx, snap.Ax = data[8], data[9]
+	snap.Cx, snap.Bx = data[10], data[11]
+	snap.Ex, snap.Dx = data[12], data[13]
+	snap.Lx, snap.Hx = data[14], data[15]
+	snap.IXl, snap.IXh = data[16], data[17]
+	snap.IYl, snap.IYh = data[18], data[19]
+	snap.SP = readWord(data, 20)
+	snap.PC = readWord(data, 22)
+	snap.I = data[24]
+	snap.R = data[25]
+	snap.IFF1 = data[26] != 0
+	snap.IFF2 = data[27] != 0
+	snap.IM = data[28] & 0x03
+	snap.Tstates = readIntN(data, 29, 4)
+}
+
+func szxLoadPage(data []byte, snap *Snapshot) bool {
+	if len(data) < 3 {
+		log.Println("SZX : Invalid RAMP block")
+		return false
+	}
+	flags := readWord(data, 0)
+	address, ok := szxPageAddresses[data[2]]
+	if !ok {
+		return true // page not used in 48k mode
+	}
+	page := data[3:]
+	if (flags & _SZXPageCompessed) != 0 {
+		reader, err := zlib.NewReader(bytes.NewReader(page))
+		if err == nil {
+			page, err = ioutil.ReadAll(reader)
+		}
+		if err != nil {
+			log.Println("SZX : Error decompressing page :", err)
+			return false
+		}
+	}
+	if len(page) != _SZXPageSize {
+		log.Println("SZX : Invalid page size")
+		return false
+	}
+	copy(snap.Memory[address:], page)
+	return true
+}
+
+// SaveSZX saves snap to SZX data format (48k)
+func (snap *Snapshot) SaveSZX() []byte {
+	var buffer bytes.Buffer
+	buffer.WriteString(_SZXSignature)
+	buffer.Write([]byte{_SZXMajorVersion, _SZXMinorVersion, _SZXMachine48k, 0})
+	// registers
+	registers := make([]byte, _SZXZ80RLength)
+	copy(registers, []byte{
+		snap.F, snap.A, snap.C, snap.B, snap.E, snap.D, snap.L, snap.H,
+		snap.Fx, snap.Ax, snap.Cx, snap.Bx, snap.Ex, snap.Dx, snap.Lx, snap.Hx,
+		snap.IXl, snap.IXh, snap.IYl, snap.IYh})
+	writeWord(registers, 20, snap.SP)
+	writeWord(registers, 22, snap.PC)
+	registers[24] = snap.I
+	registers[25] = snap.R
+	if snap.IFF1 {
+		registers[26] = 1
+	}
+	if snap.IFF2 {
+		registers[27] = 1
+	}
+	registers[28] = snap.IM & 0x03
+	writeWord(registers, 29, uint16(snap.Tstates))
+	writeWord(registers, 31, uint16(snap.Tstates>>16))
+	szxWriteBlock(&buffer, _SZXBlockZ80R, registers)
+	// spectrum registers
+	spectrum := make([]byte, _SZXSPCRLength)
+	spectrum[0] = snap.Border & 0x07
+	szxWriteBlock(&buffer, _SZXBlockSPCR, spectrum)
+	// memory pages
+	for _, page := range []byte{5, 2, 0} {
+		address := szxPageAddresses[page]
+		var compressed bytes.Buffer
+		compressed.Write([]byte{_SZXPageCompessed, 0, page})
+		writer := zlib.NewWriter(&compressed)
+		writer.Write(snap.Memory[address : address+_SZXPageSize])
+		writer.Close()
+		szxWriteBlock(&buffer, _SZXBlockRAMP, compressed.Bytes())
+	}
+	return buffer.Bytes()
+}
+
+func szxWriteBlock(buffer *bytes.Buffer, id string, data []byte) {
+	var size [4]byte
+	writeWord(size[:], 0, uint16(len(data)))
+	writeWord(size[:], 2, uint16(len(data)>>16))
+	buffer.WriteString(id)
+	buffer.Write(size[:])
+	buffer.Write(data)
+}
@@ -45,6 +45,18 @@ func (block *TapBlock) Data() []byte {
 	return block.data
 }
 
+// LoadData gets the ROM loader data bytes
+func (block *TapBlock) LoadData() []byte {
+	return block.data
+}
+
+// Meta gets the decoded block description
+func (block *TapBlock) Meta() *tape.BlockMeta {
+	meta := describeData(block.data)
+	meta.Timings = standardTimings(block.data, 1) // 1 ms end of block
+	return meta
+}
+
 // Tap implements the a tape format .TAP
 type Tap struct {
 	info        tape.Info    // Tape information
@@ -189,3 +201,27 @@ func (tap *Tap) Play(control *tape.Control) {
 		control.State = tapeStateStop
 	}
 }
+
+// SaveTAP saves the ROM loader data blocks of tape t to TAP data format
+func SaveTAP(t tape.Tape) []byte {
+	data := make([]byte, 0)
+	skipped := 0
+	for _, block := range t.Blocks() {
+		var loadData []byte
+		if dataBlock, ok := block.(tape.DataBlock); ok {
+			loadData = dataBlock.LoadData()
+		}
+		if loadData == nil {
+			skipped++
+			continue
+		}
+		var length [2]byte
+		writeWord(length[:], 0, uint16(len(loadData)))
+		data = append(data, length[:]...)
+		data = append(data, loadData...)
+	}
+	if skipped > 0 {
+		log.Println("Tape (TAP) : Skipped non data blocks:", skipped)
+	}
+	return data
+}
@@ -1,5 +1,11 @@
 package format
 
+import (
+	"fmt"
+
+	"github.com/jtruco/emu8/emulator/device/io/tape"
+)
+
 // -----------------------------------------------------------------------------
 // ZX Spectrum tape common constants
 // -----------------------------------------------------------------------------
@@ -12,6 +18,14 @@ const (
 	TapeFileCode           = 3
 )
 
+// Tape file type names
+var tapeFileTypeNames = map[byte]string{
+	TapeFileProgram:        "Program",
+	TapeFileNumberArray:    "Number array",
+	TapeFileCharacterArray: "Character array",
+	TapeFileCode:           "Bytes",
+}
+
 // Tape play states
 const (
 	tapeStateStart = iota
@@ -38,6 +52,55 @@ const (
 	tapeTimingEoB     = tapeEndBlockPause / 1000
 )
 
+// -----------------------------------------------------------------------------
+// Tape block metadata
+// -----------------------------------------------------------------------------
+
+// DataDescriber decodes the ROM loader data of a tape data block
+type DataDescriber func(data []byte) *tape.BlockMeta
+
+// describeData decodes the ROM loader data (flag, data & checksum)
+func describeData(data []byte) *tape.BlockMeta {
+	meta := new(tape.BlockMeta)
+	length := len(data)
+	if length == 19 && data[0] == tapBlockHeader {
+		header := new(tape.Header)
+		header.Type = data[1]
+		header.TypeName = tapeFileTypeNames[header.Type]
+		if header.TypeName == "" {
+			header.TypeName = "Unknown"
+		}
+		header.Name = readString(data, 2, 10)
+		header.Length = readInt(data, 12)
+		header.Param1 = readInt(data, 14)
+		header.Param2 = readInt(data, 16)
+		meta.Header = header
+		meta.Description = "Header"
+	} else if length >= 2 {
+		meta.Description = fmt.Sprintf("Data: %d bytes", length-2)
+	} else {
+		meta.Description = "Data"
+	}
+	return meta
+}
+
+// standardTimings returns the ROM loader timings
+func standardTimings(data []byte, pause int) *tape.Timings {
+	timings := &tape.Timings{
+		Pilot:       tapeTimingPilot,
+		PilotPulses: tapeDataPulses,
+		Sync1:       tapeTimingSync1,
+		Sync2:       tapeTimingSync2,
+		Zero:        tapeTimingZero,
+		One:         tapeTimingOne,
+		LastBits:    8,
+		Pause:       pause}
+	if len(data) > 0 && data[0] < 0x80 {
+		timings.PilotPulses = tapeHeaderPulses
+	}
+	return timings
+}
+
 // -----------------------------------------------------------------------------
 // Format common functions
 // -----------------------------------------------------------------------------
@@ -61,7 +124,7 @@ func writeWord(data []byte, pos int, value uint16) {
 // readIntN reads LSB unsgined integer as integer
 func readIntN(data []byte, pos int, len int) int {
 	value := uint(data[pos])
-	if len > 1 && len < 4 {
+	if len > 1 && len <= 4 {
 		lshift := uint(8)
 		for len > 1 {
 			pos++
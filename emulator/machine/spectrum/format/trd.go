@@ -0,0 +1,99 @@
+package format
+
+import (
+	"log"
+
+	"github.com/jtruco/emu8/emulator/device/io/disk"
+)
+
+// -----------------------------------------------------------------------------
+// TRD & SCL disk formats (TR-DOS)
+// -----------------------------------------------------------------------------
+
+// TR-DOS disk format extensions
+const (
+	TRD = "trd"
+	SCL = "scl"
+)
+
+// TR-DOS disk constants
+const (
+	trdSectors     = 16  // Sectors per track
+	trdSectorSize  = 256 // Sector size
+	trdTrackSize   = trdSectors * trdSectorSize
+	trdSystem      = 0x08e1 // System sector information (track 0, sector 9)
+	trdDiskType    = 0x08e3 // Disk type offset
+	trdDiskSize    = 160 * trdTrackSize
+	trdCatalogSize = 128 // Catalog entries
+	sclSignature   = "SINCLAIR"
+	sclEntrySize   = 14
+)
+
+// trdGeometries disk types : 80 or 40 cylinders, double or single side
+var trdGeometries = map[byte][2]int{
+	0x16: {80, 2},
+	0x17: {40, 2},
+	0x18: {80, 1},
+	0x19: {40, 1},
+}
+
+// LoadTRD loads a disk from TRD data format. TRD is the raw sector image,
+// logical tracks are stored by cylinder and side.
+func LoadTRD(data []byte) *disk.Disk {
+	if len(data) == 0 || len(data)%trdSectorSize != 0 || len(data) > trdDiskSize {
+		log.Println("TRD : Invalid file format")
+		return nil
+	}
+	geometry := [2]int{80, 2}
+	if len(data) > trdDiskType {
+		if g, ok := trdGeometries[data[trdDiskType]]; ok && len(data) <= g[0]*g[1]*trdTrackSize {
+			geometry = g
+		}
+	}
+	return disk.NewRegular(geometry[0], geometry[1], trdSectors, trdSectorSize, 1, data)
+}
+
+// LoadSCL loads a disk from SCL data format. The SCL archive files are
+// copied into a new 80 cylinders double side TRD disk.
+func LoadSCL(data []byte) *disk.Disk {
+	length := len(data)
+	if length < 9 || string(data[0:8]) != sclSignature {
+		log.Println("SCL : Invalid file format")
+		return nil
+	}
+	count := int(data[8])
+	pos := 9 + count*sclEntrySize
+	if count > trdCatalogSize || pos > length {
+		log.Println("SCL : Invalid file format")
+		return nil
+	}
+	image := make([]byte, trdDiskSize)
+	sector := trdSectors // first free logical sector : track 1
+	for i := 0; i < count; i++ {
+		entry := data[9+i*sclEntrySize:]
+		size := int(entry[13]) * trdSectorSize
+		if pos+size > length || sector*trdSectorSize+size > trdDiskSize {
+			log.Println("SCL : Invalid file format")
+			return nil
+		}
+		catalog := image[i*16:]
+		copy(catalog, entry[:sclEntrySize])
+		catalog[14] = byte(sector % trdSectors)
+		catalog[15] = byte(sector / trdSectors)
+		copy(image[sector*trdSectorSize:], data[pos:pos+size])
+		sector += int(entry[13])
+		pos += size
+	}
+	// system sector
+	free := trdDiskSize/trdSectorSize - sector
+	system := image[trdSystem:]
+	system[0] = byte(sector % trdSectors)
+	system[1] = byte(sector / trdSectors)
+	system[2] = 0x16
+	system[3] = byte(count)
+	writeWord(system, 4, uint16(free))
+	system[6] = 0x10                // TR-DOS id
+	copy(system[9:18], "         ") // 0x08ea : unused
+	copy(system[20:28], "        ") // 0x08f5 : disk label
+	return disk.NewRegular(80, 2, trdSectors, trdSectorSize, 1, image)
+}
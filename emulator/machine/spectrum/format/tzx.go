@@ -1,6 +1,7 @@
 package format
 
 import (
+	"fmt"
 	"log"
 
 	"github.com/jtruco/emu8/emulator/device/io/tape"
@@ -18,6 +19,9 @@ const (
 	tzxHeaderSignature = "ZXTape!"
 	tzxStartEar        = tape.LevelLow
 	tzxLogAllBlocks    = false
+	tzxMajorVersion    = 1
+	tzxMinorVersion    = 20
+	tzxDefaultPause    = 1000 // ms
 )
 
 // TZX states
@@ -36,7 +40,8 @@ const (
 // TzxBlock is a tape block
 type TzxBlock struct {
 	tape.BlockInfo
-	data []byte
+	data     []byte
+	describe DataDescriber
 }
 
 // Info gets block information
@@ -49,6 +54,135 @@ func (block *TzxBlock) Data() []byte {
 	return block.data
 }
 
+// LoadData gets the ROM loader data bytes of standard and turbo data blocks
+func (block *TzxBlock) LoadData() []byte {
+	switch block.Type {
+	case 0x10: // Standard speed data
+		return block.data[5:]
+	case 0x11: // Turbo speed data
+		return block.data[19:]
+	}
+	return nil
+}
+
+// TZX archive info text identifiers
+var tzxArchiveInfoNames = map[byte]string{
+	0x00: "Title",
+	0x01: "Publisher",
+	0x02: "Author",
+	0x03: "Year",
+	0x04: "Language",
+	0x05: "Type",
+	0x06: "Price",
+	0x07: "Loader",
+	0x08: "Origin",
+	0xff: "Comment",
+}
+
+// Meta gets the decoded block description
+func (block *TzxBlock) Meta() *tape.BlockMeta {
+	data := block.data
+	var meta *tape.BlockMeta
+	switch block.Type {
+	case 0x10: // Standard speed data
+		meta = block.describe(data[5:])
+		meta.Timings = standardTimings(data[5:], readInt(data, 1))
+	case 0x11: // Turbo speed data
+		meta = block.describe(data[19:])
+		meta.Description = "Turbo " + meta.Description
+		meta.Timings = &tape.Timings{
+			Pilot:       readInt(data, 1),
+			Sync1:       readInt(data, 3),
+			Sync2:       readInt(data, 5),
+			Zero:        readInt(data, 7),
+			One:         readInt(data, 9),
+			PilotPulses: readInt(data, 11),
+			LastBits:    int(data[13]),
+			Pause:       readInt(data, 14)}
+	case 0x12: // Pure tone
+		meta = describeText("Pure tone: %d pulses", readInt(data, 3))
+		meta.Timings = &tape.Timings{Pilot: readInt(data, 1), PilotPulses: readInt(data, 3)}
+	case 0x13: // Pulse sequence
+		meta = describeText("Pulse sequence: %d pulses", int(data[1]))
+	case 0x14: // Pure data
+		meta = describeText("Pure data: %d bytes", readIntN(data, 8, 3))
+		meta.Timings = &tape.Timings{
+			Zero:     readInt(data, 1),
+			One:      readInt(data, 3),
+			LastBits: int(data[5]),
+			Pause:    readInt(data, 6)}
+	case 0x15: // Direct recording
+		meta = describeText("Direct recording: %d bytes", readIntN(data, 6, 3))
+	case 0x18: // CSW recording
+		meta = describeText("CSW recording")
+	case 0x19: // Generalized data
+		meta = describeText("Generalized data")
+	case 0x20: // Pause or stop the tape
+		pause := readInt(data, 1)
+		if pause == 0 {
+			meta = describeText("Stop the tape")
+		} else {
+			meta = describeText("Pause: %d ms", pause)
+		}
+	case 0x21: // Group start
+		meta = describeText("Group: %s", readString(data, 2, int(data[1])))
+	case 0x22: // Group end
+		meta = describeText("Group end")
+	case 0x23: // Jump to block
+		meta = describeText("Jump: %d blocks", int(int16(readWord(data, 1))))
+	case 0x24: // Loop start
+		meta = describeText("Loop: %d times", readInt(data, 1))
+	case 0x25: // Loop end
+		meta = describeText("Loop end")
+	case 0x26: // Call sequence
+		meta = describeText("Call sequence: %d calls", readInt(data, 1))
+	case 0x27: // Return from sequence
+		meta = describeText("Return")
+	case 0x28: // Select block
+		meta = describeText("Select block")
+		for i, pos := 0, 4; i < int(data[3]); i++ {
+			length := int(data[pos+2])
+			meta.Text = append(meta.Text, readString(data, pos+3, length))
+			pos += 3 + length
+		}
+	case 0x2A: // Stop the tape if in 48K mode
+		meta = describeText("Stop the tape (48K)")
+	case 0x2B: // Set signal level
+		meta = describeText("Signal level: %d", int(data[5]))
+	case 0x30: // Text description
+		meta = describeText("Text")
+		meta.Text = []string{readString(data, 2, int(data[1]))}
+	case 0x31: // Message
+		meta = describeText("Message")
+		meta.Text = []string{readString(data, 3, int(data[2]))}
+	case 0x32: // Archive info
+		meta = describeText("Archive info")
+		for i, pos := 0, 4; i < int(data[3]); i++ {
+			name := tzxArchiveInfoNames[data[pos]]
+			if name == "" {
+				name = "Info"
+			}
+			length := int(data[pos+1])
+			meta.Text = append(meta.Text, name+": "+readString(data, pos+2, length))
+			pos += 2 + length
+		}
+	case 0x33: // Hardware type
+		meta = describeText("Hardware type")
+	case 0x35: // Custom info
+		meta = describeText("Custom info: %s", readString(data, 1, 16))
+	case 'Z': // Glue block
+		meta = describeText("Glue")
+	default:
+		meta = describeText("Unknown block 0x%02x", block.Type)
+	}
+	return meta
+}
+
+// describeText creates a block description
+func describeText(format string, args ...interface{}) *tape.BlockMeta {
+	return &tape.BlockMeta{Description: fmt.Sprintf(format, args...)}
+}
+
 // Tzx implements the a tape format .TZX
 type Tzx struct {
 	info          tape.Info    // Tape information
@@ -67,12 +201,19 @@ type Tzx struct {
 	lastBit       byte         // Last bit of current byte
 	loopCount     int          // Control loop count
 	loopStart     int          // Control loop start
+	describe      DataDescriber
 }
 
 // NewTzx creates a new tape
 func NewTzx() tape.Tape {
+	return NewTzxDescriber(describeData)
+}
+
+// NewTzxDescriber creates a new tape with a custom data block describer
+func NewTzxDescriber(describe DataDescriber) tape.Tape {
 	tzx := new(Tzx)
 	tzx.blocks = make([]tape.Block, 0, 2)
+	tzx.describe = describe
 	return tzx
 }
 
@@ -99,7 +240,8 @@ func (tzx *Tzx) Load(data []byte) bool {
 	}
 	index := 0
 	for offset := 0; offset < tapeLength; {
-		block := new(TapBlock)
+		block := new(TzxBlock)
+		block.describe = tzx.describe
 		block.Type = data[offset]
 		block.Index = index
 		block.Offset = offset
@@ -449,3 +591,56 @@ func (tzx *Tzx) parseHeader(control *tape.Control) {
 		control.BlockIndex++
 	}
 }
+
+// SaveTZX saves tape t to TZX data format. ROM loader data blocks of non TZX
+// tapes are saved as standard speed data blocks.
+func SaveTZX(t tape.Tape) []byte {
+	return SaveTZXTimings(t, nil)
+}
+
+// SaveTZXTimings saves tape t to TZX data format. ROM loader data blocks of
+// non TZX tapes are saved as turbo speed data blocks with the timings, the
+// ROM timings of other machines, or as standard speed data blocks if there
+// are no timings.
+func SaveTZXTimings(t tape.Tape, timings *tape.Timings) []byte {
+	data := make([]byte, 0)
+	if tzx, ok := t.(*Tzx); ok {
+		for _, block := range tzx.blocks {
+			data = append(data, block.Data()...)
+		}
+		return data
+	}
+	data = append(data, tzxHeaderSignature...)
+	data = append(data, 0x1a, tzxMajorVersion, tzxMinorVersion)
+	for _, block := range t.Blocks() {
+		dataBlock, ok := block.(tape.DataBlock)
+		if !ok || dataBlock.LoadData() == nil {
+			continue
+		}
+		loadData := dataBlock.LoadData()
+		if timings != nil {
+			var header [19]byte
+			header[0] = 0x11 // Turbo speed data
+			writeWord(header[:], 1, uint16(timings.Pilot))
+			writeWord(header[:], 3, uint16(timings.Sync1))
+			writeWord(header[:], 5, uint16(timings.Sync2))
+			writeWord(header[:], 7, uint16(timings.Zero))
+			writeWord(header[:], 9, uint16(timings.One))
+			writeWord(header[:], 11, uint16(timings.PilotPulses))
+			header[13] = byte(timings.LastBits)
+			writeWord(header[:], 14, uint16(timings.Pause))
+			writeWord(header[:], 16, uint16(len(loadData)))
+			header[18] = byte(len(loadData) >> 16)
+			data = append(data, header[:]...)
+			data = append(data, loadData...)
+			continue
+		}
+		var header [5]byte
+		header[0] = 0x10 // Standard speed data
+		writeWord(header[:], 1, tzxDefaultPause)
+		writeWord(header[:], 3, uint16(len(loadData)))
+		data = append(data, header[:]...)
+		data = append(data, loadData...)
+	}
+	return data
+}
@@ -0,0 +1,81 @@
+package format
+
+import (
+	"log"
+
+	"github.com/jtruco/emu8/emulator/device/io/tape"
+)
+
+// -----------------------------------------------------------------------------
+// WAV audio format
+// Renders tapes to mono 8 bit PCM audio
+// -----------------------------------------------------------------------------
+
+// WAV format extension
+const WAV = "wav"
+
+// WAV constants
+const (
+	WavDefaultRate   = 44100
+	_WAVHeaderLength = 44
+	_WAVTapeClock    = 3500000        // Tape tstates per second
+	_WAVMaxTstates   = 3600 * 3500000 // One hour
+	_WAVLevelHigh    = 0xe0
+	_WAVLevelLow     = 0x20
+)
+
+// SaveWAV renders tape t to WAV data format at sample rate
+func SaveWAV(t tape.Tape, rate int) []byte {
+	if rate <= 0 {
+		rate = WavDefaultRate
+	}
+	data := make([]byte, _WAVHeaderLength)
+	control := tape.Control{NumBlocks: len(t.Blocks()), Playing: true}
+	tstates, samples := int64(0), int64(0)
+	for tstates < _WAVMaxTstates {
+		t.Play(&control)
+		if control.Timeout > 0 {
+			level := byte(_WAVLevelLow)
+			if control.Ear != tape.LevelLow {
+				level = _WAVLevelHigh
+			}
+			tstates += int64(control.Timeout)
+			control.Timeout = 0
+			for ; samples*_WAVTapeClock < tstates*int64(rate); samples++ {
+				data = append(data, level)
+			}
+		}
+		if !control.Playing {
+			if control.EndOfTape() {
+				break
+			}
+			control.Playing = true // ignore stop the tape commands
+		}
+	}
+	if tstates >= _WAVMaxTstates {
+		log.Println("WAV : Maximum length reached")
+	}
+	wavWriteHeader(data, rate)
+	return data
+}
+
+func wavWriteHeader(data []byte, rate int) {
+	size := len(data) - _WAVHeaderLength
+	copy(data[0:], "RIFF")
+	wavWriteInt(data, 4, size+_WAVHeaderLength-8)
+	copy(data[8:], "WAVEfmt ")
+	wavWriteInt(data, 16, 16)   // format chunk size
+	writeWord(data, 20, 1)      // PCM format
+	writeWord(data, 22, 1)      // mono
+	wavWriteInt(data, 24, rate) // sample rate
+	wavWriteInt(data, 28, rate) // byte rate
+	writeWord(data, 32, 1)      // block align
+	writeWord(data, 34, 8)      // bits per sample
+	copy(data[36:], "data")
+	wavWriteInt(data, 40, size)
+}
+
+func wavWriteInt(data []byte, pos int, value int) {
+	writeWord(data, pos, uint16(value))
+	writeWord(data, pos+2, uint16(value>>16))
+}
@@ -11,8 +11,10 @@ import "log"
 const Z80 = "z80"
 
 const (
-	_Z80HeaderLength = 30
-	_Z80BankSize     = 0x4000
+	_Z80HeaderLength   = 30
+	_Z80HeaderV3Length = 54
+	_Z80BankSize       = 0x4000
+	_Z80Uncompressed   = 0xffff
 )
 
 var z80BankAddresses = map[byte]int{8: 0x0000, 4: 0x4000, 5: 0x8000}
@@ -79,9 +81,21 @@ func z80LoadHeaderV1(data []byte, snap *Snapshot) bool {
 }
 
 func z80LoadFileV1(data []byte, snap *Snapshot) bool {
-	// not implemented
-	log.Println("Z80 : Version 1 not implemented")
-	return false
+	memory := data[_Z80HeaderLength:]
+	if (data[12] & 0x20) != 0 { // compressed data
+		length := len(memory)
+		if length >= 4 && memory[length-4] == 0x00 && memory[length-3] == 0xED &&
+			memory[length-2] == 0xED && memory[length-1] == 0x00 {
+			memory = memory[:length-4] // end marker
+		}
+		memory = z80DecompressBlock(memory, len(snap.Memory))
+	}
+	if len(memory) < len(snap.Memory) {
+		log.Println("Z80 : Invalid file format")
+		return false
+	}
+	copy(snap.Memory[:], memory)
+	return true
 }
 
 func z80LoadFileV23(data []byte, snap *Snapshot) bool {
@@ -109,14 +123,23 @@ func z80LoadFileV23(data []byte, snap *Snapshot) bool {
 			return false
 		}
 		size := int(uint16(data[idx+0]) | uint16(data[idx+1])<<8)
-		if size > _Z80BankSize {
+		compressed := true
+		if size == _Z80Uncompressed {
+			size = _Z80BankSize
+			compressed = false
+		}
+		if size > _Z80BankSize || idx+3+size > totalSize {
 			log.Println("Z80 : wrong bank size")
 			return false
 		}
 		idx += 3
 		bankdata := data[idx : idx+size]
-		if size < _Z80BankSize {
-			bankdata = z80DecompressBlock(bankdata)
+		if compressed {
+			bankdata = z80DecompressBlock(bankdata, _Z80BankSize)
+		}
+		if len(bankdata) < _Z80BankSize {
+			log.Println("Z80 : wrong bank size")
+			return false
 		}
 		copy(snap.Memory[address:], bankdata[:_Z80BankSize])
 		idx += size
@@ -124,13 +147,13 @@ func z80LoadFileV23(data []byte, snap *Snapshot) bool {
 	return true
 }
 
-func z80DecompressBlock(data []byte) []byte {
-	buffer := make([]byte, _Z80BankSize)
+func z80DecompressBlock(data []byte, size int) []byte {
+	buffer := make([]byte, size)
 	sizeIn := len(data)
 	sizeOut := 0
-	for i := 0; i < sizeIn; {
+	for i := 0; i < sizeIn && sizeOut < size; {
 		if data[i] == 0xED && i < (sizeIn-3) && data[i+1] == 0xED {
-			for j := byte(0); j < data[i+2]; j++ {
+			for j := byte(0); j < data[i+2] && sizeOut < size; j++ {
 				buffer[sizeOut] = data[i+3]
 				sizeOut++
 			}
@@ -143,3 +166,85 @@ func z80DecompressBlock(data []byte) []byte {
 	}
 	return buffer[0:sizeOut]
 }
+
+func z80CompressBlock(data []byte) []byte {
+	buffer := make([]byte, 0, len(data))
+	size := len(data)
+	for i := 0; i < size; {
+		value := data[i]
+		run := 1
+		for i+run < size && data[i+run] == value && run < 0xff {
+			run++
+		}
+		if run >= 5 || (value == 0xED && run >= 2) {
+			buffer = append(buffer, 0xED, 0xED, byte(run), value)
+			i += run
+		} else if value == 0xED && i+1 < size {
+			// a single ED is followed by an uncompressed byte
+			buffer = append(buffer, value, data[i+1])
+			i += 2
+		} else {
+			buffer = append(buffer, value)
+			i++
+		}
+	}
+	return buffer
+}
+
+// SaveZ80 saves snap to Z80 version 3 data format (48k)
+func (snap *Snapshot) SaveZ80() []byte {
+	headerSize := _Z80HeaderLength + 2 + _Z80HeaderV3Length
+	data := make([]byte, headerSize, headerSize+len(snap.Memory))
+	data[0] = snap.A
+	data[1] = snap.F
+	data[2] = snap.C
+	data[3] = snap.B
+	data[4] = snap.L
+	data[5] = snap.H
+	writeWord(data, 6, 0) // version 2+
+	writeWord(data, 8, snap.SP)
+	data[10] = snap.I
+	data[11] = snap.R & 0x7f
+	data[12] = (snap.R >> 7) | ((snap.Border & 0x07) << 1)
+	data[13] = snap.E
+	data[14] = snap.D
+	data[15] = snap.Cx
+	data[16] = snap.Bx
+	data[17] = snap.Ex
+	data[18] = snap.Dx
+	data[19] = snap.Lx
+	data[20] = snap.Hx
+	data[21] = snap.Ax
+	data[22] = snap.Fx
+	data[23] = snap.IYl
+	data[24] = snap.IYh
+	data[25] = snap.IXl
+	data[26] = snap.IXh
+	if snap.IFF1 {
+		data[27] = 1
+	}
+	if snap.IFF2 {
+		data[28] = 1
+	}
+	data[29] = snap.IM & 0x03
+	writeWord(data, 30, _Z80HeaderV3Length)
+	writeWord(data, 32, snap.PC)
+	data[34] = 0 // 48k hardware mode
+	// 48k memory pages
+	for _, page := range []byte{8, 4, 5} {
+		address := z80BankAddresses[page]
+		bankdata := snap.Memory[address : address+_Z80BankSize]
+		compressed := z80CompressBlock(bankdata)
+		var header [3]byte
+		header[2] = page
+		if len(compressed) < _Z80BankSize {
+			writeWord(header[:], 0, uint16(len(compressed)))
+			bankdata = compressed
+		} else {
+			writeWord(header[:], 0, _Z80Uncompressed)
+		}
+		data = append(data, header[:]...)
+		data = append(data, bankdata...)
+	}
+	return data
+}
@@ -5,9 +5,82 @@ import "github.com/jtruco/emu8/emulator/machine"
 // ZX Spectrum models
 var models = []machine.Model{
 	{Name: "ZX Spectrum 16K", Ids: []string{"ZXSpectrum16K", "ZX16K"},
-		Build: func() machine.Machine { return New(ZXSpectrum16K) }},
+		Build: func() machine.Machine { return New(ZXSpectrum16K) }, Roms: zxRomSet},
 	{Name: "ZX Spectrum 48K", Ids: []string{"ZXSpectrum48K", "ZX48K", "Speccy"},
-		Build: func() machine.Machine { return New(ZXSpectrum48K) }},
+		Build: func() machine.Machine { return New(ZXSpectrum48K) }, Roms: zxRomSet},
+	{Name: "Pentagon 128", Ids: []string{"Pentagon128", "Pentagon"},
+		Build: func() machine.Machine { return New(Pentagon128) }, Roms: pentagonRomSet},
+	{Name: "Scorpion ZS 256", Ids: []string{"Scorpion256", "Scorpion"},
+		Build: func() machine.Machine { return New(Scorpion256) }, Roms: scorpionRomSet},
+	{Name: "Timex TC2048", Ids: []string{"TimexTC2048", "TC2048"},
+		Build: func() machine.Machine { return New(TimexTC2048) }, Roms: tc2048RomSet},
+	{Name: "Timex Sinclair 2068", Ids: []string{"TimexTS2068", "TS2068"},
+		Build: func() machine.Machine { return New(TimexTS2068) }, Roms: ts2068RomSet},
+}
+
+// zx48RomImage the 48K BASIC ROM, shared by the 16K, 48K and Pentagon models
+var zx48RomImage = machine.RomImage{ID: "zx48", File: "zxspectrum.rom", CRC32: 0xddee531f, SHA1: "5ea7c2b824672e914525d1d5c419d71b84a426a2"}
+
+// ZX Spectrum ROM set
+var zxRomSet = &machine.RomSet{
+	Slots: []machine.RomSlot{
+		{Name: "rom", Size: 0x4000, Default: "zx48"},
+	},
+	Images: []machine.RomImage{zx48RomImage},
+}
+
+// Pentagon 128 ROM set : the 128K editor, the 48K BASIC and the TR-DOS 5.03
+// ROMs
+var pentagonRomSet = &machine.RomSet{
+	Slots: []machine.RomSlot{
+		{Name: "rom0", Size: 0x4000, Default: "pentagon128"},
+		{Name: "rom1", Size: 0x4000, Default: "zx48"},
+		{Name: "trdos", Size: 0x4000, Default: "trdos503"},
+	},
+	Images: []machine.RomImage{
+		{ID: "pentagon128", File: "pentagon128.rom", CRC32: 0x124ad9e0, SHA1: "d07fcdeca892ee80494d286ea9ea5bf3928a1aca"},
+		zx48RomImage,
+		{ID: "trdos503", File: "trdos.rom", CRC32: 0x10751aba, SHA1: "21695e3f2a8f796386ce66eea8a246b0ac44810c"},
+	},
+}
+
+// Scorpion ZS 256 ROM set : the 128K editor, the 48K BASIC, the service
+// monitor and the TR-DOS ROMs
+var scorpionRomSet = &machine.RomSet{
+	Slots: []machine.RomSlot{
+		{Name: "rom0", Size: 0x4000, Default: "scorpion0"},
+		{Name: "rom1", Size: 0x4000, Default: "scorpion1"},
+		{Name: "rom2", Size: 0x4000, Default: "scorpion2"},
+		{Name: "trdos", Size: 0x4000, Default: "scorpion3"},
+	},
+	Images: []machine.RomImage{
+		{ID: "scorpion0", File: "scorpion0.rom", CRC32: 0x0eb40a09, SHA1: "477114ff0fe1388e0979df1423602b21248164e5"},
+		{ID: "scorpion1", File: "scorpion1.rom", CRC32: 0x9d513013, SHA1: "367b5a102fb663beee8e7930b8c4acc219c1f7b3"},
+		{ID: "scorpion2", File: "scorpion2.rom", CRC32: 0xfd0d3ce1, SHA1: "07783ee295274d8ff15d935bfd787c8ac1d54900"},
+		{ID: "scorpion3", File: "scorpion3.rom", CRC32: 0x1fe1d003, SHA1: "33703e97cc93b7edfcc0334b64233cf81b7930db"},
+	},
+}
+
+// Timex TC2048 ROM set
+var tc2048RomSet = &machine.RomSet{
+	Slots: []machine.RomSlot{
+		{Name: "rom", Size: 0x4000, Default: "tc2048"},
+	},
+	Images: []machine.RomImage{
+		{ID: "tc2048", File: "tc2048.rom", CRC32: 0xf1b5fa67, SHA1: "febb2d495b6eda7cdcb4074935d6e9d9f328972d"},
+	},
+}
+
+// Timex Sinclair 2068 ROM set : the 16K HOME ROM and the 8K EXROM
+var ts2068RomSet = &machine.RomSet{
+	Slots: []machine.RomSlot{
+		{Name: "rom", Size: 0x4000, Default: "ts2068-0"},
+		{Name: "exrom", Size: 0x2000, Default: "ts2068-1"},
+	},
+	Images: []machine.RomImage{
+		{ID: "ts2068-0", File: "ts2068-0.rom", CRC32: 0xbf44ec3f, SHA1: "1446cb2780a9dedf640404a639fa3ae518b2d8aa"},
+		{ID: "ts2068-1", File: "ts2068-1.rom", CRC32: 0xae16233a, SHA1: "7e265a2c1f621ed365ea23bdcafdedbc79c1299c"},
+	},
 }
 
 func init() {
@@ -1,14 +1,32 @@
 package spectrum
 
+import (
+	"log"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator/device/io/keyboard"
+)
+
 // -----------------------------------------------------------------------------
 // ZX Spectrum - Joystick emulation
 // -----------------------------------------------------------------------------
-// Only Kempston emulation supported
+// Kempston, Sinclair Interface 2, Cursor, Fuller and Timex interfaces
 
 // Joystick models
 const (
-	JoystickNone = iota
-	JoystickKempston
+	JoystickNone      = iota
+	JoystickKempston  // Kempston (port 0x1f)
+	JoystickSinclair1 // Sinclair Interface 2 left (keys 1 to 5)
+	JoystickSinclair2 // Sinclair Interface 2 right (keys 6 to 0)
+	JoystickCursor    // Cursor, Protek & AGF (keys 5 to 8 and 0)
+	JoystickFuller    // Fuller (port 0x7f)
+	JoystickTimex     // Timex (port 0xf6)
+)
+
+// Joystick ports
+const (
+	zxFullerPort = 0x7f
+	zxTimexPort  = 0xf6
 )
 
 // Kempston constants
@@ -23,75 +41,190 @@ const (
 	_KempstonButton3 = byte(0x40)
 )
 
-// Joystick Kempston emulation
+// Fuller & Timex constants (active low)
+const (
+	_FullerUp     = byte(0x01)
+	_FullerDown   = byte(0x02)
+	_FullerLeft   = byte(0x04)
+	_FullerRight  = byte(0x08)
+	_FullerButton = byte(0x80)
+)
+
+// JoystickModels joystick models by option name
+var JoystickModels = map[string]int{
+	"none":      JoystickNone,
+	"kempston":  JoystickKempston,
+	"sinclair1": JoystickSinclair1,
+	"sinclair2": JoystickSinclair2,
+	"cursor":    JoystickCursor,
+	"protek":    JoystickCursor,
+	"agf":       JoystickCursor,
+	"fuller":    JoystickFuller,
+	"timex":     JoystickTimex,
+}
+
+// joystickKeys keyboard joystick keys : right, left, down, up and fire
+var joystickKeys = map[int][5]keyboard.Key{
+	JoystickSinclair1: {ZxKey2, ZxKey1, ZxKey3, ZxKey4, ZxKey5},
+	JoystickSinclair2: {ZxKey7, ZxKey6, ZxKey8, ZxKey9, ZxKey0},
+	JoystickCursor:    {ZxKey8, ZxKey5, ZxKey6, ZxKey7, ZxKey0},
+}
+
+// Joystick is a ZX Spectrum joystick connected to a joystick interface
 type Joystick struct {
-	id    byte // ID
-	model byte // Joystick model
-	state byte // Kempston state
+	id       byte      // ID
+	model    int       // Joystick model
+	state    byte      // Kempston state
+	keyboard *Keyboard // The keyboard of keyboard joysticks
 }
 
 // NewJoystick creates a new Kempston Joystick
-func NewJoystick() *Joystick {
+func NewJoystick(id byte, keyboard *Keyboard) *Joystick {
 	joy := new(Joystick)
+	joy.id = id
 	joy.model = JoystickKempston
+	joy.keyboard = keyboard
 	return joy
 }
 
+// Model gets the joystick model
+func (joy *Joystick) Model() int { return joy.model }
+
+// SetModel sets the joystick model
+func (joy *Joystick) SetModel(model int) {
+	joy.setState(_KempstonDefault)
+	joy.model = model
+}
+
 // State gets kempston status
 func (joy *Joystick) State() byte { return joy.state }
 
+// PortState gets the active low state of Fuller and Timex joysticks
+func (joy *Joystick) PortState() byte {
+	result := byte(0xff)
+	if joy.state&_KempstonUp != 0 {
+		result &^= _FullerUp
+	}
+	if joy.state&_KempstonDown != 0 {
+		result &^= _FullerDown
+	}
+	if joy.state&_KempstonLeft != 0 {
+		result &^= _FullerLeft
+	}
+	if joy.state&_KempstonRight != 0 {
+		result &^= _FullerRight
+	}
+	if joy.state&_KempstonButton1 != 0 {
+		result &^= _FullerButton
+	}
+	return result
+}
+
 // Init initializes the device
 func (joy *Joystick) Init() { joy.Reset() }
 
 // Reset resets the device
-func (joy *Joystick) Reset() { joy.state = 0x00 }
+func (joy *Joystick) Reset() { joy.setState(_KempstonDefault) }
 
 // ID returns the joystick ID
 func (joy *Joystick) ID() byte { return joy.id }
 
 // SetAxis sets axis value
 func (joy *Joystick) SetAxis(axis byte, value byte) {
+	state := joy.state
 	if axis == 0 { // right / left
 		if value == 0 {
-			joy.state &^= _KempstonRight
-			joy.state &^= _KempstonLeft
+			state &^= _KempstonRight
+			state &^= _KempstonLeft
 		} else if value < 128 {
-			joy.state |= _KempstonRight
+			state |= _KempstonRight
 		} else {
-			joy.state |= _KempstonLeft
+			state |= _KempstonLeft
 		}
 	} else if axis == 1 { // down / up
 		if value == 0 {
-			joy.state &^= _KempstonDown
-			joy.state &^= _KempstonUp
+			state &^= _KempstonDown
+			state &^= _KempstonUp
 		} else if value < 128 {
-			joy.state |= _KempstonDown
+			state |= _KempstonDown
 		} else {
-			joy.state |= _KempstonUp
+			state |= _KempstonUp
 		}
 	}
+	joy.setState(state)
 }
 
 // SetButton sets button state
 func (joy *Joystick) SetButton(button byte, state byte) {
+	var mask byte
 	switch button {
 	case 0:
-		if state > 0 {
-			joy.state |= _KempstonButton1
-		} else {
-			joy.state &^= _KempstonButton1
-		}
+		mask = _KempstonButton1
 	case 1:
-		if state > 0 {
-			joy.state |= _KempstonButton2
-		} else {
-			joy.state &^= _KempstonButton2
-		}
+		mask = _KempstonButton2
 	case 2:
-		if state > 0 {
-			joy.state |= _KempstonButton3
-		} else {
-			joy.state &^= _KempstonButton3
+		mask = _KempstonButton3
+	default:
+		return
+	}
+	if state > 0 {
+		joy.setState(joy.state | mask)
+	} else {
+		joy.setState(joy.state &^ mask)
+	}
+}
+
+// setState sets the joystick state, pressing the keys of keyboard joysticks
+func (joy *Joystick) setState(state byte) {
+	changed := joy.state ^ state
+	joy.state = state
+	keys, ok := joystickKeys[joy.model]
+	if !ok || joy.keyboard == nil {
+		return
+	}
+	for bit, key := range keys {
+		mask := byte(1 << uint(bit))
+		if changed&mask != 0 {
+			joy.keyboard.ProcessKey(key, state&mask != 0)
+		}
+	}
+}
+
+// joystickModel returns the joystick model of an option name
+func joystickModel(name string, model int) int {
+	if name == "" {
+		return model
+	}
+	if model, ok := JoystickModels[strings.ToLower(name)]; ok {
+		return model
+	}
+	log.Println("Spectrum : Unknown joystick:", name)
+	return JoystickNone
+}
+
+// joystickState reads the joystick interfaces at port address
+func (spectrum *Spectrum) joystickState(address uint16) byte {
+	result := byte(0xff)
+	kempston, selected := _KempstonDefault, false
+	for i, joy := range spectrum.joysticks {
+		switch joy.Model() {
+		case JoystickKempston:
+			if (address & 0x00e0) == 0 { // Kempston selected
+				kempston |= joy.State()
+				selected = true
+			}
+		case JoystickFuller:
+			if (address & 0x00ff) == zxFullerPort {
+				result &= joy.PortState()
+			}
+		case JoystickTimex:
+			if (address&0x00ff) == zxTimexPort && (address>>8)&(1<<uint(i)) != 0 {
+				result &= joy.PortState()
+			}
 		}
 	}
+	if selected {
+		result &= kempston
+	}
+	return result
 }
@@ -1,6 +1,12 @@
 package spectrum
 
-import "github.com/jtruco/emu8/emulator/device/io/keyboard"
+import (
+	"log"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator/device/io/keyboard"
+	"github.com/jtruco/emu8/emulator/machine/spectrum/basic"
+)
 
 // -----------------------------------------------------------------------------
 // ZX Spectrum Keyboard
@@ -33,6 +39,9 @@ func (keyboard *Keyboard) Reset() { keyboard.Init() }
 // KeyMap returns the default keyboard mapping
 func (keyboard *Keyboard) KeyMap() keyboard.KeyMap { return zxKeyboardMap }
 
+// KeyNames returns the keys by name
+func (keyboard *Keyboard) KeyNames() map[string]keyboard.Key { return zxKeyNames }
+
 // ProcessKey processes the ZX keyboard events
 func (keyboard *Keyboard) ProcessKey(key keyboard.Key, pressed bool) {
 	state, ok := keyStates[key]
@@ -45,6 +54,11 @@ func (keyboard *Keyboard) ProcessKey(key keyboard.Key, pressed bool) {
 	}
 }
 
+// TextKeys returns the key combinations that type the text
+func (keyboard *Keyboard) TextKeys(text string) [][]keyboard.Key {
+	return zxTextKeys(text)
+}
+
 // GetState gets keyboard state at scan address
 func (keyboard *Keyboard) GetState(scan byte) byte {
 	var result byte = 0xff
@@ -109,6 +123,50 @@ const (
 	ZxKeySpace
 )
 
+// zxKeyNames ZX Spectrum keys by name
+var zxKeyNames = map[string]keyboard.Key{
+	"1":           ZxKey1,
+	"2":           ZxKey2,
+	"3":           ZxKey3,
+	"4":           ZxKey4,
+	"5":           ZxKey5,
+	"6":           ZxKey6,
+	"7":           ZxKey7,
+	"8":           ZxKey8,
+	"9":           ZxKey9,
+	"0":           ZxKey0,
+	"Q":           ZxKeyQ,
+	"W":           ZxKeyW,
+	"E":           ZxKeyE,
+	"R":           ZxKeyR,
+	"T":           ZxKeyT,
+	"Y":           ZxKeyY,
+	"U":           ZxKeyU,
+	"I":           ZxKeyI,
+	"O":           ZxKeyO,
+	"P":           ZxKeyP,
+	"A":           ZxKeyA,
+	"S":           ZxKeyS,
+	"D":           ZxKeyD,
+	"F":           ZxKeyF,
+	"G":           ZxKeyG,
+	"H":           ZxKeyH,
+	"J":           ZxKeyJ,
+	"K":           ZxKeyK,
+	"L":           ZxKeyL,
+	"Enter":       ZxKeyEnter,
+	"CapsShift":   ZxKeyCapsShift,
+	"Z":           ZxKeyZ,
+	"X":           ZxKeyX,
+	"C":           ZxKeyC,
+	"V":           ZxKeyV,
+	"B":           ZxKeyB,
+	"N":           ZxKeyN,
+	"M":           ZxKeyM,
+	"SymbolShift": ZxKeySymbolShift,
+	"Space":       ZxKeySpace,
+}
+
 // keyStates ZX Spectrum Keyboard states
 var keyStates = map[keyboard.Key][2]byte{
 	ZxKey1: {3, 0x01},
@@ -231,3 +289,132 @@ var zxKeyboardMap = map[keyboard.KeyCode][]keyboard.Key{
 	keyboard.KeyBackspace: {ZxKeyCapsShift, ZxKey0},
 	keyboard.KeyEscape:    {ZxKeyCapsShift, ZxKey1},
 }
+
+// -----------------------------------------------------------------------------
+// ZX Spectrum Text Typing
+// -----------------------------------------------------------------------------
+
+// zxKeyLegend is the legend of a ZX Spectrum key in each input mode
+type zxKeyLegend struct {
+	key      keyboard.Key // The key
+	char     byte         // L mode character
+	keyword  string       // K mode keyword
+	extended string       // E mode keyword
+	extShift string       // E mode keyword or symbol with symbol shift
+	symbol   string       // Keyword or symbol with symbol shift
+}
+
+// zxKeyLegends the ZX Spectrum 48K keyboard legends
+var zxKeyLegends = []zxKeyLegend{
+	{ZxKey1, '1', "", "", "DEF FN", "!"},
+	{ZxKey2, '2', "", "", "FN", "@"},
+	{ZxKey3, '3', "", "", "LINE", "#"},
+	{ZxKey4, '4', "", "", "OPEN #", "$"},
+	{ZxKey5, '5', "", "", "CLOSE #", "%"},
+	{ZxKey6, '6', "", "", "MOVE", "&"},
+	{ZxKey7, '7', "", "", "ERASE", "'"},
+	{ZxKey8, '8', "", "", "POINT", "("},
+	{ZxKey9, '9', "", "", "CAT", ")"},
+	{ZxKey0, '0', "", "", "FORMAT", "_"},
+	{ZxKeyQ, 'q', "PLOT", "SIN", "ASN", "<="},
+	{ZxKeyW, 'w', "DRAW", "COS", "ACS", "<>"},
+	{ZxKeyE, 'e', "REM", "TAN", "ATN", ">="},
+	{ZxKeyR, 'r', "RUN", "INT", "VERIFY", "<"},
+	{ZxKeyT, 't', "RANDOMIZE", "RND", "MERGE", ">"},
+	{ZxKeyY, 'y', "RETURN", "STR$", "[", "AND"},
+	{ZxKeyU, 'u', "IF", "CHR$", "]", "OR"},
+	{ZxKeyI, 'i', "INPUT", "CODE", "IN", "AT"},
+	{ZxKeyO, 'o', "POKE", "PEEK", "OUT", ";"},
+	{ZxKeyP, 'p', "PRINT", "TAB", "©", "\""},
+	{ZxKeyA, 'a', "NEW", "READ", "~", "STOP"},
+	{ZxKeyS, 's', "SAVE", "RESTORE", "|", "NOT"},
+	{ZxKeyD, 'd', "DIM", "DATA", "\\", "STEP"},
+	{ZxKeyF, 'f', "FOR", "SGN", "{", "TO"},
+	{ZxKeyG, 'g', "GO TO", "ABS", "}", "THEN"},
+	{ZxKeyH, 'h', "GO SUB", "SQR", "CIRCLE", "^"},
+	{ZxKeyJ, 'j', "LOAD", "VAL", "VAL$", "-"},
+	{ZxKeyK, 'k', "LIST", "LEN", "SCREEN$", "+"},
+	{ZxKeyL, 'l', "LET", "USR", "ATTR", "="},
+	{ZxKeyZ, 'z', "COPY", "LN", "BEEP", ":"},
+	{ZxKeyX, 'x', "CLEAR", "EXP", "INK", "£"},
+	{ZxKeyC, 'c', "CONTINUE", "LPRINT", "PAPER", "?"},
+	{ZxKeyV, 'v', "CLS", "LLIST", "FLASH", "/"},
+	{ZxKeyB, 'b', "BORDER", "BIN", "BRIGHT", "*"},
+	{ZxKeyN, 'n', "NEXT", "INKEY$", "OVER", ","},
+	{ZxKeyM, 'm', "PAUSE", "PI", "INVERSE", "."},
+}
+
+// zxKeyCombinations key combinations by character or token code
+var zxKeyCombinations = make(map[byte][][]keyboard.Key)
+
+// zxExtendedMode key combination that selects the E mode
+var zxExtendedMode = []keyboard.Key{ZxKeyCapsShift, ZxKeySymbolShift}
+
+func init() {
+	for _, legend := range zxKeyLegends {
+		key := legend.key
+		zxKeyCombinations[legend.char] = [][]keyboard.Key{{key}}
+		if legend.char >= 'a' && legend.char <= 'z' {
+			zxKeyCombinations[legend.char-'a'+'A'] = [][]keyboard.Key{{ZxKeyCapsShift, key}}
+		}
+		if legend.keyword != "" {
+			zxKeyCombinations[zxCharCode(legend.keyword)] = [][]keyboard.Key{{key}}
+		}
+		if legend.extended != "" {
+			zxKeyCombinations[zxCharCode(legend.extended)] = [][]keyboard.Key{zxExtendedMode, {key}}
+		}
+		zxKeyCombinations[zxCharCode(legend.extShift)] = [][]keyboard.Key{zxExtendedMode, {ZxKeySymbolShift, key}}
+		zxKeyCombinations[zxCharCode(legend.symbol)] = [][]keyboard.Key{{ZxKeySymbolShift, key}}
+	}
+	zxKeyCombinations[' '] = [][]keyboard.Key{{ZxKeySpace}}
+}
+
+// zxCharCode returns the character or token code of a key legend
+func zxCharCode(legend string) byte {
+	switch legend {
+	case "£":
+		return basic.CharPound
+	case "©":
+		return basic.CharCopy
+	}
+	if len(legend) == 1 {
+		return legend[0]
+	}
+	for i, token := range basic.Tokens {
+		if token == legend {
+			return basic.TokenFirst + byte(i)
+		}
+	}
+	return 0
+}
+
+// zxTextKeys returns the key combinations that type the text. Lines are
+// tokenized, so keywords are entered as the ROM editor expects: commands
+// with a single key in K mode at the start of a statement, functions and
+// other keywords in E mode or with symbol shift. Letters and symbols are
+// typed in L mode.
+func zxTextKeys(text string) [][]keyboard.Key {
+	keys := make([][]keyboard.Key, 0, len(text))
+	for n, line := range strings.Split(text, "\n") {
+		if n > 0 {
+			// give the editor time to process the line
+			keys = append(keys, []keyboard.Key{ZxKeyEnter}, keyboard.TypistPause, keyboard.TypistPause)
+		}
+		data, err := basic.TokenizeLine(strings.TrimSpace(strings.TrimSuffix(line, "\r")))
+		if err != nil {
+			log.Println("Spectrum : Cannot type line", n+1, ":", err.Error())
+			continue
+		}
+		for pos := 0; pos < len(data); pos++ {
+			code := data[pos]
+			if code == basic.CharNumber {
+				pos += basic.NumberLength // already typed
+				continue
+			}
+			if combinations, ok := zxKeyCombinations[code]; ok {
+				keys = append(keys, combinations...)
+			}
+		}
+	}
+	return keys
+}
@@ -0,0 +1,123 @@
+package spectrum
+
+import (
+	"github.com/jtruco/emu8/emulator/device/cpu/z80"
+	"github.com/jtruco/emu8/emulator/device/io/keyboard"
+)
+
+// -----------------------------------------------------------------------------
+// ZX Spectrum - Tape loading
+// -----------------------------------------------------------------------------
+
+// ROM loader constants
+const (
+	zxLdBytes        = 0x0556 // LD-BYTES ROM routine address
+	zxLdBytesEnd     = 0x0605 // LD-BYTES ROM routine end address
+	zxBootFrames     = 100    // Frames to wait for the ROM boot
+	zxAutoTypeFrames = 250    // Max frames since reset to auto type LOAD ""
+)
+
+// zxLoadKeys are the key combinations of LOAD "" ENTER
+var zxLoadKeys = [][]keyboard.Key{
+	{ZxKeyJ},
+	{ZxKeySymbolShift, ZxKeyP},
+	{ZxKeySymbolShift, ZxKeyP},
+	{ZxKeyEnter},
+}
+
+// isROM48 checks if the 48K BASIC ROM is mapped. On the 128K models the
+// 128 BASIC, TR-DOS and Scorpion service ROMs run other code at the loader
+// addresses, and the TS2068 ROM has its own tape routines.
+func (spectrum *Spectrum) isROM48() bool {
+	if spectrum.paging != nil {
+		return spectrum.paging.IsROM48()
+	}
+	return spectrum.config.Model != TimexTS2068
+}
+
+// isLoaderActive checks if the CPU is running the ROM tape loader
+func (spectrum *Spectrum) isLoaderActive() bool {
+	return spectrum.cpu.PC >= zxLdBytes && spectrum.cpu.PC < zxLdBytesEnd &&
+		spectrum.isROM48()
+}
+
+// onTapeInsert auto types LOAD "" on a freshly reset 16k or 48k machine.
+// The elapsed frames are measured with the model frame timing.
+func (spectrum *Spectrum) onTapeInsert() {
+	if !spectrum.tape.AutoPlay() || !spectrum.fresh || spectrum.paging != nil {
+		return
+	}
+	spectrum.fresh = false
+	elapsed := int(spectrum.clock.Total() / int64(spectrum.timing.frame))
+	if elapsed > zxAutoTypeFrames {
+		return
+	}
+	if elapsed < zxBootFrames {
+		spectrum.typist.Wait(zxBootFrames - elapsed)
+	}
+	spectrum.typist.Type(zxLoadKeys...)
+}
+
+// isLoaderTrap checks if the CPU is entering the ROM tape loader of the
+// 48K BASIC ROM
+func (spectrum *Spectrum) isLoaderTrap() bool {
+	return spectrum.fastload &&
+		spectrum.cpu.PC == zxLdBytes &&
+		spectrum.isROM48() &&
+		spectrum.tape.HasTape()
+}
+
+// loadBytes emulates the LD-BYTES ROM routine, loading the next tape data block
+// directly into memory. Returns false if there is no data block to load.
+// Entry : A flag byte, F carry set to LOAD or reset to VERIFY, IX address, DE length.
+// Exit : F carry set on success, IX and DE updated as the ROM routine does.
+func (spectrum *Spectrum) loadBytes() bool {
+	data := spectrum.tape.NextDataBlock()
+	if data == nil {
+		return false
+	}
+	cpu := spectrum.cpu
+	length := len(data)
+	if length == 0 || data[0] != cpu.A {
+		cpu.F &^= z80.FlagC // wrong block type
+		cpu.Ret()
+		return true
+	}
+	load := (cpu.F & z80.FlagC) != 0
+	address := cpu.IX.Get()
+	count := cpu.DE.Get()
+	parity := data[0]
+	read := 1
+	verified := true
+	for count > 0 && read < length {
+		value := data[read]
+		if load {
+			spectrum.memory.Poke(address, value)
+		} else if spectrum.memory.Peek(address) != value {
+			verified = false
+			break
+		}
+		cpu.L = value
+		parity ^= value
+		address++
+		count--
+		read++
+	}
+	// checksum byte
+	success := false
+	if verified && count == 0 && read < length {
+		parity ^= data[read]
+		success = parity == 0
+	}
+	cpu.IX.Set(address)
+	cpu.DE.Set(count)
+	cpu.H = parity
+	cpu.A = parity
+	if success {
+		cpu.F |= z80.FlagC
+	} else {
+		cpu.F &^= z80.FlagC
+	}
+	cpu.Ret()
+	return true
+}
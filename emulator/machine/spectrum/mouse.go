@@ -0,0 +1,220 @@
+package spectrum
+
+import (
+	"log"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator/device/io/mouse"
+	"github.com/jtruco/emu8/emulator/device/io/pio"
+)
+
+// -----------------------------------------------------------------------------
+// ZX Spectrum - Mouse emulation
+// -----------------------------------------------------------------------------
+// Kempston mouse and AMX mouse interfaces
+
+// Mouse models
+const (
+	MouseNone     = iota
+	MouseKempston // Kempston mouse (ports 0xfadf, 0xfbdf & 0xffdf)
+	MouseAMX      // AMX mouse (Z80 PIO ports 0x1f to 0x7f, buttons 0xdf)
+)
+
+// Kempston mouse port decoding masks
+const (
+	_KempstonMouseMask    = 0x05e1
+	_KempstonMouseButtons = 0x00c1 // A8 & A10 low
+	_KempstonMouseX       = 0x01c1 // A8 high, A10 low
+	_KempstonMouseY       = 0x05c1 // A8 & A10 high
+)
+
+// Kempston mouse buttons (active low)
+const (
+	_KempstonMouseRight  = byte(0x01)
+	_KempstonMouseLeft   = byte(0x02)
+	_KempstonMouseMiddle = byte(0x04)
+)
+
+// AMX mouse ports
+const (
+	_AmxPortAData    = 0x1f // X motion
+	_AmxPortBData    = 0x3f // Y motion
+	_AmxPortAControl = 0x5f
+	_AmxPortBControl = 0x7f
+	_AmxPortButtons  = 0xdf
+)
+
+// AMX mouse buttons (active low)
+const (
+	_AmxLeft   = byte(0x80)
+	_AmxMiddle = byte(0x40)
+	_AmxRight  = byte(0x20)
+)
+
+// MouseModels mouse models by option name
+var MouseModels = map[string]int{
+	"none":     MouseNone,
+	"kempston": MouseKempston,
+	"amx":      MouseAMX,
+}
+
+// Mouse is a ZX Spectrum mouse connected to a mouse interface
+type Mouse struct {
+	model   int         // Mouse model
+	x, y    byte        // Kempston position counters
+	dx, dy  int         // AMX pending motion
+	buttons [3]bool     // Button states
+	pio     *pio.Z80PIO // AMX interface PIO
+}
+
+// NewMouse creates a new mouse
+func NewMouse(model int) *Mouse {
+	m := new(Mouse)
+	m.model = model
+	m.pio = pio.New()
+	return m
+}
+
+// Model gets the mouse model
+func (m *Mouse) Model() int { return m.model }
+
+// Init initializes the device
+func (m *Mouse) Init() { m.Reset() }
+
+// Reset resets the device
+func (m *Mouse) Reset() {
+	m.x, m.y = 0, 0
+	m.dx, m.dy = 0, 0
+	m.buttons = [3]bool{}
+	m.pio.Reset()
+}
+
+// Move moves the mouse. Kempston counters grow to the right and upwards.
+func (m *Mouse) Move(dx, dy int) {
+	switch m.model {
+	case MouseKempston:
+		m.x += byte(dx)
+		m.y -= byte(dy)
+	case MouseAMX:
+		m.dx += dx
+		m.dy += dy
+	}
+}
+
+// SetButton sets button state
+func (m *Mouse) SetButton(button byte, pressed bool) {
+	if int(button) < len(m.buttons) {
+		m.buttons[button] = pressed
+	}
+}
+
+// Read reads the mouse interface at port address
+func (m *Mouse) Read(address uint16) byte {
+	switch m.model {
+	case MouseKempston:
+		switch address & _KempstonMouseMask {
+		case _KempstonMouseButtons:
+			return m.buttonState(_KempstonMouseLeft, _KempstonMouseRight, _KempstonMouseMiddle)
+		case _KempstonMouseX:
+			return m.x
+		case _KempstonMouseY:
+			return m.y
+		}
+	case MouseAMX:
+		switch address & 0x00ff {
+		case _AmxPortAData:
+			return m.pio.ReadData(pio.PortA)
+		case _AmxPortBData:
+			return m.pio.ReadData(pio.PortB)
+		case _AmxPortButtons:
+			return m.buttonState(_AmxLeft, _AmxRight, _AmxMiddle)
+		}
+	}
+	return 0xff
+}
+
+// Write writes the mouse interface at port address
+func (m *Mouse) Write(address uint16, data byte) {
+	if m.model != MouseAMX {
+		return
+	}
+	switch address & 0x00ff {
+	case _AmxPortAData:
+		m.pio.WriteData(pio.PortA, data)
+	case _AmxPortBData:
+		m.pio.WriteData(pio.PortB, data)
+	case _AmxPortAControl:
+		m.pio.WriteControl(pio.PortA, data)
+	case _AmxPortBControl:
+		m.pio.WriteControl(pio.PortB, data)
+	}
+}
+
+// Emulate strobes the AMX motion pulses, one pulse per axis while the
+// previous one is not acknowledged. Data bit 0 is set on left and up moves.
+func (m *Mouse) Emulate() {
+	if m.model != MouseAMX {
+		return
+	}
+	if m.dx != 0 && !m.pio.IsPending(pio.PortA) {
+		m.pio.Strobe(pio.PortA, amxDirection(m.dx))
+		m.dx -= amxStep(m.dx)
+	}
+	if m.dy != 0 && !m.pio.IsPending(pio.PortB) {
+		m.pio.Strobe(pio.PortB, amxDirection(m.dy))
+		m.dy -= amxStep(m.dy)
+	}
+}
+
+// IntRequest checks if the AMX interface is requesting an interrupt
+func (m *Mouse) IntRequest() bool {
+	return m.model == MouseAMX && m.pio.IntRequest()
+}
+
+// IntAcknowledge acknowledges the AMX interrupt and returns its vector
+func (m *Mouse) IntAcknowledge() byte {
+	return m.pio.IntAcknowledge()
+}
+
+// buttonState returns the active low buttons state
+func (m *Mouse) buttonState(left, right, middle byte) byte {
+	result := byte(0xff)
+	if m.buttons[mouse.ButtonLeft] {
+		result &^= left
+	}
+	if m.buttons[mouse.ButtonRight] {
+		result &^= right
+	}
+	if m.buttons[mouse.ButtonMiddle] {
+		result &^= middle
+	}
+	return result
+}
+
+// amxDirection returns the AMX data of a motion
+func amxDirection(delta int) byte {
+	if delta < 0 {
+		return 0x01
+	}
+	return 0x00
+}
+
+// amxStep returns the motion unit of a delta
+func amxStep(delta int) int {
+	if delta < 0 {
+		return -1
+	}
+	return 1
+}
+
+// mouseModel returns the mouse model of an option name
+func mouseModel(name string) int {
+	if name == "" {
+		return MouseNone
+	}
+	if model, ok := MouseModels[strings.ToLower(name)]; ok {
+		return model
+	}
+	log.Println("Spectrum : Unknown mouse:", name)
+	return MouseNone
+}
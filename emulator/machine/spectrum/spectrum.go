@@ -2,6 +2,7 @@
 package spectrum
 
 import (
+	"errors"
 	"log"
 
 	"github.com/jtruco/emu8/emulator/config"
@@ -10,9 +11,11 @@ import (
 	"github.com/jtruco/emu8/emulator/device/bus"
 	"github.com/jtruco/emu8/emulator/device/cpu"
 	"github.com/jtruco/emu8/emulator/device/cpu/z80"
+	"github.com/jtruco/emu8/emulator/device/io/keyboard"
 	"github.com/jtruco/emu8/emulator/device/io/tape"
 	"github.com/jtruco/emu8/emulator/device/memory"
 	"github.com/jtruco/emu8/emulator/machine"
+	"github.com/jtruco/emu8/emulator/machine/spectrum/basic"
 	"github.com/jtruco/emu8/emulator/machine/spectrum/format"
 )
 
@@ -24,17 +27,39 @@ import (
 const (
 	ZXSpectrum16K = iota
 	ZXSpectrum48K
+	Pentagon128
+	Scorpion256
+	TimexTC2048
+	TimexTS2068
 )
 
 // Default ZX Spectrum constants
 const (
 	zxFPS         = 50    // 50 Hz (50.08 Hz)
-	zxTStates     = 69888 // TStates per frame
-	zxIntTstates  = 32    // ZX Spectrum 16k & 48k
-	zxVideoMemory = 1     // Video memory bank
-	zxRomName     = "zxspectrum.rom"
+	zxTStates     = 69888 // TStates per frame (16k & 48k)
+	zxVideoMemory = 1     // Video memory bank (16k & 48k)
 )
 
+// zxTiming are the model frame timings
+type zxTiming struct {
+	frame       int  // TStates per frame
+	interrupt   int  // Interrupt length
+	firstScreen int  // TState of the first screen pixel
+	line        int  // TStates per scanline
+	fps         int  // Frames per second
+	contended   bool // Memory and IO contention
+}
+
+// zxTimings model timings
+var zxTimings = [...]zxTiming{
+	ZXSpectrum16K: {zxTStates, 32, tvFirstScreenTstate, tvLineTstates, zxFPS, true},
+	ZXSpectrum48K: {zxTStates, 32, tvFirstScreenTstate, tvLineTstates, zxFPS, true},
+	Pentagon128:   {71680, 36, 17988, tvLineTstates, zxFPS, false},
+	Scorpion256:   {69888, 36, 14344, tvLineTstates, zxFPS, false},
+	TimexTC2048:   {zxTStates, 32, tvFirstScreenTstate, tvLineTstates, zxFPS, true},
+	TimexTS2068:   {59736, 32, 9120, 228, 60, true}, // NTSC : 262 lines
+}
+
 // Spectrum the ZX Spectrum
 type Spectrum struct {
 	config     machine.Config      // Machine information
@@ -43,32 +68,76 @@ type Spectrum struct {
 	clock      *device.ClockDevice // The system clock
 	cpu        *z80.Z80            // The Zilog Z80A CPU
 	memory     *memory.Memory      // The machine memory
+	paging     *Paging             // The 128K memory paging (nil on 16k & 48k)
+	scld       *SCLD               // The Timex SCLD (Timex models)
 	ula        *ULA                // The spectrum ULA
 	tv         *TvVideo            // The spectrum TV video output
 	beeper     *audio.Beeper       // The spectrum Beeper
+	psg        *audio.AY38910      // The AY sound chip (128K models)
+	mixer      *audio.Mixer        // The Beeper and AY mixer (128K models)
+	beta       *Beta               // The Beta 128 disk interface (128K models)
+	dock       []*format.DockBank  // The Timex DOCK cartridge
 	keyboard   *Keyboard           // The spectrum Keyboard
 	tape       *tape.Drive         // The spectrum Tape drive
-	joystick   *Joystick           // The spectrum Joystick
+	joysticks  [2]*Joystick        // The spectrum Joysticks (ports 1 & 2)
+	mouse      *Mouse              // The spectrum Mouse
+	typist     *keyboard.Typist    // The spectrum keyboard typist
+	fastload   bool                // Tape fast loading
+	fresh      bool                // Machine is freshly reset
+	ulaInt     bool                // ULA interrupt request
+	timing     zxTiming            // The model timings
+	psgCycles  int                 // AY cycles emulated in the frame
 }
 
 // New returns a new ZX Spectrum
 func New(model int) machine.Machine {
 	spectrum := new(Spectrum)
 	spectrum.config.Model = model
-	spectrum.config.SetTimings(zxTStates, zxFPS)
+	spectrum.timing = zxTimings[model]
+	spectrum.config.SetTimings(spectrum.timing.frame, spectrum.timing.fps)
 	// memory mapping
-	if spectrum.config.Model == ZXSpectrum16K {
+	switch spectrum.config.Model {
+	case ZXSpectrum16K:
 		spectrum.memory = memory.New(2)
 		spectrum.memory.SetMap(0, memory.NewROM(0x0000, memory.Size16K))
 		spectrum.memory.SetMap(1, memory.NewRAM(0x4000, memory.Size16K))
-	} else {
+		spectrum.memory.SetMapper(bus.NewMaskMapper(14))
+	case ZXSpectrum48K, TimexTC2048:
 		spectrum.memory = memory.New(4)
 		spectrum.memory.SetMap(0, memory.NewROM(0x0000, memory.Size16K))
 		spectrum.memory.SetMap(1, memory.NewRAM(0x4000, memory.Size16K))
 		spectrum.memory.SetMap(2, memory.NewRAM(0x8000, memory.Size16K))
 		spectrum.memory.SetMap(3, memory.NewRAM(0xC000, memory.Size16K))
+		spectrum.memory.SetMapper(bus.NewMaskMapper(14))
+	case TimexTS2068:
+		spectrum.memory = memory.New(timexChunks)
+		for i := 0; i < timexChunks; i++ {
+			spectrum.memory.SetMap(i, memory.NewROM(uint16(i&7)<<13, memory.Size8K))
+		}
+		for i := timexHomeRAM; i < timexDock; i++ {
+			spectrum.memory.SetMap(i, memory.NewRAM(uint16(i&7)<<13, memory.Size8K))
+		}
+	default:
+		pages := 8
+		if spectrum.config.Model == Scorpion256 {
+			pages = 16
+		}
+		spectrum.memory = memory.New(zxROMPages + pages)
+		for i := 0; i < zxROMPages; i++ {
+			spectrum.memory.SetMap(i, memory.NewROM(0x0000, memory.Size16K))
+		}
+		for i := 0; i < pages; i++ {
+			spectrum.memory.SetMap(zxROMPages+i, memory.NewRAM(0xC000, memory.Size16K))
+		}
+		spectrum.paging = NewPaging(spectrum, pages)
+		spectrum.memory.SetMapper(spectrum.paging)
+	}
+	if spectrum.isTimex() {
+		spectrum.scld = NewSCLD(spectrum)
+		if spectrum.config.Model == TimexTS2068 {
+			spectrum.memory.SetMapper(spectrum.scld)
+		}
 	}
-	spectrum.memory.SetMapper(bus.NewMaskMapper(14))
 	// build device components
 	spectrum.clock = device.NewClock()
 	spectrum.ula = NewULA(spectrum)
@@ -76,11 +145,36 @@ func New(model int) machine.Machine {
 	spectrum.cpu.OnIntAck = spectrum.onInterruptAck
 	spectrum.tv = NewTVVideo(spectrum)
 	spectrum.beeper = audio.NewBeeper(
-		audio.NewConfig(config.Get().Audio.Frequency, zxFPS, zxTStates))
+		audio.NewConfig(config.Get().Audio.Frequency, spectrum.timing.fps, spectrum.timing.frame))
 	spectrum.beeper.SetMap(zxBeeperMap)
+	if spectrum.paging != nil || spectrum.config.Model == TimexTS2068 {
+		// AY clocked at half CPU clock, a sample every 8 AY clocks (rounded up)
+		spectrum.psg = audio.NewAY38910(
+			audio.NewConfig(config.Get().Audio.Frequency, spectrum.timing.fps, (spectrum.timing.frame+15)>>4))
+		spectrum.mixer = audio.NewMixer(spectrum.beeper, spectrum.psg)
+	}
+	if spectrum.paging != nil {
+		spectrum.beta = NewBeta(spectrum)
+		spectrum.cpu.OnFetch = spectrum.beta.onFetch
+	}
 	spectrum.keyboard = NewKeyboard()
 	spectrum.tape = tape.New(spectrum.clock)
-	spectrum.joystick = NewJoystick()
+	for i := range spectrum.joysticks {
+		spectrum.joysticks[i] = NewJoystick(byte(i), spectrum.keyboard)
+	}
+	spectrum.mouse = NewMouse(mouseModel(config.Get().Machine.Option("mouse")))
+	joy1 := JoystickKempston
+	if spectrum.config.Model == TimexTS2068 {
+		joy1 = JoystickTimex
+	} else if spectrum.mouse.Model() == MouseAMX {
+		joy1 = JoystickNone // AMX shares the Kempston port
+	}
+	spectrum.joysticks[0].SetModel(joystickModel(config.Get().Machine.Option("joy1"), joy1))
+	spectrum.joysticks[1].SetModel(joystickModel(config.Get().Machine.Option("joy2"), JoystickNone))
+	spectrum.tape.SetAutoPlay(config.Get().Tape.AutoStart)
+	spectrum.tape.OnInsert = spectrum.onTapeInsert
+	spectrum.typist = keyboard.NewTypist(spectrum.keyboard)
+	spectrum.fastload = config.Get().Tape.FastLoad
 	// register all components
 	spectrum.components = device.NewComponents()
 	spectrum.components.Add(spectrum.clock)
@@ -91,7 +185,15 @@ func New(model int) machine.Machine {
 	spectrum.components.Add(spectrum.beeper)
 	spectrum.components.Add(spectrum.keyboard)
 	spectrum.components.Add(spectrum.tape)
-	spectrum.components.Add(spectrum.joystick)
+	spectrum.components.Add(spectrum.joysticks[0])
+	spectrum.components.Add(spectrum.joysticks[1])
+	spectrum.components.Add(spectrum.mouse)
+	if spectrum.psg != nil {
+		spectrum.components.Add(spectrum.psg)
+	}
+	if spectrum.beta != nil {
+		spectrum.components.Add(spectrum.beta)
+	}
 
 	return spectrum
 }
@@ -116,13 +218,81 @@ func (spectrum *Spectrum) Reset() {
 
 // initSpectrum commont init tasks
 func (spectrum *Spectrum) initSpectrum() {
+	spectrum.typist.Cancel()
+	spectrum.fresh = true
+	spectrum.psgCycles = 0
+	if spectrum.paging != nil {
+		spectrum.paging.Reset()
+		spectrum.initROMs()
+		return
+	}
 	// load ROM at bank 0
-	data, err := spectrum.control.LoadROM(zxRomName)
+	data, err := spectrum.romSet().Load(spectrum.control, "rom")
 	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+	if spectrum.config.Model == TimexTS2068 {
+		spectrum.scld.Reset()
+		spectrum.initTimex(data)
 		return
 	}
 	rom := spectrum.memory.Bank(0)
 	rom.Load(0, data[0:0x4000])
+	if spectrum.scld != nil {
+		spectrum.scld.Reset()
+	}
+}
+
+// romSet returns the model ROM set
+func (spectrum *Spectrum) romSet() *machine.RomSet {
+	switch spectrum.config.Model {
+	case Pentagon128:
+		return pentagonRomSet
+	case Scorpion256:
+		return scorpionRomSet
+	case TimexTC2048:
+		return tc2048RomSet
+	case TimexTS2068:
+		return ts2068RomSet
+	default:
+		return zxRomSet
+	}
+}
+
+// isTimex checks if the model is a Timex model
+func (spectrum *Spectrum) isTimex() bool {
+	return spectrum.config.Model == TimexTC2048 || spectrum.config.Model == TimexTS2068
+}
+
+// videoMaps returns the memory maps of the video memory (16k, 48k & Timex)
+func (spectrum *Spectrum) videoMaps() []int {
+	switch {
+	case spectrum.paging != nil:
+		return nil
+	case spectrum.config.Model == TimexTS2068:
+		return []int{timexVideoChunk, timexVideoChunk + 1}
+	default:
+		return []int{zxVideoMemory}
+	}
+}
+
+// initROMs loads the 128K models ROM pages
+func (spectrum *Spectrum) initROMs() {
+	set := spectrum.romSet()
+	for page, slot := range zx128RomSlots {
+		if set.Slot(slot) == nil {
+			continue
+		}
+		data, err := set.Load(spectrum.control, slot)
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		if data != nil {
+			spectrum.memory.Bank(page).Load(0, data[0:0x4000])
+		}
+	}
 }
 
 // Machine properties
@@ -151,15 +321,31 @@ func (spectrum *Spectrum) Components() *device.Components {
 func (spectrum *Spectrum) InitControl(control machine.Control) {
 	// Bind devices
 	control.BindVideo(spectrum.tv)
-	control.BindAudio(spectrum.beeper)
+	if spectrum.mixer != nil {
+		control.BindAudio(spectrum.mixer)
+	} else {
+		control.BindAudio(spectrum.beeper)
+	}
 	control.BindKeyboard(spectrum.keyboard)
-	control.BindJoystick(spectrum.joystick)
+	control.BindJoystick(spectrum.joysticks[0])
+	control.BindJoystick(spectrum.joysticks[1])
+	if spectrum.mouse.Model() != MouseNone {
+		control.BindMouse(spectrum.mouse)
+	}
 	control.BindTapeDrive(spectrum.tape)
 	// Register formats
 	control.RegisterSnapshot(format.SNA)
 	control.RegisterSnapshot(format.Z80)
+	control.RegisterSnapshot(format.SZX)
 	control.RegisterTape(format.TAP, format.NewTap)
 	control.RegisterTape(format.TZX, format.NewTzx)
+	if spectrum.beta != nil {
+		control.RegisterDisk(format.TRD)
+		control.RegisterDisk(format.SCL)
+	}
+	if spectrum.config.Model == TimexTS2068 {
+		control.RegisterCartridge(format.DCK)
+	}
 	spectrum.control = control
 }
 
@@ -167,29 +353,57 @@ func (spectrum *Spectrum) InitControl(control machine.Control) {
 
 // BeginFrame begin emulation frame tasks
 func (spectrum *Spectrum) BeginFrame() {
-	// Request cpu maskable interrupt
-	spectrum.cpu.InterruptRequest(true)
+	spectrum.psgCycles = 0
+	// Request cpu maskable interrupt, unless disabled by the Timex SCLD
+	spectrum.ulaInt = spectrum.scld == nil || spectrum.scld.IntEnabled()
+	spectrum.cpu.InterruptRequest(spectrum.ulaInt)
+	// Keyboard typing
+	spectrum.typist.Frame()
 }
 
 // Emulate one machine step
 func (spectrum *Spectrum) Emulate() {
+	// Tape loader detection & fast loading
+	if spectrum.isLoaderActive() {
+		spectrum.tape.LoaderActive()
+	}
+	if spectrum.isLoaderTrap() && spectrum.loadBytes() {
+		return
+	}
+
 	// Executes a CPU instruction
 	tstates := spectrum.cpu.Execute()
 
 	// Maskable interrupt request length
-	if spectrum.cpu.IntRq && spectrum.clock.Tstates() >= zxIntTstates {
+	if spectrum.ulaInt && spectrum.clock.Tstates() >= spectrum.timing.interrupt {
+		spectrum.ulaInt = false
 		spectrum.cpu.InterruptRequest(false)
 	}
 
+	// Mouse interface interrupts
+	if spectrum.mouse.Model() == MouseAMX {
+		spectrum.mouse.Emulate()
+		spectrum.cpu.InterruptRequest(spectrum.ulaInt || spectrum.mouse.IntRequest())
+	}
+
 	// Tape emulation
 	spectrum.tape.Emulate(tstates)
 }
 
 // EndFrame end emulation frame tasks
-func (spectrum *Spectrum) EndFrame() {} // nothing to do
+func (spectrum *Spectrum) EndFrame() {
+	if spectrum.psg != nil {
+		spectrum.emulatePSG()
+	}
+}
 
-// onInterruptAck
+// onInterruptAck sets the interrupt vector on the data bus. The ULA leaves
+// the bus floating, the AMX mouse PIO puts its vector.
 func (spectrum *Spectrum) onInterruptAck() bool {
+	spectrum.cpu.DataBus = 0xff
+	if !spectrum.ulaInt && spectrum.mouse.IntRequest() {
+		spectrum.cpu.DataBus = spectrum.mouse.IntAcknowledge()
+	}
 	return true
 }
 
@@ -203,6 +417,8 @@ func (spectrum *Spectrum) LoadState(state machine.State) {
 		snap = format.LoadSNA(state.Data)
 	case format.Z80:
 		snap = format.LoadZ80(state.Data)
+	case format.SZX:
+		snap = format.LoadSZX(state.Data)
 	default:
 		log.Println("Spectrum : Not implemented snap format:", state.Format)
 	}
@@ -212,14 +428,26 @@ func (spectrum *Spectrum) LoadState(state machine.State) {
 }
 
 func (spectrum *Spectrum) loadSnapshot(snap *format.Snapshot) {
+	spectrum.fresh = false
 	spectrum.cpu.State.Copy(&snap.State)    // CPU
 	spectrum.clock.SetTstates(snap.Tstates) // TStates
 	spectrum.tv.SetBorder(snap.Border)      // Border
+	if spectrum.scld != nil {
+		spectrum.scld.Reset() // Spectrum video mode & home bank
+	}
 	// Memory banks (16k, 48k)
-	if spectrum.config.Model == ZXSpectrum16K {
+	switch spectrum.config.Model {
+	case ZXSpectrum16K:
 		spectrum.memory.LoadRAM(0x4000, snap.Memory[0:0x4000])
-	} else {
+	case ZXSpectrum48K, TimexTC2048:
 		spectrum.memory.LoadRAM(0x4000, snap.Memory[0:0xC000])
+	default: // 48K mode
+		if spectrum.paging != nil {
+			spectrum.paging.Set48K()
+		}
+		for i, data := range snap.Memory {
+			spectrum.memory.Poke(uint16(0x4000+i), data)
+		}
 	}
 }
 
@@ -236,10 +464,59 @@ func (spectrum *Spectrum) saveSnapshot() *format.Snapshot {
 	snap.Tstates = spectrum.clock.Tstates() // Clock
 	snap.Border = spectrum.tv.border        // Border
 	// Memory banks (16k, 48k)
-	spectrum.memory.Bank(1).Save(snap.Memory[0x0000:])
-	if spectrum.config.Model == ZXSpectrum48K {
+	switch spectrum.config.Model {
+	case ZXSpectrum16K:
+		spectrum.memory.Bank(1).Save(snap.Memory[0x0000:])
+	case ZXSpectrum48K, TimexTC2048:
+		spectrum.memory.Bank(1).Save(snap.Memory[0x0000:])
 		spectrum.memory.Bank(2).Save(snap.Memory[0x4000:])
 		spectrum.memory.Bank(3).Save(snap.Memory[0x8000:])
+	default: // mapped RAM
+		for i := range snap.Memory {
+			snap.Memory[i] = spectrum.memory.Peek(uint16(0x4000 + i))
+		}
 	}
 	return snap
 }
+
+// Cartridges
+
+// InsertCartridge inserts a Timex DOCK cartridge and resets the machine
+func (spectrum *Spectrum) InsertCartridge(data []byte) error {
+	if spectrum.config.Model != TimexTS2068 {
+		return errors.New("DOCK cartridges not supported")
+	}
+	dock := format.LoadDCK(data)
+	if dock == nil {
+		return errors.New("Invalid DCK cartridge")
+	}
+	spectrum.dock = dock
+	spectrum.Reset()
+	return nil
+}
+
+// Disks
+
+// InsertDisk inserts a disk image into the Beta 128 first drive
+func (spectrum *Spectrum) InsertDisk(ext string, data []byte) error {
+	if spectrum.beta == nil {
+		return errors.New("Beta disk interface not available")
+	}
+	return spectrum.beta.InsertDisk(ext, data)
+}
+
+// BASIC : list & enter programs
+
+// ListProgram lists the BASIC program in memory
+func (spectrum *Spectrum) ListProgram() string {
+	return basic.Detokenize(basic.ReadProgram(spectrum.memory))
+}
+
+// EnterProgram loads a BASIC listing into memory
+func (spectrum *Spectrum) EnterProgram(listing string) error {
+	program, err := basic.Tokenize(listing)
+	if err != nil {
+		return err
+	}
+	return basic.WriteProgram(spectrum.memory, program)
+}
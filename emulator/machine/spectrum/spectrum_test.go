@@ -0,0 +1,152 @@
+package spectrum
+
+import "testing"
+
+// newTestSpectrum creates an initialized machine, without ROMs
+func newTestSpectrum(model int) *Spectrum {
+	spectrum := New(model).(*Spectrum)
+	spectrum.components.Init()
+	if spectrum.paging != nil {
+		spectrum.paging.Reset()
+		for page := 0; page < zxROMPages; page++ {
+			spectrum.memory.Bank(page).Load(0, []byte{0xf0 + byte(page)})
+		}
+		for page := 0; page < spectrum.paging.pages; page++ {
+			spectrum.memory.Bank(zxROMPages+page).Write(0, byte(page))
+		}
+	}
+	return spectrum
+}
+
+// TestTimings checks the frame timings of the models
+func TestTimings(t *testing.T) {
+	tests := []struct {
+		model, frame, fps int
+		contended         bool
+	}{
+		{ZXSpectrum48K, 69888, 50, true},
+		{Pentagon128, 71680, 50, false},
+		{Scorpion256, 69888, 50, false},
+		{TimexTC2048, 69888, 50, true},
+		{TimexTS2068, 59736, 60, true},
+	}
+	for _, test := range tests {
+		spectrum := New(test.model).(*Spectrum)
+		timing := spectrum.timing
+		if timing.frame != test.frame || timing.fps != test.fps || timing.contended != test.contended {
+			t.Errorf("model %d : %d tstates, %d fps, contended %v", test.model, timing.frame, timing.fps, timing.contended)
+		}
+	}
+}
+
+// pagingTest is a paging port write and the expected mapping
+type pagingTest struct {
+	name       string
+	port       uint16
+	data       byte
+	rom, page  byte // ROM at 0x0000, RAM page at 0xc000
+	lowerRAM   bool // RAM page 0 at 0x0000
+	upperPages bool
+}
+
+// testPaging writes the paging ports and checks the mapped ROM and RAM pages
+func testPaging(t *testing.T, spectrum *Spectrum, tests []pagingTest) {
+	for _, test := range tests {
+		spectrum.ula.Write(test.port, test.data)
+		rom := 0xf0 + test.rom
+		if test.lowerRAM {
+			rom = 0
+		}
+		if data := spectrum.memory.Peek(0x0000); data != rom {
+			t.Errorf("%s : read %02x at 0x0000, expected %02x", test.name, data, rom)
+		}
+		if data := spectrum.memory.Peek(0xc000); data != test.page {
+			t.Errorf("%s : RAM page %d at 0xc000, expected %d", test.name, data, test.page)
+		}
+		if data := spectrum.memory.Peek(0x4000); data != zxScreenPage {
+			t.Errorf("%s : RAM page %d at 0x4000, expected %d", test.name, data, zxScreenPage)
+		}
+	}
+}
+
+// TestPentagonPaging checks the 128K paging of the Pentagon and the paging
+// lock
+func TestPentagonPaging(t *testing.T) {
+	testPaging(t, newTestSpectrum(Pentagon128), []pagingTest{
+		{name: "page 3", port: 0x7ffd, data: 0x03, rom: zxROM128, page: 3},
+		{name: "48K ROM", port: 0x7ffd, data: 0x17, rom: zxROM48, page: 7},
+		{name: "partial decoding", port: 0x3ffd, data: 0x01, rom: zxROM128, page: 1},
+		{name: "locked", port: 0x7ffd, data: 0x34, rom: zxROM48, page: 4},
+		{name: "after lock", port: 0x7ffd, data: 0x02, rom: zxROM48, page: 4},
+	})
+}
+
+// TestScorpionPaging checks the 0x1ffd port of the Scorpion : the upper
+// 128K RAM pages, the RAM at 0x0000 and the service ROM
+func TestScorpionPaging(t *testing.T) {
+	testPaging(t, newTestSpectrum(Scorpion256), []pagingTest{
+		{name: "page 3", port: 0x7ffd, data: 0x03, rom: zxROM128, page: 3},
+		{name: "not decoded 0x3ffd", port: 0x3ffd, data: 0x01, rom: zxROM128, page: 3},
+		{name: "upper page 11", port: 0x1ffd, data: 0x10, rom: zxROM128, page: 11},
+		{name: "service ROM", port: 0x1ffd, data: 0x02, rom: zxROMService, page: 3},
+		{name: "RAM at 0x0000", port: 0x1ffd, data: 0x01, lowerRAM: true, page: 3},
+		{name: "0x1ffd cleared", port: 0x1ffd, data: 0x00, rom: zxROM128, page: 3},
+	})
+	if spectrum := newTestSpectrum(Scorpion256); spectrum.paging.Page() != 0 {
+		t.Errorf("reset : RAM page %d", spectrum.paging.Page())
+	}
+}
+
+// TestTimexPorts checks the SCLD control port and the full decoding of the
+// ULA port of the TC2048
+func TestTimexPorts(t *testing.T) {
+	spectrum := newTestSpectrum(TimexTC2048)
+	spectrum.ula.Write(0x00ff, 0x46) // hi-res mode, interrupts disabled
+	if data := spectrum.ula.Read(0x00ff); data != 0x46 {
+		t.Errorf("SCLD control : read %02x, expected 46", data)
+	}
+	if spectrum.tv.mode != 0x06 || spectrum.scld.IntEnabled() {
+		t.Errorf("SCLD control : video mode %02x, interrupts %v", spectrum.tv.mode, spectrum.scld.IntEnabled())
+	}
+	if !spectrum.ula.isSelected(0x00fe) || spectrum.ula.isSelected(0x00fc) {
+		t.Error("ULA port not fully decoded")
+	}
+	if spectrum.ula.Read(0x00f4) != 0xff {
+		t.Error("TC2048 : horizontal select register port decoded")
+	}
+}
+
+// TestTimexBanks checks the TS2068 8K chunks selected by the horizontal
+// select register from the HOME, DOCK and EXROM banks
+func TestTimexBanks(t *testing.T) {
+	spectrum := newTestSpectrum(TimexTS2068)
+	for chunk := 0; chunk < timexChunks; chunk++ {
+		spectrum.memory.Bank(chunk).Load(0, []byte{byte(chunk)})
+		spectrum.scld.present[chunk] = chunk < timexDock || chunk >= timexExrom
+	}
+	spectrum.scld.present[timexDock+7] = true
+	tests := []struct {
+		name     string
+		control  byte
+		hsr      byte
+		address  uint16
+		expected byte
+	}{
+		{"HOME ROM", 0x00, 0x00, 0x0000, 0},
+		{"HOME RAM", 0x00, 0x00, 0xe000, 7},
+		{"EXROM", 0x80, 0x01, 0x0000, timexExrom},
+		{"HOME not selected", 0x80, 0x01, 0x2000, 1},
+		{"DOCK", 0x00, 0x80, 0xe000, timexDock + 7},
+		{"DOCK not present", 0x00, 0x01, 0x0000, 0xff},
+	}
+	for _, test := range tests {
+		spectrum.ula.Write(0x00ff, test.control)
+		spectrum.ula.Write(0x00f4, test.hsr)
+		if data := spectrum.memory.Peek(test.address); data != test.expected {
+			t.Errorf("%s : read %02x at %04x, expected %02x", test.name, data, test.address, test.expected)
+		}
+	}
+	if data := spectrum.ula.Read(0x00f4); data != 0x01 {
+		t.Errorf("horizontal select register : read %02x, expected 01", data)
+	}
+}
@@ -0,0 +1,183 @@
+package spectrum
+
+import (
+	"log"
+
+	"github.com/jtruco/emu8/emulator/device/bus"
+	"github.com/jtruco/emu8/emulator/device/memory"
+	"github.com/jtruco/emu8/emulator/machine/spectrum/format"
+)
+
+// -----------------------------------------------------------------------------
+// Timex SCLD
+// -----------------------------------------------------------------------------
+
+// Timex memory constants. The TS2068 memory is mapped in 8K chunks : the
+// HOME bank (16K ROM & 48K RAM), the DOCK cartridge bank and the EXROM bank.
+const (
+	timexChunks     = 24 // HOME, DOCK & EXROM chunks
+	timexHomeRAM    = 2  // First HOME RAM chunk
+	timexDock       = 8  // First DOCK chunk
+	timexExrom      = 16 // First EXROM chunk
+	timexVideoChunk = 2  // Video memory chunk (0x4000)
+)
+
+// Timex ports & SCLD control bits
+const (
+	timexPortSCLD  = 0xff // SCLD control port
+	timexPortHSR   = 0xf4 // Horizontal select register port (TS2068)
+	timexPortAYReg = 0xf5 // AY register select port (TS2068)
+	timexPortAY    = 0xf6 // AY data port (TS2068)
+	scldVideoMode  = 0x3f // Video mode bits
+	scldIntDisable = 0x40 // Disable ULA interrupts
+	scldExrom      = 0x80 // HSR selects EXROM instead of DOCK
+)
+
+// SCLD is the Timex Sinclair Computer Logic Device. The control port 0xff
+// sets the video mode and disables the interrupts. On the TS2068 each bit
+// of the horizontal select register (port 0xf4) maps an 8K chunk from the
+// HOME bank or from the DOCK or EXROM banks.
+type SCLD struct {
+	spectrum *Spectrum         // The spectrum machine
+	maps     bus.Maps          // The memory chunks (TS2068)
+	control  byte              // The control register (0xff)
+	hsr      byte              // The horizontal select register (0xf4)
+	present  [timexChunks]bool // Chunk is present
+}
+
+// NewSCLD creates the Timex SCLD
+func NewSCLD(spectrum *Spectrum) *SCLD {
+	scld := new(SCLD)
+	scld.spectrum = spectrum
+	return scld
+}
+
+// Reset resets the SCLD registers
+func (scld *SCLD) Reset() {
+	scld.control = 0
+	scld.hsr = 0
+	if scld.spectrum.tv != nil {
+		scld.spectrum.tv.SetMode(0)
+	}
+}
+
+// IntEnabled checks if the ULA interrupts are enabled
+func (scld *SCLD) IntEnabled() bool { return scld.control&scldIntDisable == 0 }
+
+// WriteControl writes the control register
+func (scld *SCLD) WriteControl(data byte) {
+	scld.control = data
+	scld.spectrum.tv.SetMode(data & scldVideoMode)
+}
+
+// Mapper
+
+// Init inits the mapper
+func (scld *SCLD) Init(maps bus.Maps) { scld.maps = maps }
+
+// Select selects the map at address for read access
+func (scld *SCLD) Select(address uint16) (*bus.Map, uint16) {
+	chunk := int(address >> 13)
+	if scld.hsr&(1<<uint(chunk)) != 0 {
+		if scld.control&scldExrom != 0 {
+			chunk += timexExrom
+		} else {
+			chunk += timexDock
+		}
+	}
+	if !scld.present[chunk] {
+		return nil, 0
+	}
+	return scld.maps[chunk], address & 0x1fff
+}
+
+// SelectWrite selects the map at address for write access
+func (scld *SCLD) SelectWrite(address uint16) (*bus.Map, uint16) {
+	return scld.Select(address)
+}
+
+// -----------------------------------------------------------------------------
+// Timex - IO ports & DOCK
+// -----------------------------------------------------------------------------
+
+// readTimex reads the SCLD ports and the TS2068 AY data port
+func (spectrum *Spectrum) readTimex(address uint16) byte {
+	switch address & 0xff {
+	case timexPortSCLD:
+		return spectrum.scld.control
+	case timexPortHSR:
+		if spectrum.config.Model == TimexTS2068 {
+			return spectrum.scld.hsr
+		}
+	case timexPortAY:
+		if spectrum.psg != nil {
+			return spectrum.psg.ReadRegister(spectrum.psg.Selected())
+		}
+	}
+	return 0xff
+}
+
+// writeTimex writes the SCLD ports and the TS2068 AY ports
+func (spectrum *Spectrum) writeTimex(address uint16, data byte) {
+	switch address & 0xff {
+	case timexPortSCLD:
+		spectrum.scld.WriteControl(data)
+	case timexPortHSR:
+		spectrum.scld.hsr = data
+	case timexPortAYReg:
+		if spectrum.psg != nil {
+			spectrum.psg.SelectRegister(data & 0x0f)
+		}
+	case timexPortAY:
+		if spectrum.psg != nil {
+			spectrum.emulatePSG()
+			spectrum.psg.WriteRegister(spectrum.psg.Selected(), data)
+		}
+	}
+}
+
+// initTimex loads the TS2068 ROMs and the DOCK cartridge. The home ROM is
+// 16K and the EXROM 8K, mirrored in all the EXROM chunks.
+func (spectrum *Spectrum) initTimex(rom []byte) {
+	scld := spectrum.scld
+	for i := range scld.present {
+		scld.present[i] = i < timexDock || i >= timexExrom
+	}
+	spectrum.memory.Bank(0).Load(0, rom[0:0x2000])
+	spectrum.memory.Bank(1).Load(0, rom[0x2000:0x4000])
+	exrom, err := ts2068RomSet.Load(spectrum.control, "exrom")
+	if err != nil {
+		log.Println(err.Error())
+	}
+	for i := timexDock; i < timexChunks; i++ {
+		spectrum.memory.SetMap(i, memory.NewROM(uint16(i&7)<<13, memory.Size8K))
+		if i >= timexExrom && exrom != nil {
+			spectrum.memory.Bank(i).Load(0, exrom)
+		}
+	}
+	// DOCK cartridge banks
+	for _, bank := range spectrum.dock {
+		first := 0
+		switch bank.ID {
+		case format.DockBankDock:
+			first = timexDock
+		case format.DockBankExrom:
+			first = timexExrom
+		}
+		for i, chunk := range bank.Chunks {
+			if chunk == nil {
+				continue
+			}
+			index := first + i
+			if index >= timexDock {
+				m := memory.NewROM(uint16(i&7)<<13, memory.Size8K)
+				if chunk.RAM {
+					m = memory.NewRAM(uint16(i&7)<<13, memory.Size8K)
+				}
+				spectrum.memory.SetMap(index, m)
+				scld.present[index] = true
+			}
+			spectrum.memory.Bank(index).Load(0, chunk.Data)
+		}
+	}
+}
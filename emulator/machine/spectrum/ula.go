@@ -28,24 +28,23 @@ var zxBeeperMap = []uint16{0, amplTape, amplBeeper, (amplBeeper + amplTape)}
 // IO contention pages
 var ulaIoPageContention = [4]bool{false, true, false, false}
 
-// ULA contention delay table
-var ulaDelayTable [zxTStates + tvLineTstates]int
-
-func init() {
-	// contention table
-	tstate := tvFirstScreenTstate - 1
+// newDelayTable builds the ULA contention delay table of the model timings
+func newDelayTable(timing *zxTiming) []int {
+	table := make([]int, timing.frame+timing.line)
+	tstate := timing.firstScreen - 1
 	for y := 0; y < tvScreenHeight; y++ {
 		for x := 0; x < tvScreenWidth; x += 16 {
 			tstatex := x / tvTstatePixels
-			ulaDelayTable[tstate+tstatex+0] = 6
-			ulaDelayTable[tstate+tstatex+1] = 5
-			ulaDelayTable[tstate+tstatex+2] = 4
-			ulaDelayTable[tstate+tstatex+3] = 3
-			ulaDelayTable[tstate+tstatex+4] = 2
-			ulaDelayTable[tstate+tstatex+5] = 1
+			table[tstate+tstatex+0] = 6
+			table[tstate+tstatex+1] = 5
+			table[tstate+tstatex+2] = 4
+			table[tstate+tstatex+3] = 3
+			table[tstate+tstatex+4] = 2
+			table[tstate+tstatex+5] = 1
 		}
-		tstate += tvLineTstates
+		tstate += timing.line
 	}
+	return table
 }
 
 // -----------------------------------------------------------------------------
@@ -56,13 +55,19 @@ func init() {
 type ULA struct {
 	spectrum *Spectrum // The spectrum machine
 	lastRead byte      // Last read value
+	delay    []int     // Contention delay table
 }
 
 // NewULA creates
 func NewULA(spectrum *Spectrum) *ULA {
 	ula := new(ULA)
 	ula.spectrum = spectrum
-	spectrum.memory.Map(zxVideoMemory).OnAccess = ula.onVideoAccess
+	if spectrum.timing.contended {
+		ula.delay = newDelayTable(&spectrum.timing)
+		for _, index := range spectrum.videoMaps() {
+			spectrum.memory.Map(index).OnAccess = ula.onVideoAccess
+		}
+	}
 	return ula
 }
 
@@ -90,18 +95,29 @@ func (ula *ULA) Read(address uint16) byte {
 	var result byte = 0xff
 	ula.preIO(address)
 	defer ula.postIO(address)
-	if (address & 0x0001) == 0x00 { // ULA selected
+	if ula.spectrum.beta != nil {
+		if data, ok := ula.spectrum.beta.Read(address); ok {
+			return data
+		}
+	}
+	if ula.isSelected(address) {
 		result = ula.lastRead
 		// Read keyboard state
 		scan := byte(address>>8) ^ 0xff
 		result &= ula.spectrum.keyboard.GetState(scan)
 		// Read tape state
+		ula.spectrum.tape.EarRead()
 		if ula.spectrum.tape.IsPlaying() && ula.spectrum.tape.EarHigh() {
 			result &^= 0x40
 		}
 	}
-	if (address & 0x00e0) == 0 { // Kempston selected
-		result &= ula.spectrum.joystick.State()
+	result &= ula.spectrum.joystickState(address)
+	result &= ula.spectrum.mouse.Read(address)
+	if ula.spectrum.paging != nil && (address&0x0001) != 0 {
+		result &= ula.spectrum.read128(address)
+	}
+	if ula.spectrum.scld != nil {
+		result &= ula.spectrum.readTimex(address)
 	}
 	return result
 }
@@ -110,7 +126,10 @@ func (ula *ULA) Read(address uint16) byte {
 func (ula *ULA) Write(address uint16, data byte) {
 	ula.preIO(address)
 	defer ula.postIO(address)
-	if (address & 0x0001) == 0 { // ULA selected
+	if ula.spectrum.beta != nil && ula.spectrum.beta.Write(address, data) {
+		return
+	}
+	if ula.isSelected(address) {
 		// border
 		ula.spectrum.tv.SetBorder(data & 0x07)
 		// beeper & tape output : EAR(bit 4) and MIC(bit 3) output
@@ -126,6 +145,22 @@ func (ula *ULA) Write(address uint16, data byte) {
 			ula.lastRead ^= 0x40
 		}
 	}
+	ula.spectrum.mouse.Write(address, data)
+	if ula.spectrum.paging != nil && (address&0x0001) != 0 {
+		ula.spectrum.write128(address, data)
+	}
+	if ula.spectrum.scld != nil {
+		ula.spectrum.writeTimex(address, data)
+	}
+}
+
+// isSelected checks if the ULA port is selected. The Timex SCLD fully
+// decodes the 0xfe port.
+func (ula *ULA) isSelected(address uint16) bool {
+	if ula.spectrum.scld != nil {
+		return address&0x00ff == 0xfe
+	}
+	return address&0x0001 == 0
 }
 
 // preIO contention
@@ -154,7 +189,10 @@ func (ula *ULA) postIO(address uint16) {
 
 // doContention aplies clock contention
 func (ula *ULA) doContention(tstates int) {
-	delay := ulaDelayTable[ula.spectrum.clock.Tstates()] + tstates
+	delay := tstates
+	if ula.spectrum.timing.contended {
+		delay += ula.delay[ula.spectrum.clock.Tstates()]
+	}
 	if delay > 0 {
 		ula.spectrum.clock.Add(delay)
 	}
@@ -163,5 +201,5 @@ func (ula *ULA) doContention(tstates int) {
 // isContended true if address access is contended
 func (ula *ULA) isContended(address uint16) bool {
 	page := address >> 14
-	return ulaIoPageContention[page]
+	return ula.spectrum.timing.contended && ulaIoPageContention[page]
 }
@@ -45,6 +45,28 @@ const (
 	tvLastScreenLine    = tvBorderTop + tvScreenHeight - 1
 )
 
+// Timex SCLD video modes (port 0xff bits 0-5)
+const (
+	scldScreen1  = 0x01 // Second screen at 0x6000
+	scldHiColour = 0x02 // Hi-colour : 8x1 attributes at 0x6000
+	scldHiRes    = 0x04 // Hi-res : 512x192, columns from 0x4000 and 0x6000
+	scldHiResInk = 0x38 // Hi-res colours (bits 3-5)
+	tvHiDataAddr = 0x2000
+)
+
+// tvDoubleBits doubles each pixel of a byte into 16 hi-res pixels
+var tvDoubleBits [256]uint16
+
+func init() {
+	for i := range tvDoubleBits {
+		for bit := uint(0); bit < 8; bit++ {
+			if i&(1<<bit) != 0 {
+				tvDoubleBits[i] |= 3 << (bit * 2)
+			}
+		}
+	}
+}
+
 // ZX Spetrum 16/48k RGBA colour palette
 var zxPaletteRGBA = []uint32{
 	/* Bright 0 (black, blue, red, magenta, green, cyan, yellow, white) */
@@ -57,12 +79,19 @@ var zxPaletteRGBA = []uint32{
 // ZX Spectrum TV video output
 // -----------------------------------------------------------------------------
 
-// TvVideo is the spectrum RF video device
+// TvVideo is the spectrum RF video device. The Timex models have a double
+// width screen for the 512 pixels hi-res mode.
 type TvVideo struct {
 	screen   *video.Screen // The video screen
 	clock    device.Clock  // The system clock
 	srcdata  []byte        // The screen data
+	hidata   []byte        // The Timex second screen data (0x6000)
 	tstate   int           // Current videoframe tstate
+	offset   int           // TState offset to the first scanline
+	line     int           // TStates per scanline
+	scale    int           // Screen pixels per spectrum pixel
+	timex    bool          // Timex SCLD video modes
+	mode     byte          // Timex SCLD video mode
 	border   byte          // The border current colour index
 	flash    bool          // Flash state
 	frames   int           // Frame count
@@ -72,11 +101,33 @@ type TvVideo struct {
 // NewTVVideo creates the video device
 func NewTVVideo(spectrum *Spectrum) *TvVideo {
 	tv := new(TvVideo)
-	tv.screen = video.NewScreen(tvTotalWidth, tvTotalHeight, zxPaletteRGBA)
-	tv.screen.SetView(tvViewLeft, tvViewTop, tvViewWidth, tvViewHeight)
+	tv.timex = spectrum.isTimex()
+	tv.scale = 1
+	if tv.timex {
+		tv.scale = 2
+	}
+	tv.screen = video.NewScreen(tvTotalWidth*tv.scale, tvTotalHeight, zxPaletteRGBA)
+	tv.screen.SetView(tvViewLeft*tv.scale, tvViewTop, tvViewWidth*tv.scale, tvViewHeight)
+	tv.screen.SetScaleX(1 / float32(tv.scale))
 	tv.clock = spectrum.clock
-	tv.srcdata = spectrum.memory.Bank(zxVideoMemory).Data()
-	spectrum.memory.Map(zxVideoMemory).OnPostAccess = tv.onVideoPostAccess
+	tv.line = spectrum.timing.line
+	tv.offset = tvBorderTop*tv.line - spectrum.timing.firstScreen
+	switch {
+	case spectrum.paging != nil:
+		tv.srcdata = spectrum.memory.Bank(zxROMPages + zxScreenPage).Data()
+		for _, page := range []int{zxScreenPage, zxShadowPage} {
+			spectrum.memory.Map(zxROMPages + page).OnPostAccess = spectrum.paging.onScreenPostAccess(page)
+		}
+	case spectrum.config.Model == TimexTS2068:
+		tv.srcdata = spectrum.memory.Bank(timexVideoChunk).Data()
+		tv.hidata = spectrum.memory.Bank(timexVideoChunk + 1).Data()
+	default:
+		tv.srcdata = spectrum.memory.Bank(zxVideoMemory).Data()
+		tv.hidata = tv.srcdata[tvHiDataAddr:]
+	}
+	for _, index := range spectrum.videoMaps() {
+		spectrum.memory.Map(index).OnPostAccess = tv.onVideoPostAccess
+	}
 	tv.accurate = true
 	return tv
 }
@@ -84,6 +135,9 @@ func NewTVVideo(spectrum *Spectrum) *TvVideo {
 // onVideoPostAccess on write in video memory
 func (tv *TvVideo) onVideoPostAccess(code int, address uint16) {
 	if code == bus.EventAfterWrite {
+		if tv.timex {
+			address &= tvHiDataAddr - 1
+		}
 		if tv.accurate && address < tvVideoSize {
 			tv.DoScanlines()
 		}
@@ -95,6 +149,9 @@ func (tv *TvVideo) SetAccurate(accurate bool) {
 	tv.accurate = accurate
 }
 
+// SetSource sets the screen data
+func (tv *TvVideo) SetSource(data []byte) { tv.srcdata = data }
+
 // SetBorder sets de current border color
 func (tv *TvVideo) SetBorder(colour byte) {
 	if tv.accurate {
@@ -103,6 +160,29 @@ func (tv *TvVideo) SetBorder(colour byte) {
 	tv.border = colour
 }
 
+// SetMode sets the Timex SCLD video mode
+func (tv *TvVideo) SetMode(mode byte) {
+	if tv.accurate {
+		tv.DoScanlines()
+	}
+	tv.mode = mode
+}
+
+// borderColour the border colour, the paper colour on hi-res mode
+func (tv *TvVideo) borderColour() uint32 {
+	if tv.mode&scldHiRes != 0 {
+		_, paper := tv.hiResColours()
+		return tv.screen.GetColour(paper)
+	}
+	return tv.screen.GetColour(int(tv.border))
+}
+
+// hiResColours the bright ink and paper colours of the hi-res mode
+func (tv *TvVideo) hiResColours() (int, int) {
+	colour := int(tv.mode&scldHiResInk) >> 3
+	return 0x08 | colour, 0x08 | (7 - colour)
+}
+
 // Device
 
 // Init initializes video device
@@ -112,6 +192,7 @@ func (tv *TvVideo) Init() { tv.Reset() }
 func (tv *TvVideo) Reset() {
 	tv.screen.Clear(0)
 	tv.border = 7
+	tv.mode = 0
 	tv.flash = false
 }
 
@@ -137,37 +218,15 @@ func (tv *TvVideo) Screen() *video.Screen { return tv.screen }
 
 // paintScreen is a simple screen emulation
 func (tv *TvVideo) paintScreen() {
-	// 3 banks, of 8 rows, of 8 lines, of 32 cols
-	baddr := 0
-	y, sy := 0, tvBorderTop
-	for b := 0; b < 3; b++ { // banks
-		for r := 0; r < 8; r++ { // rows
-			laddr := baddr + r<<5
-			for l := 0; l < 8; l++ { // lines
-				caddr := tvVideoAddr + laddr
-				aaddr := tvAttrAddr + (y>>3)<<5
-				sx := tvBorderLeft
-				for c := 0; c < 32; c++ { // columns
-					data := tv.srcdata[caddr]
-					attr := tv.srcdata[aaddr]
-					tv.paintByte(sy, sx, data, attr)
-					caddr++
-					aaddr++
-					sx += 8
-				}
-				y++
-				sy++
-				laddr += 0x100 // 8 * 32;
-			}
-		}
-		baddr += 0x800 // 2Kbytes
+	for y := tvFirstScreenLine; y <= tvLastScreenLine; y++ {
+		tv.scanlineScreen(y, tvBorderLeft, tvBorderLeft+tvScreenWidth-1)
 	}
 }
 
 // paintBorder is a simple border emulation
 func (tv *TvVideo) paintBorder() {
 	// Border Top, Bottom and Paper
-	border := tv.screen.GetColour(int(tv.border))
+	border := tv.borderColour()
 	view := tv.screen.View()
 	for y := view.Y; y < tvBorderTop; y++ {
 		tv.scanlineBorder(y, 0, tvTotalWidth-1, border)
@@ -181,32 +240,6 @@ func (tv *TvVideo) paintBorder() {
 	}
 }
 
-// paintByte paints a byte
-func (tv *TvVideo) paintByte(y, sx int, data, attr byte) {
-	var ink, paper, mask byte
-	ink = attr & 0x07
-	if (attr & 0x40) != 0 {
-		ink |= 0x08
-	}
-	paper = (attr >> 3) & 0x07
-	if (attr & 0x40) != 0 {
-		paper |= 0x08
-	}
-	if (attr&0x80) != 0 && tv.flash {
-		ink, paper = paper, ink
-	}
-	mask = 0x80
-	for x := sx; x < sx+8; x++ {
-		set := (data & mask) != 0
-		if set {
-			tv.screen.SetPixelIndex(x, y, int(ink))
-		} else {
-			tv.screen.SetPixelIndex(x, y, int(paper))
-		}
-		mask >>= 1
-	}
-}
-
 // Screen : accurate emulation
 
 // DoScanlines refresh TV scanlines
@@ -216,11 +249,11 @@ func (tv *TvVideo) DoScanlines() {
 	// Horizontal : 128 Ts screen, 24 Ts border right, 48 Ts retrace, 24 TS border left
 	// First screen (0,0) pixel Tstate = 14336 TS = 64 Scanlines * 224 Tstates
 	view := tv.screen.View()
-	border := tv.screen.GetColour(int(tv.border))
+	border := tv.borderColour()
 	tstate := tv.tstate
 	endtstate := tv.clock.Tstates()
-	limitBottom := view.Y*tvLineTstates - tvHBorderTstates
-	limitTop := (view.Y+view.H)*tvLineTstates - tvHBorderTstates
+	limitBottom := view.Y*tv.line - tvHBorderTstates - tv.offset
+	limitTop := (view.Y+view.H)*tv.line - tvHBorderTstates - tv.offset
 	if endtstate < limitBottom || tstate > limitTop {
 		return
 	}
@@ -231,8 +264,8 @@ func (tv *TvVideo) DoScanlines() {
 		endtstate = limitTop
 	}
 	tv.tstate = endtstate
-	x, y := tvTstateToXY(tstate)
-	endX, endY := tvTstateToXY(endtstate)
+	x, y := tv.tstateToXY(tstate)
+	endX, endY := tv.tstateToXY(endtstate)
 	for y <= endY {
 		// horizontal 448 px : 48 border left + 256 screen/border  + 48 border right + 96 sync
 		var hBorder, vBorder bool
@@ -271,64 +304,82 @@ func (tv *TvVideo) DoScanlines() {
 	}
 }
 
+// scanlineScreen paints the screen pixels x1 to x2 of scanline y. Each
+// screen byte is expanded to 16 hi-res pixels.
 func (tv *TvVideo) scanlineScreen(y, x1, x2 int) {
-	var attr, data, ink, paper, mask byte
+	var pixels uint16
+	var ink, paper int
 
 	xx := x1 - tvBorderLeft
 	yy := y - tvBorderTop
 	scrAddr := (yy & 0xc0 << 5) | ((yy & 0x38) << 2) | ((yy & 0x07) << 8) | (xx >> 3)
 	attrAddr := tvAttrAddr + ((yy & 0xf8) << 2) + (xx >> 3)
-	bit := byte(xx) & 0x07
-	mask = 1 << (7 - bit)
+	bit := uint(xx) & 0x07
 	readmem := true
 	for x := x1; x <= x2; x++ {
 		if readmem {
 			readmem = false
 			// read memory data & attr
-			data = tv.srcdata[scrAddr]
-			attr = tv.srcdata[attrAddr]
+			switch {
+			case tv.mode&scldHiRes != 0:
+				pixels = uint16(tv.srcdata[scrAddr])<<8 | uint16(tv.hidata[scrAddr])
+				ink, paper = tv.hiResColours()
+			case tv.mode&scldHiColour != 0:
+				pixels = tvDoubleBits[tv.srcdata[scrAddr]]
+				ink, paper = tv.attrColours(tv.hidata[scrAddr])
+			case tv.mode&scldScreen1 != 0:
+				pixels = tvDoubleBits[tv.hidata[scrAddr]]
+				ink, paper = tv.attrColours(tv.hidata[attrAddr])
+			default:
+				pixels = tvDoubleBits[tv.srcdata[scrAddr]]
+				ink, paper = tv.attrColours(tv.srcdata[attrAddr])
+			}
 			scrAddr++
 			attrAddr++
-			// calculate ink + paper
-			ink = attr & 0x07
-			if (attr & 0x40) != 0 {
-				ink |= 0x08
-			}
-			paper = (attr >> 3) & 0x07
-			if (attr & 0x40) != 0 {
-				paper |= 0x08
-			}
-			if attr&0x80 != 0 && tv.flash {
-				ink, paper = paper, ink
-			}
 		}
 		// paint pixel
-		set := (data & mask) != 0
-		if set {
-			tv.screen.SetPixelIndex(x, y, int(ink))
-		} else {
-			tv.screen.SetPixelIndex(x, y, int(paper))
+		sx := x * tv.scale
+		for s := uint(0); s < uint(tv.scale); s++ {
+			if pixels&(0x8000>>(bit*2+s)) != 0 {
+				tv.screen.SetPixelIndex(sx, y, ink)
+			} else {
+				tv.screen.SetPixelIndex(sx, y, paper)
+			}
+			sx++
 		}
 		// next pixel
 		bit++
-		mask >>= 1
 		if bit == 8 {
 			bit = 0
-			mask = 1 << 7 // 0x80
 			readmem = true
 		}
 	}
 }
 
+// attrColours the ink and paper colours of an attribute
+func (tv *TvVideo) attrColours(attr byte) (int, int) {
+	ink := int(attr & 0x07)
+	paper := int(attr>>3) & 0x07
+	if (attr & 0x40) != 0 {
+		ink |= 0x08
+		paper |= 0x08
+	}
+	if attr&0x80 != 0 && tv.flash {
+		ink, paper = paper, ink
+	}
+	return ink, paper
+}
+
 func (tv *TvVideo) scanlineBorder(y, x1, x2 int, colour uint32) {
-	for x := x1; x <= x2; x++ {
+	for x := x1 * tv.scale; x < (x2+1)*tv.scale; x++ {
 		tv.screen.SetPixel(x, y, colour)
 	}
 }
 
-func tvTstateToXY(tstate int) (int, int) {
-	tstate = tstate + tvHBorderTstates
-	y := tstate / tvLineTstates
-	x := tstate % tvLineTstates * tvTstatePixels
+// tstateToXY converts a frame tstate to screen coordinates
+func (tv *TvVideo) tstateToXY(tstate int) (int, int) {
+	tstate = tstate + tv.offset + tvHBorderTstates
+	y := tstate / tv.line
+	x := tstate % tv.line * tvTstatePixels
 	return x, y
 }
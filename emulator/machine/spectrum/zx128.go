@@ -0,0 +1,212 @@
+package spectrum
+
+import (
+	"github.com/jtruco/emu8/emulator/device/bus"
+)
+
+// -----------------------------------------------------------------------------
+// ZX Spectrum 128K - Memory paging
+// -----------------------------------------------------------------------------
+
+// 128K memory constants
+const (
+	zxROMPages     = 4 // ROM pages
+	zxROM128       = 0 // 128K editor ROM
+	zxROM48        = 1 // 48K BASIC ROM
+	zxROMService   = 2 // Scorpion service ROM
+	zxROMTRDOS     = 3 // TR-DOS ROM (Beta 128 disk interface)
+	zxScreenPage   = 5 // Normal screen RAM page
+	zxShadowPage   = 7 // Shadow screen RAM page
+	zxPagingLocked = 0x20
+)
+
+// zx128RomSlots ROM slot names of the ROM pages
+var zx128RomSlots = [zxROMPages]string{"rom0", "rom1", "rom2", "trdos"}
+
+// Paging is the 128K memory paging. The 0x7ffd port selects the RAM page at
+// 0xc000 (bits 0-2), the screen (bit 3), the ROM (bit 4) and locks the
+// paging (bit 5). The Scorpion 0x1ffd port maps RAM page 0 at 0x0000 (bit
+// 0), the service ROM (bit 1) and the upper 128K RAM (bit 4).
+type Paging struct {
+	spectrum *Spectrum // The spectrum machine
+	maps     bus.Maps  // ROM and RAM page maps
+	pages    int       // Number of RAM pages
+	port7ffd byte      // The 0x7ffd port
+	port1ffd byte      // The 0x1ffd port (Scorpion)
+	trdos    bool      // TR-DOS ROM paged in
+}
+
+// NewPaging creates the 128K memory paging of pages RAM pages
+func NewPaging(spectrum *Spectrum, pages int) *Paging {
+	paging := new(Paging)
+	paging.spectrum = spectrum
+	paging.pages = pages
+	return paging
+}
+
+// Reset resets the paging ports
+func (paging *Paging) Reset() {
+	paging.port7ffd = 0
+	paging.port1ffd = 0
+	paging.trdos = false
+	paging.updateScreen()
+}
+
+// Mapper
+
+// Init inits the mapper
+func (paging *Paging) Init(maps bus.Maps) { paging.maps = maps }
+
+// Select selects the map at address for read access
+func (paging *Paging) Select(address uint16) (*bus.Map, uint16) {
+	rel := address & 0x3fff
+	switch address >> 14 {
+	case 0:
+		if paging.port1ffd&0x01 != 0 {
+			return paging.ram(0), rel
+		}
+		return paging.maps[paging.ROM()], rel
+	case 1:
+		return paging.ram(zxScreenPage), rel
+	case 2:
+		return paging.ram(2), rel
+	default:
+		return paging.ram(paging.Page()), rel
+	}
+}
+
+// SelectWrite selects the map at address for write access
+func (paging *Paging) SelectWrite(address uint16) (*bus.Map, uint16) {
+	return paging.Select(address)
+}
+
+// ram returns the map of RAM page
+func (paging *Paging) ram(page int) *bus.Map {
+	return paging.maps[zxROMPages+page%paging.pages]
+}
+
+// Paging state
+
+// ROM returns the ROM page mapped at 0x0000
+func (paging *Paging) ROM() int {
+	switch {
+	case paging.trdos:
+		return zxROMTRDOS
+	case paging.port1ffd&0x02 != 0:
+		return zxROMService
+	case paging.port7ffd&0x10 != 0:
+		return zxROM48
+	default:
+		return zxROM128
+	}
+}
+
+// Page returns the RAM page mapped at 0xc000
+func (paging *Paging) Page() int {
+	return int(paging.port7ffd&0x07 | (paging.port1ffd&0x10)>>1)
+}
+
+// IsROM48 checks if the 48K BASIC ROM is mapped
+func (paging *Paging) IsROM48() bool {
+	return paging.port1ffd&0x01 == 0 && paging.ROM() == zxROM48
+}
+
+// SetTRDOS pages in or out the TR-DOS ROM
+func (paging *Paging) SetTRDOS(trdos bool) { paging.trdos = trdos }
+
+// Set48K sets the 48K mode : 48K BASIC ROM, RAM page 0 and paging locked
+func (paging *Paging) Set48K() {
+	paging.port1ffd = 0
+	paging.trdos = false
+	paging.Write7ffd(zxPagingLocked | 0x10)
+}
+
+// Ports
+
+// Write7ffd writes the 0x7ffd port, ignored if paging is locked
+func (paging *Paging) Write7ffd(data byte) {
+	if paging.port7ffd&zxPagingLocked != 0 {
+		return
+	}
+	screen := paging.port7ffd ^ data
+	paging.port7ffd = data
+	if screen&0x08 != 0 {
+		paging.updateScreen()
+	}
+}
+
+// Write1ffd writes the 0x1ffd port (Scorpion)
+func (paging *Paging) Write1ffd(data byte) {
+	paging.port1ffd = data
+}
+
+// updateScreen sets the screen RAM page of the video output
+func (paging *Paging) updateScreen() {
+	page := zxScreenPage
+	if paging.port7ffd&0x08 != 0 {
+		page = zxShadowPage
+	}
+	tv := paging.spectrum.tv
+	if tv != nil {
+		if tv.accurate {
+			tv.DoScanlines()
+		}
+		tv.SetSource(paging.spectrum.memory.Bank(zxROMPages + page).Data())
+	}
+}
+
+// onScreenPostAccess on write in the screen RAM pages
+func (paging *Paging) onScreenPostAccess(page int) bus.Callback {
+	return func(code int, address uint16) {
+		shadow := paging.port7ffd&0x08 != 0
+		if (page == zxShadowPage) == shadow {
+			paging.spectrum.tv.onVideoPostAccess(code, address)
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// ZX Spectrum 128K - IO ports
+// -----------------------------------------------------------------------------
+
+// read128 reads the 128K ports : the AY register (0xfffd)
+func (spectrum *Spectrum) read128(address uint16) byte {
+	if address&0xc002 == 0xc000 {
+		return spectrum.psg.ReadRegister(spectrum.psg.Selected())
+	}
+	return 0xff
+}
+
+// write128 writes the 128K ports : the paging ports and the AY register
+// select (0xfffd) and data (0xbffd) ports
+func (spectrum *Spectrum) write128(address uint16, data byte) {
+	switch {
+	case address&0xc002 == 0xc000:
+		spectrum.psg.SelectRegister(data & 0x0f)
+	case address&0xc002 == 0x8000:
+		spectrum.emulatePSG()
+		spectrum.psg.WriteRegister(spectrum.psg.Selected(), data)
+	case spectrum.config.Model == Scorpion256:
+		if address&0xf002 == 0x1000 {
+			spectrum.paging.Write1ffd(data)
+		} else if address&0xc002 == 0x4000 {
+			spectrum.paging.Write7ffd(data)
+		}
+	case address&0x8002 == 0:
+		spectrum.paging.Write7ffd(data)
+	}
+}
+
+// emulatePSG emulates the AY sound chip up to the current tstate. The AY
+// is clocked at half the CPU clock.
+func (spectrum *Spectrum) emulatePSG() {
+	tstates := spectrum.clock.Tstates()
+	if tstates > spectrum.timing.frame {
+		tstates = spectrum.timing.frame
+	}
+	cycles := tstates>>1 - spectrum.psgCycles
+	if cycles > 0 {
+		spectrum.psg.Emulate(cycles)
+		spectrum.psgCycles += cycles
+	}
+}
@@ -0,0 +1,182 @@
+// Package format contains the ZX81 and ZX80 file formats
+package format
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/jtruco/emu8/emulator/device/io/tape"
+)
+
+// -----------------------------------------------------------------------------
+// ZX81 P & ZX80 O program formats
+// -----------------------------------------------------------------------------
+
+// Program formats extensions
+const (
+	P   = "p"  // ZX81 program
+	P81 = "81" // ZX81 program (alternative extension)
+	O   = "o"  // ZX80 program
+	O80 = "80" // ZX80 program (alternative extension)
+)
+
+// Program files load addresses
+const (
+	PAddress = 0x4009 // ZX81 programs start at VERSN system variable
+	OAddress = 0x4000 // ZX80 programs start at the system variables
+)
+
+// Tape signal states
+const (
+	progStateStart = iota
+	progStateByte
+	progStatePulseHigh
+	progStatePulseLow
+	progStateGap
+	progStateStop
+)
+
+// Tape signal timings (tstates at 3.25 MHz)
+const (
+	progTimingLeader = 1625000 // 500 ms leader silence
+	progTimingPulse  = 488     // 150 us pulse level
+	progTimingGap    = 4225    // 1300 us silence after each bit
+	progPulsesZero   = 4       // Pulses of a zero bit
+	progPulsesOne    = 9       // Pulses of a one bit
+)
+
+// progDefaultName the file name written before ZX81 programs : "A" with
+// the end of name mark. LOAD "" loads any name.
+var progDefaultName = []byte{0xa6}
+
+// ProgramBlock is the program data block
+type ProgramBlock struct {
+	tape.BlockInfo
+	data []byte
+	name string
+}
+
+// Info gets block information
+func (block *ProgramBlock) Info() *tape.BlockInfo { return &block.BlockInfo }
+
+// Data gets block data bytes
+func (block *ProgramBlock) Data() []byte { return block.data }
+
+// Meta gets the decoded block description
+func (block *ProgramBlock) Meta() *tape.BlockMeta {
+	return &tape.BlockMeta{
+		Description: fmt.Sprintf("%s program: %d bytes", block.name, block.Length)}
+}
+
+// Program is a ZX81 or ZX80 program tape. The program is played as the
+// tape signal of the ROM SAVE routine : each bit is a train of pulses (4
+// for zero, 9 for one) followed by a silence.
+type Program struct {
+	info    tape.Info    // Tape information
+	blocks  []tape.Block // The program block
+	zx80    bool         // ZX80 program (without name)
+	bitMask byte         // Current bit mask
+	pulses  int          // Pending pulses of current bit
+}
+
+// NewP creates a ZX81 program tape
+func NewP() tape.Tape { return newProgram(false) }
+
+// NewO creates a ZX80 program tape
+func NewO() tape.Tape { return newProgram(true) }
+
+func newProgram(zx80 bool) *Program {
+	prog := new(Program)
+	prog.zx80 = zx80
+	prog.blocks = make([]tape.Block, 0, 1)
+	return prog
+}
+
+// Info gets tape information
+func (prog *Program) Info() *tape.Info { return &prog.info }
+
+// Blocks gets the tape blocks
+func (prog *Program) Blocks() []tape.Block { return prog.blocks }
+
+// Load loads the program file data
+func (prog *Program) Load(data []byte) bool {
+	if len(data) == 0 {
+		log.Print("Tape (P) : Invalid format: 0-length data")
+		return false
+	}
+	block := new(ProgramBlock)
+	if prog.zx80 {
+		block.name = "ZX80"
+		block.data = data
+	} else {
+		block.name = "ZX81"
+		block.data = append(append([]byte{}, progDefaultName...), data...)
+	}
+	block.Length = len(block.data)
+	prog.blocks = append(prog.blocks, block)
+	return true
+}
+
+// Play plays the program tape signal
+func (prog *Program) Play(control *tape.Control) {
+	switch control.State {
+
+	case progStateStart:
+		control.Block = prog.blocks[control.BlockIndex]
+		control.BlockPos = 0
+		control.Ear = tape.LevelLow
+		control.Timeout = progTimingLeader
+		control.State = progStateByte
+		log.Println("Tape (P) : Program block:", control.Block.Info().Length, "bytes")
+
+	case progStateByte:
+		if control.EndOfBlock() {
+			control.BlockIndex++
+			control.State = progStateStop
+			break
+		}
+		prog.bitMask = 0x80
+		prog.startBit(control)
+
+	case progStatePulseHigh:
+		control.Ear = tape.LevelHigh
+		control.Timeout = progTimingPulse
+		control.State = progStatePulseLow
+
+	case progStatePulseLow:
+		control.Ear = tape.LevelLow
+		control.Timeout = progTimingPulse
+		prog.pulses--
+		if prog.pulses == 0 {
+			control.State = progStateGap
+		} else {
+			control.State = progStatePulseHigh
+		}
+
+	case progStateGap:
+		control.Timeout = progTimingGap
+		prog.bitMask >>= 1
+		if prog.bitMask == 0 {
+			control.BlockPos++
+			control.State = progStateByte
+		} else {
+			prog.startBit(control)
+		}
+
+	case progStateStop:
+		control.Playing = false // Stop
+
+	default:
+		control.State = progStateStop
+	}
+}
+
+// startBit starts the pulses of the current bit
+func (prog *Program) startBit(control *tape.Control) {
+	if control.DataAtPos()&prog.bitMask == 0 {
+		prog.pulses = progPulsesZero
+	} else {
+		prog.pulses = progPulsesOne
+	}
+	control.State = progStatePulseHigh
+}
@@ -0,0 +1,38 @@
+package zx81
+
+import "github.com/jtruco/emu8/emulator/machine"
+
+// Sinclair ZX81 models
+var models = []machine.Model{
+	{Name: "Sinclair ZX81", Ids: []string{"SinclairZX81", "ZX81"},
+		Build: func() machine.Machine { return New(SinclairZX81) }, Roms: zx81RomSet},
+	{Name: "Sinclair ZX80", Ids: []string{"SinclairZX80", "ZX80"},
+		Build: func() machine.Machine { return New(SinclairZX80) }, Roms: zx80RomSet},
+}
+
+// ZX81 ROM set. There are three editions of the ZX81 ROM, the second
+// edition is the default.
+var zx81RomSet = &machine.RomSet{
+	Slots: []machine.RomSlot{
+		{Name: "rom", Size: zxROMSize81, Default: "zx81"},
+	},
+	Images: []machine.RomImage{
+		{ID: "zx81", File: "zx81.rom", CRC32: 0x4b1dd6eb},
+		{ID: "zx81_ed1", File: "zx81_ed1.rom", CRC32: 0xfcbbd617},
+		{ID: "zx81_ed3", File: "zx81_ed3.rom", CRC32: 0x522c37b8},
+	},
+}
+
+// ZX80 ROM set
+var zx80RomSet = &machine.RomSet{
+	Slots: []machine.RomSlot{
+		{Name: "rom", Size: zxROMSize80, Default: "zx80"},
+	},
+	Images: []machine.RomImage{
+		{ID: "zx80", File: "zx80.rom", CRC32: 0x4c7fc597},
+	},
+}
+
+func init() {
+	machine.RegisterModels(models)
+}
@@ -0,0 +1,180 @@
+package zx81
+
+// -----------------------------------------------------------------------------
+// ZX81 ULA
+// -----------------------------------------------------------------------------
+
+// The CPU generates the display : the ROM jumps to the display file echo
+// in the upper 32K. On each opcode fetch with A15 set and D6 reset, the ULA
+// latches the character code and puts a NOP on the data bus. During the
+// refresh cycle it reads the character pattern, addressed by the I register,
+// the character code and the line counter. The NEWLINE character (HALT)
+// ends the line, and the interrupt is requested when A6 goes low during the
+// refresh cycle. The ZX81 NMI generator counts the margin lines.
+
+// ULA constants
+const (
+	ulaLineTStates = 207  // TStates per line (64 us)
+	ulaIORead      = 0xff // Default IO read value
+	ula50Hz        = 0x40 // Keyboard port bit 6 : 50 Hz display
+	ulaTapeIn      = 0x80 // Keyboard port bit 7 : tape input
+)
+
+// ULA is the ZX81 ULA, also the ZX80 discrete video logic
+type ULA struct {
+	zx        *ZX81 // The ZX81 machine
+	nmiOn     bool  // NMI generator enabled (ZX81 only)
+	vsync     bool  // Vertical sync active
+	lineCount byte  // Line counter (3 bit)
+	lineStart int64 // Clock tstates at the start of the line
+	char      byte  // Display character latched on opcode fetch
+	latched   bool  // Display character latched
+}
+
+// NewULA creates the ULA
+func NewULA(zx *ZX81) *ULA {
+	ula := new(ULA)
+	ula.zx = zx
+	return ula
+}
+
+// connect connects the ULA to the CPU lines
+func (ula *ULA) connect() {
+	ula.zx.cpu.OnFetch = ula.onFetch
+	ula.zx.cpu.OnRefresh = ula.onRefresh
+	ula.zx.cpu.OnIntAck = ula.onInterruptAck
+}
+
+// Device
+
+// Init initializes the ULA
+func (ula *ULA) Init() { ula.Reset() }
+
+// Reset resets the ULA
+func (ula *ULA) Reset() {
+	ula.nmiOn = false
+	ula.vsync = false
+	ula.lineCount = 0
+	ula.lineStart = ula.zx.clock.Total()
+	ula.latched = false
+}
+
+// Sync generators
+
+// Sync generates the horizontal syncs up to the current clock
+func (ula *ULA) Sync() {
+	total := ula.zx.clock.Total()
+	if total < ula.lineStart { // clock reset
+		ula.lineStart = total
+	}
+	for total-ula.lineStart >= ulaLineTStates {
+		ula.lineStart += ulaLineTStates
+		ula.hsync()
+	}
+}
+
+// lineTStates returns the tstates since the start of the line
+func (ula *ULA) lineTStates() int {
+	return int(ula.zx.clock.Total() - ula.lineStart)
+}
+
+// hsync a new line : increments the line counter, and triggers the NMI
+// when the generator is enabled
+func (ula *ULA) hsync() {
+	if !ula.vsync {
+		ula.lineCount = (ula.lineCount + 1) & 0x07
+	}
+	if ula.nmiOn {
+		ula.zx.cpu.NMInterruptRequest(true)
+	}
+	ula.zx.tv.OnHSync()
+}
+
+// setVSync starts or ends the vertical sync. The line counter is reset.
+func (ula *ULA) setVSync(vsync bool) {
+	if vsync != ula.vsync {
+		ula.vsync = vsync
+		if !vsync {
+			ula.zx.tv.OnVSync()
+		}
+	}
+	ula.lineCount = 0
+}
+
+// CPU lines
+
+// onFetch latches the display characters executed in the upper memory
+func (ula *ULA) onFetch(address uint16, opcode byte) byte {
+	if address&0x8000 == 0 || opcode&0x40 != 0 || ula.zx.cpu.Halted {
+		return opcode
+	}
+	ula.char = opcode
+	ula.latched = true
+	return 0x00 // NOP
+}
+
+// onRefresh reads the pattern of the latched character and requests the
+// interrupt while A6 is low
+func (ula *ULA) onRefresh(address uint16) {
+	if ula.latched {
+		ula.latched = false
+		ula.Sync()
+		pattern := ula.zx.memory.Read(address&0xfe00 | uint16(ula.char&0x3f)<<3 | uint16(ula.lineCount))
+		if ula.char&0x80 != 0 {
+			pattern ^= 0xff
+		}
+		ula.zx.tv.PaintChar(ula.lineTStates(), pattern)
+	}
+	ula.zx.cpu.InterruptRequest(address&0x0040 == 0)
+}
+
+// onInterruptAck the interrupt acknowledge starts a new line. The NMI is
+// acknowledged by the NMI generator.
+func (ula *ULA) onInterruptAck() bool {
+	if !ula.zx.cpu.NmiRq {
+		ula.Sync()
+		ula.lineStart = ula.zx.clock.Total()
+		ula.hsync()
+	}
+	return false
+}
+
+// DataBus
+
+// Read reads the IO ports : the keyboard port (A0 reset) starts the
+// vertical sync when the NMI generator is off
+func (ula *ULA) Read(address uint16) byte {
+	ula.zx.clock.Add(4)
+	result := byte(ulaIORead)
+	if address&0x0001 == 0 {
+		ula.Sync()
+		if !ula.nmiOn {
+			ula.setVSync(true)
+		}
+		result = ula.zx.keyboard.GetState(byte(address>>8)^0xff) | ulaTapeIn | ula50Hz
+		if ula.zx.hz60 {
+			result &^= ula50Hz
+		}
+		ula.zx.tape.EarRead()
+		if !ula.zx.tape.IsPlaying() || !ula.zx.tape.EarHigh() {
+			result &^= ulaTapeIn
+		}
+	}
+	return result
+}
+
+// Write writes the IO ports : any write ends the vertical sync, ports 0xfe
+// and 0xfd enable and disable the ZX81 NMI generator
+func (ula *ULA) Write(address uint16, data byte) {
+	ula.zx.clock.Add(4)
+	ula.Sync()
+	if !ula.zx.IsZX80() {
+		switch byte(address) {
+		case 0xfe:
+			ula.nmiOn = true
+		case 0xfd:
+			ula.nmiOn = false
+		}
+	}
+	ula.setVSync(false)
+}
@@ -0,0 +1,124 @@
+package zx81
+
+import (
+	"github.com/jtruco/emu8/emulator/device/video"
+)
+
+// -----------------------------------------------------------------------------
+// Video constants & vars
+// -----------------------------------------------------------------------------
+
+// Video screen constants
+const (
+	tvScreenWidth  = 256
+	tvScreenHeight = 192
+	tvBorderLeft   = 32
+	tvBorderTop    = 24
+	tvTotalWidth   = tvScreenWidth + 2*tvBorderLeft
+	tvTotalHeight  = tvScreenHeight + 2*tvBorderTop
+	tvFirstLine50  = 56 // First display line after the vertical sync (50 Hz)
+	tvFirstLine60  = 32 // First display line after the vertical sync (60 Hz)
+	tvFirstChar    = 74 // Line tstate of the first character refresh
+	tvTstatePixels = 2
+)
+
+// TV colours : white & black
+const (
+	tvPaper = 0
+	tvInk   = 1
+)
+
+// ZX81 RGBA colour palette
+var zxPaletteRGBA = []uint32{0xffffffff, 0xff000000}
+
+// -----------------------------------------------------------------------------
+// ZX81 TV video output
+// -----------------------------------------------------------------------------
+
+// TvVideo is the ZX81 TV video output. Lines are painted as the ULA shifts
+// out the character patterns.
+type TvVideo struct {
+	screen *video.Screen // The video screen
+	line   int           // Current line since the vertical sync
+	first  int           // Line of the screen top
+	vsyncs int           // Vertical syncs in the current frame
+}
+
+// NewTVVideo creates the video device
+func NewTVVideo(zx *ZX81) *TvVideo {
+	tv := new(TvVideo)
+	tv.screen = video.NewScreen(tvTotalWidth, tvTotalHeight, zxPaletteRGBA)
+	tv.first = tvFirstLine50 - tvBorderTop
+	if zx.hz60 {
+		tv.first = tvFirstLine60 - tvBorderTop
+	}
+	return tv
+}
+
+// Device
+
+// Init initializes video device
+func (tv *TvVideo) Init() { tv.Reset() }
+
+// Reset resets video device
+func (tv *TvVideo) Reset() {
+	tv.screen.Clear(tvPaper)
+	tv.line = 0
+	tv.vsyncs = 0
+}
+
+// Video
+
+// EndFrame updates screen video frame. Without vertical syncs (FAST mode)
+// the TV shows no picture.
+func (tv *TvVideo) EndFrame() {
+	if tv.vsyncs == 0 {
+		tv.screen.Clear(tvInk)
+	}
+	tv.vsyncs = 0
+}
+
+// Screen the video screen
+func (tv *TvVideo) Screen() *video.Screen { return tv.screen }
+
+// Sync & painting
+
+// OnHSync starts a new line, cleared to paper colour
+func (tv *TvVideo) OnHSync() {
+	tv.line++
+	tv.clearLine()
+}
+
+// OnVSync starts a new TV frame
+func (tv *TvVideo) OnVSync() {
+	tv.line = 0
+	tv.vsyncs++
+}
+
+// PaintChar paints a character pattern refreshed at a line tstate
+func (tv *TvVideo) PaintChar(tstate int, pattern byte) {
+	y := tv.line - tv.first
+	x := (tstate-tvFirstChar)*tvTstatePixels + tvBorderLeft
+	if y < 0 || y >= tvTotalHeight || x < 0 || x+8 > tvTotalWidth {
+		return
+	}
+	for mask := byte(0x80); mask != 0; mask >>= 1 {
+		if pattern&mask != 0 {
+			tv.screen.SetPixelIndex(x, y, tvInk)
+		} else {
+			tv.screen.SetPixelIndex(x, y, tvPaper)
+		}
+		x++
+	}
+}
+
+// clearLine clears the current line
+func (tv *TvVideo) clearLine() {
+	y := tv.line - tv.first
+	if y < 0 || y >= tvTotalHeight {
+		return
+	}
+	for x := 0; x < tvTotalWidth; x++ {
+		tv.screen.SetPixelIndex(x, y, tvPaper)
+	}
+}
@@ -0,0 +1,252 @@
+// Package zx81 implements the Sinclair ZX81 and ZX80 machines
+package zx81
+
+import (
+	"log"
+	"strings"
+
+	"github.com/jtruco/emu8/emulator/config"
+	"github.com/jtruco/emu8/emulator/device"
+	"github.com/jtruco/emu8/emulator/device/bus"
+	"github.com/jtruco/emu8/emulator/device/cpu"
+	"github.com/jtruco/emu8/emulator/device/cpu/z80"
+	"github.com/jtruco/emu8/emulator/device/io/tape"
+	"github.com/jtruco/emu8/emulator/device/memory"
+	"github.com/jtruco/emu8/emulator/machine"
+	"github.com/jtruco/emu8/emulator/machine/zx81/format"
+)
+
+// -----------------------------------------------------------------------------
+// Sinclair ZX81 & ZX80
+// -----------------------------------------------------------------------------
+
+// Sinclair ZX81 models
+const (
+	SinclairZX81 = iota
+	SinclairZX80
+)
+
+// Default ZX81 constants
+const (
+	zxCPUClock    = 3250000           // 3.25 MHz
+	zxTStates50   = zxCPUClock / 50   // TStates per frame at 50 Hz
+	zxTStates60   = zxCPUClock/60 + 1 // TStates per frame at 60 Hz
+	zxRAMSize1K   = memory.Size1K     // Internal RAM
+	zxRAMSize16K  = memory.Size16K    // 16K RAM pack
+	zxROMSize81   = memory.Size8K     // ZX81 ROM
+	zxROMSize80   = memory.Size4K     // ZX80 ROM
+	zxELineZX81   = 0x4014            // E_LINE system variable (ZX81)
+	zxELineZX80   = 0x400a            // E_LINE system variable (ZX80)
+	zxMemoryROM   = 0                 // ROM memory map
+	zxMemoryRAM   = 1                 // RAM memory map
+	zxMemoryBanks = 2
+)
+
+// ZX81 is the Sinclair ZX81 or ZX80
+type ZX81 struct {
+	config     machine.Config      // Machine information
+	control    machine.Control     // The emulator controller
+	components *device.Components  // Machine device components
+	clock      *device.ClockDevice // The system clock
+	cpu        *z80.Z80            // The Zilog Z80A CPU
+	memory     *memory.Memory      // The machine memory
+	ula        *ULA                // The ZX81 ULA (ZX80 discrete logic)
+	tv         *TvVideo            // The TV video output
+	keyboard   *Keyboard           // The keyboard
+	tape       *tape.Drive         // The tape drive
+	hz60       bool                // 60 Hz display (USA)
+}
+
+// New returns a new ZX81
+func New(model int) machine.Machine {
+	zx := new(ZX81)
+	zx.config.Model = model
+	options := &config.Get().Machine
+	switch hz := options.Option("hz"); hz {
+	case "", "50":
+	case "60":
+		zx.hz60 = true
+	default:
+		log.Println("ZX81 : Unknown display frequency:", hz)
+	}
+	if zx.hz60 {
+		zx.config.SetTimings(zxTStates60, 60)
+	} else {
+		zx.config.SetTimings(zxTStates50, 50)
+	}
+	// memory map
+	ramSize := uint16(zxRAMSize16K)
+	switch ram := strings.ToLower(options.Option("ram")); ram {
+	case "", "16k":
+	case "1k":
+		ramSize = zxRAMSize1K
+	default:
+		log.Println("ZX81 : Unknown RAM size:", ram)
+	}
+	romSize := uint16(zxROMSize81)
+	if zx.IsZX80() {
+		romSize = zxROMSize80
+	}
+	zx.memory = memory.New(zxMemoryBanks)
+	zx.memory.SetMap(zxMemoryROM, memory.NewROM(0x0000, romSize))
+	zx.memory.SetMap(zxMemoryRAM, memory.NewRAM(0x4000, ramSize))
+	zx.memory.SetMapper(new(memoryMapper))
+	// devices
+	zx.clock = device.NewClock()
+	zx.ula = NewULA(zx)
+	zx.cpu = z80.New(zx.clock, zx.memory, zx.ula)
+	zx.ula.connect()
+	zx.tv = NewTVVideo(zx)
+	zx.keyboard = NewKeyboard()
+	zx.tape = tape.New(zx.clock)
+	zx.tape.SetAutoPlay(config.Get().Tape.AutoStart)
+	// register all components
+	zx.components = device.NewComponents()
+	zx.components.Add(zx.clock)
+	zx.components.Add(zx.memory)
+	zx.components.Add(zx.ula)
+	zx.components.Add(zx.cpu)
+	zx.components.Add(zx.tv)
+	zx.components.Add(zx.keyboard)
+	zx.components.Add(zx.tape)
+	return zx
+}
+
+// IsZX80 checks if the machine is a ZX80
+func (zx *ZX81) IsZX80() bool { return zx.config.Model == SinclairZX80 }
+
+// Device interface
+
+// Init initializes the machine
+func (zx *ZX81) Init() {
+	zx.components.Init()
+	zx.initZX81()
+}
+
+// Reset resets the machine
+func (zx *ZX81) Reset() {
+	zx.components.Reset()
+	zx.initZX81()
+}
+
+// initZX81 common init tasks
+func (zx *ZX81) initZX81() {
+	set := zx81RomSet
+	if zx.IsZX80() {
+		set = zx80RomSet
+	}
+	data, err := set.Load(zx.control, "rom")
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+	zx.memory.Bank(zxMemoryROM).Load(0, data)
+}
+
+// Machine properties
+
+// Clock gets the machine clock
+func (zx *ZX81) Clock() device.Clock { return zx.clock }
+
+// Config gets the machine info
+func (zx *ZX81) Config() *machine.Config { return &zx.config }
+
+// CPU gets the machine CPU
+func (zx *ZX81) CPU() cpu.CPU { return zx.cpu }
+
+// Components gets the machine components
+func (zx *ZX81) Components() *device.Components { return zx.components }
+
+// InitControl connect controllers & components
+func (zx *ZX81) InitControl(control machine.Control) {
+	// Bind devices
+	control.BindVideo(zx.tv)
+	control.BindKeyboard(zx.keyboard)
+	control.BindTapeDrive(zx.tape)
+	// Register formats
+	if zx.IsZX80() {
+		control.RegisterTape(format.O, format.NewO)
+		control.RegisterTape(format.O80, format.NewO)
+	} else {
+		control.RegisterTape(format.P, format.NewP)
+		control.RegisterTape(format.P81, format.NewP)
+	}
+	zx.control = control
+}
+
+// Emulation control
+
+// BeginFrame begin emulation frame tasks
+func (zx *ZX81) BeginFrame() {} // nothing to do
+
+// Emulate one machine step
+func (zx *ZX81) Emulate() {
+	// Executes a CPU instruction
+	tstates := zx.cpu.Execute()
+	// ULA sync generators
+	zx.ula.Sync()
+	// Tape emulation
+	zx.tape.Emulate(tstates)
+}
+
+// EndFrame end emulation frame tasks
+func (zx *ZX81) EndFrame() {} // nothing to do
+
+// Snapshots : load & save state
+
+// LoadState loads a snapshot. Programs are loaded from tape.
+func (zx *ZX81) LoadState(state machine.State) {
+	log.Println("ZX81 : Not implemented snap format:", state.Format)
+}
+
+// SaveState saves the program in memory as a P (ZX81) or O (ZX80) file
+func (zx *ZX81) SaveState() machine.State {
+	start, eline, ext := uint16(format.PAddress), uint16(zxELineZX81), format.P
+	if zx.IsZX80() {
+		start, eline, ext = format.OAddress, zxELineZX80, format.O
+	}
+	end := uint16(zx.memory.Peek(eline)) | uint16(zx.memory.Peek(eline+1))<<8
+	if end <= start {
+		end = start
+	}
+	data := make([]byte, end-start)
+	for i := range data {
+		data[i] = zx.memory.Peek(start + uint16(i))
+	}
+	return machine.State{Format: ext, Data: data}
+}
+
+// -----------------------------------------------------------------------------
+// ZX81 - Memory mapping
+// -----------------------------------------------------------------------------
+
+// memoryMapper maps the ZX81 memory. The ROM is mirrored up to 0x3fff and
+// the RAM up to 0x7fff. The upper 32K mirrors the lower 32K, the display
+// file is executed there.
+type memoryMapper struct {
+	rom     *bus.Map // ROM map
+	ram     *bus.Map // RAM map
+	romMask uint16   // ROM mirror mask
+	ramMask uint16   // RAM mirror mask
+}
+
+// Init inits the mapper
+func (mapper *memoryMapper) Init(maps bus.Maps) {
+	mapper.rom = maps[zxMemoryROM]
+	mapper.ram = maps[zxMemoryRAM]
+	mapper.romMask = mapper.rom.Size() - 1
+	mapper.ramMask = mapper.ram.Size() - 1
+}
+
+// Select selects the map at address for read access
+func (mapper *memoryMapper) Select(address uint16) (*bus.Map, uint16) {
+	if address&0x4000 == 0 {
+		return mapper.rom, address & mapper.romMask
+	}
+	return mapper.ram, address & mapper.ramMask
+}
+
+// SelectWrite selects the map at address for write access
+func (mapper *memoryMapper) SelectWrite(address uint16) (*bus.Map, uint16) {
+	return mapper.Select(address)
+}